@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -13,19 +14,25 @@ import (
 )
 
 const (
-	modeSeed = "seed"
-	modeTest = "test"
+	modeSeed     = "seed"
+	modeTest     = "test"
+	modeSolve    = "solve"
+	modeProfiles = "profiles"
 )
 
 var (
 	envFileFlag         = flag.String("env", "", "Path to .env file, 'stdin' or empty")
-	flagMode            = flag.String("mode", "", strings.Join([]string{modeSeed, modeTest}, " | "))
+	flagMode            = flag.String("mode", "", strings.Join([]string{modeSeed, modeTest, modeSolve, modeProfiles}, " | "))
 	flagUsersCount      = flag.Int("user-count", 100, "number of users to seed")
 	flagOrgsCount       = flag.Int("org-count", 10, "number of orgs to seed")
 	flagPropertiesCount = flag.Int("property-count", 100, "number of properties to seed")
 	flagRatePerSecond   = flag.Int("rps", 100, "Requests per second")
 	flagDuration        = flag.Int("duration", 10, "Duration of the load test (seconds)")
 	flagSitekeyPercent  = flag.Int("sitekey-percent", 100, "Percent of valid sitekey requests")
+	flagConcurrency     = flag.Int("concurrency", 32, "Max parallel puzzle solving iterations (solve mode)")
+	flagInvalidPercent  = flag.Int("invalid-percent", 10, "Percent of invalid solutions (solve mode)")
+	flagReplayPercent   = flag.Int("replay-percent", 10, "Percent of replayed solutions (solve mode)")
+	flagReportPath      = flag.String("report", "", "Path to JSON report file (solve mode)")
 	env                 *common.EnvMap
 )
 
@@ -54,6 +61,17 @@ func main() {
 	case modeTest:
 		err = load((*flagUsersCount)*(*flagOrgsCount)*(*flagPropertiesCount), cfg, *flagRatePerSecond, *flagDuration,
 			*flagSitekeyPercent)
+	case modeSolve:
+		err = solve((*flagUsersCount)*(*flagOrgsCount)*(*flagPropertiesCount), cfg, &solveOptions{
+			rate:           *flagRatePerSecond,
+			duration:       time.Duration(*flagDuration) * time.Second,
+			concurrency:    *flagConcurrency,
+			invalidPercent: *flagInvalidPercent,
+			replayPercent:  *flagReplayPercent,
+			reportPath:     *flagReportPath,
+		})
+	case modeProfiles:
+		err = compareProfiles(*flagRatePerSecond, *flagDuration)
 	default:
 		err = fmt.Errorf("unknown mode: '%s'", *flagMode)
 	}
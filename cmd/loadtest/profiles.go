@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+const (
+	// approximate time of serving a puzzle from the pool
+	profileHandlerLatency = 2 * time.Millisecond
+	// similar to upstream pool of a reverse proxy, otherwise HTTP/1.1 client dials a new connection for every
+	// request that is waiting for TLS handshakes
+	profileMaxConnections = 64
+)
+
+type namedProfile struct {
+	name    string
+	profile config.ServerProfile
+}
+
+type profileResult struct {
+	name        string
+	metrics     vegeta.Metrics
+	connections int64
+}
+
+func serverProfiles() []*namedProfile {
+	http1 := config.DefaultAPIProfile
+	http1.HTTP2 = false
+
+	return []*namedProfile{
+		{name: "default", profile: config.DefaultServerProfile},
+		{name: "api", profile: config.DefaultAPIProfile},
+		{name: "api-http1", profile: http1},
+		{name: "portal", profile: config.DefaultPortalProfile},
+	}
+}
+
+// startProfileServer starts local TLS server with puzzle-like handler, configured the same way as in cmd/server
+func startProfileServer(p config.ServerProfile, connections *atomic.Int64) (*httptest.Server, error) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(profileHandlerLatency)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("puzzle"))
+	})
+
+	ts := httptest.NewUnstartedServer(handler)
+
+	listener, err := p.ListenConfig().Listen(context.TODO(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	ts.Listener.Close()
+	ts.Listener = listener
+
+	if err := p.Configure(ts.Config); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	ts.EnableHTTP2 = p.HTTP2
+	ts.StartTLS()
+
+	return ts, nil
+}
+
+func attackProfile(np *namedProfile, rate vegeta.Rate, duration time.Duration) (*profileResult, error) {
+	result := &profileResult{name: np.name}
+
+	var connections atomic.Int64
+	ts, err := startProfileServer(np.profile, &connections)
+	if err != nil {
+		return nil, err
+	}
+	defer ts.Close()
+
+	targeter := vegeta.NewStaticTargeter(vegeta.Target{Method: http.MethodGet, URL: ts.URL + "/puzzle"})
+	client := ts.Client()
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		transport.MaxIdleConnsPerHost = profileMaxConnections
+		transport.MaxConnsPerHost = profileMaxConnections
+		defer transport.CloseIdleConnections()
+	}
+	attacker := vegeta.NewAttacker(vegeta.Client(client))
+
+	slog.Info("Attacking", "profile", np.name, "duration", duration.String(), "rate", rate.String())
+
+	for res := range attacker.Attack(targeter, rate, duration, np.name) {
+		result.metrics.Add(res)
+	}
+	result.metrics.Close()
+	result.connections = connections.Load()
+
+	return result, nil
+}
+
+// compareProfiles runs the same load against local servers with every server profile and prints a summary
+func compareProfiles(freq int, durationSeconds int) error {
+	rate := vegeta.Rate{Freq: freq, Per: time.Second}
+	duration := time.Duration(durationSeconds) * time.Second
+
+	results := make([]*profileResult, 0)
+	for _, np := range serverProfiles() {
+		result, err := attackProfile(np, rate, duration)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "profile\trequests\tsuccess\tp50\tp95\tp99\tmax\tthroughput\tconnections")
+	for _, r := range results {
+		m := &r.metrics
+		fmt.Fprintf(w, "%s\t%d\t%.2f%%\t%s\t%s\t%s\t%s\t%.1f/s\t%d\n", r.name, m.Requests, m.Success*100,
+			m.Latencies.P50, m.Latencies.P95, m.Latencies.P99, m.Latencies.Max, m.Throughput, r.connections)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	randv2 "math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	scenarioValid   = "valid"
+	scenarioInvalid = "invalid"
+	scenarioReplay  = "replay"
+
+	stepPuzzle = "puzzle"
+	stepSolve  = "solve"
+	stepVerify = "verify"
+	// second submission of the same solution in replay scenario
+	stepReplay = "replay"
+
+	loadtestAPIKeyName = "loadtest"
+	httpClientTimeout  = 30 * time.Second
+)
+
+var (
+	errInvalidRatios     = errors.New("sum of invalid and replay percents cannot exceed 100")
+	errNoOwnedProperties = errors.New("no properties with an owner found")
+	errUnexpectedStatus  = errors.New("unexpected status code")
+	errEmptyPuzzle       = errors.New("puzzle response is empty")
+	scenarios            = []string{scenarioValid, scenarioInvalid, scenarioReplay}
+	steps                = []string{stepPuzzle, stepSolve, stepVerify, stepReplay}
+)
+
+type solveOptions struct {
+	rate           int
+	duration       time.Duration
+	concurrency    int
+	invalidPercent int
+	replayPercent  int
+	reportPath     string
+}
+
+type LatencySummary struct {
+	Count  int     `json:"count"`
+	Errors int     `json:"errors"`
+	MinMs  float64 `json:"min_ms"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+type ScenarioSummary struct {
+	Total int `json:"total"`
+	// Expected is the count of iterations where verification result matched the scenario (e.g. invalid solution was rejected)
+	Expected   int            `json:"expected"`
+	Unexpected int            `json:"unexpected"`
+	Errors     int            `json:"errors"`
+	ErrorCodes map[string]int `json:"error_codes,omitempty"`
+}
+
+type SolveReport struct {
+	StartedAt  time.Time                   `json:"started_at"`
+	Duration   string                      `json:"duration"`
+	Rate       int                         `json:"rate"`
+	Iterations int                         `json:"iterations"`
+	Steps      map[string]*LatencySummary  `json:"steps"`
+	Scenarios  map[string]*ScenarioSummary `json:"scenarios"`
+}
+
+type stepStats struct {
+	latencies []time.Duration
+	errors    int
+}
+
+// solveCollector accumulates results from concurrent iterations
+type solveCollector struct {
+	lock       sync.Mutex
+	steps      map[string]*stepStats
+	scenarios  map[string]*ScenarioSummary
+	iterations int
+}
+
+func newSolveCollector() *solveCollector {
+	c := &solveCollector{
+		steps:     make(map[string]*stepStats),
+		scenarios: make(map[string]*ScenarioSummary),
+	}
+
+	for _, s := range steps {
+		c.steps[s] = &stepStats{}
+	}
+
+	for _, s := range scenarios {
+		c.scenarios[s] = &ScenarioSummary{ErrorCodes: make(map[string]int)}
+	}
+
+	return c
+}
+
+func (c *solveCollector) addStep(step string, latency time.Duration, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.steps[step]
+	stats.latencies = append(stats.latencies, latency)
+	if err != nil {
+		stats.errors++
+	}
+}
+
+func (c *solveCollector) addResult(scenario string, expected bool, errorCodes []string, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.iterations++
+
+	summary := c.scenarios[scenario]
+	summary.Total++
+
+	switch {
+	case err != nil:
+		summary.Errors++
+	case expected:
+		summary.Expected++
+	default:
+		summary.Unexpected++
+	}
+
+	for _, code := range errorCodes {
+		summary.ErrorCodes[code]++
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	index := int(p*float64(len(sorted)-1) + 0.5)
+	return sorted[min(index, len(sorted)-1)]
+}
+
+func summarizeLatencies(latencies []time.Duration, errors int) *LatencySummary {
+	summary := &LatencySummary{Count: len(latencies), Errors: errors}
+	if len(latencies) == 0 {
+		return summary
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+
+	summary.MinMs = durationMs(sorted[0])
+	summary.MeanMs = durationMs(total / time.Duration(len(sorted)))
+	summary.P50Ms = durationMs(percentile(sorted, 0.5))
+	summary.P90Ms = durationMs(percentile(sorted, 0.9))
+	summary.P99Ms = durationMs(percentile(sorted, 0.99))
+	summary.MaxMs = durationMs(sorted[len(sorted)-1])
+
+	return summary
+}
+
+func (c *solveCollector) report(startedAt time.Time, opts *solveOptions) *SolveReport {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	report := &SolveReport{
+		StartedAt:  startedAt,
+		Duration:   time.Since(startedAt).Round(time.Millisecond).String(),
+		Rate:       opts.rate,
+		Iterations: c.iterations,
+		Steps:      make(map[string]*LatencySummary),
+		Scenarios:  c.scenarios,
+	}
+
+	for name, stats := range c.steps {
+		report.Steps[name] = summarizeLatencies(stats.latencies, stats.errors)
+	}
+
+	return report
+}
+
+func (r *SolveReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "Iterations: %d, rate: %d/s, duration: %s\n\n", r.Iterations, r.Rate, r.Duration)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "step\tcount\terrors\tmin\tmean\tp50\tp90\tp99\tmax\t")
+	for _, name := range steps {
+		s := r.Steps[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n", name, s.Count, s.Errors,
+			s.MinMs, s.MeanMs, s.P50Ms, s.P90Ms, s.P99Ms, s.MaxMs)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "scenario\ttotal\texpected\tunexpected\terrors\terror codes\t")
+	for _, name := range scenarios {
+		s := r.Scenarios[name]
+		codes := make([]string, 0, len(s.ErrorCodes))
+		for code, count := range s.ErrorCodes {
+			codes = append(codes, fmt.Sprintf("%s=%d", code, count))
+		}
+		slices.Sort(codes)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\t\n", name, s.Total, s.Expected, s.Unexpected, s.Errors, strings.Join(codes, ","))
+	}
+	_ = tw.Flush()
+}
+
+func (r *SolveReport) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// pickScenario chooses scenario for the next iteration according to configured ratios
+func pickScenario(invalidPercent, replayPercent int) string {
+	value := randv2.IntN(100)
+
+	switch {
+	case value < invalidPercent:
+		return scenarioInvalid
+	case value < invalidPercent+replayPercent:
+		return scenarioReplay
+	default:
+		return scenarioValid
+	}
+}
+
+type solveTarget struct {
+	property *dbgen.Property
+	secret   string
+}
+
+// prepareSolveTargets loads properties and creates an API key for every owner so that solutions can be verified
+func prepareSolveTargets(ctx context.Context, count int, cfg common.ConfigStore, rate int) ([]*solveTarget, error) {
+	pool, clickhouse, dberr := db.Connect(ctx, cfg, 5*time.Second, false /*admin*/)
+	if dberr != nil {
+		return nil, dberr
+	}
+
+	defer pool.Close()
+	/*defer*/ clickhouse.Close()
+
+	businessDB := db.NewBusiness(pool)
+
+	properties, err := businessDB.Impl().RetrieveProperties(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[int32]string)
+	targets := make([]*solveTarget, 0, len(properties))
+	expiration := time.Now().Add(24 * time.Hour)
+
+	for _, p := range properties {
+		if !p.OrgOwnerID.Valid {
+			continue
+		}
+
+		ownerID := p.OrgOwnerID.Int32
+		secret, ok := secrets[ownerID]
+		if !ok {
+			apiKey, err := businessDB.Impl().CreateAPIKey(ctx, ownerID, loadtestAPIKeyName, expiration, float64(rate))
+			if err != nil {
+				return nil, err
+			}
+
+			secret = db.UUIDToSecret(apiKey.ExternalID)
+			secrets[ownerID] = secret
+		}
+
+		targets = append(targets, &solveTarget{property: p, secret: secret})
+	}
+
+	if len(targets) == 0 {
+		return nil, errNoOwnedProperties
+	}
+
+	slog.Info("Prepared solve targets", "properties", len(targets), "apiKeys", len(secrets))
+
+	return targets, nil
+}
+
+type solveClient struct {
+	client          *http.Client
+	baseURL         string
+	rateLimitHeader string
+	collector       *solveCollector
+}
+
+func (sc *solveClient) fetchPuzzle(ctx context.Context, property *dbgen.Property) (*puzzle.Puzzle, string, error) {
+	url := fmt.Sprintf("%s/%s?%s=%s", sc.baseURL, common.PuzzleEndpoint, common.ParamSiteKey, db.UUIDToSiteKey(property.ExternalID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req.Header.Set("Origin", property.Domain)
+	req.Header.Set(sc.rateLimitHeader, common_test.GenerateRandomIPv4())
+
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	puzzleStr, _, _ := strings.Cut(string(body), ".")
+	if len(puzzleStr) == 0 {
+		return nil, "", errEmptyPuzzle
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(puzzleStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	p := new(puzzle.Puzzle)
+	if err := p.UnmarshalBinary(decoded); err != nil {
+		return nil, "", err
+	}
+
+	return p, string(body), nil
+}
+
+func (sc *solveClient) verify(ctx context.Context, payload, secret string) (bool, []string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", sc.baseURL, common.VerifyEndpoint),
+		strings.NewReader(payload))
+	if err != nil {
+		return false, nil, err
+	}
+
+	req.Header.Set(common.HeaderAPIKey, secret)
+	req.Header.Set(sc.rateLimitHeader, common_test.GenerateRandomIPv4())
+
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil, fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	response := &struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return false, nil, err
+	}
+
+	return response.Success, response.ErrorCodes, nil
+}
+
+// randomSolutions imitates a client that does not solve the puzzle at all
+func randomSolutions(p *puzzle.Puzzle) *puzzle.Solutions {
+	buffer := make([]byte, int(p.SolutionsCount)*puzzle.SolutionLength)
+	_, _ = rand.Read(buffer)
+
+	return &puzzle.Solutions{Buffer: buffer, Metadata: &puzzle.Metadata{}}
+}
+
+func (sc *solveClient) run(ctx context.Context, target *solveTarget, scenario string) {
+	startTime := time.Now()
+	p, puzzleStr, err := sc.fetchPuzzle(ctx, target.property)
+	sc.collector.addStep(stepPuzzle, time.Since(startTime), err)
+	if err != nil {
+		slog.Debug("Failed to fetch puzzle", common.ErrAttr(err))
+		sc.collector.addResult(scenario, false, nil, err)
+		return
+	}
+
+	var solutions *puzzle.Solutions
+	if scenario == scenarioInvalid {
+		solutions = randomSolutions(p)
+	} else {
+		startTime = time.Now()
+		solver := &puzzle.Solver{}
+		solutions, err = solver.Solve(p)
+		sc.collector.addStep(stepSolve, time.Since(startTime), err)
+		if err != nil {
+			slog.Error("Failed to solve puzzle", common.ErrAttr(err))
+			sc.collector.addResult(scenario, false, nil, err)
+			return
+		}
+	}
+
+	payload := fmt.Sprintf("%s.%s", solutions.String(), puzzleStr)
+
+	startTime = time.Now()
+	success, errorCodes, err := sc.verify(ctx, payload, target.secret)
+	sc.collector.addStep(stepVerify, time.Since(startTime), err)
+
+	if (err != nil) || (scenario != scenarioReplay) {
+		sc.collector.addResult(scenario, success == (scenario == scenarioValid), errorCodes, err)
+		return
+	}
+
+	if !success {
+		// replay cannot be checked if the original solution was not accepted
+		sc.collector.addResult(scenario, false, errorCodes, nil)
+		return
+	}
+
+	startTime = time.Now()
+	success, errorCodes, err = sc.verify(ctx, payload, target.secret)
+	sc.collector.addStep(stepReplay, time.Since(startTime), err)
+	sc.collector.addResult(scenario, success == target.property.AllowReplay, errorCodes, err)
+}
+
+func solve(count int, cfg common.ConfigStore, opts *solveOptions) error {
+	if (opts.invalidPercent < 0) || (opts.replayPercent < 0) || (opts.invalidPercent+opts.replayPercent > 100) {
+		return errInvalidRatios
+	}
+
+	ctx := context.TODO()
+
+	targets, err := prepareSolveTargets(ctx, count, cfg, opts.rate)
+	if err != nil {
+		return err
+	}
+
+	apiURLConfig := config.AsURL(ctx, cfg.Get(common.APIBaseURLKey))
+	client := &solveClient{
+		client:          &http.Client{Timeout: httpClientTimeout},
+		baseURL:         "http:" + apiURLConfig.URL(),
+		rateLimitHeader: cfg.Get(common.RateLimitHeaderKey).Value(),
+		collector:       newSolveCollector(),
+	}
+
+	slog.Info("Solving", "duration", opts.duration.String(), "rate", opts.rate, "concurrency", opts.concurrency,
+		"invalid", opts.invalidPercent, "replay", opts.replayPercent)
+
+	startedAt := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(max(1, opts.rate)))
+	defer ticker.Stop()
+	deadline := time.After(opts.duration)
+
+	// when all workers are busy (e.g. solving takes longer than expected), actual rate will be lower than requested
+	semaphore := make(chan struct{}, max(1, opts.concurrency))
+	var wg sync.WaitGroup
+
+loop:
+	for {
+		select {
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			semaphore <- struct{}{}
+			wg.Add(1)
+			go func(target *solveTarget, scenario string) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+				client.run(ctx, target, scenario)
+			}(targets[randv2.IntN(len(targets))], pickScenario(opts.invalidPercent, opts.replayPercent))
+		}
+	}
+
+	wg.Wait()
+
+	report := client.collector.report(startedAt, opts)
+	report.Print(os.Stdout)
+
+	if len(opts.reportPath) > 0 {
+		if err := report.WriteFile(opts.reportPath); err != nil {
+			return err
+		}
+		slog.Info("Saved report", "path", opts.reportPath)
+	}
+
+	return nil
+}
@@ -5,10 +5,39 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
 )
 
-func checkLicense(context.Context, common.ConfigStore) error {
-	return errors.New("enterprise version requires a license (https://privatecaptcha.com/)")
+var (
+	errLicenseRequired = errors.New("enterprise version requires a license (https://privatecaptcha.com/)")
+)
+
+func checkLicense(ctx context.Context, cfg common.ConfigStore) (*license.License, error) {
+	key, err := license.ParsePublicKey(license.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("license public key is not available in this build: %w", err)
+	}
+
+	// activation file works offline, so nothing is fetched here
+	lic, err := license.Load(cfg.Get(common.LicenseFileKey).Value(), key)
+	if err != nil {
+		if err == license.ErrNoLicense {
+			return nil, errLicenseRequired
+		}
+
+		return nil, fmt.Errorf("failed to load license: %w", err)
+	}
+
+	if err := lic.Check(time.Now()); err != nil {
+		return nil, fmt.Errorf("license %s: %w", lic.ID, err)
+	}
+
+	slog.InfoContext(ctx, "Loaded license", "id", lic.ID, "expiresAt", lic.ExpiresAt, "maxNodes", lic.MaxNodes)
+
+	return lic, nil
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+// serviceListener is a dedicated listener of one of the services (API, portal, CDN)
+type serviceListener struct {
+	name     string
+	router   *http.ServeMux
+	listener net.Listener
+	server   *http.Server
+	profile  config.ServerProfile
+}
+
+// listeners keeps the main router and optional dedicated service routers. Services without own listen address
+// share the main router (and listener), which is also the default for all of them.
+type listeners struct {
+	main     *http.ServeMux
+	services []*serviceListener
+}
+
+func newListeners() *listeners {
+	return &listeners{
+		main:     http.NewServeMux(),
+		services: make([]*serviceListener, 0),
+	}
+}
+
+const (
+	// certificates are also reloaded on SIGHUP
+	_certReloadInterval = 10 * time.Minute
+)
+
+var (
+	// certificates of all TLS listeners. Listeners are created before signals are handled so no locking is needed
+	certReloaders []*common.CertificateReloader
+)
+
+func createListener(ctx context.Context, address, certFile, keyFile string, profile config.ServerProfile) (net.Listener, error) {
+	listener, err := profile.ListenConfig().Listen(ctx, "tcp", address)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to listen", "address", address, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if useTLS := (certFile != "") && (keyFile != ""); useTLS {
+		reloader, err := common.NewCertificateReloader(certFile, keyFile)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load certificates", "cert", certFile, "key", keyFile, common.ErrAttr(err))
+			listener.Close()
+			return nil, err
+		}
+		tlsConfig := &tls.Config{
+			GetCertificate: reloader.GetCertificate,
+			NextProtos:     profile.NextProtos(),
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+
+		certReloaders = append(certReloaders, reloader)
+		go reloader.Run(common.TraceContext(context.Background(), "cert_reloader"), _certReloadInterval)
+	}
+
+	return listener, nil
+}
+
+func reloadCertificates(ctx context.Context) {
+	for _, cr := range certReloaders {
+		_ = cr.Reload(ctx)
+	}
+}
+
+// router returns the router to setup service on: dedicated one if listen address is configured or the main one
+func (l *listeners) router(ctx context.Context, name string, settings config.ListenerSettings, profile config.ServerProfile) (*http.ServeMux, error) {
+	if len(settings.Address) == 0 {
+		return l.main, nil
+	}
+
+	listener, err := createListener(ctx, settings.Address, settings.CertFile, settings.KeyFile, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	sl := &serviceListener{
+		name:     name,
+		router:   http.NewServeMux(),
+		listener: listener,
+		profile:  profile,
+	}
+	l.services = append(l.services, sl)
+
+	return sl.router, nil
+}
+
+// routers returns all distinct routers, starting from the main one
+func (l *listeners) routers() []*http.ServeMux {
+	result := []*http.ServeMux{l.main}
+	for _, sl := range l.services {
+		result = append(result, sl.router)
+	}
+	return result
+}
+
+func (l *listeners) close() {
+	for _, sl := range l.services {
+		sl.listener.Close()
+	}
+}
+
+func newHTTPServer(ctx context.Context, handler http.Handler, baseCtx context.Context, profile config.ServerProfile) *http.Server {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		MaxHeaderBytes:    1024 * 1024,
+		BaseContext: func(_ net.Listener) context.Context {
+			return baseCtx
+		},
+	}
+
+	if err := profile.Configure(server); err != nil {
+		slog.ErrorContext(ctx, "Failed to configure server profile", common.ErrAttr(err))
+	}
+
+	return server
+}
+
+// serve starts dedicated service servers in the background
+func (l *listeners) serve(ctx context.Context, baseCtx context.Context) {
+	for _, sl := range l.services {
+		sl.server = newHTTPServer(ctx, sl.router, baseCtx, sl.profile)
+		go func(sl *serviceListener) {
+			slog.InfoContext(ctx, "Listening", "service", sl.name, "address", sl.listener.Addr().String())
+			if err := sl.server.Serve(sl.listener); err != nil && err != http.ErrServerClosed {
+				slog.ErrorContext(ctx, "Error serving", "service", sl.name, common.ErrAttr(err))
+			}
+		}(sl)
+	}
+}
+
+// shutdown gracefully stops dedicated service servers and returns the first error
+func (l *listeners) shutdown(ctx context.Context) error {
+	var result error
+
+	for _, sl := range l.services {
+		if sl.server == nil {
+			continue
+		}
+
+		sl.server.SetKeepAlivesEnabled(false)
+		if err := sl.server.Shutdown(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to shutdown service gracefully", "service", sl.name, common.ErrAttr(err))
+			if result == nil {
+				result = err
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+)
+
+type localSetuper interface {
+	Setup(mux *http.ServeMux)
+}
+
+// newLocalRouter serves health checks openly (for orchestrator probes) while metrics, profiling, jobs and
+// diagnostics endpoints are behind optional token and IP allowlist
+func newLocalRouter(access *common.LocalAccess, healthCheck *maintenance.HealthCheckJob, protected ...localSetuper) *http.ServeMux {
+	protectedRouter := http.NewServeMux()
+	for _, p := range protected {
+		p.Setup(protectedRouter)
+	}
+
+	localRouter := http.NewServeMux()
+	localRouter.Handle("/", access.Handler(protectedRouter))
+	localRouter.Handle(http.MethodGet+" /"+common.LiveEndpoint, common.Recovered(http.HandlerFunc(healthCheck.LiveHandler)))
+	localRouter.Handle(http.MethodGet+" /"+common.ReadyEndpoint, common.Recovered(http.HandlerFunc(healthCheck.ReadyHandler)))
+
+	return localRouter
+}
+
+func updateLocalAccess(ctx context.Context, cfg common.ConfigStore, access *common.LocalAccess) {
+	allowlist, err := common.ParseIPAllowlist(cfg.Get(common.LocalAllowedIPsKey).Value())
+	if err != nil {
+		// failing closed is safer than exposing the endpoints because of a typo
+		slog.ErrorContext(ctx, "Failed to parse local IP allowlist", common.ErrAttr(err))
+		access.Update(cfg.Get(common.LocalAuthTokenKey).Value(), []netip.Prefix{})
+		return
+	}
+
+	access.Update(cfg.Get(common.LocalAuthTokenKey).Value(), allowlist)
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -17,16 +17,22 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/alerts"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/api"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/chaos"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/kms"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session/store/memory"
 	"github.com/PrivateCaptcha/PrivateCaptcha/web"
@@ -38,6 +44,9 @@ const (
 	modeMigrate          = "migrate"
 	modeRollback         = "rollback"
 	modeServer           = "server"
+	modeWorker           = "worker"
+	modeCheckConfig      = "check-config"
+	modeSeedDev          = "seed-dev"
 	_readinessDrainDelay = 1 * time.Second
 	_shutdownHardPeriod  = 3 * time.Second
 	_shutdownPeriod      = 10 * time.Second
@@ -46,7 +55,7 @@ const (
 
 var (
 	GitCommit       string
-	flagMode        = flag.String("mode", "", strings.Join([]string{modeMigrate, modeServer}, " | "))
+	flagMode        = flag.String("mode", "", strings.Join([]string{modeMigrate, modeRollback, modeServer, modeWorker, modeCheckConfig, modeSeedDev}, " | "))
 	envFileFlag     = flag.String("env", "", "Path to .env file, 'stdin' or empty")
 	versionFlag     = flag.Bool("version", false, "Print version and exit")
 	migrateHashFlag = flag.String("migrate-hash", "", "Target migration version (git commit)")
@@ -55,47 +64,9 @@ var (
 	env             *common.EnvMap
 )
 
-func listenAddress(cfg common.ConfigStore) string {
-	host := cfg.Get(common.HostKey).Value()
-	if host == "" {
-		host = "localhost"
-	}
-
-	port := cfg.Get(common.PortKey).Value()
-	if port == "" {
-		port = "8080"
-	}
-	address := net.JoinHostPort(host, port)
-	return address
-}
-
-func createListener(ctx context.Context, cfg common.ConfigStore) (net.Listener, error) {
-	address := listenAddress(cfg)
-	listener, err := net.Listen("tcp", address)
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to listen", "address", address, common.ErrAttr(err))
-		return nil, err
-	}
-
-	if useTLS := (*certFileFlag != "") && (*keyFileFlag != ""); useTLS {
-		cert, err := tls.LoadX509KeyPair(*certFileFlag, *keyFileFlag)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to load certificates", "cert", *certFileFlag, "key", *keyFileFlag, common.ErrAttr(err))
-			return nil, err
-		}
-		tlsConfig := &tls.Config{
-			Certificates: []tls.Certificate{cert},
-		}
-		listener = tls.NewListener(listener, tlsConfig)
-	}
-
-	return listener, nil
-}
-
-func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener net.Listener) error {
-	stage := cfg.Get(common.StageKey).Value()
-	verbose := config.AsBool(cfg.Get(common.VerboseKey))
-	common.SetupLogs(stage, verbose)
+func run(ctx context.Context, cfg common.ConfigStore, settings *config.Settings, stderr io.Writer, listener net.Listener, lic *license.License) error {
+	stage := settings.Stage
+	common.SetupLogs(stage, settings.Verbose)
 
 	planService := billing.NewPlanService(nil)
 
@@ -110,55 +81,126 @@ func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener
 	businessDB := db.NewBusiness(pool)
 	timeSeriesDB := db.NewTimeSeries(clickhouse)
 
+	var timeSeries common.TimeSeriesStore = timeSeriesDB
+	secondaryClickhouse := db.ConnectSecondaryClickHouse(ctx, cfg)
+	if secondaryClickhouse != nil {
+		defer secondaryClickhouse.Close()
+	}
+
+	if sinks := db.NewTimeSeriesSinks(secondaryClickhouse, cfg); len(sinks) > 0 {
+		fanOut := db.NewFanOutTimeSeries(timeSeriesDB, sinks...)
+		defer fanOut.Shutdown()
+		timeSeries = fanOut
+	}
+
+	var regionalTimeSeries *db.RegionalTimeSeries
+	if regionalClickhouse := db.ConnectRegionalClickHouse(ctx, cfg, false /*admin*/); len(regionalClickhouse) > 0 {
+		for _, ch := range regionalClickhouse {
+			defer ch.Close()
+		}
+
+		regionalTimeSeries = db.NewRegionalTimeSeries(timeSeries, regionalClickhouse)
+		defer regionalTimeSeries.Shutdown()
+		timeSeries = regionalTimeSeries
+	}
+
 	metrics := monitoring.NewService()
+	businessDB.RegisterCacheMetrics(metrics)
+	diagnostics := monitoring.NewDiagnostics()
+	businessDB.RegisterCacheMetrics(diagnostics)
 
-	cdnURLConfig := config.AsURL(ctx, cfg.Get(common.CDNBaseURLKey))
-	portalURLConfig := config.AsURL(ctx, cfg.Get(common.PortalBaseURLKey))
+	if settings.CacheInvalidation {
+		businessDB.StartCacheInvalidation(ctx)
+	}
 
-	mailer := email.NewMailer(cfg)
+	cdnURLConfig := settings.CDNURL
+	portalURLConfig := settings.PortalURL
+
+	mailer := newQueuedMailer(cfg, settings, businessDB, metrics)
+	mailer.Queue.Start(ctx)
 	portalMailer := email.NewPortalMailer("https:"+cdnURLConfig.URL(), portalURLConfig.Domain(), mailer, cfg)
+	alerter := alerts.NewWebhookAlerter(cfg, metrics)
+	alerter.Queue.Start(ctx)
+
+	kmsSigner, err := kms.NewSigner(cfg)
+	if err != nil {
+		return err
+	}
+	secretsDeriver := kms.NewDeriver(cfg, kmsSigner)
+
+	apiURLConfig := settings.APIURL
+
+	// client IP resolution is shared by API, portal and status rate limiters
+	ipStrategy, err := ratelimit.NewClientIPStrategy(cfg.Get(common.RateLimitHeaderKey).Value(), cfg.Get(common.TrustedProxiesKey).Value(), metrics)
+	if err != nil {
+		return err
+	}
+
+	apiAuth := api.NewAuthMiddleware(cfg, ipStrategy, businessDB, api.NewUserLimiter(businessDB, timeSeries, planService, stage, alerter), planService)
+	apiAuth.RequireVerifiedDomain = settings.VerifiedDomains
 
 	apiServer := &api.Server{
 		Stage:              stage,
 		BusinessDB:         businessDB,
-		TimeSeries:         timeSeriesDB,
-		Auth:               api.NewAuthMiddleware(cfg, businessDB, api.NewUserLimiter(businessDB), planService),
+		TimeSeries:         timeSeries,
+		Auth:               apiAuth,
 		VerifyLogChan:      make(chan *common.VerifyRecord, 10*api.VerifyBatchSize),
-		Salt:               api.NewPuzzleSalt(cfg.Get(common.APISaltKey)),
-		UserFingerprintKey: api.NewUserFingerprintKey(cfg.Get(common.UserFingerprintIVKey)),
+		Salt:               api.NewPuzzleSalt(cfg.Get(common.APISaltKey), secretsDeriver),
+		UserFingerprintKey: api.NewUserFingerprintKey(cfg.Get(common.UserFingerprintIVKey), secretsDeriver),
 		Metrics:            metrics,
 		Mailer:             portalMailer,
-		Levels:             difficulty.NewLevels(timeSeriesDB, 100 /*levelsBatchSize*/, api.PropertyBucketSize),
+		Receipts:           api.NewReceiptSigner(cfg.Get(common.VerifyReceiptKey), cfg.Get(common.VerifyReceiptPreviousKeysKey), "https:"+apiURLConfig.URL()),
+		Levels:             difficulty.NewLevels(timeSeries, metrics, 100 /*levelsBatchSize*/, api.PropertyBucketSize),
+		ASN:                asn.NewDefaultClassifier(),
 		VerifyLogCancel:    func() {},
+		VerifyLogOverflow:  settings.VerifyLogOverflow,
+		VerifyLogSpillDir:  settings.VerifyLogSpillDir,
+		PuzzlePool:         api.NewPuzzlePool(settings.PuzzlePoolSize),
 	}
 	if err := apiServer.Init(ctx, 10*time.Second /*flush interval*/, 1*time.Second /*backfill duration*/); err != nil {
 		return err
 	}
 
-	router := http.NewServeMux()
+	ls := newListeners()
+	apiRouter, err := ls.router(ctx, "api", settings.APIListener, settings.APIProfile)
+	if err != nil {
+		return err
+	}
+	portalRouter, err := ls.router(ctx, "portal", settings.PortalListener, settings.PortalProfile)
+	if err != nil {
+		ls.close()
+		return err
+	}
+	cdnRouter, err := ls.router(ctx, "cdn", settings.CDNListener, config.DefaultServerProfile)
+	if err != nil {
+		ls.close()
+		return err
+	}
 
-	apiURLConfig := config.AsURL(ctx, cfg.Get(common.APIBaseURLKey))
 	apiDomain := apiURLConfig.Domain()
-	apiServer.Setup(router, apiDomain, verbose, common.NoopMiddleware)
+	apiServer.Setup(apiRouter, apiDomain, settings.Verbose, common.NoopMiddleware)
 
 	sessionStore := db.NewSessionStore(pool, memory.New(), 1*time.Minute, session.KeyPersistent)
 	portalServer := &portal.Server{
 		Stage:      stage,
 		Store:      businessDB,
-		TimeSeries: timeSeriesDB,
+		TimeSeries: timeSeries,
 		XSRF:       &common.XSRFMiddleware{Key: "pckey", Timeout: 1 * time.Hour},
 		Sessions: &session.Manager{
 			CookieName:  "pcsid",
 			Store:       sessionStore,
 			MaxLifetime: sessionStore.MaxLifetime(),
 		},
-		PlanService:  planService,
-		APIURL:       apiURLConfig.URL(),
-		CDNURL:       cdnURLConfig.URL(),
-		PuzzleEngine: apiServer,
-		Metrics:      metrics,
-		Mailer:       portalMailer,
-		Auth:         portal.NewAuthMiddleware(portal.NewRateLimiter(cfg)),
+		PlanService:     planService,
+		APIURL:          apiURLConfig.URL(),
+		CDNURL:          cdnURLConfig.URL(),
+		PuzzleEngine:    apiServer,
+		Metrics:         metrics,
+		Mailer:          portalMailer,
+		Auth:            portal.NewAuthMiddleware(portal.NewRateLimiter(cfg, ipStrategy)),
+		CountryHeader:   settings.CountryHeader,
+		VerifiedDomains: settings.VerifiedDomains,
+		License:         lic,
 	}
 
 	templatesBuilder := portal.NewTemplatesBuilder()
@@ -175,47 +217,80 @@ func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener
 		TimeSeriesDB:  timeSeriesDB,
 		CheckInterval: cfg.Get(common.HealthCheckIntervalKey),
 		Metrics:       metrics,
+		Alerter:       alerter,
 	}
 
 	portalDomain := portalURLConfig.Domain()
-	_ = portalServer.Setup(router, portalDomain, common.NoopMiddleware)
-	rateLimiter := portalServer.Auth.RateLimit()
 	cdnDomain := cdnURLConfig.Domain()
-	cdnChain := alice.New(common.Recovered, metrics.CDNHandler, rateLimiter)
-	router.Handle("GET "+cdnDomain+"/portal/", http.StripPrefix("/portal/", cdnChain.Then(web.Static())))
-	router.Handle("GET "+cdnDomain+"/widget/", http.StripPrefix("/widget/", cdnChain.Then(widget.Static())))
+	securityPolicy := common.NewSecurityPolicy(stage, cdnDomain, apiDomain)
+	_ = portalServer.Setup(portalRouter, portalDomain, securityPolicy.Portal)
+	rateLimiter := portalServer.Auth.RateLimit()
+	cdnChain := alice.New(common.Recovered, securityPolicy.Static, metrics.CDNHandler, rateLimiter)
+	cdnRouter.Handle("GET "+cdnDomain+"/portal/", http.StripPrefix("/portal/", cdnChain.Then(web.Static())))
+	cdnRouter.Handle("GET "+cdnDomain+"/widget/", http.StripPrefix("/widget/", cdnChain.Then(widget.Static())))
+	cdnRouter.Handle("GET "+cdnDomain+widget.VersionedPath, http.StripPrefix(widget.VersionedPath, cdnChain.Then(widget.VersionedStatic())))
+	for channel := range widget.Channels {
+		channelPath := widget.ChannelPath(channel)
+		cdnRouter.Handle("GET "+cdnDomain+channelPath, http.StripPrefix(channelPath, cdnChain.Then(widget.ChannelStatic(channel))))
+	}
+	// public status feed for external status pages has it's own (strict) rate limit so it cannot affect anything else
+	statusRateLimiter := ratelimit.NewIPAddrRateLimiter("status", ipStrategy,
+		ratelimit.NewIPAddrBuckets(10_000 /*max buckets*/, 5 /*capacity*/, 2*time.Second /*leak interval*/))
+	defer statusRateLimiter.Shutdown()
+	statusChain := alice.New(common.Recovered, metrics.IgnoredHandler, statusRateLimiter.RateLimit)
+	apiRouter.Handle(http.MethodGet+" "+apiDomain+"/"+common.HealthEndpoint, statusChain.ThenFunc(healthCheck.StatusFeedHandler))
 	// "protection" (NOTE: different than usual order of monitoring)
 	publicChain := alice.New(common.Recovered, metrics.IgnoredHandler, rateLimiter)
-	portalServer.SetupCatchAll(router, portalDomain, publicChain)
-	router.Handle("/", publicChain.ThenFunc(common.CatchAll))
+	portalServer.SetupCatchAll(portalRouter, portalDomain, publicChain.Append(securityPolicy.Portal))
+	for _, router := range ls.routers() {
+		router.Handle("/", publicChain.ThenFunc(common.CatchAll))
+	}
 
-	ongoingCtx, stopOngoingGracefully := context.WithCancel(context.Background())
-	httpServer := &http.Server{
-		Handler:           router,
-		ReadHeaderTimeout: 5 * time.Second,
-		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      10 * time.Second,
-		IdleTimeout:       60 * time.Second,
-		MaxHeaderBytes:    1024 * 1024,
-		BaseContext: func(_ net.Listener) context.Context {
-			return ongoingCtx
-		},
+	diagnostics.Register("rate_limiters", func() any {
+		return map[string]int{
+			"puzzle":  apiAuth.PuzzleRateLimiter.BucketsCount(),
+			"mobile":  apiAuth.MobileRateLimiter.BucketsCount(),
+			"api_key": apiAuth.ApiKeyRateLimiter.BucketsCount(),
+			"portal":  portalServer.Auth.BucketsCount(),
+			"status":  statusRateLimiter.BucketsCount(),
+		}
+	})
+	diagnostics.Register("queues", func() any {
+		depths := apiServer.QueueDepths()
+		depths["email"] = mailer.Queue.PendingCount()
+		depths["alerts"] = alerter.Queue.PendingCount()
+		return depths
+	})
+	if apiServer.PuzzlePool != nil {
+		diagnostics.Register("puzzle_pool", func() any { return apiServer.PuzzlePool.Stats() })
 	}
+	diagnostics.Register("config", func() any { return config.Snapshot(cfg, config.DefaultMapper) })
+
+	ongoingCtx, stopOngoingGracefully := context.WithCancel(context.Background())
+	httpServer := newHTTPServer(ctx, ls.main, ongoingCtx, config.DefaultServerProfile)
 
+	localAccess := &common.LocalAccess{}
 	updateConfigFunc := func(ctx context.Context) {
 		cfg.Update(ctx)
-		maintenanceMode := config.AsBool(cfg.Get(common.MaintenanceModeKey))
-		businessDB.UpdateConfig(maintenanceMode)
-		timeSeriesDB.UpdateConfig(maintenanceMode)
+		maintenanceModes := config.AsMaintenanceModes(cfg)
+		businessDB.UpdateConfig(maintenanceModes.All)
+		timeSeriesDB.UpdateConfig(maintenanceModes.Stats)
+		if regionalTimeSeries != nil {
+			regionalTimeSeries.UpdateConfig(maintenanceModes.Stats)
+		}
+		healthCheck.UpdateConfig(maintenanceModes)
+		chaos.Update(ctx, cfg)
 		portalServer.UpdateConfig(ctx, cfg)
 		apiServer.UpdateConfig(ctx, cfg)
+		db.UpdateQueryTimer(metrics, config.AsDuration(cfg.Get(common.SlowQueryThresholdKey), db.DefaultSlowQueryThreshold))
+		updateLocalAccess(ctx, cfg, localAccess)
 	}
 	updateConfigFunc(ctx)
 
 	quit := make(chan struct{})
 	go func(ctx context.Context) {
 		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
 		defer func() {
 			signal.Stop(signals)
 			close(signals)
@@ -233,6 +308,9 @@ func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener
 					slog.ErrorContext(ctx, "Failed to update environment", common.ErrAttr(uerr))
 				}
 				updateConfigFunc(ctx)
+				reloadCertificates(ctx)
+			case syscall.SIGUSR1:
+				diagnostics.Log(ctx)
 			case syscall.SIGINT, syscall.SIGTERM:
 				healthCheck.Shutdown(ctx)
 				// Give time for readiness check to propagate
@@ -249,35 +327,38 @@ func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener
 			slog.ErrorContext(ctx, "Error serving", common.ErrAttr(err))
 		}
 	}()
+	ls.serve(ctx, ongoingCtx)
 
 	// start maintenance jobs
-	jobs := maintenance.NewJobs(businessDB)
-	jobs.Add(healthCheck)
+	jobs := maintenance.NewJobs(businessDB, alerter)
 	jobs.Add(&maintenance.SessionsCleanupJob{
 		Session: portalServer.Sessions,
 	})
-	jobs.Add(&maintenance.CleanupDBCacheJob{Store: businessDB})
-	jobs.Add(&maintenance.CleanupDeletedRecordsJob{Store: businessDB, Age: 365 * 24 * time.Hour})
-	jobs.AddLocked(24*time.Hour, &maintenance.GarbageCollectDataJob{
-		Age:        30 * 24 * time.Hour,
-		BusinessDB: businessDB,
-		TimeSeries: timeSeriesDB,
-	})
+	bj := &backgroundJobs{
+		BusinessDB:       businessDB,
+		TimeSeriesDB:     timeSeriesDB,
+		TimeSeries:       timeSeries,
+		Mailer:           portalMailer,
+		HealthCheck:      healthCheck,
+		Alerter:          alerter,
+		PortalPrefix:     portalServer.Prefix,
+		License:          lic,
+		LicenseReportURL: settings.LicenseReportURL,
+	}
+	bj.register(ctx, cfg, jobs)
+	if secretsDeriver != nil {
+		jobs.Add(&api.RotateSecretsJob{Salt: apiServer.Salt, FingerprintKey: apiServer.UserFingerprintKey})
+	}
 	jobs.AddOneOff(&maintenance.WarmupPortalAuth{
 		Store: businessDB,
 	})
 	jobs.Run()
 
 	var localServer *http.Server
-	if localAddress := cfg.Get(common.LocalAddressKey).Value(); len(localAddress) > 0 {
-		localRouter := http.NewServeMux()
-		metrics.Setup(localRouter)
-		jobs.Setup(localRouter)
-		localRouter.Handle(http.MethodGet+" /"+common.LiveEndpoint, common.Recovered(http.HandlerFunc(healthCheck.LiveHandler)))
-		localRouter.Handle(http.MethodGet+" /"+common.ReadyEndpoint, common.Recovered(http.HandlerFunc(healthCheck.ReadyHandler)))
+	if localAddress := settings.LocalAddress; len(localAddress) > 0 {
 		localServer = &http.Server{
 			Addr:    localAddress,
-			Handler: localRouter,
+			Handler: newLocalRouter(localAccess, healthCheck, metrics, jobs, diagnostics),
 		}
 		go func() {
 			slog.InfoContext(ctx, "Serving local API", "address", localServer.Addr)
@@ -296,13 +377,19 @@ func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener
 		<-quit
 		slog.DebugContext(ctx, "Shutting down gracefully")
 		jobs.Shutdown()
+		mailer.Queue.Shutdown()
+		alerter.Queue.Shutdown()
 		sessionStore.Shutdown()
 		apiServer.Shutdown()
 		portalServer.Shutdown()
+		businessDB.StopCacheInvalidation()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), _shutdownPeriod)
 		defer cancel()
 		httpServer.SetKeepAlivesEnabled(false)
 		serr := httpServer.Shutdown(shutdownCtx)
+		if lerr := ls.shutdown(shutdownCtx); (lerr != nil) && (serr == nil) {
+			serr = lerr
+		}
 		stopOngoingGracefully()
 		if serr != nil {
 			slog.ErrorContext(ctx, "Failed to shutdown gracefully", common.ErrAttr(serr))
@@ -319,7 +406,7 @@ func run(ctx context.Context, cfg common.ConfigStore, stderr io.Writer, listener
 	return nil
 }
 
-func migrate(ctx context.Context, cfg common.ConfigStore, up bool) error {
+func migrate(ctx context.Context, cfg common.ConfigStore, settings *config.Settings, up bool) error {
 	if len(*migrateHashFlag) == 0 {
 		return errors.New("empty migrate hash")
 	}
@@ -328,11 +415,8 @@ func migrate(ctx context.Context, cfg common.ConfigStore, up bool) error {
 		return fmt.Errorf("target version (%v) does not match built version (%v)", *migrateHashFlag, GitCommit)
 	}
 
-	stage := cfg.Get(common.StageKey).Value()
-	verbose := config.AsBool(cfg.Get(common.VerboseKey))
-
-	common.SetupLogs(stage, verbose)
-	slog.InfoContext(ctx, "Migrating", "up", up, "version", GitCommit, "stage", stage)
+	common.SetupLogs(settings.Stage, settings.Verbose)
+	slog.InfoContext(ctx, "Migrating", "up", up, "version", GitCommit, "stage", settings.Stage)
 
 	planService := billing.NewPlanService(nil)
 
@@ -352,6 +436,53 @@ func migrate(ctx context.Context, cfg common.ConfigStore, up bool) error {
 		return err
 	}
 
+	if secondaryClickhouse := db.ConnectSecondaryClickHouse(ctx, cfg); secondaryClickhouse != nil {
+		defer secondaryClickhouse.Close()
+
+		if err := db.MigrateSecondaryClickHouse(ctx, secondaryClickhouse, cfg, up); err != nil {
+			return err
+		}
+	}
+
+	for region, regionalClickhouse := range db.ConnectRegionalClickHouse(ctx, cfg, true /*admin*/) {
+		defer regionalClickhouse.Close()
+
+		if err := db.MigrateRegionalClickHouse(ctx, regionalClickhouse, cfg, region, up); err != nil {
+			return err
+		}
+	}
+
+	// process exits right after migration so alert has to be delivered synchronously
+	if err := alerts.NewWebhookAlerter(cfg, nil /*metrics*/).Deliver(ctx, &common.Alert{
+		Severity: common.AlertSeverityInfo,
+		Title:    "Database migration completed",
+		Text:     fmt.Sprintf("Version: %s, up: %v", GitCommit, up),
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to send migration alert", common.ErrAttr(err))
+	}
+
+	return nil
+}
+
+func checkConfig(cfg common.ConfigStore, w io.Writer) error {
+	report := config.CheckConfig(cfg, config.DefaultMapper)
+	report.CheckFile("certfile", *certFileFlag)
+	report.CheckFile("keyfile", *keyFileFlag)
+	for _, key := range []common.ConfigKey{common.APITLSCertFileKey, common.APITLSKeyFileKey, common.PortalTLSCertFileKey,
+		common.PortalTLSKeyFileKey, common.CDNTLSCertFileKey, common.CDNTLSKeyFileKey} {
+		report.CheckFile(config.DefaultMapper(key), cfg.Get(key).Value())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("configuration has %d error(s)", report.Errors)
+	}
+
 	return nil
 }
 
@@ -371,25 +502,49 @@ func main() {
 
 	cfg := config.NewEnvConfig(config.DefaultMapper, env.Get)
 
-	if err = checkLicense(context.Background(), cfg); err != nil {
+	if *flagMode == modeCheckConfig {
+		if err = checkConfig(cfg, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	lic, err := checkLicense(context.Background(), cfg)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
+	settings, err := config.LoadSettings(context.Background(), cfg, config.DefaultMapper)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+
+	// all downstream HTTP clients use default transport, so faults (if enabled) are injected into all of them
+	http.DefaultTransport = chaos.Transport(http.DefaultTransport)
+
 	switch *flagMode {
 	case modeServer:
 		ctx := common.TraceContext(context.Background(), "main")
-		if listener, lerr := createListener(ctx, cfg); lerr == nil {
-			err = run(ctx, cfg, os.Stderr, listener)
+		if listener, lerr := createListener(ctx, settings.ListenAddress, *certFileFlag, *keyFileFlag, config.DefaultServerProfile); lerr == nil {
+			err = run(ctx, cfg, settings, os.Stderr, listener, lic)
 		} else {
 			err = lerr
 		}
+	case modeWorker:
+		ctx := common.TraceContext(context.Background(), "worker")
+		err = runWorker(ctx, cfg, settings, lic)
 	case modeMigrate:
 		ctx := common.TraceContext(context.Background(), "migration")
-		err = migrate(ctx, cfg, true /*up*/)
+		err = migrate(ctx, cfg, settings, true /*up*/)
 	case modeRollback:
 		ctx := common.TraceContext(context.Background(), "migration")
-		err = migrate(ctx, cfg, false /*up*/)
+		err = migrate(ctx, cfg, settings, false /*up*/)
+	case modeSeedDev:
+		ctx := common.TraceContext(context.Background(), "seed")
+		err = seedDev(ctx, cfg, settings, os.Stdout)
 	default:
 		err = fmt.Errorf("unknown mode: '%s'", *flagMode)
 	}
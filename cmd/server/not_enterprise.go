@@ -6,9 +6,10 @@ import (
 	"context"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
 )
 
-func checkLicense(context.Context, common.ConfigStore) error {
+func checkLicense(context.Context, common.ConfigStore) (*license.License, error) {
 	// not implemented
-	return nil
+	return nil, nil
 }
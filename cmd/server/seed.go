@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	randv2 "math/rand/v2"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	seedUserEmail  = "dev@privatecaptcha.com"
+	seedUserName   = "Dev User"
+	seedOrgName    = "Dev Organization"
+	seedAPIKeyName = "Dev API key"
+	seedAPIKey     = db.APIKeyPrefix + "dddddddddddddddddddddddddddddddd"
+	seedStatsDays  = 30
+)
+
+var (
+	errSeedStage = errors.New("seeding is only allowed in dev stage")
+)
+
+type seedProperty struct {
+	name    string
+	domain  string
+	sitekey string
+	// average number of puzzle requests per hour for synthetic stats
+	hourlyRequests int
+}
+
+// well-known sitekeys can be hardcoded in local test pages of the widget and integrations
+var seedProperties = []*seedProperty{
+	{name: "Localhost", domain: "localhost", sitekey: "dddddddd000000000000000000000001", hourlyRequests: 50},
+	{name: "Dev website", domain: "dev.privatecaptcha.com", sitekey: "dddddddd000000000000000000000002", hourlyRequests: 20},
+	{name: "Quiet website", domain: "quiet.privatecaptcha.com", sitekey: "dddddddd000000000000000000000003", hourlyRequests: 2},
+}
+
+// seedDev creates a demo account with well-known identifiers and synthetic usage stats so that a fresh local
+// environment has a working portal right away. Seeding is idempotent: nothing is done if demo user already exists
+func seedDev(ctx context.Context, cfg common.ConfigStore, settings *config.Settings, w io.Writer) error {
+	if settings.Stage != common.StageDev {
+		return errSeedStage
+	}
+
+	common.SetupLogs(settings.Stage, settings.Verbose)
+
+	planService := billing.NewPlanService(nil)
+
+	pool, clickhouse, dberr := db.Connect(ctx, cfg, _dbConnectTimeout, false /*admin*/)
+	if dberr != nil {
+		return dberr
+	}
+
+	defer pool.Close()
+	defer clickhouse.Close()
+
+	businessDB := db.NewBusiness(pool)
+	timeSeriesDB := db.NewTimeSeries(clickhouse)
+
+	if _, err := businessDB.Impl().FindUserByEmail(ctx, seedUserEmail); err == nil {
+		fmt.Fprintf(w, "Dev data is already seeded. Sign in as %s\n", seedUserEmail)
+		return nil
+	} else if err != db.ErrRecordNotFound {
+		return err
+	}
+
+	plan := planService.GetInternalAdminPlan()
+	priceIDMonthly, _ := plan.PriceIDs()
+	subscrParams := &dbgen.CreateSubscriptionParams{
+		ExternalProductID: plan.ProductID(),
+		ExternalPriceID:   priceIDMonthly,
+		Status:            planService.TrialStatus(),
+		Source:            dbgen.SubscriptionSourceInternal,
+		TrialEndsAt:       db.Timestampz(time.Now().AddDate(1, 0, 0)),
+		NextBilledAt:      pgtype.Timestamptz{},
+	}
+
+	var user *dbgen.User
+	var org *dbgen.Organization
+	var properties []*dbgen.Property
+
+	if err := businessDB.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		var err error
+		user, org, err = impl.CreateNewAccount(ctx, subscrParams, seedUserEmail, seedUserName, seedOrgName, -1 /*existing user ID*/)
+		if err != nil {
+			return err
+		}
+
+		for _, sp := range seedProperties {
+			property, err := impl.CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+				Name:       sp.name,
+				OrgID:      db.Int(org.ID),
+				CreatorID:  db.Int(user.ID),
+				OrgOwnerID: db.Int(user.ID),
+				Domain:     sp.domain,
+				Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+				Growth:     dbgen.DifficultyGrowthMedium,
+			})
+			if err != nil {
+				return err
+			}
+
+			if property, err = impl.UpdatePropertySitekey(ctx, property.ID, sp.sitekey); err != nil {
+				return err
+			}
+
+			properties = append(properties, property)
+		}
+
+		key, err := impl.CreateAPIKey(ctx, user.ID, seedAPIKeyName, time.Now().AddDate(1, 0, 0), plan.APIRequestsPerSecond())
+		if err != nil {
+			return err
+		}
+
+		_, err = impl.UpdateAPIKeySecret(ctx, user.ID, key.ID, seedAPIKey)
+		return err
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to seed business data", common.ErrAttr(err))
+		return err
+	}
+
+	tnow := time.Now().UTC()
+	for i, property := range properties {
+		if err := seedStats(ctx, timeSeriesDB, user, property, seedProperties[i].hourlyRequests, tnow); err != nil {
+			slog.ErrorContext(ctx, "Failed to seed stats", "propID", property.ID, common.ErrAttr(err))
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "Seeded dev data. Sign in as %s\n", seedUserEmail)
+	for _, property := range properties {
+		fmt.Fprintf(w, "Property %q: sitekey %s\n", property.Name, db.UUIDToSiteKey(property.ExternalID))
+	}
+	fmt.Fprintf(w, "API key: %s\n", seedAPIKey)
+
+	return nil
+}
+
+// seedStats writes synthetic access and verify logs for the last seedStatsDays days, one batch per day
+func seedStats(ctx context.Context, timeSeries *db.TimeSeriesDB, user *dbgen.User, property *dbgen.Property, hourlyRequests int, tnow time.Time) error {
+	start := tnow.Truncate(time.Hour).AddDate(0, 0, -seedStatsDays)
+	// a pool of "visitors" makes unique visitors stats differ from the requests count
+	fingerprints := make([]common.TFingerprint, 10*hourlyRequests+1)
+	for i := range fingerprints {
+		fingerprints[i] = common.RandomFingerprint()
+	}
+
+	for day := 0; day < seedStatsDays; day++ {
+		var accessRecords []*common.AccessRecord
+		var verifyRecords []*common.VerifyRecord
+
+		for hour := 0; hour < 24; hour++ {
+			hourStart := start.Add(time.Duration(day*24+hour) * time.Hour)
+			count := hourlyRequests/2 + randv2.IntN(hourlyRequests+1)
+
+			for i := 0; i < count; i++ {
+				timestamp := hourStart.Add(time.Duration(randv2.Int64N(int64(time.Hour))))
+
+				accessRecords = append(accessRecords, &common.AccessRecord{
+					Fingerprint: fingerprints[randv2.IntN(len(fingerprints))],
+					UserID:      user.ID,
+					OrgID:       property.OrgID.Int32,
+					PropertyID:  property.ID,
+					Timestamp:   timestamp,
+					Datacenter:  randv2.IntN(20) == 0,
+				})
+
+				// not every puzzle is solved and some of the solutions are rejected
+				if randv2.IntN(10) == 0 {
+					continue
+				}
+
+				status := puzzle.VerifyNoError
+				if randv2.IntN(20) == 0 {
+					status = puzzle.VerifyErrorOther
+				}
+
+				verifyRecords = append(verifyRecords, &common.VerifyRecord{
+					UserID:     user.ID,
+					OrgID:      property.OrgID.Int32,
+					PropertyID: property.ID,
+					PuzzleID:   randv2.Uint64(),
+					Timestamp:  timestamp.Add(time.Duration(1+randv2.IntN(10)) * time.Second),
+					Status:     int8(status),
+				})
+			}
+		}
+
+		if err := timeSeries.WriteAccessLogBatch(ctx, accessRecords); err != nil {
+			return err
+		}
+
+		if err := timeSeries.WriteVerifyLogBatch(ctx, verifyRecords); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
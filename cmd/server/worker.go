@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/alerts"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/chaos"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+)
+
+type jobsRegistry interface {
+	Add(job common.PeriodicJob)
+	AddLocked(lockDuration time.Duration, job common.PeriodicJob)
+	AddOneOff(job common.OneOffJob)
+}
+
+// backgroundJobs do not depend on serving traffic so they can run both in the server and in a dedicated worker
+// process. Jobs that must not run concurrently are "locked" in DB, so any number of servers and workers can coexist
+type backgroundJobs struct {
+	BusinessDB   *db.BusinessStore
+	TimeSeriesDB *db.TimeSeriesDB
+	TimeSeries   common.TimeSeriesStore
+	Mailer       common.Mailer
+	HealthCheck  *maintenance.HealthCheckJob
+	Alerter      common.Alerter
+	PortalPrefix string
+	License      *license.License
+	// empty value means offline mode
+	LicenseReportURL string
+}
+
+func (bj *backgroundJobs) portalPath(parts ...string) string {
+	return common.RelURL(bj.PortalPrefix, strings.Join(parts, "/"))
+}
+
+func (bj *backgroundJobs) register(ctx context.Context, cfg common.ConfigStore, jobs jobsRegistry) {
+	apiKeysSettingsPath := bj.portalPath(common.SettingsEndpoint) + "?" + common.ParamTab + "=" + common.APIKeysEndpoint
+
+	jobs.Add(bj.HealthCheck)
+	jobs.Add(&maintenance.CleanupDBCacheJob{Store: bj.BusinessDB})
+	jobs.Add(&maintenance.RefreshPropertySnapshotJob{Store: bj.BusinessDB})
+	jobs.Add(&maintenance.CleanupDeletedRecordsJob{Store: bj.BusinessDB, Age: 365 * 24 * time.Hour})
+	jobs.AddLocked(24*time.Hour, &maintenance.GarbageCollectDataJob{
+		Age:        30 * 24 * time.Hour,
+		BusinessDB: bj.BusinessDB,
+		TimeSeries: bj.TimeSeries,
+	})
+	supportReplies := &maintenance.SupportReplies{
+		SupportEmail: cfg.Get(common.SupportEmailKey),
+		AdminEmail:   cfg.Get(common.AdminEmailKey),
+	}
+	jobs.AddLocked(2*time.Minute, &maintenance.ProcessWebhookEventsJob{
+		Store: bj.BusinessDB,
+		Handlers: map[string]maintenance.WebhookEventHandler{
+			email.SupportReplyEventType: supportReplies.HandleEvent,
+		},
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupWebhookEventsJob{
+		Store:   bj.BusinessDB,
+		Age:     90 * 24 * time.Hour,
+		Alerter: bj.Alerter,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupQueueJobsJob{Store: bj.BusinessDB, Age: 30 * 24 * time.Hour})
+	jobs.AddLocked(1*time.Hour, &maintenance.RotateAPIKeysJob{
+		Store:        bj.BusinessDB,
+		Mailer:       bj.Mailer,
+		Overlap:      common.APIKeyRotationOverlap,
+		SettingsPath: apiKeysSettingsPath,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.ExpireAPIKeysJob{
+		Store:        bj.BusinessDB,
+		Mailer:       bj.Mailer,
+		ArchiveAfter: common.APIKeyArchiveAfter,
+		SettingsPath: apiKeysSettingsPath,
+	})
+	jobs.AddLocked(10*time.Minute, &maintenance.SuspendAnomalousAPIKeysJob{
+		Store:        bj.BusinessDB,
+		Mailer:       bj.Mailer,
+		SettingsPath: apiKeysSettingsPath,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.OrgBudgetAlertsJob{
+		Store:         bj.BusinessDB,
+		TimeSeries:    bj.TimeSeries,
+		Mailer:        bj.Mailer,
+		OrgPathPrefix: bj.portalPath(common.OrgEndpoint),
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.OrgDigestJob{
+		Store:         bj.BusinessDB,
+		TimeSeries:    bj.TimeSeries,
+		Mailer:        bj.Mailer,
+		OrgPathPrefix: bj.portalPath(common.OrgEndpoint),
+	})
+	jobs.AddLocked(15*time.Minute, &maintenance.VerifyDomainsJob{
+		Store:           bj.BusinessDB,
+		Resolver:        net.DefaultResolver,
+		RecheckInterval: 1 * time.Hour,
+	})
+	jobs.AddLocked(1*time.Minute, monitoring.NewUsageExportJob(bj.TimeSeries, cfg))
+	jobs.AddLocked(24*time.Hour, maintenance.NewWarehouseExportJob(bj.TimeSeries, cfg))
+	if bj.License != nil {
+		jobs.Add(&maintenance.LicenseHeartbeatJob{
+			Store:   bj.BusinessDB,
+			License: bj.License,
+			NodeID:  license.NewNodeID(),
+			Version: GitCommit,
+		})
+		if reportURL := bj.LicenseReportURL; len(reportURL) > 0 {
+			jobs.AddLocked(24*time.Hour, &maintenance.LicenseReportJob{
+				Store:      bj.BusinessDB,
+				TimeSeries: bj.TimeSeries,
+				License:    bj.License,
+				URL:        reportURL,
+				Version:    GitCommit,
+				Client:     &http.Client{Timeout: 1 * time.Minute},
+			})
+		} else {
+			slog.InfoContext(ctx, "License usage reporting is disabled (offline mode)")
+		}
+	}
+}
+
+// newQueuedMailer sends emails in the background, optionally keeping the queue in Postgres
+func newQueuedMailer(cfg common.ConfigStore, settings *config.Settings, businessDB db.Implementor, metrics common.QueueMetrics) *email.QueuedMailer {
+	var store common.JobStore
+	if settings.EmailQueuePersist {
+		store = db.NewQueueStore(businessDB)
+	}
+
+	return email.NewQueuedMailer(email.NewMailer(cfg), store, metrics)
+}
+
+// runWorker only runs background jobs and does not serve any public traffic. Local address (if configured) is
+// still served for metrics, health checks and on-demand job launches
+func runWorker(ctx context.Context, cfg common.ConfigStore, settings *config.Settings, lic *license.License) error {
+	stage := settings.Stage
+	common.SetupLogs(stage, settings.Verbose)
+
+	pool, clickhouse, dberr := db.Connect(ctx, cfg, _dbConnectTimeout, false /*admin*/)
+	if dberr != nil {
+		return dberr
+	}
+
+	defer pool.Close()
+	defer clickhouse.Close()
+
+	businessDB := db.NewBusiness(pool)
+	timeSeriesDB := db.NewTimeSeries(clickhouse)
+
+	var timeSeries common.TimeSeriesStore = timeSeriesDB
+	secondaryClickhouse := db.ConnectSecondaryClickHouse(ctx, cfg)
+	if secondaryClickhouse != nil {
+		defer secondaryClickhouse.Close()
+	}
+
+	if sinks := db.NewTimeSeriesSinks(secondaryClickhouse, cfg); len(sinks) > 0 {
+		fanOut := db.NewFanOutTimeSeries(timeSeriesDB, sinks...)
+		defer fanOut.Shutdown()
+		timeSeries = fanOut
+	}
+
+	var regionalTimeSeries *db.RegionalTimeSeries
+	if regionalClickhouse := db.ConnectRegionalClickHouse(ctx, cfg, false /*admin*/); len(regionalClickhouse) > 0 {
+		for _, ch := range regionalClickhouse {
+			defer ch.Close()
+		}
+
+		regionalTimeSeries = db.NewRegionalTimeSeries(timeSeries, regionalClickhouse)
+		defer regionalTimeSeries.Shutdown()
+		timeSeries = regionalTimeSeries
+	}
+
+	metrics := monitoring.NewService()
+	businessDB.RegisterCacheMetrics(metrics)
+
+	if settings.CacheInvalidation {
+		businessDB.StartCacheInvalidation(ctx)
+		defer businessDB.StopCacheInvalidation()
+	}
+
+	cdnURLConfig := settings.CDNURL
+	portalURLConfig := settings.PortalURL
+	mailer := newQueuedMailer(cfg, settings, businessDB, metrics)
+	mailer.Queue.Start(ctx)
+	portalMailer := email.NewPortalMailer("https:"+cdnURLConfig.URL(), portalURLConfig.Domain(), mailer, cfg)
+	alerter := alerts.NewWebhookAlerter(cfg, metrics)
+	alerter.Queue.Start(ctx)
+
+	healthCheck := &maintenance.HealthCheckJob{
+		BusinessDB:    businessDB,
+		TimeSeriesDB:  timeSeriesDB,
+		CheckInterval: cfg.Get(common.HealthCheckIntervalKey),
+		Metrics:       metrics,
+		Alerter:       alerter,
+	}
+
+	localAccess := &common.LocalAccess{}
+	updateConfigFunc := func(ctx context.Context) {
+		cfg.Update(ctx)
+		maintenanceModes := config.AsMaintenanceModes(cfg)
+		businessDB.UpdateConfig(maintenanceModes.All)
+		timeSeriesDB.UpdateConfig(maintenanceModes.Stats)
+		if regionalTimeSeries != nil {
+			regionalTimeSeries.UpdateConfig(maintenanceModes.Stats)
+		}
+		chaos.Update(ctx, cfg)
+		db.UpdateQueryTimer(metrics, config.AsDuration(cfg.Get(common.SlowQueryThresholdKey), db.DefaultSlowQueryThreshold))
+		updateLocalAccess(ctx, cfg, localAccess)
+	}
+	updateConfigFunc(ctx)
+
+	jobs := maintenance.NewJobs(businessDB, alerter)
+	bj := &backgroundJobs{
+		BusinessDB:       businessDB,
+		TimeSeriesDB:     timeSeriesDB,
+		TimeSeries:       timeSeries,
+		Mailer:           portalMailer,
+		HealthCheck:      healthCheck,
+		Alerter:          alerter,
+		License:          lic,
+		LicenseReportURL: settings.LicenseReportURL,
+	}
+	bj.register(ctx, cfg, jobs)
+
+	slog.InfoContext(ctx, "Starting worker", "version", GitCommit, "stage", stage)
+	jobs.Run()
+
+	var localServer *http.Server
+	if localAddress := settings.LocalAddress; len(localAddress) > 0 {
+		localServer = &http.Server{
+			Addr:    localAddress,
+			Handler: newLocalRouter(localAccess, healthCheck, metrics, jobs),
+		}
+		go func() {
+			slog.InfoContext(ctx, "Serving local API", "address", localServer.Addr)
+			if err := localServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.ErrorContext(ctx, "Error serving local API", common.ErrAttr(err))
+			}
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for sig := range signals {
+		slog.DebugContext(ctx, "Received signal", "signal", sig)
+		if sig == syscall.SIGHUP {
+			if uerr := env.Update(); uerr != nil {
+				slog.ErrorContext(ctx, "Failed to update environment", common.ErrAttr(uerr))
+			}
+			updateConfigFunc(ctx)
+			continue
+		}
+
+		break
+	}
+
+	slog.DebugContext(ctx, "Shutting down worker")
+	healthCheck.Shutdown(ctx)
+	jobs.Shutdown()
+	mailer.Queue.Shutdown()
+	alerter.Queue.Shutdown()
+	if localServer != nil {
+		localServer.Close()
+	}
+	slog.DebugContext(ctx, "Shutdown finished")
+
+	return nil
+}
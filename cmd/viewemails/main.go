@@ -26,8 +26,19 @@ const (
 
 var (
 	templates = map[string]string{
-		"two-factor": email.TwoFactorHTMLTemplate,
-		"welcome":    email.WelcomeHTMLTemplate,
+		"two-factor":       email.TwoFactorHTMLTemplate,
+		"magic-link":       email.MagicLinkHTMLTemplate,
+		"welcome":          email.WelcomeHTMLTemplate,
+		"email-changed":    email.EmailChangedHTMLTemplate,
+		"new-signin":       email.NewSignInHTMLTemplate,
+		"apikey-rotated":   email.APIKeyRotatedHTMLTemplate,
+		"apikey-expiring":  email.APIKeyExpiringHTMLTemplate,
+		"apikey-suspended": email.APIKeySuspendedHTMLTemplate,
+		"account-locked":   email.AccountLockedHTMLTemplate,
+		"budget-alert":     email.BudgetAlertHTMLTemplate,
+		"org-deleted":      email.OrgDeletedHTMLTemplate,
+		"email-verify":     email.EmailVerificationHTMLTemplate,
+		"org-digest":       email.OrgDigestHTMLTemplate,
 	}
 )
 
@@ -55,19 +66,91 @@ func serveExecute(templateBody string, w http.ResponseWriter) error {
 	}
 
 	data := struct {
-		Code        int
-		Domain      string
-		CurrentYear int
-		CDN         string
-		Message     string
-		TicketID    string
+		Code           int
+		Domain         string
+		CurrentYear    int
+		CDN            string
+		Message        string
+		TicketID       string
+		NewEmail       string
+		RevertURL      string
+		ValidHours     int
+		LoginURL       string
+		ValidMinutes   int
+		Time           string
+		Device         string
+		Country        string
+		IPAddress      string
+		SettingsURL    string
+		KeyName        string
+		RetireDate     string
+		ExpireDate     string
+		Days           int
+		Reason         string
+		LockedUntil    string
+		OrgName        string
+		Percent        int
+		Usage          int64
+		Budget         int64
+		PortalURL      string
+		ConfirmURL     string
+		WeekStart      string
+		Requests       uint64
+		HasTrend       bool
+		Trend          int
+		VerifySuccess  uint64
+		VerifyFailure  uint64
+		NewProperties  int64
+		JoinedMembers  int64
+		InvitedMembers int64
+		Spikes         []struct {
+			Day      string
+			Failures uint64
+		}
+		OrgURL string
 	}{
-		Code:        123456,
-		CDN:         "https://cdn.staging.privatecaptcha.com",
-		Domain:      "https://staging.privatecaptcha.com",
-		CurrentYear: time.Now().Year(),
-		Message:     "This is a support request message. Nothing works!",
-		TicketID:    "qwerty12345",
+		Code:           123456,
+		CDN:            "https://cdn.staging.privatecaptcha.com",
+		Domain:         "https://staging.privatecaptcha.com",
+		CurrentYear:    time.Now().Year(),
+		Message:        "This is a support request message. Nothing works!",
+		TicketID:       "qwerty12345",
+		NewEmail:       "new@example.com",
+		RevertURL:      "https://staging.privatecaptcha.com/email/revert/qwerty12345",
+		ValidHours:     48,
+		LoginURL:       "https://staging.privatecaptcha.com/login/link/qwerty12345",
+		ValidMinutes:   15,
+		Time:           time.Now().UTC().Format("02 Jan 2006 15:04 MST"),
+		Device:         "Firefox on Linux",
+		Country:        "EE",
+		IPAddress:      "192.0.2.1",
+		SettingsURL:    "https://staging.privatecaptcha.com/settings",
+		KeyName:        "Production key",
+		RetireDate:     time.Now().UTC().AddDate(0, 0, 7).Format("02 Jan 2006 15:04 MST"),
+		ExpireDate:     time.Now().UTC().AddDate(0, 0, 14).Format("02 Jan 2006 15:04 MST"),
+		Days:           14,
+		Reason:         "verification requests grew 150 times compared to the previous day",
+		LockedUntil:    time.Now().UTC().Add(1 * time.Hour).Format("02 Jan 2006 15:04 MST"),
+		OrgName:        "My organization",
+		Percent:        80,
+		Usage:          80123,
+		Budget:         100000,
+		PortalURL:      "https://staging.privatecaptcha.com/",
+		ConfirmURL:     "https://staging.privatecaptcha.com/email/confirm/qwerty12345",
+		WeekStart:      time.Now().UTC().AddDate(0, 0, -7).Format("02 Jan 2006"),
+		Requests:       123456,
+		HasTrend:       true,
+		Trend:          12,
+		VerifySuccess:  100500,
+		VerifyFailure:  1234,
+		NewProperties:  2,
+		JoinedMembers:  1,
+		InvitedMembers: 1,
+		Spikes: []struct {
+			Day      string
+			Failures uint64
+		}{{Day: time.Now().UTC().AddDate(0, 0, -3).Format("Mon, 02 Jan"), Failures: 456}},
+		OrgURL: "https://staging.privatecaptcha.com/org/1",
 	}
 
 	var htmlBodyTpl bytes.Buffer
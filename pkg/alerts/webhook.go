@@ -0,0 +1,206 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	deliveryTimeout = 10 * time.Second
+	// the same alert (by key) is not repeated more often than this
+	DefaultDedupeWindow = 1 * time.Hour
+	// Discord rejects messages longer than 2000 characters
+	maxDiscordContentLength = 2000
+	maxDedupeKeys           = 1_000
+)
+
+var (
+	alertRetryPolicy = common.RetryPolicy{
+		MaxAttempts: 5,
+		BaseBackoff: 10 * time.Second,
+		MaxBackoff:  5 * time.Minute,
+	}
+)
+
+// WebhookAlerter posts alerts to Slack or Discord incoming webhook (or any Slack-compatible one, e.g. Mattermost).
+// Webhook URL is read on every alert so it can be changed on config reload, empty URL disables alerting.
+// Alerts are delivered by the in-memory queue as they are often about database issues
+type WebhookAlerter struct {
+	URL          common.ConfigItem
+	Stage        string
+	Client       *http.Client
+	DedupeWindow time.Duration
+	Queue        *common.JobQueue
+	lock         sync.Mutex
+	lastSent     map[string]time.Time
+}
+
+var _ common.Alerter = (*WebhookAlerter)(nil)
+
+func NewWebhookAlerter(cfg common.ConfigStore, metrics common.QueueMetrics) *WebhookAlerter {
+	a := &WebhookAlerter{
+		URL:          cfg.Get(common.AlertWebhookURLKey),
+		Stage:        cfg.Get(common.StageKey).Value(),
+		Client:       &http.Client{Timeout: deliveryTimeout},
+		DedupeWindow: DefaultDedupeWindow,
+		lastSent:     make(map[string]time.Time),
+	}
+	a.Queue = common.NewJobQueue(common.QueueAlerts, a.deliverJob, alertRetryPolicy, nil /*store*/, metrics)
+	return a
+}
+
+func isDiscordWebhook(webhookURL string) bool {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	return (host == "discord.com") || (host == "discordapp.com") || strings.HasSuffix(host, ".discord.com")
+}
+
+func severityEmoji(severity common.AlertSeverity) string {
+	switch severity {
+	case common.AlertSeverityCritical:
+		return ":red_circle:"
+	case common.AlertSeverityWarning:
+		return ":warning:"
+	default:
+		return ":information_source:"
+	}
+}
+
+func alertMessage(alert *common.Alert, stage, bold string) string {
+	var sb strings.Builder
+	sb.WriteString(severityEmoji(alert.Severity))
+	sb.WriteString(" ")
+	sb.WriteString(bold)
+	sb.WriteString(alert.Title)
+	sb.WriteString(bold)
+	if len(stage) > 0 {
+		sb.WriteString(" [")
+		sb.WriteString(stage)
+		sb.WriteString("]")
+	}
+	if len(alert.Text) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(alert.Text)
+	}
+	return sb.String()
+}
+
+// webhookPayload formats alert as Slack ("text") or Discord ("content") message, which differ in markdown flavor
+func webhookPayload(webhookURL string, alert *common.Alert, stage string) ([]byte, error) {
+	if isDiscordWebhook(webhookURL) {
+		message := alertMessage(alert, stage, "**")
+		if len(message) > maxDiscordContentLength {
+			message = message[:maxDiscordContentLength-3] + "..."
+		}
+		return json.Marshal(map[string]string{"content": message})
+	}
+
+	return json.Marshal(map[string]string{"text": alertMessage(alert, stage, "*")})
+}
+
+// shouldSend implements deduplication of alerts by key
+func (a *WebhookAlerter) shouldSend(key string, tnow time.Time) bool {
+	if len(key) == 0 {
+		return true
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.lastSent == nil {
+		a.lastSent = make(map[string]time.Time)
+	}
+
+	if last, ok := a.lastSent[key]; ok && (tnow.Sub(last) < a.DedupeWindow) {
+		return false
+	}
+
+	if len(a.lastSent) >= maxDedupeKeys {
+		for k, t := range a.lastSent {
+			if tnow.Sub(t) >= a.DedupeWindow {
+				delete(a.lastSent, k)
+			}
+		}
+	}
+
+	a.lastSent[key] = tnow
+
+	return true
+}
+
+func (a *WebhookAlerter) SendAlert(ctx context.Context, alert *common.Alert) {
+	if len(a.URL.Value()) == 0 {
+		return
+	}
+
+	if !a.shouldSend(alert.Key, time.Now()) {
+		slog.DebugContext(ctx, "Skipping duplicate alert", "key", alert.Key)
+		return
+	}
+
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize alert", "key", alert.Key, common.ErrAttr(err))
+		return
+	}
+
+	if err := a.Queue.Enqueue(ctx, payload); err != nil {
+		slog.ErrorContext(ctx, "Failed to enqueue alert", "key", alert.Key, common.ErrAttr(err))
+	}
+}
+
+func (a *WebhookAlerter) deliverJob(ctx context.Context, payload []byte) error {
+	alert := &common.Alert{}
+	if err := json.Unmarshal(payload, alert); err != nil {
+		return err
+	}
+
+	return a.Deliver(ctx, alert)
+}
+
+// Deliver synchronously posts the alert (without deduplication), e.g. before process exits
+func (a *WebhookAlerter) Deliver(ctx context.Context, alert *common.Alert) error {
+	webhookURL := a.URL.Value()
+	if len(webhookURL) == 0 {
+		return nil
+	}
+
+	payload, err := webhookPayload(webhookURL, alert, a.Stage)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		return fmt.Errorf("unexpected webhook response status: %v", resp.StatusCode)
+	}
+
+	slog.DebugContext(ctx, "Delivered alert", "key", alert.Key, "severity", alert.Severity)
+
+	return nil
+}
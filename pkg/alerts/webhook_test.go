@@ -0,0 +1,151 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	alert := &common.Alert{Severity: common.AlertSeverityCritical, Title: "Postgres is down", Text: "details"}
+
+	testCases := []struct {
+		url    string
+		field  string
+		prefix string
+	}{
+		{"https://hooks.slack.com/services/T000/B000/XXX", "text", ":red_circle: *Postgres is down* [test]"},
+		{"https://discord.com/api/webhooks/123/abc", "content", ":red_circle: **Postgres is down** [test]"},
+		{"https://mattermost.example.com/hooks/xxx", "text", ":red_circle: *Postgres is down* [test]"},
+	}
+
+	for _, tc := range testCases {
+		payload, err := webhookPayload(tc.url, alert, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var message map[string]string
+		if err := json.Unmarshal(payload, &message); err != nil {
+			t.Fatal(err)
+		}
+
+		if text := message[tc.field]; !strings.HasPrefix(text, tc.prefix) || !strings.HasSuffix(text, "\ndetails") {
+			t.Errorf("Unexpected message for %v: %q", tc.url, text)
+		}
+	}
+}
+
+func TestDiscordPayloadTruncated(t *testing.T) {
+	alert := &common.Alert{Title: "Job failed", Text: strings.Repeat("a", 3*maxDiscordContentLength)}
+
+	payload, err := webhookPayload("https://discord.com/api/webhooks/123/abc", alert, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message map[string]string
+	if err := json.Unmarshal(payload, &message); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(message["content"]) != maxDiscordContentLength {
+		t.Errorf("Unexpected content length: %v", len(message["content"]))
+	}
+}
+
+func TestAlertDedupe(t *testing.T) {
+	alerter := &WebhookAlerter{DedupeWindow: 1 * time.Hour}
+	tnow := time.Now()
+
+	if !alerter.shouldSend("key", tnow) {
+		t.Error("First alert was not sent")
+	}
+
+	if alerter.shouldSend("key", tnow.Add(1*time.Minute)) {
+		t.Error("Duplicate alert was sent")
+	}
+
+	if !alerter.shouldSend("other", tnow.Add(1*time.Minute)) {
+		t.Error("Alert with another key was not sent")
+	}
+
+	if !alerter.shouldSend("key", tnow.Add(2*time.Hour)) {
+		t.Error("Alert was not sent after dedupe window")
+	}
+
+	if !alerter.shouldSend("", tnow) || !alerter.shouldSend("", tnow) {
+		t.Error("Alerts without key are not supposed to be deduplicated")
+	}
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	var received map[string]string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := config.NewEnvConfig(config.DefaultMapper, func(key string) string {
+		if key == "PC_ALERT_WEBHOOK_URL" {
+			return srv.URL
+		}
+		return ""
+	})
+
+	alerter := NewWebhookAlerter(cfg, nil /*metrics*/)
+	if err := alerter.Deliver(context.TODO(), &common.Alert{Title: "Migration completed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(received["text"], "Migration completed") {
+		t.Errorf("Unexpected webhook payload: %v", received)
+	}
+}
+
+func TestSendAlertQueued(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var message map[string]string
+		_ = json.Unmarshal(body, &message)
+		received <- message["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := config.NewEnvConfig(config.DefaultMapper, func(key string) string {
+		if key == "PC_ALERT_WEBHOOK_URL" {
+			return srv.URL
+		}
+		return ""
+	})
+
+	ctx := context.TODO()
+	alerter := NewWebhookAlerter(cfg, nil /*metrics*/)
+	alerter.SendAlert(ctx, &common.Alert{Key: "test", Title: "Job failed"})
+	alerter.SendAlert(ctx, &common.Alert{Key: "test", Title: "Job failed"})
+
+	if count := alerter.Queue.PendingCount(); count != 1 {
+		t.Fatalf("Unexpected number of queued alerts: %v", count)
+	}
+
+	if err := alerter.Queue.ProcessOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := <-received; !strings.Contains(text, "Job failed") {
+		t.Errorf("Unexpected webhook payload: %v", text)
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	// verify payload of accessible challenge is "accessible:<base64 answer>:<solutions>.<puzzle>.<signature>"
+	accessiblePayloadPrefix    = "accessible:"
+	accessibleAnswerSeparator  = ":"
+	accessibleAnswerLabel      = "accessible-answer:"
+	accessibleCodeAlphabet     = "ACDEFHJKLMNPRTUWXY3479"
+	accessibleCodeLength       = 6
+	accessibleDifficultyOffset = 2 * common.DifficultyDelta
+	// accessible challenge still requires some work, just less than the regular puzzle of the property
+	accessibleMinDifficulty = uint8(common.DifficultyLevelSmall - common.DifficultyDelta)
+)
+
+var (
+	errAccessibleDisabled = errors.New("accessible mode is not enabled")
+)
+
+type accessibleChallengeResponse struct {
+	// data URL of the image with the code that has to be typed in
+	Image string `json:"image"`
+	// regular puzzle (with reduced difficulty) that has to be solved in addition to the code
+	Challenge string `json:"challenge"`
+}
+
+// randomAccessibleCode returns a code of characters from accessibleCodeAlphabet, it is the only secret of the
+// challenge so it has to come from a cryptographic source
+func randomAccessibleCode() (string, error) {
+	const maxByte = 256 - 256%len(accessibleCodeAlphabet)
+
+	code := make([]byte, 0, accessibleCodeLength)
+	buf := make([]byte, 2*accessibleCodeLength)
+
+	for len(code) < accessibleCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+
+		for _, b := range buf {
+			// rejection sampling to keep characters uniformly distributed
+			if (int(b) < maxByte) && (len(code) < accessibleCodeLength) {
+				code = append(code, accessibleCodeAlphabet[int(b)%len(accessibleCodeAlphabet)])
+			}
+		}
+	}
+
+	return string(code), nil
+}
+
+// normalizeAccessibleAnswer makes answers case- and whitespace-insensitive
+func normalizeAccessibleAnswer(answer string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, answer)
+}
+
+func accessibleAnswerSalt(answer string) []byte {
+	return []byte(accessibleAnswerLabel + normalizeAccessibleAnswer(answer))
+}
+
+func accessibleDifficulty(difficulty uint8) uint8 {
+	return uint8(max(int(difficulty)-accessibleDifficultyOffset, int(accessibleMinDifficulty)))
+}
+
+func (s *Server) accessibleChallenge(ctx context.Context, r *http.Request) (*accessibleChallengeResponse, *puzzle.Puzzle, int32, error) {
+	var userID int32 = -1
+
+	p, property, err := s.puzzleForRequest(r)
+	if err == db.ErrTestProperty {
+		return nil, nil, userID, errAccessibleDisabled
+	} else if err != nil {
+		return nil, nil, userID, err
+	}
+
+	// property that is not cached yet is treated as if the setting was off (until it's backfilled)
+	if (property == nil) || !property.AccessibleMode {
+		return nil, nil, userID, errAccessibleDisabled
+	}
+
+	userID = property.OrgOwnerID.Int32
+	p.Difficulty = accessibleDifficulty(p.Difficulty)
+
+	code, err := randomAccessibleCode()
+	if err != nil {
+		return nil, nil, userID, err
+	}
+
+	image, err := renderAccessibleCode(code)
+	if err != nil {
+		return nil, nil, userID, err
+	}
+
+	payload, err := p.Serialize(ctx, s.Salt.Value(), accessibleAnswerSalt(code))
+	if err != nil {
+		return nil, nil, userID, err
+	}
+
+	var buf bytes.Buffer
+	if err := payload.Write(&buf); err != nil {
+		return nil, nil, userID, err
+	}
+
+	return &accessibleChallengeResponse{Image: image, Challenge: buf.String()}, p, userID, nil
+}
+
+func (s *Server) accessibleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !isValidRequestAction(r) {
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidAction)
+		return
+	}
+
+	response, p, userID, err := s.accessibleChallenge(ctx, r)
+	if err == errAccessibleDisabled {
+		slog.Log(ctx, common.LevelTrace, "Accessible mode is not enabled for the property")
+		sendError(ctx, w, http.StatusForbidden, ErrorCodeAccessibleDisabled)
+		return
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Failed to create accessible challenge", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+		return
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+
+	slog.Log(ctx, common.LevelTrace, "Issued accessible challenge", "puzzleID", p.PuzzleID, "difficulty", p.Difficulty)
+
+	s.Metrics.ObservePuzzleCreated(userID)
+}
+
+// verifyAccessible checks the answer and the solutions of accessible challenge. Every challenge can be answered only
+// once (regardless of property replay setting), otherwise the answer could be brute-forced
+func (s *Server) verifyAccessible(ctx context.Context, payload string, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (*puzzle.Puzzle, puzzle.VerifyError, error) {
+	encodedAnswer, solutionsPayload, ok := strings.Cut(payload, accessibleAnswerSeparator)
+	if !ok {
+		slog.WarnContext(ctx, "Accessible verify payload does not contain an answer")
+		return nil, puzzle.ParseResponseError, nil
+	}
+
+	verifyPayload, err := puzzle.ParseVerifyPayload(ctx, solutionsPayload)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse accessible verify payload", common.ErrAttr(err))
+		return nil, puzzle.ParseResponseError, nil
+	}
+
+	p := verifyPayload.Puzzle()
+	// accessible challenges are always signed with the answer
+	if !verifyPayload.NeedsExtraSalt() {
+		slog.WarnContext(ctx, "Payload is not an accessible challenge", "puzzleID", p.PuzzleID)
+		return p, puzzle.ParseResponseError, nil
+	}
+
+	answer, err := base64.StdEncoding.DecodeString(encodedAnswer)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to decode accessible challenge answer", common.ErrAttr(err))
+		return p, puzzle.ParseResponseError, nil
+	}
+
+	puzzleObject, property, perr := s.verifyPuzzleValid(ctx, verifyPayload, accessibleAnswerSalt(string(answer)), expectedOwner, tnow)
+	if perr == puzzle.InvalidSolutionError {
+		if cerr := s.BusinessDB.CachePuzzle(ctx, puzzleObject, tnow); cerr != nil {
+			slog.ErrorContext(ctx, "Failed to cache accessible challenge", common.ErrAttr(cerr))
+		}
+	}
+
+	if perr != puzzle.VerifyNoError && perr != puzzle.MaintenanceModeError {
+		return puzzleObject, perr, nil
+	}
+
+	// accessible mode could have been turned off after the challenge was issued
+	if (property != nil) && !property.AccessibleMode {
+		slog.WarnContext(ctx, "Accessible mode is not enabled for the property", "propertyID", property.ID)
+		return puzzleObject, puzzle.InvalidPropertyError, nil
+	}
+
+	if (puzzleObject != nil) && (property != nil) {
+		if cerr := s.BusinessDB.CachePuzzle(ctx, puzzleObject, tnow); cerr != nil {
+			slog.ErrorContext(ctx, "Failed to cache accessible challenge", common.ErrAttr(cerr))
+		}
+	}
+
+	if _, verr := verifyPayload.VerifySolutions(ctx); verr != puzzle.VerifyNoError {
+		slog.WarnContext(ctx, "Failed to verify accessible challenge solutions", "result", verr.String(),
+			"puzzleID", puzzleObject.PuzzleID)
+		s.addVerifyRecord(ctx, puzzleObject, property, verr)
+		return puzzleObject, verr, nil
+	}
+
+	s.addVerifyRecord(ctx, puzzleObject, property, puzzle.VerifyNoError)
+
+	return puzzleObject, perr, nil
+}
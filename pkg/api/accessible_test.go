@@ -0,0 +1,441 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestNormalizeAccessibleAnswer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		answer   string
+		expected string
+	}{
+		{"ACD349", "ACD349"},
+		{" acd 349 ", "ACD349"},
+		{"aCd\t349\n", "ACD349"},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := normalizeAccessibleAnswer(tc.answer); actual != tc.expected {
+			t.Errorf("Unexpected normalized answer for %q: %q (expected %q)", tc.answer, actual, tc.expected)
+		}
+	}
+}
+
+func TestRandomAccessibleCode(t *testing.T) {
+	t.Parallel()
+
+	codes := make(map[string]struct{})
+
+	for i := 0; i < 100; i++ {
+		code, err := randomAccessibleCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(code) != accessibleCodeLength {
+			t.Fatalf("Unexpected code length: %q", code)
+		}
+
+		for j := 0; j < len(code); j++ {
+			if _, ok := accessibleGlyphs[code[j]]; !ok || !strings.ContainsRune(accessibleCodeAlphabet, rune(code[j])) {
+				t.Fatalf("Unexpected character in code %q", code)
+			}
+		}
+
+		if normalizeAccessibleAnswer(code) != code {
+			t.Errorf("Code is not normalized: %q", code)
+		}
+
+		codes[code] = struct{}{}
+	}
+
+	if len(codes) < 95 {
+		t.Errorf("Too many repeated codes: %v unique", len(codes))
+	}
+}
+
+func TestAccessibleGlyphs(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []byte(accessibleCodeAlphabet) {
+		glyph, ok := accessibleGlyphs[c]
+		if !ok {
+			t.Fatalf("Missing glyph for %q", c)
+		}
+
+		for _, line := range glyph {
+			if len(line) != accessibleGlyphWidth {
+				t.Errorf("Unexpected glyph width for %q: %q", c, line)
+			}
+		}
+	}
+}
+
+func TestRenderAccessibleCode(t *testing.T) {
+	t.Parallel()
+
+	image, err := renderAccessibleCode("ACD349")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(image, accessibleImagePrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := 2*accessibleImageMargin + 6*accessibleCellWidth; img.Bounds().Dx() != expected {
+		t.Errorf("Unexpected image width %v (expected %v)", img.Bounds().Dx(), expected)
+	}
+}
+
+func TestAccessibleDifficulty(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		difficulty uint8
+		expected   uint8
+	}{
+		{uint8(common.DifficultyLevelHigh), uint8(common.DifficultyLevelSmall)},
+		{uint8(common.DifficultyLevelSmall), accessibleMinDifficulty},
+		{0, accessibleMinDifficulty},
+		{uint8(common.MaxDifficultyLevel), uint8(common.MaxDifficultyLevel) - accessibleDifficultyOffset},
+	}
+
+	for _, tc := range testCases {
+		if actual := accessibleDifficulty(tc.difficulty); actual != tc.expected {
+			t.Errorf("Unexpected accessible difficulty for %v: %v (expected %v)", tc.difficulty, actual, tc.expected)
+		}
+	}
+}
+
+func accessibleSuite(sitekey, domain string) (*http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req, err := http.NewRequest(http.MethodGet, "/"+common.AccessibleEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Origin", common_test.PrependProtocol(domain))
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	q := req.URL.Query()
+	q.Add(common.ParamSiteKey, sitekey)
+	req.URL.RawQuery = q.Encode()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+// accessibleChallengeSuite waits for the property to be backfilled and fetches accessible challenge for it
+func accessibleChallengeSuite(sitekey, domain string) (*accessibleChallengeResponse, error) {
+	if _, err := accessibleSuite(sitekey, domain); err != nil {
+		return nil, err
+	}
+
+	time.Sleep(3 * authBackfillDelay)
+
+	resp, err := accessibleSuite(sitekey, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected accessible status code %d", resp.StatusCode)
+	}
+
+	response := &accessibleChallengeResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(response.Image, accessibleImagePrefix) {
+		return nil, fmt.Errorf("Unexpected accessible challenge image")
+	}
+
+	return response, nil
+}
+
+func setupAccessibleSuite(ctx context.Context, username string, accessibleMode bool) (string, string, error) {
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, username, testPlan)
+	if err != nil {
+		return "", "", err
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       fmt.Sprintf("%v property", username),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if accessibleMode {
+		property, err = store.Impl().UpdateProperty(ctx, &dbgen.UpdatePropertyParams{
+			ID:               property.ID,
+			Name:             property.Name,
+			Level:            property.Level,
+			Growth:           property.Growth,
+			ValidityInterval: property.ValidityInterval,
+			Algorithm:        property.Algorithm,
+			WidgetChannel:    property.WidgetChannel,
+			DataRegion:       property.DataRegion,
+			AccessibleMode:   true,
+		})
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	apikey, err := store.Impl().CreateAPIKey(ctx, user.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		return "", "", err
+	}
+
+	return db.UUIDToSiteKey(property.ExternalID), db.UUIDToSecret(apikey.ExternalID), nil
+}
+
+// knownAnswerChallenge re-signs the issued challenge with the known code (instead of reading it from the image)
+// and solves its puzzle, returning the challenge and the solutions
+func knownAnswerChallenge(ctx context.Context, challenge, code string) (string, string, *puzzle.Puzzle, error) {
+	puzzleStr, _, _ := strings.Cut(challenge, ".")
+	data, err := base64.StdEncoding.DecodeString(puzzleStr)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	p := new(puzzle.Puzzle)
+	if err := p.UnmarshalBinary(data); err != nil {
+		return "", "", nil, err
+	}
+
+	payload, err := p.Serialize(ctx, s.Salt.Value(), accessibleAnswerSalt(code))
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := payload.Write(&buf); err != nil {
+		return "", "", nil, err
+	}
+
+	solver := &puzzle.Solver{}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return buf.String(), solutions.String(), p, nil
+}
+
+func accessiblePayload(answer, solutions, challenge string) string {
+	return accessiblePayloadPrefix + base64.StdEncoding.EncodeToString([]byte(answer)) + accessibleAnswerSeparator +
+		solutions + "." + challenge
+}
+
+func TestAccessibleDisabledByDefault(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	sitekey, _, err := setupAccessibleSuite(ctx, t.Name(), false /*accessible mode*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both before and after the property is backfilled
+	for i := 0; i < 2; i++ {
+		resp, err := accessibleSuite(sitekey, testPropertyDomain)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Unexpected status code %d", resp.StatusCode)
+		}
+
+		response := &apiErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+			t.Fatal(err)
+		}
+
+		if response.Error.Code != ErrorCodeAccessibleDisabled {
+			t.Errorf("Unexpected error code: %v", response.Error.Code)
+		}
+
+		time.Sleep(3 * authBackfillDelay)
+	}
+}
+
+func TestAccessibleTestProperty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	resp, err := accessibleSuite(db.TestPropertySitekey, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestVerifyAccessibleChallenge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	sitekey, apiKey, err := setupAccessibleSuite(ctx, t.Name(), true /*accessible mode*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := accessibleChallengeSuite(sitekey, testPropertyDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const code = "ACD349"
+	challenge, solutions, p, err := knownAnswerChallenge(ctx, response.Challenge, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (p.Difficulty >= uint8(common.DifficultyLevelMedium)) || (p.SolutionsCount == 0) {
+		t.Errorf("Unexpected accessible puzzle: difficulty=%v solutions=%v", p.Difficulty, p.SolutionsCount)
+	}
+
+	// answer alone is not enough without the solutions
+	resp, err := verifySuite(accessiblePayload(code, "", challenge), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.ParseResponseError); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = verifySuite(accessiblePayload(" acd 349 ", solutions, challenge), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	// accessible challenge can never be replayed
+	resp, err = verifySuite(accessiblePayload(code, solutions, challenge), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifiedBeforeError); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAccessibleWrongAnswer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	sitekey, apiKey, err := setupAccessibleSuite(ctx, t.Name(), true /*accessible mode*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := accessibleChallengeSuite(sitekey, testPropertyDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const code = "ACD349"
+	challenge, solutions, _, err := knownAnswerChallenge(ctx, response.Challenge, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := verifySuite(accessiblePayload("ACD347", solutions, challenge), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.InvalidSolutionError); err != nil {
+		t.Fatal(err)
+	}
+
+	// only one attempt is allowed per challenge
+	resp, err = verifySuite(accessiblePayload(code, solutions, challenge), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifiedBeforeError); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyPuzzleAsAccessible(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, _, err := setupVerifySuite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// proof-of-work payload cannot be passed off as an answer to accessible challenge
+	resp, err := verifySuite(accessiblePayloadPrefix+base64.StdEncoding.EncodeToString([]byte("ACD349"))+
+		accessibleAnswerSeparator+payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.ParseResponseError); err != nil {
+		t.Fatal(err)
+	}
+}
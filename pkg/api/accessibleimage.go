@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	randv2 "math/rand/v2"
+)
+
+const (
+	accessibleGlyphWidth  = 5
+	accessibleGlyphHeight = 7
+	accessibleGlyphScale  = 4
+	accessibleCellWidth   = accessibleGlyphWidth*accessibleGlyphScale + 8
+	accessibleImageMargin = 12
+	accessibleImageHeight = accessibleGlyphHeight*accessibleGlyphScale + 2*accessibleImageMargin
+	accessibleNoiseDots   = 350
+	accessibleNoiseLines  = 4
+	accessibleImagePrefix = "data:image/png;base64,"
+)
+
+// accessibleGlyphs is a bitmap font for the characters of accessible codes. Characters that are easy to confuse
+// with each other (0/O/Q/D-like, 1/I, 2/Z, 5/S, 6/G, 8/B) are not part of the alphabet
+var accessibleGlyphs = map[byte][accessibleGlyphHeight]string{
+	'A': {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'C': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	'E': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'H': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'J': {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M': {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N': {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'P': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'R': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'T': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'W': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X': {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+}
+
+func accessibleInkColor() color.NRGBA {
+	return color.NRGBA{R: uint8(randv2.IntN(90)), G: uint8(randv2.IntN(90)), B: uint8(randv2.IntN(90)), A: 0xff}
+}
+
+// drawAccessibleGlyph draws scaled character with random offset and slant, some of the "pixels" are left out
+func drawAccessibleGlyph(img *image.NRGBA, glyph [accessibleGlyphHeight]string, x0, y0 int) {
+	ink := accessibleInkColor()
+	slant := randv2.IntN(3) - 1
+
+	for row, line := range glyph {
+		shift := slant * (accessibleGlyphHeight/2 - row)
+		for col := 0; col < len(line); col++ {
+			if line[col] != '#' {
+				continue
+			}
+
+			for dy := 0; dy < accessibleGlyphScale; dy++ {
+				for dx := 0; dx < accessibleGlyphScale; dx++ {
+					if randv2.IntN(10) == 0 {
+						continue
+					}
+					img.SetNRGBA(x0+col*accessibleGlyphScale+dx+shift, y0+row*accessibleGlyphScale+dy, ink)
+				}
+			}
+		}
+	}
+}
+
+func drawAccessibleNoise(img *image.NRGBA) {
+	bounds := img.Bounds()
+
+	for i := 0; i < accessibleNoiseDots; i++ {
+		img.SetNRGBA(randv2.IntN(bounds.Dx()), randv2.IntN(bounds.Dy()), accessibleInkColor())
+	}
+
+	for i := 0; i < accessibleNoiseLines; i++ {
+		ink := accessibleInkColor()
+		y0, y1 := randv2.IntN(bounds.Dy()), randv2.IntN(bounds.Dy())
+		for x := 0; x < bounds.Dx(); x++ {
+			img.SetNRGBA(x, y0+(y1-y0)*x/bounds.Dx(), ink)
+		}
+	}
+}
+
+// renderAccessibleCode returns PNG image (as a data URL) of the code that can be read by a human, but not
+// looked up or computed by a script (unlike questions from a fixed set)
+func renderAccessibleCode(code string) (string, error) {
+	width := 2*accessibleImageMargin + len(code)*accessibleCellWidth
+	img := image.NewNRGBA(image.Rect(0, 0, width, accessibleImageHeight))
+
+	background := color.NRGBA{R: uint8(220 + randv2.IntN(36)), G: uint8(220 + randv2.IntN(36)), B: uint8(220 + randv2.IntN(36)), A: 0xff}
+	for y := 0; y < accessibleImageHeight; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, background)
+		}
+	}
+
+	for i := 0; i < len(code); i++ {
+		glyph, ok := accessibleGlyphs[code[i]]
+		if !ok {
+			continue
+		}
+
+		x0 := accessibleImageMargin + i*accessibleCellWidth + randv2.IntN(7) - 3
+		y0 := accessibleImageMargin + randv2.IntN(2*accessibleImageMargin-4) - (accessibleImageMargin - 2)
+		drawAccessibleGlyph(img, glyph, x0, y0)
+	}
+
+	drawAccessibleNoise(img)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+
+	return accessibleImagePrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	maxAllocatedProperties = 10_000
+)
+
+// propertyAllocation is the usage of the share of the owner's plan quota, allocated to the property, in current period
+type propertyAllocation struct {
+	Limit int64
+	Used  int64
+	Reset time.Time
+}
+
+func (a *propertyAllocation) Exceeded() bool {
+	return (a.Limit > 0) && (a.Used >= a.Limit)
+}
+
+// value is missing for properties without allocation
+func newAllocationCache() common.Cache[int32, *propertyAllocation] {
+	return db.NewMemoryCache[int32, *propertyAllocation](maxAllocatedProperties, nil /*missing value*/, db.HashInt32)
+}
+
+func (ul *baseUserLimiter) fetchPropertyAllocation(ctx context.Context, property *dbgen.Property, tnow time.Time) (*propertyAllocation, error) {
+	quota, err := ul.store.Impl().RetrievePropertyQuota(ctx, property.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := ul.store.Impl().RetrieveUser(ctx, property.OrgOwnerID.Int32)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.SubscriptionID.Valid {
+		return nil, db.ErrRecordNotFound
+	}
+
+	subscription, err := ul.store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := ul.planService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, ul.stage,
+		db.IsInternalSubscription(subscription.Source))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find billing plan for allocation", "userID", user.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	allocation := billing.Allocation{Percent: int(quota.Percent), Weekly: quota.Period == dbgen.QuotaPeriodWeek}
+
+	limit := allocation.Requests(plan.RequestsLimit())
+	if limit == 0 {
+		return nil, db.ErrRecordNotFound
+	}
+
+	from := allocation.PeriodStart(tnow)
+
+	stats, err := ul.timeSeries.RetrievePropertiesTotals(ctx, property.OrgID.Int32, []int32{property.ID}, from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &propertyAllocation{
+		Limit: limit,
+		Used:  int64(stats.RequestsCount),
+		Reset: allocation.PeriodEnd(tnow),
+	}, nil
+}
+
+// refreshPropertyAllocation is executed in the background to not query databases on the hot path
+func (ul *baseUserLimiter) refreshPropertyAllocation(property *dbgen.Property) {
+	ctx := common.TraceContext(context.Background(), "refresh_allocation")
+
+	allocation, err := ul.fetchPropertyAllocation(ctx, property, time.Now().UTC())
+	if err != nil {
+		if err == db.ErrRecordNotFound {
+			_ = ul.allocations.SetMissing(ctx, property.ID, quotaTTL)
+		} else {
+			slog.WarnContext(ctx, "Failed to fetch property allocation", "propID", property.ID, common.ErrAttr(err))
+		}
+		return
+	}
+
+	_ = ul.allocations.Set(ctx, property.ID, allocation, quotaTTL)
+
+	slog.DebugContext(ctx, "Refreshed property allocation", "propID", property.ID, "limit", allocation.Limit, "used", allocation.Used)
+
+	if allocation.Exceeded() {
+		slog.WarnContext(ctx, "Property exceeded allocated quota", "propID", property.ID, "limit", allocation.Limit,
+			"used", allocation.Used, "reset", allocation.Reset)
+	}
+}
+
+// PropertyQuotaExceeded checks if the property used up the share of the owner's quota that was allocated to it.
+// Usage is only refreshed in the background so unknown properties are never limited
+func (ul *baseUserLimiter) PropertyQuotaExceeded(ctx context.Context, property *dbgen.Property) bool {
+	allocation, err := ul.allocations.Get(ctx, property.ID)
+	if err == db.ErrCacheMiss {
+		// NOTE: we put a placeholder in order to not refresh concurrently for the same property
+		_ = ul.allocations.SetMissing(ctx, property.ID, quotaMissingTTL)
+		go ul.refreshPropertyAllocation(property)
+		return false
+	}
+
+	if (err != nil) || (allocation == nil) {
+		return false
+	}
+
+	return allocation.Exceeded()
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	apiKeyUsageBatchSize = 100
+	apiKeyUsageInterval  = 10 * time.Second
+)
+
+// TrackAPIKeyUsage periodically persists usage counters of API keys that are checked for anomalies by a background job
+func (am *AuthMiddleware) TrackAPIKeyUsage(metrics common.BatchMetrics) {
+	var usageCtx context.Context
+	usageCtx, am.UsageCancel = context.WithCancel(
+		context.WithValue(context.Background(), common.TraceIDContextKey, "apikey_usage"))
+	go common.ProcessBatchArray(usageCtx, common.BatchPipelineAPIKeyUsage, metrics, am.APIKeyUsageChan, apiKeyUsageInterval,
+		apiKeyUsageBatchSize, apiKeyUsageBatchSize*100, am.writeAPIKeyUsage)
+}
+
+func (am *AuthMiddleware) writeAPIKeyUsage(ctx context.Context, records []*common.APIKeyRecord) error {
+	return am.Store.Impl().AddAPIKeyUsage(ctx, records)
+}
+
+// recordAPIKeyUsage is best-effort: usage is not worth delaying API requests when the queue is full
+func (am *AuthMiddleware) recordAPIKeyUsage(ctx context.Context, wrongOwner bool) {
+	apiKey, ok := ctx.Value(common.APIKeyContextKey).(*dbgen.APIKey)
+	if !ok || (apiKey == nil) {
+		return
+	}
+
+	record := &common.APIKeyRecord{
+		APIKeyID:   apiKey.ID,
+		Timestamp:  time.Now().UTC(),
+		WrongOwner: wrongOwner,
+	}
+
+	select {
+	case am.APIKeyUsageChan <- record:
+	default:
+		slog.WarnContext(ctx, "Dropping API key usage record", "keyID", apiKey.ID)
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestRecordAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	am := &AuthMiddleware{APIKeyUsageChan: make(chan *common.APIKeyRecord, 1)}
+
+	// requests without API key are not recorded
+	am.recordAPIKeyUsage(context.TODO(), false /*wrong owner*/)
+	if len(am.APIKeyUsageChan) != 0 {
+		t.Fatal("Usage was recorded without API key")
+	}
+
+	ctx := context.WithValue(context.TODO(), common.APIKeyContextKey, &dbgen.APIKey{ID: 123})
+	am.recordAPIKeyUsage(ctx, true /*wrong owner*/)
+	// full queue should not block
+	am.recordAPIKeyUsage(ctx, false /*wrong owner*/)
+
+	record := <-am.APIKeyUsageChan
+	if (record.APIKeyID != 123) || !record.WrongOwner {
+		t.Errorf("Unexpected usage record: %+v", record)
+	}
+}
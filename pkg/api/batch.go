@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	maxBatchBodySize           = 4 * 1024 * 1024
+	maxVerifyBatchItems        = 1000
+	maxConcurrentVerifyBatches = 4
+	verifyBatchTimeout         = 30 * time.Second
+	verifyBatchRetryAfter      = 1 * time.Second
+	batchResultOK              = "ok"
+	batchResultOverloaded      = "overloaded"
+	batchResultTooLarge        = "too_large"
+	batchResultItemTooLarge    = "item_too_large"
+	batchResultTooManyItems    = "too_many_items"
+	batchResultBadRequest      = "bad_request"
+	batchResultError           = "error"
+)
+
+var (
+	errBatchNotArray     = errors.New("batch is not an array of strings")
+	errBatchTooManyItems = errors.New("batch contains too many items")
+	errBatchItemTooLarge = errors.New("batch item is too large")
+	headerRetryAfter     = http.CanonicalHeaderKey("Retry-After")
+)
+
+type VerifyBatchResponse struct {
+	// results are in the same order as solutions in the request
+	Results []*VerifyResponseRecaptchaV2 `json:"results"`
+}
+
+// decodeVerifyBatch reads JSON array of verify payloads token by token instead of buffering the whole body, so
+// that reading stops as soon as any limit is breached. Memory is bounded by the body limit of the route
+func decodeVerifyBatch(r io.Reader, maxItems int, maxItemSize int64) ([]string, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || (delim != '[') {
+		return nil, errBatchNotArray
+	}
+
+	payloads := make([]string, 0, min(maxItems, VerifyBatchSize))
+
+	for dec.More() {
+		if len(payloads) >= maxItems {
+			return nil, errBatchTooManyItems
+		}
+
+		offset := dec.InputOffset()
+
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		payload, ok := tok.(string)
+		if !ok {
+			return nil, errBatchNotArray
+		}
+
+		if dec.InputOffset()-offset > maxItemSize {
+			return nil, errBatchItemTooLarge
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	// closing bracket
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return payloads, nil
+}
+
+func (s *Server) verifyBatchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// backpressure: batches are expensive so we reject new ones instead of queueing them when busy
+	defer s.verifyBatches.Add(-1)
+	if inFlight := s.verifyBatches.Add(1); inFlight > maxConcurrentVerifyBatches {
+		slog.WarnContext(ctx, "Rejecting verify batch due to load", "inFlight", inFlight)
+		s.Metrics.ObserveVerifyBatch(batchResultOverloaded, 0)
+		w.Header().Set(headerRetryAfter, strconv.Itoa(int(verifyBatchRetryAfter.Seconds())))
+		sendError(ctx, w, http.StatusServiceUnavailable, ErrorCodeOverloaded)
+		return
+	}
+
+	payloads, err := decodeVerifyBatch(r.Body, maxVerifyBatchItems, s.verifyMaxBytes.Load(maxSolutionsBodySize))
+	if err != nil {
+		s.sendBatchError(ctx, w, err)
+		return
+	}
+
+	if outcome := r.URL.Query().Get(common.ParamTestOutcome); len(outcome) > 0 {
+		ctx = context.WithValue(ctx, common.TestOutcomeContextKey, outcome)
+	}
+
+	response := &VerifyBatchResponse{Results: make([]*VerifyResponseRecaptchaV2, 0, len(payloads))}
+	tnow := time.Now().UTC()
+
+	for _, payload := range payloads {
+		if err := ctx.Err(); err != nil {
+			slog.WarnContext(ctx, "Verify batch was cancelled", "processed", len(response.Results), "items", len(payloads))
+			s.Metrics.ObserveVerifyBatch(batchResultError, len(payloads))
+			return
+		}
+
+		p, verr, err := s.Verify(ctx, payload, &apiKeyOwnerSource{}, tnow)
+		if err != nil {
+			s.Metrics.ObserveVerifyBatch(batchResultError, len(payloads))
+			sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+			return
+		}
+
+		response.Results = append(response.Results, s.withReceipt(ctx, r, s.verifyResponse(ctx, p, verr)))
+	}
+
+	s.Metrics.ObserveVerifyBatch(batchResultOK, len(payloads))
+
+	s.writeQuotaHeaders(ctx, w)
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) sendBatchError(ctx context.Context, w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		slog.WarnContext(ctx, "Verify batch is too large", "limit", maxBytesErr.Limit)
+		s.Metrics.ObserveVerifyBatch(batchResultTooLarge, 0)
+		sendError(ctx, w, http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge)
+	case err == errBatchItemTooLarge:
+		slog.WarnContext(ctx, "Verify batch item is too large")
+		s.Metrics.ObserveVerifyBatch(batchResultItemTooLarge, 0)
+		sendError(ctx, w, http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge)
+	case err == errBatchTooManyItems:
+		slog.WarnContext(ctx, "Verify batch has too many items", "limit", maxVerifyBatchItems)
+		s.Metrics.ObserveVerifyBatch(batchResultTooManyItems, 0)
+		sendError(ctx, w, http.StatusRequestEntityTooLarge, ErrorCodeTooManyItems)
+	default:
+		slog.WarnContext(ctx, "Failed to decode verify batch", common.ErrAttr(err))
+		s.Metrics.ObserveVerifyBatch(batchResultBadRequest, 0)
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeBadRequest)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestDecodeVerifyBatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		body  string
+		items int
+		err   error
+	}{
+		{`[]`, 0, nil},
+		{`["a", "b", "c"]`, 3, nil},
+		{` [ "abc" ] `, 1, nil},
+		{`["a", "b", "c", "d", "e"]`, 0, errBatchTooManyItems},
+		{`["a", "` + strings.Repeat("b", 100) + `"]`, 0, errBatchItemTooLarge},
+		{`{"a": "b"}`, 0, errBatchNotArray},
+		{`["a", 1]`, 0, errBatchNotArray},
+		{`["a", ["b"]]`, 0, errBatchNotArray},
+		{`"abc"`, 0, errBatchNotArray},
+	}
+
+	for i, tc := range testCases {
+		payloads, err := decodeVerifyBatch(strings.NewReader(tc.body), 4 /*max items*/, 64 /*max item size*/)
+		if err != tc.err {
+			t.Errorf("Unexpected error in case %d: %v (expected %v)", i, err, tc.err)
+			continue
+		}
+
+		if len(payloads) != tc.items {
+			t.Errorf("Unexpected number of items in case %d: %d (expected %d)", i, len(payloads), tc.items)
+		}
+	}
+
+	// truncated input is an error
+	if _, err := decodeVerifyBatch(strings.NewReader(`["a", "b"`), 4, 64); err == nil {
+		t.Error("Expected error for truncated batch")
+	}
+}
+
+func verifyBatchSuite(body, secret string) (*http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req, err := http.NewRequest(http.MethodPost, "/"+common.VerifyEndpoint+"/"+common.BatchEndpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderAPIKey, secret)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+func TestVerifyBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, _, err := setupVerifySuite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := json.Marshal([]string{payload, payload, "garbage"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := verifyBatchSuite(string(body), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	response := &VerifyBatchResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Results) != 3 {
+		t.Fatalf("Unexpected number of results: %d", len(response.Results))
+	}
+
+	if !response.Results[0].Success {
+		t.Errorf("Expected first item to be verified: %v", response.Results[0].ErrorCodes)
+	}
+
+	expected := []puzzle.VerifyError{puzzle.VerifiedBeforeError, puzzle.ParseResponseError}
+	for i, verr := range expected {
+		result := response.Results[i+1]
+		if result.Success || (len(result.ErrorCodes) != 1) || (result.ErrorCodes[0] != verr.String()) {
+			t.Errorf("Unexpected result of item %d: %v (expected %v)", i+1, result.ErrorCodes, verr.String())
+		}
+	}
+}
+
+func TestVerifyBatchTooManyItems(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	_, apiKey, _, err := setupVerifySuite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := make([]string, maxVerifyBatchItems+1)
+	for i := range items {
+		items[i] = "a"
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := verifyBatchSuite(string(body), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+}
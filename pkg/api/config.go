@@ -0,0 +1,149 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	// signature of the config stays valid for much longer than it is cached
+	widgetConfigTTL = 24 * time.Hour
+)
+
+var (
+	widgetConfigCachedHeaders = map[string][]string{
+		http.CanonicalHeaderKey("Cache-Control"): []string{"public, max-age=3600"},
+	}
+)
+
+// widgetConfig is bootstrap data that widget can use to render before fetching a puzzle
+type widgetConfig struct {
+	Sitekey string `json:"sitekey"`
+	// base difficulty of the property, actual puzzle difficulty can be higher
+	Difficulty uint8  `json:"difficulty"`
+	Theme      string `json:"theme,omitempty"`
+	Locale     string `json:"locale,omitempty"`
+	// for how long (in seconds) solved puzzle can be verified
+	Validity  int64 `json:"validity"`
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+type widgetConfigResponse struct {
+	Config *widgetConfig `json:"config"`
+	// base64-encoded HMAC of JSON-serialized config, keyed with the puzzle salt
+	Signature string `json:"signature"`
+}
+
+func signWidgetConfig(salt *puzzle.Salt, config *widgetConfig) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, salt.Data())
+	mac.Write(data)
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifyWidgetConfig(salt *puzzle.Salt, config *widgetConfig, signature string, tnow time.Time) bool {
+	if tnow.Unix() > config.ExpiresAt {
+		return false
+	}
+
+	expected, err := signWidgetConfig(salt, config)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func newWidgetConfig(sitekey string, property *dbgen.Property, messages *dbgen.PropertyMessage, tnow time.Time) *widgetConfig {
+	config := &widgetConfig{
+		Sitekey:    sitekey,
+		Difficulty: uint8(common.DifficultyLevelMedium),
+		Validity:   int64(puzzle.DefaultValidityPeriod.Seconds()),
+		ExpiresAt:  tnow.Add(widgetConfigTTL).Unix(),
+	}
+
+	if property != nil {
+		config.Difficulty = uint8(property.Level.Int16)
+		config.Validity = int64(property.ValidityInterval.Seconds())
+	}
+
+	if messages != nil {
+		config.Theme = messages.Theme
+		config.Locale = messages.Locale
+	}
+
+	return config
+}
+
+// configHandler returns signed widget bootstrap data. Same as /status, it only uses cached data so properties that
+// are not cached yet get default (and not cached on the client) config until they are backfilled
+func (s *Server) configHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sitekey := r.URL.Query().Get(common.ParamSiteKey)
+	if !isSiteKeyValid(sitekey) {
+		slog.Log(ctx, common.LevelTrace, "Sitekey is not valid for config request")
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
+		return
+	}
+
+	impl := s.BusinessDB.Impl()
+	cached := true
+
+	property, err := impl.GetCachedPropertyBySitekey(ctx, sitekey)
+	if err != nil {
+		switch err {
+		case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
+			sendError(ctx, w, http.StatusForbidden, ErrorCodeSitekeyNotFound)
+			return
+		case db.ErrInvalidInput:
+			sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
+			return
+		case db.ErrTestProperty:
+			// BUMP
+		case db.ErrCacheMiss:
+			cached = false
+			s.Auth.SitekeyChan <- sitekey
+		default:
+			sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+			return
+		}
+	}
+
+	var messages *dbgen.PropertyMessage
+	if property != nil {
+		if m, err := impl.RetrievePropertyMessages(ctx, property.ID); err == nil {
+			messages = m
+		}
+	}
+
+	config := newWidgetConfig(sitekey, property, messages, time.Now())
+	signature, err := signWidgetConfig(s.Salt.Value(), config)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign widget config", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+		return
+	}
+
+	headers := widgetConfigCachedHeaders
+	if !cached {
+		headers = common.NoCacheHeaders
+	}
+
+	common.SendJSONResponse(ctx, w, &widgetConfigResponse{Config: config, Signature: signature}, headers)
+}
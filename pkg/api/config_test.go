@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestWidgetConfigSignature(t *testing.T) {
+	t.Parallel()
+
+	salt := puzzle.NewSalt([]byte("salt"))
+	tnow := time.Now()
+
+	config := newWidgetConfig(db.TestPropertySitekey, &dbgen.Property{
+		ValidityInterval: 2 * time.Hour,
+	}, &dbgen.PropertyMessage{Theme: "dark"}, tnow)
+
+	signature, err := signWidgetConfig(salt, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !verifyWidgetConfig(salt, config, signature, tnow) {
+		t.Error("Signature is not valid")
+	}
+
+	if verifyWidgetConfig(puzzle.NewSalt([]byte("other")), config, signature, tnow) {
+		t.Error("Signature is valid with another salt")
+	}
+
+	if verifyWidgetConfig(salt, config, signature, tnow.Add(widgetConfigTTL+time.Minute)) {
+		t.Error("Expired signature is valid")
+	}
+
+	config.Theme = "light"
+	if verifyWidgetConfig(salt, config, signature, tnow) {
+		t.Error("Signature is valid for modified config")
+	}
+}
+
+func configSuite(sitekey, domain string) (*widgetConfigResponse, *http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req, err := http.NewRequest(http.MethodGet, "/"+common.ConfigEndpoint+"?"+common.ParamSiteKey+"="+sitekey, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Origin", common_test.PrependProtocol(domain))
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp, nil
+	}
+
+	response := &widgetConfigResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, resp, err
+	}
+
+	return response, resp, nil
+}
+
+func TestWidgetConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       t.Name(),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelHigh)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Impl().UpdatePropertyMessages(ctx, &dbgen.UpsertPropertyMessagesParams{
+		PropertyID: property.ID,
+		Theme:      "dark",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	response, resp, err := configSuite(db.UUIDToSiteKey(property.ExternalID), property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response == nil {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	if (response.Config.Theme != "dark") || (response.Config.Difficulty != uint8(common.DifficultyLevelHigh)) {
+		t.Errorf("Unexpected config: %+v", response.Config)
+	}
+
+	if resp.Header.Get("Cache-Control") == common.NoCacheHeaders["Cache-Control"][0] {
+		t.Error("Config of cached property is not cached")
+	}
+
+	if !verifyWidgetConfig(s.Salt.Value(), response.Config, response.Signature, time.Now()) {
+		t.Error("Config signature is not valid")
+	}
+}
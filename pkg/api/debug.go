@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	debugCheckParse      = "parse"
+	debugCheckExpiration = "expiration"
+	debugCheckSignature  = "signature"
+	debugCheckReplay     = "replay"
+	debugCheckProperty   = "property"
+	debugCheckOwner      = "owner"
+	debugCheckSolutions  = "solutions"
+)
+
+type debugPuzzle struct {
+	Version        uint8           `json:"version"`
+	Difficulty     uint8           `json:"difficulty"`
+	SolutionsCount uint8           `json:"solutions_count"`
+	Sitekey        string          `json:"sitekey"`
+	PuzzleID       string          `json:"puzzle_id"`
+	Expiration     common.JSONTime `json:"expiration"`
+	Action         string          `json:"action,omitempty"`
+}
+
+type debugCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// error code that siteverify returns when this check fails
+	Error string `json:"error,omitempty"`
+}
+
+type debugParseResponse struct {
+	Puzzle         *debugPuzzle  `json:"puzzle,omitempty"`
+	SignatureValid bool          `json:"signature_valid"`
+	Checks         []*debugCheck `json:"checks"`
+	// first failed check, i.e. the one that siteverify would report
+	FailedCheck string `json:"failed_check,omitempty"`
+	Success     bool   `json:"success"`
+}
+
+func (r *debugParseResponse) add(name string, verr puzzle.VerifyError) {
+	check := &debugCheck{Name: name, Passed: verr == puzzle.VerifyNoError}
+	if !check.Passed {
+		check.Error = verr.String()
+		if len(r.FailedCheck) == 0 {
+			r.FailedCheck = name
+		}
+	}
+
+	r.Checks = append(r.Checks, check)
+}
+
+func (r *debugParseResponse) addSignature(err error) {
+	r.SignatureValid = (err == nil)
+
+	if r.SignatureValid {
+		r.add(debugCheckSignature, puzzle.VerifyNoError)
+	} else {
+		r.add(debugCheckSignature, puzzle.IntegrityError)
+	}
+}
+
+// debugEndpointsEnabled protects production from exposing verification internals
+func debugEndpointsEnabled(stage string) bool {
+	return (stage == common.StageDev) || (stage == common.StageStaging) || (stage == common.StageTest)
+}
+
+// debugParse evaluates the same checks as siteverify, but without any side effects (puzzle is not marked as used,
+// verification is not recorded, quota is not consumed) and reports every one of them instead of the first failure
+func (s *Server) debugParse(ctx context.Context, payload string, expectedOwner puzzle.OwnerIDSource, tnow time.Time) *debugParseResponse {
+	result := &debugParseResponse{Checks: make([]*debugCheck, 0)}
+
+	if strings.HasPrefix(payload, accessiblePayloadPrefix) {
+		slog.DebugContext(ctx, "Accessible challenge payloads are not supported by debug parser")
+		result.add(debugCheckParse, puzzle.ParseResponseError)
+		return result
+	}
+
+	verifyPayload, err := puzzle.ParseVerifyPayload(ctx, payload)
+	if err != nil {
+		result.add(debugCheckParse, puzzle.ParseResponseError)
+		return result
+	}
+	result.add(debugCheckParse, puzzle.VerifyNoError)
+
+	p := verifyPayload.Puzzle()
+	result.Puzzle = &debugPuzzle{
+		Version:        p.Version,
+		Difficulty:     p.Difficulty,
+		SolutionsCount: p.SolutionsCount,
+		Sitekey:        db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: p.PropertyID}),
+		PuzzleID:       strconv.FormatUint(p.PuzzleID, 10),
+		Expiration:     common.JSONTime(p.Expiration),
+		Action:         p.Action,
+	}
+
+	if p.IsZero() && bytes.Equal(p.PropertyID[:], db.TestPropertyUUID.Bytes[:]) {
+		result.add(debugCheckProperty, puzzle.TestPropertyError)
+		return result
+	}
+
+	if tnow.Before(p.Expiration) {
+		result.add(debugCheckExpiration, puzzle.VerifyNoError)
+	} else {
+		result.add(debugCheckExpiration, puzzle.PuzzleExpiredError)
+	}
+
+	// signature of puzzles with extra salt can only be checked with the property salt
+	if !verifyPayload.NeedsExtraSalt() {
+		result.addSignature(s.Salt.Verify(ctx, verifyPayload, nil /*extra salt*/))
+	}
+
+	if s.BusinessDB.CheckPuzzleCached(ctx, p) {
+		result.add(debugCheckReplay, puzzle.VerifiedBeforeError)
+	} else {
+		result.add(debugCheckReplay, puzzle.VerifyNoError)
+	}
+
+	var property *dbgen.Property
+	sitekey := result.Puzzle.Sitekey
+	properties, err := s.Auth.verifyImpl().RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey: {}})
+	switch {
+	case (err == nil) && (len(properties) == 1):
+		property = properties[0]
+		result.add(debugCheckProperty, puzzle.VerifyNoError)
+	case (err == db.ErrNegativeCacheHit) || (err == db.ErrRecordNotFound) || (err == db.ErrSoftDeleted):
+		result.add(debugCheckProperty, puzzle.InvalidPropertyError)
+	case err == db.ErrMaintenance:
+		result.add(debugCheckProperty, puzzle.MaintenanceModeError)
+	default:
+		slog.ErrorContext(ctx, "Failed to find property by sitekey", "sitekey", sitekey, common.ErrAttr(err))
+		result.add(debugCheckProperty, puzzle.VerifyErrorOther)
+	}
+
+	if verifyPayload.NeedsExtraSalt() && (property != nil) {
+		result.addSignature(s.Salt.Verify(ctx, verifyPayload, property.Salt))
+	}
+
+	if property != nil {
+		if ownerID, err := expectedOwner.OwnerID(ctx); ((err == nil) && (property.OrgOwnerID.Int32 != ownerID)) ||
+			isOutsideOwnerOrg(ctx, expectedOwner, property) {
+			result.add(debugCheckOwner, puzzle.WrongOwnerError)
+		} else {
+			result.add(debugCheckOwner, puzzle.VerifyNoError)
+		}
+	}
+
+	_, verr := verifyPayload.VerifySolutions(ctx)
+	result.add(debugCheckSolutions, verr)
+
+	result.Success = len(result.FailedCheck) == 0
+
+	return result
+}
+
+func (s *Server) debugParseHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeBadRequest)
+		return
+	}
+
+	result := s.debugParse(ctx, string(data), &apiKeyOwnerSource{}, time.Now().UTC())
+
+	common.SendJSONResponse(ctx, w, result, common.NoCacheHeaders)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func debugParseSuite(payload, secret string) (*debugParseResponse, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req := httptest.NewRequest(http.MethodPost, "/"+common.DebugEndpoint+"/"+common.ParseEndpoint, strings.NewReader(payload))
+	req.Header.Set(common.HeaderAPIKey, secret)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", w.Code)
+	}
+
+	response := &debugParseResponse{}
+	if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func TestDebugEndpointsEnabled(t *testing.T) {
+	t.Parallel()
+
+	if !debugEndpointsEnabled(common.StageDev) || !debugEndpointsEnabled(common.StageTest) {
+		t.Error("Debug endpoints are expected in non-production stages")
+	}
+
+	if debugEndpointsEnabled("prod") || debugEndpointsEnabled("") {
+		t.Error("Debug endpoints are not expected in production")
+	}
+}
+
+func TestDebugParse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, sitekey, err := setupVerifySuite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := debugParseSuite(payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !response.Success || !response.SignatureValid || (len(response.FailedCheck) > 0) ||
+		(response.Puzzle == nil) || (response.Puzzle.Sitekey != sitekey) {
+		t.Errorf("Unexpected debug parse response: %+v", response)
+	}
+
+	// debug parse does not "use" the puzzle so it can be still verified
+	resp, err := verifySuite(payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = debugParseSuite(payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Success || (response.FailedCheck != debugCheckReplay) {
+		t.Errorf("Unexpected failed check after verification: %v", response.FailedCheck)
+	}
+
+	response, err = debugParseSuite("not a payload", apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Success || (response.FailedCheck != debugCheckParse) || (response.Puzzle != nil) {
+		t.Errorf("Unexpected response for invalid payload: %+v", response)
+	}
+}
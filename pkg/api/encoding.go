@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+	// regular puzzles are ~200 bytes and compression does not pay off for them: it costs ~10us of CPU to save a few
+	// dozen bytes on the wire (see BenchmarkWriteEncoded*), so only larger payload variants are compressed
+	minCompressSize = 1024
+)
+
+// payloads are base64 of high-entropy data, so string matching finds nothing (and lower levels of compress/flate
+// end up with stored blocks) while Huffman coding of the 64-character alphabet still saves ~25%
+var (
+	gzipWriters = sync.Pool{
+		New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, gzip.HuffmanOnly)
+			return w
+		},
+	}
+	deflateWriters = sync.Pool{
+		New: func() any {
+			w, _ := flate.NewWriter(io.Discard, flate.HuffmanOnly)
+			return w
+		},
+	}
+)
+
+// negotiateEncoding picks the compression from Accept-Encoding header, preferring gzip. Empty result means identity
+func negotiateEncoding(acceptEncoding string) string {
+	if len(acceptEncoding) == 0 {
+		return ""
+	}
+
+	var gzipQ, deflateQ, anyQ float64 = -1, -1, -1
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case encodingGzip, "x-gzip":
+			gzipQ = q
+		case encodingDeflate:
+			deflateQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+
+	// wildcard only applies to codings that were not listed explicitly
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	if deflateQ < 0 {
+		deflateQ = anyQ
+	}
+
+	switch {
+	case (gzipQ > 0) && (gzipQ >= deflateQ):
+		return encodingGzip
+	case deflateQ > 0:
+		return encodingDeflate
+	default:
+		return ""
+	}
+}
+
+// writeEncoded writes data compressed with encoding (which must be one returned by negotiateEncoding)
+func writeEncoded(w io.Writer, encoding string, data []byte) error {
+	switch encoding {
+	case encodingGzip:
+		gw := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(gw)
+		gw.Reset(w)
+		if _, err := gw.Write(data); err != nil {
+			return err
+		}
+		return gw.Close()
+	case encodingDeflate:
+		fw := deflateWriters.Get().(*flate.Writer)
+		defer deflateWriters.Put(fw)
+		fw.Reset(w)
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+		return fw.Close()
+	default:
+		_, err := w.Write(data)
+		return err
+	}
+}
+
+// writePuzzlePayload writes payload, compressing it if it is large enough and client supports it (acceptEncoding is
+// the value of Accept-Encoding header). Headers that do not depend on the encoding have to be set by the caller before
+func writePuzzlePayload(w http.ResponseWriter, acceptEncoding string, payload *puzzle.PuzzlePayload) error {
+	if payload.Size() < minCompressSize {
+		return payload.Write(w)
+	}
+
+	w.Header().Add(common.HeaderVary, common.HeaderAcceptEncoding)
+
+	encoding := negotiateEncoding(acceptEncoding)
+	if len(encoding) == 0 {
+		return payload.Write(w)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(payload.Size())
+	_ = payload.Write(&buf)
+
+	w.Header().Set(common.HeaderContentEncoding, encoding)
+
+	return writeEncoded(w, encoding, buf.Bytes())
+}
+
+// payloadETag returns a strong validator for the payload that never changes while server is running (test puzzle)
+func payloadETag(payload *puzzle.PuzzlePayload) string {
+	hasher := sha256.New()
+	_ = payload.Write(hasher)
+	return `"` + hex.EncodeToString(hasher.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches implements (weak) comparison of If-None-Match header against the etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if len(ifNoneMatch) == 0 {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", encodingGzip},
+		{"deflate", encodingDeflate},
+		{"gzip, deflate, br", encodingGzip},
+		{"deflate, gzip;q=0.5", encodingDeflate},
+		{"gzip;q=0, deflate", encodingDeflate},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"*", encodingGzip},
+		{"*;q=0", ""},
+		{"gzip;q=0, *", encodingDeflate},
+		{"X-GZIP", encodingGzip},
+	}
+
+	for _, tc := range testCases {
+		if actual := negotiateEncoding(tc.header); actual != tc.expected {
+			t.Errorf("Unexpected encoding for %q: %q (expected %q)", tc.header, actual, tc.expected)
+		}
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"abc"`
+
+	testCases := []struct {
+		header   string
+		expected bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`"xyz"`, false},
+		{"*", true},
+	}
+
+	for _, tc := range testCases {
+		if actual := etagMatches(tc.header, etag); actual != tc.expected {
+			t.Errorf("Unexpected result for %q: %v", tc.header, actual)
+		}
+	}
+}
+
+func randomPayload(size int) []byte {
+	data := make([]byte, base64.StdEncoding.DecodedLen(size))
+	_, _ = rand.Read(data)
+	return []byte(base64.StdEncoding.EncodeToString(data))
+}
+
+func TestWriteEncoded(t *testing.T) {
+	data := randomPayload(4096)
+
+	for _, encoding := range []string{encodingGzip, encodingDeflate, ""} {
+		var buf bytes.Buffer
+		if err := writeEncoded(&buf, encoding, data); err != nil {
+			t.Fatal(err)
+		}
+
+		var reader io.Reader
+		switch encoding {
+		case encodingGzip:
+			gr, err := gzip.NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gr
+		case encodingDeflate:
+			reader = flate.NewReader(&buf)
+		default:
+			reader = &buf
+		}
+
+		actual, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(actual, data) {
+			t.Errorf("Payload was corrupted with encoding %q", encoding)
+		}
+	}
+}
+
+func TestTestPuzzleETag(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	send := func(ifNoneMatch string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/"+common.PuzzleEndpoint+"?"+common.ParamSiteKey+"="+db.TestPropertySitekey, nil)
+		req.Header.Set("Origin", common_test.PrependProtocol("localhost"))
+		req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+		if len(ifNoneMatch) > 0 {
+			req.Header.Set(common.HeaderIfNoneMatch, ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp := send("")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	etag := resp.Header.Get(common.HeaderETag)
+	if len(etag) == 0 {
+		t.Fatal("ETag is missing")
+	}
+
+	if resp := send(etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Unexpected status code for matching ETag %d", resp.StatusCode)
+	}
+
+	if resp := send(`"stale"`); resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code for stale ETag %d", resp.StatusCode)
+	}
+}
+
+func benchmarkWriteEncoded(b *testing.B, encoding string, size int) {
+	data := randomPayload(size)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	var written int64
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		_ = writeEncoded(&buf, encoding, data)
+		written += int64(buf.Len())
+	}
+
+	// bandwidth savings side of the tradeoff, CPU cost is ns/op
+	b.ReportMetric(float64(written)/float64(b.N), "wire-bytes/op")
+}
+
+// regular puzzle payload size
+func BenchmarkWriteEncodedPlain256(b *testing.B)   { benchmarkWriteEncoded(b, "", 256) }
+func BenchmarkWriteEncodedGzip256(b *testing.B)    { benchmarkWriteEncoded(b, encodingGzip, 256) }
+func BenchmarkWriteEncodedDeflate256(b *testing.B) { benchmarkWriteEncoded(b, encodingDeflate, 256) }
+
+// larger payload variants
+func BenchmarkWriteEncodedPlain4K(b *testing.B)   { benchmarkWriteEncoded(b, "", 4096) }
+func BenchmarkWriteEncodedGzip4K(b *testing.B)    { benchmarkWriteEncoded(b, encodingGzip, 4096) }
+func BenchmarkWriteEncodedDeflate4K(b *testing.B) { benchmarkWriteEncoded(b, encodingDeflate, 4096) }
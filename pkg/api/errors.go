@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+// ErrorCode is a machine-readable identifier of the API error. Values are part of the public API contract and
+// must never be changed or reused for a different meaning, only new ones can be added
+type ErrorCode string
+
+const (
+	// ErrorCodeBadRequest is returned when request cannot be parsed and there's no more specific code
+	ErrorCodeBadRequest ErrorCode = "bad_request"
+	// ErrorCodeMissingOrigin is returned when Origin (or Referer for fallback) header is missing
+	ErrorCodeMissingOrigin ErrorCode = "missing_origin"
+	// ErrorCodeInvalidOrigin is returned when Origin header cannot be parsed as a domain name
+	ErrorCodeInvalidOrigin ErrorCode = "invalid_origin"
+	// ErrorCodeOriginNotAllowed is returned when Origin does not match property's domain and allowed origins
+	ErrorCodeOriginNotAllowed ErrorCode = "origin_not_allowed"
+	// ErrorCodeInvalidSitekey is returned when sitekey has an invalid format
+	ErrorCodeInvalidSitekey ErrorCode = "invalid_sitekey"
+	// ErrorCodeSitekeyNotFound is returned when sitekey does not belong to any (active) property
+	ErrorCodeSitekeyNotFound ErrorCode = "sitekey_not_found"
+	// ErrorCodeSubscriptionInactive is returned when property owner does not have an active subscription
+	ErrorCodeSubscriptionInactive ErrorCode = "subscription_inactive"
+	// ErrorCodeQuotaExceeded is returned when property owner has exceeded their usage limits
+	ErrorCodeQuotaExceeded ErrorCode = "quota_exceeded"
+	// ErrorCodeInvalidAPIKey is returned when API key header is missing or has an invalid format
+	ErrorCodeInvalidAPIKey ErrorCode = "invalid_api_key"
+	// ErrorCodeUnauthorized is returned when API key is unknown, disabled or expired
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	// ErrorCodeInternal is returned for all server-side failures
+	ErrorCodeInternal ErrorCode = "internal_error"
+	// ErrorCodePayloadTooLarge is returned when request body (or any item of the batch) exceeds the size limit
+	ErrorCodePayloadTooLarge ErrorCode = "payload_too_large"
+	// ErrorCodeTooManyItems is returned when batch contains more items than allowed
+	ErrorCodeTooManyItems ErrorCode = "too_many_items"
+	// ErrorCodeOverloaded is returned when server cannot accept more requests of this kind right now
+	ErrorCodeOverloaded ErrorCode = "overloaded"
+	// ErrorCodeInvalidAction is returned when action parameter is too long or contains unsupported characters
+	ErrorCodeInvalidAction ErrorCode = "invalid_action"
+	// ErrorCodePropertyPaused is returned when property owner paused puzzle issuance
+	ErrorCodePropertyPaused ErrorCode = "property_paused"
+	// ErrorCodeDomainNotVerified is returned when deployment requires domain verification and property did not pass it
+	ErrorCodeDomainNotVerified ErrorCode = "domain_not_verified"
+	// ErrorCodeAppNotAllowed is returned when native app identifier is not allowed for the property (or property is not in mobile mode)
+	ErrorCodeAppNotAllowed ErrorCode = "app_not_allowed"
+	// ErrorCodeAttestationFailed is returned when native app attestation was rejected
+	ErrorCodeAttestationFailed ErrorCode = "attestation_failed"
+	// ErrorCodeAccessibleDisabled is returned when accessible challenge is requested for property without accessible mode
+	ErrorCodeAccessibleDisabled ErrorCode = "accessible_disabled"
+)
+
+var errorMessages = map[ErrorCode]string{
+	ErrorCodeBadRequest:           "Request is malformed.",
+	ErrorCodeMissingOrigin:        "Origin header is required.",
+	ErrorCodeInvalidOrigin:        "Origin header is not a valid domain.",
+	ErrorCodeOriginNotAllowed:     "Origin is not allowed for this sitekey.",
+	ErrorCodeInvalidSitekey:       "Sitekey is not valid.",
+	ErrorCodeSitekeyNotFound:      "Sitekey was not found.",
+	ErrorCodeSubscriptionInactive: "Subscription is not active.",
+	ErrorCodeQuotaExceeded:        "Usage quota is exceeded.",
+	ErrorCodeInvalidAPIKey:        "API key is missing or malformed.",
+	ErrorCodeUnauthorized:         "API key is not valid.",
+	ErrorCodeInternal:             "Internal server error.",
+	ErrorCodePayloadTooLarge:      "Request is too large.",
+	ErrorCodeTooManyItems:         "Batch contains too many items.",
+	ErrorCodeOverloaded:           "Server is busy, please retry later.",
+	ErrorCodeInvalidAction:        "Action must be up to 64 characters of letters, digits or \"_-./:\".",
+	ErrorCodePropertyPaused:       "Property is paused.",
+	ErrorCodeDomainNotVerified:    "Property domain is not verified.",
+	ErrorCodeAppNotAllowed:        "App is not allowed for this sitekey.",
+	ErrorCodeAttestationFailed:    "App attestation failed.",
+	ErrorCodeAccessibleDisabled:   "Accessible mode is not enabled for this sitekey.",
+}
+
+var (
+	headersErrorJSON = map[string][]string{
+		http.CanonicalHeaderKey(common.HeaderContentType): []string{common.ContentTypeJSON},
+		http.CanonicalHeaderKey("X-Content-Type-Options"): []string{"nosniff"},
+	}
+)
+
+type apiErrorBody struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+type apiErrorResponse struct {
+	Error apiErrorBody `json:"error"`
+}
+
+func newErrorResponse(ctx context.Context, code ErrorCode) *apiErrorResponse {
+	message, ok := errorMessages[code]
+	if !ok {
+		message = errorMessages[ErrorCodeInternal]
+	}
+
+	response := &apiErrorResponse{
+		Error: apiErrorBody{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	if tid, ok := ctx.Value(common.TraceIDContextKey).(string); ok {
+		response.Error.RequestID = tid
+	}
+
+	return response
+}
+
+// sendError is a drop-in replacement of http.Error() that writes a structured error envelope
+func sendError(ctx context.Context, w http.ResponseWriter, status int, code ErrorCode) {
+	common.WriteHeaders(w, common.NoCacheHeaders)
+	common.WriteHeaders(w, headersErrorJSON)
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(newErrorResponse(ctx, code)); err != nil {
+		slog.ErrorContext(ctx, "Failed to write error response", common.ErrAttr(err))
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+)
+
+// error codes are a public contract and must not change
+func TestErrorCodesStable(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		code     ErrorCode
+		expected string
+	}{
+		{ErrorCodeBadRequest, "bad_request"},
+		{ErrorCodeMissingOrigin, "missing_origin"},
+		{ErrorCodeInvalidOrigin, "invalid_origin"},
+		{ErrorCodeOriginNotAllowed, "origin_not_allowed"},
+		{ErrorCodeInvalidSitekey, "invalid_sitekey"},
+		{ErrorCodeSitekeyNotFound, "sitekey_not_found"},
+		{ErrorCodeSubscriptionInactive, "subscription_inactive"},
+		{ErrorCodeQuotaExceeded, "quota_exceeded"},
+		{ErrorCodeInvalidAPIKey, "invalid_api_key"},
+		{ErrorCodeUnauthorized, "unauthorized"},
+		{ErrorCodeInternal, "internal_error"},
+		{ErrorCodePayloadTooLarge, "payload_too_large"},
+		{ErrorCodeTooManyItems, "too_many_items"},
+		{ErrorCodeOverloaded, "overloaded"},
+		{ErrorCodeInvalidAction, "invalid_action"},
+		{ErrorCodePropertyPaused, "property_paused"},
+		{ErrorCodeDomainNotVerified, "domain_not_verified"},
+		{ErrorCodeAppNotAllowed, "app_not_allowed"},
+		{ErrorCodeAttestationFailed, "attestation_failed"},
+		{ErrorCodeAccessibleDisabled, "accessible_disabled"},
+	}
+
+	if len(testCases) != len(errorMessages) {
+		t.Errorf("Not all error codes are covered: %v vs %v", len(testCases), len(errorMessages))
+	}
+
+	for _, tc := range testCases {
+		if string(tc.code) != tc.expected {
+			t.Errorf("Error code changed: expected %v, got %v", tc.expected, tc.code)
+		}
+
+		if len(errorMessages[tc.code]) == 0 {
+			t.Errorf("Error code %v does not have a message", tc.code)
+		}
+	}
+}
+
+func TestSendError(t *testing.T) {
+	t.Parallel()
+
+	ctx := common.TraceContext(context.TODO(), "trace123")
+	w := httptest.NewRecorder()
+	sendError(ctx, w, http.StatusForbidden, ErrorCodeOriginNotAllowed)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Unexpected status code: %v", w.Code)
+	}
+
+	if ct := w.Header().Get(common.HeaderContentType); ct != common.ContentTypeJSON {
+		t.Errorf("Unexpected content type: %v", ct)
+	}
+
+	var envelope map[string]map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&envelope); err != nil {
+		t.Fatal(err)
+	}
+
+	body, ok := envelope["error"]
+	if !ok {
+		t.Fatalf("Error envelope is missing: %v", envelope)
+	}
+
+	if (body["code"] != "origin_not_allowed") || (body["message"] != errorMessages[ErrorCodeOriginNotAllowed]) || (body["request_id"] != "trace123") {
+		t.Errorf("Unexpected error body: %v", body)
+	}
+}
+
+func TestSendErrorUnknownCode(t *testing.T) {
+	t.Parallel()
+
+	response := newErrorResponse(context.TODO(), ErrorCode("unknown"))
+	if response.Error.Message != errorMessages[ErrorCodeInternal] {
+		t.Errorf("Unexpected message for unknown code: %v", response.Error.Message)
+	}
+
+	if len(response.Error.RequestID) > 0 {
+		t.Errorf("Unexpected request ID: %v", response.Error.RequestID)
+	}
+}
+
+func TestInvalidSitekeyErrorResponse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, "/"+common.StatusEndpoint+"?"+common.ParamSiteKey+"=invalid", nil)
+	req.Header.Set("Origin", common_test.PrependProtocol("example.com"))
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Unexpected status code: %v", w.Code)
+	}
+
+	response := &apiErrorResponse{}
+	if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Error.Code != ErrorCodeInvalidSitekey {
+		t.Errorf("Unexpected error code: %v", response.Error.Code)
+	}
+}
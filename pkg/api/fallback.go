@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	// fallback puzzles are solved on the server so we cap their difficulty to keep CPU usage bounded
+	fallbackMaxDifficulty = uint8(common.DifficultyLevelMedium)
+	fallbackTimeout       = 5 * time.Second
+)
+
+// fallbackTemplate is rendered for user agents without JavaScript (e.g. inside <noscript><iframe>).
+// Solution is shown in a read-only field that user copies into the form of the website ("form hand-off")
+var fallbackTemplate = template.Must(template.New("fallback").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Private Captcha</title>
+<style>
+body { font-family: sans-serif; margin: 0; padding: 8px; color: #1f2937; }
+label { display: block; font-size: 14px; margin-bottom: 4px; }
+textarea { width: 100%; box-sizing: border-box; font-family: monospace; font-size: 12px; }
+p { font-size: 12px; color: #4b5563; }
+</style>
+</head>
+<body>
+<main>
+<label for="solution">Copy the code below and paste it into the verification field of the form:</label>
+<textarea id="solution" rows="4" readonly aria-describedby="hint">{{.Solution}}</textarea>
+<p id="hint">{{if .Expiration}}The code is valid until {{.Expiration}} UTC. {{end}}Protected by Private Captcha.</p>
+</main>
+</body>
+</html>
+`))
+
+type fallbackRenderContext struct {
+	Solution   string
+	Expiration string
+}
+
+// fallbackSolution creates a puzzle and solves it on the server, returning payload in the format expected by /siteverify
+func (s *Server) fallbackSolution(ctx context.Context, r *http.Request) (string, *puzzle.Puzzle, int32, error) {
+	p, property, err := s.puzzleForRequest(r)
+
+	var extraSalt []byte
+	var userID int32 = -1
+
+	if err == db.ErrTestProperty {
+		p = puzzle.NewPuzzle(0 /*puzzle ID*/, db.TestPropertyUUID.Bytes, 0 /*difficulty*/)
+	} else if err != nil {
+		return "", nil, userID, err
+	}
+
+	if property != nil {
+		userID = property.OrgOwnerID.Int32
+		extraSalt = property.Salt
+	}
+
+	p.Difficulty = min(p.Difficulty, fallbackMaxDifficulty)
+
+	payload, err := p.Serialize(ctx, s.Salt.Value(), extraSalt)
+	if err != nil {
+		return "", nil, userID, err
+	}
+
+	solver := &puzzle.Solver{}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to solve fallback puzzle", common.ErrAttr(err))
+		return "", nil, userID, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(solutions.String())
+	buf.WriteByte('.')
+	if err := payload.Write(&buf); err != nil {
+		return "", nil, userID, err
+	}
+
+	return buf.String(), p, userID, nil
+}
+
+func (s *Server) fallbackHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !isValidRequestAction(r) {
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidAction)
+		return
+	}
+
+	solution, p, userID, err := s.fallbackSolution(ctx, r)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create fallback challenge", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+		return
+	}
+
+	renderCtx := &fallbackRenderContext{Solution: solution}
+	if !p.Expiration.IsZero() {
+		renderCtx.Expiration = p.Expiration.UTC().Format("15:04")
+	}
+
+	var out bytes.Buffer
+	if err := fallbackTemplate.Execute(&out, renderCtx); err != nil {
+		slog.ErrorContext(ctx, "Failed to render fallback challenge", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+		return
+	}
+
+	common.WriteHeaders(w, common.NoCacheHeaders)
+	common.WriteHeaders(w, common.HtmlContentHeaders)
+	_, _ = w.Write(out.Bytes())
+
+	slog.Log(ctx, common.LevelTrace, "Issued fallback challenge", "puzzleID", p.PuzzleID, "difficulty", p.Difficulty)
+
+	s.Metrics.ObservePuzzleCreated(userID)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/PuerkitoBio/goquery"
+)
+
+func fallbackSuite(sitekey, referer string) (*http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req, err := http.NewRequest(http.MethodGet, "/"+common.FallbackEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(referer) > 0 {
+		req.Header.Set("Referer", referer)
+	}
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	q := req.URL.Query()
+	q.Add(common.ParamSiteKey, sitekey)
+	req.URL.RawQuery = q.Encode()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+func TestFallbackWithoutReferer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	resp, err := fallbackSuite(db.TestPropertySitekey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestFallbackVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       fmt.Sprintf("%v property", t.Name()),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := fallbackSuite(db.UUIDToSiteKey(property.ExternalID), "https://"+testPropertyDomain+"/contact")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected fallback status code %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := doc.Find("textarea#solution").Text()
+	if len(payload) == 0 {
+		t.Fatal("Fallback solution is empty")
+	}
+
+	apikey, err := store.Impl().CreateAPIKey(ctx, user.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = verifySuite(payload, db.UUIDToSecret(apikey.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected verify status code %d", resp.StatusCode)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
@@ -17,7 +18,7 @@ func gcDataTestSuite(ctx context.Context, property *dbgen.Property, deleter func
 	tnow := time.Now()
 
 	for i := 0; i < requests; i++ {
-		s.Levels.Difficulty(common.RandomFingerprint(), property, tnow.Add(time.Duration(i)*10*time.Second))
+		s.Levels.Difficulty(common.RandomFingerprint(), asn.ClassUnknown, property, tnow.Add(time.Duration(i)*10*time.Second))
 	}
 
 	// we need to wait for the timeout in the ProcessAccessLog()
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	maxVerifyReplays = 100_000
+	// for how long the result of successful verification is replayed for retries of the same request
+	verifyReplayTTL = 1 * time.Minute
+)
+
+// verifyReplayKey identifies verification request. Payload includes the puzzle (with its unique ID) so the hash of
+// the payload is as good as the puzzle nonce, but it also guarantees that solutions were not changed in the retry
+type verifyReplayKey struct {
+	APIKeyID int32
+	Payload  [sha256.Size]byte
+}
+
+func newVerifyReplayKey(ctx context.Context, payload []byte) (verifyReplayKey, bool) {
+	apiKey, ok := ctx.Value(common.APIKeyContextKey).(*dbgen.APIKey)
+	if !ok || (apiKey == nil) {
+		return verifyReplayKey{}, false
+	}
+
+	return verifyReplayKey{APIKeyID: apiKey.ID, Payload: sha256.Sum256(payload)}, true
+}
+
+func hashVerifyReplayKey(k verifyReplayKey) uint64 {
+	return db.HashUint64(binary.BigEndian.Uint64(k.Payload[:8]) ^ uint64(uint32(k.APIKeyID)))
+}
+
+// value is the response to the first (successful) verification
+func newVerifyReplayCache() common.Cache[verifyReplayKey, *VerifyResponseRecaptchaV2] {
+	return db.NewMemoryCache[verifyReplayKey, *VerifyResponseRecaptchaV2](maxVerifyReplays, nil /*missing value*/, hashVerifyReplayKey)
+}
+
+// cachedVerifyResponse returns response of the previous verification of exactly the same payload (with the same
+// API key), so that customer backend can safely retry verification after network timeout
+func (s *Server) cachedVerifyResponse(ctx context.Context, key verifyReplayKey) *VerifyResponseRecaptchaV2 {
+	response, err := s.verifyReplays.Get(ctx, key)
+	if (err != nil) || (response == nil) {
+		return nil
+	}
+
+	slog.DebugContext(ctx, "Replaying cached verify response", "apiKeyID", key.APIKeyID)
+
+	return response
+}
+
+// cacheVerifyResponse only keeps successful verifications as failed ones are not affected by retries. Responses are
+// never replayed after the puzzle expires
+func (s *Server) cacheVerifyResponse(ctx context.Context, key verifyReplayKey, p *puzzle.Puzzle, verr puzzle.VerifyError, response *VerifyResponseRecaptchaV2, tnow time.Time) {
+	if (verr != puzzle.VerifyNoError) || (p == nil) || p.IsZero() {
+		return
+	}
+
+	ttl := min(verifyReplayTTL, p.Expiration.Sub(tnow))
+	if ttl <= 0 {
+		return
+	}
+
+	if err := s.verifyReplays.Set(ctx, key, response, ttl); err != nil {
+		slog.ErrorContext(ctx, "Failed to cache verify response", common.ErrAttr(err))
+	}
+}
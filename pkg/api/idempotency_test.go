@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestVerifyReplayCache(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{verifyReplays: newVerifyReplayCache()}
+	tnow := time.Now().UTC()
+
+	apiKeyCtx := func(id int32) context.Context {
+		return context.WithValue(context.TODO(), common.APIKeyContextKey, &dbgen.APIKey{ID: id})
+	}
+
+	if _, ok := newVerifyReplayKey(context.TODO(), []byte("payload")); ok {
+		t.Error("Replay key created without API key")
+	}
+
+	ctx := apiKeyCtx(1)
+	key, ok := newVerifyReplayKey(ctx, []byte("payload"))
+	if !ok {
+		t.Fatal("Failed to create replay key")
+	}
+
+	p := puzzle.NewPuzzle(puzzle.RandomPuzzleID(), [16]byte{}, uint8(common.DifficultyLevelMedium))
+	if err := p.Init(puzzle.DefaultValidityPeriod); err != nil {
+		t.Fatal(err)
+	}
+
+	// failed verifications are not replayed
+	s.cacheVerifyResponse(ctx, key, p, puzzle.IntegrityError, &VerifyResponseRecaptchaV2{}, tnow)
+	if r := s.cachedVerifyResponse(ctx, key); r != nil {
+		t.Error("Failed verification was cached")
+	}
+
+	// expired puzzles are not replayed
+	s.cacheVerifyResponse(ctx, key, p, puzzle.VerifyNoError, &VerifyResponseRecaptchaV2{}, p.Expiration.Add(time.Second))
+	if r := s.cachedVerifyResponse(ctx, key); r != nil {
+		t.Error("Verification of expired puzzle was cached")
+	}
+
+	expected := &VerifyResponseRecaptchaV2{VerifyResponse: VerifyResponse{Success: true, Action: "login"}}
+	s.cacheVerifyResponse(ctx, key, p, puzzle.VerifyNoError, expected, tnow)
+	if r := s.cachedVerifyResponse(ctx, key); r != expected {
+		t.Errorf("Unexpected cached response: %v", r)
+	}
+
+	otherKey, _ := newVerifyReplayKey(apiKeyCtx(2), []byte("payload"))
+	if r := s.cachedVerifyResponse(ctx, otherKey); r != nil {
+		t.Error("Response is replayed for other API key")
+	}
+
+	otherPayloadKey, _ := newVerifyReplayKey(ctx, []byte("payload2"))
+	if r := s.cachedVerifyResponse(ctx, otherPayloadKey); r != nil {
+		t.Error("Response is replayed for other payload")
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
 )
 
 const (
@@ -24,7 +26,7 @@ func TestBackfillLevels(t *testing.T) {
 	}
 
 	// minutes per bucket
-	levels := difficulty.NewLevels(timeSeries, 200, testBucketSize)
+	levels := difficulty.NewLevels(timeSeries, monitoring.NewStub(), 200, testBucketSize)
 	levels.Init(500*time.Millisecond /*access log*/, 700*time.Millisecond /*backfill*/)
 	defer levels.Shutdown()
 	tnow := time.Now()
@@ -58,7 +60,7 @@ func TestBackfillLevels(t *testing.T) {
 		for i := 0; i < iterations; i++ {
 			fingerprint := fingerprints[rand.Intn(len(fingerprints))]
 			t := btime.Add(time.Duration(i) * diffInterval)
-			diff, level = levels.DifficultyEx(fingerprint, prop, t)
+			diff, level = levels.DifficultyEx(fingerprint, asn.ClassUnknown, prop, t)
 			if (i+1)%250 == 0 {
 				slog.Debug("Simulating requests", "difficulty", diff, "level", level, "eventTime", t, "i", i, "bucket", bucket)
 			}
@@ -67,7 +69,7 @@ func TestBackfillLevels(t *testing.T) {
 
 	fingerprint := common.RandomFingerprint()
 	// reinit diff to neglect effect of other properties
-	diff, level = levels.DifficultyEx(fingerprint, prop, tnow)
+	diff, level = levels.DifficultyEx(fingerprint, asn.ClassUnknown, prop, tnow)
 
 	if diff == uint8(common.DifficultyLevelSmall) {
 		t.Errorf("Difficulty did not grow: %v", diff)
@@ -80,7 +82,7 @@ func TestBackfillLevels(t *testing.T) {
 	levels.Reset()
 
 	// now this should cause the backfill request to be fired
-	if d, l := levels.DifficultyEx(fingerprint, prop, tnow); d != uint8(common.DifficultyLevelSmall) {
+	if d, l := levels.DifficultyEx(fingerprint, asn.ClassUnknown, prop, tnow); d != uint8(common.DifficultyLevelSmall) {
 		t.Errorf("Unexpected difficulty after stats reset: %v (level %v)", d, l)
 	}
 
@@ -91,7 +93,7 @@ func TestBackfillLevels(t *testing.T) {
 	for attempt := 0; attempt < 5; attempt++ {
 		// give time to backfill difficulty
 		time.Sleep(1 * time.Second)
-		actualDifficulty, actualLevel = levels.DifficultyEx(fingerprint, prop, tnow)
+		actualDifficulty, actualLevel = levels.DifficultyEx(fingerprint, asn.ClassUnknown, prop, tnow)
 		if (actualDifficulty >= diff) && (actualDifficulty-diff < 5) {
 			backfilled = true
 			break
@@ -4,13 +4,19 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
 )
 
@@ -23,17 +29,26 @@ const (
 type UserLimiter interface {
 	CheckProperties(ctx context.Context, properties []*dbgen.Property)
 	Evaluate(ctx context.Context, userID int32) (bool, error)
+	PropertyQuotaExceeded(ctx context.Context, property *dbgen.Property) bool
 }
 
 type AuthMiddleware struct {
 	Store             db.Implementor
 	PlanService       billing.PlanService
 	PuzzleRateLimiter ratelimit.HTTPRateLimiter
+	MobileRateLimiter ratelimit.HTTPRateLimiter
 	ApiKeyRateLimiter ratelimit.HTTPRateLimiter
+	Attestor          AppAttestor
 	SitekeyChan       chan string
 	BatchSize         int
 	BackfillCancel    context.CancelFunc
+	APIKeyUsageChan   chan *common.APIKeyRecord
+	UsageCancel       context.CancelFunc
 	Limiter           UserLimiter
+	// properties serve puzzles only after their owners proved control of the domain
+	RequireVerifiedDomain bool
+	// verification (including API keys) is served only from cache
+	verifyReadOnly atomic.Bool
 }
 
 func newAPIKeyBuckets() *ratelimit.StringBuckets {
@@ -64,8 +79,13 @@ func newPuzzleIPAddrBuckets(cfg common.ConfigStore) *ratelimit.IPAddrBuckets {
 }
 
 type baseUserLimiter struct {
-	store      db.Implementor
-	userLimits common.Cache[int32, any]
+	store       db.Implementor
+	timeSeries  common.TimeSeriesStore
+	planService billing.PlanService
+	stage       string
+	userLimits  common.Cache[int32, any]
+	allocations common.Cache[int32, *propertyAllocation]
+	alerter     common.Alerter
 }
 
 func (ul *baseUserLimiter) unknownPropertiesOwners(ctx context.Context, properties []*dbgen.Property) []int32 {
@@ -108,6 +128,10 @@ func (ul *baseUserLimiter) CheckProperties(ctx context.Context, properties []*db
 			violatorsMap[u.ID] = struct{}{}
 		}
 
+		if len(users) > 0 {
+			ul.alertViolations(ctx, users)
+		}
+
 		for _, u := range owners {
 			if _, found := violatorsMap[u]; !found {
 				_ = ul.userLimits.SetMissing(ctx, u, db.UserLimitTTL)
@@ -118,72 +142,107 @@ func (ul *baseUserLimiter) CheckProperties(ctx context.Context, properties []*db
 	}
 }
 
+func (ul *baseUserLimiter) alertViolations(ctx context.Context, users []*dbgen.User) {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, strconv.Itoa(int(u.ID)))
+	}
+	slices.Sort(ids)
+	joined := strings.Join(ids, ", ")
+
+	ul.alerter.SendAlert(ctx, &common.Alert{
+		Key:      "user_limits_" + joined,
+		Severity: common.AlertSeverityWarning,
+		Title:    "Users without subscription are serving traffic",
+		Text:     "User IDs: " + joined,
+	})
+}
+
 func (ul *baseUserLimiter) Evaluate(ctx context.Context, userID int32) (bool, error) {
 	_, err := ul.userLimits.Get(ctx, userID)
 	// "false" because by we only check if user has a subscription at all, we don't verify usage limits
 	return false, err
 }
 
-func NewUserLimiter(store db.Implementor) *baseUserLimiter {
+func NewUserLimiter(store db.Implementor, timeSeries common.TimeSeriesStore, planService billing.PlanService, stage string, alerter common.Alerter) *baseUserLimiter {
 	const maxLimitedUsers = 10_000
-	var userLimits common.Cache[int32, any]
-	var err error
-	userLimits, err = db.NewMemoryCache[int32, any](maxLimitedUsers, nil /*missing value*/)
-	if err != nil {
-		slog.Error("Failed to create memory cache for user limits", common.ErrAttr(err))
-		userLimits = db.NewStaticCache[int32, any](maxLimitedUsers, nil /*missing data*/)
-	}
+	userLimits := db.NewMemoryCache[int32, any](maxLimitedUsers, nil /*missing value*/, db.HashInt32)
 
 	return &baseUserLimiter{
-		userLimits: userLimits,
-		store:      store,
+		userLimits:  userLimits,
+		allocations: newAllocationCache(),
+		store:       store,
+		timeSeries:  timeSeries,
+		planService: planService,
+		stage:       stage,
+		alerter:     alerter,
 	}
 }
 
+// sandbox properties are exempt as they never affect real traffic
+func (am *AuthMiddleware) requiresDomainVerification(property *dbgen.Property) bool {
+	return am.RequireVerifiedDomain && !property.TestMode && !property.DomainVerifiedAt.Valid
+}
+
 func NewAuthMiddleware(cfg common.ConfigStore,
+	ipStrategy *ratelimit.ClientIPStrategy,
 	store db.Implementor,
 	limiter UserLimiter,
 	planService billing.PlanService) *AuthMiddleware {
 	const batchSize = 10
-	rateLimitHeader := cfg.Get(common.RateLimitHeaderKey).Value()
 
 	am := &AuthMiddleware{
-		PuzzleRateLimiter: ratelimit.NewIPAddrRateLimiter("puzzle", rateLimitHeader, newPuzzleIPAddrBuckets(cfg)),
+		PuzzleRateLimiter: ratelimit.NewIPAddrRateLimiter("puzzle", ipStrategy, newPuzzleIPAddrBuckets(cfg)),
+		MobileRateLimiter: ratelimit.NewIPAddrRateLimiter("mobile", ipStrategy, newMobileIPAddrBuckets(cfg)),
+		Attestor:          &noopAppAttestor{},
 		Store:             store,
 		Limiter:           limiter,
 		PlanService:       planService,
 		SitekeyChan:       make(chan string, 10*batchSize),
 		BatchSize:         batchSize,
 		BackfillCancel:    func() {},
+		APIKeyUsageChan:   make(chan *common.APIKeyRecord, 10*apiKeyUsageBatchSize),
+		UsageCancel:       func() {},
 	}
 
 	am.ApiKeyRateLimiter = ratelimit.NewAPIKeyRateLimiter(
-		rateLimitHeader, newAPIKeyBuckets(), am.apiKeyKeyFunc)
+		ipStrategy, newAPIKeyBuckets(), am.apiKeyKeyFunc)
 
 	return am
 }
 
-func (am *AuthMiddleware) BackfillProperties(backfillDelay time.Duration) {
+func (am *AuthMiddleware) BackfillProperties(backfillDelay time.Duration, metrics common.BatchMetrics) {
 	var backfillCtx context.Context
 	backfillCtx, am.BackfillCancel = context.WithCancel(
 		context.WithValue(context.Background(), common.TraceIDContextKey, "auth_backfill"))
-	go common.ProcessBatchMap(backfillCtx, am.SitekeyChan, backfillDelay, am.BatchSize, am.BatchSize*100, am.backfillImpl)
+	go common.ProcessBatchMap(backfillCtx, common.BatchPipelineSitekeyBackfill, metrics, am.SitekeyChan, backfillDelay, am.BatchSize, am.BatchSize*100, am.backfillImpl)
 }
 
 func (am *AuthMiddleware) UpdateConfig(cfg common.ConfigStore) {
+	am.verifyReadOnly.Store(config.AsMaintenanceModes(cfg).Verify)
+
 	puzzleBucketRate := cfg.Get(common.PuzzleLeakyBucketRateKey)
 	puzzleBucketBurst := cfg.Get(common.PuzzleLeakyBucketBurstKey)
 	am.PuzzleRateLimiter.UpdateLimits(
 		leakybucket.Cap(puzzleBucketBurst.Value(), puzzleLeakyBucketCap),
 		leakybucket.Interval(puzzleBucketRate.Value(), puzzleLeakInterval))
+
+	mobileBucketRate := cfg.Get(common.MobileLeakyBucketRateKey)
+	mobileBucketBurst := cfg.Get(common.MobileLeakyBucketBurstKey)
+	am.MobileRateLimiter.UpdateLimits(
+		leakybucket.Cap(mobileBucketBurst.Value(), mobileLeakyBucketCap),
+		leakybucket.Interval(mobileBucketRate.Value(), mobileLeakInterval))
 }
 
 func (am *AuthMiddleware) Shutdown() {
 	slog.Debug("Shutting down auth middleware")
 	am.ApiKeyRateLimiter.Shutdown()
 	am.PuzzleRateLimiter.Shutdown()
+	am.MobileRateLimiter.Shutdown()
 	am.BackfillCancel()
 	close(am.SitekeyChan)
+	am.UsageCancel()
+	close(am.APIKeyUsageChan)
 }
 
 func isSiteKeyValid(sitekey string) bool {
@@ -218,15 +277,14 @@ func (am *AuthMiddleware) originAllowed(r *http.Request, origin string) (bool, [
 }
 
 func isOriginAllowed(origin string, property *dbgen.Property) bool {
-	if common.IsLocalhost(origin) {
-		return property.AllowLocalhost
+	rules := origins.Rules{
+		Domain:          property.Domain,
+		AllowSubdomains: property.AllowSubdomains,
+		AllowLocalhost:  property.AllowLocalhost,
+		Patterns:        property.AllowedOrigins,
 	}
 
-	if property.AllowSubdomains {
-		return common.IsSubDomainOrDomain(origin, property.Domain)
-	}
-
-	return origin == property.Domain
+	return rules.Allows(origin)
 }
 
 func (am *AuthMiddleware) SitekeyOptions(next http.Handler) http.Handler {
@@ -237,7 +295,7 @@ func (am *AuthMiddleware) SitekeyOptions(next http.Handler) http.Handler {
 		// don't validate all characters for speed reasons
 		if len(sitekey) != db.SitekeyLen {
 			slog.Log(ctx, common.LevelTrace, "Sitekey is not valid", "method", r.Method)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
 			return
 		}
 
@@ -247,21 +305,62 @@ func (am *AuthMiddleware) SitekeyOptions(next http.Handler) http.Handler {
 	}))
 }
 
+// Sitekey validates sitekey and the client of the puzzle request. Browsers are identified by Origin header, while
+// native apps (that cannot send Origin) are identified by app ID header and are rate limited separately
 func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
-	return am.PuzzleRateLimiter.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	browserHandler := am.PuzzleRateLimiter.RateLimit(am.sitekeyHandler(next, requireOrigin, checkOrigin))
+	mobileHandler := am.MobileRateLimiter.RateLimit(am.sitekeyHandler(next, requireAppID, am.checkApp))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (len(r.Header.Get("Origin")) == 0) && (len(r.Header.Get(common.HeaderAppID)) > 0) {
+			mobileHandler.ServeHTTP(w, r)
+			return
+		}
+
+		browserHandler.ServeHTTP(w, r)
+	})
+}
+
+func requireOrigin(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	if len(r.Header.Get("Origin")) == 0 {
+		slog.Log(ctx, common.LevelTrace, "Origin header is missing from the request")
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeMissingOrigin)
+		return false
+	}
+
+	return true
+}
+
+func checkOrigin(ctx context.Context, w http.ResponseWriter, r *http.Request, property *dbgen.Property) bool {
+	originHost, err := common.ParseDomainName(r.Header.Get("Origin"))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse origin domain name", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidOrigin)
+		return false
+	}
+
+	if !isOriginAllowed(originHost, property) {
+		slog.WarnContext(ctx, "Origin is not allowed", "origin", originHost, "domain", property.Domain, "subdomains", property.AllowSubdomains,
+			"patterns", len(property.AllowedOrigins))
+		sendError(ctx, w, http.StatusForbidden, ErrorCodeOriginNotAllowed)
+		return false
+	}
+
+	return true
+}
+
+func (am *AuthMiddleware) sitekeyHandler(next http.Handler, requireClient clientRequirement, checkClient clientCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		origin := r.Header.Get("Origin")
-		if len(origin) == 0 {
-			slog.Log(ctx, common.LevelTrace, "Origin header is missing from the request")
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		if !requireClient(ctx, w, r) {
 			return
 		}
 
 		sitekey := r.URL.Query().Get(common.ParamSiteKey)
 		if !isSiteKeyValid(sitekey) {
 			slog.Log(ctx, common.LevelTrace, "Sitekey is not valid", "method", r.Method)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
 			return
 		}
 
@@ -272,10 +371,10 @@ func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
 			switch err {
 			// this will happen when the user does not have such property or it was deleted
 			case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
-				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				sendError(ctx, w, http.StatusForbidden, ErrorCodeSitekeyNotFound)
 				return
 			case db.ErrInvalidInput:
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
 				return
 			case db.ErrTestProperty:
 				// BUMP
@@ -283,31 +382,43 @@ func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
 				// backfill in the background
 				am.SitekeyChan <- sitekey
 			default:
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
 				return
 			}
 		}
 
 		if property != nil {
-			if originHost, err := common.ParseDomainName(origin); err == nil {
-				if !isOriginAllowed(originHost, property) {
-					slog.WarnContext(ctx, "Origin is not allowed", "origin", originHost, "domain", property.Domain, "subdomains", property.AllowSubdomains)
-					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
-					return
-				}
-			} else {
-				slog.WarnContext(ctx, "Failed to parse origin domain name", common.ErrAttr(err))
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			if !checkClient(ctx, w, r, property) {
+				return
+			}
+
+			if property.PausedAt.Valid {
+				sendError(ctx, w, http.StatusForbidden, ErrorCodePropertyPaused)
+				return
+			}
+
+			if am.requiresDomainVerification(property) {
+				sendError(ctx, w, http.StatusForbidden, ErrorCodeDomainNotVerified)
 				return
 			}
 
 			if softRestriction, err := am.Limiter.Evaluate(ctx, property.OrgOwnerID.Int32); err == nil {
 				// if user is not an active subscriber, their properties and orgs might still exist but should not serve puzzles
 				if !softRestriction {
-					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
-				} else {
-					http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+					sendError(ctx, w, http.StatusForbidden, ErrorCodeSubscriptionInactive)
+					return
 				}
+
+				// sandbox properties never count against quota so they keep working in integration tests
+				if !property.TestMode {
+					sendError(ctx, w, http.StatusTooManyRequests, ErrorCodeQuotaExceeded)
+					return
+				}
+			}
+
+			// owners can cap the share of their quota that a single property can use up
+			if !property.TestMode && am.Limiter.PropertyQuotaExceeded(ctx, property) {
+				sendError(ctx, w, http.StatusTooManyRequests, ErrorCodeQuotaExceeded)
 				return
 			}
 
@@ -317,7 +428,22 @@ func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
 		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
-	}))
+	})
+}
+
+// SitekeyFallback is the same as Sitekey middleware, but it allows to use Referer header for origin validation,
+// because browsers do not send Origin for top-level navigation and iframes that fallback flow uses
+func (am *AuthMiddleware) SitekeyFallback(next http.Handler) http.Handler {
+	sitekeyHandler := am.Sitekey(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (len(r.Header.Get("Origin")) == 0) && (len(r.Referer()) > 0) {
+			r = r.Clone(r.Context())
+			r.Header.Set("Origin", r.Referer())
+		}
+
+		sitekeyHandler.ServeHTTP(w, r)
+	})
 }
 
 func (am *AuthMiddleware) isAPIKeyValid(ctx context.Context, key *dbgen.APIKey, tnow time.Time) bool {
@@ -355,25 +481,34 @@ func (am *AuthMiddleware) apiKeyKeyFunc(r *http.Request) string {
 	return ""
 }
 
+// verifyImpl only uses cached API keys and properties when verification is under (read-only) maintenance
+func (am *AuthMiddleware) verifyImpl() *db.BusinessStoreImpl {
+	if am.verifyReadOnly.Load() {
+		return am.Store.CacheOnlyImpl()
+	}
+
+	return am.Store.Impl()
+}
+
 func (am *AuthMiddleware) APIKey(next http.Handler) http.Handler {
 	return am.ApiKeyRateLimiter.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		secret := r.Header.Get(common.HeaderAPIKey)
 		if len(secret) != db.SecretLen {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidAPIKey)
 			return
 		}
 
 		// by now we are ratelimited or cached, so kind of OK to attempt access DB here
-		apiKey, err := am.Store.Impl().RetrieveAPIKey(ctx, secret)
+		apiKey, err := am.verifyImpl().RetrieveAPIKey(ctx, secret)
 		if err != nil {
 			switch err {
 			case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
-				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				sendError(ctx, w, http.StatusUnauthorized, ErrorCodeUnauthorized)
 			case db.ErrInvalidInput:
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidAPIKey)
 			default:
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
 			}
 			return
 		}
@@ -381,7 +516,7 @@ func (am *AuthMiddleware) APIKey(next http.Handler) http.Handler {
 		now := time.Now().UTC()
 		if !am.isAPIKeyValid(ctx, apiKey, now) {
 			// am.Cache.SetMissing(ctx, secret, negativeCacheDuration)
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			sendError(ctx, w, http.StatusUnauthorized, ErrorCodeUnauthorized)
 			return
 		} else {
 			// rate limiter key will be the {secret} itself _only_ when we are cached
@@ -390,10 +525,32 @@ func (am *AuthMiddleware) APIKey(next http.Handler) http.Handler {
 			if rateLimiterKey, ok := ctx.Value(common.RateLimitKeyContextKey).(string); ok && (rateLimiterKey != secret) {
 				interval := float64(time.Second) / apiKey.RequestsPerSecond
 				am.ApiKeyRateLimiter.Updater(r)(uint32(apiKey.RequestsBurst), time.Duration(interval))
+
+				// headers were set by rate limiter before we knew actual API key limits
+				if state, ok := am.ApiKeyRateLimiter.State(r); ok {
+					ratelimit.SetRateLimitHeaders(w, state)
+				}
 			}
 		}
 
+		// org tokens act on behalf of the current org owner, personal keys on behalf of their user
+		ownerID, orgID, err := am.verifyImpl().RetrieveAPIKeyOwner(ctx, apiKey)
+		if err != nil {
+			switch err {
+			case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
+				sendError(ctx, w, http.StatusUnauthorized, ErrorCodeUnauthorized)
+			default:
+				sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+			}
+			return
+		}
+
 		ctx = context.WithValue(ctx, common.APIKeyContextKey, apiKey)
+		ctx = context.WithValue(ctx, common.APIKeyOwnerContextKey, ownerID)
+		if orgID > 0 {
+			ctx = context.WithValue(ctx, common.APIKeyOrgContextKey, orgID)
+		}
+		am.recordAPIKeyUsage(ctx, false /*wrong owner*/)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}))
 }
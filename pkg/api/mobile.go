@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
+)
+
+const (
+	// mobile clients are often behind carrier-grade NAT, so more of them share the same IP address than browsers
+	mobileLeakyBucketCap = 50
+	mobileLeakInterval   = 1 * time.Second
+)
+
+// clientRequirement checks that the request identifies its client at all (before the sitekey is looked up)
+type clientRequirement func(ctx context.Context, w http.ResponseWriter, r *http.Request) bool
+
+// clientCheck checks that the client of the request is allowed to use the property
+type clientCheck func(ctx context.Context, w http.ResponseWriter, r *http.Request, property *dbgen.Property) bool
+
+// AppAttestor verifies platform attestation of the native app (e.g. App Attest or Play Integrity token)
+// that mobile SDK sends along with the app identifier
+type AppAttestor interface {
+	Attest(ctx context.Context, property *dbgen.Property, appID string, token string) error
+}
+
+// by default app identifier alone is trusted, same as Origin header is trusted for browsers
+type noopAppAttestor struct{}
+
+var _ AppAttestor = (*noopAppAttestor)(nil)
+
+func (noopAppAttestor) Attest(context.Context, *dbgen.Property, string, string) error { return nil }
+
+func newMobileIPAddrBuckets(cfg common.ConfigStore) *ratelimit.IPAddrBuckets {
+	const (
+		// number of simultaneous different users for /puzzle from native apps
+		maxBuckets = 100_000
+	)
+
+	mobileBucketRate := cfg.Get(common.MobileLeakyBucketRateKey)
+	mobileBucketBurst := cfg.Get(common.MobileLeakyBucketBurstKey)
+
+	return ratelimit.NewIPAddrBuckets(maxBuckets,
+		leakybucket.Cap(mobileBucketBurst.Value(), mobileLeakyBucketCap),
+		leakybucket.Interval(mobileBucketRate.Value(), mobileLeakInterval))
+}
+
+func requireAppID(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	if err := origins.ValidateAppID(r.Header.Get(common.HeaderAppID)); err != nil {
+		slog.Log(ctx, common.LevelTrace, "App ID is not valid", common.ErrAttr(err))
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// checkApp allows native app only if property is in mobile mode (has allowed apps) and app passes attestation
+func (am *AuthMiddleware) checkApp(ctx context.Context, w http.ResponseWriter, r *http.Request, property *dbgen.Property) bool {
+	appID := r.Header.Get(common.HeaderAppID)
+
+	if !origins.AppAllowed(appID, property.AllowedAppIDs) {
+		slog.WarnContext(ctx, "App is not allowed", "appID", appID, "propID", property.ID, "apps", len(property.AllowedAppIDs))
+		sendError(ctx, w, http.StatusForbidden, ErrorCodeAppNotAllowed)
+		return false
+	}
+
+	if err := am.Attestor.Attest(ctx, property, appID, r.Header.Get(common.HeaderAppAttestation)); err != nil {
+		slog.WarnContext(ctx, "App attestation failed", "appID", appID, "propID", property.ID, common.ErrAttr(err))
+		sendError(ctx, w, http.StatusForbidden, ErrorCodeAttestationFailed)
+		return false
+	}
+
+	return true
+}
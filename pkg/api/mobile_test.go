@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+type rejectingAttestor struct{}
+
+func (rejectingAttestor) Attest(context.Context, *dbgen.Property, string, string) error {
+	return errors.New("attestation rejected")
+}
+
+func TestCheckApp(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{AllowedAppIDs: []string{"com.example.app"}}
+
+	testCases := []struct {
+		attestor AppAttestor
+		property *dbgen.Property
+		appID    string
+		status   int
+		code     ErrorCode
+	}{
+		{&noopAppAttestor{}, property, "com.example.app", http.StatusOK, ""},
+		{&noopAppAttestor{}, property, "com.example.other", http.StatusForbidden, ErrorCodeAppNotAllowed},
+		// property is not in mobile mode
+		{&noopAppAttestor{}, &dbgen.Property{}, "com.example.app", http.StatusForbidden, ErrorCodeAppNotAllowed},
+		{&rejectingAttestor{}, property, "com.example.app", http.StatusForbidden, ErrorCodeAttestationFailed},
+	}
+
+	for i, tc := range testCases {
+		am := &AuthMiddleware{Attestor: tc.attestor}
+
+		req := httptest.NewRequest(http.MethodGet, "/"+common.PuzzleEndpoint, nil)
+		req.Header.Set(common.HeaderAppID, tc.appID)
+		w := httptest.NewRecorder()
+
+		allowed := am.checkApp(context.TODO(), w, req, tc.property)
+		if allowed != (tc.status == http.StatusOK) {
+			t.Errorf("Unexpected result at %v: %v", i, allowed)
+		}
+
+		if !allowed && (w.Code != tc.status) {
+			t.Errorf("Unexpected status at %v: %v", i, w.Code)
+		}
+
+		if (len(tc.code) > 0) && !strings.Contains(w.Body.String(), string(tc.code)) {
+			t.Errorf("Unexpected error body at %v: %v", i, w.Body.String())
+		}
+	}
+}
+
+func TestRequireAppID(t *testing.T) {
+	t.Parallel()
+
+	for _, appID := range []string{"", "example", "com.example/app"} {
+		req := httptest.NewRequest(http.MethodGet, "/"+common.PuzzleEndpoint, nil)
+		req.Header.Set(common.HeaderAppID, appID)
+		w := httptest.NewRecorder()
+
+		if requireAppID(context.TODO(), w, req) || (w.Code != http.StatusBadRequest) {
+			t.Errorf("App ID %q was accepted", appID)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	puzzlePoolRefillInterval = 1 * time.Second
+	// pooled puzzles are issued with expiration set at generation time, so they are not kept for too long
+	puzzlePoolMaxAge = 30 * time.Second
+	// property/difficulty pairs that were not requested for this long are not pre-generated anymore
+	puzzlePoolIdleTimeout = 5 * time.Minute
+	// limits memory, used by the pool: total capacity is (number of keys) x (pool size)
+	maxPuzzlePoolKeys = 1_000
+)
+
+// puzzlePoolKey identifies puzzles that can be served interchangeably
+type puzzlePoolKey struct {
+	propertyID [puzzle.PropertyIDSize]byte
+	version    uint8
+	difficulty uint8
+}
+
+type pooledPuzzle struct {
+	puzzle    *puzzle.Puzzle
+	payload   *puzzle.PuzzlePayload
+	salt      *puzzle.Salt
+	extraSalt []byte
+	created   time.Time
+}
+
+type puzzlePoolEntry struct {
+	key       puzzlePoolKey
+	extraSalt []byte
+	validity  time.Duration
+	requested time.Time
+	puzzles   chan *pooledPuzzle
+}
+
+func (pp *pooledPuzzle) isFresh(salt *puzzle.Salt, extraSalt []byte, tnow time.Time) bool {
+	// salt could have been rotated or property settings changed after puzzle was generated
+	return (pp.salt == salt) && bytes.Equal(pp.extraSalt, extraSalt) && (tnow.Sub(pp.created) <= puzzlePoolMaxAge)
+}
+
+// puzzlePool keeps puzzles for recently requested property/difficulty pairs serialized and signed in advance,
+// so that traffic spikes are served without hashing on the hot path. Puzzles with action or embedded
+// fingerprint are unique per request and are always generated on demand.
+type puzzlePool struct {
+	size      int
+	lock      sync.Mutex
+	entries   map[puzzlePoolKey]*puzzlePoolEntry
+	salt      func() *puzzle.Salt
+	serialize func(ctx context.Context, p *puzzle.Puzzle, salt *puzzle.Salt, extraSalt []byte) (*puzzle.PuzzlePayload, error)
+	refill    chan struct{}
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	// accounted separately from entries as they are removed when idle
+	hits      [len(puzzlePoolBuckets)]atomic.Uint64
+	fallbacks [len(puzzlePoolBuckets)]atomic.Uint64
+}
+
+var _ common.PuzzlePoolStatsSource = (*puzzlePool)(nil)
+
+// difficulty buckets are only used for metrics as there can be too many properties
+var puzzlePoolBuckets = [...]struct {
+	name          string
+	maxDifficulty int
+}{
+	{"low", int(common.DifficultyLevelMedium)},
+	{"medium", int(common.DifficultyLevelHigh)},
+	{"high", int(common.MaxDifficultyLevel) + 1},
+}
+
+func puzzlePoolBucket(difficulty uint8) int {
+	for i, b := range puzzlePoolBuckets {
+		if int(difficulty) < b.maxDifficulty {
+			return i
+		}
+	}
+
+	return len(puzzlePoolBuckets) - 1
+}
+
+// NewPuzzlePool creates a pool with up to size puzzles per property and difficulty or returns nil (pool is disabled)
+func NewPuzzlePool(size int) *puzzlePool {
+	if size <= 0 {
+		return nil
+	}
+
+	return &puzzlePool{
+		size:    size,
+		entries: make(map[puzzlePoolKey]*puzzlePoolEntry),
+		serialize: func(ctx context.Context, p *puzzle.Puzzle, salt *puzzle.Salt, extraSalt []byte) (*puzzle.PuzzlePayload, error) {
+			return p.Serialize(ctx, salt, extraSalt)
+		},
+		refill: make(chan struct{}, 1),
+	}
+}
+
+func (pp *puzzlePool) entry(key puzzlePoolKey, extraSalt []byte, validity time.Duration, tnow time.Time) *puzzlePoolEntry {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	e, ok := pp.entries[key]
+	if !ok {
+		if len(pp.entries) >= maxPuzzlePoolKeys {
+			return nil
+		}
+
+		e = &puzzlePoolEntry{key: key, puzzles: make(chan *pooledPuzzle, pp.size)}
+		pp.entries[key] = e
+	}
+
+	e.requested = tnow
+	if !bytes.Equal(e.extraSalt, extraSalt) || (e.validity != validity) {
+		e.extraSalt = bytes.Clone(extraSalt)
+		e.validity = validity
+	}
+
+	return e
+}
+
+// Get returns pre-generated puzzle (and its signed payload) that can be issued instead of p or nil if pool is
+// disabled or exhausted, in which case p has to be serialized on demand. Every request marks property and
+// difficulty of p to be pre-generated going forward.
+func (pp *puzzlePool) Get(p *puzzle.Puzzle, extraSalt []byte, validity time.Duration, tnow time.Time) (*puzzle.Puzzle, *puzzle.PuzzlePayload) {
+	if (pp == nil) || (pp.salt == nil) || (len(p.Action) > 0) || p.IsStub() {
+		return nil, nil
+	}
+
+	key := puzzlePoolKey{propertyID: p.PropertyID, version: p.Version, difficulty: p.Difficulty}
+	e := pp.entry(key, extraSalt, validity, tnow)
+	if e == nil {
+		return nil, nil
+	}
+
+	bucket := puzzlePoolBucket(p.Difficulty)
+	salt := pp.salt()
+
+	var result *pooledPuzzle
+take:
+	for {
+		select {
+		case pooled := <-e.puzzles:
+			if pooled.isFresh(salt, extraSalt, tnow) {
+				result = pooled
+				break take
+			}
+		default:
+			break take
+		}
+	}
+
+	if result != nil {
+		pp.hits[bucket].Add(1)
+	} else {
+		pp.fallbacks[bucket].Add(1)
+	}
+
+	// we wake up refill routine on every take so that the pool is replenished before it's fully drained
+	select {
+	case pp.refill <- struct{}{}:
+	default:
+	}
+
+	if result == nil {
+		return nil, nil
+	}
+
+	return result.puzzle, result.payload
+}
+
+func (pp *puzzlePool) generate(ctx context.Context, e *puzzlePoolEntry, salt *puzzle.Salt, extraSalt []byte, validity time.Duration, tnow time.Time) (*pooledPuzzle, error) {
+	p := puzzle.NewPuzzle(puzzle.RandomPuzzleID(), e.key.propertyID, e.key.difficulty)
+	p.Version = e.key.version
+	if err := p.Init(validity); err != nil {
+		return nil, err
+	}
+
+	payload, err := pp.serialize(ctx, p, salt, extraSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pooledPuzzle{puzzle: p, payload: payload, salt: salt, extraSalt: extraSalt, created: tnow}, nil
+}
+
+func (pp *puzzlePool) fill(ctx context.Context, tnow time.Time) {
+	type task struct {
+		entry     *puzzlePoolEntry
+		extraSalt []byte
+		validity  time.Duration
+	}
+
+	pp.lock.Lock()
+	tasks := make([]task, 0, len(pp.entries))
+	for key, e := range pp.entries {
+		if tnow.Sub(e.requested) > puzzlePoolIdleTimeout {
+			delete(pp.entries, key)
+			continue
+		}
+
+		tasks = append(tasks, task{entry: e, extraSalt: e.extraSalt, validity: e.validity})
+	}
+	pp.lock.Unlock()
+
+	salt := pp.salt()
+
+	for _, t := range tasks {
+		for len(t.entry.puzzles) < cap(t.entry.puzzles) {
+			pooled, err := pp.generate(ctx, t.entry, salt, t.extraSalt, t.validity, tnow)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to generate pooled puzzle", "difficulty", t.entry.key.difficulty, common.ErrAttr(err))
+				return
+			}
+
+			select {
+			case t.entry.puzzles <- pooled:
+			default:
+				// entry was filled concurrently
+			}
+		}
+	}
+}
+
+// expire drops puzzles that are about to become too old to be issued, so that they are re-generated with
+// the next refill before traffic arrives
+func (pp *puzzlePool) expire(tnow time.Time) {
+	pp.lock.Lock()
+	entries := make([]*puzzlePoolEntry, 0, len(pp.entries))
+	for _, e := range pp.entries {
+		entries = append(entries, e)
+	}
+	pp.lock.Unlock()
+
+	for _, e := range entries {
+		for count := len(e.puzzles); count > 0; count-- {
+			select {
+			case pooled := <-e.puzzles:
+				if tnow.Sub(pooled.created) < puzzlePoolMaxAge/2 {
+					select {
+					case e.puzzles <- pooled:
+					default:
+					}
+				}
+			default:
+				count = 1
+			}
+		}
+	}
+}
+
+func (pp *puzzlePool) Start(ctx context.Context, salt func() *puzzle.Salt) {
+	if pp == nil {
+		return
+	}
+
+	pp.salt = salt
+	ctx, pp.cancel = context.WithCancel(context.WithValue(ctx, common.TraceIDContextKey, "puzzle_pool"))
+
+	pp.wg.Add(1)
+	go func() {
+		defer pp.wg.Done()
+
+		ticker := time.NewTicker(puzzlePoolRefillInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				slog.DebugContext(ctx, "Stopped refilling puzzle pool")
+				return
+			case <-pp.refill:
+				pp.fill(ctx, time.Now())
+			case <-ticker.C:
+				tnow := time.Now()
+				pp.expire(tnow)
+				pp.fill(ctx, tnow)
+			}
+		}
+	}()
+
+	slog.InfoContext(ctx, "Started puzzle pool", "size", pp.size, "maxKeys", maxPuzzlePoolKeys)
+}
+
+func (pp *puzzlePool) Shutdown() {
+	if (pp == nil) || (pp.cancel == nil) {
+		return
+	}
+
+	pp.cancel()
+	pp.wg.Wait()
+}
+
+func (pp *puzzlePool) Stats() []*common.PuzzlePoolStats {
+	result := make([]*common.PuzzlePoolStats, 0, len(puzzlePoolBuckets))
+	for i, b := range puzzlePoolBuckets {
+		result = append(result, &common.PuzzlePoolStats{
+			Bucket:    b.name,
+			Hits:      pp.hits[i].Load(),
+			Fallbacks: pp.fallbacks[i].Load(),
+		})
+	}
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	for _, e := range pp.entries {
+		s := result[puzzlePoolBucket(e.key.difficulty)]
+		s.Depth += len(e.puzzles)
+		s.Capacity += cap(e.puzzles)
+	}
+
+	return result
+}
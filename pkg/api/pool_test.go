@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func newCountingPuzzlePool(size int, salt *puzzle.Salt, signed *atomic.Int32) *puzzlePool {
+	pool := NewPuzzlePool(size)
+	pool.salt = func() *puzzle.Salt { return salt }
+	pool.serialize = func(ctx context.Context, p *puzzle.Puzzle, salt *puzzle.Salt, extraSalt []byte) (*puzzle.PuzzlePayload, error) {
+		signed.Add(1)
+		return p.Serialize(ctx, salt, extraSalt)
+	}
+	return pool
+}
+
+func newPoolTestPuzzle(difficulty common.DifficultyLevel) *puzzle.Puzzle {
+	return puzzle.NewPuzzle(puzzle.RandomPuzzleID(), randomUUID().Bytes, uint8(difficulty))
+}
+
+func TestPuzzlePoolDisabled(t *testing.T) {
+	t.Parallel()
+
+	pool := NewPuzzlePool(0)
+	if pool != nil {
+		t.Fatal("Expected pool to be disabled")
+	}
+
+	if p, payload := pool.Get(newPoolTestPuzzle(common.DifficultyLevelMedium), nil, time.Minute, time.Now()); (p != nil) || (payload != nil) {
+		t.Errorf("Expected no puzzle from disabled pool")
+	}
+
+	pool.Shutdown()
+}
+
+func TestPuzzlePoolSkipsSigning(t *testing.T) {
+	t.Parallel()
+
+	const size = 3
+	ctx := context.TODO()
+	salt := puzzle.NewSalt([]byte("salt"))
+	extraSalt := []byte("property")
+	var signed atomic.Int32
+	pool := newCountingPuzzlePool(size, salt, &signed)
+
+	request := newPoolTestPuzzle(common.DifficultyLevelSmall)
+	tnow := time.Now()
+
+	// first request only marks property and difficulty to be pre-generated
+	if _, payload := pool.Get(request, extraSalt, time.Minute, tnow); payload != nil {
+		t.Fatal("Expected empty pool")
+	}
+
+	pool.fill(ctx, tnow)
+	if actual := signed.Load(); actual != size {
+		t.Fatalf("Unexpected number of signed puzzles after refill: %v", actual)
+	}
+
+	ids := make(map[uint64]struct{})
+	for i := 0; i < size; i++ {
+		p, payload := pool.Get(request, extraSalt, time.Minute, tnow)
+		if payload == nil {
+			t.Fatalf("Expected puzzle from the pool (%v)", i)
+		}
+
+		if (p.PropertyID != request.PropertyID) || (p.Difficulty != request.Difficulty) || p.IsStub() {
+			t.Errorf("Unexpected pooled puzzle: %+v", p)
+		}
+
+		expected, err := p.Serialize(ctx, salt, extraSalt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expectedBuf, actualBuf bytes.Buffer
+		_ = expected.Write(&expectedBuf)
+		_ = payload.Write(&actualBuf)
+		if !bytes.Equal(expectedBuf.Bytes(), actualBuf.Bytes()) {
+			t.Errorf("Pooled payload does not match the puzzle")
+		}
+
+		ids[p.PuzzleID] = struct{}{}
+	}
+
+	if len(ids) != size {
+		t.Errorf("Pooled puzzles are not unique: %v", len(ids))
+	}
+
+	// pool hits are served without signing
+	if actual := signed.Load(); actual != size {
+		t.Errorf("Puzzles were signed on pool hit: %v", actual)
+	}
+
+	// refill goroutine is not running
+	if _, payload := pool.Get(request, extraSalt, time.Minute, tnow); payload != nil {
+		t.Errorf("Expected pool to be exhausted")
+	}
+
+	for _, s := range pool.Stats() {
+		if s.Bucket == "low" && ((s.Hits != size) || (s.Fallbacks != 2) || (s.Depth != 0) || (s.Capacity != size)) {
+			t.Errorf("Unexpected stats of low bucket: %+v", s)
+		}
+	}
+}
+
+func TestPuzzlePoolStalePuzzles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	salt := puzzle.NewSalt([]byte("salt"))
+	var signed atomic.Int32
+	pool := newCountingPuzzlePool(1, salt, &signed)
+
+	request := newPoolTestPuzzle(common.DifficultyLevelHigh)
+	tnow := time.Now()
+
+	refill := func() {
+		_, _ = pool.Get(request, []byte("property"), time.Minute, tnow)
+		pool.fill(ctx, tnow)
+	}
+
+	refill()
+	if _, payload := pool.Get(request, []byte("changed"), time.Minute, tnow); payload != nil {
+		t.Errorf("Puzzle signed with previous property salt was served")
+	}
+
+	refill()
+	if _, payload := pool.Get(request, []byte("property"), time.Minute, tnow.Add(puzzlePoolMaxAge+time.Second)); payload != nil {
+		t.Errorf("Expired puzzle was served")
+	}
+
+	refill()
+	withAction := *request
+	withAction.Action = "login"
+	if _, payload := pool.Get(&withAction, []byte("property"), time.Minute, tnow); payload != nil {
+		t.Errorf("Pooled puzzle was served for request with action")
+	}
+
+	pool.salt = func() *puzzle.Salt { return puzzle.NewSalt([]byte("rotated")) }
+	if _, payload := pool.Get(request, []byte("property"), time.Minute, tnow); payload != nil {
+		t.Errorf("Puzzle signed with previous salt was served")
+	}
+}
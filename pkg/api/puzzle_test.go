@@ -25,6 +25,14 @@ const (
 )
 
 func puzzleSuite(sitekey, domain string) (*http.Response, error) {
+	return puzzleSuiteWithVersion(sitekey, domain, "")
+}
+
+func puzzleSuiteWithVersion(sitekey, domain, version string) (*http.Response, error) {
+	return puzzleSuiteWithParams(sitekey, domain, version, "" /*action*/)
+}
+
+func puzzleSuiteWithParams(sitekey, domain, version, action string) (*http.Response, error) {
 	srv := http.NewServeMux()
 	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
 
@@ -37,9 +45,15 @@ func puzzleSuite(sitekey, domain string) (*http.Response, error) {
 
 	req.Header.Set("Origin", common_test.PrependProtocol(domain))
 	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+	if len(version) > 0 {
+		req.Header.Set(common.HeaderCaptchaVersion, version)
+	}
 
 	q := req.URL.Query()
 	q.Add(common.ParamSiteKey, sitekey)
+	if len(action) > 0 {
+		q.Add(common.ParamAction, action)
+	}
 	req.URL.RawQuery = q.Encode()
 
 	w := httptest.NewRecorder()
@@ -194,6 +208,251 @@ func TestGetPuzzle(t *testing.T) {
 	}
 }
 
+func TestGetPausedPropertyPuzzle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       t.Name(),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Impl().UpdatePropertyPaused(ctx, property.ID, true /*paused*/); err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	resp, err := puzzleSuite(sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	status, _, err := statusSuite(sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (status == nil) || (status.Status != widgetStatusPaused) {
+		t.Errorf("Unexpected widget status: %+v", status)
+	}
+
+	if _, err := store.Impl().UpdatePropertyPaused(ctx, property.ID, false /*paused*/); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = puzzleSuite(sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code after resume %d", resp.StatusCode)
+	}
+}
+
+func mobilePuzzleSuite(sitekey, appID string) (*http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req, err := http.NewRequest(http.MethodGet, "/"+common.PuzzleEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderAppID, appID)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	q := req.URL.Query()
+	q.Add(common.ParamSiteKey, sitekey)
+	req.URL.RawQuery = q.Encode()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+func TestGetMobilePuzzle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       t.Name(),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+	const appID = "com.example.app"
+
+	// property is not in mobile mode yet
+	resp, err := mobilePuzzleSuite(sitekey, appID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	if _, err := store.Impl().UpdatePropertyAppIDs(ctx, property.ID, []string{appID}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = mobilePuzzleSuite(sitekey, appID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code in mobile mode %d", resp.StatusCode)
+	}
+
+	resp, err = mobilePuzzleSuite(sitekey, "com.example.other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code for other app %d", resp.StatusCode)
+	}
+}
+
+func TestRequiresDomainVerification(t *testing.T) {
+	t.Parallel()
+
+	verifiedAt := db.Timestampz(time.Now())
+
+	testCases := []struct {
+		required bool
+		property *dbgen.Property
+		expected bool
+	}{
+		{false, &dbgen.Property{}, false},
+		{true, &dbgen.Property{}, true},
+		{true, &dbgen.Property{DomainVerifiedAt: verifiedAt}, false},
+		{true, &dbgen.Property{TestMode: true}, false},
+	}
+
+	for i, tc := range testCases {
+		am := &AuthMiddleware{RequireVerifiedDomain: tc.required}
+		if actual := am.requiresDomainVerification(tc.property); actual != tc.expected {
+			t.Errorf("Unexpected result at %v: %v (expected %v)", i, actual, tc.expected)
+		}
+	}
+}
+
+func TestGetArgon2idPuzzle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       t.Name(),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err = store.Impl().UpdateProperty(ctx, &dbgen.UpdatePropertyParams{
+		ID:               property.ID,
+		Name:             property.Name,
+		Level:            property.Level,
+		Growth:           property.Growth,
+		ValidityInterval: property.ValidityInterval,
+		Algorithm:        dbgen.PowAlgorithmArgon2id,
+		WidgetChannel:    property.WidgetChannel,
+		DataRegion:       property.DataRegion,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	testCases := []struct {
+		widgetVersion string
+		puzzleVersion uint8
+	}{
+		{"", puzzle.VersionBlake2b},
+		{"1", puzzle.VersionBlake2b},
+		{"2", puzzle.VersionArgon2id},
+	}
+
+	for _, tc := range testCases {
+		resp, err := puzzleSuiteWithVersion(sitekey, property.Domain, tc.widgetVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status code %d", resp.StatusCode)
+		}
+
+		p, _, err := parsePuzzle(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Version != tc.puzzleVersion {
+			t.Errorf("Unexpected puzzle version %v for widget version %q", p.Version, tc.widgetVersion)
+		}
+	}
+}
+
 func TestGetTestPuzzle(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -260,3 +519,44 @@ func TestPuzzleCachePriority(t *testing.T) {
 		t.Errorf("Unexpected status code %d", resp.StatusCode)
 	}
 }
+
+func TestIsValidRequestAction(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		query string
+		valid bool
+	}{
+		{"", true},
+		{"action=login", true},
+		{"action=signup%2Fstep-1", true},
+		{"action=%3Cscript%3E", false},
+		{"action=" + strings.Repeat("a", puzzle.MaxActionLength+1), false},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/"+common.PuzzleEndpoint+"?sitekey=abc&"+tc.query, nil)
+		if actual := isValidRequestAction(req); actual != tc.valid {
+			t.Errorf("isValidRequestAction(%q) = %v, expected %v", tc.query, actual, tc.valid)
+		}
+	}
+}
+
+func TestGetPuzzleInvalidAction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	sitekey := db.UUIDToSiteKey(*randomUUID())
+
+	resp, err := puzzleSuiteWithParams(sitekey, testPropertyDomain, "", "no spaces allowed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+}
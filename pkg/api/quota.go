@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	maxQuotaUsers = 10_000
+	// monthly usage is aggregated in ClickHouse anyways, so there's no point to refresh it often
+	quotaTTL = 5 * time.Minute
+	// how long we wait before retrying to fetch quota that we failed to fetch
+	quotaMissingTTL = 1 * time.Minute
+)
+
+var (
+	quotaLimitHeader     = http.CanonicalHeaderKey("X-Quota-Limit")
+	quotaRemainingHeader = http.CanonicalHeaderKey("X-Quota-Remaining")
+	quotaResetHeader     = http.CanonicalHeaderKey("X-Quota-Reset")
+)
+
+// userQuota is the monthly requests quota of the user's plan and usage in the current (UTC) month
+type userQuota struct {
+	Limit int64
+	Used  int64
+	Reset time.Time
+}
+
+func (q *userQuota) Remaining() int64 {
+	return max(0, q.Limit-q.Used)
+}
+
+func newQuotaCache() common.Cache[int32, *userQuota] {
+	return db.NewMemoryCache[int32, *userQuota](maxQuotaUsers, nil /*missing value*/, db.HashInt32)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *Server) fetchUserQuota(ctx context.Context, userID int32, tnow time.Time) (*userQuota, error) {
+	user, err := s.BusinessDB.Impl().RetrieveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.SubscriptionID.Valid {
+		return nil, db.ErrRecordNotFound
+	}
+
+	subscription, err := s.BusinessDB.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.Auth.PlanService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, s.Stage,
+		db.IsInternalSubscription(subscription.Source))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find billing plan for quota", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	from := monthStart(tnow)
+
+	stats, err := s.TimeSeries.ReadAccountStats(ctx, userID, from, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &userQuota{
+		Limit: plan.RequestsLimit(),
+		Reset: from.AddDate(0, 1, 0),
+	}
+
+	for _, st := range stats {
+		quota.Used += int64(st.Count)
+	}
+
+	return quota, nil
+}
+
+// refreshUserQuota is executed in the background to not query databases on the hot path
+func (s *Server) refreshUserQuota(userID int32) {
+	ctx := common.TraceContext(context.Background(), "refresh_quota")
+
+	quota, err := s.fetchUserQuota(ctx, userID, time.Now().UTC())
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to fetch user quota", "userID", userID, common.ErrAttr(err))
+		return
+	}
+
+	_ = s.quotas.Set(ctx, userID, quota, quotaTTL)
+
+	slog.DebugContext(ctx, "Refreshed user quota", "userID", userID, "limit", quota.Limit, "used", quota.Used)
+}
+
+// writeQuotaHeaders sets monthly quota headers for the owner of the API key (if quota is already known)
+func (s *Server) writeQuotaHeaders(ctx context.Context, w http.ResponseWriter) {
+	userID, ok := ctx.Value(common.APIKeyOwnerContextKey).(int32)
+	if !ok || (userID <= 0) {
+		return
+	}
+
+	quota, err := s.quotas.Get(ctx, userID)
+	if err == db.ErrCacheMiss {
+		// NOTE: we put a placeholder in order to not refresh concurrently for the same user
+		_ = s.quotas.SetMissing(ctx, userID, quotaMissingTTL)
+		go s.refreshUserQuota(userID)
+		return
+	}
+
+	if (err != nil) || (quota == nil) {
+		return
+	}
+
+	headers := w.Header()
+	headers[quotaLimitHeader] = []string{strconv.FormatInt(quota.Limit, 10)}
+	headers[quotaRemainingHeader] = []string{strconv.FormatInt(quota.Remaining(), 10)}
+	if reset := time.Until(quota.Reset); reset > 0 {
+		headers[quotaResetHeader] = []string{strconv.Itoa(int(reset.Seconds()))}
+	}
+}
@@ -0,0 +1,393 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	receiptAlgorithm  = "EdDSA"
+	receiptType       = "JWT"
+	receiptNonceBytes = 16
+	// public keys, retired since the start of the process, remain published so that older receipts can still be
+	// verified. Keys that have to survive restarts (and be published by every node) are configured explicitly
+	maxReceiptPublicKeys = 4
+)
+
+var (
+	errInvalidReceiptKey    = errors.New("verify receipt key must be a hex-encoded ed25519 seed")
+	errInvalidReceiptPublic = errors.New("previous verify receipt keys must be hex-encoded ed25519 public keys")
+	errReceiptsDisabled     = errors.New("verify receipts are not configured")
+	errInvalidReceiptFormat = errors.New("receipt format is not valid")
+	errUnknownReceiptKey    = errors.New("receipt is signed with unknown key")
+	errReceiptSignature     = errors.New("receipt signature is not valid")
+	// keys can be rotated so they are cached for a shorter period than other static responses
+	headersJWKS = map[string][]string{
+		http.CanonicalHeaderKey("Cache-Control"): []string{"public, max-age=3600"},
+	}
+)
+
+type receiptHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// ReceiptClaims is the payload of the signed receipt that is returned from /siteverify when requested
+type ReceiptClaims struct {
+	Issuer      string   `json:"iss"`
+	Sitekey     string   `json:"sub"`
+	IssuedAt    int64    `json:"iat"`
+	Nonce       string   `json:"jti"`
+	Success     bool     `json:"success"`
+	ErrorCodes  []string `json:"error-codes,omitempty"`
+	ChallengeTS int64    `json:"challenge_ts,omitempty"`
+	Hostname    string   `json:"hostname,omitempty"`
+}
+
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+type JSONWebKeySet struct {
+	Keys []*JSONWebKey `json:"keys"`
+}
+
+type receiptKey struct {
+	id      string
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+func receiptKeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// parseReceiptPublicKeys parses comma-separated list of hex-encoded public keys (that can only verify receipts)
+func parseReceiptPublicKeys(value string) ([]*receiptKey, error) {
+	result := make([]*receiptKey, 0)
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+
+		data, err := hex.DecodeString(item)
+		if (err != nil) || (len(data) != ed25519.PublicKeySize) {
+			return result, errInvalidReceiptPublic
+		}
+
+		public := ed25519.PublicKey(data)
+		result = append(result, &receiptKey{id: receiptKeyID(public), public: public})
+	}
+
+	return result, nil
+}
+
+func parseReceiptKey(value string) (*receiptKey, error) {
+	seed, err := hex.DecodeString(value)
+	if (err != nil) || (len(seed) != ed25519.SeedSize) {
+		return nil, errInvalidReceiptKey
+	}
+
+	private := ed25519.NewKeyFromSeed(seed)
+	public := private.Public().(ed25519.PublicKey)
+
+	return &receiptKey{
+		id:      receiptKeyID(public),
+		private: private,
+		public:  public,
+	}, nil
+}
+
+// receiptSigner signs verification receipts with ed25519 key from config (receipts are disabled if key is not set).
+// Previous public keys from config are published in JWKS along with the current one, so that receipts signed
+// before key rotation can be verified regardless of restarts and which node serves JWKS.
+type receiptSigner struct {
+	configItem     common.ConfigItem
+	previousItem   common.ConfigItem
+	issuer         string
+	lock           sync.RWMutex
+	key            *receiptKey
+	previous       []*receiptKey
+	retired        []*receiptKey
+	source         string
+	previousSource string
+}
+
+func NewReceiptSigner(configItem, previousItem common.ConfigItem, issuer string) *receiptSigner {
+	return &receiptSigner{
+		configItem:   configItem,
+		previousItem: previousItem,
+		issuer:       issuer,
+	}
+}
+
+func (rs *receiptSigner) updatePrevious(ctx context.Context) error {
+	var source string
+	if rs.previousItem != nil {
+		source = rs.previousItem.Value()
+	}
+
+	if (source == rs.previousSource) && (rs.previous != nil) {
+		return nil
+	}
+
+	rs.previousSource = source
+
+	// invalid keys are skipped, but the rest are still published
+	previous, err := parseReceiptPublicKeys(source)
+	rs.previous = previous
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse previous verify receipt keys", "published", len(previous), common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (rs *receiptSigner) Update(ctx context.Context) error {
+	source := rs.configItem.Value()
+
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	perr := rs.updatePrevious(ctx)
+
+	if (source == rs.source) && ((rs.key != nil) || (len(source) == 0)) {
+		return perr
+	}
+
+	rs.source = source
+
+	if (rs.key != nil) && !slices.ContainsFunc(rs.retired, func(k *receiptKey) bool { return k.id == rs.key.id }) {
+		rs.retired = append([]*receiptKey{rs.key}, rs.retired...)
+		if len(rs.retired) > maxReceiptPublicKeys {
+			rs.retired = rs.retired[:maxReceiptPublicKeys]
+		}
+	}
+
+	if len(source) == 0 {
+		slog.InfoContext(ctx, "Verify receipts are disabled")
+		rs.key = nil
+		return perr
+	}
+
+	key, err := parseReceiptKey(source)
+	if err != nil {
+		rs.key = nil
+		return err
+	}
+
+	rs.key = key
+
+	// public key is what has to be configured as previous key after the next rotation
+	slog.InfoContext(ctx, "Updated verify receipt key", "kid", key.id, "public", hex.EncodeToString(key.public),
+		"previous", len(rs.previous))
+
+	return perr
+}
+
+// published returns current public key, followed by previous keys from config and keys retired by this process
+func (rs *receiptSigner) published() []*receiptKey {
+	result := make([]*receiptKey, 0, 1+len(rs.previous)+len(rs.retired))
+	seen := make(map[string]struct{})
+
+	add := func(k *receiptKey) {
+		if _, ok := seen[k.id]; !ok {
+			seen[k.id] = struct{}{}
+			result = append(result, k)
+		}
+	}
+
+	if rs.key != nil {
+		add(rs.key)
+	}
+
+	for _, k := range rs.previous {
+		add(k)
+	}
+
+	for _, k := range rs.retired {
+		add(k)
+	}
+
+	return result
+}
+
+func (rs *receiptSigner) Enabled() bool {
+	if rs == nil {
+		return false
+	}
+
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	return rs.key != nil
+}
+
+func (rs *receiptSigner) Sign(claims *ReceiptClaims) (string, error) {
+	rs.lock.RLock()
+	key := rs.key
+	rs.lock.RUnlock()
+
+	if key == nil {
+		return "", errReceiptsDisabled
+	}
+
+	header, err := json.Marshal(&receiptHeader{Algorithm: receiptAlgorithm, Type: receiptType, KeyID: key.id})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	sb.WriteByte('.')
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(payload))
+
+	signature := ed25519.Sign(key.private, []byte(sb.String()))
+
+	sb.WriteByte('.')
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(signature))
+
+	return sb.String(), nil
+}
+
+// Verify checks receipt signature against published keys and returns its claims
+func (rs *receiptSigner) Verify(receipt string) (*ReceiptClaims, error) {
+	parts := strings.Split(receipt, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidReceiptFormat
+	}
+
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errInvalidReceiptFormat
+	}
+
+	header := &receiptHeader{}
+	if err := json.Unmarshal(headerData, header); (err != nil) || (header.Algorithm != receiptAlgorithm) {
+		return nil, errInvalidReceiptFormat
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidReceiptFormat
+	}
+
+	var public ed25519.PublicKey
+	rs.lock.RLock()
+	for _, k := range rs.published() {
+		if k.id == header.KeyID {
+			public = k.public
+			break
+		}
+	}
+	rs.lock.RUnlock()
+
+	if public == nil {
+		return nil, errUnknownReceiptKey
+	}
+
+	if !ed25519.Verify(public, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, errReceiptSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errInvalidReceiptFormat
+	}
+
+	claims := &ReceiptClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, errInvalidReceiptFormat
+	}
+
+	return claims, nil
+}
+
+func (rs *receiptSigner) KeySet() *JSONWebKeySet {
+	result := &JSONWebKeySet{Keys: []*JSONWebKey{}}
+	if rs == nil {
+		return result
+	}
+
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	for _, k := range rs.published() {
+		result.Keys = append(result.Keys, &JSONWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(k.public),
+			KeyID:     k.id,
+			Use:       "sig",
+			Algorithm: receiptAlgorithm,
+		})
+	}
+
+	return result
+}
+
+func newReceiptNonce() string {
+	nonce := make([]byte, receiptNonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(nonce)
+}
+
+func (rs *receiptSigner) newClaims(sitekey string, response *VerifyResponseRecaptchaV2, tnow time.Time) *ReceiptClaims {
+	claims := &ReceiptClaims{
+		Issuer:     rs.issuer,
+		Sitekey:    sitekey,
+		IssuedAt:   tnow.Unix(),
+		Nonce:      newReceiptNonce(),
+		Success:    response.Success,
+		ErrorCodes: response.ErrorCodes,
+		Hostname:   response.Hostname,
+	}
+
+	if challengeTS := time.Time(response.ChallengeTS); !challengeTS.IsZero() {
+		claims.ChallengeTS = challengeTS.Unix()
+	}
+
+	return claims
+}
+
+// wantsReceipt checks if the caller of /siteverify requested a signed receipt
+func wantsReceipt(r *http.Request) bool {
+	return common.EnvToBool(r.Header.Get(common.HeaderVerifyReceipt))
+}
+
+func (s *Server) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	common.SendJSONResponse(ctx, w, s.Receipts.KeySet(), headersJWKS)
+}
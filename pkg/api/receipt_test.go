@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+const (
+	testReceiptKey      = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	testOtherReceiptKey = "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb"
+)
+
+func TestReceiptSignVerify(t *testing.T) {
+	ctx := context.TODO()
+
+	signer := NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, testReceiptKey), nil /*previous keys*/, "https://api.example.com")
+	if err := signer.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !signer.Enabled() {
+		t.Fatal("Receipts are not enabled")
+	}
+
+	response := &VerifyResponseRecaptchaV2{
+		VerifyResponse: VerifyResponse{Success: true},
+		ChallengeTS:    common.JSONTime(time.Now().Add(-time.Minute)),
+		Hostname:       "example.com",
+	}
+
+	tnow := time.Now().UTC()
+	receipt, err := signer.Sign(signer.newClaims("sitekey", response, tnow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := signer.Verify(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !claims.Success || (claims.Sitekey != "sitekey") || (claims.Hostname != "example.com") ||
+		(claims.IssuedAt != tnow.Unix()) || (len(claims.Nonce) == 0) || (claims.ChallengeTS == 0) {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	parts := strings.Split(receipt, ".")
+	tampered := parts[0] + "." + parts[1] + "A." + parts[2]
+	if _, err := signer.Verify(tampered); err == nil {
+		t.Error("Tampered receipt was verified")
+	}
+}
+
+func TestReceiptKeyRotation(t *testing.T) {
+	ctx := context.TODO()
+
+	item := &mutableConfigItem{key: common.VerifyReceiptKey, value: testReceiptKey}
+	signer := NewReceiptSigner(item, nil /*previous keys*/, "")
+	if err := signer.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	oldReceipt, err := signer.Sign(&ReceiptClaims{Success: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item.value = testOtherReceiptKey
+	if err := signer.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := signer.KeySet().Keys; (len(keys) != 2) || (keys[0].KeyID == keys[1].KeyID) {
+		t.Errorf("Unexpected published keys: %v", keys)
+	}
+
+	if _, err := signer.Verify(oldReceipt); err != nil {
+		t.Errorf("Receipt signed with previous key failed to verify: %v", err)
+	}
+
+	other := NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, testReceiptKey), nil /*previous keys*/, "")
+	_ = other.Update(ctx)
+	if _, err := other.Verify(oldReceipt); err != nil {
+		t.Errorf("Receipt failed to verify with the same key: %v", err)
+	}
+
+	item.value = ""
+	if err := signer.Update(ctx); (err != nil) || signer.Enabled() {
+		t.Errorf("Receipts were not disabled (err: %v)", err)
+	}
+
+	if _, err := signer.Sign(&ReceiptClaims{}); err != errReceiptsDisabled {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestInvalidReceiptKey(t *testing.T) {
+	signer := NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, "abcd"), nil /*previous keys*/, "")
+	if err := signer.Update(context.TODO()); err != errInvalidReceiptKey {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if signer.Enabled() {
+		t.Error("Receipts are enabled with invalid key")
+	}
+}
+
+func TestReceiptPreviousKeys(t *testing.T) {
+	ctx := context.TODO()
+
+	oldSigner := NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, testReceiptKey), nil /*previous keys*/, "")
+	if err := oldSigner.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	oldReceipt, err := oldSigner.Sign(&ReceiptClaims{Success: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey, _ := parseReceiptKey(testReceiptKey)
+
+	// e.g. other node or the same one after restart, that only knows the current key from config
+	signer := NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, testOtherReceiptKey),
+		config.NewStaticValue(common.VerifyReceiptPreviousKeysKey, hex.EncodeToString(oldKey.public)), "")
+	if err := signer.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := signer.KeySet().Keys; (len(keys) != 2) || (keys[1].KeyID != oldKey.id) {
+		t.Errorf("Unexpected published keys: %v", keys)
+	}
+
+	if _, err := signer.Verify(oldReceipt); err != nil {
+		t.Errorf("Receipt signed with previous key failed to verify: %v", err)
+	}
+
+	invalid := NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, testOtherReceiptKey),
+		config.NewStaticValue(common.VerifyReceiptPreviousKeysKey, "abcd"), "")
+	if err := invalid.Update(ctx); (err != errInvalidReceiptPublic) || !invalid.Enabled() {
+		t.Errorf("Unexpected result with invalid previous keys: %v", err)
+	}
+}
+
+func TestReceiptSignedAfterReplay(t *testing.T) {
+	ctx := context.TODO()
+
+	srv := &Server{Receipts: NewReceiptSigner(config.NewStaticValue(common.VerifyReceiptKey, testReceiptKey), nil /*previous keys*/, "")}
+	if err := srv.Receipts.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// response, cached for replays, is not signed
+	cached := &VerifyResponseRecaptchaV2{VerifyResponse: VerifyResponse{Success: true}, sitekey: "sitekey"}
+
+	withoutReceipt := httptest.NewRequest(http.MethodPost, "/", nil)
+	if response := srv.withReceipt(ctx, withoutReceipt, cached); len(response.Receipt) != 0 {
+		t.Error("Receipt was included without request")
+	}
+
+	withReceipt := httptest.NewRequest(http.MethodPost, "/", nil)
+	withReceipt.Header.Set(common.HeaderVerifyReceipt, "true")
+
+	first := srv.withReceipt(ctx, withReceipt, cached)
+	retry := srv.withReceipt(ctx, withReceipt, cached)
+	if (len(first.Receipt) == 0) || (len(retry.Receipt) == 0) || (first.Receipt == retry.Receipt) {
+		t.Errorf("Unexpected receipts: %q, %q", first.Receipt, retry.Receipt)
+	}
+
+	if len(cached.Receipt) != 0 {
+		t.Error("Cached response was modified")
+	}
+
+	claims, err := srv.Receipts.Verify(retry.Receipt)
+	if (err != nil) || !claims.Success || (claims.Sitekey != "sitekey") {
+		t.Errorf("Unexpected receipt claims: %+v (%v)", claims, err)
+	}
+}
@@ -1,53 +1,175 @@
 package api
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/kms"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 )
 
+const (
+	puzzleSaltLabel     = "puzzle-salt:"
+	fingerprintKeyLabel = "user-fingerprint-key:"
+)
+
 var (
 	errUAKeyTooLong = errors.New("user fingerprint key is too long")
 )
 
+// puzzleSalt is either a static value from config or derived with KMS and rotated every period.
+// After rotation the previous salt is still accepted so that puzzles issued before it can be verified.
 type puzzleSalt struct {
 	configItem common.ConfigItem
+	deriver    *kms.Deriver
+	lock       sync.RWMutex
 	value      *puzzle.Salt
+	// salts that are accepted for verification, current one goes first
+	accepted []*puzzle.Salt
+	source   string
+	epoch    int64
 }
 
-func NewPuzzleSalt(configItem common.ConfigItem) *puzzleSalt {
+func NewPuzzleSalt(configItem common.ConfigItem, deriver *kms.Deriver) *puzzleSalt {
 	return &puzzleSalt{
 		configItem: configItem,
+		deriver:    deriver,
 	}
 }
 
-func (ps *puzzleSalt) Update() error {
-	ps.value = puzzle.NewSalt([]byte(ps.configItem.Value()))
+func (ps *puzzleSalt) Update(ctx context.Context) error {
+	source := ps.configItem.Value()
+
+	if ps.deriver == nil {
+		ps.lock.Lock()
+		defer ps.lock.Unlock()
+
+		if (ps.value != nil) && (source == ps.source) {
+			return nil
+		}
+
+		salt := puzzle.NewSalt([]byte(source))
+		if ps.value != nil {
+			slog.InfoContext(ctx, "Puzzle salt was changed, previous salt is still accepted")
+			ps.accepted = []*puzzle.Salt{salt, ps.value}
+		} else {
+			ps.accepted = []*puzzle.Salt{salt}
+		}
+		ps.value = salt
+		ps.source = source
+
+		return nil
+	}
+
+	epoch := ps.deriver.Epoch(time.Now())
+
+	ps.lock.RLock()
+	upToDate := (ps.value != nil) && (source == ps.source) && (epoch == ps.epoch)
+	ps.lock.RUnlock()
+
+	if upToDate {
+		return nil
+	}
+
+	// instances do not switch at exactly the same moment, so the next salt is accepted too
+	salts := make([]*puzzle.Salt, 0, 3)
+	for _, e := range []int64{epoch, epoch - 1, epoch + 1} {
+		data, err := ps.deriver.Derive(ctx, puzzleSaltLabel+source, e)
+		if err != nil {
+			return err
+		}
+		salts = append(salts, puzzle.NewSalt(data))
+	}
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	ps.value = salts[0]
+	ps.accepted = salts
+	ps.source = source
+	ps.epoch = epoch
+
+	slog.InfoContext(ctx, "Derived puzzle salt with KMS", "epoch", epoch)
+
 	return nil
 }
 
 func (ps *puzzleSalt) Value() *puzzle.Salt {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
 	return ps.value
 }
 
+// Verify checks puzzle signature against current and previous (still accepted) salts
+func (ps *puzzleSalt) Verify(ctx context.Context, payload *puzzle.VerifyPayload, extraSalt []byte) error {
+	ps.lock.RLock()
+	accepted := ps.accepted
+	ps.lock.RUnlock()
+
+	var err error = puzzle.ErrSignKeyMismatch
+	for i, salt := range accepted {
+		if err = payload.VerifySignature(ctx, salt, extraSalt); err == nil {
+			if i > 0 {
+				slog.DebugContext(ctx, "Puzzle was verified with previous salt")
+			}
+			return nil
+		}
+	}
+
+	return err
+}
+
 type userFingerprintKey struct {
 	configItem common.ConfigItem
+	deriver    *kms.Deriver
+	lock       sync.RWMutex
 	key        []byte
+	source     string
+	epoch      int64
 }
 
-func NewUserFingerprintKey(configItem common.ConfigItem) *userFingerprintKey {
+func NewUserFingerprintKey(configItem common.ConfigItem, deriver *kms.Deriver) *userFingerprintKey {
 	return &userFingerprintKey{
 		configItem: configItem,
+		deriver:    deriver,
 		key:        make([]byte, 64),
 	}
 }
 
-func (k *userFingerprintKey) Update() error {
-	byteArray, err := hex.DecodeString(k.configItem.Value())
-	if err != nil {
-		return err
+func (k *userFingerprintKey) Update(ctx context.Context) error {
+	source := k.configItem.Value()
+
+	var byteArray []byte
+	var epoch int64
+
+	if k.deriver == nil {
+		var err error
+		byteArray, err = hex.DecodeString(source)
+		if err != nil {
+			return err
+		}
+	} else {
+		epoch = k.deriver.Epoch(time.Now())
+
+		k.lock.RLock()
+		upToDate := (source == k.source) && (epoch == k.epoch)
+		k.lock.RUnlock()
+
+		if upToDate {
+			return nil
+		}
+
+		var err error
+		byteArray, err = k.deriver.Derive(ctx, fingerprintKeyLabel+source, epoch)
+		if err != nil {
+			return err
+		}
 	}
 
 	// this requirement comes from blake256 constructor
@@ -55,11 +177,53 @@ func (k *userFingerprintKey) Update() error {
 		return errUAKeyTooLong
 	}
 
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
 	k.key = byteArray
+	k.source = source
+	k.epoch = epoch
 
 	return nil
 }
 
 func (uf *userFingerprintKey) Value() []byte {
+	uf.lock.RLock()
+	defer uf.lock.RUnlock()
+
 	return uf.key
 }
+
+// RotateSecretsJob re-derives KMS-backed secrets when rotation period changes. It runs on every instance.
+type RotateSecretsJob struct {
+	Salt           *puzzleSalt
+	FingerprintKey *userFingerprintKey
+}
+
+var _ common.PeriodicJob = (*RotateSecretsJob)(nil)
+
+func (j *RotateSecretsJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *RotateSecretsJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *RotateSecretsJob) Name() string {
+	return "rotate_secrets_job"
+}
+
+func (j *RotateSecretsJob) RunOnce(ctx context.Context) error {
+	if err := j.Salt.Update(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to update puzzle salt", common.ErrAttr(err))
+		return err
+	}
+
+	if err := j.FingerprintKey.Update(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to update user fingerprint key", common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/kms"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+type fakeKMSSigner struct {
+	key []byte
+}
+
+func (f *fakeKMSSigner) Name() string { return "fake" }
+
+func (f *fakeKMSSigner) MAC(ctx context.Context, data []byte) ([]byte, error) {
+	h := hmac.New(sha256.New, f.key)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+type mutableConfigItem struct {
+	key   common.ConfigKey
+	value string
+}
+
+func (i *mutableConfigItem) Key() common.ConfigKey { return i.key }
+func (i *mutableConfigItem) Value() string         { return i.value }
+
+func signedPayload(t *testing.T, salt *puzzle.Salt) *puzzle.VerifyPayload {
+	ctx := context.TODO()
+
+	p := puzzle.NewPuzzle(puzzle.RandomPuzzleID(), [16]byte{1, 2, 3}, 0 /*difficulty*/)
+	if err := p.Init(puzzle.DefaultValidityPeriod); err != nil {
+		t.Fatal(err)
+	}
+
+	pp, err := p.Serialize(ctx, salt, nil /*extra salt*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("AAAA.")
+	if err := pp.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := puzzle.ParseVerifyPayload(ctx, buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return payload
+}
+
+func TestStaticSaltChange(t *testing.T) {
+	ctx := context.TODO()
+
+	item := &mutableConfigItem{key: common.APISaltKey, value: "first salt"}
+	salt := NewPuzzleSalt(item, nil /*deriver*/)
+	if err := salt.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	oldPayload := signedPayload(t, salt.Value())
+
+	item.value = "second salt"
+	if err := salt.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	newPayload := signedPayload(t, salt.Value())
+
+	if err := salt.Verify(ctx, oldPayload, nil); err != nil {
+		t.Errorf("Puzzle signed with previous salt failed to verify: %v", err)
+	}
+
+	if err := salt.Verify(ctx, newPayload, nil); err != nil {
+		t.Errorf("Puzzle signed with current salt failed to verify: %v", err)
+	}
+
+	other := NewPuzzleSalt(config.NewStaticValue(common.APISaltKey, "other salt"), nil /*deriver*/)
+	_ = other.Update(ctx)
+	if err := salt.Verify(ctx, signedPayload(t, other.Value()), nil); err == nil {
+		t.Errorf("Puzzle signed with unknown salt was verified")
+	}
+}
+
+func TestDerivedSaltEpochs(t *testing.T) {
+	ctx := context.TODO()
+
+	deriver := &kms.Deriver{Signer: &fakeKMSSigner{key: []byte("kms key")}, Period: 7 * 24 * time.Hour}
+	epoch := deriver.Epoch(time.Now())
+
+	salt := NewPuzzleSalt(config.NewStaticValue(common.APISaltKey, "salt"), deriver)
+	if err := salt.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []int64{epoch - 1, epoch, epoch + 1} {
+		data, err := deriver.Derive(ctx, puzzleSaltLabel+"salt", e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := salt.Verify(ctx, signedPayload(t, puzzle.NewSalt(data)), nil); err != nil {
+			t.Errorf("Puzzle signed in epoch %v (current %v) failed to verify: %v", e, epoch, err)
+		}
+	}
+
+	data, _ := deriver.Derive(ctx, puzzleSaltLabel+"salt", epoch-2)
+	if err := salt.Verify(ctx, signedPayload(t, puzzle.NewSalt(data)), nil); err == nil {
+		t.Errorf("Puzzle signed two epochs ago was verified")
+	}
+
+	key := NewUserFingerprintKey(config.NewStaticValue(common.UserFingerprintIVKey, "abcd"), deriver)
+	if err := key.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(key.Value()) == 0 || len(key.Value()) > 64 {
+		t.Errorf("Unexpected derived fingerprint key length: %v", len(key.Value()))
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	testOutcomePass = "pass"
+	testOutcomeFail = "fail"
+)
+
+// forcedTestOutcome returns verification result requested by the caller if the puzzle was issued for the sandbox
+// (test mode) property of the API key owner. Only the signature is checked, while expiration, replay and solutions
+// are not, so that integration tests can reuse the same payload. Forcing the result is the only thing that makes
+// sandbox different: such verifications are recorded (tagged) and counted the same way as all others.
+// For all other properties the parameter is ignored
+func (s *Server) forcedTestOutcome(ctx context.Context, payload *puzzle.VerifyPayload, expectedOwner puzzle.OwnerIDSource, outcome string) (*dbgen.Property, puzzle.VerifyError, bool) {
+	var verr puzzle.VerifyError
+	switch outcome {
+	case testOutcomePass:
+		verr = puzzle.VerifyNoError
+	case testOutcomeFail:
+		verr = puzzle.InvalidSolutionError
+	default:
+		slog.WarnContext(ctx, "Unknown test outcome requested", "outcome", outcome)
+		return nil, puzzle.VerifyNoError, false
+	}
+
+	p := payload.Puzzle()
+	if (p == nil) || p.IsZero() {
+		return nil, puzzle.VerifyNoError, false
+	}
+
+	sitekey := db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: p.PropertyID})
+	properties, err := s.Auth.verifyImpl().RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey: {}})
+	if (err != nil) || (len(properties) != 1) || !properties[0].TestMode {
+		return nil, puzzle.VerifyNoError, false
+	}
+
+	property := properties[0]
+	if ownerID, err := expectedOwner.OwnerID(ctx); (err != nil) || (ownerID != property.OrgOwnerID.Int32) ||
+		isOutsideOwnerOrg(ctx, expectedOwner, property) {
+		return nil, puzzle.VerifyNoError, false
+	}
+
+	var extraSalt []byte
+	if payload.NeedsExtraSalt() {
+		extraSalt = property.Salt
+	}
+
+	// outcome can only be forced for puzzles that were actually issued by us
+	if err := s.Salt.Verify(ctx, payload, extraSalt); err != nil {
+		slog.WarnContext(ctx, "Failed to verify sandbox puzzle signature", "propertyID", property.ID, "puzzleID", p.PuzzleID)
+		return property, puzzle.IntegrityError, true
+	}
+
+	slog.DebugContext(ctx, "Returning forced test outcome", "propertyID", property.ID, "outcome", outcome,
+		"puzzleID", p.PuzzleID)
+
+	return property, verr, true
+}
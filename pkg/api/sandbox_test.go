@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/testsupport"
+)
+
+func verifyTestOutcomeSuite(payload, secret, outcome string) (*VerifyResponse, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req := httptest.NewRequest(http.MethodPost, "/"+common.VerifyEndpoint+"?"+common.ParamTestOutcome+"="+outcome, strings.NewReader(payload))
+	req.Header.Set(common.HeaderAPIKey, secret)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", w.Code)
+	}
+
+	response := &VerifyResponse{}
+	if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func TestVerifyTestModeProperty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       fmt.Sprintf("%v property", t.Name()),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelSmall)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+		TestMode:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzleStr, solutionsStr, err := solutionsSuite(ctx, db.UUIDToSiteKey(property.ExternalID), property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := fmt.Sprintf("%s.%s", solutionsStr, puzzleStr)
+
+	apikey, err := store.Impl().CreateAPIKey(ctx, user.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := db.UUIDToSecret(apikey.ExternalID)
+
+	response, err := verifyTestOutcomeSuite(payload, secret, testOutcomeFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Success || (len(response.ErrorCodes) != 1) || (response.ErrorCodes[0] != puzzle.InvalidSolutionError.String()) {
+		t.Errorf("Unexpected forced failure response: %+v", response)
+	}
+
+	// the same payload can be verified repeatedly
+	for i := 0; i < 2; i++ {
+		response, err = verifyTestOutcomeSuite(payload, secret, testOutcomePass)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !response.Success {
+			t.Errorf("Unexpected forced success response (%v): %+v", i, response)
+		}
+	}
+
+	// forced outcome is only honoured for puzzles that were issued by us
+	parts := strings.Split(payload, ".")
+	signature, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[0] ^= 0xff
+	parts[2] = base64.StdEncoding.EncodeToString(signature)
+
+	response, err = verifyTestOutcomeSuite(strings.Join(parts, "."), secret, testOutcomePass)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Success || (len(response.ErrorCodes) != 1) || (response.ErrorCodes[0] != puzzle.IntegrityError.String()) {
+		t.Errorf("Unexpected forced response for tampered payload: %+v", response)
+	}
+}
+
+func TestSandboxDoesNotCountAgainstQuota(t *testing.T) {
+	t.Parallel()
+
+	timeSeries := testsupport.NewTimeSeries()
+	levels := difficulty.NewLevels(timeSeries, monitoring.NewStub(), 10 /*batch size*/, testBucketSize)
+	levels.Init(10*time.Millisecond /*access log*/, time.Hour /*backfill*/)
+	defer levels.Shutdown()
+
+	srv := &Server{Levels: levels}
+
+	const sandboxUserID, regularUserID = 1001, 1002
+	sandbox := &dbgen.Property{
+		ID:         1,
+		ExternalID: *randomUUID(),
+		OrgOwnerID: db.Int(sandboxUserID),
+		OrgID:      db.Int(1),
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		TestMode:   true,
+	}
+	regular := &dbgen.Property{
+		ID:         2,
+		ExternalID: *randomUUID(),
+		OrgOwnerID: db.Int(regularUserID),
+		OrgID:      db.Int(2),
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthFast,
+	}
+
+	ctx := context.TODO()
+	tnow := time.Now().UTC()
+	fingerprint := common.RandomFingerprint()
+
+	for i := 0; i < 50; i++ {
+		if d := srv.propertyDifficulty(fingerprint, asn.ClassUnknown, sandbox, tnow); d != uint8(sandbox.Level.Int16) {
+			t.Fatalf("Sandbox difficulty changed: %v", d)
+		}
+		srv.propertyDifficulty(fingerprint, asn.ClassUnknown, regular, tnow)
+	}
+
+	// account stats are bucketed by month
+	from := tnow.AddDate(0, -1, 0)
+
+	// regular traffic is used as a marker that access log was flushed
+	var regularStats []*common.TimeCount
+	for attempt := 0; (attempt < 100) && (len(regularStats) == 0); attempt++ {
+		time.Sleep(20 * time.Millisecond)
+
+		var err error
+		if regularStats, err = timeSeries.ReadAccountStats(ctx, regularUserID, from, time.UTC); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(regularStats) == 0 {
+		t.Fatal("Regular traffic was not recorded")
+	}
+
+	sandboxStats, err := timeSeries.ReadAccountStats(ctx, sandboxUserID, from, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sandboxStats) != 0 {
+		t.Errorf("Sandbox traffic counted against quota: %v", sandboxStats)
+	}
+}
@@ -9,9 +9,14 @@ import (
 	"log/slog"
 	"net/http"
 	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
@@ -25,6 +30,8 @@ import (
 
 const (
 	maxSolutionsBodySize  = 256 * 1024
+	puzzleTimeout         = 1 * time.Second
+	verifyTimeout         = 5 * time.Second
 	VerifyBatchSize       = 100
 	PropertyBucketSize    = 5 * time.Minute
 	updateLimitsBatchSize = 100
@@ -43,10 +50,12 @@ var (
 )
 
 type Server struct {
-	Stage              string
-	BusinessDB         db.Implementor
-	TimeSeries         common.TimeSeriesStore
-	Levels             *difficulty.Levels
+	Stage      string
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+	Levels     *difficulty.Levels
+	// optional, without it all traffic is treated the same
+	ASN                *asn.Classifier
 	Auth               *AuthMiddleware
 	UserFingerprintKey *userFingerprintKey
 	Salt               *puzzleSalt
@@ -55,7 +64,27 @@ type Server struct {
 	Cors               *cors.Cors
 	Metrics            common.APIMetrics
 	Mailer             common.Mailer
+	Receipts           *receiptSigner
 	TestPuzzleData     *puzzle.PuzzlePayload
+	testPuzzleETag     string
+	quotas             common.Cache[int32, *userQuota]
+	trustedVisitors    common.Cache[trustedVisitorKey, int16]
+	// responses to successful verifications, replayed for retries of the same request
+	verifyReplays common.Cache[verifyReplayKey, *VerifyResponseRecaptchaV2]
+	// reloadable per-route limits, defaults are used until config is loaded
+	puzzleTimeout   common.RouteLimit
+	verifyTimeout   common.RouteLimit
+	fallbackTimeout common.RouteLimit
+	verifyMaxBytes  common.RouteLimit
+	batchMaxBytes   common.RouteLimit
+	// optional, puzzles are generated on demand if pool is not set
+	PuzzlePool *puzzlePool
+	// number of batch verify requests being processed, used for backpressure
+	verifyBatches atomic.Int32
+	// what to do with verify records when both channel and overflow buffer are full ("drop" by default)
+	VerifyLogOverflow string
+	VerifyLogSpillDir string
+	verifyLog         *verifyLogQueue
 }
 
 var _ puzzle.Engine = (*Server)(nil)
@@ -63,23 +92,53 @@ var _ puzzle.Engine = (*Server)(nil)
 type apiKeyOwnerSource struct{}
 
 func (a *apiKeyOwnerSource) OwnerID(ctx context.Context) (int32, error) {
-	apiKey, ok := ctx.Value(common.APIKeyContextKey).(*dbgen.APIKey)
+	ownerID, ok := ctx.Value(common.APIKeyOwnerContextKey).(int32)
 	if !ok {
 		return -1, errAPIKeyNotSet
 	}
 
-	return apiKey.UserID.Int32, nil
+	return ownerID, nil
+}
+
+// OrgID returns organization that org-scoped API key is restricted to
+func (a *apiKeyOwnerSource) OrgID(ctx context.Context) (int32, bool) {
+	orgID, ok := ctx.Value(common.APIKeyOrgContextKey).(int32)
+	return orgID, ok
+}
+
+// orgScopedOwnerSource is implemented by owner sources that can be further restricted to a single organization
+// of the owner (e.g. org API tokens)
+type orgScopedOwnerSource interface {
+	OrgID(ctx context.Context) (int32, bool)
+}
+
+// isOutsideOwnerOrg checks if the property belongs to a different organization than the one expected owner is
+// restricted to (if any)
+func isOutsideOwnerOrg(ctx context.Context, expectedOwner puzzle.OwnerIDSource, property *dbgen.Property) bool {
+	scoped, ok := expectedOwner.(orgScopedOwnerSource)
+	if !ok {
+		return false
+	}
+
+	orgID, ok := scoped.OrgID(ctx)
+	return ok && (property.OrgID.Int32 != orgID)
 }
 
 type VerifyResponse struct {
 	Success    bool     `json:"success"`
 	ErrorCodes []string `json:"error-codes,omitempty"`
+	// signed (JWS) receipt of the verification, only included if requested
+	Receipt string `json:"receipt,omitempty"`
+	// integrator-defined action the puzzle was requested with (if any)
+	Action string `json:"action,omitempty"`
 }
 
 type VerifyResponseRecaptchaV2 struct {
 	VerifyResponse
 	ChallengeTS common.JSONTime `json:"challenge_ts"`
 	Hostname    string          `json:"hostname"`
+	// subject of the receipt (if requested)
+	sitekey string
 }
 
 type VerifyResponseRecaptchaV3 struct {
@@ -89,16 +148,23 @@ type VerifyResponseRecaptchaV3 struct {
 }
 
 func (s *Server) Init(ctx context.Context, verifyFlushInterval, authBackfillDelay time.Duration) error {
-	if err := s.Salt.Update(); err != nil {
+	if err := s.Salt.Update(ctx); err != nil {
 		slog.ErrorContext(ctx, "Failed to update puzzle salt", common.ErrAttr(err))
 		return err
 	}
 
-	if err := s.UserFingerprintKey.Update(); err != nil {
+	if err := s.UserFingerprintKey.Update(ctx); err != nil {
 		slog.ErrorContext(ctx, "Failed to update user fingerprint key", common.ErrAttr(err))
 		return err
 	}
 
+	if s.Receipts != nil {
+		// receipts are optional so misconfiguration should not prevent the server from starting
+		if err := s.Receipts.Update(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to update verify receipt key", common.ErrAttr(err))
+		}
+	}
+
 	testPuzzle := puzzle.NewPuzzle(0 /*puzzle ID*/, db.TestPropertyUUID.Bytes, 0 /*difficulty*/)
 	var err error
 	s.TestPuzzleData, err = testPuzzle.Serialize(ctx, s.Salt.Value(), nil /*property salt*/)
@@ -106,15 +172,29 @@ func (s *Server) Init(ctx context.Context, verifyFlushInterval, authBackfillDela
 		slog.ErrorContext(ctx, "Failed to serialize test puzzle", common.ErrAttr(err))
 		return err
 	}
+	s.testPuzzleETag = payloadETag(s.TestPuzzleData)
+
+	s.quotas = newQuotaCache()
+	s.trustedVisitors = newTrustedVisitorsCache()
+	s.verifyReplays = newVerifyReplayCache()
+
+	if s.PuzzlePool != nil {
+		s.PuzzlePool.Start(ctx, s.Salt.Value)
+		s.Metrics.RegisterPuzzlePoolStats(s.PuzzlePool)
+	}
 
 	s.Levels.Init(2*time.Second /*access log interval*/, PropertyBucketSize /*backfill interval*/)
-	s.Auth.BackfillProperties(authBackfillDelay)
+	s.Auth.BackfillProperties(authBackfillDelay, s.Metrics)
+	s.Auth.TrackAPIKeyUsage(s.Metrics)
 
 	var cancelVerifyCtx context.Context
 	cancelVerifyCtx, s.VerifyLogCancel = context.WithCancel(
 		context.WithValue(context.Background(), common.TraceIDContextKey, "flush_verify_log"))
 
-	go common.ProcessBatchArray(cancelVerifyCtx, s.VerifyLogChan, verifyFlushInterval, VerifyBatchSize, maxVerifyBatchSize, s.TimeSeries.WriteVerifyLogBatch)
+	go common.ProcessBatchArray(cancelVerifyCtx, common.BatchPipelineVerifyLog, s.Metrics, s.VerifyLogChan, verifyFlushInterval, VerifyBatchSize, maxVerifyBatchSize, s.TimeSeries.WriteVerifyLogBatch)
+
+	s.verifyLog = newVerifyLogQueue(s.VerifyLogChan, s.Metrics, verifyLogOverflowSize, s.VerifyLogOverflow, s.VerifyLogSpillDir)
+	s.verifyLog.Start(common.TraceContext(context.Background(), "verify_log_overflow"))
 
 	return nil
 }
@@ -143,33 +223,117 @@ func (s *Server) Setup(router *http.ServeMux, domain string, verbose bool, secur
 
 func (s *Server) UpdateConfig(ctx context.Context, cfg common.ConfigStore) {
 	s.Auth.UpdateConfig(cfg)
+
+	s.puzzleTimeout.Store(int64(config.AsDuration(cfg.Get(common.APIPuzzleTimeoutKey), puzzleTimeout)))
+	s.verifyTimeout.Store(int64(config.AsDuration(cfg.Get(common.APIVerifyTimeoutKey), verifyTimeout)))
+	s.fallbackTimeout.Store(int64(config.AsDuration(cfg.Get(common.APIFallbackTimeoutKey), fallbackTimeout)))
+	s.verifyMaxBytes.Store(int64(config.AsInt(cfg.Get(common.APIVerifyMaxBytesKey), maxSolutionsBodySize)))
+	s.batchMaxBytes.Store(int64(config.AsInt(cfg.Get(common.APIBatchMaxBytesKey), maxBatchBodySize)))
+
+	// previous salt is still accepted after it is changed in config
+	if err := s.Salt.Update(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to update puzzle salt", common.ErrAttr(err))
+	}
+
+	if err := s.UserFingerprintKey.Update(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to update user fingerprint key", common.ErrAttr(err))
+	}
+
+	if s.Receipts != nil {
+		if err := s.Receipts.Update(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to update verify receipt key", common.ErrAttr(err))
+		}
+	}
 }
 
 func (s *Server) Shutdown() {
 	s.Levels.Shutdown()
 	s.Auth.Shutdown()
+	s.PuzzlePool.Shutdown()
 
 	slog.Debug("Shutting down API server routines")
+	s.verifyLog.Shutdown()
 	s.VerifyLogCancel()
 	close(s.VerifyLogChan)
 }
 
+// QueueDepths returns number of verify records waiting to be written to time series store
+func (s *Server) QueueDepths() map[string]int {
+	channel, overflow := s.verifyLog.Depth()
+
+	return map[string]int{
+		"verify_log":          channel,
+		"verify_log_overflow": overflow,
+	}
+}
+
 func (s *Server) setupWithPrefix(domain string, router *http.ServeMux, corsHandler, security alice.Constructor) {
 	prefix := domain + "/"
 	slog.Debug("Setting up the API routes", "prefix", prefix)
 	publicChain := alice.New(common.Recovered, monitoring.Traced, security, s.Metrics.Handler)
+	puzzleTimeoutHandler := common.ConfiguredTimeoutHandler(&s.puzzleTimeout, puzzleTimeout)
 	// NOTE: auth middleware provides rate limiting internally
-	router.Handle(http.MethodGet+" "+prefix+common.PuzzleEndpoint, publicChain.Append(corsHandler, common.TimeoutHandler(1*time.Second), s.Auth.Sitekey).ThenFunc(s.puzzleHandler))
+	router.Handle(http.MethodGet+" "+prefix+common.PuzzleEndpoint, publicChain.Append(corsHandler, puzzleTimeoutHandler, s.Auth.Sitekey).ThenFunc(s.puzzleHandler))
 	router.Handle(http.MethodOptions+" "+prefix+common.PuzzleEndpoint, publicChain.Append(common.Cached, corsHandler, s.Auth.SitekeyOptions).ThenFunc(s.puzzlePreFlight))
-	verifyChain := publicChain.Append(common.TimeoutHandler(5*time.Second), s.Auth.APIKey)
-	router.Handle(http.MethodPost+" "+prefix+common.VerifyEndpoint, verifyChain.Then(http.MaxBytesHandler(http.HandlerFunc(s.verifyHandler), maxSolutionsBodySize)))
+	// server-rendered challenge for user agents without JavaScript, CORS is not needed as it's not fetched by the widget
+	router.Handle(http.MethodGet+" "+prefix+common.FallbackEndpoint, publicChain.Append(common.ConfiguredTimeoutHandler(&s.fallbackTimeout, fallbackTimeout), s.Auth.SitekeyFallback).ThenFunc(s.fallbackHandler))
+	// alternative (non proof-of-work) challenge that widget can switch to on devices that are too slow to solve puzzles
+	router.Handle(http.MethodGet+" "+prefix+common.AccessibleEndpoint, publicChain.Append(corsHandler, puzzleTimeoutHandler, s.Auth.Sitekey).ThenFunc(s.accessibleHandler))
+	router.Handle(http.MethodOptions+" "+prefix+common.AccessibleEndpoint, publicChain.Append(common.Cached, corsHandler, s.Auth.SitekeyOptions).ThenFunc(s.puzzlePreFlight))
+	// lets the widget show property's custom message when it cannot serve puzzles (blocked, over quota, maintenance)
+	router.Handle(http.MethodGet+" "+prefix+common.StatusEndpoint, publicChain.Append(corsHandler, puzzleTimeoutHandler, s.Auth.PuzzleRateLimiter.RateLimit).ThenFunc(s.statusHandler))
+	router.Handle(http.MethodOptions+" "+prefix+common.StatusEndpoint, publicChain.Append(common.Cached, corsHandler, s.Auth.SitekeyOptions).ThenFunc(s.puzzlePreFlight))
+	// signed bootstrap data (appearance and difficulty hints) that widget can fetch before the puzzle
+	router.Handle(http.MethodGet+" "+prefix+common.ConfigEndpoint, publicChain.Append(corsHandler, puzzleTimeoutHandler, s.Auth.PuzzleRateLimiter.RateLimit).ThenFunc(s.configHandler))
+	router.Handle(http.MethodOptions+" "+prefix+common.ConfigEndpoint, publicChain.Append(common.Cached, corsHandler, s.Auth.SitekeyOptions).ThenFunc(s.puzzlePreFlight))
+	verifyChain := publicChain.Append(common.ConfiguredTimeoutHandler(&s.verifyTimeout, verifyTimeout), s.Auth.APIKey,
+		common.ConfiguredMaxBytesHandler(&s.verifyMaxBytes, maxSolutionsBodySize))
+	router.Handle(http.MethodPost+" "+prefix+common.VerifyEndpoint, verifyChain.ThenFunc(s.verifyHandler))
+	// body of the batch is decoded as a stream with per-item size limit being the same as for a single verify
+	verifyBatchChain := publicChain.Append(common.TimeoutHandler(verifyBatchTimeout), s.Auth.APIKey,
+		common.ConfiguredMaxBytesHandler(&s.batchMaxBytes, maxBatchBodySize))
+	router.Handle(http.MethodPost+" "+prefix+common.VerifyEndpoint+"/"+common.BatchEndpoint, verifyBatchChain.ThenFunc(s.verifyBatchHandler))
+
+	if debugEndpointsEnabled(s.Stage) {
+		router.Handle(http.MethodPost+" "+prefix+common.DebugEndpoint+"/"+common.ParseEndpoint, verifyChain.ThenFunc(s.debugParseHandler))
+	}
+	// public keys to verify receipts returned from verify endpoint
+	router.Handle(http.MethodGet+" "+prefix+common.WellKnownEndpoint+"/"+common.JWKSEndpoint, publicChain.Append(s.Auth.PuzzleRateLimiter.RateLimit).ThenFunc(s.jwksHandler))
 
 	// "root" access
 	router.Handle(prefix+"{$}", publicChain.Then(common.HttpStatus(http.StatusForbidden)))
 }
 
+// widgetVersion returns the latest puzzle version that widget can solve
+func widgetVersion(r *http.Request) int {
+	version, err := strconv.Atoi(r.Header.Get(common.HeaderCaptchaVersion))
+	if err != nil {
+		return puzzle.VersionBlake2b
+	}
+
+	return version
+}
+
+// isValidRequestAction checks optional integrator-defined action of the puzzle request
+func isValidRequestAction(r *http.Request) bool {
+	action := r.URL.Query().Get(common.ParamAction)
+	return (len(action) == 0) || puzzle.IsValidAction(action)
+}
+
+// propertyDifficulty records access (that is used for usage, quota and difficulty scaling) and returns difficulty of
+// the puzzle. Sandbox traffic is not recorded so it neither affects difficulty nor counts against quota
+func (s *Server) propertyDifficulty(fingerprint common.TFingerprint, ipClass asn.Class, property *dbgen.Property, tnow time.Time) uint8 {
+	if property.TestMode {
+		return uint8(property.Level.Int16)
+	}
+
+	return s.Levels.Difficulty(fingerprint, ipClass, property, tnow)
+}
+
+// puzzleForRequest expects action parameter to be validated with isValidRequestAction()
 func (s *Server) puzzleForRequest(r *http.Request) (*puzzle.Puzzle, *dbgen.Property, error) {
 	ctx := r.Context()
+	action := r.URL.Query().Get(common.ParamAction)
 	property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
 	// property will not be cached for auth.backfillDelay and we return an "average" puzzle instead
 	// this is done in order to not check the DB on the hot path (decrease attack surface)
@@ -185,6 +349,7 @@ func (s *Server) puzzleForRequest(r *http.Request) (*puzzle.Puzzle, *dbgen.Prope
 		if err := stubPuzzle.Init(puzzle.DefaultValidityPeriod); err != nil {
 			slog.ErrorContext(ctx, "Failed to init stub puzzle", common.ErrAttr(err))
 		}
+		stubPuzzle.Action = action
 
 		slog.Log(ctx, common.LevelTrace, "Returning stub puzzle before auth is backfilled", "puzzleID", stubPuzzle.PuzzleID,
 			"sitekey", sitekey, "difficulty", stubPuzzle.Difficulty)
@@ -192,8 +357,11 @@ func (s *Server) puzzleForRequest(r *http.Request) (*puzzle.Puzzle, *dbgen.Prope
 	}
 
 	var fingerprint common.TFingerprint
-	hash, err := blake2b.New256(s.UserFingerprintKey.Value())
-	if err != nil {
+	ipClass := asn.ClassUnknown
+	if property.IplessMode {
+		// customer is not allowed to have visitor IPs processed (not even to classify the network)
+		fingerprint = common.RandomFingerprint()
+	} else if hash, err := blake2b.New256(s.UserFingerprintKey.Value()); err != nil {
 		slog.ErrorContext(ctx, "Failed to create blake2b hmac", common.ErrAttr(err))
 		fingerprint = common.RandomFingerprint()
 	} else {
@@ -202,6 +370,7 @@ func (s *Server) puzzleForRequest(r *http.Request) (*puzzle.Puzzle, *dbgen.Prope
 		// hash.Write([]byte(r.UserAgent()))
 		if ip, ok := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr); ok && ip.IsValid() {
 			hash.Write(ip.AsSlice())
+			ipClass = s.ASN.Classify(ip)
 		} else {
 			slog.ErrorContext(ctx, "Rate limit context key type mismatch", "ip", ip)
 			hash.Write([]byte(r.RemoteAddr))
@@ -212,16 +381,31 @@ func (s *Server) puzzleForRequest(r *http.Request) (*puzzle.Puzzle, *dbgen.Prope
 	}
 
 	tnow := time.Now()
-	puzzleDifficulty := s.Levels.Difficulty(fingerprint, property, tnow)
+	puzzleDifficulty := s.propertyDifficulty(fingerprint, ipClass, property, tnow)
+	trustedVisitors := trustedVisitorsEnabled(property)
+
+	// trust is only taken into account when there's no elevated activity (difficulty did not grow)
+	if trustedVisitors && (puzzleDifficulty <= uint8(property.Level.Int16)) && s.isTrustedVisitor(ctx, property, fingerprint) {
+		slog.Log(ctx, common.LevelTrace, "Lowering difficulty for trusted visitor", "propertyID", property.ID)
+		puzzleDifficulty = min(puzzleDifficulty, trustedVisitorDifficulty)
+	}
 
 	puzzleID := puzzle.RandomPuzzleID()
 	result := puzzle.NewPuzzle(puzzleID, property.ExternalID.Bytes, puzzleDifficulty)
+	// older widgets do not send version or only support blake2b puzzles
+	if (property.Algorithm == dbgen.PowAlgorithmArgon2id) && (widgetVersion(r) >= puzzle.VersionArgon2id) {
+		result.UseArgon2id()
+	}
 	if err := result.Init(property.ValidityInterval); err != nil {
 		slog.ErrorContext(ctx, "Failed to init puzzle", common.ErrAttr(err))
 	}
+	if trustedVisitors {
+		s.embedFingerprint(ctx, result, fingerprint)
+	}
+	result.Action = action
 
 	slog.Log(ctx, common.LevelTrace, "Prepared new puzzle", "propertyID", property.ID, "difficulty", result.Difficulty,
-		"puzzleID", result.PuzzleID, "userID", property.OrgOwnerID.Int32)
+		"version", result.Version, "puzzleID", result.PuzzleID, "userID", property.OrgOwnerID.Int32)
 
 	return result, property, nil
 }
@@ -239,19 +423,29 @@ func (s *Server) puzzlePreFlight(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) puzzleHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	if !isValidRequestAction(r) {
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidAction)
+		return
+	}
+
 	puzzle, property, err := s.puzzleForRequest(r)
 	if err != nil {
 		if err == db.ErrTestProperty {
 			common.WriteHeaders(w, common.CachedHeaders)
 			// we cache test property responses, can as well allow them anywhere
 			common.WriteHeaders(w, headersAnyOrigin)
+			w.Header().Set(common.HeaderETag, s.testPuzzleETag)
+			if etagMatches(r.Header.Get(common.HeaderIfNoneMatch), s.testPuzzleETag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
 			common.WriteHeaders(w, headersContentPlain)
-			_ = s.TestPuzzleData.Write(w)
+			_ = writePuzzlePayload(w, r.Header.Get(common.HeaderAcceptEncoding), s.TestPuzzleData)
 			return
 		}
 
 		slog.ErrorContext(ctx, "Failed to create puzzle", common.ErrAttr(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
 		return
 	}
 
@@ -262,7 +456,20 @@ func (s *Server) puzzleHandler(w http.ResponseWriter, r *http.Request) {
 		extraSalt = property.Salt
 	}
 
-	if err := s.Write(ctx, puzzle, extraSalt, w); err != nil {
+	acceptEncoding := r.Header.Get(common.HeaderAcceptEncoding)
+	// fingerprint is embedded into puzzles of trusted visitors properties so they cannot be pre-generated
+	if (property != nil) && !trustedVisitorsEnabled(property) {
+		if pooled, payload := s.PuzzlePool.Get(puzzle, extraSalt, property.ValidityInterval, time.Now()); payload != nil {
+			slog.Log(ctx, common.LevelTrace, "Serving pooled puzzle", "puzzleID", pooled.PuzzleID, "propertyID", property.ID)
+			if err := s.writePayload(w, payload, acceptEncoding); err != nil {
+				slog.ErrorContext(ctx, "Failed to write pooled puzzle", common.ErrAttr(err))
+			}
+			s.Metrics.ObservePuzzleCreated(userID)
+			return
+		}
+	}
+
+	if err := s.write(ctx, puzzle, extraSalt, w, acceptEncoding); err != nil {
 		slog.ErrorContext(ctx, "Failed to write puzzle", common.ErrAttr(err))
 	}
 
@@ -270,25 +477,44 @@ func (s *Server) puzzleHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) Write(ctx context.Context, p *puzzle.Puzzle, extraSalt []byte, w http.ResponseWriter) error {
+	return s.write(ctx, p, extraSalt, w, "" /*accept encoding*/)
+}
+
+func (s *Server) write(ctx context.Context, p *puzzle.Puzzle, extraSalt []byte, w http.ResponseWriter, acceptEncoding string) error {
 	payload, err := p.Serialize(ctx, s.Salt.Value(), extraSalt)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
 		return err
 	}
 
+	return s.writePayload(w, payload, acceptEncoding)
+}
+
+func (s *Server) writePayload(w http.ResponseWriter, payload *puzzle.PuzzlePayload, acceptEncoding string) error {
 	common.WriteHeaders(w, common.NoCacheHeaders)
 	common.WriteHeaders(w, headersContentPlain)
-	return payload.Write(w)
+	return writePuzzlePayload(w, acceptEncoding, payload)
 }
 
 func (s *Server) Verify(ctx context.Context, payload string, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (*puzzle.Puzzle, puzzle.VerifyError, error) {
+	if answerPayload, ok := strings.CutPrefix(payload, accessiblePayloadPrefix); ok {
+		return s.verifyAccessible(ctx, answerPayload, expectedOwner, tnow)
+	}
+
 	verifyPayload, err := puzzle.ParseVerifyPayload(ctx, payload)
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to parse verify payload", common.ErrAttr(err))
 		return nil, puzzle.ParseResponseError, nil
 	}
 
-	puzzleObject, property, perr := s.verifyPuzzleValid(ctx, verifyPayload, expectedOwner, tnow)
+	if outcome, ok := ctx.Value(common.TestOutcomeContextKey).(string); ok && (len(outcome) > 0) {
+		if property, verr, forced := s.forcedTestOutcome(ctx, verifyPayload, expectedOwner, outcome); forced {
+			s.addVerifyRecord(ctx, verifyPayload.Puzzle(), property, verr)
+			return verifyPayload.Puzzle(), verr, nil
+		}
+	}
+
+	puzzleObject, property, perr := s.verifyPuzzleValid(ctx, verifyPayload, nil /*answer*/, expectedOwner, tnow)
 	if perr != puzzle.VerifyNoError && perr != puzzle.MaintenanceModeError {
 		return puzzleObject, perr, nil
 	}
@@ -309,6 +535,10 @@ func (s *Server) Verify(ctx context.Context, payload string, expectedOwner puzzl
 		}
 	}
 
+	if (puzzleObject != nil) && trustedVisitorsEnabled(property) {
+		s.recordTrustedVisitor(ctx, puzzleObject, property)
+	}
+
 	s.addVerifyRecord(ctx, puzzleObject, property, puzzle.VerifyNoError)
 
 	return puzzleObject, perr, nil
@@ -320,16 +550,61 @@ func (s *Server) verifyHandler(w http.ResponseWriter, r *http.Request) {
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeBadRequest)
 		return
 	}
 
-	p, verr, err := s.Verify(ctx, string(data), &apiKeyOwnerSource{}, time.Now().UTC())
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+	replayKey, replayable := newVerifyReplayKey(ctx, data)
+
+	if outcome := r.URL.Query().Get(common.ParamTestOutcome); len(outcome) > 0 {
+		ctx = context.WithValue(ctx, common.TestOutcomeContextKey, outcome)
+		// forced outcomes are not real verifications
+		replayable = false
+	}
+
+	var vr2 *VerifyResponseRecaptchaV2
+	if replayable {
+		vr2 = s.cachedVerifyResponse(ctx, replayKey)
 	}
 
+	if vr2 == nil {
+		tnow := time.Now().UTC()
+		p, verr, err := s.Verify(ctx, string(data), &apiKeyOwnerSource{}, tnow)
+		if err != nil {
+			sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+			return
+		}
+
+		vr2 = s.verifyResponse(ctx, p, verr)
+
+		if replayable {
+			s.cacheVerifyResponse(ctx, replayKey, p, verr, vr2, tnow)
+		}
+	}
+
+	// cached response is never signed so that retries get the receipt only if they ask for it
+	vr2 = s.withReceipt(ctx, r, vr2)
+
+	var result interface{}
+
+	recaptchaCompatVersion := r.Header.Get(common.HeaderCaptchaCompat)
+	if recaptchaCompatVersion == "rcV3" {
+		result = &VerifyResponseRecaptchaV3{
+			VerifyResponseRecaptchaV2: *vr2,
+			Action:                    vr2.VerifyResponse.Action,
+			Score:                     0.5,
+		}
+	} else {
+		result = vr2
+	}
+
+	s.writeQuotaHeaders(ctx, w)
+
+	common.SendJSONResponse(ctx, w, result, common.NoCacheHeaders)
+}
+
+// verifyResponse converts result of a single verification to the API response (without receipt)
+func (s *Server) verifyResponse(ctx context.Context, p *puzzle.Puzzle, verr puzzle.VerifyError) *VerifyResponseRecaptchaV2 {
 	errorCodes := []puzzle.VerifyError{}
 	if verr != puzzle.VerifyNoError {
 		errorCodes = append(errorCodes, verr)
@@ -345,28 +620,42 @@ func (s *Server) verifyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if p != nil && !p.IsZero() {
+		// action is not trusted unless the whole puzzle (including signature) was verified
+		if verr == puzzle.VerifyNoError {
+			vr2.VerifyResponse.Action = p.Action
+		}
 		vr2.ChallengeTS = common.JSONTime(p.Expiration.Add(-puzzle.DefaultValidityPeriod))
 
-		sitekey := db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: p.PropertyID})
-		if property, err := s.BusinessDB.Impl().GetCachedPropertyBySitekey(ctx, sitekey); err == nil {
+		vr2.sitekey = db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: p.PropertyID})
+		if property, err := s.Auth.verifyImpl().GetCachedPropertyBySitekey(ctx, vr2.sitekey); err == nil {
 			vr2.Hostname = property.Domain
 		}
 	}
 
-	var result interface{}
+	return vr2
+}
 
-	recaptchaCompatVersion := r.Header.Get(common.HeaderCaptchaCompat)
-	if recaptchaCompatVersion == "rcV3" {
-		result = &VerifyResponseRecaptchaV3{
-			VerifyResponseRecaptchaV2: *vr2,
-			Action:                    "",
-			Score:                     0.5,
-		}
-	} else {
-		result = vr2
+// withReceipt returns a copy of the response with signed receipt, if it was requested
+func (s *Server) withReceipt(ctx context.Context, r *http.Request, vr2 *VerifyResponseRecaptchaV2) *VerifyResponseRecaptchaV2 {
+	if !wantsReceipt(r) {
+		return vr2
 	}
 
-	common.SendJSONResponse(ctx, w, result, common.NoCacheHeaders)
+	if !s.Receipts.Enabled() {
+		slog.WarnContext(ctx, "Verify receipt was requested, but receipts are not configured")
+		return vr2
+	}
+
+	receipt, err := s.Receipts.Sign(s.Receipts.newClaims(vr2.sitekey, vr2, time.Now().UTC()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign verify receipt", common.ErrAttr(err))
+		return vr2
+	}
+
+	result := *vr2
+	result.Receipt = receipt
+
+	return &result
 }
 
 func (s *Server) addVerifyRecord(ctx context.Context, p *puzzle.Puzzle, property *dbgen.Property, verr puzzle.VerifyError) {
@@ -382,14 +671,19 @@ func (s *Server) addVerifyRecord(ctx context.Context, p *puzzle.Puzzle, property
 		PuzzleID:   p.PuzzleID,
 		Timestamp:  time.Now().UTC(),
 		Status:     int8(verr),
+		Region:     property.DataRegion,
+		Action:     p.Action,
+		Sandbox:    property.TestMode,
 	}
 
-	s.VerifyLogChan <- vr
+	s.verifyLog.Enqueue(ctx, vr)
 
 	s.Metrics.ObservePuzzleVerified(vr.UserID, verr.String(), p.IsStub())
 }
 
-func (s *Server) verifyPuzzleValid(ctx context.Context, payload *puzzle.VerifyPayload, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (*puzzle.Puzzle, *dbgen.Property, puzzle.VerifyError) {
+// verifyPuzzleValid checks everything about the puzzle except for solutions. Accessible challenges are signed with
+// the expected answer instead of property salt so their signature is checked against the answer
+func (s *Server) verifyPuzzleValid(ctx context.Context, payload *puzzle.VerifyPayload, answer []byte, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (*puzzle.Puzzle, *dbgen.Property, puzzle.VerifyError) {
 	p := payload.Puzzle()
 	plog := slog.With("puzzleID", p.PuzzleID)
 
@@ -403,8 +697,13 @@ func (s *Server) verifyPuzzleValid(ctx context.Context, payload *puzzle.VerifyPa
 		return p, nil, puzzle.PuzzleExpiredError
 	}
 
-	if !payload.NeedsExtraSalt() {
-		if serr := payload.VerifySignature(ctx, s.Salt.Value(), nil /*extra salt*/); serr != nil {
+	if len(answer) > 0 {
+		if serr := s.Salt.Verify(ctx, payload, answer); serr != nil {
+			plog.WarnContext(ctx, "Accessible challenge answer is not valid")
+			return p, nil, puzzle.InvalidSolutionError
+		}
+	} else if !payload.NeedsExtraSalt() {
+		if serr := s.Salt.Verify(ctx, payload, nil /*extra salt*/); serr != nil {
 			return p, nil, puzzle.IntegrityError
 		}
 	}
@@ -415,7 +714,7 @@ func (s *Server) verifyPuzzleValid(ctx context.Context, payload *puzzle.VerifyPa
 	}
 
 	sitekey := db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: p.PropertyID})
-	properties, err := s.BusinessDB.Impl().RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey: {}})
+	properties, err := s.Auth.verifyImpl().RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey: {}})
 	if (err != nil) || (len(properties) != 1) {
 		switch err {
 		case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
@@ -429,8 +728,8 @@ func (s *Server) verifyPuzzleValid(ctx context.Context, payload *puzzle.VerifyPa
 	}
 
 	property := properties[0]
-	if payload.NeedsExtraSalt() {
-		if serr := payload.VerifySignature(ctx, s.Salt.Value(), property.Salt); serr != nil {
+	if (len(answer) == 0) && payload.NeedsExtraSalt() {
+		if serr := s.Salt.Verify(ctx, payload, property.Salt); serr != nil {
 			return p, nil, puzzle.IntegrityError
 		}
 	}
@@ -439,11 +738,19 @@ func (s *Server) verifyPuzzleValid(ctx context.Context, payload *puzzle.VerifyPa
 		if property.OrgOwnerID.Int32 != ownerID {
 			plog.WarnContext(ctx, "Org owner does not match expected owner", "expectedOwner", ownerID,
 				"orgOwner", property.OrgOwnerID.Int32)
+			s.Auth.recordAPIKeyUsage(ctx, true /*wrong owner*/)
 			return p, property, puzzle.WrongOwnerError
 		}
 	} else {
 		plog.ErrorContext(ctx, "Failed to fetch owner ID", common.ErrAttr(err))
 	}
 
+	// org tokens of the same owner cannot be used across organizations
+	if isOutsideOwnerOrg(ctx, expectedOwner, property) {
+		plog.WarnContext(ctx, "Property does not belong to organization of API key", "orgID", property.OrgID.Int32)
+		s.Auth.recordAPIKeyUsage(ctx, true /*wrong owner*/)
+		return p, property, puzzle.WrongOwnerError
+	}
+
 	return p, property, puzzle.VerifyNoError
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -64,16 +65,17 @@ func TestMain(m *testing.M) {
 
 	timeSeries = db.NewTimeSeries(clickhouse)
 
-	var err error
-	cache, err = db.NewMemoryCache[db.CacheKey, any](100, nil)
-	if err != nil {
-		panic(err)
-	}
+	cache = db.NewBusinessCache(100, 1<<20 /*max bytes*/)
 
 	store = db.NewBusinessEx(pool, cache)
 
 	metrics := monitoring.NewStub()
 
+	ipStrategy, err := ratelimit.NewClientIPStrategy(cfg.Get(common.RateLimitHeaderKey).Value(), cfg.Get(common.TrustedProxiesKey).Value(), metrics)
+	if err != nil {
+		panic(err)
+	}
+
 	planService := billing.NewPlanService(nil)
 	testPlan = planService.GetInternalTrialPlan()
 
@@ -81,13 +83,13 @@ func TestMain(m *testing.M) {
 		Stage:              common.StageTest,
 		BusinessDB:         store,
 		TimeSeries:         timeSeries,
-		Auth:               NewAuthMiddleware(cfg, store, NewUserLimiter(store), planService),
+		Auth:               NewAuthMiddleware(cfg, ipStrategy, store, NewUserLimiter(store, timeSeries, planService, common.StageTest, &common.StubAlerter{}), planService),
 		VerifyLogChan:      make(chan *common.VerifyRecord, 10*VerifyBatchSize),
-		Salt:               NewPuzzleSalt(cfg.Get(common.APISaltKey)),
-		UserFingerprintKey: NewUserFingerprintKey(cfg.Get(common.UserFingerprintIVKey)),
+		Salt:               NewPuzzleSalt(cfg.Get(common.APISaltKey), nil /*deriver*/),
+		UserFingerprintKey: NewUserFingerprintKey(cfg.Get(common.UserFingerprintIVKey), nil /*deriver*/),
 		Metrics:            metrics,
 		Mailer:             &email.StubMailer{},
-		Levels:             difficulty.NewLevels(timeSeries, 100 /*levelsBatchSize*/, PropertyBucketSize),
+		Levels:             difficulty.NewLevels(timeSeries, metrics, 100 /*levelsBatchSize*/, PropertyBucketSize),
 		VerifyLogCancel:    func() {},
 	}
 	if err := s.Init(context.TODO(), verifyFlushInterval, authBackfillDelay); err != nil {
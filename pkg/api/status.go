@@ -0,0 +1,102 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	widgetStatusOK          = "ok"
+	widgetStatusBlocked     = "blocked"
+	widgetStatusOverQuota   = "over_quota"
+	widgetStatusMaintenance = "maintenance"
+	widgetStatusPaused      = "paused"
+)
+
+// widgetStatusResponse tells the widget what to show instead of the puzzle (if anything)
+type widgetStatusResponse struct {
+	Status      string `json:"status"`
+	Message     string `json:"message,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+func (r *widgetStatusResponse) applyMessages(messages *dbgen.PropertyMessage) {
+	switch r.Status {
+	// paused property is "blocked" from the visitor's point of view
+	case widgetStatusBlocked, widgetStatusPaused:
+		r.Message = messages.BlockedMessage
+	case widgetStatusOverQuota:
+		r.Message = messages.QuotaMessage
+	case widgetStatusMaintenance:
+		r.Message = messages.MaintenanceMessage
+	default:
+		return
+	}
+
+	r.RedirectURL = messages.RedirectUrl
+}
+
+// statusHandler is a lightweight check that widget can do before fetching a puzzle. It only uses cached data, same as
+// /puzzle endpoint, so properties that are not cached yet are reported as "ok" until they are backfilled
+func (s *Server) statusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sitekey := r.URL.Query().Get(common.ParamSiteKey)
+	if !isSiteKeyValid(sitekey) {
+		slog.Log(ctx, common.LevelTrace, "Sitekey is not valid for status request")
+		sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
+		return
+	}
+
+	impl := s.BusinessDB.Impl()
+	response := &widgetStatusResponse{Status: widgetStatusOK}
+
+	property, err := impl.GetCachedPropertyBySitekey(ctx, sitekey)
+	if err != nil {
+		switch err {
+		case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
+			sendError(ctx, w, http.StatusForbidden, ErrorCodeSitekeyNotFound)
+			return
+		case db.ErrInvalidInput:
+			sendError(ctx, w, http.StatusBadRequest, ErrorCodeInvalidSitekey)
+			return
+		case db.ErrTestProperty:
+			// BUMP
+		case db.ErrCacheMiss:
+			s.Auth.SitekeyChan <- sitekey
+		default:
+			sendError(ctx, w, http.StatusInternalServerError, ErrorCodeInternal)
+			return
+		}
+	}
+
+	if property != nil {
+		if property.PausedAt.Valid {
+			response.Status = widgetStatusPaused
+		} else if s.Auth.requiresDomainVerification(property) {
+			response.Status = widgetStatusBlocked
+		} else if softRestriction, err := s.Auth.Limiter.Evaluate(ctx, property.OrgOwnerID.Int32); err == nil {
+			if softRestriction {
+				response.Status = widgetStatusOverQuota
+			} else {
+				response.Status = widgetStatusBlocked
+			}
+		} else if !property.TestMode && s.Auth.Limiter.PropertyQuotaExceeded(ctx, property) {
+			response.Status = widgetStatusOverQuota
+		} else if impl.InMaintenance() {
+			response.Status = widgetStatusMaintenance
+		}
+
+		if response.Status != widgetStatusOK {
+			if messages, err := impl.RetrievePropertyMessages(ctx, property.ID); err == nil {
+				response.applyMessages(messages)
+			}
+		}
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+)
+
+func statusSuite(sitekey, domain string) (*widgetStatusResponse, int, error) {
+	srv := http.NewServeMux()
+	s.Setup(srv, "", true /*verbose*/, common.NoopMiddleware)
+
+	req, err := http.NewRequest(http.MethodGet, "/"+common.StatusEndpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.Header.Set("Origin", common_test.PrependProtocol(domain))
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	q := req.URL.Query()
+	q.Add(common.ParamSiteKey, sitekey)
+	req.URL.RawQuery = q.Encode()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+
+	response := &widgetStatusResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, resp.StatusCode, err
+	}
+
+	return response, resp.StatusCode, nil
+}
+
+func TestWidgetStatusMessages(t *testing.T) {
+	t.Parallel()
+
+	messages := &dbgen.PropertyMessage{
+		BlockedMessage:     "blocked",
+		QuotaMessage:       "quota",
+		MaintenanceMessage: "maintenance",
+		RedirectUrl:        "https://example.com/contact",
+	}
+
+	testCases := []struct {
+		status   string
+		message  string
+		redirect string
+	}{
+		{widgetStatusOK, "", ""},
+		{widgetStatusBlocked, "blocked", messages.RedirectUrl},
+		{widgetStatusOverQuota, "quota", messages.RedirectUrl},
+		{widgetStatusMaintenance, "maintenance", messages.RedirectUrl},
+		{widgetStatusPaused, "blocked", messages.RedirectUrl},
+	}
+
+	for _, tc := range testCases {
+		response := &widgetStatusResponse{Status: tc.status}
+		response.applyMessages(messages)
+
+		if (response.Message != tc.message) || (response.RedirectURL != tc.redirect) {
+			t.Errorf("Unexpected response for status %v: %+v", tc.status, response)
+		}
+	}
+}
+
+func TestWidgetStatusWithoutSubscription(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewBareAccount(ctx, store, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       t.Name(),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const blockedMessage = "Please contact support"
+
+	if _, err := store.Impl().UpdatePropertyMessages(ctx, &dbgen.UpsertPropertyMessagesParams{
+		PropertyID:     property.ID,
+		BlockedMessage: blockedMessage,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+	if err := cache.Delete(ctx, db.PropertyBySitekeyCacheKey(sitekey)); err != nil {
+		t.Fatal(err)
+	}
+
+	// first request is "ok", until we backfill
+	response, code, err := statusSuite(sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (code != http.StatusOK) || (response.Status != widgetStatusOK) {
+		t.Fatalf("Unexpected status before backfill: %v (%v)", response, code)
+	}
+
+	time.Sleep(3 * authBackfillDelay)
+
+	response, code, err = statusSuite(sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (code != http.StatusOK) || (response.Status != widgetStatusBlocked) || (response.Message != blockedMessage) {
+		t.Errorf("Unexpected status after backfill: %v (%v)", response, code)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	maxTrustedVisitors = 100_000
+	// difficulty of puzzles for trusted returning visitors (lower than any of the "standard" levels)
+	trustedVisitorDifficulty = uint8(common.DifficultyLevelSmall - common.DifficultyDelta)
+	fingerprintMaskLabel     = "trusted-visitor-fingerprint"
+	fingerprintSize          = 8
+)
+
+var (
+	errNoPuzzleFingerprint = errors.New("puzzle does not have space for fingerprint")
+)
+
+// trustedVisitorKey identifies a visitor (by hashed fingerprint) within a property
+type trustedVisitorKey struct {
+	PropertyID  int32
+	Fingerprint common.TFingerprint
+}
+
+func hashTrustedVisitorKey(k trustedVisitorKey) uint64 {
+	return db.HashUint64(k.Fingerprint ^ (uint64(uint32(k.PropertyID)) << 32))
+}
+
+// value is the count of consecutive successful verifications
+func newTrustedVisitorsCache() common.Cache[trustedVisitorKey, int16] {
+	return db.NewMemoryCache[trustedVisitorKey, int16](maxTrustedVisitors, 0 /*missing value*/, hashTrustedVisitorKey)
+}
+
+func trustedVisitorsEnabled(p *dbgen.Property) bool {
+	// privacy mode promises to not keep any per-visitor state and in IP-less mode fingerprints are random anyways
+	return (p != nil) && (p.TrustedVisitorsThreshold > 0) && (p.TrustedVisitorsTtl > 0) && !p.PrivacyMode && !p.IplessMode
+}
+
+// fingerprintMask is used to embed fingerprint into the puzzle so that it cannot be linked between puzzles
+// by anybody without the fingerprint key
+func (s *Server) fingerprintMask(puzzleID uint64) (uint64, error) {
+	hash, err := blake2b.New256(s.UserFingerprintKey.Value())
+	if err != nil {
+		return 0, err
+	}
+
+	hash.Write([]byte(fingerprintMaskLabel))
+
+	var idBytes [8]byte
+	binary.BigEndian.PutUint64(idBytes[:], puzzleID)
+	hash.Write(idBytes[:])
+
+	return binary.BigEndian.Uint64(hash.Sum(nil)[:fingerprintSize]), nil
+}
+
+func (s *Server) embedFingerprint(ctx context.Context, p *puzzle.Puzzle, fingerprint common.TFingerprint) {
+	mask, err := s.fingerprintMask(p.PuzzleID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create fingerprint mask", common.ErrAttr(err))
+		return
+	}
+
+	if len(p.UserData) < fingerprintSize {
+		return
+	}
+
+	binary.BigEndian.PutUint64(p.UserData[:fingerprintSize], fingerprint^mask)
+}
+
+func (s *Server) extractFingerprint(p *puzzle.Puzzle) (common.TFingerprint, error) {
+	mask, err := s.fingerprintMask(p.PuzzleID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(p.UserData) < fingerprintSize {
+		return 0, errNoPuzzleFingerprint
+	}
+
+	return binary.BigEndian.Uint64(p.UserData[:fingerprintSize]) ^ mask, nil
+}
+
+// isTrustedVisitor checks if visitor had enough recent successful verifications for the property
+func (s *Server) isTrustedVisitor(ctx context.Context, property *dbgen.Property, fingerprint common.TFingerprint) bool {
+	successes, err := s.trustedVisitors.Get(ctx, trustedVisitorKey{PropertyID: property.ID, Fingerprint: fingerprint})
+	if err != nil {
+		return false
+	}
+
+	return successes >= property.TrustedVisitorsThreshold
+}
+
+// recordTrustedVisitor counts successful verification towards visitor's trust. Puzzles, harder than the base
+// difficulty of the property (issued during elevated activity of the visitor or the property), reset the trust
+func (s *Server) recordTrustedVisitor(ctx context.Context, p *puzzle.Puzzle, property *dbgen.Property) {
+	if p.IsStub() || p.IsZero() {
+		return
+	}
+
+	fingerprint, err := s.extractFingerprint(p)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to extract fingerprint from puzzle", common.ErrAttr(err))
+		return
+	}
+
+	key := trustedVisitorKey{PropertyID: property.ID, Fingerprint: fingerprint}
+
+	if p.Difficulty > puzzle.AdjustedDifficulty(p.Version, uint8(property.Level.Int16)) {
+		slog.Log(ctx, common.LevelTrace, "Resetting trust of suspicious visitor", "propertyID", property.ID,
+			"difficulty", p.Difficulty)
+		_ = s.trustedVisitors.Delete(ctx, key)
+		return
+	}
+
+	successes, err := s.trustedVisitors.Get(ctx, key)
+	if err != nil {
+		successes = 0
+	}
+
+	// TTL is refreshed with every success so that active visitors remain trusted
+	_ = s.trustedVisitors.Set(ctx, key, min(successes+1, property.TrustedVisitorsThreshold), property.TrustedVisitorsTtl)
+}
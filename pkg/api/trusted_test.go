@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func trustedVisitorsSuite() (*Server, *dbgen.Property) {
+	srv := &Server{
+		UserFingerprintKey: NewUserFingerprintKey(&mutableConfigItem{key: common.UserFingerprintIVKey}, nil /*deriver*/),
+		trustedVisitors:    newTrustedVisitorsCache(),
+	}
+
+	property := &dbgen.Property{
+		ID:                       123,
+		Level:                    db.Int2(int16(common.DifficultyLevelMedium)),
+		TrustedVisitorsThreshold: 2,
+		TrustedVisitorsTtl:       10 * time.Minute,
+	}
+
+	return srv, property
+}
+
+func trustedVisitorPuzzle(t *testing.T, srv *Server, property *dbgen.Property, fingerprint common.TFingerprint, difficulty uint8) *puzzle.Puzzle {
+	p := puzzle.NewPuzzle(puzzle.RandomPuzzleID(), property.ExternalID.Bytes, difficulty)
+	if err := p.Init(property.ValidityInterval); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.embedFingerprint(context.TODO(), p, fingerprint)
+
+	return p
+}
+
+func TestPuzzleFingerprint(t *testing.T) {
+	t.Parallel()
+
+	srv, property := trustedVisitorsSuite()
+	const fingerprint = common.TFingerprint(0x1234567890abcdef)
+
+	p1 := trustedVisitorPuzzle(t, srv, property, fingerprint, 100)
+	p2 := trustedVisitorPuzzle(t, srv, property, fingerprint, 100)
+
+	for _, p := range []*puzzle.Puzzle{p1, p2} {
+		actual, err := srv.extractFingerprint(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != fingerprint {
+			t.Errorf("Unexpected fingerprint: %x", actual)
+		}
+	}
+
+	// fingerprint itself should not be visible in the puzzle
+	if string(p1.UserData[:fingerprintSize]) == string(p2.UserData[:fingerprintSize]) {
+		t.Error("Embedded fingerprints are the same")
+	}
+}
+
+func TestTrustedVisitorThreshold(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	srv, property := trustedVisitorsSuite()
+	const fingerprint = common.TFingerprint(123)
+	difficulty := uint8(property.Level.Int16)
+
+	for i := 0; i < int(property.TrustedVisitorsThreshold); i++ {
+		if srv.isTrustedVisitor(ctx, property, fingerprint) {
+			t.Fatalf("Visitor is trusted after %v verifications", i)
+		}
+
+		srv.recordTrustedVisitor(ctx, trustedVisitorPuzzle(t, srv, property, fingerprint, difficulty), property)
+	}
+
+	if !srv.isTrustedVisitor(ctx, property, fingerprint) {
+		t.Error("Visitor is not trusted after reaching the threshold")
+	}
+
+	if srv.isTrustedVisitor(ctx, property, fingerprint+1) {
+		t.Error("Other visitor is trusted")
+	}
+
+	otherProperty := *property
+	otherProperty.ID++
+	if srv.isTrustedVisitor(ctx, &otherProperty, fingerprint) {
+		t.Error("Visitor is trusted for other property")
+	}
+
+	// solving a harder puzzle (elevated activity) resets trust
+	srv.recordTrustedVisitor(ctx, trustedVisitorPuzzle(t, srv, property, fingerprint, difficulty+1), property)
+
+	if srv.isTrustedVisitor(ctx, property, fingerprint) {
+		t.Error("Visitor is still trusted after suspicious verification")
+	}
+}
+
+func TestTrustedVisitorsDisabledInPrivacyMode(t *testing.T) {
+	t.Parallel()
+
+	_, property := trustedVisitorsSuite()
+	if !trustedVisitorsEnabled(property) {
+		t.Fatal("Trusted visitors are not enabled")
+	}
+
+	property.PrivacyMode = true
+	if trustedVisitorsEnabled(property) {
+		t.Error("Trusted visitors are enabled in privacy mode")
+	}
+
+	property.PrivacyMode = false
+	property.IplessMode = true
+	if trustedVisitorsEnabled(property) {
+		t.Error("Trusted visitors are enabled in IP-less mode")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -191,17 +192,87 @@ func TestVerifyPuzzleReplay(t *testing.T) {
 		t.Errorf("Unexpected submit status code %d", resp.StatusCode)
 	}
 
-	// now second time the same
+	// retry of exactly the same request is idempotent
 	resp, err = verifySuite(payload, apiKey)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+	key, err := store.Impl().RetrieveAPIKey(ctx, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := store.Impl().CreateAPIKey(ctx, key.UserID.Int32, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// but the same solution cannot be verified by a different request
+	resp, err = verifySuite(payload, db.UUIDToSecret(otherKey.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	if err := checkVerifyError(resp, puzzle.VerifiedBeforeError); err != nil {
 		t.Fatal(err)
 	}
 }
 
+func TestVerifyRotatedSitekey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, sitekey, err := setupVerifySuite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+
+	properties, err := store.Impl().RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey: {}})
+	if (err != nil) || (len(properties) != 1) {
+		t.Fatalf("Failed to retrieve property: %v", err)
+	}
+
+	var rotated *dbgen.Property
+	if err := store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		rotated, err = impl.RotatePropertySitekey(ctx, properties[0], time.Now().Add(common.SitekeyRotationOverlap))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if db.UUIDToSiteKey(rotated.ExternalID) == sitekey {
+		t.Fatal("Sitekey was not changed")
+	}
+
+	// puzzle was issued for the previous sitekey
+	resp, err := verifySuite(payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Impl().ExpirePropertySitekeyRotation(ctx, rotated.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	properties, err = store.Impl().RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey: {}})
+	if (err != nil) || (len(properties) != 0) {
+		t.Errorf("Previous sitekey still resolves after expiration: %v", err)
+	}
+}
+
 func TestVerifyPuzzleAllowReplay(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -336,6 +407,137 @@ func TestVerifyExpiredKey(t *testing.T) {
 	}
 }
 
+func TestVerifyOrgAPIKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       fmt.Sprintf("%v property", t.Name()),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzleStr, solutionsStr, err := solutionsSuite(ctx, db.UUIDToSiteKey(property.ExternalID), property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apikey, err := store.Impl().CreateOrgAPIKey(ctx, org.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if apikey.UserID.Valid {
+		t.Errorf("Org API key should not belong to a user: %v", apikey.UserID.Int32)
+	}
+
+	resp, err := verifySuite(fmt.Sprintf("%s.%s", solutionsStr, puzzleStr), db.UUIDToSecret(apikey.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected submit status code %d", resp.StatusCode)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsOutsideOwnerOrg(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{OrgID: db.Int(1)}
+	owner := &apiKeyOwnerSource{}
+
+	if isOutsideOwnerOrg(context.TODO(), owner, property) {
+		t.Error("Personal API keys are not restricted to an organization")
+	}
+
+	if ctx := context.WithValue(context.TODO(), common.APIKeyOrgContextKey, int32(1)); isOutsideOwnerOrg(ctx, owner, property) {
+		t.Error("Property of the same organization is expected to be allowed")
+	}
+
+	if ctx := context.WithValue(context.TODO(), common.APIKeyOrgContextKey, int32(2)); !isOutsideOwnerOrg(ctx, owner, property) {
+		t.Error("Property of other organization is expected to be rejected")
+	}
+}
+
+func TestVerifyOrgAPIKeyOtherOrg(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	user, orgA, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both organizations have the same owner
+	orgB, err := store.Impl().CreateNewOrganization(ctx, fmt.Sprintf("%v other org", t.Name()), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       fmt.Sprintf("%v property", t.Name()),
+		OrgID:      db.Int(orgB.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzleStr, solutionsStr, err := solutionsSuite(ctx, db.UUIDToSiteKey(property.ExternalID), property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apikey, err := store.Impl().CreateOrgAPIKey(ctx, orgA.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := verifySuite(fmt.Sprintf("%s.%s", solutionsStr, puzzleStr), db.UUIDToSecret(apikey.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected submit status code %d", resp.StatusCode)
+	}
+
+	if err := checkVerifyError(resp, puzzle.WrongOwnerError); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestVerifyMaintenanceMode(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -407,3 +609,111 @@ func TestVerifyTestProperty(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestVerifyRateLimitHeaders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, _, err := setupVerifySuite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := verifySuite(payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 rps (see setupVerifySuite) result in burst of 50
+	if limit := resp.Header.Get("X-RateLimit-Limit"); limit != "50" {
+		t.Errorf("Unexpected rate limit header: %v", limit)
+	}
+
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); len(remaining) == 0 {
+		t.Error("Rate limit remaining header is missing")
+	}
+
+	// quota is fetched in the background after the first request
+	time.Sleep(500 * time.Millisecond)
+
+	resp, err = verifySuite(payload, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit := resp.Header.Get(quotaLimitHeader); limit != strconv.FormatInt(testPlan.RequestsLimit(), 10) {
+		t.Errorf("Unexpected quota limit header: %v", limit)
+	}
+
+	if reset := resp.Header.Get(quotaResetHeader); len(reset) == 0 {
+		t.Error("Quota reset header is missing")
+	}
+}
+
+func TestVerifyPuzzleAction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       t.Name(),
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: db.Int(user.ID),
+		Domain:     testPropertyDomain,
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const action = "login"
+
+	resp, err := puzzleSuiteWithParams(db.UUIDToSiteKey(property.ExternalID), property.Domain, "" /*version*/, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected puzzle status code %d", resp.StatusCode)
+	}
+
+	p, puzzleStr, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solver := &puzzle.Solver{}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apikey, err := store.Impl().CreateAPIKey(ctx, user.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = verifySuite(fmt.Sprintf("%s.%s", solutions.String(), puzzleStr), db.UUIDToSecret(apikey.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := &VerifyResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	if !response.Success || (response.Action != action) {
+		t.Errorf("Unexpected verify response: success=%v action=%v", response.Success, response.Action)
+	}
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	VerifyLogOverflowDrop  = "drop"
+	VerifyLogOverflowSpill = "spill"
+	verifyLogOverflowSize  = 10 * VerifyBatchSize
+	verifyLogSpillFile     = "verify_log.spill"
+	// overflow buffer is moved to the channel at least this often, even without new records
+	verifyLogPumpInterval = 1 * time.Second
+)
+
+// verifyLogQueue is a non-blocking producer side of the verify log pipeline. When the channel is full (e.g. ClickHouse
+// is slow to accept batches), records are kept in a bounded overflow buffer that is moved to the channel in background.
+// When overflow buffer is full too, records are either dropped (and counted) or spilled to disk, depending on policy.
+// Spilled records are loaded back when the pipeline catches up (or after restart)
+type verifyLogQueue struct {
+	channel     chan<- *common.VerifyRecord
+	metrics     common.BatchMetrics
+	maxOverflow int
+	// empty path means "drop" policy
+	spillPath string
+	lock      sync.Mutex
+	overflow  []*common.VerifyRecord
+	spillFile *os.File
+	signal    chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func newVerifyLogQueue(channel chan<- *common.VerifyRecord, metrics common.BatchMetrics, maxOverflow int, policy, spillDir string) *verifyLogQueue {
+	q := &verifyLogQueue{
+		channel:     channel,
+		metrics:     metrics,
+		maxOverflow: maxOverflow,
+		signal:      make(chan struct{}, 1),
+		cancel:      func() {},
+	}
+
+	if (policy == VerifyLogOverflowSpill) && (len(spillDir) > 0) {
+		q.spillPath = filepath.Join(spillDir, verifyLogSpillFile)
+	}
+
+	return q
+}
+
+func (q *verifyLogQueue) restorePath() string {
+	return q.spillPath + ".restore"
+}
+
+// Enqueue never blocks on the channel
+func (q *verifyLogQueue) Enqueue(ctx context.Context, record *common.VerifyRecord) {
+	select {
+	case q.channel <- record:
+		return
+	default:
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.overflow) < q.maxOverflow {
+		q.overflow = append(q.overflow, record)
+		if len(q.overflow) == 1 {
+			slog.WarnContext(ctx, "Verify log channel is full, using overflow buffer")
+		}
+		q.notify()
+		return
+	}
+
+	if len(q.spillPath) > 0 {
+		err := q.spillLocked([]*common.VerifyRecord{record})
+		if err == nil {
+			return
+		}
+
+		slog.ErrorContext(ctx, "Failed to spill verify record", common.ErrAttr(err))
+	}
+
+	q.dropped(1)
+}
+
+// Depth returns number of records waiting in the channel and in the overflow buffer
+func (q *verifyLogQueue) Depth() (int, int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.channel), len(q.overflow)
+}
+
+func (q *verifyLogQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *verifyLogQueue) dropped(count int) {
+	if q.metrics != nil {
+		q.metrics.ObserveBatchDropped(common.BatchPipelineVerifyLog, count)
+	}
+}
+
+func (q *verifyLogQueue) spillLocked(records []*common.VerifyRecord) error {
+	if q.spillFile == nil {
+		f, err := os.OpenFile(q.spillPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		q.spillFile = f
+	}
+
+	encoder := json.NewEncoder(q.spillFile)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// send blocks until records are accepted by the channel and returns the ones that were not sent due to cancellation
+func (q *verifyLogQueue) send(ctx context.Context, records []*common.VerifyRecord) []*common.VerifyRecord {
+	for i, r := range records {
+		select {
+		case <-ctx.Done():
+			return records[i:]
+		case q.channel <- r:
+		}
+	}
+
+	return nil
+}
+
+func (q *verifyLogQueue) readSpilled(ctx context.Context) []*common.VerifyRecord {
+	restorePath := q.restorePath()
+
+	q.lock.Lock()
+	if q.spillFile != nil {
+		_ = q.spillFile.Close()
+		q.spillFile = nil
+	}
+	// restore file can be left from the previous (interrupted) attempt
+	if _, err := os.Stat(restorePath); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(q.spillPath, restorePath); err != nil {
+			q.lock.Unlock()
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.ErrorContext(ctx, "Failed to move verify log spill file", common.ErrAttr(err))
+			}
+			return nil
+		}
+	}
+	q.lock.Unlock()
+
+	f, err := os.Open(restorePath)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to open verify log spill file", common.ErrAttr(err))
+		return nil
+	}
+	defer f.Close()
+
+	var records []*common.VerifyRecord
+	decoder := json.NewDecoder(f)
+	for {
+		r := &common.VerifyRecord{}
+		if err := decoder.Decode(r); err != nil {
+			if err != io.EOF {
+				slog.ErrorContext(ctx, "Failed to decode spilled verify record", common.ErrAttr(err))
+			}
+			break
+		}
+		records = append(records, r)
+	}
+
+	if err := os.Remove(restorePath); err != nil {
+		slog.ErrorContext(ctx, "Failed to remove verify log spill file", common.ErrAttr(err))
+	}
+
+	slog.InfoContext(ctx, "Restored spilled verify records", "count", len(records))
+
+	return records
+}
+
+func (q *verifyLogQueue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+	q.done = make(chan struct{})
+
+	go q.run(ctx)
+}
+
+func (q *verifyLogQueue) run(ctx context.Context) {
+	defer close(q.done)
+
+	for running := true; running; {
+		var pending []*common.VerifyRecord
+
+		q.lock.Lock()
+		pending, q.overflow = q.overflow, nil
+		q.lock.Unlock()
+
+		// spilled records are restored only after overflow buffer is fully moved to the channel
+		if (len(pending) == 0) && (len(q.spillPath) > 0) {
+			pending = q.readSpilled(ctx)
+		}
+
+		if unsent := q.send(ctx, pending); len(unsent) > 0 {
+			q.lock.Lock()
+			q.overflow = append(unsent, q.overflow...)
+			q.lock.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-q.signal:
+		case <-time.After(verifyLogPumpInterval):
+		}
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.overflow) > 0 {
+		if len(q.spillPath) > 0 {
+			if err := q.spillLocked(q.overflow); err != nil {
+				slog.ErrorContext(ctx, "Failed to spill verify records on shutdown", "count", len(q.overflow), common.ErrAttr(err))
+				q.dropped(len(q.overflow))
+			}
+		} else {
+			slog.WarnContext(ctx, "Dropping verify records on shutdown", "count", len(q.overflow))
+			q.dropped(len(q.overflow))
+		}
+		q.overflow = nil
+	}
+
+	if q.spillFile != nil {
+		_ = q.spillFile.Close()
+		q.spillFile = nil
+	}
+
+	slog.DebugContext(ctx, "Finished processing verify log overflow")
+}
+
+// Shutdown waits until overflow buffer is spilled or dropped so that the channel can be closed
+func (q *verifyLogQueue) Shutdown() {
+	q.cancel()
+	if q.done != nil {
+		<-q.done
+	}
+}
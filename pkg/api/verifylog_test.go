@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/testsupport"
+)
+
+type droppedMetricsStub struct {
+	common.BatchMetrics
+	dropped atomic.Int32
+}
+
+func (m *droppedMetricsStub) ObserveBatchDropped(pipeline string, count int) {
+	m.dropped.Add(int32(count))
+}
+
+func enqueueTestRecords(q *verifyLogQueue, count int) {
+	for i := 0; i < count; i++ {
+		q.Enqueue(context.TODO(), &common.VerifyRecord{PropertyID: int32(i), PuzzleID: uint64(i)})
+	}
+}
+
+// receiveTestRecords reads from the channel until expected number of records is received or timeout
+func receiveTestRecords(channel <-chan *common.VerifyRecord, expected int, timeout time.Duration) int {
+	received := 0
+	for received < expected {
+		select {
+		case <-channel:
+			received++
+		case <-time.After(timeout):
+			return received
+		}
+	}
+	return received
+}
+
+func TestVerifyLogQueueDrop(t *testing.T) {
+	t.Parallel()
+
+	channel := make(chan *common.VerifyRecord, 2)
+	metrics := &droppedMetricsStub{}
+	q := newVerifyLogQueue(channel, metrics, 3 /*max overflow*/, VerifyLogOverflowDrop, "")
+
+	// nobody reads from the channel, but enqueue must not block
+	done := make(chan struct{})
+	go func() {
+		enqueueTestRecords(q, 10)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Fatal("Enqueue is blocked on a full channel")
+	}
+
+	if (len(channel) != 2) || (len(q.overflow) != 3) || (metrics.dropped.Load() != 5) {
+		t.Errorf("Unexpected load shedding: channel=%v overflow=%v dropped=%v", len(channel), len(q.overflow), metrics.dropped.Load())
+	}
+}
+
+func TestVerifyLogQueueOverflow(t *testing.T) {
+	t.Parallel()
+
+	channel := make(chan *common.VerifyRecord, 2)
+	metrics := &droppedMetricsStub{}
+	q := newVerifyLogQueue(channel, metrics, 10 /*max overflow*/, VerifyLogOverflowDrop, "")
+	q.Start(context.TODO())
+	defer q.Shutdown()
+
+	enqueueTestRecords(q, 10)
+
+	if received := receiveTestRecords(channel, 10, 2*time.Second); received != 10 {
+		t.Errorf("Unexpected number of received records: %v", received)
+	}
+
+	if dropped := metrics.dropped.Load(); dropped != 0 {
+		t.Errorf("Unexpected number of dropped records: %v", dropped)
+	}
+}
+
+func TestVerifyLogQueueSpill(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	channel := make(chan *common.VerifyRecord, 2)
+	metrics := &droppedMetricsStub{}
+	q := newVerifyLogQueue(channel, metrics, 3 /*max overflow*/, VerifyLogOverflowSpill, dir)
+
+	enqueueTestRecords(q, 10)
+
+	if dropped := metrics.dropped.Load(); dropped != 0 {
+		t.Errorf("Unexpected number of dropped records: %v", dropped)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, verifyLogSpillFile)); err != nil {
+		t.Fatalf("Spill file is missing: %v", err)
+	}
+
+	q.Start(context.TODO())
+	defer q.Shutdown()
+
+	if received := receiveTestRecords(channel, 10, 2*time.Second); received != 10 {
+		t.Errorf("Unexpected number of received records: %v", received)
+	}
+}
+
+func TestVerifyLogQueueSpillOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	channel := make(chan *common.VerifyRecord, 2)
+	q := newVerifyLogQueue(channel, nil /*metrics*/, 10 /*max overflow*/, VerifyLogOverflowSpill, dir)
+	q.Start(context.TODO())
+
+	enqueueTestRecords(q, 5)
+	q.Shutdown()
+
+	if len(channel) != 2 {
+		t.Fatalf("Unexpected records in channel: %v", len(channel))
+	}
+
+	// records that did not fit into the channel are restored after restart
+	restarted := make(chan *common.VerifyRecord, 2)
+	q = newVerifyLogQueue(restarted, nil /*metrics*/, 10 /*max overflow*/, VerifyLogOverflowSpill, dir)
+	q.Start(context.TODO())
+	defer q.Shutdown()
+
+	if received := receiveTestRecords(restarted, 3, 2*time.Second); received != 3 {
+		t.Errorf("Unexpected number of restored records: %v", received)
+	}
+}
+
+func TestVerifyLogFlushToTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	timeSeries := testsupport.NewTimeSeries()
+	timeSeries.SetError("WriteVerifyLogBatch", testsupport.ErrInjected)
+
+	channel := make(chan *common.VerifyRecord, 10)
+	q := newVerifyLogQueue(channel, nil /*metrics*/, 10 /*max overflow*/, VerifyLogOverflowDrop, "")
+	q.Start(ctx)
+	defer q.Shutdown()
+
+	go common.ProcessBatchArray(ctx, common.BatchPipelineVerifyLog, nil /*metrics*/, channel, 10*time.Millisecond,
+		5 /*trigger size*/, 100 /*max batch size*/, timeSeries.WriteVerifyLogBatch)
+
+	enqueueTestRecords(q, 7)
+
+	// failed batch is retained and written after the store recovers
+	time.Sleep(50 * time.Millisecond)
+	if records := timeSeries.VerifyRecords(); len(records) != 0 {
+		t.Fatalf("Unexpected records written with store error: %v", len(records))
+	}
+
+	timeSeries.SetError("WriteVerifyLogBatch", nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for (len(timeSeries.VerifyRecords()) < 7) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if records := timeSeries.VerifyRecords(); len(records) != 7 {
+		t.Errorf("Unexpected number of written records: %v", len(records))
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+)
+
+type recordingAlerter struct {
+	lock   sync.Mutex
+	alerts []*common.Alert
+}
+
+func (ra *recordingAlerter) SendAlert(ctx context.Context, alert *common.Alert) {
+	ra.lock.Lock()
+	defer ra.lock.Unlock()
+	ra.alerts = append(ra.alerts, alert)
+}
+
+func isWebhookEventPending(ctx context.Context, id int32, t *testing.T) bool {
+	events, err := store.Impl().RetrievePendingWebhookEvents(ctx, 1000 /*max attempts*/, 1000 /*limit*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range events {
+		if e.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestWebhookEventWithoutHandlerIsSkipped(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	event, err := store.Impl().CreateWebhookEvent(ctx, t.Name()+"_"+db.UUIDToSiteKey(*randomUUID()), "unknown.event", []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := &maintenance.ProcessWebhookEventsJob{Store: store, Handlers: map[string]maintenance.WebhookEventHandler{}}
+	if err := job.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// skipped event is not retried (and does not use up attempts)
+	if isWebhookEventPending(ctx, event.ID, t) {
+		t.Error("Webhook event without handler is still pending")
+	}
+}
+
+func TestCleanupExhaustedWebhookEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	eventType := "test." + db.UUIDToSiteKey(*randomUUID())
+
+	event, err := store.Impl().CreateWebhookEvent(ctx, t.Name()+"_"+db.UUIDToSiteKey(*randomUUID()), eventType, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; /*max attempts*/ i++ {
+		if err := store.Impl().MarkWebhookEventFailed(ctx, event.ID, "failure", time.Now().UTC()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alerter := &recordingAlerter{}
+	job := &maintenance.CleanupWebhookEventsJob{Store: store, Age: -time.Minute, Alerter: alerter}
+	if err := job.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is left to be deleted again
+	deleted, err := store.Impl().DeleteExhaustedWebhookEvents(ctx, 10 /*max attempts*/, time.Now().UTC().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range deleted {
+		if e.ID == event.ID {
+			t.Error("Exhausted webhook event was not deleted by cleanup")
+		}
+	}
+
+	alerter.lock.Lock()
+	defer alerter.lock.Unlock()
+
+	if (len(alerter.alerts) != 1) || !strings.Contains(alerter.alerts[0].Text, eventType) {
+		t.Errorf("Unexpected alerts: %v", alerter.alerts)
+	}
+}
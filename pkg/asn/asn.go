@@ -0,0 +1,158 @@
+package asn
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Class is a coarse classification of the network that client IP belongs to
+type Class uint8
+
+const (
+	// ClassUnknown is used for invalid, private and otherwise non-routable addresses
+	ClassUnknown Class = iota
+	// ClassResidential is any public address that does not belong to a known hosting provider
+	ClassResidential
+	// ClassDatacenter is an address announced by a hosting or cloud provider
+	ClassDatacenter
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassResidential:
+		return "residential"
+	case ClassDatacenter:
+		return "datacenter"
+	default:
+		return "unknown"
+	}
+}
+
+//go:embed datacenters.csv
+var datacentersCSV string
+
+type network struct {
+	prefix netip.Prefix
+	asn    uint32
+	name   string
+}
+
+// Classifier maps IP addresses to the network (ASN) they are announced from. It is immutable after creation
+// and safe for concurrent use
+type Classifier struct {
+	// sorted by prefix address, prefixes are expected to not overlap
+	networks []network
+}
+
+// NewClassifier parses dataset in the "prefix,asn,organization" format (one network per line, # for comments)
+func NewClassifier(r io.Reader) (*Classifier, error) {
+	networks := make([]network, 0)
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if (len(line) == 0) || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ",", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 fields, got %d", lineNo, len(parts))
+		}
+
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		asn, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		networks = append(networks, network{
+			prefix: prefix.Masked(),
+			asn:    uint32(asn),
+			name:   strings.TrimSpace(parts[2]),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(networks, func(a, b network) int {
+		return a.prefix.Addr().Compare(b.prefix.Addr())
+	})
+
+	return &Classifier{networks: networks}, nil
+}
+
+// NewDefaultClassifier uses the dataset embedded into the binary
+func NewDefaultClassifier() *Classifier {
+	c, err := NewClassifier(strings.NewReader(datacentersCSV))
+	if err != nil {
+		slog.Error("Failed to parse embedded ASN dataset", "error", err)
+		return &Classifier{}
+	}
+
+	slog.Debug("Loaded ASN dataset", "networks", len(c.networks))
+
+	return c
+}
+
+func (c *Classifier) lookup(ip netip.Addr) (*network, bool) {
+	if (c == nil) || (len(c.networks) == 0) {
+		return nil, false
+	}
+
+	// index of the first network that starts after ip, so the candidate is the one before it
+	i, _ := slices.BinarySearchFunc(c.networks, ip, func(n network, target netip.Addr) int {
+		if n.prefix.Addr().Compare(target) <= 0 {
+			return -1
+		}
+		return 1
+	})
+
+	if i == 0 {
+		return nil, false
+	}
+
+	if n := &c.networks[i-1]; n.prefix.Contains(ip) {
+		return n, true
+	}
+
+	return nil, false
+}
+
+// Lookup returns ASN and organization name of a known hosting network that ip belongs to
+func (c *Classifier) Lookup(ip netip.Addr) (uint32, string, bool) {
+	if n, ok := c.lookup(ip.Unmap()); ok {
+		return n.asn, n.name, true
+	}
+
+	return 0, "", false
+}
+
+func (c *Classifier) Classify(ip netip.Addr) Class {
+	ip = ip.Unmap()
+
+	if (c == nil) || !ip.IsValid() || ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return ClassUnknown
+	}
+
+	if _, ok := c.lookup(ip); ok {
+		return ClassDatacenter
+	}
+
+	return ClassResidential
+}
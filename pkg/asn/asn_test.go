@@ -0,0 +1,70 @@
+package asn
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	classifier := NewDefaultClassifier()
+
+	testCases := []struct {
+		ip       string
+		expected Class
+	}{
+		{"95.216.1.1", ClassDatacenter},
+		{"142.93.255.255", ClassDatacenter},
+		{"2a01:4f8:10a::1", ClassDatacenter},
+		{"::ffff:5.9.10.10", ClassDatacenter},
+		{"5.10.0.1", ClassResidential},
+		{"8.8.8.8", ClassResidential},
+		{"192.168.0.1", ClassUnknown},
+		{"127.0.0.1", ClassUnknown},
+		{"::1", ClassUnknown},
+	}
+
+	for _, tc := range testCases {
+		if actual := classifier.Classify(netip.MustParseAddr(tc.ip)); actual != tc.expected {
+			t.Errorf("Unexpected class of %v: expected %v, got %v", tc.ip, tc.expected, actual)
+		}
+	}
+
+	var nilClassifier *Classifier
+	if actual := nilClassifier.Classify(netip.MustParseAddr("95.216.1.1")); actual != ClassUnknown {
+		t.Errorf("Unexpected class without dataset: %v", actual)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	classifier, err := NewClassifier(strings.NewReader("# comment\n10.0.0.0/8,64512,First\n\n11.0.0.0/16,64513,Second\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if asn, name, ok := classifier.Lookup(netip.MustParseAddr("11.0.200.1")); !ok || (asn != 64513) || (name != "Second") {
+		t.Errorf("Unexpected lookup result: %v %v %v", asn, name, ok)
+	}
+
+	if _, _, ok := classifier.Lookup(netip.MustParseAddr("11.1.0.1")); ok {
+		t.Errorf("Address outside of the dataset was found")
+	}
+
+	if _, _, ok := classifier.Lookup(netip.MustParseAddr("9.255.255.255")); ok {
+		t.Errorf("Address before the dataset was found")
+	}
+}
+
+func TestInvalidDataset(t *testing.T) {
+	t.Parallel()
+
+	for _, dataset := range []string{"10.0.0.0/8,64512", "10.0.0.0/33,64512,Name", "10.0.0.0/8,asn,Name"} {
+		if _, err := NewClassifier(strings.NewReader(dataset)); err == nil {
+			t.Errorf("Expected error for dataset %q", dataset)
+		}
+	}
+}
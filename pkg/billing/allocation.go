@@ -0,0 +1,55 @@
+package billing
+
+import "time"
+
+const (
+	// weekly allocation is the share of the average week of the monthly plan quota
+	weeksPerYear  = 52
+	monthsPerYear = 12
+	// usage of the allocation (in percent) after which owners are warned in the dashboard
+	AllocationWarningPercent = 80
+)
+
+// Allocation is the share of the plan's monthly requests quota that the owner assigned to a single property
+type Allocation struct {
+	Percent int
+	Weekly  bool
+}
+
+// PeriodStart returns the beginning of the (UTC) allocation period that contains t. Weeks start on Monday
+func (a Allocation) PeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+
+	if !a.Weekly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
+
+// PeriodEnd returns the moment when usage of the allocation period that contains t is reset
+func (a Allocation) PeriodEnd(t time.Time) time.Time {
+	start := a.PeriodStart(t)
+
+	if a.Weekly {
+		return start.AddDate(0, 0, 7)
+	}
+
+	return start.AddDate(0, 1, 0)
+}
+
+// Requests returns number of requests the property can serve in the allocation period or 0 if there's no limit
+func (a Allocation) Requests(monthlyLimit int64) int64 {
+	if (monthlyLimit <= 0) || (a.Percent <= 0) {
+		return 0
+	}
+
+	requests := monthlyLimit * int64(a.Percent) / 100
+	if a.Weekly {
+		requests = requests * monthsPerYear / weeksPerYear
+	}
+
+	return max(requests, 1)
+}
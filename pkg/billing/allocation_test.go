@@ -0,0 +1,63 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllocationPeriod(t *testing.T) {
+	t.Parallel()
+
+	// Thursday
+	tnow := time.Date(2026, time.October, 15, 13, 45, 0, 0, time.UTC)
+
+	testCases := []struct {
+		weekly bool
+		start  time.Time
+		end    time.Time
+	}{
+		{false, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{true, time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		a := Allocation{Percent: 10, Weekly: tc.weekly}
+		if start := a.PeriodStart(tnow); !start.Equal(tc.start) {
+			t.Errorf("PeriodStart(weekly=%v) = %v, expected %v", tc.weekly, start, tc.start)
+		}
+		if end := a.PeriodEnd(tnow); !end.Equal(tc.end) {
+			t.Errorf("PeriodEnd(weekly=%v) = %v, expected %v", tc.weekly, end, tc.end)
+		}
+	}
+
+	// week that started in the previous month
+	a := Allocation{Percent: 10, Weekly: true}
+	if start := a.PeriodStart(time.Date(2026, time.November, 1, 10, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2026, time.October, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected week start across months: %v", start)
+	}
+}
+
+func TestAllocationRequests(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		limit    int64
+		percent  int
+		weekly   bool
+		expected int64
+	}{
+		{0, 10, false, 0},
+		{1_000_000, 0, false, 0},
+		{1_000_000, 10, false, 100_000},
+		{1_000_000, 100, false, 1_000_000},
+		{1_000_000, 13, true, 30_000},
+		{5, 1, false, 1},
+	}
+
+	for _, tc := range testCases {
+		a := Allocation{Percent: tc.percent, Weekly: tc.weekly}
+		if actual := a.Requests(tc.limit); actual != tc.expected {
+			t.Errorf("Requests(%v, %v%%, weekly=%v) = %v, expected %v", tc.limit, tc.percent, tc.weekly, actual, tc.expected)
+		}
+	}
+}
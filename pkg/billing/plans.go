@@ -9,6 +9,8 @@ import (
 const (
 	// do NOT use
 	InternalStatusTrialing = "pc-trial"
+	// internal subscriptions that are paid outside of the billing provider (e.g. provisioned by resellers)
+	InternalStatusActive = "pc-active"
 )
 
 type Prices map[string]int
@@ -25,6 +27,15 @@ type basePlan struct {
 	requestsLimit        int64
 	throttleLimit        int64
 	apiRequestsPerSecond float64
+	// zero means "unlimited" for the counts below
+	maxOrgs       int
+	maxOrgMembers int
+	maxProperties int
+}
+
+// checkCountLimit returns true if one more item can be created when count items already exist
+func checkCountLimit(count, limit int) bool {
+	return (limit <= 0) || (count < limit)
 }
 
 func (p *basePlan) IsValid() bool {
@@ -41,15 +52,27 @@ func (plan *basePlan) Equals(productID string, priceID string) bool {
 		((plan.priceIDMonthly == priceID) || (plan.priceIDYearly == priceID))
 }
 
-func (p *basePlan) Name() string                        { return p.name }
-func (p *basePlan) CheckOrgsLimit(count int) bool       { return true }
-func (p *basePlan) CheckOrgMembersLimit(count int) bool { return true }
-func (p *basePlan) CheckPropertiesLimit(count int) bool { return true }
-func (p *basePlan) ProductID() string                   { return p.productID }
-func (p *basePlan) PriceIDs() (string, string)          { return p.priceIDMonthly, p.priceIDYearly }
-func (p *basePlan) TrialDays() int                      { return 14 }
-func (p *basePlan) RequestsLimit() int64                { return p.requestsLimit }
-func (p *basePlan) APIRequestsPerSecond() float64       { return p.apiRequestsPerSecond }
+func (p *basePlan) Name() string                  { return p.name }
+func (p *basePlan) OrgsLimit() int                { return p.maxOrgs }
+func (p *basePlan) OrgMembersLimit() int          { return p.maxOrgMembers }
+func (p *basePlan) PropertiesLimit() int          { return p.maxProperties }
+func (p *basePlan) ProductID() string             { return p.productID }
+func (p *basePlan) PriceIDs() (string, string)    { return p.priceIDMonthly, p.priceIDYearly }
+func (p *basePlan) TrialDays() int                { return 14 }
+func (p *basePlan) RequestsLimit() int64          { return p.requestsLimit }
+func (p *basePlan) APIRequestsPerSecond() float64 { return p.apiRequestsPerSecond }
+
+func (p *basePlan) CheckOrgsLimit(count int) bool {
+	return checkCountLimit(count, p.maxOrgs)
+}
+
+func (p *basePlan) CheckOrgMembersLimit(count int) bool {
+	return checkCountLimit(count, p.maxOrgMembers)
+}
+
+func (p *basePlan) CheckPropertiesLimit(count int) bool {
+	return checkCountLimit(count, p.maxProperties)
+}
 
 const (
 	version1 = 1
@@ -70,6 +93,10 @@ type Plan interface {
 	CheckOrgsLimit(count int) bool
 	CheckOrgMembersLimit(count int) bool
 	CheckPropertiesLimit(count int) bool
+	// limits below return 0 if there's no limit
+	OrgsLimit() int
+	OrgMembersLimit() int
+	PropertiesLimit() int
 	TrialDays() int
 	RequestsLimit() int64
 	APIRequestsPerSecond() float64
@@ -79,6 +106,7 @@ type PlanService interface {
 	FindPlan(productID string, priceID string, stage string, internal bool) (Plan, error)
 	IsSubscriptionActive(status string) bool
 	TrialStatus() string
+	ActiveStatus() string
 	CancelSubscription(ctx context.Context, sid string) error
 	GetInternalAdminPlan() Plan
 	GetInternalTrialPlan() Plan
@@ -103,6 +131,9 @@ var (
 		requestsLimit:        1_000,
 		throttleLimit:        2_000,
 		apiRequestsPerSecond: 10,
+		maxOrgs:              10,
+		maxOrgMembers:        10,
+		maxProperties:        50,
 	}
 
 	internalAdminPlan = &basePlan{
@@ -170,6 +201,10 @@ func (s *CorePlanService) TrialStatus() string {
 	return InternalStatusTrialing
 }
 
+func (s *CorePlanService) ActiveStatus() string {
+	return InternalStatusActive
+}
+
 func (s *CorePlanService) CancelSubscription(ctx context.Context, sid string) error {
 	// BUMP
 	return nil
@@ -177,7 +212,7 @@ func (s *CorePlanService) CancelSubscription(ctx context.Context, sid string) er
 
 func (s *CorePlanService) IsSubscriptionActive(status string) bool {
 	switch status {
-	case InternalStatusTrialing:
+	case InternalStatusTrialing, InternalStatusActive:
 		return true
 	default:
 		return false
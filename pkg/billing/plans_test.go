@@ -0,0 +1,43 @@
+package billing
+
+import "testing"
+
+func TestCheckCountLimit(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		count int
+		limit int
+		ok    bool
+	}{
+		{0, 0, true},
+		{1000, 0, true},
+		{0, 1, true},
+		{1, 1, false},
+		{9, 10, true},
+		{10, 10, false},
+		{11, 10, false},
+	}
+
+	for _, tc := range testCases {
+		if actual := checkCountLimit(tc.count, tc.limit); actual != tc.ok {
+			t.Errorf("checkCountLimit(%v, %v) = %v, expected %v", tc.count, tc.limit, actual, tc.ok)
+		}
+	}
+}
+
+func TestInternalPlansLimits(t *testing.T) {
+	t.Parallel()
+
+	svc := NewPlanService(nil)
+
+	admin := svc.GetInternalAdminPlan()
+	if !admin.CheckPropertiesLimit(1_000_000) || !admin.CheckOrgsLimit(1_000_000) || !admin.CheckOrgMembersLimit(1_000_000) {
+		t.Error("Admin plan should not have count limits")
+	}
+
+	trial := svc.GetInternalTrialPlan()
+	if limit := trial.PropertiesLimit(); (limit == 0) || trial.CheckPropertiesLimit(limit) || !trial.CheckPropertiesLimit(limit-1) {
+		t.Errorf("Unexpected trial properties limit enforcement (limit=%v)", limit)
+	}
+}
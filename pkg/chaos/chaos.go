@@ -0,0 +1,145 @@
+// Package chaos injects latency and errors into calls to dependencies (databases and downstream HTTP services), so
+// that graceful degradation (maintenance errors, stub puzzles, retries) can be exercised in end-to-end tests.
+// It is configured at runtime and only ever active in non-production stages.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	randv2 "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+const (
+	TargetPostgres   = "postgres"
+	TargetClickHouse = "clickhouse"
+	TargetHTTP       = "http"
+)
+
+var (
+	ErrInjected = errors.New("chaos: injected fault")
+	Targets     = []string{TargetPostgres, TargetClickHouse, TargetHTTP}
+	global      = &injector{}
+)
+
+type faults struct {
+	latency   time.Duration
+	errorRate float64
+	targets   map[string]struct{}
+}
+
+type injector struct {
+	faults atomic.Pointer[faults]
+}
+
+func isStageAllowed(stage string) bool {
+	return (stage == common.StageDev) || (stage == common.StageStaging) || (stage == common.StageTest)
+}
+
+func newFaults(cfg common.ConfigStore) *faults {
+	f := &faults{
+		latency:   config.AsDuration(cfg.Get(common.ChaosLatencyKey), 0),
+		errorRate: 0.0,
+		targets:   make(map[string]struct{}),
+	}
+
+	if rate, err := strconv.ParseFloat(cfg.Get(common.ChaosErrorRateKey).Value(), 64); err == nil {
+		f.errorRate = min(max(rate, 0.0), 1.0)
+	}
+
+	for _, target := range strings.Split(cfg.Get(common.ChaosTargetsKey).Value(), ",") {
+		if target = strings.ToLower(strings.TrimSpace(target)); len(target) > 0 {
+			f.targets[target] = struct{}{}
+		}
+	}
+
+	return f
+}
+
+func (f *faults) active() bool {
+	return (len(f.targets) > 0) && ((f.latency > 0) || (f.errorRate > 0.0))
+}
+
+func (i *injector) update(ctx context.Context, cfg common.ConfigStore) {
+	stage := cfg.Get(common.StageKey).Value()
+	f := newFaults(cfg)
+
+	if !f.active() || !isStageAllowed(stage) {
+		if f.active() {
+			slog.ErrorContext(ctx, "Fault injection is not allowed in this stage", "stage", stage)
+		}
+
+		if old := i.faults.Swap(nil); old != nil {
+			slog.InfoContext(ctx, "Fault injection was disabled")
+		}
+		return
+	}
+
+	i.faults.Store(f)
+
+	slog.WarnContext(ctx, "Fault injection is enabled", "latency", f.latency.String(), "errorRate", f.errorRate,
+		"targets", len(f.targets))
+}
+
+func (i *injector) inject(ctx context.Context, target string) error {
+	f := i.faults.Load()
+	if f == nil {
+		return nil
+	}
+
+	if _, ok := f.targets[target]; !ok {
+		return nil
+	}
+
+	if f.latency > 0 {
+		timer := time.NewTimer(f.latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if (f.errorRate > 0.0) && (randv2.Float64() < f.errorRate) {
+		slog.Log(ctx, common.LevelTrace, "Injecting fault", "target", target)
+		return ErrInjected
+	}
+
+	return nil
+}
+
+// Update (re)configures fault injection. It should be called on every config reload
+func Update(ctx context.Context, cfg common.ConfigStore) {
+	global.update(ctx, cfg)
+}
+
+// Inject delays the call to the target and returns ErrInjected if fault should be injected
+func Inject(ctx context.Context, target string) error {
+	return global.inject(ctx, target)
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := Inject(r.Context(), TargetHTTP); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(r)
+}
+
+// Transport injects faults into outgoing HTTP requests
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{next: next}
+}
@@ -0,0 +1,105 @@
+package chaos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+func testConfig(stage, latency, errorRate, targets string) common.ConfigStore {
+	cfg := config.NewBaseConfig(nil)
+	cfg.Add(config.NewStaticValue(common.StageKey, stage))
+	cfg.Add(config.NewStaticValue(common.ChaosLatencyKey, latency))
+	cfg.Add(config.NewStaticValue(common.ChaosErrorRateKey, errorRate))
+	cfg.Add(config.NewStaticValue(common.ChaosTargetsKey, targets))
+	return cfg
+}
+
+func TestInjectErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	i := &injector{}
+	i.update(ctx, testConfig(common.StageStaging, "", "1.0", "postgres, HTTP"))
+
+	if err := i.inject(ctx, TargetPostgres); err != ErrInjected {
+		t.Errorf("Expected injected error for postgres, got %v", err)
+	}
+
+	if err := i.inject(ctx, TargetHTTP); err != ErrInjected {
+		t.Errorf("Expected injected error for http, got %v", err)
+	}
+
+	if err := i.inject(ctx, TargetClickHouse); err != nil {
+		t.Errorf("Unexpected error for clickhouse: %v", err)
+	}
+
+	i.update(ctx, testConfig(common.StageStaging, "", "", ""))
+	if err := i.inject(ctx, TargetPostgres); err != nil {
+		t.Errorf("Unexpected error after disabling: %v", err)
+	}
+}
+
+func TestInjectNotInProduction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	i := &injector{}
+	i.update(ctx, testConfig("prod", "1h", "1.0", "postgres"))
+
+	if err := i.inject(ctx, TargetPostgres); err != nil {
+		t.Errorf("Unexpected error in production: %v", err)
+	}
+}
+
+func TestInjectLatency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	i := &injector{}
+	i.update(ctx, testConfig(common.StageDev, "50ms", "0", "clickhouse"))
+
+	start := time.Now()
+	if err := i.inject(ctx, TargetClickHouse); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Latency was not injected: %v", elapsed)
+	}
+
+	// latency is cut short by context
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := i.inject(ctx, TargetClickHouse); err != context.Canceled {
+		t.Errorf("Unexpected error with cancelled context: %v", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx := context.TODO()
+	Update(ctx, testConfig(common.StageTest, "", "1", "http"))
+	defer Update(ctx, testConfig(common.StageTest, "", "", ""))
+
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("Expected injected error")
+	}
+}
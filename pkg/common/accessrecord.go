@@ -8,6 +8,12 @@ type AccessRecord struct {
 	OrgID       int32
 	PropertyID  int32
 	Timestamp   time.Time
+	// request came from a known hosting network (ASN)
+	Datacenter bool
+	// property is in IP-less mode and fingerprint is random (per-request), so it cannot be used to count visitors
+	IPLess bool
+	// data region of the property, records are only written to the ClickHouse cluster of this region
+	Region string
 }
 
 type VerifyRecord struct {
@@ -17,4 +23,18 @@ type VerifyRecord struct {
 	PuzzleID   uint64
 	Timestamp  time.Time
 	Status     int8
+	// data region of the property (see AccessRecord)
+	Region string
+	// integrator-defined action the puzzle was requested with (can be empty)
+	Action string
+	// verification of the sandbox property (it counts towards usage the same way, but can be told apart)
+	Sandbox bool
+}
+
+// APIKeyRecord is a single request authenticated with the API key, used to detect anomalous usage of the key
+type APIKeyRecord struct {
+	APIKeyID  int32
+	Timestamp time.Time
+	// puzzle being verified belongs to someone else than the owner of the key
+	WrongOwner bool
 }
@@ -0,0 +1,31 @@
+package common
+
+import "context"
+
+type AlertSeverity string
+
+const (
+	AlertSeverityInfo     AlertSeverity = "info"
+	AlertSeverityWarning  AlertSeverity = "warning"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+// Alert is an event that operators of the installation should know about
+type Alert struct {
+	// alerts with the same key are deduplicated by the sink (e.g. repeating failures of the same job)
+	Key      string
+	Severity AlertSeverity
+	Title    string
+	Text     string
+}
+
+// Alerter delivers alerts to operators. SendAlert must not block, delivery errors are only logged
+type Alerter interface {
+	SendAlert(ctx context.Context, alert *Alert)
+}
+
+type StubAlerter struct{}
+
+var _ Alerter = (*StubAlerter)(nil)
+
+func (StubAlerter) SendAlert(ctx context.Context, alert *Alert) {}
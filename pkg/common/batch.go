@@ -6,13 +6,51 @@ import (
 	"time"
 )
 
-func ProcessBatchArray[T any](ctx context.Context, channel <-chan T, delay time.Duration, triggerSize, maxBatchSize int, processor func(context.Context, []T) error) {
+const (
+	BatchPipelineVerifyLog       = "verify-log"
+	BatchPipelineAccessLog       = "access-log"
+	BatchPipelineSitekeyBackfill = "sitekey-backfill"
+	BatchPipelineSessionPersist  = "session-persist"
+	BatchPipelineAPIKeyUsage     = "apikey-usage"
+)
+
+// batchObserver reports health of the batch pipeline (metrics can be nil)
+type batchObserver struct {
+	pipeline string
+	metrics  BatchMetrics
+}
+
+func (o *batchObserver) queue(queued, pending int) {
+	if o.metrics != nil {
+		o.metrics.ObserveBatchQueue(o.pipeline, queued, pending)
+	}
+}
+
+func (o *batchObserver) dropped(count int) {
+	if o.metrics != nil {
+		o.metrics.ObserveBatchDropped(o.pipeline, count)
+	}
+}
+
+func (o *batchObserver) flush(ctx context.Context, size int, process func(context.Context) error) error {
+	start := time.Now()
+	err := process(ctx)
+	if o.metrics != nil {
+		o.metrics.ObserveBatchFlush(o.pipeline, size, time.Since(start), err)
+	}
+	return err
+}
+
+func ProcessBatchArray[T any](ctx context.Context, pipeline string, metrics BatchMetrics, channel <-chan T, delay time.Duration, triggerSize, maxBatchSize int, processor func(context.Context, []T) error) {
 	var batch []T
-	slog.DebugContext(ctx, "Processing batch", "interval", delay.String())
+	observer := &batchObserver{pipeline: pipeline, metrics: metrics}
+	process := func(ctx context.Context) error { return processor(ctx, batch) }
+	slog.DebugContext(ctx, "Processing batch", "interval", delay.String(), "pipeline", pipeline)
 
 	for running := true; running; {
 		if len(batch) > maxBatchSize {
-			slog.ErrorContext(ctx, "Dropping pending batch due to errors", "count", len(batch))
+			slog.ErrorContext(ctx, "Dropping pending batch due to errors", "count", len(batch), "pipeline", pipeline)
+			observer.dropped(len(batch))
 			batch = []T{}
 		}
 
@@ -30,31 +68,36 @@ func ProcessBatchArray[T any](ctx context.Context, channel <-chan T, delay time.
 
 			if len(batch) >= triggerSize {
 				slog.Log(ctx, LevelTrace, "Processing batch", "count", len(batch), "reason", "batch")
-				if err := processor(ctx, batch); err == nil {
+				observer.queue(len(channel), len(batch))
+				if err := observer.flush(ctx, len(batch), process); err == nil {
 					batch = []T{}
 				}
 			}
 		case <-time.After(delay):
 			if len(batch) > 0 {
 				slog.Log(ctx, LevelTrace, "Processing batch", "count", len(batch), "reason", "timeout")
-				if err := processor(ctx, batch); err == nil {
+				if err := observer.flush(ctx, len(batch), process); err == nil {
 					batch = []T{}
 				}
 			}
+			observer.queue(len(channel), len(batch))
 		}
 	}
 
-	slog.InfoContext(ctx, "Finished processing batch")
+	slog.InfoContext(ctx, "Finished processing batch", "pipeline", pipeline)
 }
 
 // as they say, a little copy-paste is better than a little dependency
-func ProcessBatchMap[T comparable](ctx context.Context, channel <-chan T, delay time.Duration, triggerSize, maxBatchSize int, processor func(context.Context, map[T]struct{}) error) {
+func ProcessBatchMap[T comparable](ctx context.Context, pipeline string, metrics BatchMetrics, channel <-chan T, delay time.Duration, triggerSize, maxBatchSize int, processor func(context.Context, map[T]struct{}) error) {
 	batch := make(map[T]struct{})
-	slog.DebugContext(ctx, "Processing batch", "interval", delay.String())
+	observer := &batchObserver{pipeline: pipeline, metrics: metrics}
+	process := func(ctx context.Context) error { return processor(ctx, batch) }
+	slog.DebugContext(ctx, "Processing batch", "interval", delay.String(), "pipeline", pipeline)
 
 	for running := true; running; {
 		if len(batch) > maxBatchSize {
-			slog.ErrorContext(ctx, "Dropping pending batch due to errors", "count", len(batch))
+			slog.ErrorContext(ctx, "Dropping pending batch due to errors", "count", len(batch), "pipeline", pipeline)
+			observer.dropped(len(batch))
 			batch = make(map[T]struct{})
 		}
 
@@ -72,19 +115,21 @@ func ProcessBatchMap[T comparable](ctx context.Context, channel <-chan T, delay
 
 			if len(batch) >= triggerSize {
 				slog.Log(ctx, LevelTrace, "Processing batch", "count", len(batch), "reason", "batch")
-				if err := processor(ctx, batch); err == nil {
+				observer.queue(len(channel), len(batch))
+				if err := observer.flush(ctx, len(batch), process); err == nil {
 					batch = make(map[T]struct{})
 				}
 			}
 		case <-time.After(delay):
 			if len(batch) > 0 {
 				slog.Log(ctx, LevelTrace, "Processing batch", "count", len(batch), "reason", "timeout")
-				if err := processor(ctx, batch); err == nil {
+				if err := observer.flush(ctx, len(batch), process); err == nil {
 					batch = make(map[T]struct{})
 				}
 			}
+			observer.queue(len(channel), len(batch))
 		}
 	}
 
-	slog.InfoContext(ctx, "Finished processing batch")
+	slog.InfoContext(ctx, "Finished processing batch", "pipeline", pipeline)
 }
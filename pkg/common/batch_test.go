@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchMetricsStub struct {
+	lock    sync.Mutex
+	flushes int
+	errors  int
+	items   int
+	dropped int
+}
+
+func (m *batchMetricsStub) ObserveBatchQueue(pipeline string, queued, pending int) {}
+
+func (m *batchMetricsStub) ObserveBatchFlush(pipeline string, size int, duration time.Duration, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.flushes++
+	if err != nil {
+		m.errors++
+	} else {
+		m.items += size
+	}
+}
+
+func (m *batchMetricsStub) ObserveBatchDropped(pipeline string, count int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.dropped += count
+}
+
+func TestProcessBatchMetrics(t *testing.T) {
+	t.Parallel()
+
+	const (
+		triggerSize  = 2
+		maxBatchSize = 3
+	)
+
+	metrics := &batchMetricsStub{}
+	channel := make(chan int)
+	done := make(chan struct{})
+	calls := 0
+
+	go func() {
+		ProcessBatchArray(context.TODO(), "test", metrics, channel, time.Hour, triggerSize, maxBatchSize, func(ctx context.Context, batch []int) error {
+			calls++
+			// batches of 2, 3 and 4 items fail, after which pending items are dropped
+			if calls <= 3 {
+				return errors.New("test")
+			}
+			return nil
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 6; i++ {
+		channel <- i
+	}
+
+	close(channel)
+	<-done
+
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+
+	if (metrics.errors != 3) || (metrics.dropped != 4) || (metrics.items != 2) || (metrics.flushes != 4) {
+		t.Errorf("Unexpected metrics: %+v", metrics)
+	}
+}
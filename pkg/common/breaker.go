@@ -0,0 +1,120 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+type CircuitBreakerState int
+
+const (
+	CircuitBreakerClosed CircuitBreakerState = iota
+	CircuitBreakerOpen
+	CircuitBreakerHalfOpen
+)
+
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case CircuitBreakerClosed:
+		return "closed"
+	case CircuitBreakerOpen:
+		return "open"
+	case CircuitBreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker opens after {threshold} consecutive failures and, after {cooldown}, lets a single
+// probe through (half-open state). Successful probe closes the breaker, failed one opens it again.
+type CircuitBreaker struct {
+	lock      sync.Mutex
+	name      string
+	state     CircuitBreakerState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	changedAt time.Time
+	onChange  func(name string, state CircuitBreakerState)
+}
+
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration, onChange func(string, CircuitBreakerState)) *CircuitBreaker {
+	if onChange == nil {
+		onChange = func(string, CircuitBreakerState) {}
+	}
+
+	return &CircuitBreaker{
+		name:      name,
+		state:     CircuitBreakerClosed,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		onChange:  onChange,
+	}
+}
+
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	return cb.state
+}
+
+// setState should be called under the lock
+func (cb *CircuitBreaker) setState(state CircuitBreakerState, tnow time.Time) {
+	cb.changedAt = tnow
+
+	if cb.state != state {
+		cb.state = state
+		cb.onChange(cb.name, state)
+	}
+}
+
+// Allow returns true if the call to the protected resource can be made
+func (cb *CircuitBreaker) Allow(tnow time.Time) bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	switch cb.state {
+	case CircuitBreakerClosed:
+		return true
+	default:
+		// NOTE: in half-open state we also allow a new probe after cooldown in case previous one was never reported
+		if tnow.Sub(cb.changedAt) >= cb.cooldown {
+			cb.setState(CircuitBreakerHalfOpen, tnow)
+			return true
+		}
+
+		return false
+	}
+}
+
+func (cb *CircuitBreaker) Success(tnow time.Time) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	cb.failures = 0
+	if cb.state != CircuitBreakerClosed {
+		cb.setState(CircuitBreakerClosed, tnow)
+	}
+}
+
+func (cb *CircuitBreaker) Failure(tnow time.Time) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	cb.failures++
+
+	switch cb.state {
+	case CircuitBreakerClosed:
+		if cb.failures >= cb.threshold {
+			cb.setState(CircuitBreakerOpen, tnow)
+		}
+	case CircuitBreakerHalfOpen:
+		cb.setState(CircuitBreakerOpen, tnow)
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 10 * time.Second
+	changes := 0
+	cb := NewCircuitBreaker("test", 2 /*threshold*/, cooldown, func(string, CircuitBreakerState) { changes++ })
+	tnow := time.Now()
+
+	if !cb.Allow(tnow) {
+		t.Fatal("Closed breaker does not allow")
+	}
+
+	cb.Failure(tnow)
+	if cb.State() != CircuitBreakerClosed {
+		t.Errorf("Breaker opened before threshold")
+	}
+
+	cb.Failure(tnow)
+	if cb.State() != CircuitBreakerOpen {
+		t.Errorf("Breaker is not open after threshold")
+	}
+
+	if cb.Allow(tnow.Add(cooldown / 2)) {
+		t.Errorf("Open breaker allows before cooldown")
+	}
+
+	if !cb.Allow(tnow.Add(cooldown)) || (cb.State() != CircuitBreakerHalfOpen) {
+		t.Errorf("Breaker does not allow a probe after cooldown")
+	}
+
+	if cb.Allow(tnow.Add(cooldown)) {
+		t.Errorf("Half-open breaker allows more than one probe")
+	}
+
+	cb.Failure(tnow.Add(cooldown))
+	if cb.State() != CircuitBreakerOpen {
+		t.Errorf("Breaker is not open after failed probe")
+	}
+
+	if !cb.Allow(tnow.Add(2 * cooldown)) {
+		t.Errorf("Breaker does not allow a probe after second cooldown")
+	}
+
+	cb.Success(tnow.Add(2 * cooldown))
+	if cb.State() != CircuitBreakerClosed {
+		t.Errorf("Breaker is not closed after successful probe")
+	}
+
+	// closed -> open -> half-open -> open -> half-open -> closed
+	if changes != 5 {
+		t.Errorf("Unexpected number of state changes: %v", changes)
+	}
+}
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertificateReloader serves TLS certificate loaded from disk and reloads it when files change, so that renewed
+// certificates (e.g. by Let's Encrypt) are picked up without restarting the server and dropping connections
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+	lock     sync.Mutex
+	// latest modification time of cert and key files at the moment of the last load
+	modTime time.Time
+}
+
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	cr := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	modTime, err := cr.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cr.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+func (cr *CertificateReloader) filesModTime() (time.Time, error) {
+	var result time.Time
+
+	for _, path := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(result) {
+			result = info.ModTime()
+		}
+	}
+
+	return result, nil
+}
+
+func (cr *CertificateReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cr.cert.Store(&cert)
+	cr.modTime = modTime
+
+	return nil
+}
+
+// GetCertificate is meant to be used as tls.Config.GetCertificate
+func (cr *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cr.cert.Load(), nil
+}
+
+// Reload loads certificate again if any of the files was modified since the last load. Previous certificate
+// keeps being served if new files cannot be loaded (e.g. when cert is already replaced, but key is not yet)
+func (cr *CertificateReloader) Reload(ctx context.Context) error {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	modTime, err := cr.filesModTime()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check certificate files", "cert", cr.certFile, "key", cr.keyFile, ErrAttr(err))
+		return err
+	}
+
+	if modTime.Equal(cr.modTime) {
+		slog.Log(ctx, LevelTrace, "Certificate files did not change", "cert", cr.certFile)
+		return nil
+	}
+
+	if err := cr.load(modTime); err != nil {
+		slog.ErrorContext(ctx, "Failed to reload certificates", "cert", cr.certFile, "key", cr.keyFile, ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Reloaded certificates", "cert", cr.certFile, "modTime", modTime)
+
+	return nil
+}
+
+// Run checks certificate files for changes with given interval until context is cancelled
+func (cr *CertificateReloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = cr.Reload(ctx)
+		}
+	}
+}
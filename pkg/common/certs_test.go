@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certFile, keyFile, name string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{certFile, keyFile} {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func certificateName(t *testing.T, cr *CertificateReloader) string {
+	cert, err := cr.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	tnow := time.Now()
+	ctx := context.TODO()
+
+	writeTestCertificate(t, certFile, keyFile, "first", tnow.Add(-time.Hour))
+
+	cr, err := NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := certificateName(t, cr); name != "first" {
+		t.Errorf("Unexpected certificate: %v", name)
+	}
+
+	if err := cr.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestCertificate(t, certFile, keyFile, "second", tnow)
+
+	if err := cr.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := certificateName(t, cr); name != "second" {
+		t.Errorf("Unexpected certificate after reload: %v", name)
+	}
+
+	// broken key should not replace working certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(keyFile, tnow.Add(time.Hour), tnow.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cr.Reload(ctx); err == nil {
+		t.Error("Expected error for broken key")
+	}
+
+	if name := certificateName(t, cr); name != "second" {
+		t.Errorf("Unexpected certificate after failed reload: %v", name)
+	}
+}
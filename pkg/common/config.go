@@ -39,6 +39,80 @@ const (
 	PortKey
 	UserFingerprintIVKey
 	APISaltKey
+	ClamAVAddressKey
+	SupportEmailKey
+	MetricsExportURLKey
+	MetricsExportFormatKey
+	MetricsExportUserKey
+	MetricsExportPasswordKey
+	MetricsExportTokenKey
+	ClickHouseSecondaryHostKey
+	ClickHouseSecondaryDBKey
+	ClickHouseSecondaryUserKey
+	ClickHouseSecondaryPasswordKey
+	KafkaRESTURLKey
+	KafkaTopicKey
+	CountryHeaderKey
+	KMSProviderKey
+	KMSKeyIDKey
+	KMSEndpointKey
+	KMSTokenKey
+	KMSRotationPeriodKey
+	LicenseFileKey
+	LicenseReportURLKey
+	APIListenAddressKey
+	APITLSCertFileKey
+	APITLSKeyFileKey
+	PortalListenAddressKey
+	PortalTLSCertFileKey
+	PortalTLSKeyFileKey
+	CDNListenAddressKey
+	CDNTLSCertFileKey
+	CDNTLSKeyFileKey
+	SlowQueryThresholdKey
+	VerifyReceiptKey
+	CacheInvalidationKey
+	PuzzlePoolSizeKey
+	APIPuzzleTimeoutKey
+	APIVerifyTimeoutKey
+	APIFallbackTimeoutKey
+	APIVerifyMaxBytesKey
+	PortalTimeoutKey
+	PortalPublicTimeoutKey
+	PortalMaxBytesKey
+	LocalAuthTokenKey
+	LocalAllowedIPsKey
+	ProvisioningAPIKeyKey
+	AlertWebhookURLKey
+	WarehouseExportURLKey
+	WarehouseExportFormatKey
+	WarehouseExportRegionKey
+	WarehouseExportAccessKeyKey
+	WarehouseExportSecretKeyKey
+	MaintenanceSubsystemsKey
+	ChaosLatencyKey
+	ChaosErrorRateKey
+	ChaosTargetsKey
+	APIBatchMaxBytesKey
+	ClickHouseRegionsKey
+	SupportInboundKeyKey
+	VerifyLogOverflowKey
+	VerifyLogSpillDirKey
+	EmailQueuePersistKey
+	VerifiedDomainsKey
+	APIHTTP2Key
+	APIMaxStreamsKey
+	APIKeepAliveKey
+	APIIdleTimeoutKey
+	PortalHTTP2Key
+	PortalMaxStreamsKey
+	PortalKeepAliveKey
+	PortalIdleTimeoutKey
+	MobileLeakyBucketRateKey
+	MobileLeakyBucketBurstKey
+	PortalMaxSessionsKey
+	TrustedProxiesKey
+	VerifyReceiptPreviousKeysKey
 	// Add new fields _above_
 	COMMON_CONFIG_KEYS_COUNT
 )
@@ -1,6 +1,9 @@
 package common
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 const (
 	DefaultOrgName        = "My Organization"
@@ -35,18 +38,77 @@ const (
 	ParamAllowSubdomains  = "allow_subdomains"
 	ParamAllowLocalhost   = "allow_localhost"
 	ParamAllowReplay      = "allow_replay"
+	ParamMemoryHard       = "memory_hard"
+	ParamPrivacyMode      = "privacy_mode"
+	ParamIplessMode       = "ipless_mode"
+	ParamAccessibleMode   = "accessible_mode"
+	ParamTestMode         = "test_mode"
+	ParamWidgetChannel    = "widget_channel"
+	ParamTestOutcome      = "test_outcome"
+	ParamAllowedOrigins   = "allowed_origins"
+	ParamAllowedApps      = "allowed_apps"
+	ParamTrustedThreshold = "trusted_threshold"
+	ParamTrustedTTL       = "trusted_ttl"
 	ParamIgnoreError      = "ignore_error"
+	ParamQuery            = "q"
+	ParamToken            = "token"
+	ParamTag              = "tag"
+	ParamTags             = "tags"
+	ParamReverifyLogins   = "reverify_logins"
+	ParamTimezone         = "timezone"
+	ParamBlockedMessage   = "blocked_message"
+	ParamAction           = "action"
+	ParamQuotaMessage     = "quota_message"
+	ParamMaintenanceMsg   = "maintenance_message"
+	ParamRedirectURL      = "redirect_url"
+	ParamRotation         = "rotation"
+	ParamBudget           = "budget"
+	ParamEmailID          = "email_id"
+	ParamPaused           = "paused"
+	ParamTheme            = "theme"
+	ParamLocale           = "locale"
+	ParamAccess           = "access"
+	ParamPercent          = "percent"
+	ParamQuotaPeriod      = "quota_period"
+	ParamUITheme          = "ui_theme"
+	ParamTableDensity     = "table_density"
+	ParamDefaultOrg       = "default_org"
+)
+
+const (
+	// for how long the previous account email can revert the email change
+	EmailChangeRevertTimeout = 48 * time.Hour
+	// for how long the confirmation link, sent to the secondary email, is valid
+	EmailVerificationTimeout = 48 * time.Hour
+	// for how long the sign-in link, sent instead of the verification code, is valid
+	MagicLinkTimeout = 15 * time.Minute
+	// for how long the previous API key stays valid after automatic rotation
+	APIKeyRotationOverlap = 7 * 24 * time.Hour
+	// for how long the previous sitekey keeps resolving to the property after rotation
+	SitekeyRotationOverlap = 7 * 24 * time.Hour
+	// for how long expired API keys are still listed before they are archived
+	APIKeyArchiveAfter = 30 * 24 * time.Hour
+	// for how long the shareable read-only link to property reports is valid
+	SharedReportTimeout = 7 * 24 * time.Hour
 )
 
 var (
 	HeaderCDNTag              = http.CanonicalHeaderKey("CDN-Tag")
 	HeaderContentType         = http.CanonicalHeaderKey("Content-Type")
 	HeaderContentLength       = http.CanonicalHeaderKey("Content-Length")
+	HeaderContentEncoding     = http.CanonicalHeaderKey("Content-Encoding")
+	HeaderAcceptEncoding      = http.CanonicalHeaderKey("Accept-Encoding")
+	HeaderVary                = http.CanonicalHeaderKey("Vary")
+	HeaderETag                = http.CanonicalHeaderKey("ETag")
+	HeaderIfNoneMatch         = http.CanonicalHeaderKey("If-None-Match")
 	HeaderAuthorization       = http.CanonicalHeaderKey("Authorization")
 	HeaderCSRFToken           = http.CanonicalHeaderKey("X-CSRF-Token")
 	HeaderCaptchaVersion      = http.CanonicalHeaderKey("X-PC-Captcha-Version")
 	HeaderCaptchaCompat       = http.CanonicalHeaderKey("X-Captcha-Compat-Version")
 	HeaderAPIKey              = http.CanonicalHeaderKey("X-API-Key")
+	HeaderVerifyReceipt       = http.CanonicalHeaderKey("X-PC-Verify-Receipt")
+	HeaderAppID               = http.CanonicalHeaderKey("X-PC-App-ID")
+	HeaderAppAttestation      = http.CanonicalHeaderKey("X-PC-App-Attestation")
 	HeaderAccessControlOrigin = http.CanonicalHeaderKey("Access-Control-Allow-Origin")
 	HeaderAccessControlAge    = http.CanonicalHeaderKey("Access-Control-Max-Age")
 )
@@ -12,4 +12,10 @@ const (
 	RateLimitKeyContextKey ContextKey = iota
 	SessionIDContextKey    ContextKey = iota
 	TimeContextKey         ContextKey = iota
+	UserIDContextKey       ContextKey = iota
+	QueryContextKey        ContextKey = iota
+	TestOutcomeContextKey  ContextKey = iota
+	APIKeyOwnerContextKey  ContextKey = iota
+	APIKeyOrgContextKey    ContextKey = iota
+	CSPNonceContextKey     ContextKey = iota
 )
@@ -1,34 +1,74 @@
 package common
 
 const (
-	PuzzleEndpoint       = "puzzle"
-	EchoPuzzleEndpoint   = "echopuzzle"
-	VerifyEndpoint       = "siteverify"
-	LoginEndpoint        = "login"
-	TwoFactorEndpoint    = "2fa"
-	ResendEndpoint       = "resend"
-	ErrorEndpoint        = "error"
-	RegisterEndpoint     = "signup"
-	ExpiredEndpoint      = "expired"
-	SettingsEndpoint     = "settings"
-	LogoutEndpoint       = "logout"
-	PropertyEndpoint     = "property"
-	OrgEndpoint          = "org"
-	DashboardEndpoint    = "dashboard"
-	NewEndpoint          = "new"
-	StatsEndpoint        = "stats"
-	TabEndpoint          = "tab"
-	ReportsEndpoint      = "reports"
-	IntegrationsEndpoint = "integrations"
-	EditEndpoint         = "edit"
-	DeleteEndpoint       = "delete"
-	MembersEndpoint      = "members"
-	GeneralEndpoint      = "general"
-	EmailEndpoint        = "email"
-	UserEndpoint         = "user"
-	APIKeysEndpoint      = "apikeys"
-	UsageEndpoint        = "usage"
-	ReadyEndpoint        = "ready"
-	LiveEndpoint         = "live"
-	NotificationEndpoint = "notification"
+	PuzzleEndpoint        = "puzzle"
+	EchoPuzzleEndpoint    = "echopuzzle"
+	FallbackEndpoint      = "fallback"
+	AccessibleEndpoint    = "accessible"
+	StatusEndpoint        = "status"
+	VerifyEndpoint        = "siteverify"
+	BatchEndpoint         = "batch"
+	LoginEndpoint         = "login"
+	TwoFactorEndpoint     = "2fa"
+	ResendEndpoint        = "resend"
+	MagicLinkEndpoint     = "link"
+	ErrorEndpoint         = "error"
+	RegisterEndpoint      = "signup"
+	ExpiredEndpoint       = "expired"
+	SettingsEndpoint      = "settings"
+	LogoutEndpoint        = "logout"
+	PropertyEndpoint      = "property"
+	OrgEndpoint           = "org"
+	DashboardEndpoint     = "dashboard"
+	NewEndpoint           = "new"
+	StatsEndpoint         = "stats"
+	FailuresEndpoint      = "failures"
+	FingerprintsEndpoint  = "fingerprints"
+	ActionsEndpoint       = "actions"
+	TabEndpoint           = "tab"
+	ReportsEndpoint       = "reports"
+	IntegrationsEndpoint  = "integrations"
+	EditEndpoint          = "edit"
+	DeleteEndpoint        = "delete"
+	MembersEndpoint       = "members"
+	GeneralEndpoint       = "general"
+	EmailEndpoint         = "email"
+	UserEndpoint          = "user"
+	APIKeysEndpoint       = "apikeys"
+	UsageEndpoint         = "usage"
+	ReadyEndpoint         = "ready"
+	LiveEndpoint          = "live"
+	HealthEndpoint        = "health"
+	NotificationEndpoint  = "notification"
+	NotificationsEndpoint = "notifications"
+	ReadEndpoint          = "read"
+	CountEndpoint         = "count"
+	SearchEndpoint        = "search"
+	RevertEndpoint        = "revert"
+	SecurityEndpoint      = "security"
+	APIEndpoint           = "api"
+	V1Endpoint            = "v1"
+	MessagesEndpoint      = "messages"
+	BudgetEndpoint        = "budget"
+	LicenseEndpoint       = "license"
+	WellKnownEndpoint     = ".well-known"
+	JWKSEndpoint          = "jwks.json"
+	ConfirmEndpoint       = "confirm"
+	EmailsEndpoint        = "emails"
+	BillingEndpoint       = "billing"
+	SessionsEndpoint      = "sessions"
+	ProvisionEndpoint     = "provision"
+	DiagnosticsEndpoint   = "diagnostics"
+	SupportEndpoint       = "support"
+	InboundEndpoint       = "inbound"
+	PauseEndpoint         = "pause"
+	ConfigEndpoint        = "config"
+	SitekeyEndpoint       = "sitekey"
+	ShareEndpoint         = "share"
+	QuotaEndpoint         = "quota"
+	DomainEndpoint        = "domain"
+	ResumeEndpoint        = "resume"
+	OnboardingEndpoint    = "onboarding"
+	DebugEndpoint         = "debug"
+	ParseEndpoint         = "parse"
 )
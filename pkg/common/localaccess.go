@@ -0,0 +1,127 @@
+package common
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+type localAccessRules struct {
+	token     []byte
+	allowlist []netip.Prefix
+}
+
+// LocalAccess protects internal endpoints (metrics, profiling, jobs) served on the local address with optional
+// bearer token and source IP allowlist. When neither is configured, everything is allowed.
+// Rules can be updated at runtime (on config reload)
+type LocalAccess struct {
+	rules atomic.Pointer[localAccessRules]
+}
+
+// ParseIPAllowlist parses comma-separated list of IP addresses and CIDR ranges. Empty value results in nil
+func ParseIPAllowlist(value string) ([]netip.Prefix, error) {
+	var result []netip.Prefix
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or range %q: %w", item, err)
+		}
+		addr = addr.Unmap()
+		result = append(result, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return result, nil
+}
+
+// Update replaces access rules. Empty token disables token check, nil allowlist allows any address while
+// empty (non-nil) one denies all of them
+func (la *LocalAccess) Update(token string, allowlist []netip.Prefix) {
+	la.rules.Store(&localAccessRules{
+		token:     []byte(token),
+		allowlist: allowlist,
+	})
+}
+
+func (r *localAccessRules) ipAllowed(remoteAddr string) bool {
+	if r.allowlist == nil {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range r.allowlist {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *localAccessRules) tokenValid(header string) bool {
+	if len(r.token) == 0 {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), r.token) == 1
+}
+
+func (la *LocalAccess) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := la.rules.Load()
+		if rules == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// local address is not supposed to be behind a proxy so we only trust the socket address
+		if !rules.ipAllowed(r.RemoteAddr) {
+			slog.WarnContext(r.Context(), "Local endpoint access from not allowed address", "address", r.RemoteAddr, "path", r.URL.Path)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if !rules.tokenValid(r.Header.Get(HeaderAuthorization)) {
+			slog.WarnContext(r.Context(), "Local endpoint access without valid token", "address", r.RemoteAddr, "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestParseIPAllowlist(t *testing.T) {
+	if allowlist, err := ParseIPAllowlist(" "); (err != nil) || (allowlist != nil) {
+		t.Errorf("Unexpected empty allowlist: %v (%v)", allowlist, err)
+	}
+
+	allowlist, err := ParseIPAllowlist("10.0.0.0/8, 192.168.1.1,::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(allowlist) != 3 {
+		t.Errorf("Unexpected allowlist length: %v", len(allowlist))
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "localhost", "10.0.0"} {
+		if _, err := ParseIPAllowlist(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestLocalAccess(t *testing.T) {
+	access := &LocalAccess{}
+	handler := access.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = remoteAddr
+		if len(authorization) > 0 {
+			req.Header.Set(HeaderAuthorization, authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("1.2.3.4:5678", ""); code != http.StatusOK {
+		t.Errorf("Unexpected status without rules: %v", code)
+	}
+
+	allowlist, _ := ParseIPAllowlist("10.0.0.0/8")
+	access.Update("secret", allowlist)
+
+	testCases := []struct {
+		remoteAddr    string
+		authorization string
+		expected      int
+	}{
+		{"10.1.2.3:5678", "Bearer secret", http.StatusOK},
+		{"10.1.2.3:5678", "Bearer wrong", http.StatusUnauthorized},
+		{"10.1.2.3:5678", "", http.StatusUnauthorized},
+		{"11.1.2.3:5678", "Bearer secret", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		if code := send(tc.remoteAddr, tc.authorization); code != tc.expected {
+			t.Errorf("Unexpected status for %v (%q): expected %v, got %v", tc.remoteAddr, tc.authorization, tc.expected, code)
+		}
+	}
+
+	// invalid allowlist in config denies everything
+	access.Update("", []netip.Prefix{})
+	if code := send("10.1.2.3:5678", ""); code != http.StatusForbidden {
+		t.Errorf("Unexpected status with empty allowlist: %v", code)
+	}
+}
@@ -2,9 +2,65 @@ package common
 
 import (
 	"context"
+	"time"
 )
 
+// SignInInfo describes where the portal sign-in came from
+type SignInInfo struct {
+	Device    string
+	Country   string
+	IPAddress string
+	Timestamp time.Time
+}
+
+// OrgDigest summarizes activity of the organization during the week
+type OrgDigest struct {
+	OrgName        string
+	WeekStart      time.Time
+	NewProperties  int64
+	InvitedMembers int64
+	JoinedMembers  int64
+	Requests       uint64
+	// requests during the week before, to show the trend
+	PreviousRequests uint64
+	VerifySuccess    uint64
+	VerifyFailure    uint64
+	FailureSpikes    []*FailureSpike
+}
+
+// FailureSpike is a day when verification failures were notably higher than usual
+type FailureSpike struct {
+	Day      time.Time
+	Failures uint64
+}
+
+// RequestsTrend returns change of requests compared to the week before in percent (0 without previous requests)
+func (d *OrgDigest) RequestsTrend() int {
+	if d.PreviousRequests == 0 {
+		return 0
+	}
+
+	return int((float64(d.Requests) - float64(d.PreviousRequests)) * 100.0 / float64(d.PreviousRequests))
+}
+
+// IsEmpty is true when there's nothing to tell about the organization
+func (d *OrgDigest) IsEmpty() bool {
+	return (d.NewProperties == 0) && (d.InvitedMembers == 0) && (d.JoinedMembers == 0) && (d.Requests == 0) &&
+		(d.PreviousRequests == 0)
+}
+
 type Mailer interface {
 	SendTwoFactor(ctx context.Context, email string, code int) error
+	SendMagicLink(ctx context.Context, email, loginPath string) error
 	SendWelcome(ctx context.Context, email string) error
+	SendEmailChanged(ctx context.Context, oldEmail, newEmail, revertPath string) error
+	SendNewSignIn(ctx context.Context, email string, info *SignInInfo, settingsPath string) error
+	SendAPIKeyRotated(ctx context.Context, email, keyName string, retireAt time.Time, settingsPath string) error
+	SendAPIKeyExpiring(ctx context.Context, email, keyName string, expiresAt time.Time, days int, settingsPath string) error
+	SendAPIKeySuspended(ctx context.Context, email, keyName, reason string, settingsPath string) error
+	SendAccountLocked(ctx context.Context, email string, lockedUntil time.Time) error
+	SendBudgetAlert(ctx context.Context, email, orgName string, percent int, usage, budget int64, settingsPath string) error
+	SendOrgDeleted(ctx context.Context, email, orgName string) error
+	SendEmailVerification(ctx context.Context, email, confirmPath string) error
+	SendOrgDigest(ctx context.Context, email string, digest *OrgDigest, orgPath string) error
 }
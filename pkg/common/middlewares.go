@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"runtime/debug"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/xsrftoken"
@@ -14,6 +15,7 @@ import (
 
 const (
 	headerHtmxRedirect = "HX-Redirect"
+	HeaderHtmxRefresh  = "HX-Refresh"
 	maxHeaderLen       = 100
 )
 
@@ -31,10 +33,6 @@ var (
 	CachedHeaders = map[string][]string{
 		http.CanonicalHeaderKey("Cache-Control"): []string{"public, max-age=86400"},
 	}
-	SecurityHeaders = map[string][]string{
-		http.CanonicalHeaderKey("X-Frame-Options"):        []string{"DENY"},
-		http.CanonicalHeaderKey("X-Content-Type-Options"): []string{"nosniff"},
-	}
 	HtmlContentHeaders = map[string][]string{
 		http.CanonicalHeaderKey(HeaderContentType): []string{ContentTypeHTML},
 	}
@@ -64,9 +62,37 @@ func Recovered(next http.Handler) http.Handler {
 	})
 }
 
+// RouteLimit is a timeout or a body size limit of a route that can be changed on config reload.
+// Zero value means that the default limit (passed to the middleware) is used
+type RouteLimit struct {
+	value atomic.Int64
+}
+
+func (l *RouteLimit) Store(value int64) {
+	l.value.Store(value)
+}
+
+func (l *RouteLimit) Load(fallback int64) int64 {
+	if value := l.value.Load(); value > 0 {
+		return value
+	}
+
+	return fallback
+}
+
 func TimeoutHandler(timeout time.Duration) func(next http.Handler) http.Handler {
+	return ConfiguredTimeoutHandler(nil, timeout)
+}
+
+// ConfiguredTimeoutHandler is the same as TimeoutHandler, but timeout is read from the limit on every request
+func ConfiguredTimeoutHandler(limit *RouteLimit, fallback time.Duration) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		h := func(w http.ResponseWriter, r *http.Request) {
+			timeout := fallback
+			if limit != nil {
+				timeout = time.Duration(limit.Load(int64(fallback)))
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer func() {
 				cancel()
@@ -82,6 +108,17 @@ func TimeoutHandler(timeout time.Duration) func(next http.Handler) http.Handler
 	}
 }
 
+// ConfiguredMaxBytesHandler is the same as http.MaxBytesHandler, but the limit is read on every request
+func ConfiguredMaxBytesHandler(limit *RouteLimit, fallback int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r2 := *r
+			r2.Body = http.MaxBytesReader(w, r.Body, limit.Load(fallback))
+			next.ServeHTTP(w, &r2)
+		})
+	}
+}
+
 func WriteHeaders(w http.ResponseWriter, headers map[string][]string) {
 	wHeader := w.Header()
 	for k, v := range headers {
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteLimitFallback(t *testing.T) {
+	var limit RouteLimit
+
+	if v := limit.Load(10); v != 10 {
+		t.Errorf("Unexpected default limit: %v", v)
+	}
+
+	limit.Store(20)
+	if v := limit.Load(10); v != 20 {
+		t.Errorf("Unexpected stored limit: %v", v)
+	}
+
+	limit.Store(0)
+	if v := limit.Load(10); v != 10 {
+		t.Errorf("Unexpected reset limit: %v", v)
+	}
+}
+
+func TestConfiguredMaxBytesHandler(t *testing.T) {
+	var limit RouteLimit
+
+	handler := ConfiguredMaxBytesHandler(&limit, 1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", 100)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(); code != http.StatusOK {
+		t.Errorf("Unexpected status code with default limit: %v", code)
+	}
+
+	// limit is changed without recreating the handler (as on config reload)
+	limit.Store(10)
+	if code := send(); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Unexpected status code with reloaded limit: %v", code)
+	}
+}
+
+func TestConfiguredTimeoutHandler(t *testing.T) {
+	var limit RouteLimit
+	limit.Store(int64(1 * time.Millisecond))
+
+	var deadline time.Time
+	handler := ConfiguredTimeoutHandler(&limit, 1*time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if time.Until(deadline) > 1*time.Minute {
+		t.Errorf("Configured timeout was not used: %v", deadline)
+	}
+}
@@ -0,0 +1,315 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	QueueEmail  = "email"
+	QueueAlerts = "alerts"
+
+	JobResultSuccess = "success"
+	JobResultRetry   = "retry"
+	JobResultFailed  = "failed"
+	JobResultDropped = "dropped"
+
+	defaultQueuePollInterval = 10 * time.Second
+	defaultQueueBatchSize    = 20
+	defaultQueueMaxPending   = 1_000
+	// persisted job is not picked up by another worker while it's being processed
+	queueJobLease   = 5 * time.Minute
+	queueJobTimeout = 1 * time.Minute
+)
+
+var (
+	ErrQueueFull = errors.New("job queue is full")
+)
+
+// QueuedJob is the unit of work in the JobQueue. Payload is opaque for the queue and is interpreted by the handler
+type QueuedJob struct {
+	// ID is only set for persisted jobs
+	ID       int32
+	Payload  []byte
+	Attempts int32
+	RunAt    time.Time
+}
+
+type JobHandler func(ctx context.Context, payload []byte) error
+
+// JobStore persists queued jobs so that they survive restarts and can be processed by any of the instances
+type JobStore interface {
+	CreateQueueJob(ctx context.Context, queue string, payload []byte, runAt time.Time) error
+	// ClaimQueueJobs returns due jobs, locking them for the processing for the duration of the lease
+	ClaimQueueJobs(ctx context.Context, queue string, limit int, lease time.Duration) ([]*QueuedJob, error)
+	CompleteQueueJob(ctx context.Context, id int32) error
+	RetryQueueJob(ctx context.Context, id int32, reason string, runAt time.Time) error
+	FailQueueJob(ctx context.Context, id int32, reason string) error
+}
+
+// RetryPolicy defines exponential backoff between attempts of the failed job
+type RetryPolicy struct {
+	MaxAttempts int32
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Backoff returns delay before the next attempt, after the job failed the given number of times
+func (p RetryPolicy) Backoff(attempts int32) time.Duration {
+	backoff := p.BaseBackoff
+	for i := int32(1); (i < attempts) && (backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, p.MaxBackoff)
+}
+
+// JobQueue executes jobs in the background with retries. Jobs are kept in memory unless JobStore is set, in which
+// case they are persisted in the database and the queue only polls for them
+type JobQueue struct {
+	name         string
+	handler      JobHandler
+	retry        RetryPolicy
+	store        JobStore
+	metrics      QueueMetrics
+	PollInterval time.Duration
+	BatchSize    int
+	MaxPending   int
+	lock         sync.Mutex
+	pending      []*QueuedJob
+	signal       chan struct{}
+	cancel       context.CancelFunc
+	done         chan struct{}
+}
+
+func NewJobQueue(name string, handler JobHandler, retry RetryPolicy, store JobStore, metrics QueueMetrics) *JobQueue {
+	return &JobQueue{
+		name:         name,
+		handler:      handler,
+		retry:        retry,
+		store:        store,
+		metrics:      metrics,
+		PollInterval: defaultQueuePollInterval,
+		BatchSize:    defaultQueueBatchSize,
+		MaxPending:   defaultQueueMaxPending,
+		signal:       make(chan struct{}, 1),
+	}
+}
+
+func (q *JobQueue) Name() string {
+	return q.name
+}
+
+func (q *JobQueue) Persistent() bool {
+	return q.store != nil
+}
+
+// Enqueue schedules the job to be executed as soon as possible
+func (q *JobQueue) Enqueue(ctx context.Context, payload []byte) error {
+	tnow := time.Now().UTC()
+
+	if q.store != nil {
+		if err := q.store.CreateQueueJob(ctx, q.name, payload, tnow); err != nil {
+			slog.ErrorContext(ctx, "Failed to persist queued job", "queue", q.name, ErrAttr(err))
+			return err
+		}
+	} else if err := q.push(&QueuedJob{Payload: payload, RunAt: tnow}); err != nil {
+		slog.ErrorContext(ctx, "Failed to enqueue job", "queue", q.name, "pending", q.PendingCount(), ErrAttr(err))
+		q.observe(JobResultDropped, 0)
+		return err
+	}
+
+	slog.Log(ctx, LevelTrace, "Enqueued job", "queue", q.name, "persistent", q.store != nil)
+
+	q.notify()
+
+	return nil
+}
+
+func (q *JobQueue) push(job *QueuedJob) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.pending) >= q.MaxPending {
+		return ErrQueueFull
+	}
+
+	q.pending = append(q.pending, job)
+
+	if q.metrics != nil {
+		q.metrics.ObserveQueueSize(q.name, len(q.pending))
+	}
+
+	return nil
+}
+
+// PendingCount returns number of in-memory jobs (it is always 0 for the persistent queue)
+func (q *JobQueue) PendingCount() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.pending)
+}
+
+func (q *JobQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// claim returns due jobs, in-memory jobs are removed from the queue and pushed back if they need to be retried
+func (q *JobQueue) claim(ctx context.Context, tnow time.Time) ([]*QueuedJob, error) {
+	if q.store != nil {
+		return q.store.ClaimQueueJobs(ctx, q.name, q.BatchSize, queueJobLease)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var due []*QueuedJob
+	remaining := q.pending[:0]
+	for _, job := range q.pending {
+		if (len(due) < q.BatchSize) && !job.RunAt.After(tnow) {
+			due = append(due, job)
+		} else {
+			remaining = append(remaining, job)
+		}
+	}
+
+	clear(q.pending[len(remaining):])
+	q.pending = remaining
+
+	if q.metrics != nil {
+		q.metrics.ObserveQueueSize(q.name, len(q.pending))
+	}
+
+	return due, nil
+}
+
+func (q *JobQueue) observe(result string, duration time.Duration) {
+	if q.metrics != nil {
+		q.metrics.ObserveJob(q.name, result, duration)
+	}
+}
+
+func (q *JobQueue) execute(ctx context.Context, job *QueuedJob) (err error) {
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			slog.ErrorContext(ctx, "Queued job crashed", "queue", q.name, "panic", rvr, "stack", string(debug.Stack()))
+			err = fmt.Errorf("job panicked: %v", rvr)
+		}
+	}()
+
+	jctx, cancel := context.WithTimeout(ctx, queueJobTimeout)
+	defer cancel()
+
+	return q.handler(jctx, job.Payload)
+}
+
+func (q *JobQueue) process(ctx context.Context, job *QueuedJob) string {
+	jlog := slog.With("queue", q.name, "jobID", job.ID, "attempts", job.Attempts)
+
+	start := time.Now()
+	err := q.execute(ctx, job)
+	duration := time.Since(start)
+
+	if err == nil {
+		jlog.DebugContext(ctx, "Processed queued job", "duration", duration.String())
+		if q.store != nil {
+			_ = q.store.CompleteQueueJob(ctx, job.ID)
+		}
+		q.observe(JobResultSuccess, duration)
+		return JobResultSuccess
+	}
+
+	job.Attempts++
+
+	if job.Attempts >= q.retry.MaxAttempts {
+		jlog.ErrorContext(ctx, "Queued job failed permanently", ErrAttr(err))
+		if q.store != nil {
+			_ = q.store.FailQueueJob(ctx, job.ID, err.Error())
+		}
+		q.observe(JobResultFailed, duration)
+		return JobResultFailed
+	}
+
+	job.RunAt = time.Now().UTC().Add(q.retry.Backoff(job.Attempts))
+	jlog.WarnContext(ctx, "Queued job failed, will retry", "runAt", job.RunAt, ErrAttr(err))
+
+	if q.store != nil {
+		_ = q.store.RetryQueueJob(ctx, job.ID, err.Error(), job.RunAt)
+	} else if perr := q.push(job); perr != nil {
+		jlog.ErrorContext(ctx, "Dropping failed job", ErrAttr(perr))
+		q.observe(JobResultDropped, duration)
+		return JobResultDropped
+	}
+
+	q.observe(JobResultRetry, duration)
+
+	return JobResultRetry
+}
+
+// ProcessOnce executes all jobs that are due at the moment
+func (q *JobQueue) ProcessOnce(ctx context.Context) error {
+	for {
+		jobs, err := q.claim(ctx, time.Now().UTC())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim queued jobs", "queue", q.name, ErrAttr(err))
+			return err
+		}
+
+		for _, job := range jobs {
+			q.process(ctx, job)
+		}
+
+		if len(jobs) < q.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (q *JobQueue) run(ctx context.Context) {
+	defer close(q.done)
+
+	slog.DebugContext(ctx, "Starting job queue", "queue", q.name, "persistent", q.store != nil)
+
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-q.signal:
+			_ = q.ProcessOnce(ctx)
+		case <-time.After(q.PollInterval):
+			_ = q.ProcessOnce(ctx)
+		}
+	}
+
+	if pending := q.PendingCount(); pending > 0 {
+		slog.WarnContext(ctx, "Job queue stopped with pending jobs", "queue", q.name, "pending", pending)
+	}
+
+	slog.DebugContext(ctx, "Job queue finished", "queue", q.name)
+}
+
+func (q *JobQueue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(
+		context.WithValue(ctx, TraceIDContextKey, "job_queue_"+q.name))
+	q.done = make(chan struct{})
+
+	go q.run(ctx)
+}
+
+func (q *JobQueue) Shutdown() {
+	if q.cancel == nil {
+		return
+	}
+
+	q.cancel()
+	<-q.done
+}
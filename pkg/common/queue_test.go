@@ -0,0 +1,196 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errJobTest = errors.New("job failed")
+
+type queueMetricsStub struct {
+	results map[string]int
+}
+
+func (m *queueMetricsStub) ObserveJob(queue, result string, duration time.Duration) {
+	m.results[result]++
+}
+
+func (m *queueMetricsStub) ObserveQueueSize(queue string, size int) {}
+
+type jobStoreStub struct {
+	jobs      []*QueuedJob
+	completed int
+	retried   int
+	failed    int
+}
+
+func (s *jobStoreStub) CreateQueueJob(ctx context.Context, queue string, payload []byte, runAt time.Time) error {
+	s.jobs = append(s.jobs, &QueuedJob{ID: int32(len(s.jobs) + 1), Payload: payload, RunAt: runAt})
+	return nil
+}
+
+func (s *jobStoreStub) ClaimQueueJobs(ctx context.Context, queue string, limit int, lease time.Duration) ([]*QueuedJob, error) {
+	jobs := s.jobs
+	s.jobs = nil
+	return jobs, nil
+}
+
+func (s *jobStoreStub) CompleteQueueJob(ctx context.Context, id int32) error {
+	s.completed++
+	return nil
+}
+
+func (s *jobStoreStub) RetryQueueJob(ctx context.Context, id int32, reason string, runAt time.Time) error {
+	s.retried++
+	return nil
+}
+
+func (s *jobStoreStub) FailQueueJob(ctx context.Context, id int32, reason string) error {
+	s.failed++
+	return nil
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseBackoff: 10 * time.Second, MaxBackoff: 1 * time.Minute}
+
+	testCases := []struct {
+		attempts int32
+		backoff  time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, 1 * time.Minute},
+		{100, 1 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		if actual := policy.Backoff(tc.attempts); actual != tc.backoff {
+			t.Errorf("Unexpected backoff for %v attempts: %v (expected %v)", tc.attempts, actual, tc.backoff)
+		}
+	}
+}
+
+func TestJobQueueRetry(t *testing.T) {
+	ctx := context.TODO()
+	calls := 0
+	handler := func(ctx context.Context, payload []byte) error {
+		calls++
+		if calls < 3 {
+			return errJobTest
+		}
+		return nil
+	}
+
+	metrics := &queueMetricsStub{results: make(map[string]int)}
+	q := NewJobQueue("test", handler, RetryPolicy{MaxAttempts: 5, BaseBackoff: 1 * time.Nanosecond, MaxBackoff: 1 * time.Nanosecond}, nil /*store*/, metrics)
+
+	if err := q.Enqueue(ctx, []byte("job")); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; (i < 10) && (q.PendingCount() > 0); i++ {
+		time.Sleep(1 * time.Millisecond)
+		_ = q.ProcessOnce(ctx)
+	}
+
+	if calls != 3 {
+		t.Errorf("Unexpected number of calls: %v", calls)
+	}
+
+	if (metrics.results[JobResultRetry] != 2) || (metrics.results[JobResultSuccess] != 1) {
+		t.Errorf("Unexpected metrics: %v", metrics.results)
+	}
+}
+
+func TestJobQueueFailure(t *testing.T) {
+	ctx := context.TODO()
+	calls := 0
+	handler := func(ctx context.Context, payload []byte) error {
+		calls++
+		return errJobTest
+	}
+
+	q := NewJobQueue("test", handler, RetryPolicy{MaxAttempts: 2, BaseBackoff: 1 * time.Nanosecond, MaxBackoff: 1 * time.Nanosecond}, nil /*store*/, nil /*metrics*/)
+	q.MaxPending = 1
+
+	if err := q.Enqueue(ctx, []byte("job")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Enqueue(ctx, []byte("job2")); err != ErrQueueFull {
+		t.Errorf("Unexpected enqueue error: %v", err)
+	}
+
+	for i := 0; (i < 10) && (q.PendingCount() > 0); i++ {
+		time.Sleep(1 * time.Millisecond)
+		_ = q.ProcessOnce(ctx)
+	}
+
+	if calls != 2 {
+		t.Errorf("Unexpected number of calls: %v", calls)
+	}
+
+	if count := q.PendingCount(); count != 0 {
+		t.Errorf("Failed job was not removed from the queue: %v", count)
+	}
+}
+
+func TestJobQueuePersistent(t *testing.T) {
+	ctx := context.TODO()
+	handler := func(ctx context.Context, payload []byte) error {
+		switch string(payload) {
+		case "retry":
+			return errJobTest
+		case "panic":
+			panic("test")
+		default:
+			return nil
+		}
+	}
+
+	store := &jobStoreStub{}
+	q := NewJobQueue("test", handler, RetryPolicy{MaxAttempts: 2, BaseBackoff: 1 * time.Minute, MaxBackoff: 1 * time.Minute}, store, nil /*metrics*/)
+
+	for _, payload := range []string{"ok", "retry", "panic"} {
+		if err := q.Enqueue(ctx, []byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := q.PendingCount(); count != 0 {
+		t.Errorf("Persistent jobs are kept in memory: %v", count)
+	}
+
+	if err := q.ProcessOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if (store.completed != 1) || (store.retried != 2) || (store.failed != 0) {
+		t.Errorf("Unexpected store calls: completed=%v retried=%v failed=%v", store.completed, store.retried, store.failed)
+	}
+}
+
+func TestJobQueueStart(t *testing.T) {
+	done := make(chan struct{})
+	handler := func(ctx context.Context, payload []byte) error {
+		close(done)
+		return nil
+	}
+
+	q := NewJobQueue("test", handler, RetryPolicy{MaxAttempts: 1}, nil /*store*/, nil /*metrics*/)
+	q.Start(context.TODO())
+	defer q.Shutdown()
+
+	if err := q.Enqueue(context.TODO(), []byte("job")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Job was not processed")
+	}
+}
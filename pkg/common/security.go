@@ -0,0 +1,128 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	cspNonceBytes = 16
+	hstsValue     = "max-age=63072000; includeSubDomains"
+)
+
+var (
+	headerCSP           = http.CanonicalHeaderKey("Content-Security-Policy")
+	headerCSPReportOnly = http.CanonicalHeaderKey("Content-Security-Policy-Report-Only")
+	headerHSTS          = http.CanonicalHeaderKey("Strict-Transport-Security")
+)
+
+// SecurityPolicy is the single source of security headers for portal (HTML) and CDN (static assets) responses
+type SecurityPolicy struct {
+	// HSTS is not sent in dev stage as it would "pin" local domains to TLS
+	hsts bool
+	// in dev stage CSP violations are only reported (in browser console) instead of being blocked
+	reportOnly bool
+	cdnSource  string
+	apiSource  string
+}
+
+func NewSecurityPolicy(stage, cdnDomain, apiDomain string) *SecurityPolicy {
+	return &SecurityPolicy{
+		hsts:       stage != StageDev,
+		reportOnly: stage == StageDev,
+		cdnSource:  cdnDomain,
+		apiSource:  apiDomain,
+	}
+}
+
+// ContentSecurityPolicy returns CSP for portal pages. Inline scripts are only allowed with the nonce, while
+// 'unsafe-eval' is required by Alpine and htmx attributes (and WebAssembly of the captcha widget)
+func (p *SecurityPolicy) ContentSecurityPolicy(nonce string) string {
+	var b strings.Builder
+
+	directive := func(name string, sources ...string) {
+		b.WriteString(name)
+		for _, s := range sources {
+			if len(s) > 0 {
+				b.WriteByte(' ')
+				b.WriteString(s)
+			}
+		}
+		b.WriteString("; ")
+	}
+
+	directive("default-src", "'self'")
+	directive("script-src", "'self'", "'nonce-"+nonce+"'", "'unsafe-eval'", p.cdnSource)
+	directive("style-src", "'self'", "'unsafe-inline'", p.cdnSource)
+	directive("img-src", "'self'", "data:", p.cdnSource)
+	directive("font-src", "'self'", p.cdnSource)
+	directive("connect-src", "'self'", p.apiSource)
+	directive("worker-src", "'self'", "blob:")
+	directive("object-src", "'none'")
+	directive("base-uri", "'self'")
+	directive("form-action", "'self'")
+	b.WriteString("frame-ancestors 'none'")
+
+	return b.String()
+}
+
+func (p *SecurityPolicy) writeCommon(h http.Header) {
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	if p.hsts {
+		h.Set(headerHSTS, hstsValue)
+	}
+}
+
+// Portal middleware adds security headers (including CSP with a fresh nonce) to every portal response
+func (p *SecurityPolicy) Portal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := newCSPNonce()
+
+		h := w.Header()
+		p.writeCommon(h)
+		h.Set("X-Frame-Options", "DENY")
+		if p.reportOnly {
+			h.Set(headerCSPReportOnly, p.ContentSecurityPolicy(nonce))
+		} else {
+			h.Set(headerCSP, p.ContentSecurityPolicy(nonce))
+		}
+
+		ctx := context.WithValue(r.Context(), CSPNonceContextKey, nonce)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Static middleware adds security headers to CDN responses. Assets are loaded from customers' websites too so
+// they are explicitly allowed to be embedded cross-origin
+func (p *SecurityPolicy) Static(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		p.writeCommon(h)
+		h.Set("Cross-Origin-Resource-Policy", "cross-origin")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CSPNonce returns nonce of the current request for inline scripts (or empty string outside of Portal middleware)
+func CSPNonce(ctx context.Context) string {
+	if nonce, ok := ctx.Value(CSPNonceContextKey).(string); ok {
+		return nonce
+	}
+
+	return ""
+}
+
+func newCSPNonce() string {
+	buf := make([]byte, cspNonceBytes)
+	if _, err := rand.Read(buf); err != nil {
+		slog.Error("Failed to generate CSP nonce", ErrAttr(err))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
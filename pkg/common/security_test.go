@@ -0,0 +1,86 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPortalSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	policy := NewSecurityPolicy(StageStaging, "cdn.example.com", "api.example.com")
+
+	var nonces []string
+	handler := policy.Portal(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonces = append(nonces, CSPNonce(r.Context()))
+	}))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		nonce := nonces[i]
+		if len(nonce) == 0 {
+			t.Fatal("Nonce is not set in context")
+		}
+
+		csp := w.Header().Get("Content-Security-Policy")
+		if !strings.Contains(csp, "'nonce-"+nonce+"'") || !strings.Contains(csp, "cdn.example.com") {
+			t.Errorf("Unexpected CSP: %v", csp)
+		}
+
+		if w.Header().Get("Strict-Transport-Security") == "" {
+			t.Error("HSTS header is not set")
+		}
+
+		if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Error("X-Content-Type-Options header is not set")
+		}
+	}
+
+	if nonces[0] == nonces[1] {
+		t.Error("Nonce is reused between requests")
+	}
+}
+
+func TestPortalSecurityHeadersDev(t *testing.T) {
+	t.Parallel()
+
+	policy := NewSecurityPolicy(StageDev, "", "")
+	handler := policy.Portal(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Header().Get("Content-Security-Policy") != "" || w.Header().Get("Content-Security-Policy-Report-Only") == "" {
+		t.Error("CSP is not report-only in dev stage")
+	}
+
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS header is set in dev stage")
+	}
+}
+
+func TestStaticSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	policy := NewSecurityPolicy(StageStaging, "", "")
+	handler := policy.Static(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if nonce := CSPNonce(r.Context()); nonce != "" {
+			t.Errorf("Unexpected nonce for static request: %v", nonce)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Header().Get("Content-Security-Policy") != "" {
+		t.Error("CSP is set for static assets")
+	}
+
+	if w.Header().Get("Cross-Origin-Resource-Policy") != "cross-origin" {
+		t.Error("CORP header is not set")
+	}
+}
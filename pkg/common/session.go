@@ -111,6 +111,20 @@ func (st *Session) Delete(key SessionKey) error {
 	return st.store.Update(st)
 }
 
+// Clone copies all values into a new session with a different ID (the new session is not registered in the store)
+func (st *Session) Clone(sid string) *Session {
+	sess := NewSession(sid, st.store)
+
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	for k, v := range st.values {
+		sess.values[k] = v
+	}
+
+	return sess
+}
+
 func (st *Session) SessionID() string {
 	return st.sid
 }
@@ -13,6 +13,36 @@ type Cache[TKey comparable, TValue any] interface {
 	Delete(ctx context.Context, key TKey) error
 }
 
+type CacheStats struct {
+	Class       string `json:"class"`
+	Size        int64  `json:"size"`
+	Bytes       int64  `json:"bytes"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+}
+
+type CacheStatsSource interface {
+	Stats() []*CacheStats
+}
+
+type CacheStatsRegistry interface {
+	RegisterCacheStats(name string, source CacheStatsSource)
+}
+
+type PuzzlePoolStats struct {
+	Bucket    string `json:"bucket"`
+	Depth     int    `json:"depth"`
+	Capacity  int    `json:"capacity"`
+	Hits      uint64 `json:"hits"`
+	Fallbacks uint64 `json:"fallbacks"`
+}
+
+type PuzzlePoolStatsSource interface {
+	Stats() []*PuzzlePoolStats
+}
+
 type SessionStore interface {
 	Init(ctx context.Context, session *Session) error
 	Read(ctx context.Context, sid string) (*Session, error)
@@ -31,28 +61,72 @@ type ConfigStore interface {
 	Update(ctx context.Context)
 }
 
+// TimeSeriesSink is a write-only destination for access and verify logs (e.g. ClickHouse cluster or Kafka topic)
+type TimeSeriesSink interface {
+	Name() string
+	WriteAccessLogBatch(ctx context.Context, records []*AccessRecord) error
+	WriteVerifyLogBatch(ctx context.Context, records []*VerifyRecord) error
+}
+
 type TimeSeriesStore interface {
 	Ping(ctx context.Context) error
 	WriteAccessLogBatch(ctx context.Context, records []*AccessRecord) error
 	WriteVerifyLogBatch(ctx context.Context, records []*VerifyRecord) error
 	ReadPropertyStats(ctx context.Context, r *BackfillRequest, from time.Time) ([]*TimeCount, error)
-	ReadAccountStats(ctx context.Context, userID int32, from time.Time) ([]*TimeCount, error)
-	RetrievePropertyStats(ctx context.Context, orgID, propertyID int32, period TimePeriod) ([]*TimePeriodStat, error)
+	ReadAccountStats(ctx context.Context, userID int32, from time.Time, tz *time.Location) ([]*TimeCount, error)
+	RetrievePropertyStats(ctx context.Context, orgID, propertyID int32, period TimePeriod, tz *time.Location) ([]*TimePeriodStat, error)
+	RetrievePropertyFailures(ctx context.Context, orgID, propertyID int32, period TimePeriod, tz *time.Location) ([]*VerifyFailureStat, error)
+	RetrievePropertyActions(ctx context.Context, orgID, propertyID int32, from time.Time) ([]*ActionStat, error)
+	RetrievePropertyFingerprints(ctx context.Context, orgID, propertyID int32, period TimePeriod, tz *time.Location) (*FingerprintStats, error)
+	ReadUsageCounters(ctx context.Context, from time.Time) ([]*UsageCounter, error)
+	ReadDailyUsageCounters(ctx context.Context, day time.Time) ([]*UsageCounter, error)
+	RetrievePropertiesTotals(ctx context.Context, orgID int32, propertyIDs []int32, from time.Time) (*TimePeriodStat, error)
+	RetrievePropertiesActivity(ctx context.Context, orgID int32, propertyIDs []int32) (map[int32]*PropertyActivity, error)
+	ReadOrgsMonthlyUsage(ctx context.Context, orgIDs []int32, month time.Time) (map[int32]uint64, error)
 	DeletePropertiesData(ctx context.Context, propertyIDs []int32) error
 	DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error
 	DeleteUsersData(ctx context.Context, userIDs []int32) error
 }
 
+// BatchMetrics observes health of the background batch pipelines (see ProcessBatchArray)
+type BatchMetrics interface {
+	ObserveBatchQueue(pipeline string, queued, pending int)
+	ObserveBatchFlush(pipeline string, size int, duration time.Duration, err error)
+	ObserveBatchDropped(pipeline string, count int)
+}
+
+// QueueMetrics observes background job queues (see JobQueue)
+type QueueMetrics interface {
+	ObserveJob(queue, result string, duration time.Duration)
+	ObserveQueueSize(queue string, size int)
+}
+
+// ProxyMetrics observes forwarding headers that were not used to resolve client IP (see TrustedProxiesKey)
+type ProxyMetrics interface {
+	ObserveProxyHeaderRejected(reason string)
+}
+
 type PlatformMetrics interface {
+	BatchMetrics
+	QueueMetrics
+	ProxyMetrics
+	CacheStatsRegistry
 	ObserveHealth(postgres, clickhouse bool)
+	ObserveCircuitBreaker(name string, state CircuitBreakerState)
+	ObserveQuery(source, name string, duration time.Duration)
 }
 
 type APIMetrics interface {
+	BatchMetrics
 	Handler(h http.Handler) http.Handler
 	ObservePuzzleCreated(userID int32)
 	ObservePuzzleVerified(userID int32, result string, isStub bool)
+	ObserveVerifyBatch(result string, items int)
+	RegisterPuzzlePoolStats(source PuzzlePoolStatsSource)
 }
 
 type PortalMetrics interface {
 	HandlerIDFunc(handlerIDFunc func() string) func(http.Handler) http.Handler
+	ObserveTwoFactorFailure(result string)
+	ObserveLoginThrottled(endpoint, kind string)
 }
@@ -15,9 +15,59 @@ type TimePeriodStat struct {
 	Timestamp     time.Time
 	RequestsCount int
 	VerifiesCount int
+	// subset of requests that came from known hosting networks
+	DatacenterCount int
+}
+
+// PropertyActivity is the time of the first puzzle request and of the first successful verification of the
+// property (zero time if there were none yet)
+type PropertyActivity struct {
+	PropertyID   int32
+	FirstRequest time.Time
+	FirstVerify  time.Time
+}
+
+// VerifyFailureStat is the number of failed verifications with the same status (puzzle.VerifyError) in time bucket
+type VerifyFailureStat struct {
+	Timestamp time.Time
+	Status    uint8
+	Count     uint64
+}
+
+// ActionStat is the number of verifications of puzzles requested with the same (integrator-defined) action
+type ActionStat struct {
+	Action       string
+	Count        uint64
+	SuccessCount uint64
+}
+
+// FingerprintStat is the number of requests and the approximate number of distinct fingerprints among them in time
+// bucket. Requests of IP-less properties (random fingerprints) and of properties in privacy mode (no fingerprints)
+// are not counted
+type FingerprintStat struct {
+	Timestamp     time.Time
+	UniqueCount   uint64
+	RequestsCount uint64
+}
+
+// FingerprintStats contains uniqueness stats per time bucket and for the whole period (distinct counts of buckets
+// cannot be added up as the same client usually comes back in different buckets)
+type FingerprintStats struct {
+	Buckets []*FingerprintStat
+	Total   *FingerprintStat
 }
 
 type TimeCount struct {
 	Timestamp time.Time
 	Count     uint32
 }
+
+// UsageCounter contains cumulative property usage since some point in time
+type UsageCounter struct {
+	UserID        int32
+	OrgID         int32
+	PropertyID    int32
+	Requests      uint64
+	VerifySuccess uint64
+	VerifyFailure uint64
+}
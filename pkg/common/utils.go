@@ -193,3 +193,18 @@ func EnvToBool(value string) bool {
 		return false
 	}
 }
+
+// IsValidDataRegion checks that region name is a short lowercase identifier (e.g. "eu" or "us-east")
+func IsValidDataRegion(region string) bool {
+	if (len(region) == 0) || (len(region) > 32) {
+		return false
+	}
+
+	for _, ch := range region {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') || (ch == '-')) {
+			return false
+		}
+	}
+
+	return true
+}
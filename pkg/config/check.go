@@ -0,0 +1,426 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/badoux/checkmail"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	CheckStatusOK      = "ok"
+	CheckStatusMissing = "missing"
+	CheckStatusInvalid = "invalid"
+	CheckStatusEmpty   = "empty"
+)
+
+var (
+	errNotANumber     = errors.New("value is not a number")
+	errNotAPort       = errors.New("value is not a valid port")
+	errNotABool       = errors.New("value is not a boolean")
+	errNotAHost       = errors.New("value is not a valid host")
+	errInvalidScheme  = errors.New("URL scheme is not supported")
+	errUnknownOption  = errors.New("value is not one of supported options")
+	errKeyTooLong     = errors.New("value is too long")
+	errKeyTooShort    = errors.New("value is too short")
+	errInvalidKeySize = errors.New("value has invalid key size")
+	errPostgresConfig = errors.New("either full Postgres URL or host, database, user and password are required")
+	errNotAnIPRange   = errors.New("value is not a valid IP address or CIDR range")
+)
+
+type ConfigCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Message  string `json:"message,omitempty"`
+}
+
+type ConfigReport struct {
+	Checks []*ConfigCheck `json:"checks"`
+	Errors int            `json:"errors"`
+}
+
+func (r *ConfigReport) add(check *ConfigCheck) {
+	r.Checks = append(r.Checks, check)
+
+	if (check.Status == CheckStatusInvalid) || (check.Required && (check.Status == CheckStatusMissing)) {
+		r.Errors++
+	}
+}
+
+func (r *ConfigReport) OK() bool {
+	return r.Errors == 0
+}
+
+type configRule struct {
+	required bool
+	validate func(value string) error
+}
+
+func validateInt(value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return errNotANumber
+	}
+
+	return nil
+}
+
+func validateFloat(value string) error {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return errNotANumber
+	}
+
+	return nil
+}
+
+func validateDuration(value string) error {
+	_, err := time.ParseDuration(value)
+	return err
+}
+
+func validatePort(value string) error {
+	if port, err := strconv.Atoi(value); (err != nil) || (port <= 0) || (port > 65535) {
+		return errNotAPort
+	}
+
+	return nil
+}
+
+func validateBool(value string) error {
+	switch strings.ToLower(value) {
+	case "1", "0", "y", "n", "yes", "no", "true", "false":
+		return nil
+	default:
+		return errNotABool
+	}
+}
+
+// validateBaseURL checks values like "api.privatecaptcha.com" or "localhost:8080" (without scheme)
+func validateBaseURL(value string) error {
+	if strings.Contains(value, "://") {
+		return errInvalidScheme
+	}
+
+	domain, _, err := splitHostPort(strings.TrimRight(value, "/"))
+	if err != nil {
+		return err
+	}
+
+	if len(domain) == 0 {
+		return errNotAHost
+	}
+
+	return nil
+}
+
+func validateHostPort(value string) error {
+	if _, port, err := net.SplitHostPort(value); err != nil {
+		return err
+	} else {
+		return validatePort(port)
+	}
+}
+
+func validateURL(schemes ...string) func(string) error {
+	return func(value string) error {
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+
+		if len(u.Host) == 0 {
+			return errNotAHost
+		}
+
+		for _, s := range schemes {
+			if u.Scheme == s {
+				return nil
+			}
+		}
+
+		return errInvalidScheme
+	}
+}
+
+func validateOneOf(options ...string) func(string) error {
+	return func(value string) error {
+		for _, o := range options {
+			if value == o {
+				return nil
+			}
+		}
+
+		return errUnknownOption
+	}
+}
+
+// validateListOf checks comma-separated list of (case-insensitive) options
+func validateListOf(options ...string) func(string) error {
+	return func(value string) error {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); len(item) == 0 {
+				continue
+			}
+
+			if err := validateOneOf(options...)(strings.ToLower(item)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// validateIPRanges checks comma-separated list of IP addresses and CIDR ranges
+func validateIPRanges(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+
+		if _, err := netip.ParsePrefix(item); err == nil {
+			continue
+		}
+
+		if _, err := netip.ParseAddr(item); (err != nil) || strings.Contains(item, "%") {
+			return errNotAnIPRange
+		}
+	}
+
+	return nil
+}
+
+func validateDataRegions(value string) error {
+	_, err := ParseDataRegions(value)
+	return err
+}
+
+func validateEmail(value string) error {
+	return checkmail.ValidateFormat(value)
+}
+
+func validatePostgresURL(value string) error {
+	_, err := pgconn.ParseConfig(value)
+	return err
+}
+
+func validateFingerprintKey(value string) error {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > 64 {
+		return errKeyTooLong
+	}
+
+	return nil
+}
+
+func validateEd25519Seed(value string) error {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	if len(data) != ed25519.SeedSize {
+		return errInvalidKeySize
+	}
+
+	return nil
+}
+
+// validateEd25519PublicKeys checks comma-separated list of hex-encoded public keys
+func validateEd25519PublicKeys(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+
+		data, err := hex.DecodeString(item)
+		if err != nil {
+			return err
+		}
+
+		if len(data) != ed25519.PublicKeySize {
+			return errInvalidKeySize
+		}
+	}
+
+	return nil
+}
+
+// ClamAV address is either unix socket path or TCP host:port
+func validateClamAVAddress(value string) error {
+	if strings.HasPrefix(value, "/") {
+		_, err := os.Stat(value)
+		return err
+	}
+
+	return validateHostPort(value)
+}
+
+func validateIPAllowlist(value string) error {
+	_, err := common.ParseIPAllowlist(value)
+	return err
+}
+
+// validateSecretKey requires shared secrets (e.g. bearer tokens) to be long enough to not be guessable
+func validateSecretKey(value string) error {
+	const minSecretKeyLength = 32
+	if len(value) < minSecretKeyLength {
+		return errKeyTooShort
+	}
+
+	return nil
+}
+
+func configRules() map[common.ConfigKey]configRule {
+	return map[common.ConfigKey]configRule{
+		common.StageKey:                     {required: true},
+		common.VerboseKey:                   {validate: validateBool},
+		common.APIBaseURLKey:                {required: true, validate: validateBaseURL},
+		common.PortalBaseURLKey:             {required: true, validate: validateBaseURL},
+		common.CDNBaseURLKey:                {required: true, validate: validateBaseURL},
+		common.LocalAddressKey:              {validate: validateHostPort},
+		common.MaintenanceModeKey:           {validate: validateBool},
+		common.RegistrationAllowedKey:       {validate: validateBool},
+		common.HealthCheckIntervalKey:       {validate: validateInt},
+		common.AdminEmailKey:                {required: true, validate: validateEmail},
+		common.PostgresKey:                  {validate: validatePostgresURL},
+		common.ClickHouseHostKey:            {required: true},
+		common.ClickHouseDBKey:              {required: true},
+		common.ClickHouseUserKey:            {required: true},
+		common.ClickHousePasswordKey:        {required: true},
+		common.PuzzleLeakyBucketRateKey:     {validate: validateFloat},
+		common.PuzzleLeakyBucketBurstKey:    {validate: validateInt},
+		common.DefaultLeakyBucketRateKey:    {validate: validateFloat},
+		common.DefaultLeakyBucketBurstKey:   {validate: validateInt},
+		common.SmtpEndpointKey:              {validate: validateURL("smtp", "smtps")},
+		common.EmailFromKey:                 {required: true, validate: validateEmail},
+		common.PortKey:                      {validate: validatePort},
+		common.UserFingerprintIVKey:         {required: true, validate: validateFingerprintKey},
+		common.APISaltKey:                   {required: true},
+		common.ClamAVAddressKey:             {validate: validateClamAVAddress},
+		common.SupportEmailKey:              {validate: validateEmail},
+		common.MetricsExportURLKey:          {validate: validateURL("http", "https")},
+		common.MetricsExportFormatKey:       {validate: validateOneOf("remote-write", "pushgateway")},
+		common.KafkaRESTURLKey:              {validate: validateURL("http", "https")},
+		common.KMSProviderKey:               {validate: validateOneOf("vault", "aws", "gcp")},
+		common.KMSEndpointKey:               {validate: validateURL("http", "https")},
+		common.KMSRotationPeriodKey:         {validate: validateDuration},
+		common.LicenseReportURLKey:          {validate: validateURL("https")},
+		common.APIListenAddressKey:          {validate: validateHostPort},
+		common.PortalListenAddressKey:       {validate: validateHostPort},
+		common.CDNListenAddressKey:          {validate: validateHostPort},
+		common.SlowQueryThresholdKey:        {validate: validateDuration},
+		common.VerifyReceiptKey:             {validate: validateEd25519Seed},
+		common.CacheInvalidationKey:         {validate: validateBool},
+		common.PuzzlePoolSizeKey:            {validate: validateInt},
+		common.APIPuzzleTimeoutKey:          {validate: validateDuration},
+		common.APIVerifyTimeoutKey:          {validate: validateDuration},
+		common.APIFallbackTimeoutKey:        {validate: validateDuration},
+		common.APIVerifyMaxBytesKey:         {validate: validateInt},
+		common.PortalTimeoutKey:             {validate: validateDuration},
+		common.PortalPublicTimeoutKey:       {validate: validateDuration},
+		common.PortalMaxBytesKey:            {validate: validateInt},
+		common.LocalAllowedIPsKey:           {validate: validateIPAllowlist},
+		common.ProvisioningAPIKeyKey:        {validate: validateSecretKey},
+		common.AlertWebhookURLKey:           {validate: validateURL("http", "https")},
+		common.WarehouseExportURLKey:        {validate: validateURL("http", "https")},
+		common.WarehouseExportFormatKey:     {validate: validateOneOf("parquet", "csv")},
+		common.MaintenanceSubsystemsKey:     {validate: validateListOf(maintenanceSubsystems...)},
+		common.ChaosLatencyKey:              {validate: validateDuration},
+		common.ChaosErrorRateKey:            {validate: validateFloat},
+		common.ChaosTargetsKey:              {validate: validateListOf("postgres", "clickhouse", "http")},
+		common.APIBatchMaxBytesKey:          {validate: validateInt},
+		common.ClickHouseRegionsKey:         {validate: validateDataRegions},
+		common.SupportInboundKeyKey:         {validate: validateSecretKey},
+		common.VerifyLogOverflowKey:         {validate: validateOneOf("drop", "spill")},
+		common.EmailQueuePersistKey:         {validate: validateBool},
+		common.VerifiedDomainsKey:           {validate: validateBool},
+		common.APIHTTP2Key:                  {validate: validateBool},
+		common.APIMaxStreamsKey:             {validate: validateInt},
+		common.APIKeepAliveKey:              {validate: validateDuration},
+		common.APIIdleTimeoutKey:            {validate: validateDuration},
+		common.PortalHTTP2Key:               {validate: validateBool},
+		common.PortalMaxStreamsKey:          {validate: validateInt},
+		common.PortalKeepAliveKey:           {validate: validateDuration},
+		common.PortalIdleTimeoutKey:         {validate: validateDuration},
+		common.MobileLeakyBucketRateKey:     {validate: validateFloat},
+		common.MobileLeakyBucketBurstKey:    {validate: validateInt},
+		common.PortalMaxSessionsKey:         {validate: validateInt},
+		common.TrustedProxiesKey:            {validate: validateIPRanges},
+		common.VerifyReceiptPreviousKeysKey: {validate: validateEd25519PublicKeys},
+	}
+}
+
+// CheckConfig validates all known configuration keys and returns a report. It does not connect anywhere.
+func CheckConfig(cfg common.ConfigStore, mapper ConfigMapper) *ConfigReport {
+	report := &ConfigReport{Checks: make([]*ConfigCheck, 0, int(common.COMMON_CONFIG_KEYS_COUNT))}
+	rules := configRules()
+
+	for key := common.ConfigKey(0); key < common.COMMON_CONFIG_KEYS_COUNT; key++ {
+		name := mapper(key)
+		if len(name) == 0 {
+			continue
+		}
+
+		rule := rules[key]
+		check := &ConfigCheck{Name: name, Required: rule.required, Status: CheckStatusOK}
+
+		if value := cfg.Get(key).Value(); len(value) == 0 {
+			if rule.required {
+				check.Status = CheckStatusMissing
+			} else {
+				check.Status = CheckStatusEmpty
+			}
+		} else if rule.validate != nil {
+			if err := rule.validate(value); err != nil {
+				check.Status = CheckStatusInvalid
+				check.Message = err.Error()
+			}
+		}
+
+		report.add(check)
+	}
+
+	// Postgres can be configured either with a full URL or with separate parts
+	if len(cfg.Get(common.PostgresKey).Value()) == 0 {
+		for _, key := range []common.ConfigKey{common.PostgresHostKey, common.PostgresDBKey, common.PostgresUserKey, common.PostgresPasswordKey} {
+			if len(cfg.Get(key).Value()) == 0 {
+				report.add(&ConfigCheck{Name: mapper(key), Status: CheckStatusMissing, Required: true, Message: errPostgresConfig.Error()})
+			}
+		}
+	}
+
+	return report
+}
+
+// CheckFile verifies that file (e.g. TLS certificate) exists and is readable
+func (r *ConfigReport) CheckFile(name, path string) {
+	if len(path) == 0 {
+		return
+	}
+
+	check := &ConfigCheck{Name: name, Status: CheckStatusOK, Required: true}
+
+	if f, err := os.Open(path); err != nil {
+		check.Status = CheckStatusInvalid
+		check.Message = err.Error()
+	} else {
+		f.Close()
+	}
+
+	r.add(check)
+}
@@ -96,6 +96,154 @@ func DefaultMapper(c common.ConfigKey) string {
 		return "PC_USER_FINGERPRINT_KEY"
 	case common.APISaltKey:
 		return "PC_API_SALT"
+	case common.ClamAVAddressKey:
+		return "PC_CLAMAV_ADDRESS"
+	case common.SupportEmailKey:
+		return "PC_SUPPORT_EMAIL"
+	case common.MetricsExportURLKey:
+		return "PC_METRICS_EXPORT_URL"
+	case common.MetricsExportFormatKey:
+		return "PC_METRICS_EXPORT_FORMAT"
+	case common.MetricsExportUserKey:
+		return "PC_METRICS_EXPORT_USER"
+	case common.MetricsExportPasswordKey:
+		return "PC_METRICS_EXPORT_PASSWORD"
+	case common.MetricsExportTokenKey:
+		return "PC_METRICS_EXPORT_TOKEN"
+	case common.ClickHouseSecondaryHostKey:
+		return "PC_CLICKHOUSE_SECONDARY_HOST"
+	case common.ClickHouseSecondaryDBKey:
+		return "PC_CLICKHOUSE_SECONDARY_DB"
+	case common.ClickHouseSecondaryUserKey:
+		return "PC_CLICKHOUSE_SECONDARY_USER"
+	case common.ClickHouseSecondaryPasswordKey:
+		return "PC_CLICKHOUSE_SECONDARY_PASSWORD"
+	case common.KafkaRESTURLKey:
+		return "PC_KAFKA_REST_URL"
+	case common.KafkaTopicKey:
+		return "PC_KAFKA_TOPIC"
+	case common.CountryHeaderKey:
+		return "PC_COUNTRY_HEADER"
+	case common.KMSProviderKey:
+		return "PC_KMS_PROVIDER"
+	case common.KMSKeyIDKey:
+		return "PC_KMS_KEY_ID"
+	case common.KMSEndpointKey:
+		return "PC_KMS_ENDPOINT"
+	case common.KMSTokenKey:
+		return "PC_KMS_TOKEN"
+	case common.KMSRotationPeriodKey:
+		return "PC_KMS_ROTATION_PERIOD"
+	case common.LicenseFileKey:
+		return "PC_LICENSE_FILE"
+	case common.LicenseReportURLKey:
+		return "PC_LICENSE_REPORT_URL"
+	case common.APIListenAddressKey:
+		return "PC_API_LISTEN_ADDRESS"
+	case common.APITLSCertFileKey:
+		return "PC_API_TLS_CERT_FILE"
+	case common.APITLSKeyFileKey:
+		return "PC_API_TLS_KEY_FILE"
+	case common.PortalListenAddressKey:
+		return "PC_PORTAL_LISTEN_ADDRESS"
+	case common.PortalTLSCertFileKey:
+		return "PC_PORTAL_TLS_CERT_FILE"
+	case common.PortalTLSKeyFileKey:
+		return "PC_PORTAL_TLS_KEY_FILE"
+	case common.CDNListenAddressKey:
+		return "PC_CDN_LISTEN_ADDRESS"
+	case common.CDNTLSCertFileKey:
+		return "PC_CDN_TLS_CERT_FILE"
+	case common.CDNTLSKeyFileKey:
+		return "PC_CDN_TLS_KEY_FILE"
+	case common.SlowQueryThresholdKey:
+		return "PC_SLOW_QUERY_THRESHOLD"
+	case common.VerifyReceiptKey:
+		return "PC_VERIFY_RECEIPT_KEY"
+	case common.CacheInvalidationKey:
+		return "PC_CACHE_INVALIDATION"
+	case common.PuzzlePoolSizeKey:
+		return "PC_PUZZLE_POOL_SIZE"
+	case common.APIPuzzleTimeoutKey:
+		return "PC_API_PUZZLE_TIMEOUT"
+	case common.APIVerifyTimeoutKey:
+		return "PC_API_VERIFY_TIMEOUT"
+	case common.APIFallbackTimeoutKey:
+		return "PC_API_FALLBACK_TIMEOUT"
+	case common.APIVerifyMaxBytesKey:
+		return "PC_API_VERIFY_MAX_BYTES"
+	case common.PortalTimeoutKey:
+		return "PC_PORTAL_TIMEOUT"
+	case common.PortalPublicTimeoutKey:
+		return "PC_PORTAL_PUBLIC_TIMEOUT"
+	case common.PortalMaxBytesKey:
+		return "PC_PORTAL_MAX_BYTES"
+	case common.LocalAuthTokenKey:
+		return "PC_LOCAL_AUTH_TOKEN"
+	case common.LocalAllowedIPsKey:
+		return "PC_LOCAL_ALLOWED_IPS"
+	case common.ProvisioningAPIKeyKey:
+		return "PC_PROVISIONING_API_KEY"
+	case common.AlertWebhookURLKey:
+		return "PC_ALERT_WEBHOOK_URL"
+	case common.WarehouseExportURLKey:
+		return "PC_WAREHOUSE_EXPORT_URL"
+	case common.WarehouseExportFormatKey:
+		return "PC_WAREHOUSE_EXPORT_FORMAT"
+	case common.WarehouseExportRegionKey:
+		return "PC_WAREHOUSE_EXPORT_REGION"
+	case common.WarehouseExportAccessKeyKey:
+		return "PC_WAREHOUSE_EXPORT_ACCESS_KEY"
+	case common.WarehouseExportSecretKeyKey:
+		return "PC_WAREHOUSE_EXPORT_SECRET_KEY"
+	case common.MaintenanceSubsystemsKey:
+		return "PC_MAINTENANCE_SUBSYSTEMS"
+	case common.ChaosLatencyKey:
+		return "PC_CHAOS_LATENCY"
+	case common.ChaosErrorRateKey:
+		return "PC_CHAOS_ERROR_RATE"
+	case common.ChaosTargetsKey:
+		return "PC_CHAOS_TARGETS"
+	case common.APIBatchMaxBytesKey:
+		return "PC_API_BATCH_MAX_BYTES"
+	case common.ClickHouseRegionsKey:
+		return "PC_CLICKHOUSE_REGIONS"
+	case common.SupportInboundKeyKey:
+		return "PC_SUPPORT_INBOUND_KEY"
+	case common.VerifyLogOverflowKey:
+		return "PC_VERIFY_LOG_OVERFLOW"
+	case common.VerifyLogSpillDirKey:
+		return "PC_VERIFY_LOG_SPILL_DIR"
+	case common.EmailQueuePersistKey:
+		return "PC_EMAIL_QUEUE_PERSIST"
+	case common.VerifiedDomainsKey:
+		return "PC_REQUIRE_DOMAIN_VERIFICATION"
+	case common.APIHTTP2Key:
+		return "PC_API_HTTP2"
+	case common.APIMaxStreamsKey:
+		return "PC_API_MAX_CONCURRENT_STREAMS"
+	case common.APIKeepAliveKey:
+		return "PC_API_TCP_KEEPALIVE"
+	case common.APIIdleTimeoutKey:
+		return "PC_API_IDLE_TIMEOUT"
+	case common.PortalHTTP2Key:
+		return "PC_PORTAL_HTTP2"
+	case common.PortalMaxStreamsKey:
+		return "PC_PORTAL_MAX_CONCURRENT_STREAMS"
+	case common.PortalKeepAliveKey:
+		return "PC_PORTAL_TCP_KEEPALIVE"
+	case common.PortalIdleTimeoutKey:
+		return "PC_PORTAL_IDLE_TIMEOUT"
+	case common.MobileLeakyBucketRateKey:
+		return "PC_MOBILE_LEAKY_BUCKET_RPS"
+	case common.MobileLeakyBucketBurstKey:
+		return "PC_MOBILE_LEAKY_BUCKET_BURST"
+	case common.PortalMaxSessionsKey:
+		return "PC_PORTAL_MAX_SESSIONS"
+	case common.TrustedProxiesKey:
+		return "PC_TRUSTED_PROXIES"
+	case common.VerifyReceiptPreviousKeysKey:
+		return "PC_VERIFY_RECEIPT_PREVIOUS_KEYS"
 	default:
 		return ""
 	}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	// HTTP/2 spec recommends at least 100 streams
+	minConcurrentStreams = 100
+	maxConcurrentStreams = 10_000
+)
+
+// ServerProfile describes connection handling of a listener
+type ServerProfile struct {
+	// HTTP/2 is only negotiated (via ALPN) on TLS listeners
+	HTTP2                bool
+	MaxConcurrentStreams int
+	// TCP keepalive period of accepted connections, 0 disables keepalives
+	KeepAlive   time.Duration
+	IdleTimeout time.Duration
+}
+
+var (
+	// DefaultServerProfile is used for the main (shared) and CDN listeners
+	DefaultServerProfile = ServerProfile{
+		HTTP2:                true,
+		MaxConcurrentStreams: 250,
+		KeepAlive:            15 * time.Second,
+		IdleTimeout:          60 * time.Second,
+	}
+	// API is called by widgets from many short-lived clients, but also by few reverse proxies that multiplex
+	// a lot of requests, so idle connections are released early and streams limit is higher
+	DefaultAPIProfile = ServerProfile{
+		HTTP2:                true,
+		MaxConcurrentStreams: 500,
+		KeepAlive:            15 * time.Second,
+		IdleTimeout:          30 * time.Second,
+	}
+	// portal users keep browser tabs open and navigate with htmx, so connections are kept around longer
+	DefaultPortalProfile = ServerProfile{
+		HTTP2:                true,
+		MaxConcurrentStreams: 100,
+		KeepAlive:            30 * time.Second,
+		IdleTimeout:          120 * time.Second,
+	}
+)
+
+// ListenConfig returns config for the TCP listener of the profile
+func (p ServerProfile) ListenConfig() *net.ListenConfig {
+	lc := &net.ListenConfig{KeepAlive: p.KeepAlive}
+	if p.KeepAlive == 0 {
+		// for net package zero means default period
+		lc.KeepAlive = -1
+	}
+
+	return lc
+}
+
+// NextProtos returns ALPN protocols for TLS listener. http.Server.Serve() only speaks HTTP/2 if it was
+// negotiated by the listener itself
+func (p ServerProfile) NextProtos() []string {
+	if p.HTTP2 {
+		return []string{http2.NextProtoTLS, "http/1.1"}
+	}
+
+	return []string{"http/1.1"}
+}
+
+// Configure applies idle timeout and HTTP/2 settings of the profile to the server
+func (p ServerProfile) Configure(server *http.Server) error {
+	server.IdleTimeout = p.IdleTimeout
+
+	if !p.HTTP2 {
+		// non-nil empty map disables HTTP/2 even if it was negotiated
+		server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		return nil
+	}
+
+	return http2.ConfigureServer(server, &http2.Server{
+		MaxConcurrentStreams: uint32(p.MaxConcurrentStreams),
+		IdleTimeout:          p.IdleTimeout,
+	})
+}
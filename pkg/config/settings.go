@@ -0,0 +1,219 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultHost = "localhost"
+	defaultPort = 8080
+	// puzzles are pre-generated per property and difficulty, so this is multiplied by the number of active pairs
+	maxPuzzlePoolSize = 1_000
+)
+
+var (
+	errRequired     = errors.New("value is required")
+	errOutOfRange   = errors.New("value is out of range")
+	errTLSKeyPair   = errors.New("both certificate and key files are required for TLS")
+	errNoListenAddr = errors.New("listen address is required for TLS")
+	errNoSpillDir   = errors.New("spill directory is required")
+)
+
+// ListenerSettings describe optional dedicated listener of a service (API, portal or CDN)
+type ListenerSettings struct {
+	// empty address means that the service is served by the main listener
+	Address  string
+	CertFile string
+	KeyFile  string
+}
+
+// Settings are typed configuration values that are only read at startup. They are loaded and validated once
+// so that subsystems do not need to parse strings. Values that can be changed on reload (e.g. maintenance mode
+// or rate limits) are still read from common.ConfigStore.
+type Settings struct {
+	Stage   string
+	Verbose bool
+	// address of the main listener
+	ListenAddress     string
+	APIURL            *urlConfig
+	PortalURL         *urlConfig
+	CDNURL            *urlConfig
+	APIListener       ListenerSettings
+	PortalListener    ListenerSettings
+	CDNListener       ListenerSettings
+	LocalAddress      string
+	CacheInvalidation bool
+	// 0 disables the pool
+	PuzzlePoolSize   int
+	CountryHeader    string
+	LicenseReportURL string
+	// policy for verify records when verify log pipeline is overloaded
+	VerifyLogOverflow string
+	VerifyLogSpillDir string
+	// outgoing emails are queued in Postgres instead of memory (and survive restarts)
+	EmailQueuePersist bool
+	// properties serve puzzles only after their domain was verified via DNS
+	VerifiedDomains bool
+	// connection handling of dedicated API and portal listeners
+	APIProfile    ServerProfile
+	PortalProfile ServerProfile
+}
+
+// settingsLoader accumulates all validation errors so that they can be reported at once
+type settingsLoader struct {
+	cfg    common.ConfigStore
+	mapper ConfigMapper
+	errs   []error
+}
+
+func (l *settingsLoader) fail(key common.ConfigKey, err error) {
+	l.errs = append(l.errs, fmt.Errorf("%s: %w", l.mapper(key), err))
+}
+
+func (l *settingsLoader) str(key common.ConfigKey, required bool, validate func(string) error) string {
+	value := strings.TrimSpace(l.cfg.Get(key).Value())
+	if len(value) == 0 {
+		if required {
+			l.fail(key, errRequired)
+		}
+		return ""
+	}
+
+	if validate != nil {
+		if err := validate(value); err != nil {
+			l.fail(key, err)
+			return ""
+		}
+	}
+
+	return value
+}
+
+func (l *settingsLoader) boolean(key common.ConfigKey) bool {
+	value := l.str(key, false /*required*/, validateBool)
+	return common.EnvToBool(value)
+}
+
+func (l *settingsLoader) booleanOr(key common.ConfigKey, fallback bool) bool {
+	value := l.str(key, false /*required*/, validateBool)
+	if len(value) == 0 {
+		return fallback
+	}
+
+	return common.EnvToBool(value)
+}
+
+func (l *settingsLoader) integer(key common.ConfigKey, fallback, minValue, maxValue int) int {
+	value := l.str(key, false /*required*/, validateInt)
+	if len(value) == 0 {
+		return fallback
+	}
+
+	i, _ := strconv.Atoi(value)
+	if (i < minValue) || (i > maxValue) {
+		l.fail(key, errOutOfRange)
+		return fallback
+	}
+
+	return i
+}
+
+func (l *settingsLoader) duration(key common.ConfigKey, fallback, minValue, maxValue time.Duration) time.Duration {
+	value := l.str(key, false /*required*/, validateDuration)
+	if len(value) == 0 {
+		return fallback
+	}
+
+	d, _ := time.ParseDuration(value)
+	if (d < minValue) || (d > maxValue) {
+		l.fail(key, errOutOfRange)
+		return fallback
+	}
+
+	return d
+}
+
+func (l *settingsLoader) profile(http2Key, streamsKey, keepAliveKey, idleKey common.ConfigKey, fallback ServerProfile) ServerProfile {
+	return ServerProfile{
+		HTTP2:                l.booleanOr(http2Key, fallback.HTTP2),
+		MaxConcurrentStreams: l.integer(streamsKey, fallback.MaxConcurrentStreams, minConcurrentStreams, maxConcurrentStreams),
+		KeepAlive:            l.duration(keepAliveKey, fallback.KeepAlive, 0, 10*time.Minute),
+		IdleTimeout:          l.duration(idleKey, fallback.IdleTimeout, time.Second, time.Hour),
+	}
+}
+
+func (l *settingsLoader) baseURL(ctx context.Context, key common.ConfigKey) *urlConfig {
+	value := l.str(key, true /*required*/, validateBaseURL)
+	return AsURL(ctx, &envConfigValue{key: key, value: value})
+}
+
+func (l *settingsLoader) listener(addressKey, certKey, keyKey common.ConfigKey) ListenerSettings {
+	ls := ListenerSettings{
+		Address:  l.str(addressKey, false /*required*/, validateHostPort),
+		CertFile: l.str(certKey, false /*required*/, nil),
+		KeyFile:  l.str(keyKey, false /*required*/, nil),
+	}
+
+	if (len(ls.CertFile) == 0) != (len(ls.KeyFile) == 0) {
+		l.fail(certKey, errTLSKeyPair)
+	} else if (len(ls.CertFile) > 0) && (len(ls.Address) == 0) {
+		l.fail(addressKey, errNoListenAddr)
+	}
+
+	return ls
+}
+
+// LoadSettings parses and validates startup configuration. Returned error lists all invalid values
+func LoadSettings(ctx context.Context, cfg common.ConfigStore, mapper ConfigMapper) (*Settings, error) {
+	l := &settingsLoader{cfg: cfg, mapper: mapper}
+
+	host := l.str(common.HostKey, false /*required*/, nil)
+	if len(host) == 0 {
+		host = defaultHost
+	}
+	port := l.integer(common.PortKey, defaultPort, 1, 65535)
+
+	s := &Settings{
+		Stage:             l.str(common.StageKey, true /*required*/, nil),
+		Verbose:           l.boolean(common.VerboseKey),
+		ListenAddress:     net.JoinHostPort(host, strconv.Itoa(port)),
+		APIURL:            l.baseURL(ctx, common.APIBaseURLKey),
+		PortalURL:         l.baseURL(ctx, common.PortalBaseURLKey),
+		CDNURL:            l.baseURL(ctx, common.CDNBaseURLKey),
+		APIListener:       l.listener(common.APIListenAddressKey, common.APITLSCertFileKey, common.APITLSKeyFileKey),
+		PortalListener:    l.listener(common.PortalListenAddressKey, common.PortalTLSCertFileKey, common.PortalTLSKeyFileKey),
+		CDNListener:       l.listener(common.CDNListenAddressKey, common.CDNTLSCertFileKey, common.CDNTLSKeyFileKey),
+		LocalAddress:      l.str(common.LocalAddressKey, false /*required*/, validateHostPort),
+		CacheInvalidation: l.boolean(common.CacheInvalidationKey),
+		PuzzlePoolSize:    l.integer(common.PuzzlePoolSizeKey, 0, 0, maxPuzzlePoolSize),
+		CountryHeader:     l.str(common.CountryHeaderKey, false /*required*/, nil),
+		LicenseReportURL:  l.str(common.LicenseReportURLKey, false /*required*/, validateURL("https")),
+		VerifyLogOverflow: l.str(common.VerifyLogOverflowKey, false /*required*/, validateOneOf("drop", "spill")),
+		VerifyLogSpillDir: l.str(common.VerifyLogSpillDirKey, false /*required*/, nil),
+		EmailQueuePersist: l.boolean(common.EmailQueuePersistKey),
+		VerifiedDomains:   l.boolean(common.VerifiedDomainsKey),
+	}
+
+	s.APIProfile = l.profile(common.APIHTTP2Key, common.APIMaxStreamsKey, common.APIKeepAliveKey,
+		common.APIIdleTimeoutKey, DefaultAPIProfile)
+	s.PortalProfile = l.profile(common.PortalHTTP2Key, common.PortalMaxStreamsKey, common.PortalKeepAliveKey,
+		common.PortalIdleTimeoutKey, DefaultPortalProfile)
+
+	if (s.VerifyLogOverflow == "spill") && (len(s.VerifyLogSpillDir) == 0) {
+		l.fail(common.VerifyLogOverflowKey, errNoSpillDir)
+	}
+
+	if len(l.errs) > 0 {
+		return nil, errors.Join(l.errs...)
+	}
+
+	return s, nil
+}
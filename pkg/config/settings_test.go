@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTestSettings(env map[string]string) (*Settings, error) {
+	cfg := NewEnvConfig(DefaultMapper, func(key string) string { return env[key] })
+	return LoadSettings(context.TODO(), cfg, DefaultMapper)
+}
+
+func TestLoadValidSettings(t *testing.T) {
+	env := validTestEnv()
+	env["PC_VERBOSE"] = "yes"
+	env["PC_PUZZLE_POOL_SIZE"] = "100"
+
+	settings, err := loadTestSettings(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (settings.Stage != "test") || !settings.Verbose || (settings.PuzzlePoolSize != 100) {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+
+	if settings.ListenAddress != "localhost:8080" {
+		t.Errorf("Unexpected listen address: %v", settings.ListenAddress)
+	}
+
+	if (settings.PortalURL.Domain() != "portal.privatecaptcha.local") || (settings.APIURL.URL() != "//api.privatecaptcha.local") {
+		t.Errorf("Unexpected URLs: %v %v", settings.PortalURL.Domain(), settings.APIURL.URL())
+	}
+
+	if len(settings.APIListener.Address) > 0 {
+		t.Errorf("Unexpected dedicated API listener: %v", settings.APIListener.Address)
+	}
+}
+
+func TestLoadInvalidSettings(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+	}{
+		{"STAGE", ""},
+		{"PC_API_BASE_URL", ""},
+		{"PC_CDN_BASE_URL", "https://cdn.privatecaptcha.local"},
+		{"PC_PORT", "70000"},
+		{"PC_VERBOSE", "maybe"},
+		{"PC_PUZZLE_POOL_SIZE", "-1"},
+		{"PC_LOCAL_ADDRESS", "localhost"},
+		{"PC_API_TLS_CERT_FILE", "cert.pem"},
+		{"PC_VERIFY_LOG_OVERFLOW", "block"},
+		{"PC_API_HTTP2", "maybe"},
+		{"PC_API_MAX_CONCURRENT_STREAMS", "10"},
+		{"PC_PORTAL_TCP_KEEPALIVE", "1 minute"},
+		{"PC_PORTAL_IDLE_TIMEOUT", "2h"},
+		// spill directory is not set
+		{"PC_VERIFY_LOG_OVERFLOW", "spill"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := validTestEnv()
+			env[tc.name] = tc.value
+
+			_, err := loadTestSettings(env)
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+
+			if !strings.Contains(err.Error(), tc.name) {
+				t.Errorf("Error does not mention %v: %v", tc.name, err)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsReportsAllErrors(t *testing.T) {
+	env := validTestEnv()
+	env["PC_PORT"] = "abc"
+	env["PC_PUZZLE_POOL_SIZE"] = "1000000"
+
+	_, err := loadTestSettings(env)
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+
+	for _, name := range []string{"PC_PORT", "PC_PUZZLE_POOL_SIZE"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Error does not mention %v: %v", name, err)
+		}
+	}
+}
+
+func TestLoadServerProfiles(t *testing.T) {
+	env := validTestEnv()
+	env["PC_API_HTTP2"] = "false"
+	env["PC_API_MAX_CONCURRENT_STREAMS"] = "1000"
+	env["PC_PORTAL_TCP_KEEPALIVE"] = "0s"
+	env["PC_PORTAL_IDLE_TIMEOUT"] = "5m"
+
+	settings, err := loadTestSettings(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if settings.APIProfile.HTTP2 || (settings.APIProfile.MaxConcurrentStreams != 1000) ||
+		(settings.APIProfile.IdleTimeout != DefaultAPIProfile.IdleTimeout) {
+		t.Errorf("Unexpected API profile: %+v", settings.APIProfile)
+	}
+
+	if !settings.PortalProfile.HTTP2 || (settings.PortalProfile.KeepAlive != 0) ||
+		(settings.PortalProfile.IdleTimeout != 5*time.Minute) {
+		t.Errorf("Unexpected portal profile: %+v", settings.PortalProfile)
+	}
+
+	if lc := settings.PortalProfile.ListenConfig(); lc.KeepAlive >= 0 {
+		t.Errorf("Keepalives are not disabled: %v", lc.KeepAlive)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const redactedValue = "[redacted]"
+
+// secretKeys are never included in config snapshot as is
+var secretKeys = map[common.ConfigKey]struct{}{
+	common.PostgresPasswordKey:            {},
+	common.PostgresAdminPasswordKey:       {},
+	common.ClickHousePasswordKey:          {},
+	common.ClickHouseAdminPasswordKey:     {},
+	common.ClickHouseSecondaryPasswordKey: {},
+	common.SmtpPasswordKey:                {},
+	common.UserFingerprintIVKey:           {},
+	common.APISaltKey:                     {},
+	common.MetricsExportPasswordKey:       {},
+	common.MetricsExportTokenKey:          {},
+	common.KMSTokenKey:                    {},
+	common.VerifyReceiptKey:               {},
+	common.LocalAuthTokenKey:              {},
+	common.ProvisioningAPIKeyKey:          {},
+	// webhook URLs usually contain the token in the path
+	common.AlertWebhookURLKey:          {},
+	common.WarehouseExportAccessKeyKey: {},
+	common.WarehouseExportSecretKeyKey: {},
+	common.SupportInboundKeyKey:        {},
+}
+
+// redactURL hides password in URL values (e.g. Postgres connection string)
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		// we cannot tell which part is a password
+		return redactedValue
+	}
+
+	return u.Redacted()
+}
+
+// Snapshot returns current values of all known configuration keys (by environment name) with secrets redacted.
+// Empty values are kept so that it's visible which secrets are not configured
+func Snapshot(cfg common.ConfigStore, mapper ConfigMapper) map[string]string {
+	result := make(map[string]string, int(common.COMMON_CONFIG_KEYS_COUNT))
+
+	for key := common.ConfigKey(0); key < common.COMMON_CONFIG_KEYS_COUNT; key++ {
+		name := mapper(key)
+		if len(name) == 0 {
+			continue
+		}
+
+		value := cfg.Get(key).Value()
+		if len(value) > 0 {
+			if _, ok := secretKeys[key]; ok {
+				value = redactedValue
+			} else if strings.Contains(value, "://") {
+				value = redactURL(value)
+			}
+		}
+
+		result[name] = value
+	}
+
+	return result
+}
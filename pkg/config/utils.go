@@ -7,6 +7,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -25,10 +26,94 @@ func AsInt(item common.ConfigItem, fallback int) int {
 	}
 }
 
+func AsDuration(item common.ConfigItem, fallback time.Duration) time.Duration {
+	s := item.Value()
+	if len(s) == 0 {
+		return fallback
+	}
+
+	if d, err := time.ParseDuration(s); err != nil {
+		return fallback
+	} else {
+		return d
+	}
+}
+
 func AsBool(item common.ConfigItem) bool {
 	return common.EnvToBool(item.Value())
 }
 
+const (
+	MaintenancePortal  = "portal"
+	MaintenanceBilling = "billing"
+	MaintenanceStats   = "stats"
+	MaintenanceVerify  = "verify"
+)
+
+var maintenanceSubsystems = []string{MaintenancePortal, MaintenanceBilling, MaintenanceStats, MaintenanceVerify}
+
+// MaintenanceModes combines global maintenance mode with targeted maintenance of separate subsystems, so that
+// e.g. portal can be made read-only during Postgres migration while puzzles and verification keep working.
+// Global maintenance mode implies maintenance of every subsystem
+type MaintenanceModes struct {
+	// neither Postgres nor ClickHouse are used, portal is not available
+	All bool
+	// portal is read-only
+	Portal bool
+	// billing contacts and provisioning are not available
+	Billing bool
+	// ClickHouse is not used, so usage stats are neither recorded nor shown
+	Stats bool
+	// verification only uses cached properties and API keys and does not access Postgres
+	Verify bool
+}
+
+func AsMaintenanceModes(cfg common.ConfigStore) *MaintenanceModes {
+	all := AsBool(cfg.Get(common.MaintenanceModeKey))
+	modes := &MaintenanceModes{All: all, Portal: all, Billing: all, Stats: all, Verify: all}
+
+	for _, subsystem := range strings.Split(cfg.Get(common.MaintenanceSubsystemsKey).Value(), ",") {
+		switch strings.ToLower(strings.TrimSpace(subsystem)) {
+		case MaintenancePortal:
+			modes.Portal = true
+		case MaintenanceBilling:
+			modes.Billing = true
+		case MaintenanceStats:
+			modes.Stats = true
+		case MaintenanceVerify:
+			modes.Verify = true
+		}
+	}
+
+	return modes
+}
+
+// ParseDataRegions parses comma-separated "region=host" pairs of region-specific ClickHouse clusters,
+// e.g. "eu=clickhouse.eu.internal, us=clickhouse.us.internal"
+func ParseDataRegions(value string) (map[string]string, error) {
+	regions := make(map[string]string)
+
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); len(pair) == 0 {
+			continue
+		}
+
+		region, host, ok := strings.Cut(pair, "=")
+		region, host = strings.ToLower(strings.TrimSpace(region)), strings.TrimSpace(host)
+		if !ok || !common.IsValidDataRegion(region) || (len(host) == 0) {
+			return nil, fmt.Errorf("invalid data region: %q", pair)
+		}
+
+		if _, found := regions[region]; found {
+			return nil, fmt.Errorf("duplicate data region: %q", region)
+		}
+
+		regions[region] = host
+	}
+
+	return regions, nil
+}
+
 func splitHostPort(s string) (domain string, port string, err error) {
 	if len(s) == 0 {
 		return
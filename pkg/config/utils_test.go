@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
 func TestSplitHost(t *testing.T) {
@@ -26,3 +28,46 @@ func TestSplitHost(t *testing.T) {
 		})
 	}
 }
+
+func TestAsMaintenanceModes(t *testing.T) {
+	testCases := []struct {
+		all        string
+		subsystems string
+		expected   MaintenanceModes
+	}{
+		{"", "", MaintenanceModes{}},
+		{"true", "", MaintenanceModes{All: true, Portal: true, Billing: true, Stats: true, Verify: true}},
+		{"false", "portal", MaintenanceModes{Portal: true}},
+		{"", " Billing , stats,", MaintenanceModes{Billing: true, Stats: true}},
+		{"", "verify,unknown", MaintenanceModes{Verify: true}},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("maintenance_%v", i), func(t *testing.T) {
+			cfg := NewBaseConfig(nil)
+			cfg.Add(NewStaticValue(common.MaintenanceModeKey, tc.all))
+			cfg.Add(NewStaticValue(common.MaintenanceSubsystemsKey, tc.subsystems))
+
+			if actual := AsMaintenanceModes(cfg); *actual != tc.expected {
+				t.Errorf("Unexpected maintenance modes: %+v (expected %+v)", *actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseDataRegions(t *testing.T) {
+	regions, err := ParseDataRegions(" EU = ch-eu.internal, us-east=ch-us.internal,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(regions) != 2) || (regions["eu"] != "ch-eu.internal") || (regions["us-east"] != "ch-us.internal") {
+		t.Errorf("Unexpected regions: %v", regions)
+	}
+
+	for _, value := range []string{"eu", "eu=", "=host", "e u=host", "eu=a,eu=b"} {
+		if _, err := ParseDataRegions(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
@@ -23,6 +23,8 @@ var (
 	ErrMaintenance        = errors.New("maintenance mode")
 	ErrTestProperty       = errors.New("test property")
 	ErrPermissions        = errors.New("insufficient permissions")
+	ErrDuplicateEvent     = errors.New("event was already recorded")
+	ErrEmailVerified      = errors.New("email is already verified")
 	errInvalidCacheType   = errors.New("cache record type does not match")
 	TestPropertySitekey   = strings.ReplaceAll(TestPropertyID, "-", "")
 	PortalLoginSitekey    = strings.ReplaceAll(PortalLoginPropertyID, "-", "")
@@ -43,11 +45,14 @@ type BusinessStore struct {
 	Cache         common.Cache[CacheKey, any]
 	// this could have been a bloom/cuckoo filter with expiration, if they existed
 	puzzleCache     common.Cache[uint64, bool]
+	invalidator     *cacheInvalidator
+	snapshot        *propertySnapshot
 	MaintenanceMode atomic.Bool
 }
 
 type Implementor interface {
 	Impl() *BusinessStoreImpl
+	CacheOnlyImpl() *BusinessStoreImpl
 	WithTx(ctx context.Context, fn func(*BusinessStoreImpl) error) error
 	Ping(ctx context.Context) error
 	CheckPuzzleCached(ctx context.Context, p *puzzle.Puzzle) bool
@@ -58,33 +63,45 @@ var _ Implementor = (*BusinessStore)(nil)
 
 func NewBusiness(pool *pgxpool.Pool) *BusinessStore {
 	const maxCacheSize = 1_000_000
-	var cache common.Cache[CacheKey, any]
-	var err error
-	cache, err = NewMemoryCache[CacheKey, any](maxCacheSize, nil /*missing value*/)
-	if err != nil {
-		slog.Error("Failed to create memory cache", common.ErrAttr(err))
-		cache = NewStaticCache[CacheKey, any](maxCacheSize, nil /*missing value*/)
-	}
-
-	return NewBusinessEx(pool, cache)
+	const maxCacheBytes = 512 * 1024 * 1024
+	return NewBusinessEx(pool, NewBusinessCache(maxCacheSize, maxCacheBytes))
 }
 
 func NewBusinessEx(pool *pgxpool.Pool, cache common.Cache[CacheKey, any]) *BusinessStore {
 	const maxPuzzleCacheSize = 100_000
-	var puzzleCache common.Cache[uint64, bool]
-	var err error
-	puzzleCache, err = NewMemoryCache[uint64, bool](maxPuzzleCacheSize, false /*missing value*/)
-	if err != nil {
-		slog.Error("Failed to create puzzle memory cache", common.ErrAttr(err))
-		puzzleCache = NewStaticCache[uint64, bool](maxPuzzleCacheSize, false /*missing value*/)
-	}
+	puzzleCache := NewMemoryCache[uint64, bool](maxPuzzleCacheSize, false /*missing value*/, HashUint64)
+	invalidator := newCacheInvalidator(pool, cache)
+	snapshot := newPropertySnapshot(maxPropertySnapshotSize)
 
 	return &BusinessStore{
 		Pool:          pool,
-		defaultImpl:   &BusinessStoreImpl{cache: cache, querier: dbgen.New(pool), ttl: DefaultCacheTTL},
-		cacheOnlyImpl: &BusinessStoreImpl{cache: cache, ttl: DefaultCacheTTL},
+		defaultImpl:   &BusinessStoreImpl{cache: cache, querier: dbgen.New(&chaosDBTX{db: pool}), ttl: DefaultCacheTTL, invalidator: invalidator, snapshot: snapshot},
+		cacheOnlyImpl: &BusinessStoreImpl{cache: cache, ttl: DefaultCacheTTL, snapshot: snapshot},
 		Cache:         cache,
 		puzzleCache:   puzzleCache,
+		invalidator:   invalidator,
+		snapshot:      snapshot,
+	}
+}
+
+// StartCacheInvalidation makes this node broadcast changes of cached records to other nodes (and listen
+// to their changes) so that they do not serve stale data until cache expiration
+func (s *BusinessStore) StartCacheInvalidation(ctx context.Context) {
+	s.invalidator.start(ctx)
+}
+
+func (s *BusinessStore) StopCacheInvalidation() {
+	s.invalidator.stop()
+}
+
+// RegisterCacheMetrics exposes usage stats of the caches (if they are tracked)
+func (s *BusinessStore) RegisterCacheMetrics(metrics common.CacheStatsRegistry) {
+	if source, ok := s.Cache.(common.CacheStatsSource); ok {
+		metrics.RegisterCacheStats("business", source)
+	}
+
+	if source, ok := s.puzzleCache.(common.CacheStatsSource); ok {
+		metrics.RegisterCacheStats("puzzle", source)
 	}
 }
 
@@ -100,6 +117,11 @@ func (s *BusinessStore) Impl() *BusinessStoreImpl {
 	return s.defaultImpl
 }
 
+// CacheOnlyImpl never accesses Postgres, regardless of maintenance mode (e.g. for read-only maintenance of a subsystem)
+func (s *BusinessStore) CacheOnlyImpl() *BusinessStoreImpl {
+	return s.cacheOnlyImpl
+}
+
 func (s *BusinessStore) WithTx(ctx context.Context, fn func(*BusinessStoreImpl) error) error {
 	if s.MaintenanceMode.Load() {
 		return ErrMaintenance
@@ -115,9 +137,8 @@ func (s *BusinessStore) WithTx(ctx context.Context, fn func(*BusinessStoreImpl)
 		}
 	}()
 
-	db := dbgen.New(s.Pool)
 	tmpCache := NewTxCache()
-	impl := &BusinessStoreImpl{cache: tmpCache, querier: db.WithTx(tx), ttl: DefaultCacheTTL}
+	impl := &BusinessStoreImpl{cache: tmpCache, querier: dbgen.New(&chaosDBTX{db: tx}), ttl: DefaultCacheTTL, invalidator: s.invalidator}
 
 	err = fn(impl)
 
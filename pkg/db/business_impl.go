@@ -2,10 +2,13 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"log/slog"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -18,14 +21,17 @@ const (
 	// NOTE: this is the time during which changes to difficulty will propagate when we have multiple API nodes
 	propertyTTL = 30 * time.Minute
 	apiKeyTTL   = 30 * time.Minute
+	// hourly cache partitions to create in advance, has to cover the longest cache TTL (sessions)
+	cachePartitionsAhead = 6
 )
 
 var (
-	errUnsupported  = errors.New("not supported")
-	emptyOrgUsers   = []*dbgen.GetOrganizationUsersRow{}
-	emptyAPIKeys    = []*dbgen.APIKey{}
-	emptyUserOrgs   = []*dbgen.GetUserOrganizationsRow{}
-	emptyProperties = []*dbgen.Property{}
+	errUnsupported           = errors.New("not supported")
+	emptyOrgUsers            = []*dbgen.GetOrganizationUsersRow{}
+	emptyAPIKeys             = []*dbgen.APIKey{}
+	emptyUserOrgs            = []*dbgen.GetUserOrganizationsRow{}
+	emptyProperties          = []*dbgen.Property{}
+	emptyPropertyPermissions = []*dbgen.PropertyPermission{}
 	// shortcuts for nullable access levels
 	nullAccessLevelNull   = dbgen.NullAccessLevel{Valid: false}
 	nullAccessLevelOwner  = dbgen.NullAccessLevel{Valid: true, AccessLevel: dbgen.AccessLevelOwner}
@@ -112,9 +118,12 @@ func (c *TxCache) Commit(ctx context.Context, cache common.Cache[CacheKey, any])
 }
 
 type BusinessStoreImpl struct {
-	querier dbgen.Querier
-	cache   common.Cache[CacheKey, any]
-	ttl     time.Duration
+	querier     dbgen.Querier
+	cache       common.Cache[CacheKey, any]
+	ttl         time.Duration
+	invalidator *cacheInvalidator
+	// fallback for properties when Postgres is not available
+	snapshot *propertySnapshot
 }
 
 func (impl *BusinessStoreImpl) RetrieveFromCache(ctx context.Context, key string) ([]byte, error) {
@@ -162,12 +171,14 @@ func (impl *BusinessStoreImpl) ping(ctx context.Context) error {
 	return nil
 }
 
+// DeleteExpiredCache drops cache partitions with expired entries and creates partitions for the next hours. Entries
+// that expire later than that end up in the default partition until their partition is created
 func (impl *BusinessStoreImpl) DeleteExpiredCache(ctx context.Context) error {
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	return impl.querier.DeleteExpiredCache(ctx)
+	return impl.querier.DeleteExpiredCache(ctx, cachePartitionsAhead)
 }
 
 func (impl *BusinessStoreImpl) createNewSubscription(ctx context.Context, params *dbgen.CreateSubscriptionParams) (*dbgen.Subscription, error) {
@@ -342,16 +353,17 @@ func (impl *BusinessStoreImpl) RetrievePropertiesBySitekey(ctx context.Context,
 	}
 
 	if impl.querier == nil {
-		return result, ErrMaintenance
+		return impl.retrievePropertiesFromSnapshot(ctx, keysMap, result, ErrMaintenance)
 	}
 
 	properties, err := impl.querier.GetPropertiesByExternalID(ctx, keys)
 	if err != nil && err != pgx.ErrNoRows {
 		slog.ErrorContext(ctx, "Failed to retrieve properties by sitekeys", common.ErrAttr(err))
-		return nil, err
+		return impl.retrievePropertiesFromSnapshot(ctx, keysMap, result, err)
 	}
 
 	slog.DebugContext(ctx, "Fetched properties from DB by sitekeys", "count", len(properties))
+	impl.snapshot.add(properties, time.Now().UTC())
 
 	for _, p := range properties {
 		sitekey := UUIDToSiteKey(p.ExternalID)
@@ -360,6 +372,11 @@ func (impl *BusinessStoreImpl) RetrievePropertiesBySitekey(ctx context.Context,
 		delete(keysMap, sitekey)
 	}
 
+	if len(keysMap) > 0 {
+		rotated := impl.retrievePropertiesByPreviousSitekey(ctx, keysMap)
+		properties = append(properties, rotated...)
+	}
+
 	for missingKey := range keysMap {
 		_ = impl.cache.SetMissing(ctx, PropertyBySitekeyCacheKey(missingKey), impl.ttl)
 	}
@@ -369,6 +386,92 @@ func (impl *BusinessStoreImpl) RetrievePropertiesBySitekey(ctx context.Context,
 	return result, nil
 }
 
+// retrievePropertiesFromSnapshot is the degraded mode of RetrievePropertiesBySitekey when Postgres is not available.
+// Original error is returned unless all sitekeys are found in the snapshot, so unknown properties still get stub
+// puzzles and are not rejected
+func (impl *BusinessStoreImpl) retrievePropertiesFromSnapshot(ctx context.Context, keysMap map[string]bool, result []*dbgen.Property, dbErr error) ([]*dbgen.Property, error) {
+	missing := 0
+
+	for sitekey := range keysMap {
+		property, ok := impl.snapshot.get(sitekey)
+		if !ok {
+			missing++
+			continue
+		}
+
+		_ = impl.cache.Set(ctx, PropertyBySitekeyCacheKey(sitekey), property, propertySnapshotCacheTTL)
+		result = append(result, property)
+	}
+
+	slog.WarnContext(ctx, "Retrieved properties from snapshot", "found", len(keysMap)-missing, "missing", missing, common.ErrAttr(dbErr))
+
+	if missing > 0 {
+		return result, dbErr
+	}
+
+	return result, nil
+}
+
+// RefreshPropertySnapshot re-reads snapshotted properties from Postgres so that the snapshot does not serve outdated
+// settings during an outage. Snapshot is left intact when Postgres is not available
+func (impl *BusinessStoreImpl) RefreshPropertySnapshot(ctx context.Context, tnow time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	const batchSize = 1000
+	keys, evicted := impl.snapshot.evict(tnow.Add(-propertySnapshotRetention))
+
+	for chunk := range slices.Chunk(keys, batchSize) {
+		properties, err := impl.querier.GetPropertiesByExternalID(ctx, chunk)
+		if err != nil && err != pgx.ErrNoRows {
+			slog.ErrorContext(ctx, "Failed to refresh property snapshot", common.ErrAttr(err))
+			return err
+		}
+
+		impl.snapshot.refresh(chunk, properties)
+	}
+
+	slog.DebugContext(ctx, "Refreshed property snapshot", "refreshed", len(keys), "evicted", evicted, "size", impl.snapshot.size())
+
+	return nil
+}
+
+// retrievePropertiesByPreviousSitekey resolves sitekeys that were rotated recently (and are still in the overlap
+// window) and removes found ones from keysMap. Previous sitekey is cached no longer than it stays valid
+func (impl *BusinessStoreImpl) retrievePropertiesByPreviousSitekey(ctx context.Context, keysMap map[string]bool) []*dbgen.Property {
+	keys := make([]pgtype.UUID, 0, len(keysMap))
+	for sitekey := range keysMap {
+		keys = append(keys, UUIDFromSiteKey(sitekey))
+	}
+
+	rows, err := impl.querier.GetPropertiesByPreviousExternalID(ctx, keys)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			slog.ErrorContext(ctx, "Failed to retrieve properties by previous sitekeys", common.ErrAttr(err))
+		}
+		return nil
+	}
+
+	tnow := time.Now()
+	result := make([]*dbgen.Property, 0, len(rows))
+
+	for _, row := range rows {
+		property := &row.Property
+		sitekey := UUIDToSiteKey(row.PreviousExternalID)
+		ttl := min(propertyTTL, row.ExpiresAt.Time.Sub(tnow))
+		if ttl > 0 {
+			_ = impl.cache.Set(ctx, PropertyBySitekeyCacheKey(sitekey), property, ttl)
+		}
+		delete(keysMap, sitekey)
+		result = append(result, property)
+	}
+
+	slog.DebugContext(ctx, "Fetched properties from DB by previous sitekeys", "count", len(result))
+
+	return result
+}
+
 func (impl *BusinessStoreImpl) GetCachedAPIKey(ctx context.Context, secret string) (*dbgen.APIKey, error) {
 	cacheKey := APIKeyCacheKey(secret)
 
@@ -804,892 +907,3526 @@ func (impl *BusinessStoreImpl) UpdateProperty(ctx context.Context, params *dbgen
 	// invalidate org properties in cache as we just created a new property
 	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(property.OrgID.Int32))
 
+	impl.notifyCacheInvalidation(ctx, cacheBySitekeyKey, cacheByIDKey, orgPropertiesCacheKey(property.OrgID.Int32))
+
 	return property, nil
 }
 
-func (impl *BusinessStoreImpl) SoftDeleteProperty(ctx context.Context, propID int32, orgID int32) error {
+// UpdatePropertyPaused pauses or resumes puzzle issuance for the property, settings and stats are kept intact
+func (impl *BusinessStoreImpl) UpdatePropertyPaused(ctx context.Context, propID int32, paused bool) (*dbgen.Property, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	property, err := impl.querier.SoftDeleteProperty(ctx, propID)
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to mark property as deleted in DB", "propID", propID, common.ErrAttr(err))
-		return err
+	var pausedAt pgtype.Timestamptz
+	if paused {
+		pausedAt = Timestampz(time.Now().UTC())
 	}
 
-	slog.DebugContext(ctx, "Soft-deleted property", "propID", propID)
+	property, err := impl.querier.UpdatePropertyPaused(ctx, &dbgen.UpdatePropertyPausedParams{
+		ID:       propID,
+		PausedAt: pausedAt,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
 
-	// update caches
-	sitekey := UUIDToSiteKey(property.ExternalID)
-	// cache mostly used in API server
-	_ = impl.cache.SetMissing(ctx, PropertyBySitekeyCacheKey(sitekey), impl.ttl)
-	_ = impl.cache.SetMissing(ctx, propertyByIDCacheKey(propID), impl.ttl)
-	// invalidate org properties in cache as we just deleted a property
-	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(orgID))
+		slog.ErrorContext(ctx, "Failed to update property paused state", "propID", propID, "paused", paused, common.ErrAttr(err))
+		return nil, err
+	}
 
-	return nil
-}
+	slog.DebugContext(ctx, "Updated property paused state", "propID", propID, "paused", paused)
 
-func (impl *BusinessStoreImpl) RetrieveOrgProperties(ctx context.Context, orgID int32) ([]*dbgen.Property, error) {
-	cacheKey := orgPropertiesCacheKey(orgID)
+	impl.cacheUpdatedProperty(ctx, property)
 
-	if properties, err := fetchCachedMany[dbgen.Property](ctx, impl.cache, cacheKey); err == nil {
-		return properties, nil
-	}
+	return property, nil
+}
 
+// UpdatePropertyAppIDs sets native apps that can request puzzles without Origin header (empty list disables mobile mode)
+func (impl *BusinessStoreImpl) UpdatePropertyAppIDs(ctx context.Context, propID int32, appIDs []string) (*dbgen.Property, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	properties, err := impl.querier.GetOrgProperties(ctx, Int(orgID))
+	if appIDs == nil {
+		appIDs = []string{}
+	}
+
+	property, err := impl.querier.UpdatePropertyAppIDs(ctx, &dbgen.UpdatePropertyAppIDsParams{
+		ID:            propID,
+		AllowedAppIDs: appIDs,
+	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			_ = impl.cache.Set(ctx, cacheKey, emptyProperties, impl.ttl)
-			return emptyProperties, nil
+			return nil, ErrRecordNotFound
 		}
 
-		slog.ErrorContext(ctx, "Failed to retrieve org properties", "org", orgID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to update property app IDs", "propID", propID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.Log(ctx, common.LevelTrace, "Retrieved properties", "count", len(properties))
-	if len(properties) > 0 {
-		_ = impl.cache.Set(ctx, cacheKey, properties, impl.ttl)
-	}
+	slog.DebugContext(ctx, "Updated property app IDs", "propID", propID, "count", len(appIDs))
 
-	return properties, err
+	impl.cacheUpdatedProperty(ctx, property)
+
+	return property, nil
 }
 
-func (impl *BusinessStoreImpl) UpdateOrganization(ctx context.Context, orgID int32, name string) (*dbgen.Organization, error) {
+// cacheUpdatedProperty replaces cached property (including the one used by API) after its state was changed
+func (impl *BusinessStoreImpl) cacheUpdatedProperty(ctx context.Context, property *dbgen.Property) {
+	sitekey := UUIDToSiteKey(property.ExternalID)
+	cacheBySitekeyKey := PropertyBySitekeyCacheKey(sitekey)
+	_ = impl.cache.Set(ctx, cacheBySitekeyKey, property, propertyTTL)
+
+	cacheByIDKey := propertyByIDCacheKey(property.ID)
+	_ = impl.cache.Set(ctx, cacheByIDKey, property, impl.ttl)
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(property.OrgID.Int32))
+
+	impl.notifyCacheInvalidation(ctx, cacheBySitekeyKey, cacheByIDKey, orgPropertiesCacheKey(property.OrgID.Int32))
+}
+
+// UpdatePropertyDomainVerified marks the property domain as verified (or not) by the DNS challenge
+func (impl *BusinessStoreImpl) UpdatePropertyDomainVerified(ctx context.Context, propID int32, verified bool) (*dbgen.Property, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	org, err := impl.querier.UpdateOrganization(ctx, &dbgen.UpdateOrganizationParams{
-		Name: name,
-		ID:   orgID,
-	})
+	var verifiedAt pgtype.Timestamptz
+	if verified {
+		verifiedAt = Timestampz(time.Now().UTC())
+	}
 
+	property, err := impl.querier.UpdatePropertyDomainVerified(ctx, &dbgen.UpdatePropertyDomainVerifiedParams{
+		ID:               propID,
+		DomainVerifiedAt: verifiedAt,
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to update org in DB", "name", name, "orgID", orgID, common.ErrAttr(err))
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to update property domain verification", "propID", propID, "verified", verified, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Updated organization", "name", name, "orgID", orgID)
+	slog.InfoContext(ctx, "Updated property domain verification", "propID", propID, "domain", property.Domain, "verified", verified)
 
-	cacheKey := orgCacheKey(org.ID)
-	_ = impl.cache.Set(ctx, cacheKey, org, impl.ttl)
-	// invalidate user orgs in cache as we just updated name
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(org.UserID.Int32))
+	impl.cacheUpdatedProperty(ctx, property)
 
-	return org, nil
+	return property, nil
 }
 
-func (impl *BusinessStoreImpl) SoftDeleteOrganization(ctx context.Context, orgID int32, userID int32) error {
-	if impl.querier == nil {
-		return ErrMaintenance
+// CreateDomainVerification returns DNS challenge of the property, creating it with the token if it does not exist yet
+func (impl *BusinessStoreImpl) CreateDomainVerification(ctx context.Context, propID int32, token string) (*dbgen.DomainVerification, error) {
+	if len(token) == 0 {
+		return nil, ErrInvalidInput
 	}
 
-	if err := impl.querier.SoftDeleteUserOrganization(ctx, &dbgen.SoftDeleteUserOrganizationParams{
-		ID:     orgID,
-		UserID: Int(userID),
-	}); err != nil {
-		slog.ErrorContext(ctx, "Failed to mark organization as deleted in DB", "orgID", orgID, common.ErrAttr(err))
-		return err
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	slog.DebugContext(ctx, "Soft-deleted organization", "orgID", orgID)
-
-	// update caches
-	_ = impl.cache.SetMissing(ctx, orgCacheKey(orgID), impl.ttl)
-	// invalidate user orgs in cache as we just deleted one
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+	verification, err := impl.querier.CreateDomainVerification(ctx, &dbgen.CreateDomainVerificationParams{
+		PropertyID: propID,
+		Token:      token,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create domain verification", "propID", propID, common.ErrAttr(err))
+		return nil, err
+	}
 
-	return nil
+	return verification, nil
 }
 
-// NOTE: by definition this does not include the owner as this relationship is set directly in the 'organizations' table
-func (impl *BusinessStoreImpl) RetrieveOrganizationUsers(ctx context.Context, orgID int32) ([]*dbgen.GetOrganizationUsersRow, error) {
-	cacheKey := orgUsersCacheKey(orgID)
-
-	if users, err := fetchCachedMany[dbgen.GetOrganizationUsersRow](ctx, impl.cache, cacheKey); err == nil {
-		return users, nil
-	}
-
+func (impl *BusinessStoreImpl) RetrieveDomainVerification(ctx context.Context, propID int32) (*dbgen.DomainVerification, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	users, err := impl.querier.GetOrganizationUsers(ctx, orgID)
+	verification, err := impl.querier.GetDomainVerification(ctx, propID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			_ = impl.cache.Set(ctx, cacheKey, emptyOrgUsers, impl.ttl)
-			return emptyOrgUsers, nil
+			return nil, ErrRecordNotFound
 		}
-		slog.ErrorContext(ctx, "Failed to fetch organization users", "orgID", orgID, common.ErrAttr(err))
-		return nil, err
-	}
-
-	slog.DebugContext(ctx, "Fetched organization users", "orgID", orgID, "count", len(users))
 
-	if len(users) > 0 {
-		_ = impl.cache.Set(ctx, cacheKey, users, impl.ttl)
+		slog.ErrorContext(ctx, "Failed to retrieve domain verification", "propID", propID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	return users, nil
+	return verification, nil
 }
 
-func (impl *BusinessStoreImpl) InviteUserToOrg(ctx context.Context, orgID int32, userID int32) error {
+// RetrievePendingDomainVerifications returns challenges of unverified properties that were not checked since {before}
+func (impl *BusinessStoreImpl) RetrievePendingDomainVerifications(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetPendingDomainVerificationsRow, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	_, err := impl.querier.InviteUserToOrg(ctx, &dbgen.InviteUserToOrgParams{
-		OrgID:  orgID,
-		UserID: userID,
+	rows, err := impl.querier.GetPendingDomainVerifications(ctx, &dbgen.GetPendingDomainVerificationsParams{
+		CheckedAt: Timestampz(before),
+		Limit:     int32(limit),
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to add user to org", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve pending domain verifications", common.ErrAttr(err))
+		return nil, err
 	}
 
-	// invalidate relevant caches
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
-	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
-
-	slog.DebugContext(ctx, "Added org membership invite", "orgID", orgID, "userID", userID)
-
-	return nil
+	return rows, nil
 }
 
-func (impl *BusinessStoreImpl) JoinOrg(ctx context.Context, orgID int32, userID int32) error {
+// UpdateDomainVerificationCheck records the result of the DNS check (empty error means success)
+func (impl *BusinessStoreImpl) UpdateDomainVerificationCheck(ctx context.Context, propID int32, lastError string) error {
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	err := impl.querier.UpdateOrgMembershipLevel(ctx, &dbgen.UpdateOrgMembershipLevelParams{
-		OrgID:   orgID,
-		UserID:  userID,
-		Level:   dbgen.AccessLevelMember,
-		Level_2: dbgen.AccessLevelInvited,
+	err := impl.querier.UpdateDomainVerificationCheck(ctx, &dbgen.UpdateDomainVerificationCheckParams{
+		PropertyID: propID,
+		LastError:  lastError,
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to accept org invite", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to update domain verification check", "propID", propID, common.ErrAttr(err))
 		return err
 	}
 
-	slog.DebugContext(ctx, "Accepted org invite", "orgID", orgID, "userID", userID)
-
-	// invalidate relevant caches
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
-	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
-
 	return nil
 }
 
-func (impl *BusinessStoreImpl) LeaveOrg(ctx context.Context, orgID int32, userID int32) error {
+// CreatePropertyEvents records settings changes of the property made by the user (0 means the system)
+func (impl *BusinessStoreImpl) CreatePropertyEvents(ctx context.Context, propID int32, userID int32, events []*dbgen.PropertyEvent) error {
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	err := impl.querier.UpdateOrgMembershipLevel(ctx, &dbgen.UpdateOrgMembershipLevelParams{
-		OrgID:   orgID,
-		UserID:  userID,
-		Level:   dbgen.AccessLevelInvited,
-		Level_2: dbgen.AccessLevelMember,
-	})
+	if len(events) == 0 {
+		return nil
+	}
 
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to leave org", "orgID", orgID, "userID", userID, common.ErrAttr(err))
-		return err
+	params := &dbgen.CreatePropertyEventsParams{
+		PropertyID: propID,
+		UserID:     pgtype.Int4{Int32: userID, Valid: userID != 0},
+		Settings:   make([]string, 0, len(events)),
+		OldValues:  make([]string, 0, len(events)),
+		NewValues:  make([]string, 0, len(events)),
 	}
 
-	slog.DebugContext(ctx, "Left organization", "orgID", orgID, "userID", userID)
+	for _, e := range events {
+		params.Settings = append(params.Settings, e.Setting)
+		params.OldValues = append(params.OldValues, e.OldValue)
+		params.NewValues = append(params.NewValues, e.NewValue)
+	}
 
-	// invalidate relevant caches
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
-	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+	if err := impl.querier.CreatePropertyEvents(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to create property events", "propID", propID, "count", len(events), common.ErrAttr(err))
+		return err
+	}
 
 	return nil
 }
 
-func (impl *BusinessStoreImpl) RemoveUserFromOrg(ctx context.Context, orgID int32, userID int32) error {
+// RetrievePropertyEvents returns the most recent settings changes of the property
+func (impl *BusinessStoreImpl) RetrievePropertyEvents(ctx context.Context, propID int32, limit int) ([]*dbgen.GetPropertyEventsRow, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	err := impl.querier.RemoveUserFromOrg(ctx, &dbgen.RemoveUserFromOrgParams{
-		OrgID:  orgID,
-		UserID: userID,
+	events, err := impl.querier.GetPropertyEvents(ctx, &dbgen.GetPropertyEventsParams{
+		PropertyID: propID,
+		Limit:      int32(limit),
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to remove user from org", "orgID", orgID, "userID", userID, common.ErrAttr(err))
-		return err
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetPropertyEventsRow{}, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve property events", "propID", propID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Removed user from org", "orgID", orgID, "userID", userID)
-
-	// invalidate relevant caches
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
-	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
-
-	return nil
+	return events, nil
 }
 
-func (impl *BusinessStoreImpl) updateUserSubscription(ctx context.Context, userID, subscriptionID int32) error {
+// UpdatePropertySitekey replaces generated sitekey of the property with a well-known one (used to seed dev environments)
+func (impl *BusinessStoreImpl) UpdatePropertySitekey(ctx context.Context, propID int32, sitekey string) (*dbgen.Property, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	user, err := impl.querier.UpdateUserSubscription(ctx, &dbgen.UpdateUserSubscriptionParams{
-		ID:             userID,
-		SubscriptionID: Int(subscriptionID),
-	})
-
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to update user subscription", "userID", userID, "subscriptionID", subscriptionID, common.ErrAttr(err))
-		return err
+	externalID := UUIDFromSiteKey(sitekey)
+	if !externalID.Valid {
+		return nil, ErrInvalidInput
 	}
 
-	slog.DebugContext(ctx, "Updated user subscription", "userID", userID, "subscriptionID", subscriptionID)
+	property, err := impl.querier.UpdatePropertyExternalID(ctx, &dbgen.UpdatePropertyExternalIDParams{
+		ID:         propID,
+		ExternalID: externalID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
 
-	if user != nil {
-		_ = impl.cache.Set(ctx, userCacheKey(user.ID), user, impl.ttl)
+		slog.ErrorContext(ctx, "Failed to update property sitekey", "propID", propID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	return nil
-}
+	slog.DebugContext(ctx, "Updated property sitekey", "propID", propID, "sitekey", sitekey)
+
+	cacheByIDKey := propertyByIDCacheKey(property.ID)
+	_ = impl.cache.Set(ctx, cacheByIDKey, property, impl.ttl)
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(property.OrgID.Int32))
+
+	impl.notifyCacheInvalidation(ctx, cacheByIDKey, orgPropertiesCacheKey(property.OrgID.Int32))
+
+	return property, nil
+}
+
+// RotatePropertySitekey generates a new sitekey for the property while the current one keeps resolving to the
+// property until expiresAt. Both queries have to be executed in the same transaction
+func (impl *BusinessStoreImpl) RotatePropertySitekey(ctx context.Context, property *dbgen.Property, expiresAt time.Time) (*dbgen.Property, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	rotation, err := impl.querier.UpsertPropertySitekeyRotation(ctx, &dbgen.UpsertPropertySitekeyRotationParams{
+		PropertyID:         property.ID,
+		PreviousExternalID: property.ExternalID,
+		ExpiresAt:          Timestampz(expiresAt),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save previous property sitekey", "propID", property.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	rotated, err := impl.querier.RotatePropertyExternalID(ctx, property.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to rotate property sitekey", "propID", property.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Audit: rotated property sitekey", "propID", property.ID, "orgID", property.OrgID.Int32,
+		"expiresAt", expiresAt)
+
+	cacheBySitekeyKey := PropertyBySitekeyCacheKey(UUIDToSiteKey(rotated.ExternalID))
+	_ = impl.cache.Set(ctx, cacheBySitekeyKey, rotated, propertyTTL)
+
+	// previous sitekey resolves to the updated property right away so that new puzzles use the new sitekey
+	cacheByPreviousSitekeyKey := PropertyBySitekeyCacheKey(UUIDToSiteKey(rotation.PreviousExternalID))
+	if ttl := min(propertyTTL, time.Until(expiresAt)); ttl > 0 {
+		_ = impl.cache.Set(ctx, cacheByPreviousSitekeyKey, rotated, ttl)
+	}
+
+	cacheByIDKey := propertyByIDCacheKey(rotated.ID)
+	_ = impl.cache.Set(ctx, cacheByIDKey, rotated, impl.ttl)
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(rotated.OrgID.Int32))
+
+	impl.notifyCacheInvalidation(ctx, cacheBySitekeyKey, cacheByPreviousSitekeyKey, cacheByIDKey, orgPropertiesCacheKey(rotated.OrgID.Int32))
+
+	return rotated, nil
+}
+
+// RetrievePropertySitekeyRotation returns previous sitekey of the property if it is still in the overlap window
+func (impl *BusinessStoreImpl) RetrievePropertySitekeyRotation(ctx context.Context, propID int32) (*dbgen.PropertySitekeyRotation, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	rotation, err := impl.querier.GetPropertySitekeyRotation(ctx, propID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property sitekey rotation", "propID", propID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return rotation, nil
+}
+
+// ExpirePropertySitekeyRotation stops resolving previous sitekey of the property before the overlap window ends
+func (impl *BusinessStoreImpl) ExpirePropertySitekeyRotation(ctx context.Context, propID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	rotation, err := impl.querier.DeletePropertySitekeyRotation(ctx, propID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to delete property sitekey rotation", "propID", propID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Audit: expired previous property sitekey", "propID", propID)
+
+	cacheByPreviousSitekeyKey := PropertyBySitekeyCacheKey(UUIDToSiteKey(rotation.PreviousExternalID))
+	_ = impl.cache.Delete(ctx, cacheByPreviousSitekeyKey)
+
+	impl.notifyCacheInvalidation(ctx, cacheByPreviousSitekeyKey)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) SoftDeleteProperty(ctx context.Context, propID int32, orgID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	property, err := impl.querier.SoftDeleteProperty(ctx, propID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to mark property as deleted in DB", "propID", propID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Soft-deleted property", "propID", propID)
+
+	// update caches
+	sitekey := UUIDToSiteKey(property.ExternalID)
+	// cache mostly used in API server
+	_ = impl.cache.SetMissing(ctx, PropertyBySitekeyCacheKey(sitekey), impl.ttl)
+	_ = impl.cache.SetMissing(ctx, propertyByIDCacheKey(propID), impl.ttl)
+	// invalidate org properties in cache as we just deleted a property
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(orgID))
+
+	impl.notifyCacheInvalidation(ctx, PropertyBySitekeyCacheKey(sitekey), propertyByIDCacheKey(propID), orgPropertiesCacheKey(orgID))
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrgProperties(ctx context.Context, orgID int32) ([]*dbgen.Property, error) {
+	cacheKey := orgPropertiesCacheKey(orgID)
+
+	if properties, err := fetchCachedMany[dbgen.Property](ctx, impl.cache, cacheKey); err == nil {
+		return properties, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	properties, err := impl.querier.GetOrgProperties(ctx, Int(orgID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.Set(ctx, cacheKey, emptyProperties, impl.ttl)
+			return emptyProperties, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org properties", "org", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Retrieved properties", "count", len(properties))
+	if len(properties) > 0 {
+		_ = impl.cache.Set(ctx, cacheKey, properties, impl.ttl)
+	}
+
+	return properties, err
+}
+
+func (impl *BusinessStoreImpl) UpdateOrganization(ctx context.Context, orgID int32, name string) (*dbgen.Organization, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	org, err := impl.querier.UpdateOrganization(ctx, &dbgen.UpdateOrganizationParams{
+		Name: name,
+		ID:   orgID,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update org in DB", "name", name, "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated organization", "name", name, "orgID", orgID)
+
+	cacheKey := orgCacheKey(org.ID)
+	_ = impl.cache.Set(ctx, cacheKey, org, impl.ttl)
+	// invalidate user orgs in cache as we just updated name
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(org.UserID.Int32))
+
+	return org, nil
+}
+
+func (impl *BusinessStoreImpl) SoftDeleteOrganization(ctx context.Context, orgID int32, userID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.SoftDeleteUserOrganization(ctx, &dbgen.SoftDeleteUserOrganizationParams{
+		ID:     orgID,
+		UserID: Int(userID),
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark organization as deleted in DB", "orgID", orgID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Soft-deleted organization", "orgID", orgID)
+
+	// update caches
+	_ = impl.cache.SetMissing(ctx, orgCacheKey(orgID), impl.ttl)
+	// invalidate user orgs in cache as we just deleted one
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+
+	return nil
+}
+
+// NOTE: by definition this does not include the owner as this relationship is set directly in the 'organizations' table
+func (impl *BusinessStoreImpl) RetrieveOrganizationUsers(ctx context.Context, orgID int32) ([]*dbgen.GetOrganizationUsersRow, error) {
+	cacheKey := orgUsersCacheKey(orgID)
+
+	if users, err := fetchCachedMany[dbgen.GetOrganizationUsersRow](ctx, impl.cache, cacheKey); err == nil {
+		return users, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	users, err := impl.querier.GetOrganizationUsers(ctx, orgID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.Set(ctx, cacheKey, emptyOrgUsers, impl.ttl)
+			return emptyOrgUsers, nil
+		}
+		slog.ErrorContext(ctx, "Failed to fetch organization users", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched organization users", "orgID", orgID, "count", len(users))
+
+	if len(users) > 0 {
+		_ = impl.cache.Set(ctx, cacheKey, users, impl.ttl)
+	}
+
+	return users, nil
+}
+
+func (impl *BusinessStoreImpl) InviteUserToOrg(ctx context.Context, orgID int32, userID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	_, err := impl.querier.InviteUserToOrg(ctx, &dbgen.InviteUserToOrgParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add user to org", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+	}
+
+	// invalidate relevant caches
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+
+	slog.DebugContext(ctx, "Added org membership invite", "orgID", orgID, "userID", userID)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) JoinOrg(ctx context.Context, orgID int32, userID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.UpdateOrgMembershipLevel(ctx, &dbgen.UpdateOrgMembershipLevelParams{
+		OrgID:   orgID,
+		UserID:  userID,
+		Level:   dbgen.AccessLevelMember,
+		Level_2: dbgen.AccessLevelInvited,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to accept org invite", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Accepted org invite", "orgID", orgID, "userID", userID)
+
+	// invalidate relevant caches
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) LeaveOrg(ctx context.Context, orgID int32, userID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.UpdateOrgMembershipLevel(ctx, &dbgen.UpdateOrgMembershipLevelParams{
+		OrgID:   orgID,
+		UserID:  userID,
+		Level:   dbgen.AccessLevelInvited,
+		Level_2: dbgen.AccessLevelMember,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to leave org", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Left organization", "orgID", orgID, "userID", userID)
+
+	// invalidate relevant caches
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RemoveUserFromOrg(ctx context.Context, orgID int32, userID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.RemoveUserFromOrg(ctx, &dbgen.RemoveUserFromOrgParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to remove user from org", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Removed user from org", "orgID", orgID, "userID", userID)
+
+	// if user is invited again, they should not inherit previous permissions
+	if err := impl.querier.DeleteUserOrgPropertyPermissions(ctx, &dbgen.DeleteUserOrgPropertyPermissionsParams{
+		OrgID:  Int(orgID),
+		UserID: userID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete user property permissions", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+	}
+
+	// invalidate relevant caches
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+	_ = impl.cache.Delete(ctx, userPropertyPermissionsCacheKey(orgID, userID))
+
+	impl.notifyCacheInvalidation(ctx, userPropertyPermissionsCacheKey(orgID, userID))
+
+	return nil
+}
+
+// RetrieveUserPropertyPermissions returns explicit permissions of the org member. Members without any permissions
+// can access all properties of the organization, otherwise only the ones listed
+func (impl *BusinessStoreImpl) RetrieveUserPropertyPermissions(ctx context.Context, orgID, userID int32) ([]*dbgen.PropertyPermission, error) {
+	cacheKey := userPropertyPermissionsCacheKey(orgID, userID)
+
+	if permissions, err := fetchCachedMany[dbgen.PropertyPermission](ctx, impl.cache, cacheKey); err == nil {
+		return permissions, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	permissions, err := impl.querier.GetUserPropertyPermissions(ctx, &dbgen.GetUserPropertyPermissionsParams{
+		OrgID:  Int(orgID),
+		UserID: userID,
+	})
+	if err != nil && err != pgx.ErrNoRows {
+		slog.ErrorContext(ctx, "Failed to retrieve user property permissions", "orgID", orgID, "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if permissions == nil {
+		permissions = emptyPropertyPermissions
+	}
+
+	_ = impl.cache.Set(ctx, cacheKey, permissions, impl.ttl)
+
+	return permissions, nil
+}
+
+// RetrievePropertyPermissions returns explicit permissions of all org members to the property
+func (impl *BusinessStoreImpl) RetrievePropertyPermissions(ctx context.Context, propID int32) ([]*dbgen.PropertyPermission, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	permissions, err := impl.querier.GetPropertyPermissions(ctx, propID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return emptyPropertyPermissions, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property permissions", "propID", propID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// UpdatePropertyPermission grants access to the property for the org member or revokes it (when level is empty)
+func (impl *BusinessStoreImpl) UpdatePropertyPermission(ctx context.Context, orgID, propID, userID int32, level dbgen.PropertyAccessLevel) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	switch level {
+	case "":
+		if err := impl.querier.DeletePropertyPermission(ctx, &dbgen.DeletePropertyPermissionParams{
+			PropertyID: propID,
+			UserID:     userID,
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete property permission", "propID", propID, "userID", userID, common.ErrAttr(err))
+			return err
+		}
+	case dbgen.PropertyAccessLevelView, dbgen.PropertyAccessLevelEdit:
+		if _, err := impl.querier.UpsertPropertyPermission(ctx, &dbgen.UpsertPropertyPermissionParams{
+			PropertyID: propID,
+			UserID:     userID,
+			Level:      level,
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to upsert property permission", "propID", propID, "userID", userID, common.ErrAttr(err))
+			return err
+		}
+	default:
+		slog.ErrorContext(ctx, "Unknown property access level", "level", level)
+		return ErrInvalidInput
+	}
+
+	slog.DebugContext(ctx, "Updated property permission", "orgID", orgID, "propID", propID, "userID", userID, "level", level)
+
+	cacheKey := userPropertyPermissionsCacheKey(orgID, userID)
+	_ = impl.cache.Delete(ctx, cacheKey)
+	impl.notifyCacheInvalidation(ctx, cacheKey)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) updateUserSubscription(ctx context.Context, userID, subscriptionID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	user, err := impl.querier.UpdateUserSubscription(ctx, &dbgen.UpdateUserSubscriptionParams{
+		ID:             userID,
+		SubscriptionID: Int(subscriptionID),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update user subscription", "userID", userID, "subscriptionID", subscriptionID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Updated user subscription", "userID", userID, "subscriptionID", subscriptionID)
+
+	if user != nil {
+		_ = impl.cache.Set(ctx, userCacheKey(user.ID), user, impl.ttl)
+	}
+
+	return nil
+}
 
 func (impl *BusinessStoreImpl) UpdateUser(ctx context.Context, userID int32, name string, newEmail, oldEmail string) error {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return ErrMaintenance
+	}
+
+	user, err := impl.querier.UpdateUserData(ctx, &dbgen.UpdateUserDataParams{
+		Name:  name,
+		Email: newEmail,
+		ID:    userID,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update user", "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Updated user", "userID", userID)
+
+	if user != nil {
+		_ = impl.cache.Set(ctx, userCacheKey(user.ID), user, impl.ttl)
+	}
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserAPIKeys(ctx context.Context, userID int32) ([]*dbgen.APIKey, error) {
+	cacheKey := userAPIKeysCacheKey(userID)
+
+	if keys, err := fetchCachedMany[dbgen.APIKey](ctx, impl.cache, cacheKey); err == nil {
+		return keys, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrNegativeCacheHit
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.GetUserAPIKeys(ctx, Int(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.Set(ctx, cacheKey, emptyAPIKeys, impl.ttl)
+			return emptyAPIKeys, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve user API keys", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved API keys", "count", len(keys))
+
+	if len(keys) > 0 {
+		_ = impl.cache.Set(ctx, cacheKey, keys, impl.ttl)
+	}
+
+	return keys, err
+}
+
+func (impl *BusinessStoreImpl) UpdateAPIKey(ctx context.Context, externalID pgtype.UUID, expiration time.Time, enabled bool) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	key, err := impl.querier.UpdateAPIKey(ctx, &dbgen.UpdateAPIKeyParams{
+		ExpiresAt:  Timestampz(expiration),
+		Enabled:    Bool(enabled),
+		ExternalID: externalID,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update API key", "externalID", UUIDToSecret(externalID), common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Updated API key", "externalID", UUIDToSecret(externalID))
+
+	if key != nil {
+		secret := UUIDToSecret(key.ExternalID)
+		cacheKey := APIKeyCacheKey(secret)
+		_ = impl.cache.Set(ctx, cacheKey, key, apiKeyTTL)
+
+		// invalidate keys cache
+		_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+
+		impl.notifyCacheInvalidation(ctx, cacheKey, apiKeysListCacheKey(key))
+	}
+
+	return nil
+}
+
+// current logic is that initial values will be set per plan and adjusted manually in DB if requested by customer
+func apiKeyRequestsBurst(requestsPerSecond float64) int32 {
+	const minAPIKeyRequestsBurst = 20
+	burst := int32(requestsPerSecond * 5)
+	if burst < minAPIKeyRequestsBurst {
+		burst = minAPIKeyRequestsBurst
+	}
+
+	return burst
+}
+
+func (impl *BusinessStoreImpl) CreateAPIKey(ctx context.Context, userID int32, name string, expiration time.Time, requestsPerSecond float64) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	key, err := impl.querier.CreateAPIKey(ctx, &dbgen.CreateAPIKeyParams{
+		Name:              name,
+		UserID:            Int(userID),
+		ExpiresAt:         Timestampz(expiration),
+		RequestsPerSecond: requestsPerSecond,
+		RequestsBurst:     apiKeyRequestsBurst(requestsPerSecond),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create API key", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if key != nil {
+		secret := UUIDToSecret(key.ExternalID)
+		cacheKey := APIKeyCacheKey(secret)
+		_ = impl.cache.Set(ctx, cacheKey, key, apiKeyTTL)
+
+		// invalidate keys cache
+		_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+	}
+
+	return key, nil
+}
+
+// UpdateAPIKeySecret replaces generated secret of the API key with a well-known one (used to seed dev environments)
+func (impl *BusinessStoreImpl) UpdateAPIKeySecret(ctx context.Context, userID, keyID int32, secret string) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	externalID := UUIDFromSecret(secret)
+	if !externalID.Valid {
+		return nil, ErrInvalidInput
+	}
+
+	key, err := impl.querier.UpdateAPIKeyExternalID(ctx, &dbgen.UpdateAPIKeyExternalIDParams{
+		ID:         keyID,
+		ExternalID: externalID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to update API key secret", "keyID", keyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	_ = impl.cache.Set(ctx, APIKeyCacheKey(secret), key, apiKeyTTL)
+	_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+
+	return key, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteAPIKey(ctx context.Context, userID, keyID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	key, err := impl.querier.DeleteAPIKey(ctx, &dbgen.DeleteAPIKeyParams{
+		ID:     keyID,
+		UserID: Int(userID),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.ErrorContext(ctx, "Failed to find API Key", "keyID", keyID, "userID", userID)
+			return ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to delete API key", "keyID", keyID, "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Deleted API Key", "keyID", keyID, "userID", userID)
+
+	// invalidate keys cache
+	if key != nil {
+		secret := UUIDToSecret(key.ExternalID)
+		cacheKey := APIKeyCacheKey(secret)
+		_ = impl.cache.Delete(ctx, cacheKey)
+
+		impl.notifyCacheInvalidation(ctx, cacheKey)
+	}
+
+	_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+
+	impl.notifyCacheInvalidation(ctx, userAPIKeysCacheKey(userID))
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrgAPIKeys(ctx context.Context, orgID int32) ([]*dbgen.APIKey, error) {
+	cacheKey := orgAPIKeysCacheKey(orgID)
+
+	if keys, err := fetchCachedMany[dbgen.APIKey](ctx, impl.cache, cacheKey); err == nil {
+		return keys, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrNegativeCacheHit
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.GetOrgAPIKeys(ctx, Int(orgID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.Set(ctx, cacheKey, emptyAPIKeys, impl.ttl)
+			return emptyAPIKeys, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve org API keys", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved org API keys", "orgID", orgID, "count", len(keys))
+
+	if len(keys) > 0 {
+		_ = impl.cache.Set(ctx, cacheKey, keys, impl.ttl)
+	}
+
+	return keys, err
+}
+
+// CreateOrgAPIKey creates organization token that does not belong to any user. Rate limits are the ones of
+// the org owner's plan (see UpdateUserAPIKeysRateLimits)
+func (impl *BusinessStoreImpl) CreateOrgAPIKey(ctx context.Context, orgID int32, name string, expiration time.Time, requestsPerSecond float64) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	key, err := impl.querier.CreateOrgAPIKey(ctx, &dbgen.CreateOrgAPIKeyParams{
+		Name:              name,
+		OrgID:             Int(orgID),
+		ExpiresAt:         Timestampz(expiration),
+		RequestsPerSecond: requestsPerSecond,
+		RequestsBurst:     apiKeyRequestsBurst(requestsPerSecond),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create org API key", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Created org API key", "orgID", orgID, "keyID", key.ID)
+
+	_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)), key, apiKeyTTL)
+	// invalidate keys cache
+	_ = impl.cache.Delete(ctx, orgAPIKeysCacheKey(orgID))
+
+	impl.notifyCacheInvalidation(ctx, orgAPIKeysCacheKey(orgID))
+
+	return key, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOrgAPIKey(ctx context.Context, orgID, keyID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	key, err := impl.querier.DeleteOrgAPIKey(ctx, &dbgen.DeleteOrgAPIKeyParams{
+		ID:    keyID,
+		OrgID: Int(orgID),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.ErrorContext(ctx, "Failed to find org API Key", "keyID", keyID, "orgID", orgID)
+			return ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to delete org API key", "keyID", keyID, "orgID", orgID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Deleted org API Key", "keyID", keyID, "orgID", orgID)
+
+	cacheKey := APIKeyCacheKey(UUIDToSecret(key.ExternalID))
+	_ = impl.cache.Delete(ctx, cacheKey)
+	_ = impl.cache.Delete(ctx, orgAPIKeysCacheKey(orgID))
+
+	impl.notifyCacheInvalidation(ctx, cacheKey, orgAPIKeysCacheKey(orgID))
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) retrieveOrganization(ctx context.Context, orgID int32) (*dbgen.Organization, error) {
+	cacheKey := orgCacheKey(orgID)
+
+	if org, err := fetchCachedOne[dbgen.Organization](ctx, impl.cache, cacheKey); err == nil {
+		return org, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrNegativeCacheHit
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	org, err := impl.querier.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve organization by ID", "orgID", orgID, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	_ = impl.cache.Set(ctx, cacheKey, org, impl.ttl)
+
+	return org, nil
+}
+
+// RetrieveAPIKeyOwner returns ID of the user on whose behalf the key acts: the key's user for personal keys
+// and the current owner of the organization for org tokens (so they keep working when their creator leaves).
+// Org tokens are also scoped to their organization, which is returned as the second value (0 for personal keys)
+func (impl *BusinessStoreImpl) RetrieveAPIKeyOwner(ctx context.Context, key *dbgen.APIKey) (int32, int32, error) {
+	if !key.OrgID.Valid {
+		return key.UserID.Int32, 0, nil
+	}
+
+	org, err := impl.retrieveOrganization(ctx, key.OrgID.Int32)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if org.DeletedAt.Valid {
+		slog.WarnContext(ctx, "Organization of API key is soft-deleted", "orgID", org.ID, "keyID", key.ID)
+		return -1, -1, ErrSoftDeleted
+	}
+
+	return org.UserID.Int32, org.ID, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateUserAPIKeysRateLimits(ctx context.Context, userID int32, requestsPerSecond float64) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.UpdateUserAPIKeysRateLimits(ctx, &dbgen.UpdateUserAPIKeysRateLimitsParams{
+		RequestsPerSecond: requestsPerSecond,
+		UserID:            Int(userID),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.WarnContext(ctx, "Failed to find user API Keys", "userID", userID)
+			return ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to update user API keys rate limit", "userID", userID, "rateLimit", requestsPerSecond,
+			common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.DebugContext(ctx, "Updated user API keys rate limit", "userID", userID)
+
+	// invalidate keys cache
+	_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+
+	if impl.invalidator.Enabled() {
+		invalidatedKeys := []CacheKey{userAPIKeysCacheKey(userID)}
+		// rate limits are used by API servers from cached keys so they also need to be dropped on other nodes
+		if keys, err := impl.querier.GetUserAPIKeys(ctx, Int(userID)); err == nil {
+			for _, key := range keys {
+				invalidatedKeys = append(invalidatedKeys, APIKeyCacheKey(UUIDToSecret(key.ExternalID)))
+			}
+		} else {
+			slog.ErrorContext(ctx, "Failed to retrieve user API keys for invalidation", "userID", userID, common.ErrAttr(err))
+		}
+		// organization tokens follow limits of the org owner
+		if orgs, err := impl.querier.GetUserOrganizations(ctx, Int(userID)); err == nil {
+			for _, org := range orgs {
+				if org.Level != dbgen.AccessLevelOwner {
+					continue
+				}
+
+				invalidatedKeys = append(invalidatedKeys, orgAPIKeysCacheKey(org.Organization.ID))
+				if keys, err := impl.querier.GetOrgAPIKeys(ctx, Int(org.Organization.ID)); err == nil {
+					for _, key := range keys {
+						invalidatedKeys = append(invalidatedKeys, APIKeyCacheKey(UUIDToSecret(key.ExternalID)))
+					}
+				}
+			}
+		} else {
+			slog.ErrorContext(ctx, "Failed to retrieve user organizations for invalidation", "userID", userID, common.ErrAttr(err))
+		}
+
+		impl.notifyCacheInvalidation(ctx, invalidatedKeys...)
+	}
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) UpdateAPIKeyRotation(ctx context.Context, userID, keyID int32, rotationDays int32) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	key, err := impl.querier.UpdateAPIKeyRotation(ctx, &dbgen.UpdateAPIKeyRotationParams{
+		RotationDays: rotationDays,
+		ID:           keyID,
+		UserID:       Int(userID),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.ErrorContext(ctx, "Failed to find API Key", "keyID", keyID, "userID", userID)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to update API key rotation", "keyID", keyID, "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated API key rotation", "keyID", keyID, "days", rotationDays)
+
+	if key != nil {
+		secret := UUIDToSecret(key.ExternalID)
+		_ = impl.cache.Set(ctx, APIKeyCacheKey(secret), key, apiKeyTTL)
+
+		impl.notifyCacheInvalidation(ctx, APIKeyCacheKey(secret))
+	}
+
+	_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+
+	impl.notifyCacheInvalidation(ctx, userAPIKeysCacheKey(userID))
+
+	return key, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveAPIKeysDueForRotation(ctx context.Context, limit int) ([]*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.GetAPIKeysDueForRotation(ctx, int32(limit))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.APIKey{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve API keys due for rotation", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey creates a successor for the key with the same settings and lifetime. Both keys stay valid
+// until the old one is disabled with DisableRotatedAPIKeys(). Should be called in a transaction.
+func (impl *BusinessStoreImpl) RotateAPIKey(ctx context.Context, key *dbgen.APIKey, tnow time.Time) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	lifetime := key.ExpiresAt.Time.Sub(key.CreatedAt.Time)
+
+	successor, err := impl.querier.CreateAPIKey(ctx, &dbgen.CreateAPIKeyParams{
+		Name:              key.Name,
+		UserID:            key.UserID,
+		ExpiresAt:         Timestampz(tnow.Add(lifetime)),
+		RequestsPerSecond: key.RequestsPerSecond,
+		RequestsBurst:     key.RequestsBurst,
+		RotationDays:      key.RotationDays,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create successor API key", "keyID", key.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	rotated, err := impl.querier.SetAPIKeySuccessor(ctx, &dbgen.SetAPIKeySuccessorParams{
+		SuccessorID: Int(successor.ID),
+		ID:          key.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.WarnContext(ctx, "API key was already rotated", "keyID", key.ID)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to set API key successor", "keyID", key.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Audit: rotated API key", "keyID", key.ID, "successorID", successor.ID, "userID", key.UserID.Int32)
+
+	_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(rotated.ExternalID)), rotated, apiKeyTTL)
+	_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(successor.ExternalID)), successor, apiKeyTTL)
+	_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+
+	impl.notifyCacheInvalidation(ctx, APIKeyCacheKey(UUIDToSecret(rotated.ExternalID)), apiKeysListCacheKey(key))
+
+	return successor, nil
+}
+
+// DisableRotatedAPIKeys disables keys that were replaced by successors before the cutoff
+func (impl *BusinessStoreImpl) DisableRotatedAPIKeys(ctx context.Context, rotatedBefore time.Time) ([]*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.DisableRotatedAPIKeys(ctx, Timestampz(rotatedBefore))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.APIKey{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to disable rotated API keys", common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, key := range keys {
+		slog.InfoContext(ctx, "Audit: disabled rotated API key", "keyID", key.ID, "userID", key.UserID.Int32)
+		_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)), key, apiKeyTTL)
+		_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+	}
+
+	impl.notifyCacheInvalidation(ctx, apiKeysInvalidationKeys(keys)...)
+
+	return keys, nil
+}
+
+// RetrieveAPIKeysExpiringSoon returns enabled keys that expire within the given number of days and for which
+// owners were not yet reminded for this (or smaller) number of days
+func (impl *BusinessStoreImpl) RetrieveAPIKeysExpiringSoon(ctx context.Context, tnow time.Time, days int, limit int) ([]*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.GetAPIKeysExpiringSoon(ctx, &dbgen.GetAPIKeysExpiringSoonParams{
+		ExpiresAfter:  Timestampz(tnow),
+		ExpiresBefore: Timestampz(tnow.AddDate(0, 0, days)),
+		NotifyDays:    int16(days),
+		MaxResults:    int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.APIKey{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve API keys expiring soon", "days", days, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateAPIKeyExpiryNotified(ctx context.Context, key *dbgen.APIKey, days int) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpdateAPIKeyExpiryNotified(ctx, &dbgen.UpdateAPIKeyExpiryNotifiedParams{
+		ExpiryNotifiedDays: int16(days),
+		ID:                 key.ID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update API key expiry reminder", "keyID", key.ID, "days", days, common.ErrAttr(err))
+		return err
+	}
+
+	// cached key is used for API authentication where this field is irrelevant so we only drop the list
+	_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+
+	return nil
+}
+
+// ExpireAPIKeys disables enabled keys that are past their expiration date
+func (impl *BusinessStoreImpl) ExpireAPIKeys(ctx context.Context, tnow time.Time) ([]*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.ExpireAPIKeys(ctx, Timestampz(tnow))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.APIKey{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to expire API keys", common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, key := range keys {
+		slog.InfoContext(ctx, "Audit: expired API key", "keyID", key.ID, "userID", key.UserID.Int32)
+		_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)), key, apiKeyTTL)
+		_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+	}
+
+	impl.notifyCacheInvalidation(ctx, apiKeysInvalidationKeys(keys)...)
+
+	return keys, nil
+}
+
+// ArchiveExpiredAPIKeys deletes keys that expired before the cutoff (deleted rows are kept in deleted records)
+func (impl *BusinessStoreImpl) ArchiveExpiredAPIKeys(ctx context.Context, expiredBefore time.Time, limit int) ([]*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.ArchiveExpiredAPIKeys(ctx, &dbgen.ArchiveExpiredAPIKeysParams{
+		ExpiresAt: Timestampz(expiredBefore),
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.APIKey{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to archive expired API keys", common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, key := range keys {
+		slog.InfoContext(ctx, "Audit: archived expired API key", "keyID", key.ID, "userID", key.UserID.Int32)
+		_ = impl.cache.SetMissing(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)), impl.ttl)
+		_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+	}
+
+	impl.notifyCacheInvalidation(ctx, apiKeysInvalidationKeys(keys)...)
+
+	return keys, nil
+}
+
+// AddAPIKeyUsage accumulates hourly counters of requests authenticated with API keys
+func (impl *BusinessStoreImpl) AddAPIKeyUsage(ctx context.Context, records []*common.APIKeyRecord) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	type usage struct {
+		requests   int32
+		wrongOwner int32
+	}
+
+	hours := make(map[time.Time]map[int32]*usage)
+	for _, r := range records {
+		hour := r.Timestamp.UTC().Truncate(time.Hour)
+		keys, ok := hours[hour]
+		if !ok {
+			keys = make(map[int32]*usage)
+			hours[hour] = keys
+		}
+
+		u, ok := keys[r.APIKeyID]
+		if !ok {
+			u = &usage{}
+			keys[r.APIKeyID] = u
+		}
+
+		if r.WrongOwner {
+			u.wrongOwner++
+		} else {
+			u.requests++
+		}
+	}
+
+	for hour, keys := range hours {
+		params := &dbgen.AddAPIKeyUsageParams{
+			APIKeyIDs:  make([]int32, 0, len(keys)),
+			Hour:       Timestampz(hour),
+			Requests:   make([]int32, 0, len(keys)),
+			WrongOwner: make([]int32, 0, len(keys)),
+		}
+
+		for keyID, u := range keys {
+			params.APIKeyIDs = append(params.APIKeyIDs, keyID)
+			params.Requests = append(params.Requests, u.requests)
+			params.WrongOwner = append(params.WrongOwner, u.wrongOwner)
+		}
+
+		if err := impl.querier.AddAPIKeyUsage(ctx, params); err != nil {
+			slog.ErrorContext(ctx, "Failed to add API keys usage", "keys", len(keys), "hour", hour, common.ErrAttr(err))
+			return err
+		}
+	}
+
+	slog.DebugContext(ctx, "Added API keys usage", "records", len(records), "hours", len(hours))
+
+	return nil
+}
+
+// RetrieveAPIKeysUsageStats returns usage of active keys since recentFrom together with their usage during
+// the baseline period (from baselineFrom until recentFrom)
+func (impl *BusinessStoreImpl) RetrieveAPIKeysUsageStats(ctx context.Context, recentFrom, baselineFrom time.Time) ([]*dbgen.GetAPIKeysUsageStatsRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := impl.querier.GetAPIKeysUsageStats(ctx, &dbgen.GetAPIKeysUsageStatsParams{
+		RecentFrom:   Timestampz(recentFrom),
+		BaselineFrom: Timestampz(baselineFrom),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetAPIKeysUsageStatsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve API keys usage", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteAPIKeysUsage(ctx context.Context, before time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteAPIKeyUsage(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete API keys usage", "before", before, common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+// SuspendAPIKey disables the key due to anomalous usage. Unlike expired keys, suspended keys can be resumed
+func (impl *BusinessStoreImpl) SuspendAPIKey(ctx context.Context, keyID int32, reason string) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	key, err := impl.querier.SuspendAPIKey(ctx, keyID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.WarnContext(ctx, "API key is already disabled", "keyID", keyID)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to suspend API key", "keyID", keyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.WarnContext(ctx, "Audit: suspended API key", "keyID", key.ID, "userID", key.UserID.Int32, "orgID", key.OrgID.Int32,
+		"reason", reason)
+
+	_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)), key, apiKeyTTL)
+	_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+
+	impl.notifyCacheInvalidation(ctx, apiKeysInvalidationKeys([]*dbgen.APIKey{key})...)
+
+	return key, nil
+}
+
+// ResumeAPIKey re-enables the suspended key of the user (personal or of the owned organization)
+func (impl *BusinessStoreImpl) ResumeAPIKey(ctx context.Context, userID, keyID int32) (*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	key, err := impl.querier.ResumeAPIKey(ctx, &dbgen.ResumeAPIKeyParams{
+		ID:     keyID,
+		UserID: Int(userID),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.WarnContext(ctx, "Failed to find suspended API key", "keyID", keyID, "userID", userID)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to resume API key", "keyID", keyID, "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Audit: resumed API key", "keyID", key.ID, "userID", userID)
+
+	_ = impl.cache.Set(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)), key, apiKeyTTL)
+	_ = impl.cache.Delete(ctx, apiKeysListCacheKey(key))
+
+	impl.notifyCacheInvalidation(ctx, apiKeysInvalidationKeys([]*dbgen.APIKey{key})...)
+
+	return key, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUsersWithoutSubscription(ctx context.Context, userIDs []int32) ([]*dbgen.User, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	users, err := impl.querier.GetUsersWithoutSubscription(ctx, userIDs)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.User{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve users without subscriptions", "userIDs", len(userIDs), common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched users without subscriptions", "count", len(users), "userIDs", len(userIDs))
+
+	return users, err
+}
+
+func (impl *BusinessStoreImpl) RetrieveSubscriptionsByUserIDs(ctx context.Context, userIDs []int32) ([]*dbgen.GetSubscriptionsByUserIDsRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	subscriptions, err := impl.querier.GetSubscriptionsByUserIDs(ctx, userIDs)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetSubscriptionsByUserIDsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve user subscriptions", "userIDs", len(userIDs), common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched users subscriptions", "count", len(subscriptions), "userIDs", len(userIDs))
+
+	return subscriptions, err
+}
+
+func (impl *BusinessStoreImpl) AcquireLock(ctx context.Context, name string, data []byte, expiration time.Time) (*dbgen.Lock, error) {
+	if (len(name) == 0) || expiration.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	lock, err := impl.querier.InsertLock(ctx, &dbgen.InsertLockParams{
+		Name:      name,
+		Data:      data,
+		ExpiresAt: Timestampz(expiration),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// slog.WarnContext(ctx, "Lock is still taken", "name", name)
+			return nil, ErrLocked
+		}
+		slog.ErrorContext(ctx, "Failed to acquire a lock", "name", name, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Acquired a lock", "name", name, "expires_at", lock.ExpiresAt.Time)
+
+	return lock, nil
+}
+
+func (impl *BusinessStoreImpl) ReleaseLock(ctx context.Context, name string) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+	err := impl.querier.DeleteLock(ctx, name)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to release a lock", "name", name, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) DeleteDeletedRecords(ctx context.Context, before time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteDeletedRecords(ctx, Timestampz(before))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to cleanup deleted records", "before", before, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) RetrieveSoftDeletedProperties(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetSoftDeletedPropertiesRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	properties, err := impl.querier.GetSoftDeletedProperties(ctx, &dbgen.GetSoftDeletedPropertiesParams{
+		DeletedAt: Timestampz(before),
+		Limit:     int32(limit),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve soft deleted properties", "before", before, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched soft-deleted properties", "count", len(properties), "before", before)
+
+	return properties, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteProperties(ctx context.Context, ids []int32) error {
+	if len(ids) == 0 {
+		slog.WarnContext(ctx, "No properties to delete")
+		return nil
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteProperties(ctx, ids)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete properties", "count", len(ids), common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) RetrieveSoftDeletedOrganizations(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetSoftDeletedOrganizationsRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	organizations, err := impl.querier.GetSoftDeletedOrganizations(ctx, &dbgen.GetSoftDeletedOrganizationsParams{
+		DeletedAt: Timestampz(before),
+		Limit:     int32(limit),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve soft deleted organizations", "before", before, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched soft-deleted organizations", "count", len(organizations), "before", before)
+
+	return organizations, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOrganizations(ctx context.Context, ids []int32) error {
+	if len(ids) == 0 {
+		slog.WarnContext(ctx, "No organizations to delete")
+		return nil
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteOrganizations(ctx, ids)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete organizations", "count", len(ids), common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) RetrieveSoftDeletedUsers(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetSoftDeletedUsersRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	users, err := impl.querier.GetSoftDeletedUsers(ctx, &dbgen.GetSoftDeletedUsersParams{
+		DeletedAt: Timestampz(before),
+		Limit:     int32(limit),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve soft deleted users", "before", before, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched soft-deleted users", "count", len(users), "before", before)
+
+	return users, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteUsers(ctx context.Context, ids []int32) error {
+	if len(ids) == 0 {
+		slog.WarnContext(ctx, "No users to delete")
+		return nil
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteUsers(ctx, ids)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete users", "count", len(ids), common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) RetrieveNotification(ctx context.Context, id int32) (*dbgen.SystemNotification, error) {
+	cacheKey := notificationCacheKey(id)
+
+	if notif, err := fetchCachedOne[dbgen.SystemNotification](ctx, impl.cache, cacheKey); err == nil {
+		return notif, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrNegativeCacheHit
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	notification, err := impl.querier.GetNotificationById(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve notification by ID", "notifID", id, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	if notification != nil {
+		_ = impl.cache.Set(ctx, cacheKey, notification, impl.ttl)
+	}
+
+	return notification, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserNotification(ctx context.Context, tnow time.Time, userID int32) (*dbgen.SystemNotification, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	n, err := impl.querier.GetLastActiveNotification(ctx, &dbgen.GetLastActiveNotificationParams{
+		Column1: Timestampz(tnow),
+		UserID:  Int(userID),
+	})
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve system notification", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	cacheKey := notificationCacheKey(n.ID)
+	_ = impl.cache.Set(ctx, cacheKey, n, impl.ttl)
+
+	slog.DebugContext(ctx, "Retrieved system notification", "userID", userID, "notifID", n.ID)
+
+	return n, err
+}
+
+func (impl *BusinessStoreImpl) CreateNotification(ctx context.Context, message string, tnow time.Time, duration *time.Duration, userID *int32) (*dbgen.SystemNotification, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	arg := &dbgen.CreateNotificationParams{
+		Message:   message,
+		StartDate: Timestampz(tnow),
+		EndDate:   pgtype.Timestamptz{Valid: false},
+		UserID:    pgtype.Int4{Valid: false},
+	}
+
+	if duration != nil {
+		arg.EndDate = Timestampz(tnow.Add(*duration))
+	}
+
+	if userID != nil {
+		arg.UserID = Int(*userID)
+	}
+
+	n, err := impl.querier.CreateNotification(ctx, arg)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create a system notification", common.ErrAttr(err))
+		return nil, err
+	}
+
+	if n != nil {
+		cacheKey := notificationCacheKey(n.ID)
+		_ = impl.cache.Set(ctx, cacheKey, n, impl.ttl)
+	}
+
+	slog.DebugContext(ctx, "Created system notification", "notifID", n.ID)
+
+	return n, err
+}
+
+func (impl *BusinessStoreImpl) CreateUserNotification(ctx context.Context, userID int32, category dbgen.NotificationCategory, message string) (*dbgen.UserNotification, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	n, err := impl.querier.CreateUserNotification(ctx, &dbgen.CreateUserNotificationParams{
+		UserID:   userID,
+		Category: category,
+		Message:  message,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create user notification", "userID", userID, "category", category, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Created user notification", "userID", userID, "notifID", n.ID, "category", category)
+
+	return n, nil
+}
+
+// RetrieveUserNotifications returns latest notifications of the user that were not dismissed
+func (impl *BusinessStoreImpl) RetrieveUserNotifications(ctx context.Context, userID int32, limit int) ([]*dbgen.UserNotification, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	notifications, err := impl.querier.GetUserNotifications(ctx, &dbgen.GetUserNotificationsParams{
+		UserID: userID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.UserNotification{}, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve user notifications", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Retrieved user notifications", "userID", userID, "count", len(notifications))
+
+	return notifications, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUnreadUserNotificationsCount(ctx context.Context, userID int32) (int64, error) {
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
+
+	count, err := impl.querier.GetUnreadUserNotificationsCount(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve unread user notifications count", "userID", userID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (impl *BusinessStoreImpl) MarkUserNotificationRead(ctx context.Context, userID int32, notificationID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.MarkUserNotificationRead(ctx, &dbgen.MarkUserNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark user notification as read", "userID", userID, "notifID", notificationID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Marked user notification as read", "userID", userID, "notifID", notificationID)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) MarkAllUserNotificationsRead(ctx context.Context, userID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.MarkAllUserNotificationsRead(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark all user notifications as read", "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Marked all user notifications as read", "userID", userID)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) DismissUserNotification(ctx context.Context, userID int32, notificationID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DismissUserNotification(ctx, &dbgen.DismissUserNotificationParams{
+		ID:     notificationID,
+		UserID: userID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to dismiss user notification", "userID", userID, "notifID", notificationID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Dismissed user notification", "userID", userID, "notifID", notificationID)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveProperties(ctx context.Context, limit int) ([]*dbgen.Property, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	properties, err := impl.querier.GetProperties(ctx, int32(limit))
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve properties", common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched properties", "count", len(properties))
+
+	return properties, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserPropertiesCount(ctx context.Context, userID int32) (int64, error) {
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
+
+	count, err := impl.querier.GetUserPropertiesCount(ctx, Int(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user properties count", "userID", userID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	slog.DebugContext(ctx, "Fetched user properties count", "userID", userID, "count", count)
+
+	return count, nil
+}
+
+func (s *BusinessStoreImpl) GetCachedPropertyBySitekey(ctx context.Context, sitekey string) (*dbgen.Property, error) {
+	if sitekey == TestPropertySitekey {
+		return nil, ErrTestProperty
+	}
+
+	return s.getCachedPropertyBySitekey(ctx, sitekey)
+}
+
+func (s *BusinessStoreImpl) RetrieveUser(ctx context.Context, id int32) (*dbgen.User, error) {
+	user, err := s.retrieveUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.DeletedAt.Valid {
+		slog.WarnContext(ctx, "User is soft-deleted", "userID", id, "deletedAt", user.DeletedAt.Time)
+		return user, ErrSoftDeleted
+	}
+
+	return user, nil
+}
+
+func (s *BusinessStoreImpl) RetrieveUserOrganization(ctx context.Context, userID, orgID int32) (*dbgen.Organization, error) {
+	org, level, err := s.retrieveOrganizationWithAccess(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !level.Valid {
+		slog.WarnContext(ctx, "User cannot access this org", "orgID", orgID, "userID", userID)
+		return nil, ErrPermissions
+	}
+
+	if org.DeletedAt.Valid {
+		slog.WarnContext(ctx, "Organization is soft-deleted", "orgID", orgID, "deletedAt", org.DeletedAt.Time)
+		return org, ErrSoftDeleted
+	}
+
+	return org, nil
+}
+
+// RetrieveOrgProperty returns property of the organization if user (a member of the org) is allowed to access it
+func (s *BusinessStoreImpl) RetrieveOrgProperty(ctx context.Context, orgID, userID, propID int32) (*dbgen.Property, error) {
+	property, err := s.retrieveOrgProperty(ctx, orgID, propID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !property.OrgID.Valid || (property.OrgID.Int32 != orgID) {
+		slog.ErrorContext(ctx, "Property org does not match", "propertyOrgID", property.OrgID.Int32, "orgID", orgID)
+		return nil, ErrPermissions
+	}
+
+	permissions, err := s.RetrieveUserPropertyPermissions(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := PropertyAccessLevel(property, userID, permissions); !ok {
+		slog.WarnContext(ctx, "User has no permission to access property", "propID", propID, "orgID", orgID, "userID", userID)
+		return nil, ErrPermissions
+	}
+
+	if property.DeletedAt.Valid {
+		slog.WarnContext(ctx, "Property is soft-deleted", "propID", propID, "deletedAt", property.DeletedAt.Time)
+		return property, ErrSoftDeleted
+	}
+
+	return property, nil
+}
+
+func (s *BusinessStoreImpl) CreateNewAccount(ctx context.Context, params *dbgen.CreateSubscriptionParams, email, name, orgName string, existingUserID int32) (*dbgen.User, *dbgen.Organization, error) {
+	if s.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	var subscriptionID *int32
+
+	if params != nil {
+		subscription, err := s.createNewSubscription(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		subscriptionID = &subscription.ID
+
+		if existingUser, err := s.FindUserByEmail(ctx, email); err == nil {
+			slog.InfoContext(ctx, "User with such email already exists", "userID", existingUser.ID, "subscriptionID", existingUser.SubscriptionID)
+			if ((existingUser.ID == existingUserID) || (existingUserID == -1)) && !existingUser.SubscriptionID.Valid {
+				if err := s.updateUserSubscription(ctx, existingUser.ID, subscription.ID); err != nil {
+					return nil, nil, err
+				}
+
+				return existingUser, nil, nil
+			} else {
+				slog.ErrorContext(ctx, "Cannot update existing user with same email", "existingUserID", existingUser.ID,
+					"passthrough", existingUserID, "subscribed", existingUser.SubscriptionID.Valid, "email", email)
+				return nil, nil, ErrDuplicateAccount
+			}
+		}
+	}
+
+	user, err := s.createNewUser(ctx, email, name, subscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	org, err := s.CreateNewOrganization(ctx, orgName, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, org, nil
+}
+
+// CreateWebhookEvent persists incoming webhook in the outbox. Returns ErrDuplicateEvent if event with the same ID
+// was already recorded, which allows webhook handlers to be idempotent (e.g. when provider retries delivery)
+func (impl *BusinessStoreImpl) CreateWebhookEvent(ctx context.Context, eventID, eventType string, payload []byte) (*dbgen.WebhookEvent, error) {
+	if (len(eventID) == 0) || (len(eventType) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	event, err := impl.querier.CreateWebhookEvent(ctx, &dbgen.CreateWebhookEventParams{
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			slog.WarnContext(ctx, "Webhook event was already recorded", "eventID", eventID, "type", eventType)
+			return nil, ErrDuplicateEvent
+		}
+		slog.ErrorContext(ctx, "Failed to create webhook event", "eventID", eventID, "type", eventType, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Created webhook event", "eventID", eventID, "type", eventType, "id", event.ID)
+
+	return event, nil
+}
+
+func (impl *BusinessStoreImpl) RetrievePendingWebhookEvents(ctx context.Context, maxAttempts int, limit int) ([]*dbgen.WebhookEvent, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	events, err := impl.querier.GetPendingWebhookEvents(ctx, &dbgen.GetPendingWebhookEventsParams{
+		Attempts: int32(maxAttempts),
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve pending webhook events", common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched pending webhook events", "count", len(events))
+
+	return events, nil
+}
+
+func (impl *BusinessStoreImpl) MarkWebhookEventProcessed(ctx context.Context, id int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.MarkWebhookEventProcessed(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to mark webhook event as processed", "id", id, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) MarkWebhookEventFailed(ctx context.Context, id int32, reason string, nextAttempt time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.MarkWebhookEventFailed(ctx, &dbgen.MarkWebhookEventFailedParams{
+		ID:            id,
+		LastError:     reason,
+		NextAttemptAt: Timestampz(nextAttempt),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to mark webhook event as failed", "id", id, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+// MarkWebhookEventSkipped marks event as processed without side-effects (e.g. there's no handler for its type)
+func (impl *BusinessStoreImpl) MarkWebhookEventSkipped(ctx context.Context, id int32, reason string) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.MarkWebhookEventSkipped(ctx, &dbgen.MarkWebhookEventSkippedParams{
+		ID:        id,
+		LastError: reason,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to mark webhook event as skipped", "id", id, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+// DeleteExhaustedWebhookEvents deletes events, created before the cutoff, that failed maxAttempts times and will
+// never be retried. Deleted events are returned so that they can be reported
+func (impl *BusinessStoreImpl) DeleteExhaustedWebhookEvents(ctx context.Context, maxAttempts int, before time.Time) ([]*dbgen.WebhookEvent, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	events, err := impl.querier.DeleteExhaustedWebhookEvents(ctx, &dbgen.DeleteExhaustedWebhookEventsParams{
+		Attempts:  int32(maxAttempts),
+		CreatedAt: Timestampz(before),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete exhausted webhook events", "before", before, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteProcessedWebhookEvents(ctx context.Context, before time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteProcessedWebhookEvents(ctx, Timestampz(before))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete processed webhook events", "before", before, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) CreateQueueJob(ctx context.Context, queue string, payload []byte, runAt time.Time) error {
+	if len(queue) == 0 {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.CreateQueueJob(ctx, &dbgen.CreateQueueJobParams{
+		Queue:   queue,
+		Payload: payload,
+		RunAt:   Timestampz(runAt),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create queue job", "queue", queue, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+// ClaimQueueJobs locks due jobs until lockedUntil so that concurrent workers (on other instances) skip them
+func (impl *BusinessStoreImpl) ClaimQueueJobs(ctx context.Context, queue string, limit int, lockedUntil time.Time) ([]*dbgen.QueueJob, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	jobs, err := impl.querier.ClaimQueueJobs(ctx, &dbgen.ClaimQueueJobsParams{
+		Queue:       queue,
+		Limit:       int32(limit),
+		LockedUntil: Timestampz(lockedUntil),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim queue jobs", "queue", queue, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Claimed queue jobs", "queue", queue, "count", len(jobs))
+
+	return jobs, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteQueueJob(ctx context.Context, id int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteQueueJob(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete queue job", "id", id, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) RetryQueueJob(ctx context.Context, id int32, reason string, runAt time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.RetryQueueJob(ctx, &dbgen.RetryQueueJobParams{
+		ID:        id,
+		LastError: reason,
+		RunAt:     Timestampz(runAt),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reschedule queue job", "id", id, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) FailQueueJob(ctx context.Context, id int32, reason string) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.FailQueueJob(ctx, &dbgen.FailQueueJobParams{
+		ID:        id,
+		LastError: reason,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to mark queue job as failed", "id", id, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) DeleteFailedQueueJobs(ctx context.Context, before time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteFailedQueueJobs(ctx, Timestampz(before))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete failed queue jobs", "before", before, common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (impl *BusinessStoreImpl) SearchUserProperties(ctx context.Context, userID int32, term string, limit int) ([]*dbgen.SearchUserPropertiesRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	properties, err := impl.querier.SearchUserProperties(ctx, &dbgen.SearchUserPropertiesParams{
+		UserID: Int(userID),
+		Name:   ContainsPattern(term),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to search user properties", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Searched user properties", "userID", userID, "count", len(properties))
+
+	return properties, nil
+}
+
+func (impl *BusinessStoreImpl) SearchUserOrganizations(ctx context.Context, userID int32, term string, limit int) ([]*dbgen.SearchUserOrganizationsRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	orgs, err := impl.querier.SearchUserOrganizations(ctx, &dbgen.SearchUserOrganizationsParams{
+		UserID: Int(userID),
+		Name:   ContainsPattern(term),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to search user organizations", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Searched user organizations", "userID", userID, "count", len(orgs))
+
+	return orgs, nil
+}
+
+func (impl *BusinessStoreImpl) SearchUserAPIKeys(ctx context.Context, userID int32, term string, limit int) ([]*dbgen.APIKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys, err := impl.querier.SearchUserAPIKeys(ctx, &dbgen.SearchUserAPIKeysParams{
+		UserID: Int(userID),
+		Name:   ContainsPattern(term),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to search user API keys", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Searched user API keys", "userID", userID, "count", len(keys))
+
+	return keys, nil
+}
+
+func emailChangeTokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (impl *BusinessStoreImpl) CreateEmailChange(ctx context.Context, userID int32, oldEmail, newEmail, revertToken string, expiresAt time.Time) (*dbgen.EmailChange, error) {
+	if (len(oldEmail) == 0) || (len(newEmail) == 0) || (len(revertToken) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	change, err := impl.querier.CreateEmailChange(ctx, &dbgen.CreateEmailChangeParams{
+		UserID:          userID,
+		OldEmail:        oldEmail,
+		NewEmail:        newEmail,
+		RevertTokenHash: emailChangeTokenHash(revertToken),
+		ExpiresAt:       Timestampz(expiresAt),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create email change", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Recorded email change", "userID", userID, "changeID", change.ID, "expiresAt", expiresAt)
+
+	return change, nil
+}
+
+// RevertEmailChange restores previous email of the user if revert token is valid. It should be called in a transaction.
+func (impl *BusinessStoreImpl) RevertEmailChange(ctx context.Context, revertToken string, tnow time.Time) (*dbgen.EmailChange, error) {
+	if len(revertToken) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	change, err := impl.querier.GetEmailChangeByTokenHash(ctx, emailChangeTokenHash(revertToken))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve email change", common.ErrAttr(err))
+		return nil, err
+	}
+
+	if change.RevertedAt.Valid || !tnow.Before(change.ExpiresAt.Time) {
+		slog.WarnContext(ctx, "Email change cannot be reverted", "changeID", change.ID, "reverted", change.RevertedAt.Valid,
+			"expiresAt", change.ExpiresAt.Time)
+		return nil, ErrRecordNotFound
+	}
+
+	user, err := impl.retrieveUser(ctx, change.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := impl.querier.MarkEmailChangeReverted(ctx, change.ID); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to mark email change as reverted", "changeID", change.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if err := impl.UpdateUser(ctx, user.ID, user.Name, change.OldEmail, user.Email); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Reverted email change", "userID", user.ID, "changeID", change.ID)
+
+	return change, nil
+}
+
+func (impl *BusinessStoreImpl) RetrievePropertyTags(ctx context.Context, propertyID int32) ([]string, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	tags, err := impl.querier.GetPropertyTags(ctx, propertyID)
+	if err != nil && err != pgx.ErrNoRows {
+		slog.ErrorContext(ctx, "Failed to retrieve property tags", "propID", propertyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, t.Tag)
+	}
+
+	return result, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrgPropertyTags(ctx context.Context, orgID int32) ([]*dbgen.PropertyTag, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	tags, err := impl.querier.GetOrgPropertyTags(ctx, Int(orgID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.PropertyTag{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org property tags", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Retrieved org property tags", "orgID", orgID, "count", len(tags))
+
+	return tags, nil
+}
+
+// RetrievePropertyMessages returns custom widget messages of the property. It is used on the widget path so it
+// is cached, including the absence of messages.
+func (impl *BusinessStoreImpl) RetrievePropertyMessages(ctx context.Context, propertyID int32) (*dbgen.PropertyMessage, error) {
+	cacheKey := propertyMessagesCacheKey(propertyID)
+
+	if messages, err := fetchCachedOne[dbgen.PropertyMessage](ctx, impl.cache, cacheKey); err == nil {
+		return messages, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrRecordNotFound
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	messages, err := impl.querier.GetPropertyMessages(ctx, propertyID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property messages", "propID", propertyID, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	_ = impl.cache.Set(ctx, cacheKey, messages, impl.ttl)
+
+	return messages, nil
+}
+
+func (impl *BusinessStoreImpl) UpdatePropertyMessages(ctx context.Context, params *dbgen.UpsertPropertyMessagesParams) (*dbgen.PropertyMessage, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	messages, err := impl.querier.UpsertPropertyMessages(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update property messages", "propID", params.PropertyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated property messages", "propID", params.PropertyID)
+
+	_ = impl.cache.Set(ctx, propertyMessagesCacheKey(params.PropertyID), messages, impl.ttl)
+
+	impl.notifyCacheInvalidation(ctx, propertyMessagesCacheKey(params.PropertyID))
+
+	return messages, nil
+}
+
+// RetrieveOrgBudget returns monthly verification budget of the organization, if it was ever set
+func (impl *BusinessStoreImpl) RetrieveOrgBudget(ctx context.Context, orgID int32) (*dbgen.OrgBudget, error) {
+	cacheKey := orgBudgetCacheKey(orgID)
+
+	if budget, err := fetchCachedOne[dbgen.OrgBudget](ctx, impl.cache, cacheKey); err == nil {
+		return budget, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrRecordNotFound
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	budget, err := impl.querier.GetOrgBudget(ctx, orgID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org budget", "orgID", orgID, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	_ = impl.cache.Set(ctx, cacheKey, budget, impl.ttl)
+
+	return budget, nil
+}
+
+// UpdateOrgBudget sets monthly budget of the organization (0 disables it) and resets sent alerts
+func (impl *BusinessStoreImpl) UpdateOrgBudget(ctx context.Context, orgID int32, monthlyLimit int64) (*dbgen.OrgBudget, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	budget, err := impl.querier.UpsertOrgBudget(ctx, &dbgen.UpsertOrgBudgetParams{
+		OrgID:        orgID,
+		MonthlyLimit: monthlyLimit,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update org budget", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated org budget", "orgID", orgID, "limit", monthlyLimit)
+
+	_ = impl.cache.Set(ctx, orgBudgetCacheKey(orgID), budget, impl.ttl)
+
+	return budget, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveActiveOrgBudgets(ctx context.Context) ([]*dbgen.GetActiveOrgBudgetsRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	budgets, err := impl.querier.GetActiveOrgBudgets(ctx)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetActiveOrgBudgetsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve active org budgets", common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved active org budgets", "count", len(budgets))
+
+	return budgets, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateOrgBudgetAlert(ctx context.Context, orgID int32, percent int16, tnow time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpdateOrgBudgetAlert(ctx, &dbgen.UpdateOrgBudgetAlertParams{
+		AlertedPercent: percent,
+		AlertedAt:      Timestampz(tnow),
+		OrgID:          orgID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update org budget alert", "orgID", orgID, common.ErrAttr(err))
+		return err
+	}
+
+	_ = impl.cache.Delete(ctx, orgBudgetCacheKey(orgID))
+
+	return nil
+}
+
+// RetrievePropertyQuota returns share of the owner's plan quota allocated to the property, if it was set
+func (impl *BusinessStoreImpl) RetrievePropertyQuota(ctx context.Context, propID int32) (*dbgen.PropertyQuota, error) {
+	cacheKey := propertyQuotaCacheKey(propID)
+
+	if quota, err := fetchCachedOne[dbgen.PropertyQuota](ctx, impl.cache, cacheKey); err == nil {
+		return quota, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrRecordNotFound
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	quota, err := impl.querier.GetPropertyQuota(ctx, propID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property quota", "propID", propID, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	_ = impl.cache.Set(ctx, cacheKey, quota, impl.ttl)
+
+	return quota, nil
+}
+
+// UpdatePropertyQuota allocates percent of the owner's plan quota to the property (0 removes the allocation)
+func (impl *BusinessStoreImpl) UpdatePropertyQuota(ctx context.Context, propID int32, percent int16, period dbgen.QuotaPeriod) (*dbgen.PropertyQuota, error) {
+	if (percent < 0) || (percent > 100) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	cacheKey := propertyQuotaCacheKey(propID)
+
+	if percent == 0 {
+		if err := impl.querier.DeletePropertyQuota(ctx, propID); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete property quota", "propID", propID, common.ErrAttr(err))
+			return nil, err
+		}
+
+		slog.DebugContext(ctx, "Deleted property quota", "propID", propID)
+		_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+		impl.notifyCacheInvalidation(ctx, cacheKey)
+
+		return nil, nil
+	}
+
+	quota, err := impl.querier.UpsertPropertyQuota(ctx, &dbgen.UpsertPropertyQuotaParams{
+		PropertyID: propID,
+		Percent:    percent,
+		Period:     period,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update property quota", "propID", propID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated property quota", "propID", propID, "percent", percent, "period", period)
+
+	_ = impl.cache.Set(ctx, cacheKey, quota, impl.ttl)
+	impl.notifyCacheInvalidation(ctx, cacheKey)
+
+	return quota, nil
+}
+
+// RetrieveOwnerAllocatedQuota returns total percent of the owner's plan quota allocated to properties except one
+func (impl *BusinessStoreImpl) RetrieveOwnerAllocatedQuota(ctx context.Context, ownerID, exceptPropID int32) (int, error) {
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
+
+	allocated, err := impl.querier.GetOwnerAllocatedQuota(ctx, &dbgen.GetOwnerAllocatedQuotaParams{
+		OrgOwnerID: Int(ownerID),
+		ID:         exceptPropID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve allocated quota", "ownerID", ownerID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	return int(allocated), nil
+}
+
+// RetrieveOrgDigestCandidates returns organizations that did not get the digest for the week starting at {week}
+func (impl *BusinessStoreImpl) RetrieveOrgDigestCandidates(ctx context.Context, week time.Time, limit int) ([]*dbgen.GetOrgDigestCandidatesRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	orgs, err := impl.querier.GetOrgDigestCandidates(ctx, &dbgen.GetOrgDigestCandidatesParams{
+		WeekStart: Timestampz(week),
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetOrgDigestCandidatesRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org digest candidates", common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved org digest candidates", "count", len(orgs), "week", week)
+
+	return orgs, nil
+}
+
+// CreateOrgDigest marks digest for the week as sent and returns false if it was already marked before
+func (impl *BusinessStoreImpl) CreateOrgDigest(ctx context.Context, orgID int32, week time.Time) (bool, error) {
+	if impl.querier == nil {
+		return false, ErrMaintenance
+	}
+
+	if _, err := impl.querier.CreateOrgDigest(ctx, &dbgen.CreateOrgDigestParams{
+		OrgID:     orgID,
+		WeekStart: Timestampz(week),
+	}); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to create org digest", "orgID", orgID, "week", week, common.ErrAttr(err))
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RetrieveOrgDigestActivity counts new properties and membership changes of the organization in [from, to)
+func (impl *BusinessStoreImpl) RetrieveOrgDigestActivity(ctx context.Context, orgID int32, from, to time.Time) (*dbgen.GetOrgDigestActivityRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	user, err := impl.querier.UpdateUserData(ctx, &dbgen.UpdateUserDataParams{
-		Name:  name,
-		Email: newEmail,
-		ID:    userID,
+	activity, err := impl.querier.GetOrgDigestActivity(ctx, &dbgen.GetOrgDigestActivityParams{
+		OrgID:       Int(orgID),
+		CreatedAt:   Timestampz(from),
+		CreatedAt_2: Timestampz(to),
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to update user", "userID", userID, common.ErrAttr(err))
-		return err
+		slog.ErrorContext(ctx, "Failed to retrieve org digest activity", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Updated user", "userID", userID)
+	return activity, nil
+}
 
-	if user != nil {
-		_ = impl.cache.Set(ctx, userCacheKey(user.ID), user, impl.ttl)
+// UpdateLicenseNode records a heartbeat of the server instance and removes instances that were not seen since {staleBefore}
+func (impl *BusinessStoreImpl) UpdateLicenseNode(ctx context.Context, nodeID, version string, staleBefore time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpsertLicenseNode(ctx, &dbgen.UpsertLicenseNodeParams{
+		ID:      nodeID,
+		Version: version,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update license node", "nodeID", nodeID, common.ErrAttr(err))
+		return err
+	}
+
+	if err := impl.querier.DeleteStaleLicenseNodes(ctx, Timestampz(staleBefore)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete stale license nodes", common.ErrAttr(err))
+		return err
 	}
 
 	return nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveUserAPIKeys(ctx context.Context, userID int32) ([]*dbgen.APIKey, error) {
-	cacheKey := userAPIKeysCacheKey(userID)
+func (impl *BusinessStoreImpl) RetrieveActiveLicenseNodesCount(ctx context.Context, since time.Time) (int64, error) {
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
 
-	if keys, err := fetchCachedMany[dbgen.APIKey](ctx, impl.cache, cacheKey); err == nil {
-		return keys, nil
-	} else if err == ErrNegativeCacheHit {
-		return nil, ErrNegativeCacheHit
+	count, err := impl.querier.GetActiveLicenseNodesCount(ctx, Timestampz(since))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve active license nodes count", common.ErrAttr(err))
+		return 0, err
 	}
 
+	return count, nil
+}
+
+// RetrieveInstanceCounts returns number of users, organizations and properties that are not deleted
+func (impl *BusinessStoreImpl) RetrieveInstanceCounts(ctx context.Context) (*dbgen.GetInstanceCountsRow, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	keys, err := impl.querier.GetUserAPIKeys(ctx, Int(userID))
+	counts, err := impl.querier.GetInstanceCounts(ctx)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			_ = impl.cache.Set(ctx, cacheKey, emptyAPIKeys, impl.ttl)
-			return emptyAPIKeys, nil
-		}
-		slog.ErrorContext(ctx, "Failed to retrieve user API keys", "userID", userID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve instance counts", common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Retrieved API keys", "count", len(keys))
-
-	if len(keys) > 0 {
-		_ = impl.cache.Set(ctx, cacheKey, keys, impl.ttl)
-	}
+	return counts, nil
+}
 
-	return keys, err
+// InMaintenance returns true if the store can only serve cached data
+func (impl *BusinessStoreImpl) InMaintenance() bool {
+	return impl.querier == nil
 }
 
-func (impl *BusinessStoreImpl) UpdateAPIKey(ctx context.Context, externalID pgtype.UUID, expiration time.Time, enabled bool) error {
+// UpdatePropertyTags replaces all tags of the property. It should be called in a transaction.
+func (impl *BusinessStoreImpl) UpdatePropertyTags(ctx context.Context, propertyID int32, tags []string) error {
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	key, err := impl.querier.UpdateAPIKey(ctx, &dbgen.UpdateAPIKeyParams{
-		ExpiresAt:  Timestampz(expiration),
-		Enabled:    Bool(enabled),
-		ExternalID: externalID,
-	})
-
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to update API key", "externalID", UUIDToSecret(externalID), common.ErrAttr(err))
+	if err := impl.querier.DeleteOtherPropertyTags(ctx, &dbgen.DeleteOtherPropertyTagsParams{
+		PropertyID: propertyID,
+		Tags:       tags,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete property tags", "propID", propertyID, common.ErrAttr(err))
 		return err
 	}
 
-	slog.DebugContext(ctx, "Updated API key", "externalID", UUIDToSecret(externalID))
-
-	if key != nil {
-		secret := UUIDToSecret(key.ExternalID)
-		cacheKey := APIKeyCacheKey(secret)
-		_ = impl.cache.Set(ctx, cacheKey, key, apiKeyTTL)
-
-		// invalidate keys cache
-		_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(key.UserID.Int32))
+	if len(tags) > 0 {
+		if err := impl.querier.AddPropertyTags(ctx, &dbgen.AddPropertyTagsParams{
+			PropertyID: propertyID,
+			Tags:       tags,
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to add property tags", "propID", propertyID, common.ErrAttr(err))
+			return err
+		}
 	}
 
+	slog.DebugContext(ctx, "Updated property tags", "propID", propertyID, "count", len(tags))
+
 	return nil
 }
 
-func (impl *BusinessStoreImpl) CreateAPIKey(ctx context.Context, userID int32, name string, expiration time.Time, requestsPerSecond float64) (*dbgen.APIKey, error) {
+const maxUserAgentLength = 512
+
+// RecordUserLogin saves the sign-in event of the user. Returned login has NewOrigin set if it came from a country or
+// a device that were not seen before for this user (the very first sign-in is never considered new).
+func (impl *BusinessStoreImpl) RecordUserLogin(ctx context.Context, userID int32, ip, country, device, userAgent, sessionID string) (*dbgen.UserLogin, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	// current logic is that initial values will be set per plan and adjusted manually in DB if requested by customer
-	const minAPIKeyRequestsBurst = 20
-	burst := int32(requestsPerSecond * 5)
-	if burst < minAPIKeyRequestsBurst {
-		burst = minAPIKeyRequestsBurst
-	}
-
-	key, err := impl.querier.CreateAPIKey(ctx, &dbgen.CreateAPIKeyParams{
-		Name:              name,
-		UserID:            Int(userID),
-		ExpiresAt:         Timestampz(expiration),
-		RequestsPerSecond: requestsPerSecond,
-		RequestsBurst:     burst,
+	origins, err := impl.querier.GetUserLoginOrigins(ctx, &dbgen.GetUserLoginOriginsParams{
+		UserID:  userID,
+		Country: country,
+		Device:  device,
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to create API key", "userID", userID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve user login origins", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	if key != nil {
-		secret := UUIDToSecret(key.ExternalID)
-		cacheKey := APIKeyCacheKey(secret)
-		_ = impl.cache.Set(ctx, cacheKey, key, apiKeyTTL)
+	newOrigin := (origins.Total > 0) &&
+		(((len(country) > 0) && (origins.SameCountry == 0)) || ((len(device) > 0) && (origins.SameDevice == 0)))
 
-		// invalidate keys cache
-		_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
 	}
 
-	return key, nil
+	login, err := impl.querier.CreateUserLogin(ctx, &dbgen.CreateUserLoginParams{
+		UserID:    userID,
+		IpAddress: ip,
+		Country:   country,
+		Device:    device,
+		UserAgent: userAgent,
+		NewOrigin: newOrigin,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create user login", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Recorded user login", "userID", userID, "loginID", login.ID, "newOrigin", newOrigin)
+
+	return login, nil
 }
 
-func (impl *BusinessStoreImpl) DeleteAPIKey(ctx context.Context, userID, keyID int32) error {
+// RetrieveUserLogins returns the most recent sign-ins of the user
+func (impl *BusinessStoreImpl) RetrieveUserLogins(ctx context.Context, userID int32, limit int) ([]*dbgen.UserLogin, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	key, err := impl.querier.DeleteAPIKey(ctx, &dbgen.DeleteAPIKeyParams{
-		ID:     keyID,
-		UserID: Int(userID),
+	logins, err := impl.querier.GetUserLogins(ctx, &dbgen.GetUserLoginsParams{
+		UserID: userID,
+		Limit:  int32(limit),
 	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			slog.ErrorContext(ctx, "Failed to find API Key", "keyID", keyID, "userID", userID)
-			return ErrRecordNotFound
+			return []*dbgen.UserLogin{}, nil
 		}
-
-		slog.ErrorContext(ctx, "Failed to delete API key", "keyID", keyID, "userID", userID, common.ErrAttr(err))
-		return err
+		slog.ErrorContext(ctx, "Failed to retrieve user logins", "userID", userID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Deleted API Key", "keyID", keyID, "userID", userID)
-
-	// invalidate keys cache
-	if key != nil {
-		secret := UUIDToSecret(key.ExternalID)
-		cacheKey := APIKeyCacheKey(secret)
-		_ = impl.cache.Delete(ctx, cacheKey)
+	return logins, nil
+}
 
+func (impl *BusinessStoreImpl) RetrieveUserLogin(ctx context.Context, loginID int32) (*dbgen.UserLogin, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+	login, err := impl.querier.GetUserLoginByID(ctx, loginID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve user login", "loginID", loginID, common.ErrAttr(err))
+		return nil, err
+	}
 
-	return nil
+	return login, nil
 }
 
-func (impl *BusinessStoreImpl) UpdateUserAPIKeysRateLimits(ctx context.Context, userID int32, requestsPerSecond float64) error {
+// RevokeOtherUserLogins marks sessions of all user's sign-ins, except for the current one, as revoked and returns them
+func (impl *BusinessStoreImpl) RevokeOtherUserLogins(ctx context.Context, userID int32, currentLoginID int32) ([]*dbgen.UserLogin, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	err := impl.querier.UpdateUserAPIKeysRateLimits(ctx, &dbgen.UpdateUserAPIKeysRateLimitsParams{
-		RequestsPerSecond: requestsPerSecond,
-		UserID:            Int(userID),
+	logins, err := impl.querier.RevokeUserLogins(ctx, &dbgen.RevokeUserLoginsParams{
+		UserID: userID,
+		ID:     currentLoginID,
 	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			slog.WarnContext(ctx, "Failed to find user API Keys", "userID", userID)
-			return ErrRecordNotFound
+			return []*dbgen.UserLogin{}, nil
 		}
-
-		slog.ErrorContext(ctx, "Failed to update user API keys rate limit", "userID", userID, "rateLimit", requestsPerSecond,
-			common.ErrAttr(err))
-
-		return err
+		slog.ErrorContext(ctx, "Failed to revoke user logins", "userID", userID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Updated user API keys rate limit", "userID", userID)
-
-	// invalidate keys cache
-	_ = impl.cache.Delete(ctx, userAPIKeysCacheKey(userID))
+	slog.InfoContext(ctx, "Revoked user logins", "userID", userID, "count", len(logins))
 
-	return nil
+	return logins, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveUsersWithoutSubscription(ctx context.Context, userIDs []int32) ([]*dbgen.User, error) {
+// RetrieveActiveUserLogins returns not revoked sign-ins of the user (newest first), sessions of which are still alive
+func (impl *BusinessStoreImpl) RetrieveActiveUserLogins(ctx context.Context, userID int32) ([]*dbgen.UserLogin, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	users, err := impl.querier.GetUsersWithoutSubscription(ctx, userIDs)
+	logins, err := impl.querier.GetActiveUserLogins(ctx, &dbgen.GetActiveUserLoginsParams{
+		UserID:        userID,
+		SessionPrefix: sessionPrefix,
+	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return []*dbgen.User{}, nil
+			return []*dbgen.UserLogin{}, nil
 		}
-
-		slog.ErrorContext(ctx, "Failed to retrieve users without subscriptions", "userIDs", len(userIDs), common.ErrAttr(err))
-
+		slog.ErrorContext(ctx, "Failed to retrieve active user logins", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched users without subscriptions", "count", len(users), "userIDs", len(userIDs))
-
-	return users, err
+	return logins, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveSubscriptionsByUserIDs(ctx context.Context, userIDs []int32) ([]*dbgen.GetSubscriptionsByUserIDsRow, error) {
+// RevokeUserLogins marks sessions of the specific user's sign-ins as revoked and returns the ones that were revoked
+func (impl *BusinessStoreImpl) RevokeUserLogins(ctx context.Context, userID int32, loginIDs []int32) ([]*dbgen.UserLogin, error) {
+	if len(loginIDs) == 0 {
+		return []*dbgen.UserLogin{}, nil
+	}
+
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	subscriptions, err := impl.querier.GetSubscriptionsByUserIDs(ctx, userIDs)
+	logins, err := impl.querier.RevokeUserLoginsByID(ctx, &dbgen.RevokeUserLoginsByIDParams{
+		UserID:   userID,
+		LoginIDs: loginIDs,
+	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return []*dbgen.GetSubscriptionsByUserIDsRow{}, nil
+			return []*dbgen.UserLogin{}, nil
 		}
-
-		slog.ErrorContext(ctx, "Failed to retrieve user subscriptions", "userIDs", len(userIDs), common.ErrAttr(err))
-
+		slog.ErrorContext(ctx, "Failed to revoke user logins", "userID", userID, "count", len(loginIDs), common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched users subscriptions", "count", len(subscriptions), "userIDs", len(userIDs))
+	slog.InfoContext(ctx, "Revoked user logins", "userID", userID, "count", len(logins))
 
-	return subscriptions, err
+	return logins, nil
 }
 
-func (impl *BusinessStoreImpl) AcquireLock(ctx context.Context, name string, data []byte, expiration time.Time) (*dbgen.Lock, error) {
-	if (len(name) == 0) || expiration.IsZero() {
-		return nil, ErrInvalidInput
-	}
-
+// RetrieveUserLockout returns failed two-factor attempts and lockout state of the user
+func (impl *BusinessStoreImpl) RetrieveUserLockout(ctx context.Context, userID int32) (*dbgen.UserLockout, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	lock, err := impl.querier.InsertLock(ctx, &dbgen.InsertLockParams{
-		Name:      name,
-		Data:      data,
-		ExpiresAt: Timestampz(expiration),
-	})
+	lockout, err := impl.querier.GetUserLockout(ctx, userID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			// slog.WarnContext(ctx, "Lock is still taken", "name", name)
-			return nil, ErrLocked
+			return nil, ErrRecordNotFound
 		}
-		slog.ErrorContext(ctx, "Failed to acquire a lock", "name", name, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve user lockout", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Acquired a lock", "name", name, "expires_at", lock.ExpiresAt.Time)
-
-	return lock, nil
+	return lockout, nil
 }
 
-func (impl *BusinessStoreImpl) ReleaseLock(ctx context.Context, name string) error {
+// RecordTwoFactorFailure counts failed two-factor attempt of the user and locks the account until tnow+duration
+// after maxAttempts failures. Failures are counted from scratch if the previous one happened more than duration
+// ago. Returned flag is set only if the account was locked by this call.
+func (impl *BusinessStoreImpl) RecordTwoFactorFailure(ctx context.Context, userID int32, maxAttempts int, duration time.Duration, tnow time.Time) (*dbgen.UserLockout, bool, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, false, ErrMaintenance
 	}
-	err := impl.querier.DeleteLock(ctx, name)
+
+	lockout, err := impl.querier.IncrementUserFailedAttempts(ctx, &dbgen.IncrementUserFailedAttemptsParams{
+		UserID:       userID,
+		LastFailedAt: Timestampz(tnow),
+		DecayBefore:  Timestampz(tnow.Add(-duration)),
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to release a lock", "name", name, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to record two-factor failure", "userID", userID, common.ErrAttr(err))
+		return nil, false, err
 	}
 
-	return err
+	if int(lockout.FailedAttempts) < maxAttempts {
+		slog.DebugContext(ctx, "Recorded two-factor failure", "userID", userID, "attempts", lockout.FailedAttempts)
+		return lockout, false, nil
+	}
+
+	lockout, err = impl.querier.LockUser(ctx, &dbgen.LockUserParams{
+		UserID:      userID,
+		LockedUntil: Timestampz(tnow.Add(duration)),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to lock user", "userID", userID, common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	slog.WarnContext(ctx, "Audit: locked user after failed two-factor attempts", "userID", userID, "attempts", maxAttempts,
+		"until", lockout.LockedUntil.Time)
+
+	return lockout, true, nil
 }
 
-func (impl *BusinessStoreImpl) DeleteDeletedRecords(ctx context.Context, before time.Time) error {
+func (impl *BusinessStoreImpl) ResetUserLockout(ctx context.Context, userID int32) error {
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	err := impl.querier.DeleteDeletedRecords(ctx, Timestampz(before))
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to cleanup deleted records", "before", before, common.ErrAttr(err))
+	if err := impl.querier.DeleteUserLockout(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset user lockout", "userID", userID, common.ErrAttr(err))
+		return err
 	}
 
-	return err
+	return nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveSoftDeletedProperties(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetSoftDeletedPropertiesRow, error) {
+func (impl *BusinessStoreImpl) UpdateUserReverifyNewLogins(ctx context.Context, userID int32, enabled bool) (*dbgen.User, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	properties, err := impl.querier.GetSoftDeletedProperties(ctx, &dbgen.GetSoftDeletedPropertiesParams{
-		DeletedAt: Timestampz(before),
-		Limit:     int32(limit),
+	user, err := impl.querier.UpdateUserReverifyNewLogins(ctx, &dbgen.UpdateUserReverifyNewLoginsParams{
+		ID:                userID,
+		ReverifyNewLogins: enabled,
 	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to update user re-verification setting", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated user re-verification setting", "userID", userID, "enabled", enabled)
 
+	_ = impl.cache.Set(ctx, userCacheKey(user.ID), user, impl.ttl)
+
+	return user, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateUserTimezone(ctx context.Context, userID int32, timezone string) (*dbgen.User, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	user, err := impl.querier.UpdateUserTimezone(ctx, &dbgen.UpdateUserTimezoneParams{
+		ID:       userID,
+		Timezone: timezone,
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve soft deleted properties", "before", before, common.ErrAttr(err))
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to update user timezone", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched soft-deleted properties", "count", len(properties), "before", before)
+	slog.DebugContext(ctx, "Updated user timezone", "userID", userID, "timezone", timezone)
 
-	return properties, nil
+	_ = impl.cache.Set(ctx, userCacheKey(user.ID), user, impl.ttl)
+
+	return user, nil
 }
 
-func (impl *BusinessStoreImpl) DeleteProperties(ctx context.Context, ids []int32) error {
-	if len(ids) == 0 {
-		slog.WarnContext(ctx, "No properties to delete")
-		return nil
+// RetrieveUserPreferences returns portal UI preferences of the user, if they were ever saved
+func (impl *BusinessStoreImpl) RetrieveUserPreferences(ctx context.Context, userID int32) (*dbgen.UserPreference, error) {
+	cacheKey := userPreferencesCacheKey(userID)
+
+	if prefs, err := fetchCachedOne[dbgen.UserPreference](ctx, impl.cache, cacheKey); err == nil {
+		return prefs, nil
+	} else if err == ErrNegativeCacheHit {
+		return nil, ErrRecordNotFound
 	}
 
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	err := impl.querier.DeleteProperties(ctx, ids)
+	prefs, err := impl.querier.GetUserPreferences(ctx, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve user preferences", "userID", userID, common.ErrAttr(err))
 
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to delete properties", "count", len(ids), common.ErrAttr(err))
+		return nil, err
 	}
 
-	return err
+	_ = impl.cache.Set(ctx, cacheKey, prefs, impl.ttl)
+
+	return prefs, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveSoftDeletedOrganizations(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetSoftDeletedOrganizationsRow, error) {
+// UpdateUserPreferences saves portal UI preferences of the user (defaultOrgID <= 0 resets the default organization)
+func (impl *BusinessStoreImpl) UpdateUserPreferences(ctx context.Context, userID int32, theme dbgen.UiTheme, density dbgen.TableDensity, defaultOrgID int32) (*dbgen.UserPreference, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	organizations, err := impl.querier.GetSoftDeletedOrganizations(ctx, &dbgen.GetSoftDeletedOrganizationsParams{
-		DeletedAt: Timestampz(before),
-		Limit:     int32(limit),
-	})
+	var orgID pgtype.Int4
+	if defaultOrgID > 0 {
+		orgID = Int(defaultOrgID)
+	}
 
+	prefs, err := impl.querier.UpsertUserPreferences(ctx, &dbgen.UpsertUserPreferencesParams{
+		UserID:       userID,
+		Theme:        theme,
+		TableDensity: density,
+		DefaultOrgID: orgID,
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve soft deleted organizations", "before", before, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to update user preferences", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched soft-deleted organizations", "count", len(organizations), "before", before)
+	slog.DebugContext(ctx, "Updated user preferences", "userID", userID, "theme", theme, "density", density,
+		"defaultOrgID", defaultOrgID)
 
-	return organizations, nil
-}
+	cacheKey := userPreferencesCacheKey(userID)
+	_ = impl.cache.Set(ctx, cacheKey, prefs, impl.ttl)
+	impl.notifyCacheInvalidation(ctx, cacheKey)
 
-func (impl *BusinessStoreImpl) DeleteOrganizations(ctx context.Context, ids []int32) error {
-	if len(ids) == 0 {
-		slog.WarnContext(ctx, "No organizations to delete")
-		return nil
-	}
+	return prefs, nil
+}
 
+// DismissOnboarding hides onboarding checklist of the user for good
+func (impl *BusinessStoreImpl) DismissOnboarding(ctx context.Context, userID int32) (*dbgen.UserPreference, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	err := impl.querier.DeleteOrganizations(ctx, ids)
-
+	prefs, err := impl.querier.DismissOnboarding(ctx, userID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to delete organizations", "count", len(ids), common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to dismiss onboarding", "userID", userID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	return err
+	slog.DebugContext(ctx, "Dismissed onboarding", "userID", userID)
+
+	cacheKey := userPreferencesCacheKey(userID)
+	_ = impl.cache.Set(ctx, cacheKey, prefs, impl.ttl)
+	impl.notifyCacheInvalidation(ctx, cacheKey)
+
+	return prefs, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveSoftDeletedUsers(ctx context.Context, before time.Time, limit int) ([]*dbgen.GetSoftDeletedUsersRow, error) {
+// CreateUserEmail adds (or re-sends verification of) a secondary email address of the user. Returns
+// ErrEmailVerified if the address is already verified.
+func (impl *BusinessStoreImpl) CreateUserEmail(ctx context.Context, userID int32, email, verifyToken string, expiresAt time.Time) (*dbgen.UserEmail, error) {
+	if (len(email) == 0) || (len(verifyToken) == 0) {
+		return nil, ErrInvalidInput
+	}
+
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	users, err := impl.querier.GetSoftDeletedUsers(ctx, &dbgen.GetSoftDeletedUsersParams{
-		DeletedAt: Timestampz(before),
-		Limit:     int32(limit),
+	userEmail, err := impl.querier.CreateUserEmail(ctx, &dbgen.CreateUserEmailParams{
+		UserID:          userID,
+		Email:           email,
+		VerifyTokenHash: Text(emailChangeTokenHash(verifyToken)),
+		VerifyExpiresAt: Timestampz(expiresAt),
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve soft deleted users", "before", before, common.ErrAttr(err))
+		if err == pgx.ErrNoRows {
+			// conflict with already verified address
+			return nil, ErrEmailVerified
+		}
+		slog.ErrorContext(ctx, "Failed to create user email", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched soft-deleted users", "count", len(users), "before", before)
+	slog.InfoContext(ctx, "Created secondary user email", "userID", userID, "emailID", userEmail.ID)
 
-	return users, nil
+	return userEmail, nil
 }
 
-func (impl *BusinessStoreImpl) DeleteUsers(ctx context.Context, ids []int32) error {
-	if len(ids) == 0 {
-		slog.WarnContext(ctx, "No users to delete")
-		return nil
-	}
-
+func (impl *BusinessStoreImpl) RetrieveUserEmails(ctx context.Context, userID int32) ([]*dbgen.UserEmail, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	err := impl.querier.DeleteUsers(ctx, ids)
-
+	emails, err := impl.querier.GetUserEmails(ctx, userID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to delete users", "count", len(ids), common.ErrAttr(err))
+		if err == pgx.ErrNoRows {
+			return []*dbgen.UserEmail{}, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve user emails", "userID", userID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	return err
+	return emails, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveNotification(ctx context.Context, id int32) (*dbgen.SystemNotification, error) {
-	cacheKey := notificationCacheKey(id)
-
-	if notif, err := fetchCachedOne[dbgen.SystemNotification](ctx, impl.cache, cacheKey); err == nil {
-		return notif, nil
-	} else if err == ErrNegativeCacheHit {
-		return nil, ErrNegativeCacheHit
+// VerifyUserEmail marks secondary email as verified if verification token is valid
+func (impl *BusinessStoreImpl) VerifyUserEmail(ctx context.Context, verifyToken string, tnow time.Time) (*dbgen.UserEmail, error) {
+	if len(verifyToken) == 0 {
+		return nil, ErrInvalidInput
 	}
 
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	notification, err := impl.querier.GetNotificationById(ctx, id)
+	userEmail, err := impl.querier.GetUserEmailByTokenHash(ctx, Text(emailChangeTokenHash(verifyToken)))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			_ = impl.cache.SetMissing(ctx, cacheKey, impl.ttl)
 			return nil, ErrRecordNotFound
 		}
+		slog.ErrorContext(ctx, "Failed to retrieve user email", common.ErrAttr(err))
+		return nil, err
+	}
 
-		slog.ErrorContext(ctx, "Failed to retrieve notification by ID", "notifID", id, common.ErrAttr(err))
+	if userEmail.VerifiedAt.Valid || !tnow.Before(userEmail.VerifyExpiresAt.Time) {
+		slog.WarnContext(ctx, "User email cannot be verified", "emailID", userEmail.ID, "verified", userEmail.VerifiedAt.Valid,
+			"expiresAt", userEmail.VerifyExpiresAt.Time)
+		return nil, ErrRecordNotFound
+	}
 
+	verified, err := impl.querier.MarkUserEmailVerified(ctx, userEmail.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to mark user email as verified", "emailID", userEmail.ID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	if notification != nil {
-		_ = impl.cache.Set(ctx, cacheKey, notification, impl.ttl)
+	slog.InfoContext(ctx, "Verified secondary user email", "userID", verified.UserID, "emailID", verified.ID)
+
+	return verified, nil
+}
+
+// DeleteUserEmail removes secondary email of the user (organizations that used it as billing contact fall back to primary)
+func (impl *BusinessStoreImpl) DeleteUserEmail(ctx context.Context, userID, emailID int32) error {
+	if impl.querier == nil {
+		return ErrMaintenance
 	}
 
-	return notification, nil
+	if err := impl.querier.DeleteUserEmail(ctx, &dbgen.DeleteUserEmailParams{ID: emailID, UserID: userID}); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete user email", "userID", userID, "emailID", emailID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted secondary user email", "userID", userID, "emailID", emailID)
+
+	return nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveUserNotification(ctx context.Context, tnow time.Time, userID int32) (*dbgen.SystemNotification, error) {
+// RetrieveOrgBillingContacts returns billing contacts of organizations, that use secondary emails of the user
+func (impl *BusinessStoreImpl) RetrieveOrgBillingContacts(ctx context.Context, userID int32) ([]*dbgen.OrgBillingContact, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	n, err := impl.querier.GetLastActiveNotification(ctx, &dbgen.GetLastActiveNotificationParams{
-		Column1: Timestampz(tnow),
-		UserID:  Int(userID),
-	})
-
+	contacts, err := impl.querier.GetOrgBillingContacts(ctx, userID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, ErrRecordNotFound
+			return []*dbgen.OrgBillingContact{}, nil
 		}
-		slog.ErrorContext(ctx, "Failed to retrieve system notification", "userID", userID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve org billing contacts", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	cacheKey := notificationCacheKey(n.ID)
-	_ = impl.cache.Set(ctx, cacheKey, n, impl.ttl)
-
-	slog.DebugContext(ctx, "Retrieved system notification", "userID", userID, "notifID", n.ID)
-
-	return n, err
+	return contacts, nil
 }
 
-func (impl *BusinessStoreImpl) CreateNotification(ctx context.Context, message string, tnow time.Time, duration *time.Duration, userID *int32) (*dbgen.SystemNotification, error) {
+// UpdateOrgBillingContact sets verified secondary email of the owner as billing contact of the organization
+// (emailID 0 resets it back to the primary email of the owner)
+func (impl *BusinessStoreImpl) UpdateOrgBillingContact(ctx context.Context, org *dbgen.Organization, userID, emailID int32) error {
 	if impl.querier == nil {
-		return nil, ErrMaintenance
+		return ErrMaintenance
 	}
 
-	arg := &dbgen.CreateNotificationParams{
-		Message:   message,
-		StartDate: Timestampz(tnow),
-		EndDate:   pgtype.Timestamptz{Valid: false},
-		UserID:    pgtype.Int4{Valid: false},
+	if org.UserID.Int32 != userID {
+		slog.WarnContext(ctx, "Only org owner can change billing contact", "orgID", org.ID, "userID", userID)
+		return ErrPermissions
 	}
 
-	if duration != nil {
-		arg.EndDate = Timestampz(tnow.Add(*duration))
-	}
+	if emailID == 0 {
+		if err := impl.querier.DeleteOrgBillingContact(ctx, org.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete org billing contact", "orgID", org.ID, common.ErrAttr(err))
+			return err
+		}
 
-	if userID != nil {
-		arg.UserID = Int(*userID)
+		return nil
 	}
 
-	n, err := impl.querier.CreateNotification(ctx, arg)
-
+	emails, err := impl.RetrieveUserEmails(ctx, userID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to create a system notification", common.ErrAttr(err))
-		return nil, err
+		return err
 	}
 
-	if n != nil {
-		cacheKey := notificationCacheKey(n.ID)
-		_ = impl.cache.Set(ctx, cacheKey, n, impl.ttl)
+	if !slices.ContainsFunc(emails, func(e *dbgen.UserEmail) bool { return (e.ID == emailID) && e.VerifiedAt.Valid }) {
+		slog.WarnContext(ctx, "Billing contact is not a verified email of the user", "userID", userID, "emailID", emailID)
+		return ErrInvalidInput
 	}
 
-	slog.DebugContext(ctx, "Created system notification", "notifID", n.ID)
+	if err := impl.querier.UpsertOrgBillingContact(ctx, &dbgen.UpsertOrgBillingContactParams{OrgID: org.ID, EmailID: emailID}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update org billing contact", "orgID", org.ID, "emailID", emailID, common.ErrAttr(err))
+		return err
+	}
 
-	return n, err
+	slog.InfoContext(ctx, "Updated org billing contact", "orgID", org.ID, "emailID", emailID)
+
+	return nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveProperties(ctx context.Context, limit int) ([]*dbgen.Property, error) {
+// RetrieveOrgBillingEmail returns verified billing contact of the organization or ErrRecordNotFound if it's not set
+func (impl *BusinessStoreImpl) RetrieveOrgBillingEmail(ctx context.Context, orgID int32) (string, error) {
 	if impl.querier == nil {
-		return nil, ErrMaintenance
+		return "", ErrMaintenance
 	}
 
-	properties, err := impl.querier.GetProperties(ctx, int32(limit))
-
+	email, err := impl.querier.GetOrgBillingEmail(ctx, orgID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve properties", common.ErrAttr(err))
-		return nil, err
+		if err == pgx.ErrNoRows {
+			return "", ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve org billing email", "orgID", orgID, common.ErrAttr(err))
+		return "", err
 	}
 
-	slog.DebugContext(ctx, "Fetched properties", "count", len(properties))
-
-	return properties, nil
+	return email, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveUserPropertiesCount(ctx context.Context, userID int32) (int64, error) {
+// CreateSupportTicket persists support request of the user, ticketID is generated by the support form
+func (impl *BusinessStoreImpl) CreateSupportTicket(ctx context.Context, userID int32, ticketID, subject, message string) (*dbgen.SupportTicket, error) {
+	if (len(ticketID) == 0) || (len(message) == 0) {
+		return nil, ErrInvalidInput
+	}
+
 	if impl.querier == nil {
-		return 0, ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	count, err := impl.querier.GetUserPropertiesCount(ctx, Int(userID))
+	ticket, err := impl.querier.CreateSupportTicket(ctx, &dbgen.CreateSupportTicketParams{
+		TicketID: ticketID,
+		UserID:   userID,
+		Subject:  subject,
+		Message:  message,
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve user properties count", "userID", userID, common.ErrAttr(err))
-		return 0, err
+		slog.ErrorContext(ctx, "Failed to create support ticket", "userID", userID, "ticketID", ticketID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched user properties count", "userID", userID, "count", count)
+	slog.InfoContext(ctx, "Created support ticket", "userID", userID, "ticketID", ticketID, "id", ticket.ID)
 
-	return count, nil
+	return ticket, nil
 }
 
-func (s *BusinessStoreImpl) GetCachedPropertyBySitekey(ctx context.Context, sitekey string) (*dbgen.Property, error) {
-	if sitekey == TestPropertySitekey {
-		return nil, ErrTestProperty
+// RetrieveUserSupportTickets returns the latest support tickets of the user (newest first)
+func (impl *BusinessStoreImpl) RetrieveUserSupportTickets(ctx context.Context, userID int32, limit int) ([]*dbgen.SupportTicket, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	return s.getCachedPropertyBySitekey(ctx, sitekey)
-}
-
-func (s *BusinessStoreImpl) RetrieveUser(ctx context.Context, id int32) (*dbgen.User, error) {
-	user, err := s.retrieveUser(ctx, id)
+	tickets, err := impl.querier.GetUserSupportTickets(ctx, &dbgen.GetUserSupportTicketsParams{
+		UserID: userID,
+		Limit:  int32(limit),
+	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.SupportTicket{}, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve support tickets", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	if user.DeletedAt.Valid {
-		slog.WarnContext(ctx, "User is soft-deleted", "userID", id, "deletedAt", user.DeletedAt.Time)
-		return user, ErrSoftDeleted
-	}
-
-	return user, nil
+	return tickets, nil
 }
 
-func (s *BusinessStoreImpl) RetrieveUserOrganization(ctx context.Context, userID, orgID int32) (*dbgen.Organization, error) {
-	org, level, err := s.retrieveOrganizationWithAccess(ctx, userID, orgID)
-	if err != nil {
-		return nil, err
+// RetrieveSupportTicketsReplies returns replies of all given tickets in chronological order
+func (impl *BusinessStoreImpl) RetrieveSupportTicketsReplies(ctx context.Context, ids []int32) ([]*dbgen.SupportTicketReply, error) {
+	if len(ids) == 0 {
+		return []*dbgen.SupportTicketReply{}, nil
 	}
 
-	if !level.Valid {
-		slog.WarnContext(ctx, "User cannot access this org", "orgID", orgID, "userID", userID)
-		return nil, ErrPermissions
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	if org.DeletedAt.Valid {
-		slog.WarnContext(ctx, "Organization is soft-deleted", "orgID", orgID, "deletedAt", org.DeletedAt.Time)
-		return org, ErrSoftDeleted
+	replies, err := impl.querier.GetSupportTicketsReplies(ctx, ids)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.SupportTicketReply{}, nil
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve support ticket replies", "tickets", len(ids), common.ErrAttr(err))
+		return nil, err
 	}
 
-	return org, nil
+	return replies, nil
 }
 
-func (s *BusinessStoreImpl) RetrieveOrgProperty(ctx context.Context, orgID, propID int32) (*dbgen.Property, error) {
-	property, err := s.retrieveOrgProperty(ctx, orgID, propID)
-	if err != nil {
-		return nil, err
+func (impl *BusinessStoreImpl) RetrieveSupportTicket(ctx context.Context, ticketID string) (*dbgen.SupportTicket, error) {
+	if len(ticketID) == 0 {
+		return nil, ErrInvalidInput
 	}
 
-	if !property.OrgID.Valid || (property.OrgID.Int32 != orgID) {
-		slog.ErrorContext(ctx, "Property org does not match", "propertyOrgID", property.OrgID.Int32, "orgID", orgID)
-		return nil, ErrPermissions
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	if property.DeletedAt.Valid {
-		slog.WarnContext(ctx, "Property is soft-deleted", "propID", propID, "deletedAt", property.DeletedAt.Time)
-		return property, ErrSoftDeleted
+	ticket, err := impl.querier.GetSupportTicketByTicketID(ctx, ticketID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve support ticket", "ticketID", ticketID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	return property, nil
+	return ticket, nil
 }
 
-func (s *BusinessStoreImpl) CreateNewAccount(ctx context.Context, params *dbgen.CreateSubscriptionParams, email, name, orgName string, existingUserID int32) (*dbgen.User, *dbgen.Organization, error) {
-	if s.querier == nil {
-		return nil, nil, ErrMaintenance
+// AddSupportTicketReply appends reply to the ticket thread. Ticket becomes "answered" after the reply from support
+// and is (re)opened after the reply from the user
+func (impl *BusinessStoreImpl) AddSupportTicketReply(ctx context.Context, ticket *dbgen.SupportTicket, fromSupport bool, message string) (*dbgen.SupportTicketReply, error) {
+	if len(message) == 0 {
+		return nil, ErrInvalidInput
 	}
 
-	var subscriptionID *int32
-
-	if params != nil {
-		subscription, err := s.createNewSubscription(ctx, params)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		subscriptionID = &subscription.ID
-
-		if existingUser, err := s.FindUserByEmail(ctx, email); err == nil {
-			slog.InfoContext(ctx, "User with such email already exists", "userID", existingUser.ID, "subscriptionID", existingUser.SubscriptionID)
-			if ((existingUser.ID == existingUserID) || (existingUserID == -1)) && !existingUser.SubscriptionID.Valid {
-				if err := s.updateUserSubscription(ctx, existingUser.ID, subscription.ID); err != nil {
-					return nil, nil, err
-				}
-
-				return existingUser, nil, nil
-			} else {
-				slog.ErrorContext(ctx, "Cannot update existing user with same email", "existingUserID", existingUser.ID,
-					"passthrough", existingUserID, "subscribed", existingUser.SubscriptionID.Valid, "email", email)
-				return nil, nil, ErrDuplicateAccount
-			}
-		}
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	user, err := s.createNewUser(ctx, email, name, subscriptionID)
+	reply, err := impl.querier.CreateSupportTicketReply(ctx, &dbgen.CreateSupportTicketReplyParams{
+		TicketID:    ticket.ID,
+		FromSupport: fromSupport,
+		Message:     message,
+	})
 	if err != nil {
-		return nil, nil, err
+		slog.ErrorContext(ctx, "Failed to create support ticket reply", "ticketID", ticket.TicketID, common.ErrAttr(err))
+		return nil, err
 	}
 
-	org, err := s.CreateNewOrganization(ctx, orgName, user.ID)
-	if err != nil {
-		return nil, nil, err
+	status := dbgen.SupportTicketStatusOpen
+	if fromSupport {
+		status = dbgen.SupportTicketStatusAnswered
 	}
 
-	return user, org, nil
+	if err := impl.querier.UpdateSupportTicketStatus(ctx, &dbgen.UpdateSupportTicketStatusParams{ID: ticket.ID, Status: status}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update support ticket status", "ticketID", ticket.TicketID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Added support ticket reply", "ticketID", ticket.TicketID, "fromSupport", fromSupport, "status", status)
+
+	return reply, nil
 }
@@ -1,14 +1,10 @@
 package db
 
 import (
-	"context"
 	"errors"
 	"log/slog"
 	"strconv"
 	"time"
-
-	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
-	"github.com/maypok86/otter"
 )
 
 var (
@@ -22,73 +18,24 @@ const (
 	DefaultCacheTTL = 5 * time.Minute
 )
 
-type memcache[TKey comparable, TValue comparable] struct {
-	store        otter.CacheWithVariableTTL[TKey, TValue]
-	missingValue TValue
+// NewMemoryCache creates sharded LRU cache with default options
+func NewMemoryCache[TKey comparable, TValue comparable](maxCacheSize int, missingValue TValue, hash func(TKey) uint64) *memcache[TKey, TValue] {
+	return NewMemoryCacheEx(MemoryCacheOptions[TKey, TValue]{
+		Capacity: maxCacheSize,
+		Hash:     hash,
+	}, missingValue)
 }
 
-func NewMemoryCache[TKey comparable, TValue comparable](maxCacheSize int, missingValue TValue) (*memcache[TKey, TValue], error) {
-	store, err := otter.MustBuilder[TKey, TValue](maxCacheSize).
-		WithVariableTTL().
-		Build()
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &memcache[TKey, TValue]{
-		store:        store,
-		missingValue: missingValue,
-	}, nil
-}
-
-var _ common.Cache[int, any] = (*memcache[int, any])(nil)
-
-func (c *memcache[TKey, TValue]) Get(ctx context.Context, key TKey) (TValue, error) {
-	data, found := c.store.Get(key)
-	if !found {
-		slog.Log(ctx, common.LevelTrace, "Item not found in memory cache", "key", key)
-		var zero TValue
-		return zero, ErrCacheMiss
-	}
-
-	if data == c.missingValue {
-		slog.Log(ctx, common.LevelTrace, "Item set as missing in memory cache", "key", key)
-		var zero TValue
-		return zero, ErrNegativeCacheHit
-	}
-
-	slog.Log(ctx, common.LevelTrace, "Found item in memory cache", "key", key)
-
-	return data, nil
-}
-
-func (c *memcache[TKey, TValue]) SetMissing(ctx context.Context, key TKey, ttl time.Duration) error {
-	c.store.Set(key, c.missingValue, ttl)
-
-	slog.Log(ctx, common.LevelTrace, "Set item as missing in memory cache", "key", key, "ttl", ttl)
-
-	return nil
-}
-
-func (c *memcache[TKey, TValue]) Set(ctx context.Context, key TKey, t TValue, ttl time.Duration) error {
-	if t == c.missingValue {
-		return ErrSetMissing
-	}
-
-	c.store.Set(key, t, ttl)
-
-	slog.Log(ctx, common.LevelTrace, "Saved item to memory cache", "key", key, "ttl", ttl)
-
-	return nil
-}
-
-func (c *memcache[TKey, TValue]) Delete(ctx context.Context, key TKey) error {
-	c.store.Delete(key)
-
-	slog.Log(ctx, common.LevelTrace, "Deleted item from memory cache", "key", key)
-
-	return nil
+// NewBusinessCache creates cache for business entities with usage stats separated by key class. As entities
+// (e.g. lists of org properties) can be of very different size, cache is bounded by memory too
+func NewBusinessCache(maxCacheSize int, maxCacheBytes int64) *memcache[CacheKey, any] {
+	return NewMemoryCacheEx(MemoryCacheOptions[CacheKey, any]{
+		Capacity: maxCacheSize,
+		MaxBytes: maxCacheBytes,
+		Hash:     CacheKey.Hash,
+		Classes:  cacheKeyClasses,
+		Classify: CacheKey.class,
+	}, nil /*missing value*/)
 }
 
 type cacheKeyPrefix byte
@@ -105,6 +52,27 @@ const (
 	userAPIKeysCacheKeyPrefix
 	subscriptionCacheKeyPrefix
 	notificationCacheKeyPrefix
+	propertyMessagesCacheKeyPrefix
+	orgBudgetCacheKeyPrefix
+	orgAPIKeysCacheKeyPrefix
+	userPropertyPermissionsCacheKeyPrefix
+	propertyQuotaCacheKeyPrefix
+	userPreferencesCacheKeyPrefix
+	// Add new prefixes _above_ and classify them in CacheKey.class()
+	cacheKeyPrefixCount
+)
+
+const (
+	userCacheKeyClass = iota
+	apiKeyCacheKeyClass
+	orgCacheKeyClass
+	propertyCacheKeyClass
+	// unclassified keys are accounted separately so that they do not skew stats of other classes
+	otherCacheKeyClass
+)
+
+var (
+	cacheKeyClasses = []string{"user", "apikey", "org", "property", "other"}
 )
 
 // it's a "union" type which is better than doing string concatenation as before
@@ -139,6 +107,18 @@ func (ck CacheKey) String() string {
 		prefix = "subscr/"
 	case notificationCacheKeyPrefix:
 		prefix = "notif/"
+	case propertyMessagesCacheKeyPrefix:
+		prefix = "propMessages/"
+	case orgBudgetCacheKeyPrefix:
+		prefix = "orgBudget/"
+	case orgAPIKeysCacheKeyPrefix:
+		prefix = "orgApiKeys/"
+	case userPropertyPermissionsCacheKeyPrefix:
+		prefix = "userPropPermissions/"
+	case propertyQuotaCacheKeyPrefix:
+		prefix = "propQuota/"
+	case userPreferencesCacheKeyPrefix:
+		prefix = "userPrefs/"
 	}
 
 	if len(ck.StrValue) != 0 {
@@ -148,6 +128,23 @@ func (ck CacheKey) String() string {
 	return prefix + strconv.Itoa(ck.IntValue)
 }
 
+func (ck CacheKey) class() int {
+	switch ck.Prefix {
+	case apiKeyCacheKeyPrefix, userAPIKeysCacheKeyPrefix, orgAPIKeysCacheKeyPrefix:
+		return apiKeyCacheKeyClass
+	case orgCacheKeyPrefix, orgUsersCacheKeyPrefix, orgBudgetCacheKeyPrefix, userPropertyPermissionsCacheKeyPrefix:
+		return orgCacheKeyClass
+	case orgPropertiesCacheKeyPrefix, propertyByIDCacheKeyPrefix, propertyBySitekeyCacheKeyPrefix, propertyMessagesCacheKeyPrefix,
+		propertyQuotaCacheKeyPrefix:
+		return propertyCacheKeyClass
+	case userCacheKeyPrefix, userOrgsCacheKeyPrefix, subscriptionCacheKeyPrefix, notificationCacheKeyPrefix,
+		userPreferencesCacheKeyPrefix:
+		return userCacheKeyClass
+	default:
+		return otherCacheKeyClass
+	}
+}
+
 func (ck CacheKey) LogValue() slog.Value {
 	return slog.StringValue(ck.String())
 }
@@ -187,3 +184,19 @@ func userAPIKeysCacheKey(userID int32) CacheKey {
 }
 func subscriptionCacheKey(sID int32) CacheKey { return int32CacheKey(subscriptionCacheKeyPrefix, sID) }
 func notificationCacheKey(ID int32) CacheKey  { return int32CacheKey(notificationCacheKeyPrefix, ID) }
+func propertyMessagesCacheKey(propID int32) CacheKey {
+	return int32CacheKey(propertyMessagesCacheKeyPrefix, propID)
+}
+func orgBudgetCacheKey(orgID int32) CacheKey { return int32CacheKey(orgBudgetCacheKeyPrefix, orgID) }
+func orgAPIKeysCacheKey(orgID int32) CacheKey {
+	return int32CacheKey(orgAPIKeysCacheKeyPrefix, orgID)
+}
+func userPropertyPermissionsCacheKey(orgID, userID int32) CacheKey {
+	return stringCacheKey(userPropertyPermissionsCacheKeyPrefix, strconv.Itoa(int(orgID))+"/"+strconv.Itoa(int(userID)))
+}
+func propertyQuotaCacheKey(propID int32) CacheKey {
+	return int32CacheKey(propertyQuotaCacheKeyPrefix, propID)
+}
+func userPreferencesCacheKey(userID int32) CacheKey {
+	return int32CacheKey(userPreferencesCacheKeyPrefix, userID)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/chaos"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// chaosDBTX injects faults (if enabled) into Postgres queries
+type chaosDBTX struct {
+	db dbgen.DBTX
+}
+
+var _ dbgen.DBTX = (*chaosDBTX)(nil)
+
+func (c *chaosDBTX) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if err := chaos.Inject(ctx, chaos.TargetPostgres); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	return c.db.Exec(ctx, sql, args...)
+}
+
+func (c *chaosDBTX) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if err := chaos.Inject(ctx, chaos.TargetPostgres); err != nil {
+		return nil, err
+	}
+
+	return c.db.Query(ctx, sql, args...)
+}
+
+func (c *chaosDBTX) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if err := chaos.Inject(ctx, chaos.TargetPostgres); err != nil {
+		return &errRow{err: err}
+	}
+
+	return c.db.QueryRow(ctx, sql, args...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...any) error {
+	return r.err
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidIdentifier = errors.New("invalid ClickHouse identifier")
+	chIdentifierRegexp   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// chIdentifier validates the name of a table or column (optionally qualified by the database) before it is put
+// into the query text. Only identifiers are ever interpolated, all values are passed as query parameters
+func chIdentifier(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", errInvalidIdentifier
+	}
+
+	for _, part := range parts {
+		if !chIdentifierRegexp.MatchString(part) {
+			return "", errInvalidIdentifier
+		}
+	}
+
+	return name, nil
+}
+
+// chIDs formats IDs as the value of {name:Array(UInt32)} query parameter
+func chIDs(ids []int32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+
+	for i, id := range ids {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.Itoa(int(id)))
+	}
+
+	sb.WriteByte(']')
+
+	return sb.String()
+}
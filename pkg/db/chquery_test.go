@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChIdentifier(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		VerifyLogTableName, VerifyLogTable1h, VerifyLogTable1d,
+		AccessLogTableName, AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
+		VerifyFailuresTable1h, VerifyFailuresTable1d, VerifyActionsTable1d,
+		FingerprintsTable1h, FingerprintsTable1d,
+		"property_id", "_col1",
+	}
+
+	for _, name := range valid {
+		if _, err := chIdentifier(name); err != nil {
+			t.Errorf("Unexpected error for %q: %v", name, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"1table",
+		"privatecaptcha.",
+		".request_logs",
+		"a.b.c",
+		"request_logs; DROP TABLE users",
+		"request_logs WHERE 1=1 --",
+		"property_id) OR (1=1",
+		"`request_logs`",
+		"\"request_logs\"",
+		"request_logs\n",
+		"request-logs",
+		"{table:Identifier}",
+	}
+
+	for _, name := range invalid {
+		if _, err := chIdentifier(name); err != errInvalidIdentifier {
+			t.Errorf("Expected %q to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestChIDs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		ids      []int32
+		expected string
+	}{
+		{nil, "[]"},
+		{[]int32{1}, "[1]"},
+		{[]int32{1, 22, 333}, "[1,22,333]"},
+	}
+
+	for _, tc := range testCases {
+		if actual := chIDs(tc.ids); actual != tc.expected {
+			t.Errorf("Unexpected IDs parameter: %q (expected %q)", actual, tc.expected)
+		}
+	}
+}
+
+func TestLightDeleteRejectsInjection(t *testing.T) {
+	t.Parallel()
+
+	// there is no connection so any statement that reaches ClickHouse would panic
+	ts := &TimeSeriesDB{name: "clickhouse"}
+	ctx := context.TODO()
+
+	if err := ts.lightDelete(ctx, []string{AccessLogTableName1d}, "org_id IN (1) OR 1=1 --", []int32{1}); err != errInvalidIdentifier {
+		t.Errorf("Unexpected error for injected column: %v", err)
+	}
+
+	tables := []string{AccessLogTableName1d, "privatecaptcha.request_logs_1h; DROP TABLE privatecaptcha.verify_logs"}
+	if err := ts.lightDelete(ctx, tables, "org_id", []int32{1}); err != errInvalidIdentifier {
+		t.Errorf("Unexpected error for injected table: %v", err)
+	}
+}
@@ -11,8 +11,56 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const archiveExpiredAPIKeys = `-- name: ArchiveExpiredAPIKeys :many
+DELETE FROM backend.apikeys
+WHERE id IN (SELECT id FROM backend.apikeys WHERE expires_at < $1 ORDER BY expires_at LIMIT $2)
+RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+type ArchiveExpiredAPIKeysParams struct {
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	Limit     int32              `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ArchiveExpiredAPIKeys(ctx context.Context, arg *ArchiveExpiredAPIKeysParams) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, archiveExpiredAPIKeys, arg.ExpiresAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createAPIKey = `-- name: CreateAPIKey :one
-INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst) VALUES ($1, $2, $3, $4, $5) RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes
+INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst, rotation_days) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
 `
 
 type CreateAPIKeyParams struct {
@@ -21,6 +69,7 @@ type CreateAPIKeyParams struct {
 	ExpiresAt         pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
 	RequestsPerSecond float64            `db:"requests_per_second" json:"requests_per_second"`
 	RequestsBurst     int32              `db:"requests_burst" json:"requests_burst"`
+	RotationDays      int32              `db:"rotation_days" json:"rotation_days"`
 }
 
 func (q *Queries) CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*APIKey, error) {
@@ -30,6 +79,7 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*A
 		arg.ExpiresAt,
 		arg.RequestsPerSecond,
 		arg.RequestsBurst,
+		arg.RotationDays,
 	)
 	var i APIKey
 	err := row.Scan(
@@ -43,12 +93,60 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*A
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
+const createOrgAPIKey = `-- name: CreateOrgAPIKey :one
+INSERT INTO backend.apikeys (name, org_id, expires_at, requests_per_second, requests_burst) VALUES ($1, $2, $3, $4, $5) RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+type CreateOrgAPIKeyParams struct {
+	Name              string             `db:"name" json:"name"`
+	OrgID             pgtype.Int4        `db:"org_id" json:"org_id"`
+	ExpiresAt         pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	RequestsPerSecond float64            `db:"requests_per_second" json:"requests_per_second"`
+	RequestsBurst     int32              `db:"requests_burst" json:"requests_burst"`
+}
+
+func (q *Queries) CreateOrgAPIKey(ctx context.Context, arg *CreateOrgAPIKeyParams) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, createOrgAPIKey,
+		arg.Name,
+		arg.OrgID,
+		arg.ExpiresAt,
+		arg.RequestsPerSecond,
+		arg.RequestsBurst,
+	)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
 	)
 	return &i, err
 }
 
 const deleteAPIKey = `-- name: DeleteAPIKey :one
-DELETE FROM backend.apikeys WHERE id=$1 AND user_id = $2 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes
+DELETE FROM backend.apikeys WHERE id=$1 AND user_id = $2 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
 `
 
 type DeleteAPIKeyParams struct {
@@ -70,6 +168,45 @@ func (q *Queries) DeleteAPIKey(ctx context.Context, arg *DeleteAPIKeyParams) (*A
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
+const deleteOrgAPIKey = `-- name: DeleteOrgAPIKey :one
+DELETE FROM backend.apikeys WHERE id = $1 AND org_id = $2 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+type DeleteOrgAPIKeyParams struct {
+	ID    int32       `db:"id" json:"id"`
+	OrgID pgtype.Int4 `db:"org_id" json:"org_id"`
+}
+
+func (q *Queries) DeleteOrgAPIKey(ctx context.Context, arg *DeleteOrgAPIKeyParams) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, deleteOrgAPIKey, arg.ID, arg.OrgID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
 	)
 	return &i, err
 }
@@ -83,8 +220,94 @@ func (q *Queries) DeleteUserAPIKeys(ctx context.Context, userID pgtype.Int4) err
 	return err
 }
 
+const disableRotatedAPIKeys = `-- name: DisableRotatedAPIKeys :many
+UPDATE backend.apikeys SET enabled = FALSE
+WHERE enabled = TRUE AND successor_id IS NOT NULL AND rotated_at < $1
+RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+func (q *Queries) DisableRotatedAPIKeys(ctx context.Context, rotatedAt pgtype.Timestamptz) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, disableRotatedAPIKeys, rotatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expireAPIKeys = `-- name: ExpireAPIKeys :many
+UPDATE backend.apikeys SET enabled = FALSE
+WHERE enabled = TRUE AND expires_at <= $1
+RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+func (q *Queries) ExpireAPIKeys(ctx context.Context, expiresAt pgtype.Timestamptz) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, expireAPIKeys, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAPIKeyByExternalID = `-- name: GetAPIKeyByExternalID :one
-SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes FROM backend.apikeys WHERE external_id = $1
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at FROM backend.apikeys WHERE external_id = $1
 `
 
 func (q *Queries) GetAPIKeyByExternalID(ctx context.Context, externalID pgtype.UUID) (*APIKey, error) {
@@ -101,12 +324,162 @@ func (q *Queries) GetAPIKeyByExternalID(ctx context.Context, externalID pgtype.U
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
 	)
 	return &i, err
 }
 
+const getAPIKeysDueForRotation = `-- name: GetAPIKeysDueForRotation :many
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at FROM backend.apikeys
+WHERE enabled = TRUE AND rotation_days > 0 AND successor_id IS NULL AND expires_at > NOW()
+  AND created_at + make_interval(days => rotation_days) <= NOW()
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) GetAPIKeysDueForRotation(ctx context.Context, limit int32) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, getAPIKeysDueForRotation, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPIKeysExpiringSoon = `-- name: GetAPIKeysExpiringSoon :many
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at FROM backend.apikeys
+WHERE enabled = TRUE AND successor_id IS NULL
+  AND expires_at > $1::timestamptz AND expires_at <= $2::timestamptz
+  AND (expiry_notified_days = 0 OR expiry_notified_days > $3::smallint)
+ORDER BY expires_at
+LIMIT $4::int
+`
+
+type GetAPIKeysExpiringSoonParams struct {
+	ExpiresAfter  pgtype.Timestamptz `db:"expires_after" json:"expires_after"`
+	ExpiresBefore pgtype.Timestamptz `db:"expires_before" json:"expires_before"`
+	NotifyDays    int16              `db:"notify_days" json:"notify_days"`
+	MaxResults    int32              `db:"max_results" json:"max_results"`
+}
+
+func (q *Queries) GetAPIKeysExpiringSoon(ctx context.Context, arg *GetAPIKeysExpiringSoonParams) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, getAPIKeysExpiringSoon,
+		arg.ExpiresAfter,
+		arg.ExpiresBefore,
+		arg.NotifyDays,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrgAPIKeys = `-- name: GetOrgAPIKeys :many
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at FROM backend.apikeys WHERE org_id = $1 AND expires_at > NOW()
+`
+
+func (q *Queries) GetOrgAPIKeys(ctx context.Context, orgID pgtype.Int4) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, getOrgAPIKeys, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserAPIKeys = `-- name: GetUserAPIKeys :many
-SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW()
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW()
 `
 
 func (q *Queries) GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*APIKey, error) {
@@ -129,6 +502,12 @@ func (q *Queries) GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*AP
 			&i.CreatedAt,
 			&i.ExpiresAt,
 			&i.Notes,
+			&i.RotationDays,
+			&i.SuccessorID,
+			&i.RotatedAt,
+			&i.ExpiryNotifiedDays,
+			&i.OrgID,
+			&i.SuspendedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -140,8 +519,111 @@ func (q *Queries) GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*AP
 	return items, nil
 }
 
+const resumeAPIKey = `-- name: ResumeAPIKey :one
+WITH resumed AS (
+    UPDATE backend.apikeys SET enabled = TRUE, suspended_at = NULL
+    WHERE id = $1 AND suspended_at IS NOT NULL AND expires_at > NOW()
+      AND (user_id = $2 OR org_id IN (SELECT id FROM backend.organizations WHERE user_id = $2))
+    RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+), deleted AS (
+    DELETE FROM backend.apikey_usage WHERE apikey_id IN (SELECT id FROM resumed)
+)
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at FROM resumed
+`
+
+type ResumeAPIKeyParams struct {
+	ID     int32       `db:"id" json:"id"`
+	UserID pgtype.Int4 `db:"user_id" json:"user_id"`
+}
+
+// usage before suspension is removed so that the same spike does not suspend the key again
+func (q *Queries) ResumeAPIKey(ctx context.Context, arg *ResumeAPIKeyParams) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, resumeAPIKey, arg.ID, arg.UserID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
+const setAPIKeySuccessor = `-- name: SetAPIKeySuccessor :one
+UPDATE backend.apikeys SET successor_id = $1, rotated_at = NOW() WHERE id = $2 AND successor_id IS NULL RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+type SetAPIKeySuccessorParams struct {
+	SuccessorID pgtype.Int4 `db:"successor_id" json:"successor_id"`
+	ID          int32       `db:"id" json:"id"`
+}
+
+func (q *Queries) SetAPIKeySuccessor(ctx context.Context, arg *SetAPIKeySuccessorParams) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, setAPIKeySuccessor, arg.SuccessorID, arg.ID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
+const suspendAPIKey = `-- name: SuspendAPIKey :one
+UPDATE backend.apikeys SET enabled = FALSE, suspended_at = NOW() WHERE id = $1 AND enabled = TRUE RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+func (q *Queries) SuspendAPIKey(ctx context.Context, id int32) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, suspendAPIKey, id)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
 const updateAPIKey = `-- name: UpdateAPIKey :one
-UPDATE backend.apikeys SET expires_at = $1, enabled = $2 WHERE external_id = $3 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes
+UPDATE backend.apikeys SET expires_at = $1, enabled = $2 WHERE external_id = $3 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
 `
 
 type UpdateAPIKeyParams struct {
@@ -164,12 +646,100 @@ func (q *Queries) UpdateAPIKey(ctx context.Context, arg *UpdateAPIKeyParams) (*A
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
+const updateAPIKeyExternalID = `-- name: UpdateAPIKeyExternalID :one
+UPDATE backend.apikeys SET external_id = $2 WHERE id = $1 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+type UpdateAPIKeyExternalIDParams struct {
+	ID         int32       `db:"id" json:"id"`
+	ExternalID pgtype.UUID `db:"external_id" json:"external_id"`
+}
+
+func (q *Queries) UpdateAPIKeyExternalID(ctx context.Context, arg *UpdateAPIKeyExternalIDParams) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, updateAPIKeyExternalID, arg.ID, arg.ExternalID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
+	)
+	return &i, err
+}
+
+const updateAPIKeyExpiryNotified = `-- name: UpdateAPIKeyExpiryNotified :exec
+UPDATE backend.apikeys SET expiry_notified_days = $1 WHERE id = $2
+`
+
+type UpdateAPIKeyExpiryNotifiedParams struct {
+	ExpiryNotifiedDays int16 `db:"expiry_notified_days" json:"expiry_notified_days"`
+	ID                 int32 `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAPIKeyExpiryNotified(ctx context.Context, arg *UpdateAPIKeyExpiryNotifiedParams) error {
+	_, err := q.db.Exec(ctx, updateAPIKeyExpiryNotified, arg.ExpiryNotifiedDays, arg.ID)
+	return err
+}
+
+const updateAPIKeyRotation = `-- name: UpdateAPIKeyRotation :one
+UPDATE backend.apikeys SET rotation_days = $1 WHERE id = $2 AND user_id = $3 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, rotation_days, successor_id, rotated_at, expiry_notified_days, org_id, suspended_at
+`
+
+type UpdateAPIKeyRotationParams struct {
+	RotationDays int32       `db:"rotation_days" json:"rotation_days"`
+	ID           int32       `db:"id" json:"id"`
+	UserID       pgtype.Int4 `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) UpdateAPIKeyRotation(ctx context.Context, arg *UpdateAPIKeyRotationParams) (*APIKey, error) {
+	row := q.db.QueryRow(ctx, updateAPIKeyRotation, arg.RotationDays, arg.ID, arg.UserID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.UserID,
+		&i.Enabled,
+		&i.RequestsPerSecond,
+		&i.RequestsBurst,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Notes,
+		&i.RotationDays,
+		&i.SuccessorID,
+		&i.RotatedAt,
+		&i.ExpiryNotifiedDays,
+		&i.OrgID,
+		&i.SuspendedAt,
 	)
 	return &i, err
 }
 
 const updateUserAPIKeysRateLimits = `-- name: UpdateUserAPIKeysRateLimits :exec
-UPDATE backend.apikeys SET requests_per_second = $1 WHERE user_id = $2
+UPDATE backend.apikeys SET requests_per_second = $1
+WHERE user_id = $2 OR org_id IN (SELECT id FROM backend.organizations WHERE user_id = $2)
 `
 
 type UpdateUserAPIKeysRateLimitsParams struct {
@@ -12,9 +12,10 @@ import (
 )
 
 const createCache = `-- name: CreateCache :exec
+WITH deleted AS (
+    DELETE FROM backend.cache WHERE key = $1
+)
 INSERT INTO backend.cache (key, value, expires_at) VALUES ($1, $2, NOW() + $3::INTERVAL)
-ON CONFLICT (key) DO UPDATE 
-SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
 `
 
 type CreateCacheParams struct {
@@ -23,20 +24,20 @@ type CreateCacheParams struct {
 	Column3 time.Duration `db:"column_3" json:"column_3"`
 }
 
+// cache is partitioned by expiration so key alone is not unique and previous value is deleted instead of upsert
 func (q *Queries) CreateCache(ctx context.Context, arg *CreateCacheParams) error {
 	_, err := q.db.Exec(ctx, createCache, arg.Key, arg.Value, arg.Column3)
 	return err
 }
 
 const createCacheMany = `-- name: CreateCacheMany :exec
+WITH deleted AS (
+    DELETE FROM backend.cache WHERE key = ANY($1::TEXT[])
+)
 INSERT INTO backend.cache (key, value, expires_at)
 SELECT unnest($1::TEXT[]) as key,
        unnest($2::BYTEA[]) as value,
        NOW() + unnest($3::INTERVAL[]) as expires_at
-ON CONFLICT (key)
-DO UPDATE SET
-    value = EXCLUDED.value,
-    expires_at = EXCLUDED.expires_at
 `
 
 type CreateCacheManyParams struct {
@@ -60,16 +61,16 @@ func (q *Queries) DeleteCachedByKey(ctx context.Context, key string) error {
 }
 
 const deleteExpiredCache = `-- name: DeleteExpiredCache :exec
-DELETE FROM backend.cache WHERE expires_at < NOW()
+SELECT backend.rotate_cache_partitions($1::INTEGER)
 `
 
-func (q *Queries) DeleteExpiredCache(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, deleteExpiredCache)
+func (q *Queries) DeleteExpiredCache(ctx context.Context, hoursAhead int32) error {
+	_, err := q.db.Exec(ctx, deleteExpiredCache, hoursAhead)
 	return err
 }
 
 const getCachedByKey = `-- name: GetCachedByKey :one
-SELECT value FROM backend.cache WHERE key = $1 AND expires_at >= NOW()
+SELECT value FROM backend.cache WHERE key = $1 AND expires_at >= NOW() ORDER BY expires_at DESC LIMIT 1
 `
 
 func (q *Queries) GetCachedByKey(ctx context.Context, key string) ([]byte, error) {
@@ -79,6 +80,20 @@ func (q *Queries) GetCachedByKey(ctx context.Context, key string) ([]byte, error
 	return value, err
 }
 
+const notifyCacheInvalidation = `-- name: NotifyCacheInvalidation :exec
+SELECT pg_notify($1::TEXT, $2::TEXT)
+`
+
+type NotifyCacheInvalidationParams struct {
+	Channel string `db:"channel" json:"channel"`
+	Payload string `db:"payload" json:"payload"`
+}
+
+func (q *Queries) NotifyCacheInvalidation(ctx context.Context, arg *NotifyCacheInvalidationParams) error {
+	_, err := q.db.Exec(ctx, notifyCacheInvalidation, arg.Channel, arg.Payload)
+	return err
+}
+
 const updateCacheExpiration = `-- name: UpdateCacheExpiration :exec
 UPDATE backend.cache SET expires_at = NOW() + $2::INTERVAL WHERE key = $1
 `
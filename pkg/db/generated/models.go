@@ -99,6 +99,176 @@ func (ns NullDifficultyGrowth) Value() (driver.Value, error) {
 	return string(ns.DifficultyGrowth), nil
 }
 
+type NotificationCategory string
+
+const (
+	NotificationCategoryGeneral  NotificationCategory = "general"
+	NotificationCategoryBilling  NotificationCategory = "billing"
+	NotificationCategoryUsage    NotificationCategory = "usage"
+	NotificationCategorySecurity NotificationCategory = "security"
+)
+
+func (e *NotificationCategory) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationCategory(s)
+	case string:
+		*e = NotificationCategory(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationCategory: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationCategory struct {
+	NotificationCategory NotificationCategory `json:"backend_notification_category"`
+	Valid                bool                 `json:"valid"` // Valid is true if NotificationCategory is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationCategory) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationCategory, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationCategory.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationCategory) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationCategory), nil
+}
+
+type PowAlgorithm string
+
+const (
+	PowAlgorithmBlake2b  PowAlgorithm = "blake2b"
+	PowAlgorithmArgon2id PowAlgorithm = "argon2id"
+)
+
+func (e *PowAlgorithm) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PowAlgorithm(s)
+	case string:
+		*e = PowAlgorithm(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PowAlgorithm: %T", src)
+	}
+	return nil
+}
+
+type NullPowAlgorithm struct {
+	PowAlgorithm PowAlgorithm `json:"backend_pow_algorithm"`
+	Valid        bool         `json:"valid"` // Valid is true if PowAlgorithm is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPowAlgorithm) Scan(value interface{}) error {
+	if value == nil {
+		ns.PowAlgorithm, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PowAlgorithm.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPowAlgorithm) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PowAlgorithm), nil
+}
+
+type PropertyAccessLevel string
+
+const (
+	PropertyAccessLevelView PropertyAccessLevel = "view"
+	PropertyAccessLevelEdit PropertyAccessLevel = "edit"
+)
+
+func (e *PropertyAccessLevel) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PropertyAccessLevel(s)
+	case string:
+		*e = PropertyAccessLevel(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PropertyAccessLevel: %T", src)
+	}
+	return nil
+}
+
+type NullPropertyAccessLevel struct {
+	PropertyAccessLevel PropertyAccessLevel `json:"backend_property_access_level"`
+	Valid               bool                `json:"valid"` // Valid is true if PropertyAccessLevel is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPropertyAccessLevel) Scan(value interface{}) error {
+	if value == nil {
+		ns.PropertyAccessLevel, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PropertyAccessLevel.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPropertyAccessLevel) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PropertyAccessLevel), nil
+}
+
+type QuotaPeriod string
+
+const (
+	QuotaPeriodWeek  QuotaPeriod = "week"
+	QuotaPeriodMonth QuotaPeriod = "month"
+)
+
+func (e *QuotaPeriod) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = QuotaPeriod(s)
+	case string:
+		*e = QuotaPeriod(s)
+	default:
+		return fmt.Errorf("unsupported scan type for QuotaPeriod: %T", src)
+	}
+	return nil
+}
+
+type NullQuotaPeriod struct {
+	QuotaPeriod QuotaPeriod `json:"backend_quota_period"`
+	Valid       bool        `json:"valid"` // Valid is true if QuotaPeriod is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullQuotaPeriod) Scan(value interface{}) error {
+	if value == nil {
+		ns.QuotaPeriod, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.QuotaPeriod.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullQuotaPeriod) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.QuotaPeriod), nil
+}
+
 type SubscriptionSource string
 
 const (
@@ -141,21 +311,204 @@ func (ns NullSubscriptionSource) Value() (driver.Value, error) {
 	return string(ns.SubscriptionSource), nil
 }
 
+type SupportTicketStatus string
+
+const (
+	SupportTicketStatusOpen     SupportTicketStatus = "open"
+	SupportTicketStatusAnswered SupportTicketStatus = "answered"
+	SupportTicketStatusClosed   SupportTicketStatus = "closed"
+)
+
+func (e *SupportTicketStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SupportTicketStatus(s)
+	case string:
+		*e = SupportTicketStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SupportTicketStatus: %T", src)
+	}
+	return nil
+}
+
+type NullSupportTicketStatus struct {
+	SupportTicketStatus SupportTicketStatus `json:"backend_support_ticket_status"`
+	Valid               bool                `json:"valid"` // Valid is true if SupportTicketStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSupportTicketStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.SupportTicketStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SupportTicketStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSupportTicketStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SupportTicketStatus), nil
+}
+
+type TableDensity string
+
+const (
+	TableDensityComfortable TableDensity = "comfortable"
+	TableDensityCompact     TableDensity = "compact"
+)
+
+func (e *TableDensity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TableDensity(s)
+	case string:
+		*e = TableDensity(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TableDensity: %T", src)
+	}
+	return nil
+}
+
+type NullTableDensity struct {
+	TableDensity TableDensity `json:"backend_table_density"`
+	Valid        bool         `json:"valid"` // Valid is true if TableDensity is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTableDensity) Scan(value interface{}) error {
+	if value == nil {
+		ns.TableDensity, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TableDensity.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTableDensity) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TableDensity), nil
+}
+
+type UiTheme string
+
+const (
+	UiThemeLight  UiTheme = "light"
+	UiThemeDark   UiTheme = "dark"
+	UiThemeSystem UiTheme = "system"
+)
+
+func (e *UiTheme) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UiTheme(s)
+	case string:
+		*e = UiTheme(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UiTheme: %T", src)
+	}
+	return nil
+}
+
+type NullUiTheme struct {
+	UiTheme UiTheme `json:"backend_ui_theme"`
+	Valid   bool    `json:"valid"` // Valid is true if UiTheme is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUiTheme) Scan(value interface{}) error {
+	if value == nil {
+		ns.UiTheme, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UiTheme.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUiTheme) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UiTheme), nil
+}
+
+type WidgetChannel string
+
+const (
+	WidgetChannelStable WidgetChannel = "stable"
+	WidgetChannelBeta   WidgetChannel = "beta"
+	WidgetChannelPinned WidgetChannel = "pinned"
+)
+
+func (e *WidgetChannel) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WidgetChannel(s)
+	case string:
+		*e = WidgetChannel(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WidgetChannel: %T", src)
+	}
+	return nil
+}
+
+type NullWidgetChannel struct {
+	WidgetChannel WidgetChannel `json:"backend_widget_channel"`
+	Valid         bool          `json:"valid"` // Valid is true if WidgetChannel is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWidgetChannel) Scan(value interface{}) error {
+	if value == nil {
+		ns.WidgetChannel, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WidgetChannel.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWidgetChannel) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WidgetChannel), nil
+}
+
 type APIKey struct {
-	ID                int32              `db:"id" json:"id"`
-	Name              string             `db:"name" json:"name"`
-	ExternalID        pgtype.UUID        `db:"external_id" json:"external_id"`
-	UserID            pgtype.Int4        `db:"user_id" json:"user_id"`
-	Enabled           pgtype.Bool        `db:"enabled" json:"enabled"`
-	RequestsPerSecond float64            `db:"requests_per_second" json:"requests_per_second"`
-	RequestsBurst     int32              `db:"requests_burst" json:"requests_burst"`
-	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	ExpiresAt         pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
-	Notes             pgtype.Text        `db:"notes" json:"notes"`
+	ID                 int32              `db:"id" json:"id"`
+	Name               string             `db:"name" json:"name"`
+	ExternalID         pgtype.UUID        `db:"external_id" json:"external_id"`
+	UserID             pgtype.Int4        `db:"user_id" json:"user_id"`
+	Enabled            pgtype.Bool        `db:"enabled" json:"enabled"`
+	RequestsPerSecond  float64            `db:"requests_per_second" json:"requests_per_second"`
+	RequestsBurst      int32              `db:"requests_burst" json:"requests_burst"`
+	CreatedAt          pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt          pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	Notes              pgtype.Text        `db:"notes" json:"notes"`
+	RotationDays       int32              `db:"rotation_days" json:"rotation_days"`
+	SuccessorID        pgtype.Int4        `db:"successor_id" json:"successor_id"`
+	RotatedAt          pgtype.Timestamptz `db:"rotated_at" json:"rotated_at"`
+	ExpiryNotifiedDays int16              `db:"expiry_notified_days" json:"expiry_notified_days"`
+	OrgID              pgtype.Int4        `db:"org_id" json:"org_id"`
+	SuspendedAt        pgtype.Timestamptz `db:"suspended_at" json:"suspended_at"`
+}
+
+type APIKeyUsage struct {
+	APIKeyID   int32              `db:"apikey_id" json:"apikey_id"`
+	Hour       pgtype.Timestamptz `db:"hour" json:"hour"`
+	Requests   int32              `db:"requests" json:"requests"`
+	WrongOwner int32              `db:"wrong_owner" json:"wrong_owner"`
 }
 
 type Cache struct {
-	ID        int32            `db:"id" json:"id"`
 	Key       string           `db:"key" json:"key"`
 	Value     []byte           `db:"value" json:"value"`
 	ExpiresAt pgtype.Timestamp `db:"expires_at" json:"expires_at"`
@@ -169,12 +522,58 @@ type DeletedRecord struct {
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
+type DomainVerification struct {
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	Token      string             `db:"token" json:"token"`
+	CheckedAt  pgtype.Timestamptz `db:"checked_at" json:"checked_at"`
+	LastError  string             `db:"last_error" json:"last_error"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type EmailChange struct {
+	ID              int32              `db:"id" json:"id"`
+	UserID          int32              `db:"user_id" json:"user_id"`
+	OldEmail        string             `db:"old_email" json:"old_email"`
+	NewEmail        string             `db:"new_email" json:"new_email"`
+	RevertTokenHash string             `db:"revert_token_hash" json:"revert_token_hash"`
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt       pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	RevertedAt      pgtype.Timestamptz `db:"reverted_at" json:"reverted_at"`
+}
+
+type LicenseNode struct {
+	ID         string             `db:"id" json:"id"`
+	Version    string             `db:"version" json:"version"`
+	StartedAt  pgtype.Timestamptz `db:"started_at" json:"started_at"`
+	LastSeenAt pgtype.Timestamptz `db:"last_seen_at" json:"last_seen_at"`
+}
+
 type Lock struct {
 	Name      string             `db:"name" json:"name"`
 	Data      []byte             `db:"data" json:"data"`
 	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
 }
 
+type OrgBillingContact struct {
+	OrgID     int32              `db:"org_id" json:"org_id"`
+	EmailID   int32              `db:"email_id" json:"email_id"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type OrgBudget struct {
+	OrgID          int32              `db:"org_id" json:"org_id"`
+	MonthlyLimit   int64              `db:"monthly_limit" json:"monthly_limit"`
+	AlertedPercent int16              `db:"alerted_percent" json:"alerted_percent"`
+	AlertedAt      pgtype.Timestamptz `db:"alerted_at" json:"alerted_at"`
+	UpdatedAt      pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type OrgDigest struct {
+	OrgID     int32              `db:"org_id" json:"org_id"`
+	WeekStart pgtype.Timestamptz `db:"week_start" json:"week_start"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
 type Organization struct {
 	ID        int32              `db:"id" json:"id"`
 	Name      string             `db:"name" json:"name"`
@@ -193,23 +592,96 @@ type OrganizationUser struct {
 }
 
 type Property struct {
-	ID               int32              `db:"id" json:"id"`
-	Name             string             `db:"name" json:"name"`
-	ExternalID       pgtype.UUID        `db:"external_id" json:"external_id"`
-	OrgID            pgtype.Int4        `db:"org_id" json:"org_id"`
-	CreatorID        pgtype.Int4        `db:"creator_id" json:"creator_id"`
-	OrgOwnerID       pgtype.Int4        `db:"org_owner_id" json:"org_owner_id"`
-	Domain           string             `db:"domain" json:"domain"`
-	Level            pgtype.Int2        `db:"level" json:"level"`
-	Salt             []byte             `db:"salt" json:"salt"`
-	Growth           DifficultyGrowth   `db:"growth" json:"growth"`
-	CreatedAt        pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt        pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	DeletedAt        pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
-	ValidityInterval time.Duration      `db:"validity_interval" json:"validity_interval"`
-	AllowSubdomains  bool               `db:"allow_subdomains" json:"allow_subdomains"`
-	AllowLocalhost   bool               `db:"allow_localhost" json:"allow_localhost"`
-	AllowReplay      bool               `db:"allow_replay" json:"allow_replay"`
+	ID                       int32              `db:"id" json:"id"`
+	Name                     string             `db:"name" json:"name"`
+	ExternalID               pgtype.UUID        `db:"external_id" json:"external_id"`
+	OrgID                    pgtype.Int4        `db:"org_id" json:"org_id"`
+	CreatorID                pgtype.Int4        `db:"creator_id" json:"creator_id"`
+	OrgOwnerID               pgtype.Int4        `db:"org_owner_id" json:"org_owner_id"`
+	Domain                   string             `db:"domain" json:"domain"`
+	Level                    pgtype.Int2        `db:"level" json:"level"`
+	Salt                     []byte             `db:"salt" json:"salt"`
+	Growth                   DifficultyGrowth   `db:"growth" json:"growth"`
+	CreatedAt                pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt                pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	DeletedAt                pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	ValidityInterval         time.Duration      `db:"validity_interval" json:"validity_interval"`
+	AllowSubdomains          bool               `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhost           bool               `db:"allow_localhost" json:"allow_localhost"`
+	AllowReplay              bool               `db:"allow_replay" json:"allow_replay"`
+	Algorithm                PowAlgorithm       `db:"algorithm" json:"algorithm"`
+	PrivacyMode              bool               `db:"privacy_mode" json:"privacy_mode"`
+	AllowedOrigins           []string           `db:"allowed_origins" json:"allowed_origins"`
+	TrustedVisitorsThreshold int16              `db:"trusted_visitors_threshold" json:"trusted_visitors_threshold"`
+	TrustedVisitorsTtl       time.Duration      `db:"trusted_visitors_ttl" json:"trusted_visitors_ttl"`
+	TestMode                 bool               `db:"test_mode" json:"test_mode"`
+	IplessMode               bool               `db:"ipless_mode" json:"ipless_mode"`
+	WidgetChannel            WidgetChannel      `db:"widget_channel" json:"widget_channel"`
+	DataRegion               string             `db:"data_region" json:"data_region"`
+	PausedAt                 pgtype.Timestamptz `db:"paused_at" json:"paused_at"`
+	DomainVerifiedAt         pgtype.Timestamptz `db:"domain_verified_at" json:"domain_verified_at"`
+	AllowedAppIDs            []string           `db:"allowed_app_ids" json:"allowed_app_ids"`
+	AccessibleMode           bool               `db:"accessible_mode" json:"accessible_mode"`
+}
+
+type PropertyEvent struct {
+	ID         int32              `db:"id" json:"id"`
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	UserID     pgtype.Int4        `db:"user_id" json:"user_id"`
+	Setting    string             `db:"setting" json:"setting"`
+	OldValue   string             `db:"old_value" json:"old_value"`
+	NewValue   string             `db:"new_value" json:"new_value"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type PropertyMessage struct {
+	PropertyID         int32              `db:"property_id" json:"property_id"`
+	BlockedMessage     string             `db:"blocked_message" json:"blocked_message"`
+	QuotaMessage       string             `db:"quota_message" json:"quota_message"`
+	MaintenanceMessage string             `db:"maintenance_message" json:"maintenance_message"`
+	RedirectUrl        string             `db:"redirect_url" json:"redirect_url"`
+	UpdatedAt          pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Theme              string             `db:"theme" json:"theme"`
+	Locale             string             `db:"locale" json:"locale"`
+}
+
+type PropertyPermission struct {
+	PropertyID int32               `db:"property_id" json:"property_id"`
+	UserID     int32               `db:"user_id" json:"user_id"`
+	Level      PropertyAccessLevel `db:"level" json:"level"`
+	CreatedAt  pgtype.Timestamptz  `db:"created_at" json:"created_at"`
+}
+
+type PropertyQuota struct {
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	Percent    int16              `db:"percent" json:"percent"`
+	Period     QuotaPeriod        `db:"period" json:"period"`
+	UpdatedAt  pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type PropertySitekeyRotation struct {
+	PropertyID         int32              `db:"property_id" json:"property_id"`
+	PreviousExternalID pgtype.UUID        `db:"previous_external_id" json:"previous_external_id"`
+	ExpiresAt          pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	CreatedAt          pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type PropertyTag struct {
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	Tag        string             `db:"tag" json:"tag"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type QueueJob struct {
+	ID          int32              `db:"id" json:"id"`
+	Queue       string             `db:"queue" json:"queue"`
+	Payload     []byte             `db:"payload" json:"payload"`
+	Attempts    int32              `db:"attempts" json:"attempts"`
+	LastError   string             `db:"last_error" json:"last_error"`
+	RunAt       pgtype.Timestamptz `db:"run_at" json:"run_at"`
+	LockedUntil pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+	FailedAt    pgtype.Timestamptz `db:"failed_at" json:"failed_at"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
 }
 
 type Subscription struct {
@@ -227,6 +699,25 @@ type Subscription struct {
 	UpdatedAt              pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
+type SupportTicket struct {
+	ID        int32               `db:"id" json:"id"`
+	TicketID  string              `db:"ticket_id" json:"ticket_id"`
+	UserID    int32               `db:"user_id" json:"user_id"`
+	Subject   string              `db:"subject" json:"subject"`
+	Message   string              `db:"message" json:"message"`
+	Status    SupportTicketStatus `db:"status" json:"status"`
+	CreatedAt pgtype.Timestamptz  `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz  `db:"updated_at" json:"updated_at"`
+}
+
+type SupportTicketReply struct {
+	ID          int32              `db:"id" json:"id"`
+	TicketID    int32              `db:"ticket_id" json:"ticket_id"`
+	FromSupport bool               `db:"from_support" json:"from_support"`
+	Message     string             `db:"message" json:"message"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
 type SystemNotification struct {
 	ID        int32              `db:"id" json:"id"`
 	Message   string             `db:"message" json:"message"`
@@ -237,11 +728,74 @@ type SystemNotification struct {
 }
 
 type User struct {
-	ID             int32              `db:"id" json:"id"`
-	Name           string             `db:"name" json:"name"`
-	Email          string             `db:"email" json:"email"`
-	SubscriptionID pgtype.Int4        `db:"subscription_id" json:"subscription_id"`
-	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt      pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	DeletedAt      pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	ID                int32              `db:"id" json:"id"`
+	Name              string             `db:"name" json:"name"`
+	Email             string             `db:"email" json:"email"`
+	SubscriptionID    pgtype.Int4        `db:"subscription_id" json:"subscription_id"`
+	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	DeletedAt         pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	ReverifyNewLogins bool               `db:"reverify_new_logins" json:"reverify_new_logins"`
+	Timezone          string             `db:"timezone" json:"timezone"`
+}
+
+type UserEmail struct {
+	ID              int32              `db:"id" json:"id"`
+	UserID          int32              `db:"user_id" json:"user_id"`
+	Email           string             `db:"email" json:"email"`
+	VerifyTokenHash pgtype.Text        `db:"verify_token_hash" json:"verify_token_hash"`
+	VerifyExpiresAt pgtype.Timestamptz `db:"verify_expires_at" json:"verify_expires_at"`
+	VerifiedAt      pgtype.Timestamptz `db:"verified_at" json:"verified_at"`
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type UserLockout struct {
+	UserID         int32              `db:"user_id" json:"user_id"`
+	FailedAttempts int32              `db:"failed_attempts" json:"failed_attempts"`
+	LastFailedAt   pgtype.Timestamptz `db:"last_failed_at" json:"last_failed_at"`
+	LockedUntil    pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+}
+
+type UserLogin struct {
+	ID        int32              `db:"id" json:"id"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	IpAddress string             `db:"ip_address" json:"ip_address"`
+	Country   string             `db:"country" json:"country"`
+	Device    string             `db:"device" json:"device"`
+	UserAgent string             `db:"user_agent" json:"user_agent"`
+	NewOrigin bool               `db:"new_origin" json:"new_origin"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	SessionID string             `db:"session_id" json:"session_id"`
+	RevokedAt pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+type UserNotification struct {
+	ID          int32                `db:"id" json:"id"`
+	UserID      int32                `db:"user_id" json:"user_id"`
+	Category    NotificationCategory `db:"category" json:"category"`
+	Message     string               `db:"message" json:"message"`
+	CreatedAt   pgtype.Timestamptz   `db:"created_at" json:"created_at"`
+	ReadAt      pgtype.Timestamptz   `db:"read_at" json:"read_at"`
+	DismissedAt pgtype.Timestamptz   `db:"dismissed_at" json:"dismissed_at"`
+}
+
+type UserPreference struct {
+	UserID                int32              `db:"user_id" json:"user_id"`
+	Theme                 UiTheme            `db:"theme" json:"theme"`
+	TableDensity          TableDensity       `db:"table_density" json:"table_density"`
+	DefaultOrgID          pgtype.Int4        `db:"default_org_id" json:"default_org_id"`
+	UpdatedAt             pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	OnboardingDismissedAt pgtype.Timestamptz `db:"onboarding_dismissed_at" json:"onboarding_dismissed_at"`
+}
+
+type WebhookEvent struct {
+	ID            int32              `db:"id" json:"id"`
+	EventID       string             `db:"event_id" json:"event_id"`
+	EventType     string             `db:"event_type" json:"event_type"`
+	Payload       []byte             `db:"payload" json:"payload"`
+	Attempts      int32              `db:"attempts" json:"attempts"`
+	LastError     string             `db:"last_error" json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `db:"next_attempt_at" json:"next_attempt_at"`
+	ProcessedAt   pgtype.Timestamptz `db:"processed_at" json:"processed_at"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
 }
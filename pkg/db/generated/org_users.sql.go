@@ -10,7 +10,7 @@ import (
 )
 
 const getOrganizationUsers = `-- name: GetOrganizationUsers :many
-SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, ou.level
+SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, u.reverify_new_logins, u.timezone, ou.level
 FROM backend.organization_users ou
 JOIN backend.users u ON ou.user_id = u.id
 WHERE ou.org_id = $1 AND u.deleted_at IS NULL
@@ -38,6 +38,8 @@ func (q *Queries) GetOrganizationUsers(ctx context.Context, orgID int32) ([]*Get
 			&i.User.CreatedAt,
 			&i.User.UpdatedAt,
 			&i.User.DeletedAt,
+			&i.User.ReverifyNewLogins,
+			&i.User.Timezone,
 			&i.Level,
 		); err != nil {
 			return nil, err
@@ -66,6 +66,24 @@ func (q *Queries) FindUserOrgByName(ctx context.Context, arg *FindUserOrgByNameP
 	return &i, err
 }
 
+const getOrganizationByID = `-- name: GetOrganizationByID :one
+SELECT id, name, user_id, created_at, updated_at, deleted_at FROM backend.organizations WHERE id = $1
+`
+
+func (q *Queries) GetOrganizationByID(ctx context.Context, id int32) (*Organization, error) {
+	row := q.db.QueryRow(ctx, getOrganizationByID, id)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return &i, err
+}
+
 const getOrganizationWithAccess = `-- name: GetOrganizationWithAccess :one
  SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, ou.level
  FROM backend.organizations o
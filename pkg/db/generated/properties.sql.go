@@ -13,9 +13,9 @@ import (
 )
 
 const createProperty = `-- name: CreateProperty :one
-INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay
+INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, test_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
 `
 
 type CreatePropertyParams struct {
@@ -26,6 +26,7 @@ type CreatePropertyParams struct {
 	Domain     string           `db:"domain" json:"domain"`
 	Level      pgtype.Int2      `db:"level" json:"level"`
 	Growth     DifficultyGrowth `db:"growth" json:"growth"`
+	TestMode   bool             `db:"test_mode" json:"test_mode"`
 }
 
 func (q *Queries) CreateProperty(ctx context.Context, arg *CreatePropertyParams) (*Property, error) {
@@ -37,6 +38,7 @@ func (q *Queries) CreateProperty(ctx context.Context, arg *CreatePropertyParams)
 		arg.Domain,
 		arg.Level,
 		arg.Growth,
+		arg.TestMode,
 	)
 	var i Property
 	err := row.Scan(
@@ -57,6 +59,19 @@ func (q *Queries) CreateProperty(ctx context.Context, arg *CreatePropertyParams)
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
 	)
 	return &i, err
 }
@@ -71,7 +86,7 @@ func (q *Queries) DeleteProperties(ctx context.Context, dollar_1 []int32) error
 }
 
 const getOrgProperties = `-- name: GetOrgProperties :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay from backend.properties WHERE org_id = $1 AND deleted_at IS NULL ORDER BY created_at
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode from backend.properties WHERE org_id = $1 AND deleted_at IS NULL ORDER BY created_at
 `
 
 func (q *Queries) GetOrgProperties(ctx context.Context, orgID pgtype.Int4) ([]*Property, error) {
@@ -101,6 +116,19 @@ func (q *Queries) GetOrgProperties(ctx context.Context, orgID pgtype.Int4) ([]*P
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.AllowReplay,
+			&i.Algorithm,
+			&i.PrivacyMode,
+			&i.AllowedOrigins,
+			&i.TrustedVisitorsThreshold,
+			&i.TrustedVisitorsTtl,
+			&i.TestMode,
+			&i.IplessMode,
+			&i.WidgetChannel,
+			&i.DataRegion,
+			&i.PausedAt,
+			&i.DomainVerifiedAt,
+			&i.AllowedAppIDs,
+			&i.AccessibleMode,
 		); err != nil {
 			return nil, err
 		}
@@ -113,7 +141,7 @@ func (q *Queries) GetOrgProperties(ctx context.Context, orgID pgtype.Int4) ([]*P
 }
 
 const getOrgPropertyByName = `-- name: GetOrgPropertyByName :one
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay from backend.properties WHERE org_id = $1 AND name = $2 AND deleted_at IS NULL
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode from backend.properties WHERE org_id = $1 AND name = $2 AND deleted_at IS NULL
 `
 
 type GetOrgPropertyByNameParams struct {
@@ -142,12 +170,25 @@ func (q *Queries) GetOrgPropertyByName(ctx context.Context, arg *GetOrgPropertyB
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
 	)
 	return &i, err
 }
 
 const getProperties = `-- name: GetProperties :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay FROM backend.properties LIMIT $1
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode FROM backend.properties LIMIT $1
 `
 
 func (q *Queries) GetProperties(ctx context.Context, limit int32) ([]*Property, error) {
@@ -177,6 +218,19 @@ func (q *Queries) GetProperties(ctx context.Context, limit int32) ([]*Property,
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.AllowReplay,
+			&i.Algorithm,
+			&i.PrivacyMode,
+			&i.AllowedOrigins,
+			&i.TrustedVisitorsThreshold,
+			&i.TrustedVisitorsTtl,
+			&i.TestMode,
+			&i.IplessMode,
+			&i.WidgetChannel,
+			&i.DataRegion,
+			&i.PausedAt,
+			&i.DomainVerifiedAt,
+			&i.AllowedAppIDs,
+			&i.AccessibleMode,
 		); err != nil {
 			return nil, err
 		}
@@ -189,7 +243,7 @@ func (q *Queries) GetProperties(ctx context.Context, limit int32) ([]*Property,
 }
 
 const getPropertiesByExternalID = `-- name: GetPropertiesByExternalID :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay from backend.properties WHERE external_id = ANY($1::UUID[])
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode from backend.properties WHERE external_id = ANY($1::UUID[])
 `
 
 func (q *Queries) GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgtype.UUID) ([]*Property, error) {
@@ -219,6 +273,19 @@ func (q *Queries) GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgty
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.AllowReplay,
+			&i.Algorithm,
+			&i.PrivacyMode,
+			&i.AllowedOrigins,
+			&i.TrustedVisitorsThreshold,
+			&i.TrustedVisitorsTtl,
+			&i.TestMode,
+			&i.IplessMode,
+			&i.WidgetChannel,
+			&i.DataRegion,
+			&i.PausedAt,
+			&i.DomainVerifiedAt,
+			&i.AllowedAppIDs,
+			&i.AccessibleMode,
 		); err != nil {
 			return nil, err
 		}
@@ -231,7 +298,7 @@ func (q *Queries) GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgty
 }
 
 const getPropertyByID = `-- name: GetPropertyByID :one
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay from backend.properties WHERE id = $1
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode from backend.properties WHERE id = $1
 `
 
 func (q *Queries) GetPropertyByID(ctx context.Context, id int32) (*Property, error) {
@@ -255,12 +322,25 @@ func (q *Queries) GetPropertyByID(ctx context.Context, id int32) (*Property, err
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
 	)
 	return &i, err
 }
 
 const getSoftDeletedProperties = `-- name: GetSoftDeletedProperties :many
-SELECT p.id, p.name, p.external_id, p.org_id, p.creator_id, p.org_owner_id, p.domain, p.level, p.salt, p.growth, p.created_at, p.updated_at, p.deleted_at, p.validity_interval, p.allow_subdomains, p.allow_localhost, p.allow_replay
+SELECT p.id, p.name, p.external_id, p.org_id, p.creator_id, p.org_owner_id, p.domain, p.level, p.salt, p.growth, p.created_at, p.updated_at, p.deleted_at, p.validity_interval, p.allow_subdomains, p.allow_localhost, p.allow_replay, p.algorithm, p.privacy_mode, p.allowed_origins, p.trusted_visitors_threshold, p.trusted_visitors_ttl, p.test_mode, p.ipless_mode, p.widget_channel, p.data_region, p.paused_at, p.domain_verified_at, p.allowed_app_ids, p.accessible_mode
 FROM backend.properties p
 JOIN backend.organizations o ON p.org_id = o.id
 JOIN backend.users u ON o.user_id = u.id
@@ -307,6 +387,19 @@ func (q *Queries) GetSoftDeletedProperties(ctx context.Context, arg *GetSoftDele
 			&i.Property.AllowSubdomains,
 			&i.Property.AllowLocalhost,
 			&i.Property.AllowReplay,
+			&i.Property.Algorithm,
+			&i.Property.PrivacyMode,
+			&i.Property.AllowedOrigins,
+			&i.Property.TrustedVisitorsThreshold,
+			&i.Property.TrustedVisitorsTtl,
+			&i.Property.TestMode,
+			&i.Property.IplessMode,
+			&i.Property.WidgetChannel,
+			&i.Property.DataRegion,
+			&i.Property.PausedAt,
+			&i.Property.DomainVerifiedAt,
+			&i.Property.AllowedAppIDs,
+			&i.Property.AccessibleMode,
 		); err != nil {
 			return nil, err
 		}
@@ -329,8 +422,50 @@ func (q *Queries) GetUserPropertiesCount(ctx context.Context, orgOwnerID pgtype.
 	return count, err
 }
 
+const rotatePropertyExternalID = `-- name: RotatePropertyExternalID :one
+UPDATE backend.properties SET external_id = gen_random_uuid(), updated_at = NOW() WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
+`
+
+func (q *Queries) RotatePropertyExternalID(ctx context.Context, id int32) (*Property, error) {
+	row := q.db.QueryRow(ctx, rotatePropertyExternalID, id)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
+	)
+	return &i, err
+}
+
 const softDeleteProperty = `-- name: SoftDeleteProperty :one
-UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay
+UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
 `
 
 func (q *Queries) SoftDeleteProperty(ctx context.Context, id int32) (*Property, error) {
@@ -354,25 +489,47 @@ func (q *Queries) SoftDeleteProperty(ctx context.Context, id int32) (*Property,
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
 	)
 	return &i, err
 }
 
 const updateProperty = `-- name: UpdateProperty :one
-UPDATE backend.properties SET name = $2, level = $3, growth = $4, validity_interval = $5, allow_subdomains = $6, allow_localhost = $7, allow_replay = $8, updated_at = NOW()
+UPDATE backend.properties SET name = $2, level = $3, growth = $4, validity_interval = $5, allow_subdomains = $6, allow_localhost = $7, allow_replay = $8, algorithm = $9, privacy_mode = $10, allowed_origins = $11, trusted_visitors_threshold = $12, trusted_visitors_ttl = $13, ipless_mode = $14, widget_channel = $15, data_region = $16, accessible_mode = $17, updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
 `
 
 type UpdatePropertyParams struct {
-	ID               int32            `db:"id" json:"id"`
-	Name             string           `db:"name" json:"name"`
-	Level            pgtype.Int2      `db:"level" json:"level"`
-	Growth           DifficultyGrowth `db:"growth" json:"growth"`
-	ValidityInterval time.Duration    `db:"validity_interval" json:"validity_interval"`
-	AllowSubdomains  bool             `db:"allow_subdomains" json:"allow_subdomains"`
-	AllowLocalhost   bool             `db:"allow_localhost" json:"allow_localhost"`
-	AllowReplay      bool             `db:"allow_replay" json:"allow_replay"`
+	ID                       int32            `db:"id" json:"id"`
+	Name                     string           `db:"name" json:"name"`
+	Level                    pgtype.Int2      `db:"level" json:"level"`
+	Growth                   DifficultyGrowth `db:"growth" json:"growth"`
+	ValidityInterval         time.Duration    `db:"validity_interval" json:"validity_interval"`
+	AllowSubdomains          bool             `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhost           bool             `db:"allow_localhost" json:"allow_localhost"`
+	AllowReplay              bool             `db:"allow_replay" json:"allow_replay"`
+	Algorithm                PowAlgorithm     `db:"algorithm" json:"algorithm"`
+	PrivacyMode              bool             `db:"privacy_mode" json:"privacy_mode"`
+	AllowedOrigins           []string         `db:"allowed_origins" json:"allowed_origins"`
+	TrustedVisitorsThreshold int16            `db:"trusted_visitors_threshold" json:"trusted_visitors_threshold"`
+	TrustedVisitorsTtl       time.Duration    `db:"trusted_visitors_ttl" json:"trusted_visitors_ttl"`
+	IplessMode               bool             `db:"ipless_mode" json:"ipless_mode"`
+	WidgetChannel            WidgetChannel    `db:"widget_channel" json:"widget_channel"`
+	DataRegion               string           `db:"data_region" json:"data_region"`
+	AccessibleMode           bool             `db:"accessible_mode" json:"accessible_mode"`
 }
 
 func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams) (*Property, error) {
@@ -385,6 +542,15 @@ func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams)
 		arg.AllowSubdomains,
 		arg.AllowLocalhost,
 		arg.AllowReplay,
+		arg.Algorithm,
+		arg.PrivacyMode,
+		arg.AllowedOrigins,
+		arg.TrustedVisitorsThreshold,
+		arg.TrustedVisitorsTtl,
+		arg.IplessMode,
+		arg.WidgetChannel,
+		arg.DataRegion,
+		arg.AccessibleMode,
 	)
 	var i Property
 	err := row.Scan(
@@ -405,6 +571,207 @@ func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams)
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
+	)
+	return &i, err
+}
+
+const updatePropertyAppIDs = `-- name: UpdatePropertyAppIDs :one
+UPDATE backend.properties SET allowed_app_ids = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
+`
+
+type UpdatePropertyAppIDsParams struct {
+	ID            int32    `db:"id" json:"id"`
+	AllowedAppIDs []string `db:"allowed_app_ids" json:"allowed_app_ids"`
+}
+
+func (q *Queries) UpdatePropertyAppIDs(ctx context.Context, arg *UpdatePropertyAppIDsParams) (*Property, error) {
+	row := q.db.QueryRow(ctx, updatePropertyAppIDs, arg.ID, arg.AllowedAppIDs)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
+	)
+	return &i, err
+}
+
+const updatePropertyDomainVerified = `-- name: UpdatePropertyDomainVerified :one
+UPDATE backend.properties SET domain_verified_at = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
+`
+
+type UpdatePropertyDomainVerifiedParams struct {
+	ID               int32              `db:"id" json:"id"`
+	DomainVerifiedAt pgtype.Timestamptz `db:"domain_verified_at" json:"domain_verified_at"`
+}
+
+func (q *Queries) UpdatePropertyDomainVerified(ctx context.Context, arg *UpdatePropertyDomainVerifiedParams) (*Property, error) {
+	row := q.db.QueryRow(ctx, updatePropertyDomainVerified, arg.ID, arg.DomainVerifiedAt)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
+	)
+	return &i, err
+}
+
+const updatePropertyExternalID = `-- name: UpdatePropertyExternalID :one
+UPDATE backend.properties SET external_id = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
+`
+
+type UpdatePropertyExternalIDParams struct {
+	ID         int32       `db:"id" json:"id"`
+	ExternalID pgtype.UUID `db:"external_id" json:"external_id"`
+}
+
+func (q *Queries) UpdatePropertyExternalID(ctx context.Context, arg *UpdatePropertyExternalIDParams) (*Property, error) {
+	row := q.db.QueryRow(ctx, updatePropertyExternalID, arg.ID, arg.ExternalID)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
+	)
+	return &i, err
+}
+
+const updatePropertyPaused = `-- name: UpdatePropertyPaused :one
+UPDATE backend.properties SET paused_at = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, allow_replay, algorithm, privacy_mode, allowed_origins, trusted_visitors_threshold, trusted_visitors_ttl, test_mode, ipless_mode, widget_channel, data_region, paused_at, domain_verified_at, allowed_app_ids, accessible_mode, accessible_mode
+`
+
+type UpdatePropertyPausedParams struct {
+	ID       int32              `db:"id" json:"id"`
+	PausedAt pgtype.Timestamptz `db:"paused_at" json:"paused_at"`
+}
+
+func (q *Queries) UpdatePropertyPaused(ctx context.Context, arg *UpdatePropertyPausedParams) (*Property, error) {
+	row := q.db.QueryRow(ctx, updatePropertyPaused, arg.ID, arg.PausedAt)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.AllowReplay,
+		&i.Algorithm,
+		&i.PrivacyMode,
+		&i.AllowedOrigins,
+		&i.TrustedVisitorsThreshold,
+		&i.TrustedVisitorsTtl,
+		&i.TestMode,
+		&i.IplessMode,
+		&i.WidgetChannel,
+		&i.DataRegion,
+		&i.PausedAt,
+		&i.DomainVerifiedAt,
+		&i.AllowedAppIDs,
+		&i.AccessibleMode,
 	)
 	return &i, err
 }
@@ -11,64 +11,184 @@ import (
 )
 
 type Querier interface {
+	AddAPIKeyUsage(ctx context.Context, arg *AddAPIKeyUsageParams) error
+	AddPropertyTags(ctx context.Context, arg *AddPropertyTagsParams) error
+	ArchiveExpiredAPIKeys(ctx context.Context, arg *ArchiveExpiredAPIKeysParams) ([]*APIKey, error)
+	ClaimQueueJobs(ctx context.Context, arg *ClaimQueueJobsParams) ([]*QueueJob, error)
 	CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*APIKey, error)
+	// cache is partitioned by expiration so key alone is not unique and previous value is deleted instead of upsert
 	CreateCache(ctx context.Context, arg *CreateCacheParams) error
 	CreateCacheMany(ctx context.Context, arg *CreateCacheManyParams) error
+	CreateDomainVerification(ctx context.Context, arg *CreateDomainVerificationParams) (*DomainVerification, error)
+	CreateEmailChange(ctx context.Context, arg *CreateEmailChangeParams) (*EmailChange, error)
 	CreateNotification(ctx context.Context, arg *CreateNotificationParams) (*SystemNotification, error)
+	CreateOrgAPIKey(ctx context.Context, arg *CreateOrgAPIKeyParams) (*APIKey, error)
+	CreateOrgDigest(ctx context.Context, arg *CreateOrgDigestParams) (*OrgDigest, error)
 	CreateOrganization(ctx context.Context, arg *CreateOrganizationParams) (*Organization, error)
 	CreateProperty(ctx context.Context, arg *CreatePropertyParams) (*Property, error)
+	CreatePropertyEvents(ctx context.Context, arg *CreatePropertyEventsParams) error
+	CreateQueueJob(ctx context.Context, arg *CreateQueueJobParams) error
 	CreateSubscription(ctx context.Context, arg *CreateSubscriptionParams) (*Subscription, error)
+	CreateSupportTicket(ctx context.Context, arg *CreateSupportTicketParams) (*SupportTicket, error)
+	CreateSupportTicketReply(ctx context.Context, arg *CreateSupportTicketReplyParams) (*SupportTicketReply, error)
 	CreateUser(ctx context.Context, arg *CreateUserParams) (*User, error)
+	CreateUserEmail(ctx context.Context, arg *CreateUserEmailParams) (*UserEmail, error)
+	CreateUserLogin(ctx context.Context, arg *CreateUserLoginParams) (*UserLogin, error)
+	CreateUserNotification(ctx context.Context, arg *CreateUserNotificationParams) (*UserNotification, error)
+	CreateWebhookEvent(ctx context.Context, arg *CreateWebhookEventParams) (*WebhookEvent, error)
 	DeleteAPIKey(ctx context.Context, arg *DeleteAPIKeyParams) (*APIKey, error)
+	DeleteAPIKeyUsage(ctx context.Context, hour pgtype.Timestamptz) error
 	DeleteCachedByKey(ctx context.Context, key string) error
 	DeleteDeletedRecords(ctx context.Context, deletedAt pgtype.Timestamptz) error
-	DeleteExpiredCache(ctx context.Context) error
+	DeleteExpiredCache(ctx context.Context, hoursAhead int32) error
+	DeleteFailedQueueJobs(ctx context.Context, failedAt pgtype.Timestamptz) error
 	DeleteLock(ctx context.Context, name string) error
+	DeleteOrgAPIKey(ctx context.Context, arg *DeleteOrgAPIKeyParams) (*APIKey, error)
+	DeleteOrgBillingContact(ctx context.Context, orgID int32) error
 	DeleteOrganizations(ctx context.Context, dollar_1 []int32) error
+	DeleteOtherPropertyTags(ctx context.Context, arg *DeleteOtherPropertyTagsParams) error
+	DeleteExhaustedWebhookEvents(ctx context.Context, arg *DeleteExhaustedWebhookEventsParams) ([]*WebhookEvent, error)
+	DeleteProcessedWebhookEvents(ctx context.Context, processedAt pgtype.Timestamptz) error
 	DeleteProperties(ctx context.Context, dollar_1 []int32) error
+	DeletePropertyPermission(ctx context.Context, arg *DeletePropertyPermissionParams) error
+	DeletePropertyQuota(ctx context.Context, propertyID int32) error
+	DeletePropertySitekeyRotation(ctx context.Context, propertyID int32) (*PropertySitekeyRotation, error)
+	DeleteQueueJob(ctx context.Context, id int32) error
+	DeleteStaleLicenseNodes(ctx context.Context, lastSeenAt pgtype.Timestamptz) error
 	DeleteUserAPIKeys(ctx context.Context, userID pgtype.Int4) error
+	DeleteUserEmail(ctx context.Context, arg *DeleteUserEmailParams) error
+	DeleteUserLockout(ctx context.Context, userID int32) error
+	DeleteUserOrgPropertyPermissions(ctx context.Context, arg *DeleteUserOrgPropertyPermissionsParams) error
 	DeleteUsers(ctx context.Context, dollar_1 []int32) error
+	DisableRotatedAPIKeys(ctx context.Context, rotatedAt pgtype.Timestamptz) ([]*APIKey, error)
+	DismissOnboarding(ctx context.Context, userID int32) (*UserPreference, error)
+	DismissUserNotification(ctx context.Context, arg *DismissUserNotificationParams) error
+	ExpireAPIKeys(ctx context.Context, expiresAt pgtype.Timestamptz) ([]*APIKey, error)
+	FailQueueJob(ctx context.Context, arg *FailQueueJobParams) error
 	FindUserOrgByName(ctx context.Context, arg *FindUserOrgByNameParams) (*Organization, error)
 	GetAPIKeyByExternalID(ctx context.Context, externalID pgtype.UUID) (*APIKey, error)
+	GetAPIKeysDueForRotation(ctx context.Context, limit int32) ([]*APIKey, error)
+	GetAPIKeysExpiringSoon(ctx context.Context, arg *GetAPIKeysExpiringSoonParams) ([]*APIKey, error)
+	// usage of active keys since @recent_from compared to hourly usage of the same keys during the baseline period
+	GetAPIKeysUsageStats(ctx context.Context, arg *GetAPIKeysUsageStatsParams) ([]*GetAPIKeysUsageStatsRow, error)
+	GetActiveLicenseNodesCount(ctx context.Context, lastSeenAt pgtype.Timestamptz) (int64, error)
+	GetActiveOrgBudgets(ctx context.Context) ([]*GetActiveOrgBudgetsRow, error)
+	GetActiveUserLogins(ctx context.Context, arg *GetActiveUserLoginsParams) ([]*UserLogin, error)
 	GetCachedByKey(ctx context.Context, key string) ([]byte, error)
+	GetDomainVerification(ctx context.Context, propertyID int32) (*DomainVerification, error)
+	GetEmailChangeByTokenHash(ctx context.Context, revertTokenHash string) (*EmailChange, error)
+	GetInstanceCounts(ctx context.Context) (*GetInstanceCountsRow, error)
 	GetLastActiveNotification(ctx context.Context, arg *GetLastActiveNotificationParams) (*SystemNotification, error)
 	GetNotificationById(ctx context.Context, id int32) (*SystemNotification, error)
+	GetOrgAPIKeys(ctx context.Context, orgID pgtype.Int4) ([]*APIKey, error)
+	GetOrgBillingContacts(ctx context.Context, userID int32) ([]*OrgBillingContact, error)
+	GetOrgBillingEmail(ctx context.Context, orgID int32) (string, error)
+	GetOrgBudget(ctx context.Context, orgID int32) (*OrgBudget, error)
+	GetOrgDigestActivity(ctx context.Context, arg *GetOrgDigestActivityParams) (*GetOrgDigestActivityRow, error)
+	GetOrgDigestCandidates(ctx context.Context, arg *GetOrgDigestCandidatesParams) ([]*GetOrgDigestCandidatesRow, error)
 	GetOrgProperties(ctx context.Context, orgID pgtype.Int4) ([]*Property, error)
 	GetOrgPropertyByName(ctx context.Context, arg *GetOrgPropertyByNameParams) (*Property, error)
+	GetOrgPropertyTags(ctx context.Context, orgID pgtype.Int4) ([]*PropertyTag, error)
+	GetOrganizationByID(ctx context.Context, id int32) (*Organization, error)
 	GetOrganizationUsers(ctx context.Context, orgID int32) ([]*GetOrganizationUsersRow, error)
 	GetOrganizationWithAccess(ctx context.Context, arg *GetOrganizationWithAccessParams) (*GetOrganizationWithAccessRow, error)
+	GetOwnerAllocatedQuota(ctx context.Context, arg *GetOwnerAllocatedQuotaParams) (int32, error)
+	GetPendingDomainVerifications(ctx context.Context, arg *GetPendingDomainVerificationsParams) ([]*GetPendingDomainVerificationsRow, error)
+	GetPendingWebhookEvents(ctx context.Context, arg *GetPendingWebhookEventsParams) ([]*WebhookEvent, error)
 	GetProperties(ctx context.Context, limit int32) ([]*Property, error)
 	GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgtype.UUID) ([]*Property, error)
+	GetPropertiesByPreviousExternalID(ctx context.Context, dollar_1 []pgtype.UUID) ([]*GetPropertiesByPreviousExternalIDRow, error)
 	GetPropertyByID(ctx context.Context, id int32) (*Property, error)
+	GetPropertyEvents(ctx context.Context, arg *GetPropertyEventsParams) ([]*GetPropertyEventsRow, error)
+	GetPropertyMessages(ctx context.Context, propertyID int32) (*PropertyMessage, error)
+	GetPropertyPermissions(ctx context.Context, propertyID int32) ([]*PropertyPermission, error)
+	GetPropertyQuota(ctx context.Context, propertyID int32) (*PropertyQuota, error)
+	GetPropertySitekeyRotation(ctx context.Context, propertyID int32) (*PropertySitekeyRotation, error)
+	GetPropertyTags(ctx context.Context, propertyID int32) ([]*PropertyTag, error)
 	GetSoftDeletedOrganizations(ctx context.Context, arg *GetSoftDeletedOrganizationsParams) ([]*GetSoftDeletedOrganizationsRow, error)
 	GetSoftDeletedProperties(ctx context.Context, arg *GetSoftDeletedPropertiesParams) ([]*GetSoftDeletedPropertiesRow, error)
 	GetSoftDeletedUsers(ctx context.Context, arg *GetSoftDeletedUsersParams) ([]*GetSoftDeletedUsersRow, error)
 	GetSubscriptionByID(ctx context.Context, id int32) (*Subscription, error)
 	GetSubscriptionsByUserIDs(ctx context.Context, dollar_1 []int32) ([]*GetSubscriptionsByUserIDsRow, error)
+	GetSupportTicketByTicketID(ctx context.Context, ticketID string) (*SupportTicket, error)
+	GetSupportTicketsReplies(ctx context.Context, dollar_1 []int32) ([]*SupportTicketReply, error)
+	GetUnreadUserNotificationsCount(ctx context.Context, userID int32) (int64, error)
 	GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*APIKey, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserByID(ctx context.Context, id int32) (*User, error)
 	GetUserBySubscriptionID(ctx context.Context, subscriptionID pgtype.Int4) (*User, error)
+	GetUserEmailByTokenHash(ctx context.Context, verifyTokenHash pgtype.Text) (*UserEmail, error)
+	GetUserEmails(ctx context.Context, userID int32) ([]*UserEmail, error)
+	GetUserLockout(ctx context.Context, userID int32) (*UserLockout, error)
+	GetUserLoginByID(ctx context.Context, id int32) (*UserLogin, error)
+	GetUserLoginOrigins(ctx context.Context, arg *GetUserLoginOriginsParams) (*GetUserLoginOriginsRow, error)
+	GetUserLogins(ctx context.Context, arg *GetUserLoginsParams) ([]*UserLogin, error)
+	GetUserNotifications(ctx context.Context, arg *GetUserNotificationsParams) ([]*UserNotification, error)
 	GetUserOrganizations(ctx context.Context, userID pgtype.Int4) ([]*GetUserOrganizationsRow, error)
+	GetUserPreferences(ctx context.Context, userID int32) (*UserPreference, error)
 	GetUserPropertiesCount(ctx context.Context, orgOwnerID pgtype.Int4) (int64, error)
+	GetUserPropertyPermissions(ctx context.Context, arg *GetUserPropertyPermissionsParams) ([]*PropertyPermission, error)
+	GetUserSupportTickets(ctx context.Context, arg *GetUserSupportTicketsParams) ([]*SupportTicket, error)
 	GetUsersWithoutSubscription(ctx context.Context, dollar_1 []int32) ([]*User, error)
+	// failures before @decay_before are forgotten so that occasional typos do not add up to a lockout over time
+	IncrementUserFailedAttempts(ctx context.Context, arg *IncrementUserFailedAttemptsParams) (*UserLockout, error)
 	InsertLock(ctx context.Context, arg *InsertLockParams) (*Lock, error)
 	InviteUserToOrg(ctx context.Context, arg *InviteUserToOrgParams) (*OrganizationUser, error)
+	LockUser(ctx context.Context, arg *LockUserParams) (*UserLockout, error)
+	MarkAllUserNotificationsRead(ctx context.Context, userID int32) error
+	MarkEmailChangeReverted(ctx context.Context, id int32) (*EmailChange, error)
+	MarkUserEmailVerified(ctx context.Context, id int32) (*UserEmail, error)
+	MarkUserNotificationRead(ctx context.Context, arg *MarkUserNotificationReadParams) error
+	MarkWebhookEventFailed(ctx context.Context, arg *MarkWebhookEventFailedParams) error
+	MarkWebhookEventProcessed(ctx context.Context, id int32) error
+	MarkWebhookEventSkipped(ctx context.Context, arg *MarkWebhookEventSkippedParams) error
+	NotifyCacheInvalidation(ctx context.Context, arg *NotifyCacheInvalidationParams) error
 	Ping(ctx context.Context) (int32, error)
 	RemoveUserFromOrg(ctx context.Context, arg *RemoveUserFromOrgParams) error
+	// usage before suspension is removed so that the same spike does not suspend the key again
+	ResumeAPIKey(ctx context.Context, arg *ResumeAPIKeyParams) (*APIKey, error)
+	RetryQueueJob(ctx context.Context, arg *RetryQueueJobParams) error
+	RevokeUserLogins(ctx context.Context, arg *RevokeUserLoginsParams) ([]*UserLogin, error)
+	RevokeUserLoginsByID(ctx context.Context, arg *RevokeUserLoginsByIDParams) ([]*UserLogin, error)
+	RotatePropertyExternalID(ctx context.Context, id int32) (*Property, error)
+	SearchUserAPIKeys(ctx context.Context, arg *SearchUserAPIKeysParams) ([]*APIKey, error)
+	SearchUserOrganizations(ctx context.Context, arg *SearchUserOrganizationsParams) ([]*SearchUserOrganizationsRow, error)
+	SearchUserProperties(ctx context.Context, arg *SearchUserPropertiesParams) ([]*SearchUserPropertiesRow, error)
+	SetAPIKeySuccessor(ctx context.Context, arg *SetAPIKeySuccessorParams) (*APIKey, error)
 	SoftDeleteProperty(ctx context.Context, id int32) (*Property, error)
 	SoftDeleteUser(ctx context.Context, id int32) (*User, error)
 	SoftDeleteUserOrganization(ctx context.Context, arg *SoftDeleteUserOrganizationParams) error
 	SoftDeleteUserOrganizations(ctx context.Context, userID pgtype.Int4) error
+	SuspendAPIKey(ctx context.Context, id int32) (*APIKey, error)
 	UpdateAPIKey(ctx context.Context, arg *UpdateAPIKeyParams) (*APIKey, error)
+	UpdateAPIKeyExpiryNotified(ctx context.Context, arg *UpdateAPIKeyExpiryNotifiedParams) error
+	UpdateAPIKeyExternalID(ctx context.Context, arg *UpdateAPIKeyExternalIDParams) (*APIKey, error)
+	UpdateAPIKeyRotation(ctx context.Context, arg *UpdateAPIKeyRotationParams) (*APIKey, error)
 	UpdateCacheExpiration(ctx context.Context, arg *UpdateCacheExpirationParams) error
+	UpdateDomainVerificationCheck(ctx context.Context, arg *UpdateDomainVerificationCheckParams) error
+	UpdateOrgBudgetAlert(ctx context.Context, arg *UpdateOrgBudgetAlertParams) error
 	UpdateOrgMembershipLevel(ctx context.Context, arg *UpdateOrgMembershipLevelParams) error
 	UpdateOrganization(ctx context.Context, arg *UpdateOrganizationParams) (*Organization, error)
 	UpdateProperty(ctx context.Context, arg *UpdatePropertyParams) (*Property, error)
+	UpdatePropertyAppIDs(ctx context.Context, arg *UpdatePropertyAppIDsParams) (*Property, error)
+	UpdatePropertyDomainVerified(ctx context.Context, arg *UpdatePropertyDomainVerifiedParams) (*Property, error)
+	UpdatePropertyExternalID(ctx context.Context, arg *UpdatePropertyExternalIDParams) (*Property, error)
+	UpdatePropertyPaused(ctx context.Context, arg *UpdatePropertyPausedParams) (*Property, error)
 	UpdateSubscription(ctx context.Context, arg *UpdateSubscriptionParams) (*Subscription, error)
+	UpdateSupportTicketStatus(ctx context.Context, arg *UpdateSupportTicketStatusParams) error
 	UpdateUserAPIKeysRateLimits(ctx context.Context, arg *UpdateUserAPIKeysRateLimitsParams) error
 	UpdateUserData(ctx context.Context, arg *UpdateUserDataParams) (*User, error)
+	UpdateUserReverifyNewLogins(ctx context.Context, arg *UpdateUserReverifyNewLoginsParams) (*User, error)
 	UpdateUserSubscription(ctx context.Context, arg *UpdateUserSubscriptionParams) (*User, error)
+	UpdateUserTimezone(ctx context.Context, arg *UpdateUserTimezoneParams) (*User, error)
+	UpsertLicenseNode(ctx context.Context, arg *UpsertLicenseNodeParams) error
+	UpsertOrgBillingContact(ctx context.Context, arg *UpsertOrgBillingContactParams) error
+	UpsertOrgBudget(ctx context.Context, arg *UpsertOrgBudgetParams) (*OrgBudget, error)
+	UpsertPropertyMessages(ctx context.Context, arg *UpsertPropertyMessagesParams) (*PropertyMessage, error)
+	UpsertPropertyPermission(ctx context.Context, arg *UpsertPropertyPermissionParams) (*PropertyPermission, error)
+	UpsertPropertyQuota(ctx context.Context, arg *UpsertPropertyQuotaParams) (*PropertyQuota, error)
+	UpsertPropertySitekeyRotation(ctx context.Context, arg *UpsertPropertySitekeyRotationParams) (*PropertySitekeyRotation, error)
+	UpsertUserPreferences(ctx context.Context, arg *UpsertUserPreferencesParams) (*UserPreference, error)
 }
 
 var _ Querier = (*Queries)(nil)
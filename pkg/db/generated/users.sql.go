@@ -12,7 +12,7 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO backend.users (name, email, subscription_id) VALUES ($1, $2, $3) RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at
+INSERT INTO backend.users (name, email, subscription_id) VALUES ($1, $2, $3) RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone
 `
 
 type CreateUserParams struct {
@@ -32,6 +32,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg *CreateUserParams) (*User,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
@@ -46,7 +48,7 @@ func (q *Queries) DeleteUsers(ctx context.Context, dollar_1 []int32) error {
 }
 
 const getSoftDeletedUsers = `-- name: GetSoftDeletedUsers :many
-SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at
+SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, u.reverify_new_logins, u.timezone
 FROM backend.users u
 WHERE u.deleted_at IS NOT NULL
   AND u.deleted_at < $1
@@ -79,6 +81,8 @@ func (q *Queries) GetSoftDeletedUsers(ctx context.Context, arg *GetSoftDeletedUs
 			&i.User.CreatedAt,
 			&i.User.UpdatedAt,
 			&i.User.DeletedAt,
+			&i.User.ReverifyNewLogins,
+			&i.User.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -91,7 +95,7 @@ func (q *Queries) GetSoftDeletedUsers(ctx context.Context, arg *GetSoftDeletedUs
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at FROM backend.users WHERE email = $1 AND deleted_at IS NULL
+SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone FROM backend.users WHERE email = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (*User, error) {
@@ -105,12 +109,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (*User, erro
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at FROM backend.users WHERE id = $1
+SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone FROM backend.users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id int32) (*User, error) {
@@ -124,12 +130,14 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (*User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
 
 const getUserBySubscriptionID = `-- name: GetUserBySubscriptionID :one
-SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at FROM backend.users WHERE subscription_id = $1
+SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone FROM backend.users WHERE subscription_id = $1
 `
 
 func (q *Queries) GetUserBySubscriptionID(ctx context.Context, subscriptionID pgtype.Int4) (*User, error) {
@@ -143,12 +151,14 @@ func (q *Queries) GetUserBySubscriptionID(ctx context.Context, subscriptionID pg
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
 
 const getUsersWithoutSubscription = `-- name: GetUsersWithoutSubscription :many
-SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at FROM backend.users where id = ANY($1::INT[]) AND (subscription_id IS NULL OR deleted_at IS NOT NULL)
+SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone FROM backend.users where id = ANY($1::INT[]) AND (subscription_id IS NULL OR deleted_at IS NOT NULL)
 `
 
 func (q *Queries) GetUsersWithoutSubscription(ctx context.Context, dollar_1 []int32) ([]*User, error) {
@@ -168,6 +178,8 @@ func (q *Queries) GetUsersWithoutSubscription(ctx context.Context, dollar_1 []in
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.ReverifyNewLogins,
+			&i.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -180,7 +192,7 @@ func (q *Queries) GetUsersWithoutSubscription(ctx context.Context, dollar_1 []in
 }
 
 const softDeleteUser = `-- name: SoftDeleteUser :one
-UPDATE backend.users SET deleted_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at
+UPDATE backend.users SET deleted_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone
 `
 
 func (q *Queries) SoftDeleteUser(ctx context.Context, id int32) (*User, error) {
@@ -194,12 +206,14 @@ func (q *Queries) SoftDeleteUser(ctx context.Context, id int32) (*User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
 
 const updateUserData = `-- name: UpdateUserData :one
-UPDATE backend.users SET name = $2, email = $3, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at
+UPDATE backend.users SET name = $2, email = $3, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone
 `
 
 type UpdateUserDataParams struct {
@@ -219,12 +233,40 @@ func (q *Queries) UpdateUserData(ctx context.Context, arg *UpdateUserDataParams)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
+	)
+	return &i, err
+}
+
+const updateUserReverifyNewLogins = `-- name: UpdateUserReverifyNewLogins :one
+UPDATE backend.users SET reverify_new_logins = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone
+`
+
+type UpdateUserReverifyNewLoginsParams struct {
+	ID                int32 `db:"id" json:"id"`
+	ReverifyNewLogins bool  `db:"reverify_new_logins" json:"reverify_new_logins"`
+}
+
+func (q *Queries) UpdateUserReverifyNewLogins(ctx context.Context, arg *UpdateUserReverifyNewLoginsParams) (*User, error) {
+	row := q.db.QueryRow(ctx, updateUserReverifyNewLogins, arg.ID, arg.ReverifyNewLogins)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.SubscriptionID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
 
 const updateUserSubscription = `-- name: UpdateUserSubscription :one
-UPDATE backend.users SET subscription_id = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at
+UPDATE backend.users SET subscription_id = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone
 `
 
 type UpdateUserSubscriptionParams struct {
@@ -243,6 +285,34 @@ func (q *Queries) UpdateUserSubscription(ctx context.Context, arg *UpdateUserSub
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
+	)
+	return &i, err
+}
+
+const updateUserTimezone = `-- name: UpdateUserTimezone :one
+UPDATE backend.users SET timezone = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, subscription_id, created_at, updated_at, deleted_at, reverify_new_logins, timezone
+`
+
+type UpdateUserTimezoneParams struct {
+	ID       int32  `db:"id" json:"id"`
+	Timezone string `db:"timezone" json:"timezone"`
+}
+
+func (q *Queries) UpdateUserTimezone(ctx context.Context, arg *UpdateUserTimezoneParams) (*User, error) {
+	row := q.db.QueryRow(ctx, updateUserTimezone, arg.ID, arg.Timezone)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.SubscriptionID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ReverifyNewLogins,
+		&i.Timezone,
 	)
 	return &i, err
 }
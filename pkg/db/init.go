@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -107,3 +108,91 @@ func connectEx(ctx context.Context, cfg common.ConfigStore, timeout time.Duratio
 
 	return
 }
+
+// ConnectSecondaryClickHouse connects to the secondary ClickHouse cluster (if configured), that is used only for writes.
+// Secondary cluster being unavailable on start is not an error as connections are re-established lazily.
+func ConnectSecondaryClickHouse(ctx context.Context, cfg common.ConfigStore) *sql.DB {
+	host := cfg.Get(common.ClickHouseSecondaryHostKey).Value()
+	if len(host) == 0 {
+		return nil
+	}
+
+	opts := ClickHouseConnectOpts{
+		Host:     host,
+		Database: cfg.Get(common.ClickHouseSecondaryDBKey).Value(),
+		User:     cfg.Get(common.ClickHouseSecondaryUserKey).Value(),
+		Password: cfg.Get(common.ClickHouseSecondaryPasswordKey).Value(),
+		Port:     9000,
+		Verbose:  config_pkg.AsBool(cfg.Get(common.VerboseKey)),
+	}
+
+	clickhouse := connectClickhouse(common.TraceContext(ctx, "clickhouse_secondary"), opts)
+	if err := clickhouse.Ping(); err != nil {
+		slog.WarnContext(ctx, "Failed to ping secondary ClickHouse", "host", host, common.ErrAttr(err))
+	}
+
+	return clickhouse
+}
+
+func MigrateSecondaryClickHouse(ctx context.Context, db *sql.DB, cfg common.ConfigStore, up bool) error {
+	dbCfg := cfg.Get(common.ClickHouseSecondaryDBKey)
+	const migrationsTable = "private_captcha_migrations"
+
+	return MigrateClickhouseEx(common.TraceContext(ctx, "clickhouse_secondary"), db, clickhouseMigrationsFS, dbCfg.Value(), migrationsTable, up)
+}
+
+// ConnectRegionalClickHouse connects to region-specific ClickHouse clusters (if configured). They use the same database
+// and credentials as the primary cluster. Like with secondary cluster, unavailable regions are not an error on start.
+func ConnectRegionalClickHouse(ctx context.Context, cfg common.ConfigStore, admin bool) map[string]*sql.DB {
+	regions, err := config_pkg.ParseDataRegions(cfg.Get(common.ClickHouseRegionsKey).Value())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse ClickHouse regions", common.ErrAttr(err))
+		return nil
+	}
+
+	result := make(map[string]*sql.DB, len(regions))
+
+	for region, host := range regions {
+		opts := ClickHouseConnectOpts{
+			Host:     host,
+			Database: cfg.Get(common.ClickHouseDBKey).Value(),
+			User:     clickHouseUser(cfg, admin),
+			Password: clickHousePassword(cfg, admin),
+			Port:     9000,
+			Verbose:  config_pkg.AsBool(cfg.Get(common.VerboseKey)),
+		}
+
+		clickhouse := connectClickhouse(common.TraceContext(ctx, "clickhouse_"+region), opts)
+		if err := clickhouse.Ping(); err != nil {
+			slog.WarnContext(ctx, "Failed to ping regional ClickHouse", "region", region, "host", host, common.ErrAttr(err))
+		}
+
+		result[region] = clickhouse
+	}
+
+	return result
+}
+
+func MigrateRegionalClickHouse(ctx context.Context, db *sql.DB, cfg common.ConfigStore, region string, up bool) error {
+	dbCfg := cfg.Get(common.ClickHouseDBKey)
+	const migrationsTable = "private_captcha_migrations"
+
+	return MigrateClickhouseEx(common.TraceContext(ctx, "clickhouse_"+region), db, clickhouseMigrationsFS, dbCfg.Value(), migrationsTable, up)
+}
+
+// NewTimeSeriesSinks creates additional (write-only) sinks for access and verify logs
+func NewTimeSeriesSinks(secondary *sql.DB, cfg common.ConfigStore) []common.TimeSeriesSink {
+	sinks := make([]common.TimeSeriesSink, 0)
+
+	if secondary != nil {
+		ts := NewTimeSeries(secondary)
+		ts.name = "clickhouse_secondary"
+		sinks = append(sinks, ts)
+	}
+
+	if kafka := NewKafkaSink(cfg); kafka != nil {
+		sinks = append(sinks, kafka)
+	}
+
+	return sinks
+}
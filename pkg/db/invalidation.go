@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/xid"
+)
+
+const (
+	cacheInvalidationChannel = "pc_cache_invalidation"
+	// NOTIFY payload is limited to 8000 bytes so keys are sent in chunks
+	maxInvalidationKeysPerMessage = 50
+	invalidationMinBackoff        = 1 * time.Second
+	invalidationMaxBackoff        = 1 * time.Minute
+)
+
+type cacheInvalidationKey struct {
+	Prefix cacheKeyPrefix `json:"p"`
+	Int    int            `json:"i,omitempty"`
+	Str    string         `json:"s,omitempty"`
+}
+
+type cacheInvalidationMessage struct {
+	Node string                  `json:"n"`
+	Keys []*cacheInvalidationKey `json:"k"`
+}
+
+// cacheInvalidator broadcasts cache keys, changed on this node, to other nodes with Postgres NOTIFY and
+// drops keys, changed on other nodes, from the local cache. Without it, other nodes would use stale records
+// until they expire from their caches.
+type cacheInvalidator struct {
+	nodeID  string
+	pool    *pgxpool.Pool
+	cache   common.Cache[CacheKey, any]
+	enabled atomic.Bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newCacheInvalidator(pool *pgxpool.Pool, cache common.Cache[CacheKey, any]) *cacheInvalidator {
+	return &cacheInvalidator{
+		nodeID: xid.New().String(),
+		pool:   pool,
+		cache:  cache,
+	}
+}
+
+func (ci *cacheInvalidator) Enabled() bool {
+	return (ci != nil) && ci.enabled.Load()
+}
+
+func (ci *cacheInvalidator) encode(keys []CacheKey) (string, error) {
+	msg := &cacheInvalidationMessage{
+		Node: ci.nodeID,
+		Keys: make([]*cacheInvalidationKey, 0, len(keys)),
+	}
+
+	for _, k := range keys {
+		msg.Keys = append(msg.Keys, &cacheInvalidationKey{Prefix: k.Prefix, Int: k.IntValue, Str: k.StrValue})
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// handle processes notification payload and returns number of invalidated keys
+func (ci *cacheInvalidator) handle(ctx context.Context, payload string) int {
+	msg := &cacheInvalidationMessage{}
+	if err := json.Unmarshal([]byte(payload), msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse cache invalidation message", "size", len(payload), common.ErrAttr(err))
+		return 0
+	}
+
+	// local cache was already updated by the node itself
+	if msg.Node == ci.nodeID {
+		return 0
+	}
+
+	for _, k := range msg.Keys {
+		_ = ci.cache.Delete(ctx, CacheKey{Prefix: k.Prefix, IntValue: k.Int, StrValue: k.Str})
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Invalidated cache keys", "count", len(msg.Keys), "node", msg.Node)
+
+	return len(msg.Keys)
+}
+
+func (ci *cacheInvalidator) start(ctx context.Context) {
+	ctx, ci.cancel = context.WithCancel(context.WithValue(ctx, common.TraceIDContextKey, "cache_invalidation"))
+	ci.enabled.Store(true)
+
+	ci.wg.Add(1)
+	go func() {
+		defer ci.wg.Done()
+		ci.listen(ctx)
+	}()
+
+	slog.InfoContext(ctx, "Started cache invalidation listener", "node", ci.nodeID)
+}
+
+func (ci *cacheInvalidator) stop() {
+	ci.enabled.Store(false)
+
+	if ci.cancel != nil {
+		ci.cancel()
+	}
+
+	ci.wg.Wait()
+}
+
+func (ci *cacheInvalidator) listen(ctx context.Context) {
+	backoff := invalidationMinBackoff
+
+	for {
+		err := ci.listenOnce(ctx, func() { backoff = invalidationMinBackoff })
+		if ctx.Err() != nil {
+			slog.DebugContext(ctx, "Cache invalidation listener stopped")
+			return
+		}
+
+		// notifications sent while we were not listening are lost, but cached records will expire anyways
+		slog.ErrorContext(ctx, "Cache invalidation listener failed", "backoff", backoff, common.ErrAttr(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, invalidationMaxBackoff)
+	}
+}
+
+func (ci *cacheInvalidator) listenOnce(ctx context.Context, onConnected func()) error {
+	pooledConn, err := ci.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// connection is taken out of the pool as otherwise it would continue to receive notifications after release
+	conn := pooledConn.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+cacheInvalidationChannel); err != nil {
+		return err
+	}
+
+	onConnected()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		ci.handle(ctx, notification.Payload)
+	}
+}
+
+// apiKeysListCacheKey returns cache key of the list the API key belongs to (user keys or organization tokens)
+func apiKeysListCacheKey(key *dbgen.APIKey) CacheKey {
+	if key.OrgID.Valid {
+		return orgAPIKeysCacheKey(key.OrgID.Int32)
+	}
+
+	return userAPIKeysCacheKey(key.UserID.Int32)
+}
+
+// apiKeysInvalidationKeys returns cache keys of the API keys themselves and of their owners' lists
+func apiKeysInvalidationKeys(keys []*dbgen.APIKey) []CacheKey {
+	result := make([]CacheKey, 0, 2*len(keys))
+	lists := make(map[CacheKey]struct{})
+
+	for _, key := range keys {
+		result = append(result, APIKeyCacheKey(UUIDToSecret(key.ExternalID)))
+
+		listKey := apiKeysListCacheKey(key)
+		if _, ok := lists[listKey]; !ok {
+			lists[listKey] = struct{}{}
+			result = append(result, listKey)
+		}
+	}
+
+	return result
+}
+
+// notifyCacheInvalidation broadcasts keys to other nodes. When called in transaction, notification is only
+// delivered after commit.
+func (impl *BusinessStoreImpl) notifyCacheInvalidation(ctx context.Context, keys ...CacheKey) {
+	if (impl.querier == nil) || !impl.invalidator.Enabled() || (len(keys) == 0) {
+		return
+	}
+
+	for i := 0; i < len(keys); i += maxInvalidationKeysPerMessage {
+		chunk := keys[i:min(i+maxInvalidationKeysPerMessage, len(keys))]
+
+		payload, err := impl.invalidator.encode(chunk)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to encode cache invalidation message", common.ErrAttr(err))
+			return
+		}
+
+		if err := impl.querier.NotifyCacheInvalidation(ctx, &dbgen.NotifyCacheInvalidationParams{
+			Channel: cacheInvalidationChannel,
+			Payload: payload,
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to notify cache invalidation", "keys", len(chunk), common.ErrAttr(err))
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestCacheInvalidationFromOtherNode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	cache := NewBusinessCache(100, 1<<20 /*max bytes*/)
+	sender := newCacheInvalidator(nil /*pool*/, NewBusinessCache(100, 1<<20 /*max bytes*/))
+	receiver := newCacheInvalidator(nil /*pool*/, cache)
+
+	keys := []CacheKey{PropertyBySitekeyCacheKey("abcdef"), propertyByIDCacheKey(123), userAPIKeysCacheKey(456)}
+	for _, key := range keys {
+		if err := cache.Set(ctx, key, "value", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payload, err := sender.encode(keys[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := receiver.handle(ctx, payload); count != 2 {
+		t.Errorf("Unexpected invalidated count: %v", count)
+	}
+
+	for _, key := range keys[:2] {
+		if _, err := cache.Get(ctx, key); err != ErrCacheMiss {
+			t.Errorf("Expected key %v to be invalidated, but got: %v", key, err)
+		}
+	}
+
+	if _, err := cache.Get(ctx, keys[2]); err != nil {
+		t.Errorf("Expected key %v to stay in cache: %v", keys[2], err)
+	}
+}
+
+func TestCacheInvalidationFromSameNode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	cache := NewBusinessCache(100, 1<<20 /*max bytes*/)
+	invalidator := newCacheInvalidator(nil /*pool*/, cache)
+
+	key := propertyByIDCacheKey(123)
+	if err := cache.Set(ctx, key, "value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := invalidator.encode([]CacheKey{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := invalidator.handle(ctx, payload); count != 0 {
+		t.Errorf("Unexpected invalidated count: %v", count)
+	}
+
+	if _, err := cache.Get(ctx, key); err != nil {
+		t.Errorf("Expected key to stay in cache: %v", err)
+	}
+}
+
+func TestCacheInvalidationMalformedPayload(t *testing.T) {
+	t.Parallel()
+
+	invalidator := newCacheInvalidator(nil /*pool*/, NewBusinessCache(100, 1<<20 /*max bytes*/))
+
+	if count := invalidator.handle(context.TODO(), "{not json"); count != 0 {
+		t.Errorf("Unexpected invalidated count: %v", count)
+	}
+}
+
+func TestAPIKeysInvalidationKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := []*dbgen.APIKey{
+		{ID: 1, UserID: Int(10)},
+		{ID: 2, UserID: Int(10)},
+		{ID: 3, OrgID: Int(20)},
+	}
+
+	actual := apiKeysInvalidationKeys(keys)
+
+	if len(actual) != 5 {
+		t.Errorf("Unexpected number of keys: %v", actual)
+	}
+
+	for _, expected := range []CacheKey{userAPIKeysCacheKey(10), orgAPIKeysCacheKey(20)} {
+		if !slices.Contains(actual, expected) {
+			t.Errorf("Key %v is missing", expected)
+		}
+	}
+
+	if slices.Contains(actual, userAPIKeysCacheKey(0)) {
+		t.Error("Org API key should not invalidate user keys")
+	}
+}
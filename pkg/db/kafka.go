@@ -0,0 +1,150 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaTimeout     = 30 * time.Second
+)
+
+var (
+	errKafkaStatus = errors.New("unexpected Kafka REST proxy response status")
+)
+
+// NOTE: property ID is used as a key so that records of the same property end up in the same partition
+type kafkaRecord struct {
+	Key   string `json:"key,omitempty"`
+	Value any    `json:"value"`
+}
+
+type kafkaAccessValue struct {
+	Type        string `json:"type"`
+	UserID      int32  `json:"user_id"`
+	OrgID       int32  `json:"org_id"`
+	PropertyID  int32  `json:"property_id"`
+	Fingerprint uint64 `json:"fingerprint"`
+	Timestamp   int64  `json:"timestamp"`
+	Datacenter  bool   `json:"datacenter"`
+	IPLess      bool   `json:"ipless"`
+}
+
+type kafkaVerifyValue struct {
+	Type       string `json:"type"`
+	UserID     int32  `json:"user_id"`
+	OrgID      int32  `json:"org_id"`
+	PropertyID int32  `json:"property_id"`
+	PuzzleID   uint64 `json:"puzzle_id"`
+	Status     int8   `json:"status"`
+	Timestamp  int64  `json:"timestamp"`
+	Action     string `json:"action,omitempty"`
+}
+
+// KafkaSink produces access and verify logs to a Kafka topic via Kafka REST Proxy (v2 API)
+type KafkaSink struct {
+	URL    string
+	Topic  string
+	Client *http.Client
+}
+
+var _ common.TimeSeriesSink = (*KafkaSink)(nil)
+
+func NewKafkaSink(cfg common.ConfigStore) *KafkaSink {
+	restURL := cfg.Get(common.KafkaRESTURLKey).Value()
+	topic := cfg.Get(common.KafkaTopicKey).Value()
+	if (len(restURL) == 0) || (len(topic) == 0) {
+		return nil
+	}
+
+	return &KafkaSink{
+		URL:    strings.TrimRight(restURL, "/"),
+		Topic:  topic,
+		Client: &http.Client{Timeout: kafkaTimeout},
+	}
+}
+
+func (ks *KafkaSink) Name() string {
+	return "kafka"
+}
+
+func (ks *KafkaSink) produce(ctx context.Context, records []*kafkaRecord) error {
+	body, err := json.Marshal(struct {
+		Records []*kafkaRecord `json:"records"`
+	}{Records: records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ks.URL+"/topics/"+url.PathEscape(ks.Topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(common.HeaderContentType, kafkaContentType)
+
+	resp, err := ks.Client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send records to Kafka", common.ErrAttr(err))
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		slog.ErrorContext(ctx, "Kafka REST proxy returned error", "status", resp.StatusCode)
+		return errKafkaStatus
+	}
+
+	slog.DebugContext(ctx, "Produced records to Kafka", "topic", ks.Topic, "count", len(records))
+
+	return nil
+}
+
+func (ks *KafkaSink) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	kr := make([]*kafkaRecord, 0, len(records))
+	for _, r := range records {
+		kr = append(kr, &kafkaRecord{Key: strconv.Itoa(int(r.PropertyID)), Value: &kafkaAccessValue{
+			Type:        "access",
+			UserID:      r.UserID,
+			OrgID:       r.OrgID,
+			PropertyID:  r.PropertyID,
+			Fingerprint: r.Fingerprint,
+			Timestamp:   r.Timestamp.UTC().Unix(),
+			Datacenter:  r.Datacenter,
+			IPLess:      r.IPLess,
+		}})
+	}
+
+	return ks.produce(ctx, kr)
+}
+
+func (ks *KafkaSink) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	kr := make([]*kafkaRecord, 0, len(records))
+	for _, r := range records {
+		kr = append(kr, &kafkaRecord{Key: strconv.Itoa(int(r.PropertyID)), Value: &kafkaVerifyValue{
+			Type:       "verify",
+			UserID:     r.UserID,
+			OrgID:      r.OrgID,
+			PropertyID: r.PropertyID,
+			PuzzleID:   r.PuzzleID,
+			Status:     r.Status,
+			Timestamp:  r.Timestamp.UTC().Unix(),
+			Action:     r.Action,
+		}})
+	}
+
+	return ks.produce(ctx, kr)
+}
@@ -0,0 +1,457 @@
+package db
+
+import (
+	"context"
+	"hash/maphash"
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultCacheShards = 64
+	// TTL of each item is randomly decreased by up to this fraction so that items cached at the same time
+	// (e.g. hot sitekeys after restart) do not expire and get refreshed from the DB at the same time
+	defaultCacheTTLJitter = 0.1
+	defaultCacheClass     = "default"
+	// approximate memory used by the entry itself, its place in the map and in the list
+	cacheEntryOverhead = 128
+	// values are not walked deeper than this when estimating their size
+	maxCacheSizeDepth = 8
+)
+
+var (
+	cacheHashSeed = maphash.MakeSeed()
+)
+
+type lruEntry[TKey comparable, TValue comparable] struct {
+	key     TKey
+	value   TValue
+	expires int64
+	class   int
+	size    int64
+	prev    *lruEntry[TKey, TValue]
+	next    *lruEntry[TKey, TValue]
+}
+
+// lruShard is a map plus an intrusive doubly-linked list, where the most recently used item is the head
+type lruShard[TKey comparable, TValue comparable] struct {
+	lock     sync.Mutex
+	items    map[TKey]*lruEntry[TKey, TValue]
+	head     *lruEntry[TKey, TValue]
+	tail     *lruEntry[TKey, TValue]
+	capacity int
+	// approximate memory used by the items of the shard and its upper bound (0 means unbounded)
+	bytes    int64
+	maxBytes int64
+}
+
+func (s *lruShard[TKey, TValue]) isFullUnsafe() bool {
+	return (len(s.items) > s.capacity) || ((s.maxBytes > 0) && (s.bytes > s.maxBytes))
+}
+
+func (s *lruShard[TKey, TValue]) unlinkUnsafe(e *lruEntry[TKey, TValue]) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		s.head = e.next
+	}
+
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		s.tail = e.prev
+	}
+
+	e.prev = nil
+	e.next = nil
+}
+
+func (s *lruShard[TKey, TValue]) pushFrontUnsafe(e *lruEntry[TKey, TValue]) {
+	e.prev = nil
+	e.next = s.head
+	if s.head != nil {
+		s.head.prev = e
+	}
+	s.head = e
+	if s.tail == nil {
+		s.tail = e
+	}
+}
+
+func (s *lruShard[TKey, TValue]) removeUnsafe(e *lruEntry[TKey, TValue]) {
+	s.unlinkUnsafe(e)
+	delete(s.items, e.key)
+	s.bytes -= e.size
+}
+
+type cacheClassStats struct {
+	size        atomic.Int64
+	bytes       atomic.Int64
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+type MemoryCacheOptions[TKey comparable, TValue comparable] struct {
+	// Capacity is the upper bound for the number of items in the cache (split evenly between shards)
+	Capacity int
+	// MaxBytes is the upper bound for approximate memory used by the items (split evenly between shards).
+	// If it's 0, cache is only bounded by Capacity
+	MaxBytes int64
+	// Size estimates memory used by the item and is only needed if MaxBytes is set. Values are walked with
+	// reflection by default
+	Size   func(key TKey, value TValue) int64
+	Shards int
+	Hash   func(key TKey) uint64
+	// Classes are used to separate metrics for different kinds of items (e.g. properties or API keys)
+	Classes  []string
+	Classify func(key TKey) int
+	// TTLJitter is a fraction of TTL by which it is randomly decreased
+	TTLJitter float64
+}
+
+// memcache is a sharded in-memory LRU cache with per-item TTL that tracks usage stats per class of keys
+type memcache[TKey comparable, TValue comparable] struct {
+	shards       []*lruShard[TKey, TValue]
+	hash         func(key TKey) uint64
+	classify     func(key TKey) int
+	classes      []string
+	stats        []*cacheClassStats
+	jitter       float64
+	size         func(key TKey, value TValue) int64
+	missingValue TValue
+}
+
+var _ common.Cache[int, any] = (*memcache[int, any])(nil)
+
+func NewMemoryCacheEx[TKey comparable, TValue comparable](opts MemoryCacheOptions[TKey, TValue], missingValue TValue) *memcache[TKey, TValue] {
+	shardsCount := opts.Shards
+	if shardsCount <= 0 {
+		shardsCount = defaultCacheShards
+	}
+	// small caches should not be split into too small shards as LRU order is only maintained within a shard
+	shardsCount = max(1, min(shardsCount, opts.Capacity/16))
+
+	shardCapacity := max(1, opts.Capacity/shardsCount)
+	var shardMaxBytes int64
+	if opts.MaxBytes > 0 {
+		shardMaxBytes = max(1, opts.MaxBytes/int64(shardsCount))
+	}
+
+	shards := make([]*lruShard[TKey, TValue], shardsCount)
+	for i := range shards {
+		shards[i] = &lruShard[TKey, TValue]{
+			items:    make(map[TKey]*lruEntry[TKey, TValue]),
+			capacity: shardCapacity,
+			maxBytes: shardMaxBytes,
+		}
+	}
+
+	size := opts.Size
+	if opts.MaxBytes <= 0 {
+		// memory is not bounded so there's no need to spend time on estimating it
+		size = func(TKey, TValue) int64 { return 0 }
+	} else if size == nil {
+		size = approximateEntrySize[TKey, TValue]
+	}
+
+	classes := opts.Classes
+	classify := opts.Classify
+	if (len(classes) == 0) || (classify == nil) {
+		classes = []string{defaultCacheClass}
+		classify = func(TKey) int { return 0 }
+	}
+
+	stats := make([]*cacheClassStats, len(classes))
+	for i := range stats {
+		stats[i] = &cacheClassStats{}
+	}
+
+	jitter := opts.TTLJitter
+	if (jitter < 0) || (jitter >= 1) {
+		jitter = defaultCacheTTLJitter
+	}
+
+	return &memcache[TKey, TValue]{
+		shards:       shards,
+		hash:         opts.Hash,
+		classify:     classify,
+		classes:      classes,
+		stats:        stats,
+		jitter:       jitter,
+		size:         size,
+		missingValue: missingValue,
+	}
+}
+
+func (c *memcache[TKey, TValue]) shard(key TKey) *lruShard[TKey, TValue] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+
+	return c.shards[c.hash(key)%uint64(len(c.shards))]
+}
+
+func (c *memcache[TKey, TValue]) classStats(class int) *cacheClassStats {
+	if (class < 0) || (class >= len(c.stats)) {
+		return c.stats[0]
+	}
+
+	return c.stats[class]
+}
+
+func (c *memcache[TKey, TValue]) jitteredTTL(ttl time.Duration) time.Duration {
+	if (c.jitter == 0) || (ttl <= 0) {
+		return ttl
+	}
+
+	return ttl - time.Duration(rand.Float64()*c.jitter*float64(ttl))
+}
+
+func (c *memcache[TKey, TValue]) Get(ctx context.Context, key TKey) (TValue, error) {
+	var zero TValue
+	class := c.classify(key)
+	stats := c.classStats(class)
+	s := c.shard(key)
+
+	s.lock.Lock()
+	e, found := s.items[key]
+	if !found {
+		s.lock.Unlock()
+		stats.misses.Add(1)
+		slog.Log(ctx, common.LevelTrace, "Item not found in memory cache", "key", key)
+		return zero, ErrCacheMiss
+	}
+
+	if e.expires <= time.Now().UnixNano() {
+		s.removeUnsafe(e)
+		s.lock.Unlock()
+		c.removed(e)
+		stats.expirations.Add(1)
+		stats.misses.Add(1)
+		slog.Log(ctx, common.LevelTrace, "Item expired in memory cache", "key", key)
+		return zero, ErrCacheMiss
+	}
+
+	s.unlinkUnsafe(e)
+	s.pushFrontUnsafe(e)
+	data := e.value
+	s.lock.Unlock()
+
+	stats.hits.Add(1)
+
+	if data == c.missingValue {
+		slog.Log(ctx, common.LevelTrace, "Item set as missing in memory cache", "key", key)
+		return zero, ErrNegativeCacheHit
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Found item in memory cache", "key", key)
+
+	return data, nil
+}
+
+func (c *memcache[TKey, TValue]) removed(e *lruEntry[TKey, TValue]) {
+	stats := c.classStats(e.class)
+	stats.size.Add(-1)
+	stats.bytes.Add(-e.size)
+}
+
+func (c *memcache[TKey, TValue]) set(key TKey, value TValue, ttl time.Duration) {
+	class := c.classify(key)
+	expires := time.Now().Add(c.jitteredTTL(ttl)).UnixNano()
+	size := c.size(key, value)
+	s := c.shard(key)
+
+	s.lock.Lock()
+
+	if (s.maxBytes > 0) && (size > s.maxBytes) {
+		// item would evict everything else so we do not cache it at all (previous value is stale now)
+		previous, found := s.items[key]
+		if found {
+			s.removeUnsafe(previous)
+		}
+		s.lock.Unlock()
+
+		if found {
+			c.removed(previous)
+		}
+		c.classStats(class).evictions.Add(1)
+		return
+	}
+
+	e, found := s.items[key]
+	if found {
+		s.unlinkUnsafe(e)
+		s.bytes += size - e.size
+		c.classStats(e.class).bytes.Add(size - e.size)
+		e.value = value
+		e.expires = expires
+		e.size = size
+	} else {
+		e = &lruEntry[TKey, TValue]{key: key, value: value, expires: expires, class: class, size: size}
+		s.items[key] = e
+		s.bytes += size
+		stats := c.classStats(class)
+		stats.size.Add(1)
+		stats.bytes.Add(size)
+	}
+	s.pushFrontUnsafe(e)
+
+	var evicted []*lruEntry[TKey, TValue]
+	for s.isFullUnsafe() && (s.tail != nil) && (s.tail != e) {
+		victim := s.tail
+		s.removeUnsafe(victim)
+		evicted = append(evicted, victim)
+	}
+
+	s.lock.Unlock()
+
+	for _, victim := range evicted {
+		c.removed(victim)
+		c.classStats(victim.class).evictions.Add(1)
+	}
+}
+
+func (c *memcache[TKey, TValue]) SetMissing(ctx context.Context, key TKey, ttl time.Duration) error {
+	c.set(key, c.missingValue, ttl)
+
+	slog.Log(ctx, common.LevelTrace, "Set item as missing in memory cache", "key", key, "ttl", ttl)
+
+	return nil
+}
+
+func (c *memcache[TKey, TValue]) Set(ctx context.Context, key TKey, t TValue, ttl time.Duration) error {
+	if t == c.missingValue {
+		return ErrSetMissing
+	}
+
+	c.set(key, t, ttl)
+
+	slog.Log(ctx, common.LevelTrace, "Saved item to memory cache", "key", key, "ttl", ttl)
+
+	return nil
+}
+
+func (c *memcache[TKey, TValue]) Delete(ctx context.Context, key TKey) error {
+	s := c.shard(key)
+
+	s.lock.Lock()
+	e, found := s.items[key]
+	if found {
+		s.removeUnsafe(e)
+	}
+	s.lock.Unlock()
+
+	if found {
+		c.removed(e)
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Deleted item from memory cache", "key", key)
+
+	return nil
+}
+
+// Stats returns usage counters for every class of keys
+func (c *memcache[TKey, TValue]) Stats() []*common.CacheStats {
+	result := make([]*common.CacheStats, 0, len(c.classes))
+
+	for i, class := range c.classes {
+		s := c.stats[i]
+		result = append(result, &common.CacheStats{
+			Class:       class,
+			Size:        s.size.Load(),
+			Bytes:       s.bytes.Load(),
+			Hits:        s.hits.Load(),
+			Misses:      s.misses.Load(),
+			Evictions:   s.evictions.Load(),
+			Expirations: s.expirations.Load(),
+		})
+	}
+
+	return result
+}
+
+func approximateEntrySize[TKey comparable, TValue comparable](key TKey, value TValue) int64 {
+	return cacheEntryOverhead + approximateSize(reflect.ValueOf(key), 0) + approximateSize(reflect.ValueOf(value), 0)
+}
+
+// approximateSize estimates memory retained by the value. Memory shared between values (e.g. the same pointer in
+// different entries) is counted for each of them, which is fine for the upper bound
+func approximateSize(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+
+	return int64(v.Type().Size()) + referencedSize(v, depth)
+}
+
+// referencedSize estimates memory that is referenced by the value, but is not stored inline
+func referencedSize(v reflect.Value, depth int) int64 {
+	if depth >= maxCacheSizeDepth {
+		return 0
+	}
+
+	var result int64
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			result = approximateSize(v.Elem(), depth+1)
+		}
+	case reflect.String:
+		result = int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		result = int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			result += referencedSize(v.Index(i), depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			result += referencedSize(v.Index(i), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			result += referencedSize(v.Field(i), depth+1)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		for it := v.MapRange(); it.Next(); {
+			result += approximateSize(it.Key(), depth+1) + approximateSize(it.Value(), depth+1)
+		}
+	}
+
+	return result
+}
+
+func hashUint64(value uint64) uint64 {
+	// splitmix64 finalizer
+	value ^= value >> 30
+	value *= 0xbf58476d1ce4e5b9
+	value ^= value >> 27
+	value *= 0x94d049bb133111eb
+	value ^= value >> 31
+	return value
+}
+
+func HashInt32(key int32) uint64   { return hashUint64(uint64(key)) }
+func HashUint64(key uint64) uint64 { return hashUint64(key) }
+
+func (ck CacheKey) Hash() uint64 {
+	h := hashUint64(uint64(ck.IntValue) ^ (uint64(ck.Prefix) << 56))
+	if len(ck.StrValue) > 0 {
+		h ^= maphash.String(cacheHashSeed, ck.StrValue)
+	}
+	return h
+}
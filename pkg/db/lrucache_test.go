@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestMemoryCacheLRUEviction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	cache := NewMemoryCacheEx[int32, any](MemoryCacheOptions[int32, any]{Capacity: 3, Shards: 1, Hash: HashInt32}, nil /*missing value*/)
+
+	for i := int32(0); i < 3; i++ {
+		if err := cache.Set(ctx, i, i, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// make 0 the most recently used, so 1 is evicted instead
+	if _, err := cache.Get(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Set(ctx, 3, int32(3), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Get(ctx, 1); err != ErrCacheMiss {
+		t.Errorf("Least recently used item was not evicted: %v", err)
+	}
+
+	for _, key := range []int32{0, 2, 3} {
+		if value, err := cache.Get(ctx, key); (err != nil) || (value.(int32) != key) {
+			t.Errorf("Unexpected value for key %v: %v (err: %v)", key, value, err)
+		}
+	}
+
+	stats := cache.Stats()
+	if (len(stats) != 1) || (stats[0].Size != 3) || (stats[0].Evictions != 1) || (stats[0].Misses != 1) || (stats[0].Hits != 4) {
+		t.Errorf("Unexpected stats: %+v", stats[0])
+	}
+}
+
+func TestMemoryCacheExpiration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	cache := NewMemoryCache[uint64, bool](100, false /*missing value*/, HashUint64)
+
+	if err := cache.Set(ctx, 1, true, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.SetMissing(ctx, 2, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := cache.Get(ctx, 1); err != ErrCacheMiss {
+		t.Errorf("Item did not expire: %v", err)
+	}
+
+	if _, err := cache.Get(ctx, 2); err != ErrNegativeCacheHit {
+		t.Errorf("Unexpected error for missing item: %v", err)
+	}
+
+	if err := cache.Set(ctx, 3, false, time.Minute); err != ErrSetMissing {
+		t.Errorf("Unexpected error when setting missing value: %v", err)
+	}
+
+	stats := cache.Stats()
+	if (stats[0].Expirations != 1) || (stats[0].Size != 1) {
+		t.Errorf("Unexpected stats: %+v", stats[0])
+	}
+}
+
+func TestMemoryCacheJitteredTTL(t *testing.T) {
+	t.Parallel()
+
+	cache := NewMemoryCacheEx[int32, any](MemoryCacheOptions[int32, any]{Capacity: 10, Hash: HashInt32, TTLJitter: 0.2}, nil /*missing value*/)
+
+	const ttl = 10 * time.Minute
+	for i := 0; i < 100; i++ {
+		if actual := cache.jitteredTTL(ttl); (actual > ttl) || (actual < 8*time.Minute) {
+			t.Fatalf("Jittered TTL is out of bounds: %v", actual)
+		}
+	}
+}
+
+func TestBusinessCacheClasses(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	cache := NewBusinessCache(1000, 1<<20 /*max bytes*/)
+
+	_ = cache.Set(ctx, PropertyBySitekeyCacheKey("sitekey"), "property", time.Minute)
+	_ = cache.Set(ctx, APIKeyCacheKey("secret"), "apikey", time.Minute)
+	_ = cache.Set(ctx, orgCacheKey(1), "org", time.Minute)
+	_ = cache.Set(ctx, userCacheKey(1), "user", time.Minute)
+
+	_, _ = cache.Get(ctx, PropertyBySitekeyCacheKey("sitekey"))
+	_, _ = cache.Get(ctx, PropertyBySitekeyCacheKey("other"))
+	_ = cache.Delete(ctx, orgCacheKey(1))
+
+	stats := make(map[string]*common.CacheStats)
+	for _, s := range cache.Stats() {
+		stats[s.Class] = s
+	}
+
+	if property := stats["property"]; (property.Size != 1) || (property.Hits != 1) || (property.Misses != 1) {
+		t.Errorf("Unexpected property stats: %+v", property)
+	}
+
+	if org := stats["org"]; org.Size != 0 {
+		t.Errorf("Unexpected org stats: %+v", org)
+	}
+
+	if apikey := stats["apikey"]; apikey.Size != 1 {
+		t.Errorf("Unexpected apikey stats: %+v", apikey)
+	}
+}
+
+func TestMemoryCacheBytesEviction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	cache := NewMemoryCacheEx(MemoryCacheOptions[int32, string]{
+		Capacity: 100,
+		MaxBytes: 300,
+		Shards:   1,
+		Hash:     HashInt32,
+		Size:     func(key int32, value string) int64 { return int64(len(value)) },
+	}, "" /*missing value*/)
+
+	_ = cache.Set(ctx, 1, strings.Repeat("a", 100), time.Minute)
+	_ = cache.Set(ctx, 2, strings.Repeat("b", 100), time.Minute)
+	// large value has to evict both of the previous items even though item capacity is not reached
+	_ = cache.Set(ctx, 3, strings.Repeat("c", 250), time.Minute)
+
+	for _, key := range []int32{1, 2} {
+		if _, err := cache.Get(ctx, key); err != ErrCacheMiss {
+			t.Errorf("Item %v was not evicted: %v", key, err)
+		}
+	}
+
+	if _, err := cache.Get(ctx, 3); err != nil {
+		t.Errorf("Large item was not cached: %v", err)
+	}
+
+	// value that does not fit at all is not cached and does not evict anything
+	_ = cache.Set(ctx, 4, strings.Repeat("d", 301), time.Minute)
+	if _, err := cache.Get(ctx, 4); err != ErrCacheMiss {
+		t.Errorf("Oversized item was cached: %v", err)
+	}
+
+	// growing an existing item is accounted too
+	_ = cache.Set(ctx, 5, "e", time.Minute)
+	_ = cache.Set(ctx, 5, strings.Repeat("e", 100), time.Minute)
+	if _, err := cache.Get(ctx, 3); err != ErrCacheMiss {
+		t.Errorf("Item was not evicted after update: %v", err)
+	}
+
+	stats := cache.Stats()
+	if (stats[0].Size != 1) || (stats[0].Bytes != 100) || (stats[0].Evictions != 4) {
+		t.Errorf("Unexpected stats: %+v", stats[0])
+	}
+}
+
+func TestApproximateSize(t *testing.T) {
+	t.Parallel()
+
+	small := approximateEntrySize[CacheKey, any](orgCacheKey(1), &dbgen.Property{Name: "name"})
+	large := approximateEntrySize[CacheKey, any](orgCacheKey(1), &dbgen.Property{Name: strings.Repeat("n", 1000)})
+	if (small <= cacheEntryOverhead) || (large-small != 996) {
+		t.Errorf("Unexpected sizes: %v and %v", small, large)
+	}
+
+	list := approximateEntrySize[CacheKey, any](orgPropertiesCacheKey(1), []*dbgen.Property{{Name: "name"}, {Name: "name"}})
+	if list <= small {
+		t.Errorf("List is estimated smaller than a single item: %v", list)
+	}
+}
+
+func TestCacheKeyClasses(t *testing.T) {
+	t.Parallel()
+
+	for prefix := cacheKeyPrefix(0); prefix < cacheKeyPrefixCount; prefix++ {
+		if class := (CacheKey{Prefix: prefix}).class(); class == otherCacheKeyClass {
+			t.Errorf("Cache key prefix %v is not classified", prefix)
+		}
+	}
+
+	if class := (CacheKey{Prefix: cacheKeyPrefixCount}).class(); class != otherCacheKeyClass {
+		t.Errorf("Unknown prefix is classified as %v", cacheKeyClasses[class])
+	}
+}
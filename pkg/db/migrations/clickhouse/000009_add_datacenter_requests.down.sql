@@ -0,0 +1,41 @@
+DROP VIEW IF EXISTS privatecaptcha.request_logs_5m_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_logs_5m_mv TO privatecaptcha.request_logs_5m AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfFiveMinute(timestamp) AS timestamp,
+    count() AS count
+FROM privatecaptcha.request_logs
+GROUP BY user_id, org_id, property_id, timestamp;
+
+DROP VIEW IF EXISTS privatecaptcha.request_logs_1h_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_logs_1h_mv TO privatecaptcha.request_logs_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfHour(timestamp) AS timestamp,
+    sum(count) AS count
+FROM privatecaptcha.request_logs_5m
+GROUP BY user_id, org_id, property_id, timestamp;
+
+DROP VIEW IF EXISTS privatecaptcha.request_logs_1d_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_logs_1d_mv TO privatecaptcha.request_logs_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfDay(timestamp) AS timestamp,
+    sum(count) AS count
+FROM privatecaptcha.request_logs_1h
+GROUP BY user_id, org_id, property_id, timestamp;
+
+ALTER TABLE privatecaptcha.request_logs_1d DROP COLUMN IF EXISTS datacenter_count;
+ALTER TABLE privatecaptcha.request_logs_1h DROP COLUMN IF EXISTS datacenter_count;
+ALTER TABLE privatecaptcha.request_logs_5m DROP COLUMN IF EXISTS datacenter_count;
+
+ALTER TABLE privatecaptcha.request_logs DROP COLUMN IF EXISTS datacenter;
@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS privatecaptcha.verify_failures_1d_mv;
+DROP TABLE IF EXISTS privatecaptcha.verify_failures_1d;
+
+DROP VIEW IF EXISTS privatecaptcha.verify_failures_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.verify_failures_1h;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS privatecaptcha.verify_failures_1h
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    status UInt8,
+    timestamp DateTime,
+    count UInt32
+)
+ENGINE = SummingMergeTree
+ORDER BY (user_id, org_id, property_id, status, timestamp)
+TTL timestamp + INTERVAL 1 DAY;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_failures_1h_mv TO privatecaptcha.verify_failures_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    status,
+    toStartOfHour(timestamp) AS timestamp,
+    count() AS count
+FROM privatecaptcha.verify_logs
+WHERE status != 0
+GROUP BY user_id, org_id, property_id, status, timestamp;
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.verify_failures_1d
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    status UInt8,
+    timestamp DateTime,
+    count UInt64
+)
+ENGINE = SummingMergeTree
+ORDER BY (user_id, org_id, property_id, status, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_failures_1d_mv TO privatecaptcha.verify_failures_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    status,
+    toStartOfDay(timestamp) AS timestamp,
+    sum(count) AS count
+FROM privatecaptcha.verify_failures_1h
+GROUP BY user_id, org_id, property_id, status, timestamp;
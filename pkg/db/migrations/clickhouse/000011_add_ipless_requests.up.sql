@@ -0,0 +1,47 @@
+ALTER TABLE privatecaptcha.request_logs ADD COLUMN IF NOT EXISTS ipless UInt8 DEFAULT 0;
+
+ALTER TABLE privatecaptcha.request_logs_5m ADD COLUMN IF NOT EXISTS ipless_count UInt32 DEFAULT 0;
+ALTER TABLE privatecaptcha.request_logs_1h ADD COLUMN IF NOT EXISTS ipless_count UInt32 DEFAULT 0;
+ALTER TABLE privatecaptcha.request_logs_1d ADD COLUMN IF NOT EXISTS ipless_count UInt64 DEFAULT 0;
+
+DROP VIEW IF EXISTS privatecaptcha.request_logs_5m_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_logs_5m_mv TO privatecaptcha.request_logs_5m AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfFiveMinute(timestamp) AS timestamp,
+    count() AS count,
+    countIf(datacenter != 0) AS datacenter_count,
+    countIf(ipless != 0) AS ipless_count
+FROM privatecaptcha.request_logs
+GROUP BY user_id, org_id, property_id, timestamp;
+
+DROP VIEW IF EXISTS privatecaptcha.request_logs_1h_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_logs_1h_mv TO privatecaptcha.request_logs_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfHour(timestamp) AS timestamp,
+    sum(count) AS count,
+    sum(datacenter_count) AS datacenter_count,
+    sum(ipless_count) AS ipless_count
+FROM privatecaptcha.request_logs_5m
+GROUP BY user_id, org_id, property_id, timestamp;
+
+DROP VIEW IF EXISTS privatecaptcha.request_logs_1d_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_logs_1d_mv TO privatecaptcha.request_logs_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfDay(timestamp) AS timestamp,
+    sum(count) AS count,
+    sum(datacenter_count) AS datacenter_count,
+    sum(ipless_count) AS ipless_count
+FROM privatecaptcha.request_logs_1h
+GROUP BY user_id, org_id, property_id, timestamp;
@@ -0,0 +1,4 @@
+DROP VIEW IF EXISTS privatecaptcha.verify_actions_1d_mv;
+DROP TABLE IF EXISTS privatecaptcha.verify_actions_1d;
+
+ALTER TABLE privatecaptcha.verify_logs DROP COLUMN IF EXISTS action;
@@ -0,0 +1,28 @@
+ALTER TABLE privatecaptcha.verify_logs ADD COLUMN IF NOT EXISTS action LowCardinality(String) DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.verify_actions_1d
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    action LowCardinality(String),
+    timestamp DateTime,
+    count UInt64,
+    success_count UInt64
+)
+ENGINE = SummingMergeTree
+ORDER BY (user_id, org_id, property_id, action, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_actions_1d_mv TO privatecaptcha.verify_actions_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    action,
+    toStartOfDay(timestamp) AS timestamp,
+    count() AS count,
+    countIf(status = 0) AS success_count
+FROM privatecaptcha.verify_logs
+WHERE action != ''
+GROUP BY user_id, org_id, property_id, action, timestamp;
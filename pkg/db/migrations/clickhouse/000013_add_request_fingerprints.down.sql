@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS privatecaptcha.request_fingerprints_1d_mv;
+DROP TABLE IF EXISTS privatecaptcha.request_fingerprints_1d;
+
+DROP VIEW IF EXISTS privatecaptcha.request_fingerprints_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.request_fingerprints_1h;
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS privatecaptcha.request_fingerprints_1h
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    timestamp DateTime,
+    fingerprints AggregateFunction(uniqCombined, UInt64),
+    count SimpleAggregateFunction(sum, UInt64)
+)
+ENGINE = AggregatingMergeTree
+ORDER BY (user_id, org_id, property_id, timestamp)
+TTL timestamp + INTERVAL 1 DAY;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_fingerprints_1h_mv TO privatecaptcha.request_fingerprints_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfHour(timestamp) AS timestamp,
+    uniqCombinedState(fingerprint) AS fingerprints,
+    toUInt64(count()) AS count
+FROM privatecaptcha.request_logs
+WHERE ipless = 0 AND fingerprint != 0
+GROUP BY user_id, org_id, property_id, timestamp;
+
+-- uniqCombined states are derived from fingerprints, so their retention is documented in WriteAccessLogBatch
+CREATE TABLE IF NOT EXISTS privatecaptcha.request_fingerprints_1d
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    timestamp DateTime,
+    fingerprints AggregateFunction(uniqCombined, UInt64),
+    count SimpleAggregateFunction(sum, UInt64)
+)
+ENGINE = AggregatingMergeTree
+ORDER BY (user_id, org_id, property_id, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.request_fingerprints_1d_mv TO privatecaptcha.request_fingerprints_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfDay(timestamp) AS timestamp,
+    uniqCombinedMergeState(fingerprints) AS fingerprints,
+    sum(count) AS count
+FROM privatecaptcha.request_fingerprints_1h
+GROUP BY user_id, org_id, property_id, timestamp;
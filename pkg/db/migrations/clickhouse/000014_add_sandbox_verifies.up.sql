@@ -0,0 +1,33 @@
+ALTER TABLE privatecaptcha.verify_logs ADD COLUMN IF NOT EXISTS sandbox UInt8 DEFAULT 0;
+
+ALTER TABLE privatecaptcha.verify_logs_1h ADD COLUMN IF NOT EXISTS sandbox_count UInt32 DEFAULT 0;
+ALTER TABLE privatecaptcha.verify_logs_1d ADD COLUMN IF NOT EXISTS sandbox_count UInt64 DEFAULT 0;
+
+-- sandbox verifications are only tagged and never count towards usage
+DROP VIEW IF EXISTS privatecaptcha.verify_logs_1h_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_logs_1h_mv TO privatecaptcha.verify_logs_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfHour(timestamp) AS timestamp,
+    countIf((status = 0) AND (sandbox = 0)) AS success_count,
+    countIf((status != 0) AND (sandbox = 0)) AS failure_count,
+    countIf(sandbox != 0) AS sandbox_count
+FROM privatecaptcha.verify_logs
+GROUP BY user_id, org_id, property_id, timestamp;
+
+DROP VIEW IF EXISTS privatecaptcha.verify_logs_1d_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_logs_1d_mv TO privatecaptcha.verify_logs_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfDay(timestamp) AS timestamp,
+    sum(success_count) AS success_count,
+    sum(failure_count) AS failure_count,
+    sum(sandbox_count) AS sandbox_count
+FROM privatecaptcha.verify_logs_1h
+GROUP BY user_id, org_id, property_id, timestamp;
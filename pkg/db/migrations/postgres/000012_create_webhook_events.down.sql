@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_webhook_events_pending;
+DROP INDEX IF EXISTS backend.index_webhook_events_event_id;
+DROP TABLE IF EXISTS backend.webhook_events;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS backend.webhook_events(
+    id SERIAL PRIMARY KEY,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    processed_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS index_webhook_events_event_id ON backend.webhook_events(event_id);
+CREATE INDEX IF NOT EXISTS index_webhook_events_pending ON backend.webhook_events(next_attempt_at) WHERE processed_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS backend.index_apikeys_name_trgm;
+DROP INDEX IF EXISTS backend.index_organizations_name_trgm;
+DROP INDEX IF EXISTS backend.index_properties_domain_trgm;
+DROP INDEX IF EXISTS backend.index_properties_name_trgm;
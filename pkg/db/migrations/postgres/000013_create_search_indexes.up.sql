@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA backend;
+
+CREATE INDEX IF NOT EXISTS index_properties_name_trgm ON backend.properties USING GIN (name backend.gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS index_properties_domain_trgm ON backend.properties USING GIN (domain backend.gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS index_organizations_name_trgm ON backend.organizations USING GIN (name backend.gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS index_apikeys_name_trgm ON backend.apikeys USING GIN (name backend.gin_trgm_ops);
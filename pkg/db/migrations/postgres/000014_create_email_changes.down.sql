@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_email_changes_user_id;
+DROP INDEX IF EXISTS backend.index_email_changes_revert_token_hash;
+DROP TABLE IF EXISTS backend.email_changes;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS backend.email_changes(
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    -- NOTE: we only store hash of the revert token
+    revert_token_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    expires_at TIMESTAMPTZ NOT NULL,
+    reverted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS index_email_changes_revert_token_hash ON backend.email_changes(revert_token_hash);
+CREATE INDEX IF NOT EXISTS index_email_changes_user_id ON backend.email_changes(user_id);
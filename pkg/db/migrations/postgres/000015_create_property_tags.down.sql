@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS backend.index_property_tags_tag;
+DROP TABLE IF EXISTS backend.property_tags;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS backend.property_tags(
+    property_id INTEGER NOT NULL REFERENCES backend.properties(id) ON DELETE CASCADE,
+    tag VARCHAR(32) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (property_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS index_property_tags_tag ON backend.property_tags(tag);
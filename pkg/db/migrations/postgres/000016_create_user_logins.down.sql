@@ -0,0 +1,3 @@
+ALTER TABLE backend.users DROP COLUMN IF EXISTS reverify_new_logins;
+DROP INDEX IF EXISTS backend.index_user_logins_user_id;
+DROP TABLE IF EXISTS backend.user_logins;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS backend.user_logins(
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    -- ISO 3166 code as provided by reverse proxy (if configured)
+    country VARCHAR(8) NOT NULL DEFAULT '',
+    -- coarse browser and OS derived from the user agent
+    device VARCHAR(255) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    -- sign-in from a country or a device that was not seen before for this user
+    new_origin BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_user_logins_user_id ON backend.user_logins(user_id, created_at);
+
+ALTER TABLE backend.users ADD COLUMN IF NOT EXISTS reverify_new_logins BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE backend.users DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,2 @@
+-- IANA time zone name used to bucket usage charts on user's "day" boundaries
+ALTER TABLE backend.users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,9 @@
+-- what the widget shows instead of the puzzle when property cannot serve captcha
+CREATE TABLE IF NOT EXISTS backend.property_messages(
+    property_id INTEGER PRIMARY KEY REFERENCES backend.properties(id) ON DELETE CASCADE,
+    blocked_message VARCHAR(255) NOT NULL DEFAULT '',
+    quota_message VARCHAR(255) NOT NULL DEFAULT '',
+    maintenance_message VARCHAR(255) NOT NULL DEFAULT '',
+    redirect_url VARCHAR(512) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
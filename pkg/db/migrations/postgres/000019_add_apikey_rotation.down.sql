@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS backend.index_apikeys_rotation;
+ALTER TABLE backend.apikeys DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE backend.apikeys DROP COLUMN IF EXISTS successor_id;
+ALTER TABLE backend.apikeys DROP COLUMN IF EXISTS rotation_days;
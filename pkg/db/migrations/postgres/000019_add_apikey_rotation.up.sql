@@ -0,0 +1,7 @@
+-- optional automatic rotation policy: 0 means rotation is disabled
+ALTER TABLE backend.apikeys ADD COLUMN IF NOT EXISTS rotation_days INTEGER NOT NULL DEFAULT 0;
+-- key that replaced this one during rotation (both are valid during the overlap window)
+ALTER TABLE backend.apikeys ADD COLUMN IF NOT EXISTS successor_id INTEGER REFERENCES backend.apikeys(id) ON DELETE SET NULL;
+ALTER TABLE backend.apikeys ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS index_apikeys_rotation ON backend.apikeys(created_at) WHERE rotation_days > 0 AND successor_id IS NULL;
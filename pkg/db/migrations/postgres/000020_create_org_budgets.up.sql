@@ -0,0 +1,9 @@
+-- monthly verification budget of the organization, independent of plan limits
+CREATE TABLE IF NOT EXISTS backend.org_budgets(
+    org_id INTEGER PRIMARY KEY REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    monthly_limit BIGINT NOT NULL DEFAULT 0,
+    -- highest alert threshold that was already sent and when (thresholds reset every month)
+    alerted_percent SMALLINT NOT NULL DEFAULT 0,
+    alerted_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
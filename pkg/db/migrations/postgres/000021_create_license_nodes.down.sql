@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS index_license_nodes_last_seen_at;
+DROP TABLE IF EXISTS backend.license_nodes;
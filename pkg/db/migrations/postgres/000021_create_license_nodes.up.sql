@@ -0,0 +1,9 @@
+-- server instances of a self-hosted installation, used to count nodes against the license
+CREATE TABLE IF NOT EXISTS backend.license_nodes(
+    id TEXT PRIMARY KEY,
+    version TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_license_nodes_last_seen_at ON backend.license_nodes(last_seen_at);
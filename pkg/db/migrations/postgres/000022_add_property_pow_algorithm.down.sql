@@ -0,0 +1,2 @@
+ALTER TABLE backend.properties DROP COLUMN IF EXISTS algorithm;
+DROP TYPE IF EXISTS backend.pow_algorithm;
@@ -0,0 +1,4 @@
+CREATE TYPE backend.pow_algorithm AS ENUM ('blake2b', 'argon2id');
+
+-- argon2id puzzles are only issued to widgets that support them, others still receive blake2b puzzles
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS algorithm backend.pow_algorithm NOT NULL DEFAULT 'blake2b';
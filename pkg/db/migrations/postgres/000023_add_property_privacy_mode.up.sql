@@ -0,0 +1,2 @@
+-- privacy mode: visitor fingerprints are not stored in access logs (only aggregated counters are kept)
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS privacy_mode BOOLEAN NOT NULL DEFAULT FALSE;
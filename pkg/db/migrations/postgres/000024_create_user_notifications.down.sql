@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.user_notifications;
+DROP TYPE IF EXISTS backend.notification_category;
@@ -0,0 +1,14 @@
+CREATE TYPE backend.notification_category AS ENUM ('general', 'billing', 'usage', 'security');
+
+CREATE TABLE IF NOT EXISTS backend.user_notifications(
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    category backend.notification_category NOT NULL DEFAULT 'general',
+    message TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    read_at TIMESTAMPTZ DEFAULT NULL,
+    -- dismissed notifications are not shown in the notification center anymore
+    dismissed_at TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS index_user_notifications_user_id ON backend.user_notifications(user_id, created_at DESC) WHERE dismissed_at IS NULL;
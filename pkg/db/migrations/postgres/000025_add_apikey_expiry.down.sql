@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS deleted_record_insert ON backend.apikeys;
+DROP INDEX IF EXISTS backend.index_apikeys_expires_at;
+ALTER TABLE backend.apikeys DROP COLUMN IF EXISTS expiry_notified_days;
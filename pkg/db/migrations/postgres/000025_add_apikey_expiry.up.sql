@@ -0,0 +1,8 @@
+-- the smallest "days before expiry" for which the owner was already reminded (0 means no reminder was sent yet)
+ALTER TABLE backend.apikeys ADD COLUMN IF NOT EXISTS expiry_notified_days SMALLINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS index_apikeys_expires_at ON backend.apikeys(expires_at);
+
+-- keys that were expired for long enough are deleted and archived
+CREATE OR REPLACE TRIGGER deleted_record_insert AFTER DELETE ON backend.apikeys
+   FOR EACH ROW EXECUTE FUNCTION backend.deleted_record_insert();
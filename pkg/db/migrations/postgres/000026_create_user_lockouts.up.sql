@@ -0,0 +1,7 @@
+-- failed two-factor verifications per account (across sessions) and temporary lockout after too many of them
+CREATE TABLE IF NOT EXISTS backend.user_lockouts(
+    user_id INTEGER PRIMARY KEY REFERENCES backend.users(id) ON DELETE CASCADE,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ DEFAULT NULL,
+    locked_until TIMESTAMPTZ DEFAULT NULL
+);
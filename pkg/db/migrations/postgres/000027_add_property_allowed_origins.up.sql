@@ -0,0 +1,2 @@
+-- explicit origin patterns (exact domains or wildcards like *.example.com) allowed in addition to the property domain
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS allowed_origins TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE backend.properties DROP COLUMN IF EXISTS trusted_visitors_ttl;
+ALTER TABLE backend.properties DROP COLUMN IF EXISTS trusted_visitors_threshold;
@@ -0,0 +1,3 @@
+-- returning visitors with recent successful verifications get minimal difficulty (disabled when threshold is 0)
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS trusted_visitors_threshold SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS trusted_visitors_ttl INTERVAL NOT NULL DEFAULT INTERVAL '30 minutes';
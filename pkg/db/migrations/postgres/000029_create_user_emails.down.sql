@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.org_billing_contacts;
+DROP TABLE IF EXISTS backend.user_emails;
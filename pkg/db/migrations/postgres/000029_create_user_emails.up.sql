@@ -0,0 +1,20 @@
+-- secondary (verified) email addresses of the user, primary email stays in users table
+CREATE TABLE IF NOT EXISTS backend.user_emails(
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    verify_token_hash TEXT,
+    verify_expires_at TIMESTAMPTZ,
+    verified_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (user_id, email)
+);
+
+CREATE INDEX IF NOT EXISTS index_user_emails_verify_token_hash ON backend.user_emails(verify_token_hash);
+
+-- email (of the owner) that receives billing and usage alerts of the organization instead of the primary one
+CREATE TABLE IF NOT EXISTS backend.org_billing_contacts(
+    org_id INTEGER PRIMARY KEY REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    email_id INTEGER NOT NULL REFERENCES backend.user_emails(id) ON DELETE CASCADE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,2 @@
+ALTER TABLE backend.user_logins DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE backend.user_logins DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,3 @@
+-- session that was created by the sign-in, allows to revoke other sessions of the user
+ALTER TABLE backend.user_logins ADD COLUMN IF NOT EXISTS session_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE backend.user_logins ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ NULL;
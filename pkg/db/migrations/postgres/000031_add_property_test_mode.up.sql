@@ -0,0 +1,2 @@
+-- test mode (sandbox): verification outcome can be forced by the caller and usage is not counted
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS test_mode BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- IP-less mode: client IP is never used for the fingerprint (for customers that cannot process IP addresses)
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS ipless_mode BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_apikey_org_id;
+
+ALTER TABLE backend.apikeys DROP COLUMN IF EXISTS org_id;
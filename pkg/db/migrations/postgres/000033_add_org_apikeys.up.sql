@@ -0,0 +1,4 @@
+-- organization tokens belong to the org (user_id is NULL) so they keep working when the creator leaves
+ALTER TABLE backend.apikeys ADD COLUMN IF NOT EXISTS org_id INTEGER REFERENCES backend.organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS index_apikey_org_id ON backend.apikeys(org_id) WHERE org_id IS NOT NULL;
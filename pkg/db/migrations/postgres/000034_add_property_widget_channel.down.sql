@@ -0,0 +1,2 @@
+ALTER TABLE backend.properties DROP COLUMN IF EXISTS widget_channel;
+DROP TYPE IF EXISTS backend.widget_channel;
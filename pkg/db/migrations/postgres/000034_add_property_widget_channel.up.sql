@@ -0,0 +1,4 @@
+CREATE TYPE backend.widget_channel AS ENUM ('stable', 'beta', 'pinned');
+
+-- release channel of the widget script that is suggested in the integration snippet (canary of breaking releases)
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS widget_channel backend.widget_channel NOT NULL DEFAULT 'stable';
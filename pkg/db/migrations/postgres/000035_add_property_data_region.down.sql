@@ -0,0 +1 @@
+ALTER TABLE backend.properties DROP COLUMN IF EXISTS data_region;
@@ -0,0 +1,2 @@
+-- region of ClickHouse cluster where time-series data of the property is stored, empty means the primary cluster
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS data_region TEXT NOT NULL DEFAULT '';
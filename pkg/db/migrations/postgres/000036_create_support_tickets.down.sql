@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS backend.support_ticket_replies;
+DROP TABLE IF EXISTS backend.support_tickets;
+DROP TYPE IF EXISTS backend.support_ticket_status;
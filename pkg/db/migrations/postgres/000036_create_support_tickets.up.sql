@@ -0,0 +1,26 @@
+CREATE TYPE backend.support_ticket_status AS ENUM ('open', 'answered', 'closed');
+
+-- support requests of the users (ticket ID is generated by the support form and is a part of the email subject)
+CREATE TABLE IF NOT EXISTS backend.support_tickets(
+    id SERIAL PRIMARY KEY,
+    ticket_id TEXT NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    message TEXT NOT NULL,
+    status backend.support_ticket_status NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_support_tickets_user_id ON backend.support_tickets(user_id);
+
+-- replies in the ticket thread, received via inbound email webhook
+CREATE TABLE IF NOT EXISTS backend.support_ticket_replies(
+    id SERIAL PRIMARY KEY,
+    ticket_id INTEGER NOT NULL REFERENCES backend.support_tickets(id) ON DELETE CASCADE,
+    from_support BOOLEAN NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_support_ticket_replies_ticket_id ON backend.support_ticket_replies(ticket_id);
@@ -0,0 +1,2 @@
+-- paused properties keep their settings and stats, but do not serve puzzles (unlike soft-deleted, they are never GC-ed)
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS paused_at TIMESTAMPTZ DEFAULT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE backend.property_messages DROP COLUMN IF EXISTS locale;
+ALTER TABLE backend.property_messages DROP COLUMN IF EXISTS theme;
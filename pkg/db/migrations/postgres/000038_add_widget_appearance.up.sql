@@ -0,0 +1,3 @@
+-- widget presentation defaults of the property, returned by /config endpoint (empty values mean widget's own defaults)
+ALTER TABLE backend.property_messages ADD COLUMN IF NOT EXISTS theme TEXT NOT NULL DEFAULT '';
+ALTER TABLE backend.property_messages ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- weekly activity digests that were sent (or are being sent) to organization owners, to never send the same week twice
+CREATE TABLE IF NOT EXISTS backend.org_digests(
+    org_id INTEGER NOT NULL REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    week_start TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (org_id, week_start)
+);
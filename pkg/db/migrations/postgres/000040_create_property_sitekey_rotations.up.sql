@@ -0,0 +1,9 @@
+-- previous sitekey of the property that still resolves to it until expires_at, so that websites can be updated
+CREATE TABLE IF NOT EXISTS backend.property_sitekey_rotations(
+    property_id INTEGER PRIMARY KEY REFERENCES backend.properties(id) ON DELETE CASCADE,
+    previous_external_id UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS index_property_sitekey_rotations_previous_external_id ON backend.property_sitekey_rotations(previous_external_id);
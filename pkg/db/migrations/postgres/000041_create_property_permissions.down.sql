@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.property_permissions;
+DROP TYPE IF EXISTS backend.property_access_level;
@@ -0,0 +1,13 @@
+CREATE TYPE backend.property_access_level AS ENUM ('view', 'edit');
+
+-- explicit access of organization members to properties. Members that have no rows here can access all properties
+-- of the organization, otherwise only the listed ones
+CREATE TABLE IF NOT EXISTS backend.property_permissions(
+    property_id INT REFERENCES backend.properties(id) ON DELETE CASCADE,
+    user_id INT REFERENCES backend.users(id) ON DELETE CASCADE,
+    level backend.property_access_level NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (property_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS index_property_permissions_user_id ON backend.property_permissions(user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS backend.index_queue_jobs_pending;
+DROP TABLE IF EXISTS backend.queue_jobs;
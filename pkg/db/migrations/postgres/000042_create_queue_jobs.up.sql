@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS backend.queue_jobs(
+    id SERIAL PRIMARY KEY,
+    queue TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    locked_until TIMESTAMPTZ DEFAULT NULL,
+    failed_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_queue_jobs_pending ON backend.queue_jobs(queue, run_at) WHERE failed_at IS NULL;
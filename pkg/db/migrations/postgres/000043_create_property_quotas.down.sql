@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.property_quotas;
+DROP TYPE IF EXISTS backend.quota_period;
@@ -0,0 +1,9 @@
+CREATE TYPE backend.quota_period AS ENUM ('week', 'month');
+
+-- share of the plan's requests quota (of the organization owner) that the property is allowed to use
+CREATE TABLE IF NOT EXISTS backend.property_quotas(
+    property_id INT PRIMARY KEY REFERENCES backend.properties(id) ON DELETE CASCADE,
+    percent SMALLINT NOT NULL CHECK (percent > 0 AND percent <= 100),
+    period backend.quota_period NOT NULL DEFAULT 'month',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.domain_verifications;
+ALTER TABLE backend.properties DROP COLUMN IF EXISTS domain_verified_at;
@@ -0,0 +1,10 @@
+-- set when the owner proved control of the property domain with DNS TXT record
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS domain_verified_at TIMESTAMPTZ DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS backend.domain_verifications(
+    property_id INT PRIMARY KEY REFERENCES backend.properties(id) ON DELETE CASCADE,
+    token TEXT NOT NULL,
+    checked_at TIMESTAMPTZ DEFAULT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,13 @@
+-- history of property settings changes (before and after values) with the user who made them
+CREATE TABLE IF NOT EXISTS backend.property_events(
+    id SERIAL PRIMARY KEY,
+    property_id INTEGER NOT NULL REFERENCES backend.properties(id) ON DELETE CASCADE,
+    -- NULL for changes made by the system (e.g. background jobs)
+    user_id INTEGER REFERENCES backend.users(id) ON DELETE SET NULL,
+    setting TEXT NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_property_events_property_id ON backend.property_events(property_id, created_at DESC);
@@ -0,0 +1,21 @@
+DROP FUNCTION IF EXISTS backend.rotate_cache_partitions;
+
+DROP INDEX IF EXISTS backend.index_cache_key;
+
+ALTER TABLE IF EXISTS backend.cache RENAME TO cache_partitioned;
+
+CREATE UNLOGGED TABLE IF NOT EXISTS backend.cache (
+    id serial PRIMARY KEY,
+    key text UNIQUE NOT NULL,
+    value bytea NOT NULL,
+    expires_at timestamp DEFAULT CURRENT_TIMESTAMP + INTERVAL '5 minutes' NOT NULL
+);
+
+INSERT INTO backend.cache (key, value, expires_at)
+SELECT DISTINCT ON (key) key, value, expires_at FROM backend.cache_partitioned
+WHERE expires_at >= NOW()
+ORDER BY key, expires_at DESC;
+
+DROP TABLE IF EXISTS backend.cache_partitioned;
+
+CREATE UNIQUE INDEX IF NOT EXISTS index_cache_key ON backend.cache (key);
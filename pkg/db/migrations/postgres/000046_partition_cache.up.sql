@@ -0,0 +1,68 @@
+-- cache is split into hourly partitions by expiration time so that expired entries are dropped together with their
+-- partition instead of being deleted row by row (and vacuumed afterwards). Partitioned tables cannot be UNLOGGED,
+-- but they do not store any data: every partition is created UNLOGGED
+CREATE TABLE IF NOT EXISTS backend.cache_partitioned (
+    key text NOT NULL,
+    value bytea NOT NULL,
+    expires_at timestamp DEFAULT CURRENT_TIMESTAMP + INTERVAL '5 minutes' NOT NULL,
+    PRIMARY KEY (key, expires_at)
+) PARTITION BY RANGE (expires_at);
+
+-- entries that expire later than the latest hourly partition
+CREATE UNLOGGED TABLE IF NOT EXISTS backend.cache_default PARTITION OF backend.cache_partitioned DEFAULT;
+
+INSERT INTO backend.cache_partitioned (key, value, expires_at)
+SELECT key, value, expires_at FROM backend.cache WHERE expires_at >= NOW();
+
+DROP TABLE IF EXISTS backend.cache;
+
+ALTER TABLE backend.cache_partitioned RENAME TO cache;
+
+CREATE INDEX IF NOT EXISTS index_cache_key ON backend.cache (key);
+
+-- drops partitions that contain only expired entries, creates partitions for the next hours and deletes expired
+-- entries from the current hour partition
+CREATE OR REPLACE FUNCTION backend.rotate_cache_partitions(hours_ahead INTEGER) RETURNS VOID AS
+$$
+DECLARE
+    current_hour TIMESTAMP := date_trunc('hour', NOW()::TIMESTAMP);
+    lower_bound TIMESTAMP;
+    upper_bound TIMESTAMP;
+    partition_name TEXT;
+BEGIN
+    -- concurrent rotations would race to create the same partitions
+    PERFORM pg_advisory_xact_lock(hashtext('backend.cache'));
+
+    FOR partition_name IN
+        SELECT c.relname FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'backend.cache'::regclass AND c.relname LIKE 'cache\_p%'
+    LOOP
+        -- partition names sort the same way as their hours
+        IF partition_name < 'cache_p' || to_char(current_hour, 'YYYYMMDDHH24') THEN
+            EXECUTE format('DROP TABLE IF EXISTS backend.%I', partition_name);
+        END IF;
+    END LOOP;
+
+    FOR i IN 0..hours_ahead LOOP
+        lower_bound := current_hour + make_interval(hours => i);
+        upper_bound := lower_bound + INTERVAL '1 hour';
+        partition_name := 'cache_p' || to_char(lower_bound, 'YYYYMMDDHH24');
+
+        IF to_regclass('backend.' || partition_name) IS NULL THEN
+            EXECUTE format('CREATE UNLOGGED TABLE backend.%I (LIKE backend.cache INCLUDING DEFAULTS)', partition_name);
+            -- attaching fails if default partition has entries from the new partition range
+            EXECUTE format('WITH moved AS (DELETE FROM backend.cache_default WHERE expires_at >= %L AND expires_at < %L RETURNING key, value, expires_at) ' ||
+                           'INSERT INTO backend.%I (key, value, expires_at) SELECT key, value, expires_at FROM moved',
+                           lower_bound, upper_bound, partition_name);
+            EXECUTE format('ALTER TABLE backend.cache ATTACH PARTITION backend.%I FOR VALUES FROM (%L) TO (%L)',
+                           partition_name, lower_bound, upper_bound);
+        END IF;
+    END LOOP;
+
+    DELETE FROM backend.cache WHERE expires_at < NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- moves existing entries from the default partition
+SELECT backend.rotate_cache_partitions(6);
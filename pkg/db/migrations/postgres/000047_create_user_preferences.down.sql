@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS backend.user_preferences;
+DROP TYPE IF EXISTS backend.table_density;
+DROP TYPE IF EXISTS backend.ui_theme;
@@ -0,0 +1,11 @@
+CREATE TYPE backend.ui_theme AS ENUM ('light', 'dark', 'system');
+CREATE TYPE backend.table_density AS ENUM ('comfortable', 'compact');
+
+-- portal UI preferences are stored server-side so that they follow the user across devices
+CREATE TABLE IF NOT EXISTS backend.user_preferences(
+    user_id INT PRIMARY KEY REFERENCES backend.users(id) ON DELETE CASCADE,
+    theme backend.ui_theme NOT NULL DEFAULT 'light',
+    table_density backend.table_density NOT NULL DEFAULT 'comfortable',
+    default_org_id INT REFERENCES backend.organizations(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,2 @@
+-- bundle IDs / package names of native apps that can request puzzles without Origin header ("mobile" mode)
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS allowed_app_ids TEXT[] NOT NULL DEFAULT '{}';
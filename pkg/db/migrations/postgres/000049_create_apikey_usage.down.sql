@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_apikey_usage_hour;
+DROP TABLE IF EXISTS backend.apikey_usage;
+ALTER TABLE backend.apikeys DROP COLUMN IF EXISTS suspended_at;
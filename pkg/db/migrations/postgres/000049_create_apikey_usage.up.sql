@@ -0,0 +1,13 @@
+-- set when the key was disabled automatically due to anomalous usage (as opposed to rotation or expiry)
+ALTER TABLE backend.apikeys ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+
+-- hourly counters of authenticated API requests, used to detect anomalous usage of API keys
+CREATE TABLE IF NOT EXISTS backend.apikey_usage(
+    apikey_id INT NOT NULL REFERENCES backend.apikeys(id) ON DELETE CASCADE,
+    hour TIMESTAMPTZ NOT NULL,
+    requests INT NOT NULL DEFAULT 0,
+    wrong_owner INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (apikey_id, hour)
+);
+
+CREATE INDEX IF NOT EXISTS index_apikey_usage_hour ON backend.apikey_usage(hour);
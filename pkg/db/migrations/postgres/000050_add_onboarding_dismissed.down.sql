@@ -0,0 +1 @@
+ALTER TABLE backend.user_preferences DROP COLUMN IF EXISTS onboarding_dismissed_at;
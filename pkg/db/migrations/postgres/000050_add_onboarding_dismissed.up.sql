@@ -0,0 +1,2 @@
+-- onboarding checklist on the dashboard is shown until all steps are done or until user dismisses it
+ALTER TABLE backend.user_preferences ADD COLUMN IF NOT EXISTS onboarding_dismissed_at TIMESTAMPTZ DEFAULT NULL;
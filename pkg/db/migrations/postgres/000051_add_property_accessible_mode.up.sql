@@ -0,0 +1,2 @@
+-- accessible mode: visitors can answer a human-readable challenge (with reduced proof-of-work) instead of solving the full puzzle
+ALTER TABLE backend.properties ADD COLUMN IF NOT EXISTS accessible_mode BOOLEAN NOT NULL DEFAULT FALSE;
@@ -36,19 +36,23 @@ func (tracer *myQueryTracer) TraceQueryStart(
 	_ *pgx.Conn,
 	data pgx.TraceQueryStartData) context.Context {
 	slog.Log(ctx, common.LevelTrace, "Starting SQL command", "sql", data.SQL, "args", data.Args, "source", "postgres")
-	return context.WithValue(ctx, common.TimeContextKey, time.Now())
+	qs := &queryStart{name: queryName(data.SQL), params: postgresParams(data.Args), start: time.Now()}
+	return context.WithValue(ctx, common.QueryContextKey, qs)
 }
 
 func (tracer *myQueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	qs, ok := ctx.Value(common.QueryContextKey).(*queryStart)
+	if !ok {
+		qs = &queryStart{name: unnamedQuery, start: time.Now()}
+	}
+
 	if data.Err != nil {
 		slog.Log(ctx, common.LevelTrace, "SQL command failed", common.ErrAttr(data.Err), "source", "postgres")
 	} else {
-		t, ok := ctx.Value(common.TimeContextKey).(time.Time)
-		if !ok {
-			t = time.Now()
-		}
-		slog.DebugContext(ctx, "SQL command finished", "source", "postgres", "duration", time.Since(t).Milliseconds())
+		slog.DebugContext(ctx, "SQL command finished", "source", "postgres", "duration", time.Since(qs.start).Milliseconds())
 	}
+
+	globalQueryTimer.observe(ctx, "postgres", qs, data.Err)
 }
 
 func postgresUser(cfg common.ConfigStore, admin bool) string {
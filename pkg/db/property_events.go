@@ -0,0 +1,88 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+// settings names of property events
+const (
+	PropertySettingName             = "name"
+	PropertySettingDifficulty       = "difficulty"
+	PropertySettingGrowth           = "growth"
+	PropertySettingValidityInterval = "validity_interval"
+	PropertySettingAllowSubdomains  = "allow_subdomains"
+	PropertySettingAllowLocalhost   = "allow_localhost"
+	PropertySettingAllowReplay      = "allow_replay"
+	PropertySettingAlgorithm        = "algorithm"
+	PropertySettingPrivacyMode      = "privacy_mode"
+	PropertySettingIplessMode       = "ipless_mode"
+	PropertySettingAccessibleMode   = "accessible_mode"
+	PropertySettingWidgetChannel    = "widget_channel"
+	PropertySettingAllowedOrigins   = "allowed_origins"
+	PropertySettingAllowedAppIDs    = "allowed_app_ids"
+	PropertySettingTrustedThreshold = "trusted_visitors_threshold"
+	PropertySettingTrustedTTL       = "trusted_visitors_ttl"
+	PropertySettingPaused           = "paused"
+	PropertySettingDomainVerified   = "domain_verified"
+	PropertySettingTags             = "tags"
+)
+
+// NewPropertyEvent returns event of a single setting change (or nil if value did not change)
+func NewPropertyEvent(setting, oldValue, newValue string) *dbgen.PropertyEvent {
+	if oldValue == newValue {
+		return nil
+	}
+
+	return &dbgen.PropertyEvent{
+		Setting:  setting,
+		OldValue: oldValue,
+		NewValue: newValue,
+	}
+}
+
+// PropertyFlagEvent returns event of a boolean setting change (or nil if flag did not change)
+func PropertyFlagEvent(setting string, oldValue, newValue bool) *dbgen.PropertyEvent {
+	return NewPropertyEvent(setting, strconv.FormatBool(oldValue), strconv.FormatBool(newValue))
+}
+
+// PropertyListEvent returns event of a list setting change (or nil if list did not change)
+func PropertyListEvent(setting string, oldValue, newValue []string) *dbgen.PropertyEvent {
+	return NewPropertyEvent(setting, strings.Join(oldValue, ", "), strings.Join(newValue, ", "))
+}
+
+// PropertyChanges returns events for all settings that differ between two versions of the property
+func PropertyChanges(before, after *dbgen.Property) []*dbgen.PropertyEvent {
+	candidates := []*dbgen.PropertyEvent{
+		NewPropertyEvent(PropertySettingName, before.Name, after.Name),
+		NewPropertyEvent(PropertySettingDifficulty, strconv.Itoa(int(before.Level.Int16)), strconv.Itoa(int(after.Level.Int16))),
+		NewPropertyEvent(PropertySettingGrowth, string(before.Growth), string(after.Growth)),
+		NewPropertyEvent(PropertySettingValidityInterval, before.ValidityInterval.String(), after.ValidityInterval.String()),
+		PropertyFlagEvent(PropertySettingAllowSubdomains, before.AllowSubdomains, after.AllowSubdomains),
+		PropertyFlagEvent(PropertySettingAllowLocalhost, before.AllowLocalhost, after.AllowLocalhost),
+		PropertyFlagEvent(PropertySettingAllowReplay, before.AllowReplay, after.AllowReplay),
+		NewPropertyEvent(PropertySettingAlgorithm, string(before.Algorithm), string(after.Algorithm)),
+		PropertyFlagEvent(PropertySettingPrivacyMode, before.PrivacyMode, after.PrivacyMode),
+		PropertyFlagEvent(PropertySettingIplessMode, before.IplessMode, after.IplessMode),
+		PropertyFlagEvent(PropertySettingAccessibleMode, before.AccessibleMode, after.AccessibleMode),
+		NewPropertyEvent(PropertySettingWidgetChannel, string(before.WidgetChannel), string(after.WidgetChannel)),
+		PropertyListEvent(PropertySettingAllowedOrigins, before.AllowedOrigins, after.AllowedOrigins),
+		PropertyListEvent(PropertySettingAllowedAppIDs, before.AllowedAppIDs, after.AllowedAppIDs),
+		NewPropertyEvent(PropertySettingTrustedThreshold, strconv.Itoa(int(before.TrustedVisitorsThreshold)),
+			strconv.Itoa(int(after.TrustedVisitorsThreshold))),
+		NewPropertyEvent(PropertySettingTrustedTTL, before.TrustedVisitorsTtl.String(), after.TrustedVisitorsTtl.String()),
+		PropertyFlagEvent(PropertySettingPaused, before.PausedAt.Valid, after.PausedAt.Valid),
+		PropertyFlagEvent(PropertySettingDomainVerified, before.DomainVerifiedAt.Valid, after.DomainVerifiedAt.Valid),
+	}
+
+	result := make([]*dbgen.PropertyEvent, 0)
+	for _, e := range candidates {
+		if e != nil {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestPropertyChanges(t *testing.T) {
+	before := &dbgen.Property{
+		Name:             "test",
+		Level:            Int2(80),
+		Growth:           dbgen.DifficultyGrowthMedium,
+		ValidityInterval: 6 * time.Hour,
+		AllowedOrigins:   []string{"a.example.com"},
+	}
+
+	if changes := PropertyChanges(before, before); len(changes) != 0 {
+		t.Fatalf("Unexpected changes of the same property: %v", len(changes))
+	}
+
+	after := *before
+	after.Level = Int2(100)
+	after.AllowSubdomains = true
+	after.AllowedOrigins = []string{"a.example.com", "b.example.com"}
+	after.AllowedAppIDs = []string{"com.example.app"}
+	after.PausedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	expected := []*dbgen.PropertyEvent{
+		{Setting: PropertySettingDifficulty, OldValue: "80", NewValue: "100"},
+		{Setting: PropertySettingAllowSubdomains, OldValue: "false", NewValue: "true"},
+		{Setting: PropertySettingAllowedOrigins, OldValue: "a.example.com", NewValue: "a.example.com, b.example.com"},
+		{Setting: PropertySettingAllowedAppIDs, OldValue: "", NewValue: "com.example.app"},
+		{Setting: PropertySettingPaused, OldValue: "false", NewValue: "true"},
+	}
+
+	changes := PropertyChanges(before, &after)
+	if len(changes) != len(expected) {
+		t.Fatalf("Unexpected changes count: %v", len(changes))
+	}
+
+	for i, e := range expected {
+		if (changes[i].Setting != e.Setting) || (changes[i].OldValue != e.OldValue) || (changes[i].NewValue != e.NewValue) {
+			t.Errorf("Unexpected change at %v: %+v", i, changes[i])
+		}
+	}
+}
@@ -0,0 +1,22 @@
+-- name: AddAPIKeyUsage :exec
+INSERT INTO backend.apikey_usage (apikey_id, hour, requests, wrong_owner)
+SELECT unnest(@apikey_ids::INT[]), @hour::timestamptz, unnest(@requests::INT[]), unnest(@wrong_owner::INT[])
+ON CONFLICT (apikey_id, hour) DO UPDATE
+SET requests = backend.apikey_usage.requests + EXCLUDED.requests,
+    wrong_owner = backend.apikey_usage.wrong_owner + EXCLUDED.wrong_owner;
+
+-- name: GetAPIKeysUsageStats :many
+-- usage of active keys since @recent_from compared to hourly usage of the same keys during the baseline period
+SELECT sqlc.embed(k),
+       COALESCE(SUM(u.requests) FILTER (WHERE u.hour >= @recent_from::timestamptz), 0)::BIGINT AS recent_requests,
+       COALESCE(SUM(u.wrong_owner) FILTER (WHERE u.hour >= @recent_from::timestamptz), 0)::BIGINT AS recent_wrong_owner,
+       COALESCE(SUM(u.requests) FILTER (WHERE u.hour < @recent_from::timestamptz), 0)::BIGINT AS baseline_requests
+FROM backend.apikey_usage u
+JOIN backend.apikeys k ON k.id = u.apikey_id
+WHERE u.hour >= @baseline_from::timestamptz AND k.enabled = TRUE AND k.suspended_at IS NULL
+GROUP BY k.id
+HAVING SUM(u.requests) FILTER (WHERE u.hour >= @recent_from::timestamptz) > 0
+    OR SUM(u.wrong_owner) FILTER (WHERE u.hour >= @recent_from::timestamptz) > 0;
+
+-- name: DeleteAPIKeyUsage :exec
+DELETE FROM backend.apikey_usage WHERE hour < $1;
@@ -4,17 +4,84 @@ SELECT * FROM backend.apikeys WHERE external_id = $1;
 -- name: GetUserAPIKeys :many
 SELECT * FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW();
 
+-- name: GetOrgAPIKeys :many
+SELECT * FROM backend.apikeys WHERE org_id = $1 AND expires_at > NOW();
+
 -- name: CreateAPIKey :one
-INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst) VALUES ($1, $2, $3, $4, $5) RETURNING *;
+INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst, rotation_days) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: CreateOrgAPIKey :one
+INSERT INTO backend.apikeys (name, org_id, expires_at, requests_per_second, requests_burst) VALUES ($1, $2, $3, $4, $5) RETURNING *;
 
 -- name: UpdateAPIKey :one
 UPDATE backend.apikeys SET expires_at = $1, enabled = $2 WHERE external_id = $3 RETURNING *;
 
+-- name: UpdateAPIKeyExternalID :one
+UPDATE backend.apikeys SET external_id = $2 WHERE id = $1 RETURNING *;
+
 -- name: UpdateUserAPIKeysRateLimits :exec
-UPDATE backend.apikeys SET requests_per_second = $1 WHERE user_id = $2;
+UPDATE backend.apikeys SET requests_per_second = $1
+WHERE user_id = $2 OR org_id IN (SELECT id FROM backend.organizations WHERE user_id = $2);
 
 -- name: DeleteUserAPIKeys :exec
 DELETE FROM backend.apikeys WHERE user_id = $1;
 
 -- name: DeleteAPIKey :one
 DELETE FROM backend.apikeys WHERE id=$1 AND user_id = $2 RETURNING *;
+
+-- name: DeleteOrgAPIKey :one
+DELETE FROM backend.apikeys WHERE id = $1 AND org_id = $2 RETURNING *;
+
+-- name: UpdateAPIKeyRotation :one
+UPDATE backend.apikeys SET rotation_days = $1 WHERE id = $2 AND user_id = $3 RETURNING *;
+
+-- name: GetAPIKeysDueForRotation :many
+SELECT * FROM backend.apikeys
+WHERE enabled = TRUE AND rotation_days > 0 AND successor_id IS NULL AND expires_at > NOW()
+  AND created_at + make_interval(days => rotation_days) <= NOW()
+ORDER BY id
+LIMIT $1;
+
+-- name: SetAPIKeySuccessor :one
+UPDATE backend.apikeys SET successor_id = $1, rotated_at = NOW() WHERE id = $2 AND successor_id IS NULL RETURNING *;
+
+-- name: DisableRotatedAPIKeys :many
+UPDATE backend.apikeys SET enabled = FALSE
+WHERE enabled = TRUE AND successor_id IS NOT NULL AND rotated_at < $1
+RETURNING *;
+
+-- name: GetAPIKeysExpiringSoon :many
+SELECT * FROM backend.apikeys
+WHERE enabled = TRUE AND successor_id IS NULL
+  AND expires_at > @expires_after::timestamptz AND expires_at <= @expires_before::timestamptz
+  AND (expiry_notified_days = 0 OR expiry_notified_days > @notify_days::smallint)
+ORDER BY expires_at
+LIMIT @max_results::int;
+
+-- name: UpdateAPIKeyExpiryNotified :exec
+UPDATE backend.apikeys SET expiry_notified_days = $1 WHERE id = $2;
+
+-- name: ExpireAPIKeys :many
+UPDATE backend.apikeys SET enabled = FALSE
+WHERE enabled = TRUE AND expires_at <= $1
+RETURNING *;
+
+-- name: ArchiveExpiredAPIKeys :many
+DELETE FROM backend.apikeys
+WHERE id IN (SELECT id FROM backend.apikeys WHERE expires_at < $1 ORDER BY expires_at LIMIT $2)
+RETURNING *;
+
+-- name: SuspendAPIKey :one
+UPDATE backend.apikeys SET enabled = FALSE, suspended_at = NOW() WHERE id = $1 AND enabled = TRUE RETURNING *;
+
+-- name: ResumeAPIKey :one
+-- usage before suspension is removed so that the same spike does not suspend the key again
+WITH resumed AS (
+    UPDATE backend.apikeys SET enabled = TRUE, suspended_at = NULL
+    WHERE id = @id AND suspended_at IS NOT NULL AND expires_at > NOW()
+      AND (user_id = @user_id OR org_id IN (SELECT id FROM backend.organizations WHERE user_id = @user_id))
+    RETURNING *
+), deleted AS (
+    DELETE FROM backend.apikey_usage WHERE apikey_id IN (SELECT id FROM resumed)
+)
+SELECT * FROM resumed;
@@ -1,20 +1,21 @@
 -- name: GetCachedByKey :one
-SELECT value FROM backend.cache WHERE key = $1 AND expires_at >= NOW();
+SELECT value FROM backend.cache WHERE key = $1 AND expires_at >= NOW() ORDER BY expires_at DESC LIMIT 1;
 
 -- name: CreateCache :exec
-INSERT INTO backend.cache (key, value, expires_at) VALUES ($1, $2, NOW() + $3::INTERVAL)
-ON CONFLICT (key) DO UPDATE 
-SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at;
+-- cache is partitioned by expiration so key alone is not unique and previous value is deleted instead of upsert
+WITH deleted AS (
+    DELETE FROM backend.cache WHERE key = $1
+)
+INSERT INTO backend.cache (key, value, expires_at) VALUES ($1, $2, NOW() + $3::INTERVAL);
 
 -- name: CreateCacheMany :exec
+WITH deleted AS (
+    DELETE FROM backend.cache WHERE key = ANY(@keys::TEXT[])
+)
 INSERT INTO backend.cache (key, value, expires_at)
 SELECT unnest(@keys::TEXT[]) as key,
        unnest(@values::BYTEA[]) as value,
-       NOW() + unnest(@intervals::INTERVAL[]) as expires_at
-ON CONFLICT (key)
-DO UPDATE SET
-    value = EXCLUDED.value,
-    expires_at = EXCLUDED.expires_at;
+       NOW() + unnest(@intervals::INTERVAL[]) as expires_at;
 
 -- name: UpdateCacheExpiration :exec
 UPDATE backend.cache SET expires_at = NOW() + $2::INTERVAL WHERE key = $1;
@@ -23,4 +24,7 @@ UPDATE backend.cache SET expires_at = NOW() + $2::INTERVAL WHERE key = $1;
 DELETE FROM backend.cache WHERE key = $1;
 
 -- name: DeleteExpiredCache :exec
-DELETE FROM backend.cache WHERE expires_at < NOW();
+SELECT backend.rotate_cache_partitions(@hours_ahead::INTEGER);
+
+-- name: NotifyCacheInvalidation :exec
+SELECT pg_notify(@channel::TEXT, @payload::TEXT);
@@ -0,0 +1,19 @@
+-- name: GetDomainVerification :one
+SELECT * FROM backend.domain_verifications WHERE property_id = $1;
+
+-- name: CreateDomainVerification :one
+INSERT INTO backend.domain_verifications (property_id, token)
+VALUES ($1, $2)
+ON CONFLICT (property_id) DO UPDATE SET token = backend.domain_verifications.token
+RETURNING *;
+
+-- name: UpdateDomainVerificationCheck :exec
+UPDATE backend.domain_verifications SET checked_at = NOW(), last_error = $2 WHERE property_id = $1;
+
+-- name: GetPendingDomainVerifications :many
+SELECT sqlc.embed(v), p.domain
+FROM backend.domain_verifications v
+JOIN backend.properties p ON p.id = v.property_id
+WHERE p.domain_verified_at IS NULL AND p.deleted_at IS NULL AND (v.checked_at IS NULL OR v.checked_at < $1)
+ORDER BY v.checked_at NULLS FIRST
+LIMIT $2;
@@ -0,0 +1,10 @@
+-- name: CreateEmailChange :one
+INSERT INTO backend.email_changes (user_id, old_email, new_email, revert_token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetEmailChangeByTokenHash :one
+SELECT * FROM backend.email_changes WHERE revert_token_hash = $1;
+
+-- name: MarkEmailChangeReverted :one
+UPDATE backend.email_changes SET reverted_at = NOW() WHERE id = $1 AND reverted_at IS NULL RETURNING *;
@@ -0,0 +1,17 @@
+-- name: UpsertLicenseNode :exec
+INSERT INTO backend.license_nodes (id, version)
+VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE
+SET version = EXCLUDED.version,
+    last_seen_at = NOW();
+
+-- name: GetActiveLicenseNodesCount :one
+SELECT COUNT(*) as count FROM backend.license_nodes WHERE last_seen_at >= $1;
+
+-- name: DeleteStaleLicenseNodes :exec
+DELETE FROM backend.license_nodes WHERE last_seen_at < $1;
+
+-- name: GetInstanceCounts :one
+SELECT (SELECT COUNT(*) FROM backend.users u WHERE u.deleted_at IS NULL) AS users,
+       (SELECT COUNT(*) FROM backend.organizations o WHERE o.deleted_at IS NULL) AS orgs,
+       (SELECT COUNT(*) FROM backend.properties p WHERE p.deleted_at IS NULL) AS properties;
@@ -0,0 +1,21 @@
+-- name: GetOrgBudget :one
+SELECT * FROM backend.org_budgets WHERE org_id = $1;
+
+-- name: UpsertOrgBudget :one
+INSERT INTO backend.org_budgets (org_id, monthly_limit)
+VALUES ($1, $2)
+ON CONFLICT (org_id) DO UPDATE
+SET monthly_limit = EXCLUDED.monthly_limit,
+    alerted_percent = 0,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetActiveOrgBudgets :many
+SELECT sqlc.embed(b), o.name AS org_name, o.user_id AS owner_id
+FROM backend.org_budgets b
+JOIN backend.organizations o ON o.id = b.org_id
+WHERE b.monthly_limit > 0 AND o.deleted_at IS NULL
+ORDER BY b.org_id;
+
+-- name: UpdateOrgBudgetAlert :exec
+UPDATE backend.org_budgets SET alerted_percent = $1, alerted_at = $2 WHERE org_id = $3;
@@ -0,0 +1,21 @@
+-- name: GetOrgDigestCandidates :many
+SELECT o.id, o.name, o.user_id AS owner_id
+FROM backend.organizations o
+LEFT JOIN backend.org_digests d ON d.org_id = o.id AND d.week_start = $1
+WHERE o.deleted_at IS NULL AND o.user_id IS NOT NULL AND d.org_id IS NULL
+ORDER BY o.id
+LIMIT $2;
+
+-- name: CreateOrgDigest :one
+INSERT INTO backend.org_digests (org_id, week_start) VALUES ($1, $2)
+ON CONFLICT (org_id, week_start) DO NOTHING
+RETURNING *;
+
+-- name: GetOrgDigestActivity :one
+SELECT
+    (SELECT COUNT(*) FROM backend.properties p
+     WHERE p.org_id = $1 AND p.deleted_at IS NULL AND p.created_at >= $2 AND p.created_at < $3) AS new_properties,
+    (SELECT COUNT(*) FROM backend.organization_users ou
+     WHERE ou.org_id = $1 AND ou.level = 'invited' AND ou.created_at >= $2 AND ou.created_at < $3) AS invited_members,
+    (SELECT COUNT(*) FROM backend.organization_users ou
+     WHERE ou.org_id = $1 AND ou.level = 'member' AND ou.updated_at >= $2 AND ou.updated_at < $3) AS joined_members;
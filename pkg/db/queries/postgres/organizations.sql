@@ -10,6 +10,9 @@ INSERT INTO backend.organizations (name, user_id) VALUES ($1, $2) RETURNING *;
      AND o.user_id != $2  -- Only do the join if user isn't the owner
  WHERE o.id = $1;
 
+-- name: GetOrganizationByID :one
+SELECT * FROM backend.organizations WHERE id = $1;
+
 -- name: FindUserOrgByName :one
 SELECT * from backend.organizations WHERE user_id = $1 AND name = $2 AND deleted_at IS NULL;
 
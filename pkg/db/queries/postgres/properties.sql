@@ -2,15 +2,27 @@
 SELECT * from backend.properties WHERE external_id = ANY($1::UUID[]);
 
 -- name: CreateProperty :one
-INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, test_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: UpdateProperty :one
-UPDATE backend.properties SET name = $2, level = $3, growth = $4, validity_interval = $5, allow_subdomains = $6, allow_localhost = $7, allow_replay = $8, updated_at = NOW()
+UPDATE backend.properties SET name = $2, level = $3, growth = $4, validity_interval = $5, allow_subdomains = $6, allow_localhost = $7, allow_replay = $8, algorithm = $9, privacy_mode = $10, allowed_origins = $11, trusted_visitors_threshold = $12, trusted_visitors_ttl = $13, ipless_mode = $14, widget_channel = $15, data_region = $16, accessible_mode = $17, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdatePropertyPaused :one
+UPDATE backend.properties SET paused_at = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdatePropertyDomainVerified :one
+UPDATE backend.properties SET domain_verified_at = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdatePropertyAppIDs :one
+UPDATE backend.properties SET allowed_app_ids = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdatePropertyExternalID :one
+UPDATE backend.properties SET external_id = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
 -- name: GetOrgPropertyByName :one
 SELECT * from backend.properties WHERE org_id = $1 AND name = $2 AND deleted_at IS NULL;
 
@@ -42,3 +54,6 @@ SELECT * FROM backend.properties LIMIT $1;
 
 -- name: GetUserPropertiesCount :one
 SELECT COUNT(*) as count FROM backend.properties WHERE org_owner_id = $1 AND deleted_at IS NULL;
+
+-- name: RotatePropertyExternalID :one
+UPDATE backend.properties SET external_id = gen_random_uuid(), updated_at = NOW() WHERE id = $1 RETURNING *;
@@ -0,0 +1,11 @@
+-- name: CreatePropertyEvents :exec
+INSERT INTO backend.property_events (property_id, user_id, setting, old_value, new_value)
+SELECT @property_id::INT, sqlc.narg(user_id)::INT, unnest(@settings::TEXT[]), unnest(@old_values::TEXT[]), unnest(@new_values::TEXT[]);
+
+-- name: GetPropertyEvents :many
+SELECT sqlc.embed(e), u.name AS user_name
+FROM backend.property_events e
+LEFT JOIN backend.users u ON u.id = e.user_id
+WHERE e.property_id = $1
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT $2;
@@ -0,0 +1,15 @@
+-- name: GetPropertyMessages :one
+SELECT * FROM backend.property_messages WHERE property_id = $1;
+
+-- name: UpsertPropertyMessages :one
+INSERT INTO backend.property_messages (property_id, blocked_message, quota_message, maintenance_message, redirect_url, theme, locale)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (property_id) DO UPDATE
+SET blocked_message = EXCLUDED.blocked_message,
+    quota_message = EXCLUDED.quota_message,
+    maintenance_message = EXCLUDED.maintenance_message,
+    redirect_url = EXCLUDED.redirect_url,
+    theme = EXCLUDED.theme,
+    locale = EXCLUDED.locale,
+    updated_at = NOW()
+RETURNING *;
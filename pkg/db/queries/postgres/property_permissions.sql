@@ -0,0 +1,22 @@
+-- name: GetUserPropertyPermissions :many
+SELECT pp.* FROM backend.property_permissions pp
+JOIN backend.properties p ON p.id = pp.property_id
+WHERE p.org_id = $1 AND pp.user_id = $2;
+
+-- name: GetPropertyPermissions :many
+SELECT * FROM backend.property_permissions WHERE property_id = $1;
+
+-- name: UpsertPropertyPermission :one
+INSERT INTO backend.property_permissions (property_id, user_id, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (property_id, user_id) DO UPDATE
+SET level = EXCLUDED.level
+RETURNING *;
+
+-- name: DeletePropertyPermission :exec
+DELETE FROM backend.property_permissions WHERE property_id = $1 AND user_id = $2;
+
+-- name: DeleteUserOrgPropertyPermissions :exec
+DELETE FROM backend.property_permissions pp
+USING backend.properties p
+WHERE pp.property_id = p.id AND p.org_id = $1 AND pp.user_id = $2;
@@ -0,0 +1,20 @@
+-- name: GetPropertyQuota :one
+SELECT * FROM backend.property_quotas WHERE property_id = $1;
+
+-- name: UpsertPropertyQuota :one
+INSERT INTO backend.property_quotas (property_id, percent, period)
+VALUES ($1, $2, $3)
+ON CONFLICT (property_id) DO UPDATE
+SET percent = EXCLUDED.percent,
+    period = EXCLUDED.period,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeletePropertyQuota :exec
+DELETE FROM backend.property_quotas WHERE property_id = $1;
+
+-- name: GetOwnerAllocatedQuota :one
+SELECT COALESCE(SUM(q.percent), 0)::INTEGER AS allocated
+FROM backend.property_quotas q
+JOIN backend.properties p ON p.id = q.property_id
+WHERE p.org_owner_id = $1 AND p.id <> $2 AND p.deleted_at IS NULL;
@@ -0,0 +1,20 @@
+-- name: GetPropertySitekeyRotation :one
+SELECT * FROM backend.property_sitekey_rotations WHERE property_id = $1 AND expires_at > NOW();
+
+-- name: GetPropertiesByPreviousExternalID :many
+SELECT sqlc.embed(p), r.previous_external_id, r.expires_at
+FROM backend.properties p
+JOIN backend.property_sitekey_rotations r ON r.property_id = p.id
+WHERE r.previous_external_id = ANY($1::UUID[]) AND r.expires_at > NOW();
+
+-- name: UpsertPropertySitekeyRotation :one
+INSERT INTO backend.property_sitekey_rotations (property_id, previous_external_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (property_id) DO UPDATE
+SET previous_external_id = EXCLUDED.previous_external_id,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW()
+RETURNING *;
+
+-- name: DeletePropertySitekeyRotation :one
+DELETE FROM backend.property_sitekey_rotations WHERE property_id = $1 RETURNING *;
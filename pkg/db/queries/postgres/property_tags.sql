@@ -0,0 +1,16 @@
+-- name: GetPropertyTags :many
+SELECT * FROM backend.property_tags WHERE property_id = $1 ORDER BY tag;
+
+-- name: GetOrgPropertyTags :many
+SELECT pt.* FROM backend.property_tags pt
+JOIN backend.properties p ON p.id = pt.property_id
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+ORDER BY pt.tag, pt.property_id;
+
+-- name: AddPropertyTags :exec
+INSERT INTO backend.property_tags (property_id, tag)
+SELECT @property_id::INT, unnest(@tags::TEXT[])
+ON CONFLICT (property_id, tag) DO NOTHING;
+
+-- name: DeleteOtherPropertyTags :exec
+DELETE FROM backend.property_tags WHERE property_id = @property_id::INT AND NOT (tag = ANY(@tags::TEXT[]));
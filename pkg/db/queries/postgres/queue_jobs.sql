@@ -0,0 +1,25 @@
+-- name: CreateQueueJob :exec
+INSERT INTO backend.queue_jobs (queue, payload, run_at) VALUES ($1, $2, $3);
+
+-- name: ClaimQueueJobs :many
+UPDATE backend.queue_jobs SET locked_until = $3
+WHERE id IN (
+    SELECT id FROM backend.queue_jobs
+    WHERE queue = $1 AND failed_at IS NULL AND run_at <= NOW() AND (locked_until IS NULL OR locked_until < NOW())
+    ORDER BY run_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteQueueJob :exec
+DELETE FROM backend.queue_jobs WHERE id = $1;
+
+-- name: RetryQueueJob :exec
+UPDATE backend.queue_jobs SET attempts = attempts + 1, last_error = $2, run_at = $3, locked_until = NULL WHERE id = $1;
+
+-- name: FailQueueJob :exec
+UPDATE backend.queue_jobs SET attempts = attempts + 1, last_error = $2, failed_at = NOW(), locked_until = NULL WHERE id = $1;
+
+-- name: DeleteFailedQueueJobs :exec
+DELETE FROM backend.queue_jobs WHERE failed_at IS NOT NULL AND failed_at < $1;
@@ -0,0 +1,31 @@
+-- name: SearchUserProperties :many
+SELECT p.id, p.name, p.domain, p.org_id, o.name AS org_name
+FROM backend.properties p
+JOIN backend.organizations o ON p.org_id = o.id
+LEFT JOIN backend.organization_users ou ON ou.org_id = o.id AND ou.user_id = $1
+WHERE (o.user_id = $1 OR ou.level = 'member')
+  AND p.deleted_at IS NULL
+  AND o.deleted_at IS NULL
+  AND (p.name ILIKE $2 OR p.domain ILIKE $2)
+  AND (o.user_id = $1 OR p.creator_id = $1
+    OR EXISTS (SELECT 1 FROM backend.property_permissions pp WHERE pp.property_id = p.id AND pp.user_id = $1)
+    OR NOT EXISTS (SELECT 1 FROM backend.property_permissions pp JOIN backend.properties op ON op.id = pp.property_id
+                   WHERE pp.user_id = $1 AND op.org_id = o.id))
+ORDER BY p.name
+LIMIT $3;
+
+-- name: SearchUserOrganizations :many
+SELECT o.id, o.name
+FROM backend.organizations o
+LEFT JOIN backend.organization_users ou ON ou.org_id = o.id AND ou.user_id = $1
+WHERE (o.user_id = $1 OR ou.level IN ('owner', 'member'))
+  AND o.deleted_at IS NULL
+  AND o.name ILIKE $2
+ORDER BY o.name
+LIMIT $3;
+
+-- name: SearchUserAPIKeys :many
+SELECT * FROM backend.apikeys
+WHERE user_id = $1 AND name ILIKE $2
+ORDER BY name
+LIMIT $3;
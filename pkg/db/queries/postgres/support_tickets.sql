@@ -0,0 +1,21 @@
+-- name: CreateSupportTicket :one
+INSERT INTO backend.support_tickets (ticket_id, user_id, subject, message)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetUserSupportTickets :many
+SELECT * FROM backend.support_tickets WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2;
+
+-- name: GetSupportTicketByTicketID :one
+SELECT * FROM backend.support_tickets WHERE ticket_id = $1;
+
+-- name: UpdateSupportTicketStatus :exec
+UPDATE backend.support_tickets SET status = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: CreateSupportTicketReply :one
+INSERT INTO backend.support_ticket_replies (ticket_id, from_support, message)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetSupportTicketsReplies :many
+SELECT * FROM backend.support_ticket_replies WHERE ticket_id = ANY($1::INT[]) ORDER BY id;
@@ -0,0 +1,40 @@
+-- name: CreateUserEmail :one
+INSERT INTO backend.user_emails (user_id, email, verify_token_hash, verify_expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, email) DO UPDATE
+SET verify_token_hash = EXCLUDED.verify_token_hash,
+    verify_expires_at = EXCLUDED.verify_expires_at
+WHERE backend.user_emails.verified_at IS NULL
+RETURNING *;
+
+-- name: GetUserEmails :many
+SELECT * FROM backend.user_emails WHERE user_id = $1 ORDER BY id;
+
+-- name: GetUserEmailByTokenHash :one
+SELECT * FROM backend.user_emails WHERE verify_token_hash = $1;
+
+-- name: MarkUserEmailVerified :one
+UPDATE backend.user_emails SET verified_at = NOW(), verify_token_hash = NULL WHERE id = $1 AND verified_at IS NULL RETURNING *;
+
+-- name: DeleteUserEmail :exec
+DELETE FROM backend.user_emails WHERE id = $1 AND user_id = $2;
+
+-- name: GetOrgBillingContacts :many
+SELECT c.* FROM backend.org_billing_contacts c
+JOIN backend.user_emails e ON e.id = c.email_id
+WHERE e.user_id = $1;
+
+-- name: UpsertOrgBillingContact :exec
+INSERT INTO backend.org_billing_contacts (org_id, email_id)
+VALUES ($1, $2)
+ON CONFLICT (org_id) DO UPDATE
+SET email_id = EXCLUDED.email_id,
+    updated_at = NOW();
+
+-- name: DeleteOrgBillingContact :exec
+DELETE FROM backend.org_billing_contacts WHERE org_id = $1;
+
+-- name: GetOrgBillingEmail :one
+SELECT e.email FROM backend.org_billing_contacts c
+JOIN backend.user_emails e ON e.id = c.email_id
+WHERE c.org_id = $1 AND e.verified_at IS NOT NULL;
@@ -0,0 +1,18 @@
+-- name: GetUserLockout :one
+SELECT * FROM backend.user_lockouts WHERE user_id = $1;
+
+-- name: IncrementUserFailedAttempts :one
+-- failures before @decay_before are forgotten so that occasional typos do not add up to a lockout over time
+INSERT INTO backend.user_lockouts (user_id, failed_attempts, last_failed_at)
+VALUES (@user_id, 1, @last_failed_at::timestamptz)
+ON CONFLICT (user_id) DO UPDATE
+SET failed_attempts = CASE WHEN backend.user_lockouts.last_failed_at < @decay_before::timestamptz THEN 1
+                           ELSE backend.user_lockouts.failed_attempts + 1 END,
+    last_failed_at = @last_failed_at::timestamptz
+RETURNING *;
+
+-- name: LockUser :one
+UPDATE backend.user_lockouts SET locked_until = $2, failed_attempts = 0 WHERE user_id = $1 RETURNING *;
+
+-- name: DeleteUserLockout :exec
+DELETE FROM backend.user_lockouts WHERE user_id = $1;
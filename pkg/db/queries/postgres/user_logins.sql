@@ -0,0 +1,33 @@
+-- name: CreateUserLogin :one
+INSERT INTO backend.user_logins (user_id, ip_address, country, device, user_agent, new_origin, session_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetActiveUserLogins :many
+SELECT ul.* FROM backend.user_logins ul
+WHERE ul.user_id = @user_id AND ul.session_id <> '' AND ul.revoked_at IS NULL
+  AND EXISTS (SELECT 1 FROM backend.cache c WHERE c.key = @session_prefix::TEXT || ul.session_id AND c.expires_at >= NOW())
+ORDER BY ul.created_at DESC;
+
+-- name: GetUserLoginByID :one
+SELECT * FROM backend.user_logins WHERE id = $1;
+
+-- name: GetUserLoginOrigins :one
+SELECT COUNT(*) AS total,
+       COUNT(*) FILTER (WHERE country = $2) AS same_country,
+       COUNT(*) FILTER (WHERE device = $3) AS same_device
+FROM backend.user_logins
+WHERE user_id = $1;
+
+-- name: GetUserLogins :many
+SELECT * FROM backend.user_logins WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2;
+
+-- name: RevokeUserLogins :many
+UPDATE backend.user_logins SET revoked_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND session_id <> '' AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeUserLoginsByID :many
+UPDATE backend.user_logins SET revoked_at = NOW()
+WHERE user_id = @user_id AND id = ANY(@login_ids::INT[]) AND revoked_at IS NULL
+RETURNING *;
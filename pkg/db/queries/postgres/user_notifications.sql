@@ -0,0 +1,26 @@
+-- name: CreateUserNotification :one
+INSERT INTO backend.user_notifications (user_id, category, message)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetUserNotifications :many
+SELECT * FROM backend.user_notifications
+WHERE user_id = $1 AND dismissed_at IS NULL
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: GetUnreadUserNotificationsCount :one
+SELECT COUNT(*) FROM backend.user_notifications
+WHERE user_id = $1 AND read_at IS NULL AND dismissed_at IS NULL;
+
+-- name: MarkUserNotificationRead :exec
+UPDATE backend.user_notifications SET read_at = NOW()
+WHERE id = $1 AND user_id = $2 AND read_at IS NULL;
+
+-- name: MarkAllUserNotificationsRead :exec
+UPDATE backend.user_notifications SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL AND dismissed_at IS NULL;
+
+-- name: DismissUserNotification :exec
+UPDATE backend.user_notifications SET dismissed_at = NOW(), read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND user_id = $2 AND dismissed_at IS NULL;
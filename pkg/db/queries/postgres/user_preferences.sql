@@ -0,0 +1,20 @@
+-- name: GetUserPreferences :one
+SELECT * FROM backend.user_preferences WHERE user_id = $1;
+
+-- name: UpsertUserPreferences :one
+INSERT INTO backend.user_preferences (user_id, theme, table_density, default_org_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET theme = EXCLUDED.theme,
+    table_density = EXCLUDED.table_density,
+    default_org_id = EXCLUDED.default_org_id,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DismissOnboarding :one
+INSERT INTO backend.user_preferences (user_id, onboarding_dismissed_at)
+VALUES ($1, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET onboarding_dismissed_at = NOW(),
+    updated_at = NOW()
+RETURNING *;
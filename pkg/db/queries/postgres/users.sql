@@ -31,3 +31,9 @@ DELETE FROM backend.users WHERE id = ANY($1::INT[]);
 
 -- name: GetUsersWithoutSubscription :many
 SELECT * FROM backend.users where id = ANY($1::INT[]) AND (subscription_id IS NULL OR deleted_at IS NOT NULL);
+
+-- name: UpdateUserReverifyNewLogins :one
+UPDATE backend.users SET reverify_new_logins = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateUserTimezone :one
+UPDATE backend.users SET timezone = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
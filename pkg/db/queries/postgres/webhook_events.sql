@@ -0,0 +1,27 @@
+-- name: CreateWebhookEvent :one
+INSERT INTO backend.webhook_events (event_id, event_type, payload)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetPendingWebhookEvents :many
+SELECT * FROM backend.webhook_events
+WHERE processed_at IS NULL AND attempts < $1 AND next_attempt_at <= NOW()
+ORDER BY id
+LIMIT $2;
+
+-- name: MarkWebhookEventProcessed :exec
+UPDATE backend.webhook_events SET processed_at = NOW(), attempts = attempts + 1, last_error = '' WHERE id = $1;
+
+-- name: MarkWebhookEventSkipped :exec
+UPDATE backend.webhook_events SET processed_at = NOW(), attempts = attempts + 1, last_error = $2 WHERE id = $1;
+
+-- name: MarkWebhookEventFailed :exec
+UPDATE backend.webhook_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1;
+
+-- name: DeleteProcessedWebhookEvents :exec
+DELETE FROM backend.webhook_events WHERE processed_at IS NOT NULL AND processed_at < $1;
+
+-- name: DeleteExhaustedWebhookEvents :many
+DELETE FROM backend.webhook_events WHERE processed_at IS NULL AND attempts >= $1 AND created_at < $2
+RETURNING *;
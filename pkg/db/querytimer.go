@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/chaos"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	DefaultSlowQueryThreshold = 1 * time.Second
+	unnamedQuery              = "unnamed"
+)
+
+var (
+	// sqlc prepends each query with a "-- name: QueryName :kind" comment
+	sqlcNameRegexp   = regexp.MustCompile(`^--\s*name:\s*(\w+)`)
+	globalQueryTimer = newQueryTimer()
+)
+
+type queryStart struct {
+	name   string
+	params []string
+	start  time.Time
+}
+
+// queryTimer records latency of database queries per query name and logs slow queries. Only names of bound
+// parameters are ever logged, never the values.
+type queryTimer struct {
+	metrics   atomic.Pointer[common.PlatformMetrics]
+	threshold atomic.Int64
+}
+
+func newQueryTimer() *queryTimer {
+	qt := &queryTimer{}
+	qt.threshold.Store(int64(DefaultSlowQueryThreshold))
+	return qt
+}
+
+// UpdateQueryTimer sets metrics and slow query threshold (both can be updated at runtime)
+func UpdateQueryTimer(metrics common.PlatformMetrics, threshold time.Duration) {
+	if metrics != nil {
+		globalQueryTimer.metrics.Store(&metrics)
+	}
+
+	if threshold > 0 {
+		globalQueryTimer.threshold.Store(int64(threshold))
+	}
+}
+
+func (qt *queryTimer) observe(ctx context.Context, source string, qs *queryStart, err error) {
+	duration := time.Since(qs.start)
+
+	if m := qt.metrics.Load(); m != nil {
+		(*m).ObserveQuery(source, qs.name, duration)
+	}
+
+	if threshold := time.Duration(qt.threshold.Load()); duration >= threshold {
+		slog.WarnContext(ctx, "Slow query", "source", source, "name", qs.name, "params", qs.params,
+			"duration", duration.Milliseconds(), "threshold", threshold.Milliseconds(), "failed", err != nil)
+	}
+}
+
+// queryName returns sqlc name of the query or a generic name, that keeps metrics cardinality low
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if matches := sqlcNameRegexp.FindStringSubmatch(sql); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return unnamedQuery
+}
+
+// postgresParams returns positional names of bound parameters
+func postgresParams(args []any) []string {
+	params := make([]string, 0, len(args))
+	for i := range args {
+		params = append(params, "$"+strconv.Itoa(i+1))
+	}
+	return params
+}
+
+// clickhouseParams returns names of named parameters (clickhouse.Named())
+func clickhouseParams(args []any) []string {
+	params := make([]string, 0, len(args))
+	for i, arg := range args {
+		if nv, ok := arg.(driver.NamedValue); ok && len(nv.Name) > 0 {
+			params = append(params, nv.Name)
+		} else {
+			params = append(params, "$"+strconv.Itoa(i+1))
+		}
+	}
+	return params
+}
+
+// query runs ClickHouse query with a query timer, queries are named by the caller
+func (ts *TimeSeriesDB) query(ctx context.Context, name string, query string, args ...any) (*sql.Rows, error) {
+	if err := chaos.Inject(ctx, chaos.TargetClickHouse); err != nil {
+		return nil, err
+	}
+
+	qs := &queryStart{name: name, params: clickhouseParams(args), start: time.Now()}
+	rows, err := ts.Clickhouse.Query(query, args...)
+	globalQueryTimer.observe(ctx, ts.name, qs, err)
+	return rows, err
+}
+
+func (ts *TimeSeriesDB) exec(ctx context.Context, name string, query string, args ...any) (sql.Result, error) {
+	if err := chaos.Inject(ctx, chaos.TargetClickHouse); err != nil {
+		return nil, err
+	}
+
+	qs := &queryStart{name: name, params: clickhouseParams(args), start: time.Now()}
+	result, err := ts.Clickhouse.Exec(query, args...)
+	globalQueryTimer.observe(ctx, ts.name, qs, err)
+	return result, err
+}
+
+// commit commits batch insert transaction, which is where ClickHouse actually receives the data
+func (ts *TimeSeriesDB) commit(ctx context.Context, name string, tx *sql.Tx) error {
+	if err := chaos.Inject(ctx, chaos.TargetClickHouse); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	qs := &queryStart{name: name, start: time.Now()}
+	err := tx.Commit()
+	globalQueryTimer.observe(ctx, ts.name, qs, err)
+	return err
+}
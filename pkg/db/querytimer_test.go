@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+)
+
+func TestQueryName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sql      string
+		expected string
+	}{
+		{"-- name: GetUserByID :one\nSELECT * FROM backend.users WHERE id = $1", "GetUserByID"},
+		{"\n-- name: UpdateProperty :one\nUPDATE backend.properties", "UpdateProperty"},
+		{"SELECT 1", unnamedQuery},
+		{"", unnamedQuery},
+	}
+
+	for _, tc := range testCases {
+		if actual := queryName(tc.sql); actual != tc.expected {
+			t.Errorf("Unexpected query name %q for %q (expected %q)", actual, tc.sql, tc.expected)
+		}
+	}
+}
+
+func TestQueryParamsHaveNoValues(t *testing.T) {
+	t.Parallel()
+
+	chParams := clickhouseParams([]any{clickhouse.Named("org_id", "123"), clickhouse.Named("timestamp", "secret")})
+	if (len(chParams) != 2) || (chParams[0] != "org_id") || (chParams[1] != "timestamp") {
+		t.Errorf("Unexpected ClickHouse params: %v", chParams)
+	}
+
+	pgParams := postgresParams([]any{123, "secret"})
+	if (len(pgParams) != 2) || (pgParams[0] != "$1") || (pgParams[1] != "$2") {
+		t.Errorf("Unexpected Postgres params: %v", pgParams)
+	}
+}
+
+type queryMetrics struct {
+	common.PlatformMetrics
+	names []string
+}
+
+func (qm *queryMetrics) ObserveQuery(source, name string, duration time.Duration) {
+	qm.names = append(qm.names, source+"/"+name)
+}
+
+func TestQueryTimerObserve(t *testing.T) {
+	t.Parallel()
+
+	qt := newQueryTimer()
+	// does not panic without metrics
+	qt.observe(context.TODO(), "postgres", &queryStart{name: "Test", start: time.Now()}, nil)
+
+	metrics := &queryMetrics{PlatformMetrics: monitoring.NewStub()}
+	var m common.PlatformMetrics = metrics
+	qt.metrics.Store(&m)
+
+	qt.observe(context.TODO(), "postgres", &queryStart{name: "GetUserByID", start: time.Now()}, nil)
+	qt.observe(context.TODO(), "clickhouse", &queryStart{name: "ReadUsageCounters", start: time.Now()}, errors.New("test"))
+
+	if (len(metrics.names) != 2) || (metrics.names[0] != "postgres/GetUserByID") || (metrics.names[1] != "clickhouse/ReadUsageCounters") {
+		t.Errorf("Unexpected observed queries: %v", metrics.names)
+	}
+}
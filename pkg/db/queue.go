@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+// QueueStore persists jobs of common.JobQueue in Postgres
+type QueueStore struct {
+	store Implementor
+}
+
+func NewQueueStore(store Implementor) *QueueStore {
+	return &QueueStore{store: store}
+}
+
+var _ common.JobStore = (*QueueStore)(nil)
+
+func (qs *QueueStore) CreateQueueJob(ctx context.Context, queue string, payload []byte, runAt time.Time) error {
+	return qs.store.Impl().CreateQueueJob(ctx, queue, payload, runAt)
+}
+
+func (qs *QueueStore) ClaimQueueJobs(ctx context.Context, queue string, limit int, lease time.Duration) ([]*common.QueuedJob, error) {
+	jobs, err := qs.store.Impl().ClaimQueueJobs(ctx, queue, limit, time.Now().UTC().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*common.QueuedJob, 0, len(jobs))
+	for _, j := range jobs {
+		result = append(result, &common.QueuedJob{
+			ID:       j.ID,
+			Payload:  j.Payload,
+			Attempts: j.Attempts,
+			RunAt:    j.RunAt.Time,
+		})
+	}
+
+	return result, nil
+}
+
+func (qs *QueueStore) CompleteQueueJob(ctx context.Context, id int32) error {
+	return qs.store.Impl().DeleteQueueJob(ctx, id)
+}
+
+func (qs *QueueStore) RetryQueueJob(ctx context.Context, id int32, reason string, runAt time.Time) error {
+	return qs.store.Impl().RetryQueueJob(ctx, id, reason, runAt)
+}
+
+func (qs *QueueStore) FailQueueJob(ctx context.Context, id int32, reason string) error {
+	return qs.store.Impl().FailQueueJob(ctx, id, reason)
+}
@@ -0,0 +1,494 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+type regionalStore struct {
+	store *TimeSeriesDB
+	sink  *bufferedSink
+}
+
+// RegionalTimeSeries keeps time-series data of properties with a data region in the ClickHouse cluster of that region
+// (data residency). Writes are split by the region of the record and regional records never reach the primary store
+// (or its secondary sinks). Reads are merged from all clusters, so stats stay correct after property region changes.
+type RegionalTimeSeries struct {
+	primary common.TimeSeriesStore
+	regions map[string]*regionalStore
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+var _ common.TimeSeriesStore = (*RegionalTimeSeries)(nil)
+
+func NewRegionalTimeSeries(primary common.TimeSeriesStore, regions map[string]*sql.DB) *RegionalTimeSeries {
+	ts := &RegionalTimeSeries{
+		primary: primary,
+		regions: make(map[string]*regionalStore, len(regions)),
+	}
+
+	var ctx context.Context
+	ctx, ts.cancel = context.WithCancel(common.TraceContext(context.Background(), "timeseries_regions"))
+
+	for region, clickhouse := range regions {
+		store := NewTimeSeries(clickhouse)
+		store.name = "clickhouse_" + region
+
+		rs := &regionalStore{store: store, sink: newBufferedSink(store)}
+		ts.regions[region] = rs
+
+		ts.wg.Add(1)
+		go func() {
+			defer ts.wg.Done()
+			rs.sink.run(ctx)
+		}()
+	}
+
+	return ts
+}
+
+func (ts *RegionalTimeSeries) UpdateConfig(maintenanceMode bool) {
+	for _, rs := range ts.regions {
+		rs.store.UpdateConfig(maintenanceMode)
+	}
+}
+
+func (ts *RegionalTimeSeries) Shutdown() {
+	slog.Debug("Shutting down regional time series", "count", len(ts.regions))
+	ts.cancel()
+	ts.wg.Wait()
+}
+
+// splitByRegion returns records without data region (that go to primary store) and the rest of records by region
+func splitByRegion[T any](records []T, region func(T) string) ([]T, map[string][]T) {
+	regional := make(map[string][]T)
+	primary := make([]T, 0, len(records))
+
+	for _, r := range records {
+		if name := region(r); len(name) > 0 {
+			regional[name] = append(regional[name], r)
+		} else {
+			primary = append(primary, r)
+		}
+	}
+
+	return primary, regional
+}
+
+func (ts *RegionalTimeSeries) enqueue(ctx context.Context, region string, batch *sinkBatch) {
+	rs, ok := ts.regions[region]
+	if !ok {
+		// falling back to primary cluster would defeat the purpose of data residency
+		slog.ErrorContext(ctx, "Dropping records of unknown data region", "region", region, "access", len(batch.access),
+			"verify", len(batch.verify))
+		return
+	}
+
+	rs.sink.enqueue(ctx, batch)
+}
+
+func (ts *RegionalTimeSeries) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	primary, regional := splitByRegion(records, func(r *common.AccessRecord) string { return r.Region })
+
+	// primary is written first because failed batch is retried by the caller as a whole
+	if len(primary) > 0 {
+		if err := ts.primary.WriteAccessLogBatch(ctx, primary); err != nil {
+			return err
+		}
+	}
+
+	for region, batch := range regional {
+		ts.enqueue(ctx, region, &sinkBatch{access: batch})
+	}
+
+	return nil
+}
+
+func (ts *RegionalTimeSeries) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	primary, regional := splitByRegion(records, func(r *common.VerifyRecord) string { return r.Region })
+
+	if len(primary) > 0 {
+		if err := ts.primary.WriteVerifyLogBatch(ctx, primary); err != nil {
+			return err
+		}
+	}
+
+	for region, batch := range regional {
+		ts.enqueue(ctx, region, &sinkBatch{verify: batch})
+	}
+
+	return nil
+}
+
+// forEach calls fn for primary and every regional store and stops on the first error
+func (ts *RegionalTimeSeries) forEach(ctx context.Context, fn func(store common.TimeSeriesStore) error) error {
+	if err := fn(ts.primary); err != nil {
+		return err
+	}
+
+	for region, rs := range ts.regions {
+		if err := fn(rs.store); err != nil {
+			slog.ErrorContext(ctx, "Failed to access regional time series", "region", region, common.ErrAttr(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forAll calls fn for primary and every regional store regardless of errors (e.g. data must be deleted everywhere)
+func (ts *RegionalTimeSeries) forAll(ctx context.Context, fn func(store common.TimeSeriesStore) error) error {
+	errs := []error{fn(ts.primary)}
+
+	for region, rs := range ts.regions {
+		if err := fn(rs.store); err != nil {
+			slog.ErrorContext(ctx, "Failed to access regional time series", "region", region, common.ErrAttr(err))
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func readAllRegions[T any](ctx context.Context, ts *RegionalTimeSeries, read func(store common.TimeSeriesStore) ([]T, error)) ([]T, error) {
+	var result []T
+
+	err := ts.forEach(ctx, func(store common.TimeSeriesStore) error {
+		items, err := read(store)
+		if err == nil {
+			result = append(result, items...)
+		}
+		return err
+	})
+
+	return result, err
+}
+
+func mergeTimeCounts(counts []*common.TimeCount) []*common.TimeCount {
+	merged := make(map[int64]*common.TimeCount)
+	result := make([]*common.TimeCount, 0, len(counts))
+
+	for _, c := range counts {
+		if m, ok := merged[c.Timestamp.Unix()]; ok {
+			m.Count += c.Count
+		} else {
+			merged[c.Timestamp.Unix()] = c
+			result = append(result, c)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result
+}
+
+func mergeTimePeriodStats(stats []*common.TimePeriodStat) []*common.TimePeriodStat {
+	merged := make(map[int64]*common.TimePeriodStat)
+	result := make([]*common.TimePeriodStat, 0, len(stats))
+
+	for _, s := range stats {
+		if m, ok := merged[s.Timestamp.Unix()]; ok {
+			m.RequestsCount += s.RequestsCount
+			m.VerifiesCount += s.VerifiesCount
+			m.DatacenterCount += s.DatacenterCount
+		} else {
+			merged[s.Timestamp.Unix()] = s
+			result = append(result, s)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result
+}
+
+func mergeVerifyFailureStats(stats []*common.VerifyFailureStat) []*common.VerifyFailureStat {
+	type key struct {
+		timestamp int64
+		status    uint8
+	}
+
+	merged := make(map[key]*common.VerifyFailureStat)
+	result := make([]*common.VerifyFailureStat, 0, len(stats))
+
+	for _, s := range stats {
+		k := key{timestamp: s.Timestamp.Unix(), status: s.Status}
+		if m, ok := merged[k]; ok {
+			m.Count += s.Count
+		} else {
+			merged[k] = s
+			result = append(result, s)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result
+}
+
+// mergeFingerprintStats adds up distinct counts from different regions, which is exact as long as the property
+// was served by a single region during the period and overestimates otherwise
+func mergeFingerprintStats(stats []*common.FingerprintStat) []*common.FingerprintStat {
+	merged := make(map[int64]*common.FingerprintStat)
+	result := make([]*common.FingerprintStat, 0, len(stats))
+
+	for _, s := range stats {
+		if m, ok := merged[s.Timestamp.Unix()]; ok {
+			m.UniqueCount += s.UniqueCount
+			m.RequestsCount += s.RequestsCount
+		} else {
+			merged[s.Timestamp.Unix()] = s
+			result = append(result, s)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result
+}
+
+func mergeActionStats(stats []*common.ActionStat) []*common.ActionStat {
+	merged := make(map[string]*common.ActionStat)
+	result := make([]*common.ActionStat, 0, len(stats))
+
+	for _, s := range stats {
+		if m, ok := merged[s.Action]; ok {
+			m.Count += s.Count
+			m.SuccessCount += s.SuccessCount
+		} else {
+			merged[s.Action] = s
+			result = append(result, s)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+
+	return result
+}
+
+func mergeUsageCounters(counters []*common.UsageCounter) []*common.UsageCounter {
+	type key struct {
+		userID, orgID, propertyID int32
+	}
+
+	merged := make(map[key]*common.UsageCounter)
+	result := make([]*common.UsageCounter, 0, len(counters))
+
+	for _, c := range counters {
+		k := key{userID: c.UserID, orgID: c.OrgID, propertyID: c.PropertyID}
+		if m, ok := merged[k]; ok {
+			m.Requests += c.Requests
+			m.VerifySuccess += c.VerifySuccess
+			m.VerifyFailure += c.VerifyFailure
+		} else {
+			merged[k] = c
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+func (ts *RegionalTimeSeries) Ping(ctx context.Context) error {
+	return ts.forAll(ctx, func(store common.TimeSeriesStore) error {
+		return store.Ping(ctx)
+	})
+}
+
+func (ts *RegionalTimeSeries) ReadPropertyStats(ctx context.Context, r *common.BackfillRequest, from time.Time) ([]*common.TimeCount, error) {
+	counts, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.TimeCount, error) {
+		return store.ReadPropertyStats(ctx, r, from)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeTimeCounts(counts), nil
+}
+
+func (ts *RegionalTimeSeries) ReadAccountStats(ctx context.Context, userID int32, from time.Time, tz *time.Location) ([]*common.TimeCount, error) {
+	counts, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.TimeCount, error) {
+		return store.ReadAccountStats(ctx, userID, from, tz)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeTimeCounts(counts), nil
+}
+
+func (ts *RegionalTimeSeries) RetrievePropertyStats(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) ([]*common.TimePeriodStat, error) {
+	stats, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.TimePeriodStat, error) {
+		return store.RetrievePropertyStats(ctx, orgID, propertyID, period, tz)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeTimePeriodStats(stats), nil
+}
+
+func (ts *RegionalTimeSeries) RetrievePropertyFailures(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) ([]*common.VerifyFailureStat, error) {
+	stats, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.VerifyFailureStat, error) {
+		return store.RetrievePropertyFailures(ctx, orgID, propertyID, period, tz)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeVerifyFailureStats(stats), nil
+}
+
+func (ts *RegionalTimeSeries) RetrievePropertyActions(ctx context.Context, orgID, propertyID int32, from time.Time) ([]*common.ActionStat, error) {
+	stats, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.ActionStat, error) {
+		return store.RetrievePropertyActions(ctx, orgID, propertyID, from)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeActionStats(stats), nil
+}
+
+func (ts *RegionalTimeSeries) RetrievePropertyFingerprints(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) (*common.FingerprintStats, error) {
+	var buckets []*common.FingerprintStat
+	var total *common.FingerprintStat
+
+	err := ts.forEach(ctx, func(store common.TimeSeriesStore) error {
+		stats, err := store.RetrievePropertyFingerprints(ctx, orgID, propertyID, period, tz)
+		if err == nil {
+			buckets = append(buckets, stats.Buckets...)
+			if total == nil {
+				total = stats.Total
+			} else {
+				total.UniqueCount += stats.Total.UniqueCount
+				total.RequestsCount += stats.Total.RequestsCount
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if total == nil {
+		total = &common.FingerprintStat{}
+	}
+
+	return &common.FingerprintStats{Buckets: mergeFingerprintStats(buckets), Total: total}, nil
+}
+
+func (ts *RegionalTimeSeries) ReadUsageCounters(ctx context.Context, from time.Time) ([]*common.UsageCounter, error) {
+	counters, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.UsageCounter, error) {
+		return store.ReadUsageCounters(ctx, from)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeUsageCounters(counters), nil
+}
+
+func (ts *RegionalTimeSeries) ReadDailyUsageCounters(ctx context.Context, day time.Time) ([]*common.UsageCounter, error) {
+	counters, err := readAllRegions(ctx, ts, func(store common.TimeSeriesStore) ([]*common.UsageCounter, error) {
+		return store.ReadDailyUsageCounters(ctx, day)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeUsageCounters(counters), nil
+}
+
+func (ts *RegionalTimeSeries) RetrievePropertiesTotals(ctx context.Context, orgID int32, propertyIDs []int32, from time.Time) (*common.TimePeriodStat, error) {
+	result := &common.TimePeriodStat{Timestamp: from}
+
+	err := ts.forEach(ctx, func(store common.TimeSeriesStore) error {
+		totals, err := store.RetrievePropertiesTotals(ctx, orgID, propertyIDs, from)
+		if err == nil {
+			result.RequestsCount += totals.RequestsCount
+			result.VerifiesCount += totals.VerifiesCount
+			result.DatacenterCount += totals.DatacenterCount
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (ts *RegionalTimeSeries) RetrievePropertiesActivity(ctx context.Context, orgID int32, propertyIDs []int32) (map[int32]*common.PropertyActivity, error) {
+	result := make(map[int32]*common.PropertyActivity)
+
+	err := ts.forEach(ctx, func(store common.TimeSeriesStore) error {
+		activities, err := store.RetrievePropertiesActivity(ctx, orgID, propertyIDs)
+		for propertyID, a := range activities {
+			if existing, ok := result[propertyID]; ok {
+				existing.FirstRequest = earliestTime(existing.FirstRequest, a.FirstRequest)
+				existing.FirstVerify = earliestTime(existing.FirstVerify, a.FirstVerify)
+			} else {
+				result[propertyID] = a
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// earliestTime returns the earliest of non-zero times
+func earliestTime(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+
+	return a
+}
+
+func (ts *RegionalTimeSeries) ReadOrgsMonthlyUsage(ctx context.Context, orgIDs []int32, month time.Time) (map[int32]uint64, error) {
+	result := make(map[int32]uint64, len(orgIDs))
+
+	err := ts.forEach(ctx, func(store common.TimeSeriesStore) error {
+		usage, err := store.ReadOrgsMonthlyUsage(ctx, orgIDs, month)
+		for orgID, count := range usage {
+			result[orgID] += count
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (ts *RegionalTimeSeries) DeletePropertiesData(ctx context.Context, propertyIDs []int32) error {
+	return ts.forAll(ctx, func(store common.TimeSeriesStore) error {
+		return store.DeletePropertiesData(ctx, propertyIDs)
+	})
+}
+
+func (ts *RegionalTimeSeries) DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error {
+	return ts.forAll(ctx, func(store common.TimeSeriesStore) error {
+		return store.DeleteOrganizationsData(ctx, orgIDs)
+	})
+}
+
+func (ts *RegionalTimeSeries) DeleteUsersData(ctx context.Context, userIDs []int32) error {
+	return ts.forAll(ctx, func(store common.TimeSeriesStore) error {
+		return store.DeleteUsersData(ctx, userIDs)
+	})
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+// stubPrimary only implements writes, other methods of the store are not used in tests
+type stubPrimary struct {
+	common.TimeSeriesStore
+	access []*common.AccessRecord
+	verify []*common.VerifyRecord
+}
+
+func (s *stubPrimary) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	s.access = append(s.access, records...)
+	return nil
+}
+
+func (s *stubPrimary) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	s.verify = append(s.verify, records...)
+	return nil
+}
+
+func TestRegionalWritesSplit(t *testing.T) {
+	primary := &stubPrimary{}
+	eu := &stubSink{name: "eu"}
+
+	ts := &RegionalTimeSeries{
+		primary: primary,
+		regions: map[string]*regionalStore{"eu": {sink: newBufferedSink(eu)}},
+	}
+
+	ctx := context.TODO()
+
+	if err := ts.WriteAccessLogBatch(ctx, []*common.AccessRecord{
+		{PropertyID: 1},
+		{PropertyID: 2, Region: "eu"},
+		{PropertyID: 3, Region: "eu"},
+		// unknown region is dropped instead of written to primary
+		{PropertyID: 4, Region: "us"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ts.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{{PropertyID: 2, Region: "eu"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(primary.access) != 1) || (primary.access[0].PropertyID != 1) || (len(primary.verify) != 0) {
+		t.Errorf("Unexpected records in primary: access=%v verify=%v", len(primary.access), len(primary.verify))
+	}
+
+	sink := ts.regions["eu"].sink
+	for len(sink.queue) > 0 {
+		sink.write(ctx, <-sink.queue, 1 /*attempts*/)
+	}
+
+	if (eu.access.Load() != 2) || (eu.verify.Load() != 1) {
+		t.Errorf("Unexpected records in region: access=%v verify=%v", eu.access.Load(), eu.verify.Load())
+	}
+}
+
+func TestMergeRegionalStats(t *testing.T) {
+	t.Parallel()
+
+	t1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(1 * time.Hour)
+
+	counts := mergeTimeCounts([]*common.TimeCount{
+		{Timestamp: t1, Count: 1},
+		{Timestamp: t2, Count: 2},
+		{Timestamp: t2, Count: 3},
+		{Timestamp: t1, Count: 4},
+	})
+
+	if (len(counts) != 2) || (counts[0].Count != 5) || (counts[1].Count != 5) || !counts[0].Timestamp.Equal(t1) {
+		t.Errorf("Unexpected merged time counts: %v", counts)
+	}
+
+	counters := mergeUsageCounters([]*common.UsageCounter{
+		{UserID: 1, OrgID: 1, PropertyID: 1, Requests: 10, VerifySuccess: 1},
+		{UserID: 1, OrgID: 1, PropertyID: 2, Requests: 20},
+		{UserID: 1, OrgID: 1, PropertyID: 1, Requests: 5, VerifyFailure: 2},
+	})
+
+	if (len(counters) != 2) || (counters[0].Requests != 15) || (counters[0].VerifySuccess != 1) || (counters[0].VerifyFailure != 2) {
+		t.Errorf("Unexpected merged usage counters: %v", counters)
+	}
+
+	failures := mergeVerifyFailureStats([]*common.VerifyFailureStat{
+		{Timestamp: t1, Status: 1, Count: 1},
+		{Timestamp: t1, Status: 2, Count: 1},
+		{Timestamp: t1, Status: 1, Count: 3},
+	})
+
+	if (len(failures) != 2) || (failures[0].Count != 4) {
+		t.Errorf("Unexpected merged failures: %v", failures)
+	}
+
+	fingerprints := mergeFingerprintStats([]*common.FingerprintStat{
+		{Timestamp: t2, UniqueCount: 1, RequestsCount: 3},
+		{Timestamp: t1, UniqueCount: 2, RequestsCount: 2},
+		{Timestamp: t2, UniqueCount: 4, RequestsCount: 5},
+	})
+
+	if (len(fingerprints) != 2) || !fingerprints[0].Timestamp.Equal(t1) || (fingerprints[1].UniqueCount != 5) ||
+		(fingerprints[1].RequestsCount != 8) {
+		t.Errorf("Unexpected merged fingerprints: %v", fingerprints)
+	}
+
+	actions := mergeActionStats([]*common.ActionStat{
+		{Action: "login", Count: 2, SuccessCount: 1},
+		{Action: "signup", Count: 5, SuccessCount: 5},
+		{Action: "login", Count: 4, SuccessCount: 4},
+	})
+
+	if (len(actions) != 2) || (actions[0].Action != "login") || (actions[0].Count != 6) || (actions[0].SuccessCount != 5) {
+		t.Errorf("Unexpected merged actions: %v", actions)
+	}
+}
@@ -28,7 +28,7 @@ type SessionStore struct {
 
 func NewSessionStore(pool *pgxpool.Pool, fallback common.SessionStore, interval time.Duration, persistKey common.SessionKey) *SessionStore {
 	store := &SessionStore{
-		db:          dbgen.New(pool),
+		db:          dbgen.New(&chaosDBTX{db: pool}),
 		fallback:    fallback,
 		persistChan: make(chan string, sessionBatchSize),
 		batchSize:   sessionBatchSize,
@@ -38,7 +38,7 @@ func NewSessionStore(pool *pgxpool.Pool, fallback common.SessionStore, interval
 	var cancelCtx context.Context
 	cancelCtx, store.processCancel = context.WithCancel(
 		context.WithValue(context.Background(), common.TraceIDContextKey, "persist_session"))
-	go common.ProcessBatchMap(cancelCtx, store.persistChan, interval, store.batchSize, store.batchSize*100, store.persistSessions)
+	go common.ProcessBatchMap(cancelCtx, common.BatchPipelineSessionPersist, nil /*metrics*/, store.persistChan, interval, store.batchSize, store.batchSize*100, store.persistSessions)
 
 	return store
 }
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	// number of pending batches per sink, after which we start dropping new batches for this sink
+	sinkQueueSize     = 100
+	sinkMaxAttempts   = 3
+	sinkRetryInterval = 2 * time.Second
+	sinkWriteTimeout  = 30 * time.Second
+	sinkDrainTimeout  = 10 * time.Second
+)
+
+type sinkBatch struct {
+	access []*common.AccessRecord
+	verify []*common.VerifyRecord
+}
+
+// bufferedSink writes batches to the sink in a separate goroutine, so that slow or unavailable sink
+// does not block writers (and other sinks)
+type bufferedSink struct {
+	sink    common.TimeSeriesSink
+	queue   chan *sinkBatch
+	dropped atomic.Int64
+}
+
+func newBufferedSink(sink common.TimeSeriesSink) *bufferedSink {
+	return &bufferedSink{
+		sink:  sink,
+		queue: make(chan *sinkBatch, sinkQueueSize),
+	}
+}
+
+func (bs *bufferedSink) enqueue(ctx context.Context, batch *sinkBatch) {
+	select {
+	case bs.queue <- batch:
+	default:
+		dropped := bs.dropped.Add(1)
+		slog.ErrorContext(ctx, "Dropping batch for overloaded time series sink", "sink", bs.sink.Name(),
+			"access", len(batch.access), "verify", len(batch.verify), "dropped", dropped)
+	}
+}
+
+func (bs *bufferedSink) writeOnce(ctx context.Context, batch *sinkBatch) error {
+	ctx, cancel := context.WithTimeout(ctx, sinkWriteTimeout)
+	defer cancel()
+
+	if len(batch.access) > 0 {
+		if err := bs.sink.WriteAccessLogBatch(ctx, batch.access); err != nil {
+			return err
+		}
+	}
+
+	if len(batch.verify) > 0 {
+		if err := bs.sink.WriteVerifyLogBatch(ctx, batch.verify); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bs *bufferedSink) write(ctx context.Context, batch *sinkBatch, attempts int) {
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err := bs.writeOnce(ctx, batch)
+		if err == nil {
+			return
+		}
+
+		slog.WarnContext(ctx, "Failed to write batch to time series sink", "sink", bs.sink.Name(), "attempt", attempt,
+			common.ErrAttr(err))
+
+		if attempt < attempts {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(attempt) * sinkRetryInterval):
+			}
+		}
+	}
+
+	slog.ErrorContext(ctx, "Dropping batch after failed attempts", "sink", bs.sink.Name(), "attempts", attempts,
+		"access", len(batch.access), "verify", len(batch.verify))
+}
+
+func (bs *bufferedSink) run(ctx context.Context) {
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case batch := <-bs.queue:
+			bs.write(ctx, batch, sinkMaxAttempts)
+		}
+	}
+
+	// best-effort flush of what's left in the queue on shutdown
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sinkDrainTimeout)
+	defer cancel()
+
+	for len(bs.queue) > 0 && drainCtx.Err() == nil {
+		bs.write(drainCtx, <-bs.queue, 1 /*attempts*/)
+	}
+
+	slog.DebugContext(ctx, "Finished writing to time series sink", "sink", bs.sink.Name())
+}
+
+// FanOutTimeSeries reads from the primary ClickHouse, but writes access and verify logs to all configured sinks
+// (primary included). Every sink has it's own queue and writer so they are isolated from each other's failures.
+type FanOutTimeSeries struct {
+	*TimeSeriesDB
+	sinks  []*bufferedSink
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ common.TimeSeriesStore = (*FanOutTimeSeries)(nil)
+
+func NewFanOutTimeSeries(primary *TimeSeriesDB, secondary ...common.TimeSeriesSink) *FanOutTimeSeries {
+	ts := &FanOutTimeSeries{
+		TimeSeriesDB: primary,
+		sinks:        make([]*bufferedSink, 0, len(secondary)+1),
+	}
+
+	ts.sinks = append(ts.sinks, newBufferedSink(primary))
+	for _, s := range secondary {
+		ts.sinks = append(ts.sinks, newBufferedSink(s))
+	}
+
+	var ctx context.Context
+	ctx, ts.cancel = context.WithCancel(common.TraceContext(context.Background(), "timeseries_sinks"))
+
+	for _, s := range ts.sinks {
+		ts.wg.Add(1)
+		go func(s *bufferedSink) {
+			defer ts.wg.Done()
+			s.run(ctx)
+		}(s)
+	}
+
+	return ts
+}
+
+func (ts *FanOutTimeSeries) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	batch := &sinkBatch{access: records}
+	for _, s := range ts.sinks {
+		s.enqueue(ctx, batch)
+	}
+
+	return nil
+}
+
+func (ts *FanOutTimeSeries) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	batch := &sinkBatch{verify: records}
+	for _, s := range ts.sinks {
+		s.enqueue(ctx, batch)
+	}
+
+	return nil
+}
+
+func (ts *FanOutTimeSeries) Shutdown() {
+	slog.Debug("Shutting down time series sinks", "count", len(ts.sinks))
+	ts.cancel()
+	ts.wg.Wait()
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+type stubSink struct {
+	name    string
+	access  atomic.Int32
+	verify  atomic.Int32
+	release chan struct{}
+}
+
+func (s *stubSink) Name() string { return s.name }
+
+func (s *stubSink) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.access.Add(int32(len(records)))
+	return nil
+}
+
+func (s *stubSink) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.verify.Add(int32(len(records)))
+	return nil
+}
+
+func TestFanOutSlowSinkIsolation(t *testing.T) {
+	slow := &stubSink{name: "slow", release: make(chan struct{})}
+	fast := &stubSink{name: "fast"}
+
+	// primary is only used for reads in this test
+	ts := &FanOutTimeSeries{TimeSeriesDB: NewTimeSeries(nil)}
+	ts.sinks = []*bufferedSink{newBufferedSink(slow), newBufferedSink(fast)}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	ts.cancel = cancel
+	for _, s := range ts.sinks {
+		ts.wg.Add(1)
+		go func(s *bufferedSink) {
+			defer ts.wg.Done()
+			s.run(ctx)
+		}(s)
+	}
+
+	const batches = 2 * sinkQueueSize
+	for i := 0; i < batches; i++ {
+		start := time.Now()
+		_ = ts.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{{UserID: 1}})
+		if time.Since(start) > 100*time.Millisecond {
+			t.Fatal("Writes were blocked by the slow sink")
+		}
+
+		// let the fast sink keep up so that only the slow one overflows
+		for j := 0; (j < 100) && (fast.verify.Load() != int32(i+1)); j++ {
+			time.Sleep(1 * time.Millisecond)
+		}
+	}
+
+	if count := fast.verify.Load(); count != batches {
+		t.Errorf("Unexpected number of records in fast sink: %v", count)
+	}
+
+	if dropped := ts.sinks[0].dropped.Load(); dropped == 0 {
+		t.Error("Slow sink did not drop any batches")
+	}
+
+	close(slow.release)
+	ts.Shutdown()
+}
+
+func TestKafkaSink(t *testing.T) {
+	var received struct {
+		Records []struct {
+			Key   string           `json:"key"`
+			Value kafkaVerifyValue `json:"value"`
+		} `json:"records"`
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.URL.Path != "/topics/captcha-logs") || (r.Header.Get(common.HeaderContentType) != kafkaContentType) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer srv.Close()
+
+	cfg := config.NewBaseConfig(config.NewEnvConfig(config.DefaultMapper, func(string) string { return "" }))
+	cfg.Add(config.NewStaticValue(common.KafkaRESTURLKey, srv.URL+"/"))
+	cfg.Add(config.NewStaticValue(common.KafkaTopicKey, "captcha-logs"))
+
+	sink := NewKafkaSink(cfg)
+	if sink == nil {
+		t.Fatal("Kafka sink is not configured")
+	}
+
+	err := sink.WriteVerifyLogBatch(context.TODO(), []*common.VerifyRecord{
+		{UserID: 1, OrgID: 2, PropertyID: 3, PuzzleID: 4, Status: 5, Timestamp: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received.Records) != 1 {
+		t.Fatalf("Unexpected number of records: %v", len(received.Records))
+	}
+
+	if r := received.Records[0]; (r.Key != "3") || (r.Value.Type != "verify") || (r.Value.PuzzleID != 4) {
+		t.Errorf("Unexpected record: %+v", r)
+	}
+}
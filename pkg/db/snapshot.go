@@ -0,0 +1,144 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// properties that were not fetched from Postgres for this long (no traffic) are dropped from the snapshot
+	propertySnapshotRetention = 24 * time.Hour
+	// properties served from snapshot are cached only briefly so that we go back to Postgres as soon as it recovers
+	propertySnapshotCacheTTL = 1 * time.Minute
+	maxPropertySnapshotSize  = 1_000_000
+)
+
+type snapshotEntry struct {
+	property *dbgen.Property
+	seenAt   time.Time
+}
+
+// propertySnapshot keeps properties recently served by this node much longer than the cache does, so that during
+// a short Postgres outage (or maintenance) puzzles are still issued and verified against the real property salt.
+// All methods are safe to call on a nil snapshot
+type propertySnapshot struct {
+	lock    sync.RWMutex
+	entries map[string]*snapshotEntry
+	maxSize int
+}
+
+func newPropertySnapshot(maxSize int) *propertySnapshot {
+	return &propertySnapshot{
+		entries: make(map[string]*snapshotEntry),
+		maxSize: maxSize,
+	}
+}
+
+// add records properties that were just fetched from Postgres (i.e. they are in use)
+func (s *propertySnapshot) add(properties []*dbgen.Property, tnow time.Time) {
+	if (s == nil) || (len(properties) == 0) {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, p := range properties {
+		sitekey := UUIDToSiteKey(p.ExternalID)
+		if e, ok := s.entries[sitekey]; ok {
+			e.property = p
+			e.seenAt = tnow
+			continue
+		}
+
+		if len(s.entries) >= s.maxSize {
+			continue
+		}
+
+		s.entries[sitekey] = &snapshotEntry{property: p, seenAt: tnow}
+	}
+}
+
+func (s *propertySnapshot) get(sitekey string) (*dbgen.Property, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if e, ok := s.entries[sitekey]; ok {
+		return e.property, true
+	}
+
+	return nil, false
+}
+
+// evict drops properties that were not seen since {before} and returns external IDs of the remaining ones
+func (s *propertySnapshot) evict(before time.Time) ([]pgtype.UUID, int) {
+	if s == nil {
+		return nil, 0
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	evicted := 0
+	result := make([]pgtype.UUID, 0, len(s.entries))
+
+	for sitekey, e := range s.entries {
+		if e.seenAt.Before(before) {
+			delete(s.entries, sitekey)
+			evicted++
+			continue
+		}
+
+		result = append(result, e.property.ExternalID)
+	}
+
+	return result, evicted
+}
+
+// refresh replaces snapshotted properties with their current versions from Postgres. Properties that were
+// requested, but not returned, do not exist anymore. Unlike add(), it does not count as the property being seen
+func (s *propertySnapshot) refresh(requested []pgtype.UUID, properties []*dbgen.Property) {
+	if s == nil {
+		return
+	}
+
+	found := make(map[string]*dbgen.Property, len(properties))
+	for _, p := range properties {
+		found[UUIDToSiteKey(p.ExternalID)] = p
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, eid := range requested {
+		sitekey := UUIDToSiteKey(eid)
+		e, ok := s.entries[sitekey]
+		if !ok {
+			continue
+		}
+
+		if p, ok := found[sitekey]; ok {
+			e.property = p
+		} else {
+			delete(s.entries, sitekey)
+		}
+	}
+}
+
+func (s *propertySnapshot) size() int {
+	if s == nil {
+		return 0
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.entries)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func snapshotTestProperty(id int32, b byte) *dbgen.Property {
+	return &dbgen.Property{
+		ID:         id,
+		ExternalID: pgtype.UUID{Valid: true, Bytes: [16]byte{b, b, b, b, b, b, b, b, b, b, b, b, b, b, b, b}},
+		Salt:       []byte{b},
+	}
+}
+
+func TestPropertySnapshotEviction(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC()
+	snapshot := newPropertySnapshot(2)
+	p1, p2, p3 := snapshotTestProperty(1, 1), snapshotTestProperty(2, 2), snapshotTestProperty(3, 3)
+
+	snapshot.add([]*dbgen.Property{p1}, tnow.Add(-2*propertySnapshotRetention))
+	snapshot.add([]*dbgen.Property{p2, p3}, tnow)
+
+	if size := snapshot.size(); size != 2 {
+		t.Fatalf("Unexpected snapshot size: %v", size)
+	}
+
+	keys, evicted := snapshot.evict(tnow.Add(-propertySnapshotRetention))
+	if (evicted != 1) || (len(keys) != 1) || (keys[0] != p2.ExternalID) {
+		t.Fatalf("Unexpected eviction result: %v (evicted %v)", keys, evicted)
+	}
+
+	// p2 is deleted in Postgres, p3 did not fit in the first place
+	snapshot.add([]*dbgen.Property{p3}, tnow)
+	snapshot.refresh([]pgtype.UUID{p2.ExternalID}, []*dbgen.Property{})
+
+	if _, ok := snapshot.get(UUIDToSiteKey(p2.ExternalID)); ok {
+		t.Error("Deleted property was not removed from snapshot")
+	}
+
+	if p, ok := snapshot.get(UUIDToSiteKey(p3.ExternalID)); !ok || (p.ID != p3.ID) {
+		t.Error("Property was not added to snapshot")
+	}
+}
+
+func TestRetrievePropertiesFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	snapshot := newPropertySnapshot(maxPropertySnapshotSize)
+	impl := &BusinessStoreImpl{cache: NewBusinessCache(100, 1<<20 /*max bytes*/), ttl: DefaultCacheTTL, snapshot: snapshot}
+	p1, p2 := snapshotTestProperty(1, 1), snapshotTestProperty(2, 2)
+	sitekey1, sitekey2 := UUIDToSiteKey(p1.ExternalID), UUIDToSiteKey(p2.ExternalID)
+
+	snapshot.add([]*dbgen.Property{p1}, time.Now().UTC())
+
+	properties, err := impl.RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey1: {}})
+	if (err != nil) || (len(properties) != 1) || (string(properties[0].Salt) != string(p1.Salt)) {
+		t.Fatalf("Failed to retrieve property from snapshot: %v", err)
+	}
+
+	if _, err := impl.GetCachedPropertyBySitekey(ctx, sitekey1); err != nil {
+		t.Errorf("Property from snapshot was not cached: %v", err)
+	}
+
+	if _, err := impl.RetrievePropertiesBySitekey(ctx, map[string]struct{}{sitekey2: {}}); err != ErrMaintenance {
+		t.Errorf("Unexpected error for property missing from snapshot: %v", err)
+	}
+}
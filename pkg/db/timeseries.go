@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
-	"strings"
 	"sync/atomic"
 	"text/template"
 	"time"
@@ -25,24 +24,22 @@ const (
 	AccessLogTableName1h  = "privatecaptcha.request_logs_1h"
 	AccessLogTableName1d  = "privatecaptcha.request_logs_1d"
 	AccessLogTableName1mo = "privatecaptcha.request_logs_1mo"
+	VerifyFailuresTable1h = "privatecaptcha.verify_failures_1h"
+	VerifyFailuresTable1d = "privatecaptcha.verify_failures_1d"
+	VerifyActionsTable1d  = "privatecaptcha.verify_actions_1d"
+	FingerprintsTable1h   = "privatecaptcha.request_fingerprints_1h"
+	FingerprintsTable1d   = "privatecaptcha.request_fingerprints_1d"
 )
 
 type TimeSeriesDB struct {
 	Clickhouse         *sql.DB
 	statsQueryTemplate *template.Template
 	maintenanceMode    atomic.Bool
+	name               string
 }
 
 var _ common.TimeSeriesStore = (*TimeSeriesDB)(nil)
-
-func idsToString(ids []int32) string {
-	idStrings := make([]string, len(ids))
-	for i, id := range ids {
-		idStrings[i] = fmt.Sprintf("%d", id)
-	}
-	idsStr := strings.Join(idStrings, ",")
-	return idsStr
-}
+var _ common.TimeSeriesSink = (*TimeSeriesDB)(nil)
 
 func NewTimeSeries(clickhouse *sql.DB) *TimeSeriesDB {
 	// ClickHouse docs:
@@ -50,8 +47,9 @@ func NewTimeSeries(clickhouse *sql.DB) *TimeSeriesDB {
 	const statsQuery = `WITH requests AS
 (
 SELECT
-toDateTime({{.TimeFuncRequests}}) AS agg_time,
-sum(count) AS count
+toDateTime({{.TimeFuncRequests}}, {tz:String}) AS agg_time,
+sum(count) AS count,
+sum(datacenter_count) AS datacenter_count
 FROM {{.RequestsTable}} FINAL
 WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
 GROUP BY agg_time
@@ -59,7 +57,7 @@ ORDER BY agg_time
 ),
 verifies AS (
 SELECT
-toDateTime({{.TimeFuncVerifies}}) AS agg_time,
+toDateTime({{.TimeFuncVerifies}}, {tz:String}) AS agg_time,
 sum(success_count) AS count
 FROM {{.VerifiesTable}} FINAL
 WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
@@ -69,19 +67,25 @@ ORDER BY agg_time
 SELECT
 requests.agg_time AS agg_time,
 sum(requests.count) AS requests_count,
-sum(verifies.count) AS verifies_count
+sum(verifies.count) AS verifies_count,
+sum(requests.datacenter_count) AS datacenter_count
 FROM requests
 LEFT OUTER JOIN verifies ON verifies.agg_time = requests.agg_time
 GROUP BY agg_time
-ORDER BY agg_time WITH FILL FROM toDateTime({{.FillFrom}}) TO now() STEP {{.Interval}}
+ORDER BY agg_time WITH FILL FROM toDateTime({{.FillFrom}}, {tz:String}) TO now() STEP {{.Interval}}
 SETTINGS use_query_cache = true, query_cache_nondeterministic_function_handling = 'save'`
 
 	return &TimeSeriesDB{
 		statsQueryTemplate: template.Must(template.New("stats").Parse(statsQuery)),
 		Clickhouse:         clickhouse,
+		name:               "clickhouse",
 	}
 }
 
+func (ts *TimeSeriesDB) Name() string {
+	return ts.name
+}
+
 func (ts *TimeSeriesDB) UpdateConfig(maintenanceMode bool) {
 	ts.maintenanceMode.Store(maintenanceMode)
 }
@@ -134,15 +138,26 @@ func (ts *TimeSeriesDB) WriteAccessLogBatch(ctx context.Context, records []*comm
 		return err
 	}
 
+	// NOTE: request_logs table uses Null engine so raw fingerprints are never stored in ClickHouse as is. However,
+	// uniqCombined states in request_fingerprints_* tables are derived from them (small states keep hashes of
+	// fingerprints) and are retained for 1 day (hourly) and 1 year (daily) per property or until it is deleted.
+	// Properties in privacy or IP-less mode do not contribute to these states.
 	for i, r := range records {
-		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.Fingerprint, r.Timestamp.UTC())
+		var datacenter, ipless uint8
+		if r.Datacenter {
+			datacenter = 1
+		}
+		if r.IPLess {
+			ipless = 1
+		}
+		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.Fingerprint, r.Timestamp.UTC(), datacenter, ipless)
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to exec insert for record", common.ErrAttr(err), "index", i)
 			return err
 		}
 	}
 
-	err = scope.Commit()
+	err = ts.commit(ctx, "WriteAccessLogBatch", scope)
 	if err == nil {
 		slog.DebugContext(ctx, "Inserted batch of access records", "size", len(records))
 	} else {
@@ -175,14 +190,18 @@ func (ts *TimeSeriesDB) WriteVerifyLogBatch(ctx context.Context, records []*comm
 	}
 
 	for i, r := range records {
-		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.PuzzleID, r.Status, r.Timestamp)
+		var sandbox uint8
+		if r.Sandbox {
+			sandbox = 1
+		}
+		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.PuzzleID, r.Status, r.Timestamp, r.Action, sandbox)
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to exec insert for record", common.ErrAttr(err), "index", i)
 			return err
 		}
 	}
 
-	err = scope.Commit()
+	err = ts.commit(ctx, "WriteVerifyLogBatch", scope)
 	if err == nil {
 		slog.DebugContext(ctx, "Inserted batch of verify records", "size", len(records))
 	} else {
@@ -202,7 +221,7 @@ FROM %s FINAL
 WHERE user_id = {user_id:UInt32} AND org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
 GROUP BY timestamp
 ORDER BY timestamp`
-	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, AccessLogTableName5m),
+	rows, err := ts.query(ctx, "ReadPropertyStats", fmt.Sprintf(query, AccessLogTableName5m),
 		clickhouse.Named("user_id", strconv.Itoa(int(r.UserID))),
 		clickhouse.Named("org_id", strconv.Itoa(int(r.OrgID))),
 		clickhouse.Named("property_id", strconv.Itoa(int(r.PropertyID))),
@@ -230,19 +249,40 @@ ORDER BY timestamp`
 	return results, nil
 }
 
-func (ts *TimeSeriesDB) ReadAccountStats(ctx context.Context, userID int32, from time.Time) ([]*common.TimeCount, error) {
+func timeZoneName(tz *time.Location) string {
+	if tz == nil {
+		return time.UTC.String()
+	}
+
+	return tz.String()
+}
+
+// localDayExpr returns the expression that labels pre-aggregated UTC day (or month) of the column as the same
+// calendar date in the timezone. Daily and monthly rollups are done in UTC so they cannot be split on local
+// boundaries, but at least they are shown on the "day" that customers expect.
+func localDayExpr(column string) string {
+	return fmt.Sprintf("toDateTime(toDate(%s), {tz:String})", column)
+}
+
+// localTimeExpr converts the column with precise timestamps to the timezone
+func localTimeExpr(column string) string {
+	return fmt.Sprintf("toTimeZone(%s, {tz:String})", column)
+}
+
+func (ts *TimeSeriesDB) ReadAccountStats(ctx context.Context, userID int32, from time.Time, tz *time.Location) ([]*common.TimeCount, error) {
 	if !ts.IsAvailable() {
 		return nil, ErrMaintenance
 	}
 
-	query := `SELECT timestamp, sum(count) as count
+	query := `SELECT %s AS agg_time, sum(count) as count
 FROM %s FINAL
 WHERE user_id = {user_id:UInt32} AND timestamp >= {timestamp:DateTime}
-GROUP BY timestamp
-ORDER BY timestamp`
-	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, AccessLogTableName1mo),
+GROUP BY agg_time
+ORDER BY agg_time`
+	rows, err := ts.query(ctx, "ReadAccountStats", fmt.Sprintf(query, localDayExpr("timestamp"), AccessLogTableName1mo),
 		clickhouse.Named("user_id", strconv.Itoa(int(userID))),
-		clickhouse.Named("timestamp", from.Format(time.DateTime)))
+		clickhouse.Named("timestamp", from.Format(time.DateTime)),
+		clickhouse.Named("tz", timeZoneName(tz)))
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to execute account stats query", common.ErrAttr(err))
 		return nil, err
@@ -264,43 +304,59 @@ ORDER BY timestamp`
 	return results, nil
 }
 
-func (ts *TimeSeriesDB) RetrievePropertyStats(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodStat, error) {
-	if !ts.IsAvailable() {
-		return nil, ErrMaintenance
-	}
+// periodParams describe how the stats for the time period are bucketed
+type periodParams struct {
+	timeFrom time.Time
+	// "1h" or "1d" rollup tables are used
+	tableSuffix  string
+	timeFunction string
+	interval     string
+	localExpr    func(column string) string
+}
 
-	tnow := time.Now().UTC()
-	var timeFrom time.Time
-	var requestsTable string
-	var verificationsTable string
-	var timeFunction string
-	var interval string
+func newPeriodParams(period common.TimePeriod, tnow time.Time) *periodParams {
+	// hourly tables can be bucketed precisely in any timezone, daily ones only get relabeled
+	pp := &periodParams{tableSuffix: "1d", localExpr: localDayExpr}
 
 	switch period {
 	case common.TimePeriodToday:
-		timeFrom = tnow.AddDate(0, 0, -1)
-		requestsTable = "request_logs_1h"
-		verificationsTable = "verify_logs_1h"
-		timeFunction = "toStartOfHour(%s)"
-		interval = "INTERVAL 1 HOUR"
+		pp.timeFrom = tnow.AddDate(0, 0, -1)
+		pp.tableSuffix = "1h"
+		pp.timeFunction = "toStartOfHour(%s)"
+		pp.interval = "INTERVAL 1 HOUR"
+		pp.localExpr = localTimeExpr
 	case common.TimePeriodWeek:
-		timeFrom = tnow.AddDate(0, 0, -7)
-		requestsTable = "request_logs_1d"
-		verificationsTable = "verify_logs_1d"
-		timeFunction = "toStartOfInterval(%s, INTERVAL 6 HOUR)"
-		interval = "INTERVAL 6 HOUR"
+		pp.timeFrom = tnow.AddDate(0, 0, -7)
+		pp.timeFunction = "toStartOfInterval(%s, INTERVAL 6 HOUR)"
+		pp.interval = "INTERVAL 6 HOUR"
 	case common.TimePeriodMonth:
-		timeFrom = tnow.AddDate(0, -1, 0)
-		requestsTable = "request_logs_1d"
-		verificationsTable = "verify_logs_1d"
-		timeFunction = "toStartOfDay(%s)"
-		interval = "INTERVAL 1 DAY"
+		pp.timeFrom = tnow.AddDate(0, -1, 0)
+		pp.timeFunction = "toStartOfDay(%s)"
+		pp.interval = "INTERVAL 1 DAY"
 	case common.TimePeriodYear:
-		timeFrom = tnow.AddDate(-1, 0, 0)
-		requestsTable = "request_logs_1d"
-		verificationsTable = "verify_logs_1d"
-		timeFunction = "toStartOfMonth(%s)"
-		interval = "INTERVAL 1 MONTH"
+		pp.timeFrom = tnow.AddDate(-1, 0, 0)
+		pp.timeFunction = "toStartOfMonth(%s)"
+		pp.interval = "INTERVAL 1 MONTH"
+	}
+
+	return pp
+}
+
+func (ts *TimeSeriesDB) RetrievePropertyStats(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) ([]*common.TimePeriodStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	pp := newPeriodParams(period, time.Now().UTC())
+
+	requestsTable, err := chIdentifier("request_logs_" + pp.tableSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationsTable, err := chIdentifier("verify_logs_" + pp.tableSuffix)
+	if err != nil {
+		return nil, err
 	}
 
 	data := struct {
@@ -313,10 +369,10 @@ func (ts *TimeSeriesDB) RetrievePropertyStats(ctx context.Context, orgID, proper
 	}{
 		RequestsTable:    "privatecaptcha." + requestsTable,
 		VerifiesTable:    "privatecaptcha." + verificationsTable,
-		TimeFuncRequests: fmt.Sprintf(timeFunction, requestsTable+".timestamp"),
-		TimeFuncVerifies: fmt.Sprintf(timeFunction, verificationsTable+".timestamp"),
-		Interval:         interval,
-		FillFrom:         fmt.Sprintf(timeFunction, "{timestamp:DateTime}"),
+		TimeFuncRequests: fmt.Sprintf(pp.timeFunction, pp.localExpr(requestsTable+".timestamp")),
+		TimeFuncVerifies: fmt.Sprintf(pp.timeFunction, pp.localExpr(verificationsTable+".timestamp")),
+		Interval:         pp.interval,
+		FillFrom:         fmt.Sprintf(pp.timeFunction, localTimeExpr("{timestamp:DateTime}")),
 	}
 
 	buf := &bytes.Buffer{}
@@ -326,10 +382,11 @@ func (ts *TimeSeriesDB) RetrievePropertyStats(ctx context.Context, orgID, proper
 	}
 	query := buf.String()
 
-	rows, err := ts.Clickhouse.Query(query,
+	rows, err := ts.query(ctx, "RetrievePropertyStats", query,
 		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
 		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
-		clickhouse.Named("timestamp", timeFrom.Format(time.DateTime)))
+		clickhouse.Named("timestamp", pp.timeFrom.Format(time.DateTime)),
+		clickhouse.Named("tz", timeZoneName(tz)))
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to query property stats", common.ErrAttr(err))
 		return nil, err
@@ -341,7 +398,7 @@ func (ts *TimeSeriesDB) RetrievePropertyStats(ctx context.Context, orgID, proper
 
 	for rows.Next() {
 		bc := &common.TimePeriodStat{}
-		if err := rows.Scan(&bc.Timestamp, &bc.RequestsCount, &bc.VerifiesCount); err != nil {
+		if err := rows.Scan(&bc.Timestamp, &bc.RequestsCount, &bc.VerifiesCount, &bc.DatacenterCount); err != nil {
 			slog.ErrorContext(ctx, "Failed to read row from property stats query", common.ErrAttr(err))
 			return nil, err
 		}
@@ -351,15 +408,424 @@ func (ts *TimeSeriesDB) RetrievePropertyStats(ctx context.Context, orgID, proper
 	}
 
 	slog.InfoContext(ctx, "Fetched time period stats", "count", len(results), "orgID", orgID, "propID", propertyID,
-		"from", timeFrom, "period", period)
+		"from", pp.timeFrom, "period", period, "tz", timeZoneName(tz))
 
 	return results, nil
 }
 
-func (ts *TimeSeriesDB) lightDelete(ctx context.Context, tables []string, column string, ids string) error {
+// RetrievePropertyFailures returns failed verifications by status in the same buckets as RetrievePropertyStats.
+// Buckets without failures are not returned
+func (ts *TimeSeriesDB) RetrievePropertyFailures(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) ([]*common.VerifyFailureStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	pp := newPeriodParams(period, time.Now().UTC())
+
+	table, err := chIdentifier("verify_failures_" + pp.tableSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT toDateTime(%s, {tz:String}) AS agg_time, status, sum(count) AS count
+FROM privatecaptcha.%s FINAL
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
+GROUP BY agg_time, status
+ORDER BY agg_time, status`
+
+	timeFunc := fmt.Sprintf(pp.timeFunction, pp.localExpr(table+".timestamp"))
+
+	rows, err := ts.query(ctx, "RetrievePropertyFailures", fmt.Sprintf(query, timeFunc, table),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("timestamp", pp.timeFrom.Format(time.DateTime)),
+		clickhouse.Named("tz", timeZoneName(tz)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query property failures", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.VerifyFailureStat, 0)
+
+	for rows.Next() {
+		fs := &common.VerifyFailureStat{}
+		if err := rows.Scan(&fs.Timestamp, &fs.Status, &fs.Count); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from property failures query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, fs)
+	}
+
+	slog.DebugContext(ctx, "Fetched property failures", "count", len(results), "orgID", orgID, "propID", propertyID,
+		"from", pp.timeFrom, "period", period)
+
+	return results, nil
+}
+
+// RetrievePropertyFingerprints returns the number of requests and distinct fingerprints (approximated with
+// uniqCombined) in the same buckets as RetrievePropertyStats and for the whole period
+func (ts *TimeSeriesDB) RetrievePropertyFingerprints(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) (*common.FingerprintStats, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	pp := newPeriodParams(period, time.Now().UTC())
+
+	table, err := chIdentifier("request_fingerprints_" + pp.tableSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("timestamp", pp.timeFrom.Format(time.DateTime)),
+		clickhouse.Named("tz", timeZoneName(tz)),
+	}
+
+	query := `SELECT toDateTime(%s, {tz:String}) AS agg_time, uniqCombinedMerge(fingerprints) AS unique_count, sum(count) AS count
+FROM privatecaptcha.%s
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
+GROUP BY agg_time
+ORDER BY agg_time`
+
+	timeFunc := fmt.Sprintf(pp.timeFunction, pp.localExpr(table+".timestamp"))
+
+	rows, err := ts.query(ctx, "RetrievePropertyFingerprints", fmt.Sprintf(query, timeFunc, table), args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query property fingerprints", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := &common.FingerprintStats{
+		Buckets: make([]*common.FingerprintStat, 0),
+		Total:   &common.FingerprintStat{Timestamp: pp.timeFrom},
+	}
+
+	for rows.Next() {
+		fs := &common.FingerprintStat{}
+		if err := rows.Scan(&fs.Timestamp, &fs.UniqueCount, &fs.RequestsCount); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from property fingerprints query", common.ErrAttr(err))
+			return nil, err
+		}
+		result.Buckets = append(result.Buckets, fs)
+	}
+
+	if len(result.Buckets) == 0 {
+		return result, nil
+	}
+
+	totalsQuery := `SELECT uniqCombinedMerge(fingerprints) AS unique_count, sum(count) AS count
+FROM privatecaptcha.%s
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}`
+
+	totalRows, err := ts.query(ctx, "RetrievePropertyFingerprintsTotal", fmt.Sprintf(totalsQuery, table), args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query property fingerprints total", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer totalRows.Close()
+
+	if totalRows.Next() {
+		if err := totalRows.Scan(&result.Total.UniqueCount, &result.Total.RequestsCount); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from property fingerprints total query", common.ErrAttr(err))
+			return nil, err
+		}
+	}
+
+	slog.DebugContext(ctx, "Fetched property fingerprints", "count", len(result.Buckets), "orgID", orgID, "propID", propertyID,
+		"from", pp.timeFrom, "period", period)
+
+	return result, nil
+}
+
+// RetrievePropertyActions returns verifications of the property since the given time grouped by integrator-defined action.
+// Verifications without action are not returned
+func (ts *TimeSeriesDB) RetrievePropertyActions(ctx context.Context, orgID, propertyID int32, from time.Time) ([]*common.ActionStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT action, sum(count) AS count, sum(success_count) AS success_count
+FROM %s FINAL
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
+GROUP BY action
+ORDER BY count DESC, action`
+
+	rows, err := ts.query(ctx, "RetrievePropertyActions", fmt.Sprintf(query, VerifyActionsTable1d),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("timestamp", from.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query property actions", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.ActionStat, 0)
+
+	for rows.Next() {
+		as := &common.ActionStat{}
+		if err := rows.Scan(&as.Action, &as.Count, &as.SuccessCount); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from property actions query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, as)
+	}
+
+	slog.DebugContext(ctx, "Fetched property actions", "count", len(results), "orgID", orgID, "propID", propertyID, "from", from)
+
+	return results, nil
+}
+
+func (ts *TimeSeriesDB) ReadUsageCounters(ctx context.Context, from time.Time) ([]*common.UsageCounter, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT r.user_id, r.org_id, r.property_id, r.requests, v.success, v.failure
+FROM (
+SELECT user_id, org_id, property_id, sum(count) AS requests
+FROM %s FINAL
+WHERE timestamp >= {timestamp:DateTime}
+GROUP BY user_id, org_id, property_id
+) AS r
+LEFT JOIN (
+SELECT user_id, org_id, property_id, sum(success_count) AS success, sum(failure_count) AS failure
+FROM %s FINAL
+WHERE timestamp >= {timestamp:DateTime}
+GROUP BY user_id, org_id, property_id
+) AS v ON r.user_id = v.user_id AND r.org_id = v.org_id AND r.property_id = v.property_id`
+	rows, err := ts.query(ctx, "ReadUsageCounters", fmt.Sprintf(query, AccessLogTableName1d, VerifyLogTable1d),
+		clickhouse.Named("timestamp", from.Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute usage counters query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.UsageCounter, 0)
+
+	for rows.Next() {
+		uc := &common.UsageCounter{}
+		if err := rows.Scan(&uc.UserID, &uc.OrgID, &uc.PropertyID, &uc.Requests, &uc.VerifySuccess, &uc.VerifyFailure); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from usage counters query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, uc)
+	}
+
+	slog.DebugContext(ctx, "Read usage counters", "count", len(results), "from", from)
+
+	return results, nil
+}
+
+// ReadDailyUsageCounters returns requests and verifications of each property during the (UTC) day that starts at {day}
+func (ts *TimeSeriesDB) ReadDailyUsageCounters(ctx context.Context, day time.Time) ([]*common.UsageCounter, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT r.user_id, r.org_id, r.property_id, r.requests, v.success, v.failure
+FROM (
+SELECT user_id, org_id, property_id, sum(count) AS requests
+FROM %s FINAL
+WHERE timestamp = {timestamp:DateTime}
+GROUP BY user_id, org_id, property_id
+) AS r
+LEFT JOIN (
+SELECT user_id, org_id, property_id, sum(success_count) AS success, sum(failure_count) AS failure
+FROM %s FINAL
+WHERE timestamp = {timestamp:DateTime}
+GROUP BY user_id, org_id, property_id
+) AS v ON r.user_id = v.user_id AND r.org_id = v.org_id AND r.property_id = v.property_id`
+	rows, err := ts.query(ctx, "ReadDailyUsageCounters", fmt.Sprintf(query, AccessLogTableName1d, VerifyLogTable1d),
+		clickhouse.Named("timestamp", day.Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute daily usage counters query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.UsageCounter, 0)
+
+	for rows.Next() {
+		uc := &common.UsageCounter{}
+		if err := rows.Scan(&uc.UserID, &uc.OrgID, &uc.PropertyID, &uc.Requests, &uc.VerifySuccess, &uc.VerifyFailure); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from daily usage counters query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, uc)
+	}
+
+	slog.DebugContext(ctx, "Read daily usage counters", "count", len(results), "day", day)
+
+	return results, nil
+}
+
+// ReadOrgsMonthlyUsage returns requests count of organizations in the month that starts at {month}
+func (ts *TimeSeriesDB) ReadOrgsMonthlyUsage(ctx context.Context, orgIDs []int32, month time.Time) (map[int32]uint64, error) {
+	results := make(map[int32]uint64)
+
+	if len(orgIDs) == 0 {
+		return results, nil
+	}
+
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT org_id, sum(count)
+FROM %s FINAL
+WHERE org_id IN {org_ids:Array(UInt32)} AND timestamp = {timestamp:DateTime}
+GROUP BY org_id`
+	rows, err := ts.query(ctx, "ReadOrgsMonthlyUsage", fmt.Sprintf(query, AccessLogTableName1mo),
+		clickhouse.Named("org_ids", chIDs(orgIDs)),
+		clickhouse.Named("timestamp", month.Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute orgs monthly usage query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var orgID uint32
+		var count uint64
+		if err := rows.Scan(&orgID, &count); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from orgs monthly usage query", common.ErrAttr(err))
+			return nil, err
+		}
+		results[int32(orgID)] = count
+	}
+
+	slog.DebugContext(ctx, "Read orgs monthly usage", "orgs", len(orgIDs), "count", len(results), "month", month)
+
+	return results, nil
+}
+
+// RetrievePropertiesTotals returns aggregated requests and successful verifications of properties since {from}
+func (ts *TimeSeriesDB) RetrievePropertiesTotals(ctx context.Context, orgID int32, propertyIDs []int32, from time.Time) (*common.TimePeriodStat, error) {
+	result := &common.TimePeriodStat{Timestamp: from}
+
+	if len(propertyIDs) == 0 {
+		return result, nil
+	}
+
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT
+(SELECT sum(count) FROM %s FINAL WHERE org_id = {org_id:UInt32} AND property_id IN {property_ids:Array(UInt32)} AND timestamp >= {timestamp:DateTime}) AS requests_count,
+(SELECT sum(success_count) FROM %s FINAL WHERE org_id = {org_id:UInt32} AND property_id IN {property_ids:Array(UInt32)} AND timestamp >= {timestamp:DateTime}) AS verifies_count`
+	rows, err := ts.query(ctx, "RetrievePropertiesTotals", fmt.Sprintf(query, AccessLogTableName1d, VerifyLogTable1d),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_ids", chIDs(propertyIDs)),
+		clickhouse.Named("timestamp", from.Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute properties totals query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&result.RequestsCount, &result.VerifiesCount); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from properties totals query", common.ErrAttr(err))
+			return nil, err
+		}
+	}
+
+	slog.DebugContext(ctx, "Read properties totals", "orgID", orgID, "properties", len(propertyIDs), "from", from)
+
+	return result, nil
+}
+
+// RetrievePropertiesActivity returns when properties got their first puzzle request and first successful verification
+func (ts *TimeSeriesDB) RetrievePropertiesActivity(ctx context.Context, orgID int32, propertyIDs []int32) (map[int32]*common.PropertyActivity, error) {
+	results := make(map[int32]*common.PropertyActivity)
+
+	if len(propertyIDs) == 0 {
+		return results, nil
+	}
+
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT property_id, 0 AS kind, min(timestamp) FROM %s FINAL
+WHERE org_id = {org_id:UInt32} AND property_id IN {property_ids:Array(UInt32)}
+GROUP BY property_id
+UNION ALL
+SELECT property_id, 1 AS kind, min(timestamp) FROM %s FINAL
+WHERE org_id = {org_id:UInt32} AND property_id IN {property_ids:Array(UInt32)} AND success_count > 0
+GROUP BY property_id`
+	rows, err := ts.query(ctx, "RetrievePropertiesActivity", fmt.Sprintf(query, AccessLogTableName1d, VerifyLogTable1d),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_ids", chIDs(propertyIDs)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute properties activity query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var propertyID uint32
+		var kind uint8
+		var timestamp time.Time
+		if err := rows.Scan(&propertyID, &kind, &timestamp); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from properties activity query", common.ErrAttr(err))
+			return nil, err
+		}
+
+		activity, ok := results[int32(propertyID)]
+		if !ok {
+			activity = &common.PropertyActivity{PropertyID: int32(propertyID)}
+			results[int32(propertyID)] = activity
+		}
+
+		if kind == 0 {
+			activity.FirstRequest = timestamp
+		} else {
+			activity.FirstVerify = timestamp
+		}
+	}
+
+	slog.DebugContext(ctx, "Read properties activity", "orgID", orgID, "properties", len(propertyIDs), "active", len(results))
+
+	return results, nil
+}
+
+// lightDelete deletes rows with any of the IDs in the column from all tables. All identifiers are validated before
+// anything is deleted so that the operation is not applied partially
+func (ts *TimeSeriesDB) lightDelete(ctx context.Context, tables []string, column string, ids []int32) error {
+	if _, err := chIdentifier(column); err != nil {
+		slog.ErrorContext(ctx, "Invalid column to delete data", "column", column, common.ErrAttr(err))
+		return err
+	}
+
 	for _, table := range tables {
-		query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", table, column, ids)
-		if _, err := ts.Clickhouse.Exec(query); err != nil {
+		if _, err := chIdentifier(table); err != nil {
+			slog.ErrorContext(ctx, "Invalid table to delete data", "table", table, common.ErrAttr(err))
+			return err
+		}
+	}
+
+	idsParam := chIDs(ids)
+
+	for _, table := range tables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s IN {ids:Array(UInt32)}", table, column)
+		if _, err := ts.exec(ctx, "lightDelete", query, clickhouse.Named("ids", idsParam)); err != nil {
 			slog.ErrorContext(ctx, "Failed to delete data", "table", table, "column", column, common.ErrAttr(err))
 			return err
 		}
@@ -379,15 +845,16 @@ func (ts *TimeSeriesDB) DeletePropertiesData(ctx context.Context, propertyIDs []
 		return ErrMaintenance
 	}
 
-	ids := idsToString(propertyIDs)
-
 	// NOTE: access table for 1 month is not included as it does not have property_id column
 	tables := []string{
 		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d,
 		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyFailuresTable1h, VerifyFailuresTable1d,
+		VerifyActionsTable1d,
+		FingerprintsTable1h, FingerprintsTable1d,
 	}
 
-	return ts.lightDelete(ctx, tables, "property_id", ids)
+	return ts.lightDelete(ctx, tables, "property_id", propertyIDs)
 }
 
 func (ts *TimeSeriesDB) DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error {
@@ -400,14 +867,15 @@ func (ts *TimeSeriesDB) DeleteOrganizationsData(ctx context.Context, orgIDs []in
 		return ErrMaintenance
 	}
 
-	ids := idsToString(orgIDs)
-
 	tables := []string{
 		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
 		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyFailuresTable1h, VerifyFailuresTable1d,
+		VerifyActionsTable1d,
+		FingerprintsTable1h, FingerprintsTable1d,
 	}
 
-	return ts.lightDelete(ctx, tables, "org_id", ids)
+	return ts.lightDelete(ctx, tables, "org_id", orgIDs)
 }
 
 func (ts *TimeSeriesDB) DeleteUsersData(ctx context.Context, userIDs []int32) error {
@@ -420,12 +888,13 @@ func (ts *TimeSeriesDB) DeleteUsersData(ctx context.Context, userIDs []int32) er
 		return ErrMaintenance
 	}
 
-	ids := idsToString(userIDs)
-
 	tables := []string{
 		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
 		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyFailuresTable1h, VerifyFailuresTable1d,
+		VerifyActionsTable1d,
+		FingerprintsTable1h, FingerprintsTable1d,
 	}
 
-	return ts.lightDelete(ctx, tables, "user_id", ids)
+	return ts.lightDelete(ctx, tables, "user_id", userIDs)
 }
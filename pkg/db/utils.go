@@ -127,3 +127,31 @@ func UUIDFromSecret(s string) pgtype.UUID {
 
 	return invalidUUID
 }
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern creates (I)LIKE pattern that matches {term} as a substring
+func ContainsPattern(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
+// PropertyAccessLevel resolves access of the organization member to the property, given all explicit permissions
+// of the member in this organization. Creators can always edit their properties, members without any explicit
+// permissions can view all properties of the organization, otherwise only the listed ones are accessible
+func PropertyAccessLevel(property *dbgen.Property, userID int32, permissions []*dbgen.PropertyPermission) (dbgen.PropertyAccessLevel, bool) {
+	if property.CreatorID.Valid && (property.CreatorID.Int32 == userID) {
+		return dbgen.PropertyAccessLevelEdit, true
+	}
+
+	if len(permissions) == 0 {
+		return dbgen.PropertyAccessLevelView, true
+	}
+
+	for _, p := range permissions {
+		if p.PropertyID == property.ID {
+			return p.Level, true
+		}
+	}
+
+	return "", false
+}
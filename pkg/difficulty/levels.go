@@ -2,17 +2,35 @@ package difficulty
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"math"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
 )
 
+const (
+	timeSeriesBreakerName      = "clickhouse"
+	timeSeriesBreakerThreshold = 5
+	timeSeriesBreakerCooldown  = 30 * time.Second
+	// requests from hosting networks are rarely made by real people so each of them counts as several requests
+	// from the same client and the puzzle starts harder even without any elevated activity
+	datacenterRequestWeight    = 4
+	datacenterDifficultyOffset = common.DifficultyDelta / 3
+)
+
+var (
+	errTimeSeriesUnavailable = errors.New("time-series store is unavailable")
+)
+
 type Levels struct {
 	timeSeries      common.TimeSeriesStore
+	breaker         *common.CircuitBreaker
+	metrics         common.PlatformMetrics
 	propertyBuckets *leakybucket.Manager[int32, leakybucket.VarLeakyBucket[int32], *leakybucket.VarLeakyBucket[int32]]
 	userBuckets     *leakybucket.Manager[common.TFingerprint, leakybucket.ConstLeakyBucket[common.TFingerprint], *leakybucket.ConstLeakyBucket[common.TFingerprint]]
 	accessChan      chan *common.AccessRecord
@@ -22,7 +40,7 @@ type Levels struct {
 	cleanupCancel   context.CancelFunc
 }
 
-func NewLevels(timeSeries common.TimeSeriesStore, batchSize int, bucketSize time.Duration) *Levels {
+func NewLevels(timeSeries common.TimeSeriesStore, metrics common.PlatformMetrics, batchSize int, bucketSize time.Duration) *Levels {
 	const (
 		propertyBucketCap = math.MaxUint32
 		// below numbers are rather arbitrary as we can support "many"
@@ -37,8 +55,16 @@ func NewLevels(timeSeries common.TimeSeriesStore, batchSize int, bucketSize time
 		userBucketSize        = time.Minute / userLeakRatePerMinute
 	)
 
+	breaker := common.NewCircuitBreaker(timeSeriesBreakerName, timeSeriesBreakerThreshold, timeSeriesBreakerCooldown,
+		func(name string, state common.CircuitBreakerState) {
+			slog.Warn("Circuit breaker state changed", "name", name, "state", state.String())
+			metrics.ObserveCircuitBreaker(name, state)
+		})
+
 	levels := &Levels{
 		timeSeries:      timeSeries,
+		breaker:         breaker,
+		metrics:         metrics,
 		propertyBuckets: leakybucket.NewManager[int32, leakybucket.VarLeakyBucket[int32]](maxPropertyBuckets, propertyBucketCap, bucketSize),
 		userBuckets:     leakybucket.NewManager[common.TFingerprint, leakybucket.ConstLeakyBucket[common.TFingerprint]](maxUserBuckets, userBucketCap, userBucketSize),
 		accessChan:      make(chan *common.AccessRecord, 10*batchSize),
@@ -96,7 +122,7 @@ func (levels *Levels) Init(accessLogInterval, backfillInterval time.Duration) {
 	var accessCtx context.Context
 	accessCtx, levels.accessLogCancel = context.WithCancel(
 		context.WithValue(context.Background(), common.TraceIDContextKey, "access_log"))
-	go common.ProcessBatchArray(accessCtx, levels.accessChan, accessLogInterval, levels.batchSize, maxPendingBatchSize, levels.timeSeries.WriteAccessLogBatch)
+	go common.ProcessBatchArray(accessCtx, common.BatchPipelineAccessLog, levels.metrics, levels.accessChan, accessLogInterval, levels.batchSize, maxPendingBatchSize, levels.writeAccessLogBatch)
 
 	go levels.backfillDifficulty(context.WithValue(context.Background(), common.TraceIDContextKey, "backfill_difficulty"),
 		backfillInterval)
@@ -122,31 +148,58 @@ func (l *Levels) Shutdown() {
 	close(l.backfillChan)
 }
 
-func (l *Levels) DifficultyEx(fingerprint common.TFingerprint, p *dbgen.Property, tnow time.Time) (uint8, leakybucket.TLevel) {
-	l.recordAccess(fingerprint, p, tnow)
+func (l *Levels) DifficultyEx(fingerprint common.TFingerprint, class asn.Class, p *dbgen.Property, tnow time.Time) (uint8, leakybucket.TLevel) {
+	l.recordAccess(fingerprint, class, p, tnow)
 
 	minDifficulty := uint8(p.Level.Int16)
+	var userWeight leakybucket.TLevel = 1
+
+	if class == asn.ClassDatacenter {
+		minDifficulty = uint8(min(int(minDifficulty)+datacenterDifficultyOffset, int(common.MaxDifficultyLevel)))
+		userWeight = datacenterRequestWeight
+	}
 
 	propertyAddResult := l.propertyBuckets.Add(p.ID, 1, tnow)
 	if !propertyAddResult.Found {
 		l.backfillProperty(p)
 	}
 
-	userAddResult := l.userBuckets.Add(fingerprint, 1, tnow)
+	userAddResult := l.userBuckets.Add(fingerprint, userWeight, tnow)
 
 	level := int64(userAddResult.CurrLevel)
 	level += int64(propertyAddResult.CurrLevel)
 
+	// without time-series store we cannot backfill nor record stats so we fall back to the static difficulty
+	if l.breaker.State() == common.CircuitBreakerOpen {
+		return minDifficulty, propertyAddResult.CurrLevel
+	}
+
 	// just as bucket's level is the measure of deviation of requests
 	// difficulty is the scaled deviation from minDifficulty
 	return requestsToDifficulty(float64(level), minDifficulty, p.Growth), propertyAddResult.CurrLevel
 }
 
-func (l *Levels) Difficulty(fingerprint common.TFingerprint, p *dbgen.Property, tnow time.Time) uint8 {
-	diff, _ := l.DifficultyEx(fingerprint, p, tnow)
+func (l *Levels) Difficulty(fingerprint common.TFingerprint, class asn.Class, p *dbgen.Property, tnow time.Time) uint8 {
+	diff, _ := l.DifficultyEx(fingerprint, class, p, tnow)
 	return diff
 }
 
+func (l *Levels) writeAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	if !l.breaker.Allow(time.Now()) {
+		// batch will be kept pending (up to the limit) until time-series store is available again
+		return errTimeSeriesUnavailable
+	}
+
+	err := l.timeSeries.WriteAccessLogBatch(ctx, records)
+	if err != nil {
+		l.breaker.Failure(time.Now())
+	} else {
+		l.breaker.Success(time.Now())
+	}
+
+	return err
+}
+
 func (l *Levels) backfillProperty(p *dbgen.Property) {
 	br := &common.BackfillRequest{
 		OrgID:      p.OrgID.Int32,
@@ -156,11 +209,17 @@ func (l *Levels) backfillProperty(p *dbgen.Property) {
 	l.backfillChan <- br
 }
 
-func (l *Levels) recordAccess(fingerprint common.TFingerprint, p *dbgen.Property, tnow time.Time) {
+func (l *Levels) recordAccess(fingerprint common.TFingerprint, class asn.Class, p *dbgen.Property, tnow time.Time) {
 	if (p == nil) || !p.ExternalID.Valid {
 		return
 	}
 
+	// properties in privacy mode only contribute to aggregated counters, fingerprint is still used in memory
+	// for difficulty scaling but it never leaves the server
+	if p.PrivacyMode {
+		fingerprint = 0
+	}
+
 	ar := &common.AccessRecord{
 		Fingerprint: fingerprint,
 		// we record events for the user that owns the org where the property belongs
@@ -169,6 +228,9 @@ func (l *Levels) recordAccess(fingerprint common.TFingerprint, p *dbgen.Property
 		OrgID:      p.OrgID.Int32,
 		PropertyID: p.ID,
 		Timestamp:  tnow,
+		Datacenter: class == asn.ClassDatacenter,
+		IPLess:     p.IplessMode,
+		Region:     p.DataRegion,
 	}
 
 	l.accessChan <- ar
@@ -195,15 +257,23 @@ func (l *Levels) backfillDifficulty(ctx context.Context, cacheDuration time.Dura
 			continue
 		}
 
+		if !l.breaker.Allow(tnow) {
+			blog.Log(ctx, common.LevelTrace, "Skipping backfill while time-series store is unavailable")
+			continue
+		}
+
 		// 12 because we keep last hour of 5-minute intervals in Clickhouse, so we grab all of them
 		timeFrom := time.Now().UTC().Add(-time.Duration(12) * l.propertyBuckets.LeakInterval())
 		counts, err := l.timeSeries.ReadPropertyStats(ctx, r, timeFrom)
 
 		if err != nil {
+			l.breaker.Failure(time.Now())
 			blog.ErrorContext(ctx, "Failed to backfill stats", common.ErrAttr(err))
 			continue
 		}
 
+		l.breaker.Success(time.Now())
+
 		cache[cacheKey] = tnow
 
 		if len(counts) > 0 {
@@ -3,8 +3,13 @@ package difficulty
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/asn"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestDifficultyFormula(t *testing.T) {
@@ -29,3 +34,72 @@ func TestDifficultyFormula(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordAccessPrivacyMode(t *testing.T) {
+	levels := &Levels{accessChan: make(chan *common.AccessRecord, 2)}
+	tnow := time.Now()
+
+	testCases := []struct {
+		privacyMode bool
+		expected    common.TFingerprint
+	}{
+		{false, 123},
+		{true, 0},
+	}
+
+	for _, tc := range testCases {
+		p := &dbgen.Property{
+			ID:          1,
+			ExternalID:  pgtype.UUID{Valid: true},
+			PrivacyMode: tc.privacyMode,
+		}
+
+		levels.recordAccess(123, asn.ClassUnknown, p, tnow)
+
+		ar := <-levels.accessChan
+		if ar.Fingerprint != tc.expected {
+			t.Errorf("Unexpected fingerprint %v (privacy mode %v)", ar.Fingerprint, tc.privacyMode)
+		}
+	}
+}
+
+func TestRecordAccessIPLessMode(t *testing.T) {
+	levels := &Levels{accessChan: make(chan *common.AccessRecord, 2)}
+	tnow := time.Now()
+
+	for _, iplessMode := range []bool{false, true} {
+		p := &dbgen.Property{
+			ID:         1,
+			ExternalID: pgtype.UUID{Valid: true},
+			IplessMode: iplessMode,
+		}
+
+		levels.recordAccess(123, asn.ClassUnknown, p, tnow)
+
+		ar := <-levels.accessChan
+		if ar.IPLess != iplessMode {
+			t.Errorf("Unexpected IP-less flag in access record (IP-less mode %v)", iplessMode)
+		}
+	}
+}
+
+func TestDatacenterDifficulty(t *testing.T) {
+	levels := NewLevels(nil /*time-series*/, monitoring.NewStub(), 10 /*batch size*/, 5*time.Minute)
+	tnow := time.Now()
+
+	p := &dbgen.Property{
+		ID:     1,
+		Level:  pgtype.Int2{Int16: int16(common.DifficultyLevelSmall), Valid: true},
+		Growth: dbgen.DifficultyGrowthMedium,
+	}
+
+	residential := levels.Difficulty(1, asn.ClassResidential, p, tnow)
+	if residential != uint8(common.DifficultyLevelSmall) {
+		t.Errorf("Unexpected residential difficulty: %v", residential)
+	}
+
+	datacenter := levels.Difficulty(2, asn.ClassDatacenter, p, tnow)
+	if datacenter <= residential {
+		t.Errorf("Datacenter difficulty (%v) is not higher than residential (%v)", datacenter, residential)
+	}
+}
@@ -0,0 +1,63 @@
+package email
+
+const (
+	AccountLockedHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDN}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="50" src="{{.CDN}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              We noticed too many failed attempts to enter the verification code for your account, so signing in is temporarily blocked until <strong>{{.LockedUntil}}</strong>.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              If it was you, simply try again later. If it was not, someone might be trying to access your account, but they will still need access to your email to sign in. You do not need to do anything else.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="{{.Domain}}" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	accountLockedTextTemplate = `
+Hello,
+
+We noticed too many failed attempts to enter the verification code for your account, so signing in is temporarily blocked until {{.LockedUntil}}.
+
+If it was you, simply try again later. If it was not, someone might be trying to access your account, but they will still need access to your email to sign in. You do not need to do anything else.
+
+Warmly,
+The Private Captcha team
+
+--------------------------------------------------------------------------------
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ`
+)
@@ -0,0 +1,203 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultMaxAttachmentSize  = 5 * 1024 * 1024
+	defaultMaxAttachmentCount = 5
+	clamdChunkSize            = 64 * 1024
+	clamdTimeout              = 30 * time.Second
+)
+
+var (
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+	ErrTooManyAttachments    = errors.New("too many attachments")
+	ErrAttachmentContentType = errors.New("attachment content type is not allowed")
+	ErrAttachmentInfected    = errors.New("attachment did not pass content scan")
+	errClamdResponse         = errors.New("unexpected clamd response")
+)
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// AttachmentChecker is a pluggable check (validation, virus scan etc.) that every attachment has to pass
+// before it is relayed anywhere
+type AttachmentChecker interface {
+	CheckAttachment(ctx context.Context, a *Attachment) error
+}
+
+func CheckAttachments(ctx context.Context, checkers []AttachmentChecker, attachments []*Attachment) error {
+	for _, a := range attachments {
+		for _, c := range checkers {
+			if err := c.CheckAttachment(ctx, a); err != nil {
+				slog.WarnContext(ctx, "Attachment check failed", "filename", a.Filename, "type", a.ContentType,
+					"size", len(a.Data), common.ErrAttr(err))
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type AttachmentPolicy struct {
+	MaxSize      int
+	MaxCount     int
+	AllowedTypes map[string]struct{}
+}
+
+var _ AttachmentChecker = (*AttachmentPolicy)(nil)
+
+func NewAttachmentPolicy() *AttachmentPolicy {
+	return &AttachmentPolicy{
+		MaxSize:  defaultMaxAttachmentSize,
+		MaxCount: defaultMaxAttachmentCount,
+		AllowedTypes: map[string]struct{}{
+			"image/png":       {},
+			"image/jpeg":      {},
+			"image/gif":       {},
+			"image/webp":      {},
+			"application/pdf": {},
+			"text/plain":      {},
+		},
+	}
+}
+
+func (p *AttachmentPolicy) CheckCount(count int) error {
+	if count > p.MaxCount {
+		return ErrTooManyAttachments
+	}
+
+	return nil
+}
+
+// CheckAttachment verifies size and that both declared and sniffed content types are allowed
+// (so that client cannot bypass the check just by declaring a "good" content type)
+func (p *AttachmentPolicy) CheckAttachment(ctx context.Context, a *Attachment) error {
+	if len(a.Data) > p.MaxSize {
+		return ErrAttachmentTooLarge
+	}
+
+	declared, _, err := mime.ParseMediaType(a.ContentType)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse attachment content type", "type", a.ContentType, common.ErrAttr(err))
+		return ErrAttachmentContentType
+	}
+
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(a.Data))
+	if err != nil {
+		return ErrAttachmentContentType
+	}
+
+	if _, ok := p.AllowedTypes[declared]; !ok {
+		return ErrAttachmentContentType
+	}
+
+	if sniffed != declared {
+		slog.WarnContext(ctx, "Attachment content type mismatch", "declared", declared, "sniffed", sniffed)
+		return ErrAttachmentContentType
+	}
+
+	return nil
+}
+
+// ClamAVScanner scans attachments using clamd INSTREAM command. Scanning is skipped if address is not configured.
+type ClamAVScanner struct {
+	Address common.ConfigItem
+	Timeout time.Duration
+}
+
+var _ AttachmentChecker = (*ClamAVScanner)(nil)
+
+func NewClamAVScanner(cfg common.ConfigStore) *ClamAVScanner {
+	return &ClamAVScanner{
+		Address: cfg.Get(common.ClamAVAddressKey),
+		Timeout: clamdTimeout,
+	}
+}
+
+func (s *ClamAVScanner) dial(ctx context.Context, address string) (net.Conn, error) {
+	d := &net.Dialer{Timeout: s.Timeout}
+
+	if strings.HasPrefix(address, "/") {
+		return d.DialContext(ctx, "unix", address)
+	}
+
+	return d.DialContext(ctx, "tcp", address)
+}
+
+func (s *ClamAVScanner) CheckAttachment(ctx context.Context, a *Attachment) error {
+	address := s.Address.Value()
+	if len(address) == 0 {
+		return nil
+	}
+
+	conn, err := s.dial(ctx, address)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to clamd", "address", address, common.ErrAttr(err))
+		return err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	var size [4]byte
+	for data := a.Data; len(data) > 0; {
+		chunk := data[:min(len(data), clamdChunkSize)]
+		data = data[len(chunk):]
+
+		binary.BigEndian.PutUint32(size[:], uint32(len(chunk)))
+		if _, err := conn.Write(size[:]); err != nil {
+			return err
+		}
+		if _, err := conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	// zero-length chunk marks end of stream
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := conn.Write(size[:]); err != nil {
+		return err
+	}
+
+	response, err := bufio.NewReader(conn).ReadBytes('\x00')
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read clamd response", common.ErrAttr(err))
+		return err
+	}
+
+	result := string(bytes.TrimRight(response, "\x00"))
+
+	switch {
+	case strings.HasSuffix(result, "OK"):
+		return nil
+	case strings.HasSuffix(result, "FOUND"):
+		slog.WarnContext(ctx, "Attachment is infected", "filename", a.Filename, "result", result)
+		return ErrAttachmentInfected
+	default:
+		return fmt.Errorf("%w: %s", errClamdResponse, result)
+	}
+}
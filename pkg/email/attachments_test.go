@@ -0,0 +1,132 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+var (
+	pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+)
+
+func TestAttachmentPolicy(t *testing.T) {
+	policy := NewAttachmentPolicy()
+	ctx := context.TODO()
+
+	if err := policy.CheckAttachment(ctx, &Attachment{Filename: "a.png", ContentType: "image/png", Data: pngHeader}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := policy.CheckAttachment(ctx, &Attachment{Filename: "a.txt", ContentType: "text/plain; charset=utf-8", Data: []byte("hello")}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// declared type does not match the content
+	if err := policy.CheckAttachment(ctx, &Attachment{Filename: "a.png", ContentType: "image/png", Data: []byte("<html><body></body></html>")}); err != ErrAttachmentContentType {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := policy.CheckAttachment(ctx, &Attachment{Filename: "a.html", ContentType: "text/html", Data: []byte("<html><body></body></html>")}); err != ErrAttachmentContentType {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	large := append(bytes.Clone(pngHeader), make([]byte, policy.MaxSize)...)
+	if err := policy.CheckAttachment(ctx, &Attachment{Filename: "a.png", ContentType: "image/png", Data: large}); err != ErrAttachmentTooLarge {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := policy.CheckCount(policy.MaxCount + 1); err != ErrTooManyAttachments {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func fakeClamd(t *testing.T, response string) (string, <-chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []byte, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		if _, err := reader.ReadBytes('\x00'); err != nil {
+			return
+		}
+
+		var data []byte
+		var size [4]byte
+		for {
+			if _, err := io.ReadFull(reader, size[:]); err != nil {
+				return
+			}
+			length := binary.BigEndian.Uint32(size[:])
+			if length == 0 {
+				break
+			}
+			chunk := make([]byte, length)
+			if _, err := io.ReadFull(reader, chunk); err != nil {
+				return
+			}
+			data = append(data, chunk...)
+		}
+
+		received <- data
+		_, _ = conn.Write([]byte(response + "\x00"))
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestClamAVScanner(t *testing.T) {
+	testCases := []struct {
+		response string
+		err      error
+	}{
+		{"stream: OK", nil},
+		{"stream: Eicar-Signature FOUND", ErrAttachmentInfected},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.response, func(t *testing.T) {
+			address, received := fakeClamd(t, tc.response)
+			scanner := &ClamAVScanner{
+				Address: config.NewStaticValue(common.ClamAVAddressKey, address),
+				Timeout: 5 * time.Second,
+			}
+
+			data := bytes.Repeat([]byte("a"), 3*clamdChunkSize/2)
+			err := scanner.CheckAttachment(context.TODO(), &Attachment{Filename: "a.txt", ContentType: "text/plain", Data: data})
+			if err != tc.err {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if actual := <-received; !bytes.Equal(actual, data) {
+				t.Errorf("Received data differs: %v vs %v bytes", len(actual), len(data))
+			}
+		})
+	}
+}
+
+func TestClamAVScannerDisabled(t *testing.T) {
+	scanner := &ClamAVScanner{Address: config.NewStaticValue(common.ClamAVAddressKey, "")}
+	if err := scanner.CheckAttachment(context.TODO(), &Attachment{Data: []byte("hello")}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
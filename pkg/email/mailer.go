@@ -3,6 +3,7 @@ package email
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"net/url"
 	"strconv"
@@ -12,14 +13,15 @@ import (
 )
 
 type Message struct {
-	HTMLBody  string
-	TextBody  string
-	Subject   string
-	EmailTo   string
-	NameTo    string
-	EmailFrom string
-	NameFrom  string
-	ReplyTo   string
+	HTMLBody    string
+	TextBody    string
+	Subject     string
+	EmailTo     string
+	NameTo      string
+	EmailFrom   string
+	NameFrom    string
+	ReplyTo     string
+	Attachments []*Attachment
 }
 
 var (
@@ -106,6 +108,16 @@ func (sm *SimpleMailer) SendEmail(ctx context.Context, msg *Message) error {
 		return errors.New("no email body was generated")
 	}
 
+	for _, a := range msg.Attachments {
+		data := a.Data
+		m.Attach(a.Filename,
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}))
+	}
+
 	err = dialer.DialAndSend(m)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to send an email", "email", msg.EmailTo, "host", dialer.Host, "port", dialer.Port,
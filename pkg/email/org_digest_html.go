@@ -0,0 +1,88 @@
+package email
+
+const (
+	OrgDigestHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDN}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="50" src="{{.CDN}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Here is what happened in your organization <strong>{{html .OrgName}}</strong> during the week of {{.WeekStart}}.
+            </p>
+            <ul style="font-size:16px;line-height:26px;margin:16px 0">
+              <li>Puzzle requests: <strong>{{.Requests}}</strong>{{if .HasTrend}} ({{if ge .Trend 0}}+{{end}}{{.Trend}}% compared to the week before){{end}}</li>
+              <li>Verifications: {{.VerifySuccess}} successful, {{.VerifyFailure}} failed</li>
+              <li>New properties: {{.NewProperties}}</li>
+              <li>New members: {{.JoinedMembers}}, pending invitations: {{.InvitedMembers}}</li>
+            </ul>
+            {{if .Spikes}}
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Verification failures were notably higher than usual on these days:
+            </p>
+            <ul style="font-size:16px;line-height:26px;margin:16px 0">
+              {{range .Spikes}}<li>{{.Day}}: {{.Failures}} failed verifications</li>{{end}}
+            </ul>
+            {{end}}
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              You can see more details in the <a href="{{.OrgURL}}" style="color:#111827;text-decoration:underline">organization dashboard</a>.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="{{.Domain}}" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	orgDigestTextTemplate = `
+Hello,
+
+Here is what happened in your organization "{{.OrgName}}" during the week of {{.WeekStart}}.
+
+- Puzzle requests: {{.Requests}}{{if .HasTrend}} ({{if ge .Trend 0}}+{{end}}{{.Trend}}% compared to the week before){{end}}
+- Verifications: {{.VerifySuccess}} successful, {{.VerifyFailure}} failed
+- New properties: {{.NewProperties}}
+- New members: {{.JoinedMembers}}, pending invitations: {{.InvitedMembers}}
+{{if .Spikes}}
+Verification failures were notably higher than usual on these days:
+{{range .Spikes}}
+- {{.Day}}: {{.Failures}} failed verifications{{end}}
+{{end}}
+You can see more details in the organization dashboard:
+
+{{.OrgURL}}
+
+Warmly,
+The Private Captcha team
+
+--------------------------------------------------------------------------------
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ`
+)
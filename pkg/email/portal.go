@@ -12,28 +12,82 @@ import (
 )
 
 type PortalMailer struct {
-	Mailer                *SimpleMailer
-	CDN                   string
-	Domain                string
-	EmailFrom             common.ConfigItem
-	AdminEmail            common.ConfigItem
-	twofactorHTMLTemplate *template.Template
-	twofactorTextTemplate *template.Template
-	welcomeHTMLTemplate   *template.Template
-	welcomeTextTemplate   *template.Template
-}
-
-func NewPortalMailer(cdn, domain string, mailer *SimpleMailer, cfg common.ConfigStore) *PortalMailer {
+	Mailer                 Sender
+	CDN                    string
+	Domain                 string
+	EmailFrom              common.ConfigItem
+	AdminEmail             common.ConfigItem
+	SupportEmail           common.ConfigItem
+	AttachmentPolicy       *AttachmentPolicy
+	AttachmentCheckers     []AttachmentChecker
+	twofactorHTMLTemplate  *template.Template
+	twofactorTextTemplate  *template.Template
+	magicLinkHTMLTemplate  *template.Template
+	magicLinkTextTemplate  *template.Template
+	welcomeHTMLTemplate    *template.Template
+	welcomeTextTemplate    *template.Template
+	supportTextTemplate    *template.Template
+	changedHTMLTemplate    *template.Template
+	changedTextTemplate    *template.Template
+	signInHTMLTemplate     *template.Template
+	signInTextTemplate     *template.Template
+	rotatedHTMLTemplate    *template.Template
+	rotatedTextTemplate    *template.Template
+	expiringHTMLTemplate   *template.Template
+	expiringTextTemplate   *template.Template
+	suspendedHTMLTemplate  *template.Template
+	suspendedTextTemplate  *template.Template
+	lockedHTMLTemplate     *template.Template
+	lockedTextTemplate     *template.Template
+	budgetHTMLTemplate     *template.Template
+	budgetTextTemplate     *template.Template
+	orgDeletedHTMLTemplate *template.Template
+	orgDeletedTextTemplate *template.Template
+	verifyHTMLTemplate     *template.Template
+	verifyTextTemplate     *template.Template
+	digestHTMLTemplate     *template.Template
+	digestTextTemplate     *template.Template
+}
+
+func NewPortalMailer(cdn, domain string, mailer Sender, cfg common.ConfigStore) *PortalMailer {
+	policy := NewAttachmentPolicy()
+
 	return &PortalMailer{
-		Mailer:                mailer,
-		EmailFrom:             cfg.Get(common.EmailFromKey),
-		AdminEmail:            cfg.Get(common.AdminEmailKey),
-		CDN:                   cdn,
-		Domain:                domain,
-		twofactorHTMLTemplate: template.Must(template.New("HtmlBody").Parse(TwoFactorHTMLTemplate)),
-		twofactorTextTemplate: template.Must(template.New("TextBody").Parse(twoFactorTextTemplate)),
-		welcomeHTMLTemplate:   template.Must(template.New("HtmlBody").Parse(WelcomeHTMLTemplate)),
-		welcomeTextTemplate:   template.Must(template.New("TextBody").Parse(welcomeTextTemplate)),
+		Mailer:                 mailer,
+		EmailFrom:              cfg.Get(common.EmailFromKey),
+		AdminEmail:             cfg.Get(common.AdminEmailKey),
+		SupportEmail:           cfg.Get(common.SupportEmailKey),
+		AttachmentPolicy:       policy,
+		AttachmentCheckers:     []AttachmentChecker{policy, NewClamAVScanner(cfg)},
+		CDN:                    cdn,
+		Domain:                 domain,
+		twofactorHTMLTemplate:  template.Must(template.New("HtmlBody").Parse(TwoFactorHTMLTemplate)),
+		twofactorTextTemplate:  template.Must(template.New("TextBody").Parse(twoFactorTextTemplate)),
+		magicLinkHTMLTemplate:  template.Must(template.New("HtmlBody").Parse(MagicLinkHTMLTemplate)),
+		magicLinkTextTemplate:  template.Must(template.New("TextBody").Parse(magicLinkTextTemplate)),
+		welcomeHTMLTemplate:    template.Must(template.New("HtmlBody").Parse(WelcomeHTMLTemplate)),
+		welcomeTextTemplate:    template.Must(template.New("TextBody").Parse(welcomeTextTemplate)),
+		supportTextTemplate:    template.Must(template.New("TextBody").Parse(supportRequestTextTemplate)),
+		changedHTMLTemplate:    template.Must(template.New("HtmlBody").Parse(EmailChangedHTMLTemplate)),
+		changedTextTemplate:    template.Must(template.New("TextBody").Parse(emailChangedTextTemplate)),
+		signInHTMLTemplate:     template.Must(template.New("HtmlBody").Parse(NewSignInHTMLTemplate)),
+		signInTextTemplate:     template.Must(template.New("TextBody").Parse(newSignInTextTemplate)),
+		rotatedHTMLTemplate:    template.Must(template.New("HtmlBody").Parse(APIKeyRotatedHTMLTemplate)),
+		rotatedTextTemplate:    template.Must(template.New("TextBody").Parse(apiKeyRotatedTextTemplate)),
+		expiringHTMLTemplate:   template.Must(template.New("HtmlBody").Parse(APIKeyExpiringHTMLTemplate)),
+		expiringTextTemplate:   template.Must(template.New("TextBody").Parse(apiKeyExpiringTextTemplate)),
+		suspendedHTMLTemplate:  template.Must(template.New("HtmlBody").Parse(APIKeySuspendedHTMLTemplate)),
+		suspendedTextTemplate:  template.Must(template.New("TextBody").Parse(apiKeySuspendedTextTemplate)),
+		lockedHTMLTemplate:     template.Must(template.New("HtmlBody").Parse(AccountLockedHTMLTemplate)),
+		lockedTextTemplate:     template.Must(template.New("TextBody").Parse(accountLockedTextTemplate)),
+		budgetHTMLTemplate:     template.Must(template.New("HtmlBody").Parse(BudgetAlertHTMLTemplate)),
+		budgetTextTemplate:     template.Must(template.New("TextBody").Parse(budgetAlertTextTemplate)),
+		orgDeletedHTMLTemplate: template.Must(template.New("HtmlBody").Parse(OrgDeletedHTMLTemplate)),
+		orgDeletedTextTemplate: template.Must(template.New("TextBody").Parse(orgDeletedTextTemplate)),
+		verifyHTMLTemplate:     template.Must(template.New("HtmlBody").Parse(EmailVerificationHTMLTemplate)),
+		verifyTextTemplate:     template.Must(template.New("TextBody").Parse(emailVerificationTextTemplate)),
+		digestHTMLTemplate:     template.Must(template.New("HtmlBody").Parse(OrgDigestHTMLTemplate)),
+		digestTextTemplate:     template.Must(template.New("TextBody").Parse(orgDigestTextTemplate)),
 	}
 }
 
@@ -95,6 +149,56 @@ func (pm *PortalMailer) SendTwoFactor(ctx context.Context, email string, code in
 	return nil
 }
 
+// SendMagicLink sends a single-use sign-in link as an alternative to the verification code
+func (pm *PortalMailer) SendMagicLink(ctx context.Context, email, loginPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		LoginURL     string
+		ValidMinutes int
+		Domain       string
+		CurrentYear  int
+		CDN          string
+	}{
+		LoginURL:     fmt.Sprintf("https://%s%s", pm.Domain, loginPath),
+		ValidMinutes: int(common.MagicLinkTimeout.Minutes()),
+		CDN:          pm.CDN,
+		Domain:       fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear:  time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.magicLinkHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.magicLinkTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Your sign-in link", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send sign-in link", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent sign-in link", "email", email)
+
+	return nil
+}
+
 func (pm *PortalMailer) SendWelcome(ctx context.Context, email string) error {
 	data := struct {
 		Domain      string
@@ -136,3 +240,617 @@ func (pm *PortalMailer) SendWelcome(ctx context.Context, email string) error {
 
 	return nil
 }
+
+// SendEmailChanged notifies the previous email address of the account about the change so that the
+// legitimate owner can revert it if the session was hijacked
+func (pm *PortalMailer) SendEmailChanged(ctx context.Context, oldEmail, newEmail, revertPath string) error {
+	if len(oldEmail) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		NewEmail    string
+		RevertURL   string
+		ValidHours  int
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		NewEmail:    newEmail,
+		RevertURL:   fmt.Sprintf("https://%s%s", pm.Domain, revertPath),
+		ValidHours:  int(common.EmailChangeRevertTimeout.Hours()),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.changedHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.changedTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Your account email was changed", common.PrivateCaptcha),
+		EmailTo:   oldEmail,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send email changed notification", "email", oldEmail, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent email changed notification", "email", oldEmail)
+
+	return nil
+}
+
+// SendNewSignIn warns the user about sign-in from a new device or location
+func (pm *PortalMailer) SendNewSignIn(ctx context.Context, email string, info *common.SignInInfo, settingsPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		Time        string
+		Device      string
+		Country     string
+		IPAddress   string
+		SettingsURL string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		Time:        info.Timestamp.UTC().Format("02 Jan 2006 15:04 MST"),
+		Device:      info.Device,
+		Country:     info.Country,
+		IPAddress:   info.IPAddress,
+		SettingsURL: fmt.Sprintf("https://%s%s", pm.Domain, settingsPath),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.signInHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.signInTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] New sign-in to your account", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send new sign-in notification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent new sign-in notification", "email", email)
+
+	return nil
+}
+
+// SendAPIKeyRotated tells the user that a successor for the API key was generated
+func (pm *PortalMailer) SendAPIKeyRotated(ctx context.Context, email, keyName string, retireAt time.Time, settingsPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		KeyName     string
+		RetireDate  string
+		SettingsURL string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		KeyName:     keyName,
+		RetireDate:  retireAt.UTC().Format("02 Jan 2006 15:04 MST"),
+		SettingsURL: fmt.Sprintf("https://%s%s", pm.Domain, settingsPath),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.rotatedHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.rotatedTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Your API key was rotated", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send API key rotated notification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent API key rotated notification", "email", email)
+
+	return nil
+}
+
+// SendAPIKeyExpiring reminds the user that the API key will expire soon
+func (pm *PortalMailer) SendAPIKeyExpiring(ctx context.Context, email, keyName string, expiresAt time.Time, days int, settingsPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		KeyName     string
+		ExpireDate  string
+		Days        int
+		SettingsURL string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		KeyName:     keyName,
+		ExpireDate:  expiresAt.UTC().Format("02 Jan 2006 15:04 MST"),
+		Days:        days,
+		SettingsURL: fmt.Sprintf("https://%s%s", pm.Domain, settingsPath),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.expiringHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.expiringTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Your API key expires soon", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send API key expiring notification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent API key expiring notification", "email", email, "days", days)
+
+	return nil
+}
+
+// SendAPIKeySuspended tells the user that the API key was disabled due to anomalous usage
+func (pm *PortalMailer) SendAPIKeySuspended(ctx context.Context, email, keyName, reason string, settingsPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		KeyName     string
+		Reason      string
+		SettingsURL string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		KeyName:     keyName,
+		Reason:      reason,
+		SettingsURL: fmt.Sprintf("https://%s%s", pm.Domain, settingsPath),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.suspendedHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.suspendedTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Your API key was suspended", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send API key suspended notification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent API key suspended notification", "email", email)
+
+	return nil
+}
+
+// SendAccountLocked tells the user that sign-in was blocked after too many failed two-factor attempts
+func (pm *PortalMailer) SendAccountLocked(ctx context.Context, email string, lockedUntil time.Time) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		LockedUntil string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		LockedUntil: lockedUntil.UTC().Format("02 Jan 2006 15:04 MST"),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.lockedHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.lockedTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Sign-in to your account was blocked", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send account locked notification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent account locked notification", "email", email)
+
+	return nil
+}
+
+// SendBudgetAlert tells the organization owner that usage reached a threshold of the monthly budget
+func (pm *PortalMailer) SendBudgetAlert(ctx context.Context, email, orgName string, percent int, usage, budget int64, settingsPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		OrgName     string
+		Percent     int
+		Usage       int64
+		Budget      int64
+		SettingsURL string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		OrgName:     orgName,
+		Percent:     percent,
+		Usage:       usage,
+		Budget:      budget,
+		SettingsURL: fmt.Sprintf("https://%s%s", pm.Domain, settingsPath),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.budgetHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.budgetTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Organization reached %d%% of monthly budget", common.PrivateCaptcha, percent),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send budget alert", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent budget alert", "email", email, "percent", percent)
+
+	return nil
+}
+
+// SendOrgDeleted tells the organization member that the organization was deleted by the owner
+func (pm *PortalMailer) SendOrgDeleted(ctx context.Context, email, orgName string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		OrgName     string
+		PortalURL   string
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		OrgName:     orgName,
+		PortalURL:   fmt.Sprintf("https://%s/", pm.Domain),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.orgDeletedHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.orgDeletedTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Organization %s was deleted", common.PrivateCaptcha, orgName),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send organization deleted notification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent organization deleted notification", "email", email)
+
+	return nil
+}
+
+// SendEmailVerification sends confirmation link to the secondary email address of the user
+func (pm *PortalMailer) SendEmailVerification(ctx context.Context, email, confirmPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		ConfirmURL  string
+		ValidHours  int
+		Domain      string
+		CurrentYear int
+		CDN         string
+	}{
+		ConfirmURL:  fmt.Sprintf("https://%s%s", pm.Domain, confirmPath),
+		ValidHours:  int(common.EmailVerificationTimeout.Hours()),
+		CDN:         pm.CDN,
+		Domain:      fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.verifyHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.verifyTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Confirm your email address", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send email verification", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent email verification", "email", email)
+
+	return nil
+}
+
+// SendOrgDigest sends weekly summary of the organization activity to the owner
+func (pm *PortalMailer) SendOrgDigest(ctx context.Context, email string, digest *common.OrgDigest, orgPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	type spike struct {
+		Day      string
+		Failures uint64
+	}
+
+	spikes := make([]*spike, 0, len(digest.FailureSpikes))
+	for _, s := range digest.FailureSpikes {
+		spikes = append(spikes, &spike{Day: s.Day.Format("Mon, 02 Jan"), Failures: s.Failures})
+	}
+
+	data := struct {
+		OrgName        string
+		WeekStart      string
+		Requests       uint64
+		HasTrend       bool
+		Trend          int
+		VerifySuccess  uint64
+		VerifyFailure  uint64
+		NewProperties  int64
+		JoinedMembers  int64
+		InvitedMembers int64
+		Spikes         []*spike
+		OrgURL         string
+		Domain         string
+		CurrentYear    int
+		CDN            string
+	}{
+		OrgName:        digest.OrgName,
+		WeekStart:      digest.WeekStart.Format("02 Jan 2006"),
+		Requests:       digest.Requests,
+		HasTrend:       digest.PreviousRequests > 0,
+		Trend:          digest.RequestsTrend(),
+		VerifySuccess:  digest.VerifySuccess,
+		VerifyFailure:  digest.VerifyFailure,
+		NewProperties:  digest.NewProperties,
+		JoinedMembers:  digest.JoinedMembers,
+		InvitedMembers: digest.InvitedMembers,
+		Spikes:         spikes,
+		OrgURL:         fmt.Sprintf("https://%s%s", pm.Domain, orgPath),
+		CDN:            pm.CDN,
+		Domain:         fmt.Sprintf("https://%s/", pm.Domain),
+		CurrentYear:    time.Now().Year(),
+	}
+
+	var htmlBodyTpl bytes.Buffer
+	if err := pm.digestHTMLTemplate.Execute(&htmlBodyTpl, data); err != nil {
+		return err
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.digestTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		HTMLBody:  htmlBodyTpl.String(),
+		TextBody:  textBodyTpl.String(),
+		Subject:   fmt.Sprintf("[%s] Weekly summary of %s", common.PrivateCaptcha, digest.OrgName),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptcha,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send organization digest", "email", email, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent organization digest", "email", email)
+
+	return nil
+}
+
+func (pm *PortalMailer) supportMailbox() string {
+	if email := pm.SupportEmail.Value(); len(email) > 0 {
+		return email
+	}
+
+	return pm.AdminEmail.Value()
+}
+
+// SendSupportRequest relays support request (with attachments) from the user to the support mailbox.
+// Attachments have to pass all configured checks, otherwise nothing is sent.
+func (pm *PortalMailer) SendSupportRequest(ctx context.Context, email, ticketID, subject, message string, attachments []*Attachment) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	if err := pm.AttachmentPolicy.CheckCount(len(attachments)); err != nil {
+		return err
+	}
+
+	if err := CheckAttachments(ctx, pm.AttachmentCheckers, attachments); err != nil {
+		return err
+	}
+
+	data := struct {
+		Email            string
+		TicketID         string
+		Subject          string
+		Message          string
+		AttachmentsCount int
+	}{
+		Email:            email,
+		TicketID:         ticketID,
+		Subject:          subject,
+		Message:          message,
+		AttachmentsCount: len(attachments),
+	}
+
+	var textBodyTpl bytes.Buffer
+	if err := pm.supportTextTemplate.Execute(&textBodyTpl, data); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		TextBody:    textBodyTpl.String(),
+		Subject:     supportRequestSubject(ticketID, subject),
+		EmailTo:     pm.supportMailbox(),
+		EmailFrom:   pm.EmailFrom.Value(),
+		NameFrom:    common.PrivateCaptcha,
+		ReplyTo:     email,
+		Attachments: attachments,
+	}
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send support request", "ticketID", ticketID, common.ErrAttr(err))
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent support request", "email", email, "ticketID", ticketID, "attachments", len(attachments))
+
+	return nil
+}
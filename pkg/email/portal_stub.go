@@ -3,13 +3,25 @@ package email
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
 type StubMailer struct {
-	LastCode  int
-	LastEmail string
+	LastCode         int
+	LastLoginPath    string
+	LastEmail        string
+	LastRevertPath   string
+	LastSignIn       *common.SignInInfo
+	LastRotatedKey   string
+	LastExpiringKey  string
+	LastSuspendedKey string
+	LastLockedUntil  time.Time
+	LastBudgetAlert  int
+	LastDeletedOrg   string
+	LastConfirmPath  string
+	LastDigest       *common.OrgDigest
 }
 
 var _ common.Mailer = (*StubMailer)(nil)
@@ -21,7 +33,83 @@ func (sm *StubMailer) SendTwoFactor(ctx context.Context, email string, code int)
 	return nil
 }
 
+func (sm *StubMailer) SendMagicLink(ctx context.Context, email, loginPath string) error {
+	slog.InfoContext(ctx, "Sent sign-in link via email", "email", email)
+	sm.LastLoginPath = loginPath
+	sm.LastEmail = email
+	return nil
+}
+
 func (sm *StubMailer) SendWelcome(ctx context.Context, email string) error {
 	slog.InfoContext(ctx, "Sent welcome email", "email", email)
 	return nil
 }
+
+func (sm *StubMailer) SendEmailChanged(ctx context.Context, oldEmail, newEmail, revertPath string) error {
+	slog.InfoContext(ctx, "Sent email changed notification", "email", oldEmail, "new_email", newEmail)
+	sm.LastRevertPath = revertPath
+	return nil
+}
+
+func (sm *StubMailer) SendNewSignIn(ctx context.Context, email string, info *common.SignInInfo, settingsPath string) error {
+	slog.InfoContext(ctx, "Sent new sign-in notification", "email", email, "device", info.Device, "country", info.Country)
+	sm.LastSignIn = info
+	return nil
+}
+
+func (sm *StubMailer) SendAPIKeyRotated(ctx context.Context, email, keyName string, retireAt time.Time, settingsPath string) error {
+	slog.InfoContext(ctx, "Sent API key rotated notification", "email", email, "key", keyName, "retireAt", retireAt)
+	sm.LastRotatedKey = keyName
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendAPIKeyExpiring(ctx context.Context, email, keyName string, expiresAt time.Time, days int, settingsPath string) error {
+	slog.InfoContext(ctx, "Sent API key expiring notification", "email", email, "key", keyName, "expiresAt", expiresAt, "days", days)
+	sm.LastExpiringKey = keyName
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendAPIKeySuspended(ctx context.Context, email, keyName, reason string, settingsPath string) error {
+	slog.InfoContext(ctx, "Sent API key suspended notification", "email", email, "key", keyName, "reason", reason)
+	sm.LastSuspendedKey = keyName
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendAccountLocked(ctx context.Context, email string, lockedUntil time.Time) error {
+	slog.InfoContext(ctx, "Sent account locked notification", "email", email, "until", lockedUntil)
+	sm.LastLockedUntil = lockedUntil
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendBudgetAlert(ctx context.Context, email, orgName string, percent int, usage, budget int64, settingsPath string) error {
+	slog.InfoContext(ctx, "Sent budget alert", "email", email, "org", orgName, "percent", percent, "usage", usage,
+		"budget", budget)
+	sm.LastBudgetAlert = percent
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendOrgDeleted(ctx context.Context, email, orgName string) error {
+	slog.InfoContext(ctx, "Sent organization deleted notification", "email", email, "org", orgName)
+	sm.LastDeletedOrg = orgName
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendEmailVerification(ctx context.Context, email, confirmPath string) error {
+	slog.InfoContext(ctx, "Sent email verification", "email", email)
+	sm.LastConfirmPath = confirmPath
+	sm.LastEmail = email
+	return nil
+}
+
+func (sm *StubMailer) SendOrgDigest(ctx context.Context, email string, digest *common.OrgDigest, orgPath string) error {
+	slog.InfoContext(ctx, "Sent organization digest", "email", email, "org", digest.OrgName, "requests", digest.Requests)
+	sm.LastDigest = digest
+	sm.LastEmail = email
+	return nil
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+var (
+	// SMTP outages are usually short, but we don't want to deliver e.g. two-factor codes hours later
+	emailRetryPolicy = common.RetryPolicy{
+		MaxAttempts: 8,
+		BaseBackoff: 15 * time.Second,
+		MaxBackoff:  30 * time.Minute,
+	}
+)
+
+// Sender delivers composed message (SimpleMailer sends it right away and QueuedMailer in the background)
+type Sender interface {
+	SendEmail(ctx context.Context, msg *Message) error
+}
+
+var _ Sender = (*SimpleMailer)(nil)
+var _ Sender = (*QueuedMailer)(nil)
+
+// QueuedMailer sends emails in the background with retries. Messages are persisted in the database if the
+// queue has a store, so they are not lost on restarts
+type QueuedMailer struct {
+	Queue  *common.JobQueue
+	sender Sender
+}
+
+func NewQueuedMailer(sender Sender, store common.JobStore, metrics common.QueueMetrics) *QueuedMailer {
+	qm := &QueuedMailer{sender: sender}
+	qm.Queue = common.NewJobQueue(common.QueueEmail, qm.deliver, emailRetryPolicy, store, metrics)
+	return qm
+}
+
+func (qm *QueuedMailer) SendEmail(ctx context.Context, msg *Message) error {
+	if !msg.Valid() {
+		return errInvalidMessage
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize email", "email", msg.EmailTo, common.ErrAttr(err))
+		return err
+	}
+
+	return qm.Queue.Enqueue(ctx, payload)
+}
+
+func (qm *QueuedMailer) deliver(ctx context.Context, payload []byte) error {
+	msg := &Message{}
+	if err := json.Unmarshal(payload, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to deserialize queued email", common.ErrAttr(err))
+		return err
+	}
+
+	return qm.sender.SendEmail(ctx, msg)
+}
@@ -0,0 +1,53 @@
+package email
+
+import (
+	"context"
+	"testing"
+)
+
+type senderStub struct {
+	messages []*Message
+}
+
+func (s *senderStub) SendEmail(ctx context.Context, msg *Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func TestQueuedMailer(t *testing.T) {
+	ctx := context.TODO()
+	sender := &senderStub{}
+	mailer := NewQueuedMailer(sender, nil /*store*/, nil /*metrics*/)
+
+	if err := mailer.SendEmail(ctx, &Message{EmailTo: "to@example.com"}); err != errInvalidMessage {
+		t.Errorf("Unexpected error for invalid message: %v", err)
+	}
+
+	msg := &Message{
+		Subject:     "Test",
+		TextBody:    "text",
+		EmailTo:     "to@example.com",
+		EmailFrom:   "from@example.com",
+		Attachments: []*Attachment{{Filename: "a.txt", ContentType: "text/plain", Data: []byte("data")}},
+	}
+
+	if err := mailer.SendEmail(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.messages) != 0 {
+		t.Fatal("Email was sent synchronously")
+	}
+
+	if err := mailer.Queue.ProcessOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("Unexpected number of sent emails: %v", len(sender.messages))
+	}
+
+	if sent := sender.messages[0]; (sent.Subject != msg.Subject) || (len(sent.Attachments) != 1) || (string(sent.Attachments[0].Data) != "data") {
+		t.Errorf("Unexpected sent email: %+v", sent)
+	}
+}
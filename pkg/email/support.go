@@ -0,0 +1,81 @@
+package email
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	supportRequestTextTemplate = `
+New support request {{.TicketID}} from {{.Email}}
+
+Subject: {{.Subject}}
+
+{{.Message}}
+
+--------------------------------------------------------------------------------
+
+Attachments: {{.AttachmentsCount}}`
+)
+
+const (
+	// type of webhook event (in the outbox) with reply to the support request
+	SupportReplyEventType = "support.reply"
+)
+
+// InboundEmail is the payload of the inbound email webhook. Mail provider has to be configured to parse emails,
+// that are sent to the support mailbox, into this format.
+type InboundEmail struct {
+	MessageID string `json:"message_id"`
+	From      string `json:"from"`
+	Subject   string `json:"subject"`
+	Text      string `json:"text"`
+}
+
+var (
+	// replies keep the subject (with "Re:" etc. prefixes) so this is how they are matched to the ticket
+	supportTicketIDRegexp = regexp.MustCompile(`Support request ([A-Za-z0-9_-]+)`)
+	quoteHeaderRegexp     = regexp.MustCompile(`^On .+ wrote:$`)
+)
+
+func supportRequestSubject(ticketID, subject string) string {
+	if len(subject) == 0 {
+		return fmt.Sprintf("[%s] Support request %s", common.PrivateCaptcha, ticketID)
+	}
+
+	return fmt.Sprintf("[%s] Support request %s: %s", common.PrivateCaptcha, ticketID, subject)
+}
+
+// ParseSupportTicketID extracts ticket ID from the subject of the support request email or a reply to it
+func ParseSupportTicketID(subject string) (string, bool) {
+	matches := supportTicketIDRegexp.FindStringSubmatch(subject)
+	if len(matches) != 2 {
+		return "", false
+	}
+
+	return matches[1], true
+}
+
+// StripQuotedReply removes the quoted thread, that mail clients append to replies, from the text body
+func StripQuotedReply(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if quoteHeaderRegexp.MatchString(trimmed) || strings.HasPrefix(trimmed, "-----Original Message-----") {
+			break
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+
+		result = append(result, line)
+	}
+
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
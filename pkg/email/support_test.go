@@ -0,0 +1,47 @@
+package email
+
+import "testing"
+
+func TestParseSupportTicketID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		subject  string
+		ticketID string
+		ok       bool
+	}{
+		{supportRequestSubject("abc123", "Help"), "abc123", true},
+		{supportRequestSubject("abc-123", ""), "abc-123", true},
+		{"Re: RE: " + supportRequestSubject("x_y", "Something: else"), "x_y", true},
+		{"Hello", "", false},
+		{"Support request", "", false},
+	}
+
+	for _, tc := range testCases {
+		ticketID, ok := ParseSupportTicketID(tc.subject)
+		if (ok != tc.ok) || (ticketID != tc.ticketID) {
+			t.Errorf("Unexpected ticket ID for %q: %q (expected %q)", tc.subject, ticketID, tc.ticketID)
+		}
+	}
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{"Thanks!", "Thanks!"},
+		{"Thanks!\r\n\r\nOn Mon, 1 Jan 2030 at 10:00, Support <support@example.com> wrote:\r\n> Try again\r\n", "Thanks!"},
+		{"First\n> quoted\nSecond", "First\nSecond"},
+		{"Reply\n\n-----Original Message-----\nFrom: someone", "Reply"},
+		{"> only quoted", ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := StripQuotedReply(tc.text); actual != tc.expected {
+			t.Errorf("Unexpected stripped reply for %q: %q (expected %q)", tc.text, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	awsContentType  = "application/x-amz-json-1.1"
+	awsTarget       = "TrentService.GenerateMac"
+	awsService      = "kms"
+	awsAlgorithm    = "AWS4-HMAC-SHA256"
+	awsMACAlgorithm = "HMAC_SHA_256"
+)
+
+// awsSigner uses AWS KMS GenerateMac with an HMAC key. Credentials are taken from the standard
+// AWS environment variables (e.g. set by ECS/EKS or an instance profile helper).
+type awsSigner struct {
+	URL    string
+	Host   string
+	Region string
+	KeyID  string
+	Client *http.Client
+	getenv func(string) string
+}
+
+var _ Signer = (*awsSigner)(nil)
+
+func awsRegion(getenv func(string) string) string {
+	if region := getenv("AWS_REGION"); len(region) > 0 {
+		return region
+	}
+
+	return getenv("AWS_DEFAULT_REGION")
+}
+
+func newAWSSigner(endpoint, keyID string, client *http.Client) *awsSigner {
+	region := awsRegion(os.Getenv)
+	if len(endpoint) == 0 {
+		endpoint = "https://kms." + region + ".amazonaws.com"
+	}
+
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil {
+		host = u.Host
+	}
+
+	return &awsSigner{
+		URL:    endpoint + "/",
+		Host:   host,
+		Region: region,
+		KeyID:  keyID,
+		Client: client,
+		getenv: os.Getenv,
+	}
+}
+
+func (as *awsSigner) Name() string {
+	return "aws"
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (as *awsSigner) sign(req *http.Request, body []byte, tnow time.Time) {
+	amzDate := tnow.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if token := as.getenv("AWS_SESSION_TOKEN"); len(token) > 0 {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	headers := map[string]string{"host": as.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"", // query
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + as.Region + "/" + awsService + "/aws4_request"
+	stringToSign := strings.Join([]string{awsAlgorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+as.getenv("AWS_SECRET_ACCESS_KEY")), date)
+	key = hmacSHA256(key, as.Region)
+	key = hmacSHA256(key, awsService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(common.HeaderAuthorization, awsAlgorithm+" Credential="+as.getenv("AWS_ACCESS_KEY_ID")+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func (as *awsSigner) MAC(ctx context.Context, data []byte) ([]byte, error) {
+	body, err := json.Marshal(struct {
+		KeyID        string `json:"KeyId"`
+		Message      string `json:"Message"`
+		MacAlgorithm string `json:"MacAlgorithm"`
+	}{
+		KeyID:        as.KeyID,
+		Message:      base64.StdEncoding.EncodeToString(data),
+		MacAlgorithm: awsMACAlgorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, as.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderContentType, awsContentType)
+	req.Header.Set("X-Amz-Target", awsTarget)
+	as.sign(req, body, time.Now())
+
+	resp, err := as.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		slog.ErrorContext(ctx, "AWS KMS returned error", "status", resp.StatusCode)
+		return nil, errKMSStatus
+	}
+
+	response := struct {
+		Mac string `json:"Mac"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(response.Mac)
+}
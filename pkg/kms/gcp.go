@@ -0,0 +1,141 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultGCPEndpoint = "https://cloudkms.googleapis.com"
+	gcpMetadataToken   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// refresh access token a bit before it actually expires
+	gcpTokenLeeway = 1 * time.Minute
+)
+
+// gcpSigner uses Cloud KMS macSign. keyID is the full resource name of the key version:
+// projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*
+// If static token is not configured, access token is fetched from the metadata server.
+type gcpSigner struct {
+	URL         string
+	StaticToken string
+	Client      *http.Client
+	lock        sync.Mutex
+	token       string
+	expiresAt   time.Time
+}
+
+var _ Signer = (*gcpSigner)(nil)
+
+func newGCPSigner(endpoint, keyID, token string, client *http.Client) *gcpSigner {
+	if len(endpoint) == 0 {
+		endpoint = defaultGCPEndpoint
+	}
+
+	return &gcpSigner{
+		URL:         endpoint + "/v1/" + keyID + ":macSign",
+		StaticToken: token,
+		Client:      client,
+	}
+}
+
+func (gs *gcpSigner) Name() string {
+	return "gcp"
+}
+
+func (gs *gcpSigner) accessToken(ctx context.Context) (string, error) {
+	if len(gs.StaticToken) > 0 {
+		return gs.StaticToken, nil
+	}
+
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+
+	tnow := time.Now()
+	if (len(gs.token) > 0) && tnow.Before(gs.expiresAt) {
+		return gs.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataToken, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := gs.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		slog.ErrorContext(ctx, "GCP metadata server returned error", "status", resp.StatusCode)
+		return "", errKMSStatus
+	}
+
+	response := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", err
+	}
+
+	gs.token = response.AccessToken
+	gs.expiresAt = tnow.Add(time.Duration(response.ExpiresIn)*time.Second - gcpTokenLeeway)
+
+	return gs.token, nil
+}
+
+func (gs *gcpSigner) MAC(ctx context.Context, data []byte) ([]byte, error) {
+	token, err := gs.accessToken(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get GCP access token", common.ErrAttr(err))
+		return nil, err
+	}
+
+	body, err := json.Marshal(struct {
+		Data string `json:"data"`
+	}{Data: base64.StdEncoding.EncodeToString(data)})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, gs.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	req.Header.Set(common.HeaderAuthorization, "Bearer "+token)
+
+	resp, err := gs.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		slog.ErrorContext(ctx, "GCP KMS returned error", "status", resp.StatusCode)
+		return nil, errKMSStatus
+	}
+
+	response := struct {
+		Mac string `json:"mac"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(response.Mac)
+}
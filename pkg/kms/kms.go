@@ -0,0 +1,111 @@
+package kms
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	kmsTimeout            = 10 * time.Second
+	defaultRotationPeriod = 30 * 24 * time.Hour
+	// previous secret is accepted for one more period, so it must cover the longest puzzle validity
+	minRotationPeriod = 7 * 24 * time.Hour
+)
+
+var (
+	errKMSStatus       = errors.New("unexpected KMS response status")
+	errEmptyMAC        = errors.New("KMS returned empty MAC")
+	errUnknownProvider = errors.New("unknown KMS provider")
+	errMissingKeyID    = errors.New("KMS key ID is not configured")
+)
+
+// Signer computes HMAC of the data with the key that never leaves KMS. Output must be deterministic
+// for the same key version so that all server instances derive the same secrets.
+type Signer interface {
+	Name() string
+	MAC(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// NewSigner returns nil (and no error) when KMS is not configured
+func NewSigner(cfg common.ConfigStore) (Signer, error) {
+	provider := strings.ToLower(cfg.Get(common.KMSProviderKey).Value())
+	if len(provider) == 0 {
+		return nil, nil
+	}
+
+	keyID := cfg.Get(common.KMSKeyIDKey).Value()
+	if len(keyID) == 0 {
+		return nil, errMissingKeyID
+	}
+
+	endpoint := strings.TrimRight(cfg.Get(common.KMSEndpointKey).Value(), "/")
+	token := cfg.Get(common.KMSTokenKey).Value()
+	client := &http.Client{Timeout: kmsTimeout}
+
+	switch provider {
+	case "vault":
+		return newVaultSigner(endpoint, keyID, token, client), nil
+	case "aws":
+		return newAWSSigner(endpoint, keyID, client), nil
+	case "gcp":
+		return newGCPSigner(endpoint, keyID, token, client), nil
+	default:
+		return nil, errUnknownProvider
+	}
+}
+
+// Deriver produces secrets that are rotated every Period. Secrets are derived with KMS from the label
+// and the number of the rotation period (epoch), so no coordination between instances is needed.
+type Deriver struct {
+	Signer Signer
+	Period time.Duration
+}
+
+func NewDeriver(cfg common.ConfigStore, signer Signer) *Deriver {
+	if signer == nil {
+		return nil
+	}
+
+	period := defaultRotationPeriod
+	if value := cfg.Get(common.KMSRotationPeriodKey).Value(); len(value) > 0 {
+		if d, err := time.ParseDuration(value); err == nil {
+			period = max(d, minRotationPeriod)
+		} else {
+			slog.Error("Failed to parse KMS rotation period", "value", value, common.ErrAttr(err))
+		}
+	}
+
+	slog.Info("Using KMS to derive secrets", "provider", signer.Name(), "period", period.String())
+
+	return &Deriver{Signer: signer, Period: period}
+}
+
+func (d *Deriver) Epoch(t time.Time) int64 {
+	return t.Unix() / int64(d.Period/time.Second)
+}
+
+func (d *Deriver) Derive(ctx context.Context, label string, epoch int64) ([]byte, error) {
+	data := make([]byte, 0, len(label)+8)
+	data = append(data, label...)
+	data = binary.BigEndian.AppendUint64(data, uint64(epoch))
+
+	mac, err := d.Signer.MAC(ctx, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to derive secret with KMS", "provider", d.Signer.Name(), "epoch", epoch,
+			common.ErrAttr(err))
+		return nil, err
+	}
+
+	if len(mac) == 0 {
+		return nil, errEmptyMAC
+	}
+
+	return mac, nil
+}
@@ -0,0 +1,120 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func TestVaultSigner(t *testing.T) {
+	mac := []byte("0123456789abcdef")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transit/hmac/captcha/sha2-256" {
+			t.Errorf("Unexpected path: %v", r.URL.Path)
+		}
+
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]string{"hmac": "vault:v1:" + base64.StdEncoding.EncodeToString(mac)},
+		})
+	}))
+	defer srv.Close()
+
+	signer := newVaultSigner(srv.URL, "transit/captcha", "token", srv.Client())
+	actual, err := signer.MAC(context.TODO(), []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(actual, mac) {
+		t.Errorf("Unexpected MAC: %v", actual)
+	}
+
+	signer.Token = "wrong"
+	if _, err := signer.MAC(context.TODO(), []byte("data")); err != errKMSStatus {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestAWSSigner(t *testing.T) {
+	mac := []byte("fedcba9876543210")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != awsTarget {
+			t.Errorf("Unexpected target: %v", r.Header.Get("X-Amz-Target"))
+		}
+
+		auth := r.Header.Get(common.HeaderAuthorization)
+		if !strings.HasPrefix(auth, awsAlgorithm+" Credential=AKID/") ||
+			!strings.Contains(auth, "/eu-central-1/kms/aws4_request") ||
+			!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-target,") {
+			t.Errorf("Unexpected authorization header: %v", auth)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"Mac": base64.StdEncoding.EncodeToString(mac)})
+	}))
+	defer srv.Close()
+
+	env := map[string]string{
+		"AWS_REGION":            "eu-central-1",
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	}
+
+	signer := newAWSSigner(srv.URL, "alias/captcha", srv.Client())
+	signer.Region = env["AWS_REGION"]
+	signer.getenv = func(key string) string { return env[key] }
+
+	actual, err := signer.MAC(context.TODO(), []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(actual, mac) {
+		t.Errorf("Unexpected MAC: %v", actual)
+	}
+}
+
+type fakeSigner struct {
+	calls int
+}
+
+func (f *fakeSigner) Name() string { return "fake" }
+
+func (f *fakeSigner) MAC(ctx context.Context, data []byte) ([]byte, error) {
+	f.calls++
+	return append([]byte{}, data...), nil
+}
+
+func TestDeriverEpochs(t *testing.T) {
+	d := &Deriver{Signer: &fakeSigner{}, Period: 7 * 24 * time.Hour}
+
+	tnow := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if d.Epoch(tnow) == d.Epoch(tnow.Add(d.Period)) {
+		t.Errorf("Epoch did not change after rotation period")
+	}
+
+	first, _ := d.Derive(context.TODO(), "label", 1)
+	second, _ := d.Derive(context.TODO(), "label", 2)
+	if bytes.Equal(first, second) {
+		t.Errorf("Secrets for different epochs are equal")
+	}
+
+	again, _ := d.Derive(context.TODO(), "label", 1)
+	if !bytes.Equal(first, again) {
+		t.Errorf("Secret derivation is not deterministic")
+	}
+}
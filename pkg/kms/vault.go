@@ -0,0 +1,101 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultVaultAddress = "http://127.0.0.1:8200"
+	vaultMACPrefix      = "vault:"
+)
+
+var (
+	errInvalidVaultMAC = errors.New("invalid Vault HMAC format")
+)
+
+// vaultSigner uses HashiCorp Vault transit secrets engine (keyID is "mount/key", e.g. "transit/captcha")
+type vaultSigner struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+var _ Signer = (*vaultSigner)(nil)
+
+func newVaultSigner(address, keyID, token string, client *http.Client) *vaultSigner {
+	if len(address) == 0 {
+		address = defaultVaultAddress
+	}
+
+	mount, key, found := strings.Cut(keyID, "/")
+	if !found {
+		mount, key = "transit", keyID
+	}
+
+	return &vaultSigner{
+		URL:    address + "/v1/" + url.PathEscape(mount) + "/hmac/" + url.PathEscape(key) + "/sha2-256",
+		Token:  token,
+		Client: client,
+	}
+}
+
+func (vs *vaultSigner) Name() string {
+	return "vault"
+}
+
+func (vs *vaultSigner) MAC(ctx context.Context, data []byte) ([]byte, error) {
+	body, err := json.Marshal(struct {
+		Input string `json:"input"`
+	}{Input: base64.StdEncoding.EncodeToString(data)})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, vs.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	req.Header.Set("X-Vault-Token", vs.Token)
+
+	resp, err := vs.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		slog.ErrorContext(ctx, "Vault returned error", "status", resp.StatusCode)
+		return nil, errKMSStatus
+	}
+
+	response := struct {
+		Data struct {
+			HMAC string `json:"hmac"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	// format is "vault:v1:base64"
+	_, encoded, found := strings.Cut(strings.TrimPrefix(response.Data.HMAC, vaultMACPrefix), ":")
+	if !found {
+		return nil, errInvalidVaultMAC
+	}
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
@@ -38,6 +38,11 @@ type AddResult struct {
 }
 
 func (r *AddResult) Remaining() TLevel {
+	// capacity can be lowered after bucket was filled
+	if r.CurrLevel >= r.Capacity {
+		return 0
+	}
+
 	return r.Capacity - r.CurrLevel
 }
 
@@ -74,6 +79,14 @@ func (m *Manager[TKey, T, TBucket]) LeakInterval() time.Duration {
 	return m.leakInterval
 }
 
+// BucketsCount returns number of currently tracked buckets (excluding default bucket)
+func (m *Manager[TKey, T, TBucket]) BucketsCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.buckets)
+}
+
 func (m *Manager[TKey, T, TBucket]) Level(key TKey, tnow time.Time) (TLevel, bool) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -86,6 +99,31 @@ func (m *Manager[TKey, T, TBucket]) Level(key TKey, tnow time.Time) (TLevel, boo
 	return bucket.Level(tnow), true
 }
 
+// State returns current state of the bucket without adding anything to it
+func (m *Manager[TKey, T, TBucket]) State(key TKey, tnow time.Time) (AddResult, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var bucket TBucket
+
+	if m.defaultBucket != nil && (m.defaultBucket.Key() == key) {
+		bucket = m.defaultBucket
+	} else if existing, ok := m.buckets[key]; ok {
+		bucket = existing
+	} else {
+		return AddResult{}, false
+	}
+
+	level := bucket.Level(tnow)
+
+	return AddResult{
+		CurrLevel:  level,
+		Capacity:   bucket.Capacity(),
+		ResetAfter: time.Duration(level) * bucket.LeakInterval(),
+		Found:      true,
+	}, true
+}
+
 func (m *Manager[TKey, T, TBucket]) ensureUpperBoundUnsafe() {
 	if (m.upperBound > 0) && (len(m.buckets) > m.upperBound) {
 		last := m.heap.Peek()
@@ -42,6 +42,10 @@ func TestManagerCleanup(t *testing.T) {
 	if !slices.Equal(deletedKeys, []int32{7, 6, 5, 4}) {
 		t.Errorf("Unexpected keys were deleted: %v", deletedKeys)
 	}
+
+	if count := manager.BucketsCount(); count != maxBuckets-4 {
+		t.Errorf("Unexpected buckets count after cleanup: %v", count)
+	}
 }
 
 func TestManagerAdd(t *testing.T) {
@@ -153,3 +157,41 @@ func TestManagerIPAddrAddDefault(t *testing.T) {
 		t.Errorf("Managed to add to full bucket")
 	}
 }
+
+func TestManagerState(t *testing.T) {
+	const maxBuckets = 8
+	const cap = 5
+	const key = 123
+
+	manager := NewManager[int32, ConstLeakyBucket[int32]](maxBuckets, cap, 1*time.Second)
+	tnow := time.Now().Truncate(1 * time.Second)
+
+	if _, ok := manager.State(key, tnow); ok {
+		t.Fatal("State is available for unknown key")
+	}
+
+	manager.Add(key, 3, tnow)
+
+	state, ok := manager.State(key, tnow.Add(1*time.Second))
+	if !ok {
+		t.Fatal("State is not available")
+	}
+
+	if state.CurrLevel != 2 {
+		t.Errorf("Unexpected level: %v", state.CurrLevel)
+	}
+
+	if state.Remaining() != 3 {
+		t.Errorf("Unexpected remaining: %v", state.Remaining())
+	}
+
+	if state.ResetAfter != 2*time.Second {
+		t.Errorf("Unexpected reset after: %v", state.ResetAfter)
+	}
+
+	// lowering capacity below current level should not underflow
+	manager.UpdaterFunc(key)(1, 1*time.Second)
+	if state, _ := manager.State(key, tnow); state.Remaining() != 0 {
+		t.Errorf("Unexpected remaining after capacity update: %v", state.Remaining())
+	}
+}
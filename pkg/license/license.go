@@ -0,0 +1,132 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+const (
+	// license page starts warning about the expiration this long before it happens
+	ExpiryWarningPeriod = 30 * 24 * time.Hour
+)
+
+// PublicKey is the base64-encoded ed25519 key that verifies activation files. It is set at build time.
+var PublicKey string
+
+var (
+	ErrNoLicense        = errors.New("license file is not configured")
+	ErrLicenseExpired   = errors.New("license has expired")
+	errInvalidSignature = errors.New("license signature is invalid")
+	errInvalidPublicKey = errors.New("license public key is invalid")
+	errMalformedLicense = errors.New("license file is malformed")
+)
+
+// License describes terms of the self-hosted installation
+type License struct {
+	ID        string    `json:"id"`
+	Customer  string    `json:"customer"`
+	Plan      string    `json:"plan"`
+	MaxNodes  int       `json:"max_nodes"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// activationFile is issued for (offline) installations. License is base64-encoded JSON and the signature
+// covers its exact bytes, so that reformatting of the file does not invalidate it.
+type activationFile struct {
+	License   string `json:"license"`
+	Signature string `json:"signature"`
+}
+
+func ParsePublicKey(value string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errInvalidPublicKey
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// Parse verifies signature of the activation file and returns the license in it. Expiration is not checked.
+func Parse(data []byte, key ed25519.PublicKey) (*License, error) {
+	var file activationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	if (len(file.License) == 0) || (len(file.Signature) == 0) {
+		return nil, errMalformedLicense
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(file.License)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(file.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ed25519.Verify(key, payload, signature) {
+		return nil, errInvalidSignature
+	}
+
+	license := &License{}
+	if err := json.Unmarshal(payload, license); err != nil {
+		return nil, err
+	}
+
+	if len(license.ID) == 0 || license.ExpiresAt.IsZero() {
+		return nil, errMalformedLicense
+	}
+
+	return license, nil
+}
+
+func Load(path string, key ed25519.PublicKey) (*License, error) {
+	if len(path) == 0 {
+		return nil, ErrNoLicense
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data, key)
+}
+
+func (l *License) Check(tnow time.Time) error {
+	if tnow.After(l.ExpiresAt) {
+		return ErrLicenseExpired
+	}
+
+	return nil
+}
+
+func (l *License) ExpiresSoon(tnow time.Time) bool {
+	return l.ExpiresAt.Sub(tnow) < ExpiryWarningPeriod
+}
+
+// NodesExceeded returns true if more server instances are running than the license allows (0 means unlimited)
+func (l *License) NodesExceeded(nodes int64) bool {
+	return (l.MaxNodes > 0) && (nodes > int64(l.MaxNodes))
+}
+
+// NewNodeID returns random identifier of the server instance that does not reveal anything about the host
+func NewNodeID() string {
+	data := make([]byte, 16)
+	_, _ = rand.Read(data)
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,140 @@
+package license
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func signedLicense(t *testing.T, priv ed25519.PrivateKey, license *License) []byte {
+	payload, err := json.Marshal(license)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.MarshalIndent(&activationFile{
+		License:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload)),
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestParseLicense(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tnow := time.Now().UTC()
+	expected := &License{
+		ID:        "lic_123",
+		Customer:  "ACME",
+		Plan:      "enterprise",
+		MaxNodes:  3,
+		IssuedAt:  tnow.Add(-24 * time.Hour).Truncate(time.Second),
+		ExpiresAt: tnow.Add(10 * 24 * time.Hour).Truncate(time.Second),
+	}
+
+	data := signedLicense(t, priv, expected)
+
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := Parse(data, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (actual.ID != expected.ID) || (actual.MaxNodes != expected.MaxNodes) || !actual.ExpiresAt.Equal(expected.ExpiresAt) {
+		t.Errorf("Unexpected license: %+v", actual)
+	}
+
+	if err := actual.Check(tnow); err != nil {
+		t.Errorf("Unexpected license check error: %v", err)
+	}
+
+	if !actual.ExpiresSoon(tnow) {
+		t.Errorf("License should expire soon")
+	}
+
+	if err := actual.Check(tnow.Add(11 * 24 * time.Hour)); err != ErrLicenseExpired {
+		t.Errorf("Unexpected expired license check error: %v", err)
+	}
+
+	if actual.NodesExceeded(3) || !actual.NodesExceeded(4) {
+		t.Errorf("Unexpected nodes limit check")
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if _, err := Parse(data, otherPub); err != errInvalidSignature {
+		t.Errorf("Unexpected error for another key: %v", err)
+	}
+
+	tampered := *expected
+	tampered.MaxNodes = 100
+	file := &activationFile{}
+	_ = json.Unmarshal(data, file)
+	payload, _ := json.Marshal(&tampered)
+	file.License = base64.StdEncoding.EncodeToString(payload)
+	data, _ = json.Marshal(file)
+	if _, err := Parse(data, key); err != errInvalidSignature {
+		t.Errorf("Unexpected error for tampered license: %v", err)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	testCases := []struct {
+		value    int64
+		expected int64
+	}{
+		{-1, 0},
+		{0, 0},
+		{7, 7},
+		{10, 10},
+		{19, 10},
+		{1234, 1000},
+		{56789, 50000},
+		{999999, 900000},
+	}
+
+	for _, tc := range testCases {
+		if actual := Bucket(tc.value); actual != tc.expected {
+			t.Errorf("Unexpected bucket for %v: %v (expected %v)", tc.value, actual, tc.expected)
+		}
+	}
+}
+
+func TestSendReport(t *testing.T) {
+	var received Report
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	license := &License{ID: "lic_123"}
+	report := NewReport(license, "v1", time.Now(), 2 /*nodes*/, 1234 /*users*/, 56 /*orgs*/, 789 /*properties*/, 123456 /*requests*/)
+
+	if err := SendReport(context.TODO(), srv.Client(), srv.URL, report); err != nil {
+		t.Fatal(err)
+	}
+
+	if (received.LicenseID != license.ID) || (received.Users != 1000) || (received.MonthlyRequests != 100000) {
+		t.Errorf("Unexpected report: %+v", received)
+	}
+}
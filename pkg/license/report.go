@@ -0,0 +1,97 @@
+package license
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	NodeHeartbeatInterval = 5 * time.Minute
+	// node is considered running if it sent a heartbeat within this window
+	NodeActiveWindow = 3 * NodeHeartbeatInterval
+	reportTimeout    = 30 * time.Second
+)
+
+var (
+	errReportStatus = errors.New("unexpected license report response status")
+)
+
+// Report is sent periodically for the license. It contains only aggregates that are rounded with Bucket(),
+// and no information about users, domains or hosts.
+type Report struct {
+	LicenseID       string `json:"license_id"`
+	Version         string `json:"version"`
+	Date            string `json:"date"`
+	Nodes           int64  `json:"nodes"`
+	Users           int64  `json:"users"`
+	Organizations   int64  `json:"organizations"`
+	Properties      int64  `json:"properties"`
+	MonthlyRequests int64  `json:"monthly_requests"`
+}
+
+// Bucket rounds value down to a single significant digit (e.g. 1234 -> 1000, 56789 -> 50000)
+func Bucket(value int64) int64 {
+	if value < 10 {
+		return max(value, 0)
+	}
+
+	magnitude := int64(1)
+	for value/magnitude >= 10 {
+		magnitude *= 10
+	}
+
+	return (value / magnitude) * magnitude
+}
+
+func NewReport(license *License, version string, tnow time.Time, nodes, users, orgs, properties, requests int64) *Report {
+	return &Report{
+		LicenseID:       license.ID,
+		Version:         version,
+		Date:            tnow.UTC().Format(time.DateOnly),
+		Nodes:           nodes,
+		Users:           Bucket(users),
+		Organizations:   Bucket(orgs),
+		Properties:      Bucket(properties),
+		MonthlyRequests: Bucket(requests),
+	}
+}
+
+func SendReport(ctx context.Context, client *http.Client, url string, report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		slog.ErrorContext(ctx, "License report was rejected", "status", resp.StatusCode)
+		return errReportStatus
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package maintenance
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func jobFailedAlert(name string, err error) *common.Alert {
+	return &common.Alert{
+		Key:      "job_failed_" + name,
+		Severity: common.AlertSeverityWarning,
+		Title:    "Job " + name + " failed",
+		Text:     err.Error(),
+	}
+}
+
+// alertingPeriodicJob notifies operators when the job starts failing and when it recovers, but not about every
+// failed run in between
+type alertingPeriodicJob struct {
+	common.PeriodicJob
+	alerter common.Alerter
+	failing atomic.Bool
+}
+
+var _ common.PeriodicJob = (*alertingPeriodicJob)(nil)
+
+func (j *alertingPeriodicJob) RunOnce(ctx context.Context) error {
+	err := j.PeriodicJob.RunOnce(ctx)
+
+	if err != nil {
+		if !j.failing.Swap(true) {
+			j.alerter.SendAlert(ctx, jobFailedAlert(j.Name(), err))
+		}
+	} else if j.failing.Swap(false) {
+		j.alerter.SendAlert(ctx, &common.Alert{
+			Key:      "job_recovered_" + j.Name(),
+			Severity: common.AlertSeverityInfo,
+			Title:    "Job " + j.Name() + " succeeded after failures",
+		})
+	}
+
+	return err
+}
+
+type alertingOneOffJob struct {
+	common.OneOffJob
+	alerter common.Alerter
+}
+
+var _ common.OneOffJob = (*alertingOneOffJob)(nil)
+
+func (j *alertingOneOffJob) RunOnce(ctx context.Context) error {
+	err := j.OneOffJob.RunOnce(ctx)
+	if err != nil {
+		j.alerter.SendAlert(ctx, jobFailedAlert(j.Name(), err))
+	}
+	return err
+}
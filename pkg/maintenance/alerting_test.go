@@ -0,0 +1,68 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+type recordingAlerter struct {
+	alerts []*common.Alert
+}
+
+func (ra *recordingAlerter) SendAlert(ctx context.Context, alert *common.Alert) {
+	ra.alerts = append(ra.alerts, alert)
+}
+
+type failingJob struct {
+	err error
+}
+
+func (j *failingJob) RunOnce(ctx context.Context) error { return j.err }
+func (j *failingJob) Interval() time.Duration           { return time.Minute }
+func (j *failingJob) Jitter() time.Duration             { return 1 }
+func (j *failingJob) Name() string                      { return "failing_job" }
+
+func TestAlertingPeriodicJob(t *testing.T) {
+	alerter := &recordingAlerter{}
+	inner := &failingJob{err: errors.New("failure")}
+	job := &alertingPeriodicJob{PeriodicJob: inner, alerter: alerter}
+	ctx := context.TODO()
+
+	for i := 0; i < 3; i++ {
+		if err := job.RunOnce(ctx); err == nil {
+			t.Fatal("Error was swallowed")
+		}
+	}
+
+	if len(alerter.alerts) != 1 || (alerter.alerts[0].Severity != common.AlertSeverityWarning) {
+		t.Fatalf("Unexpected alerts after repeated failures: %v", alerter.alerts)
+	}
+
+	inner.err = nil
+	_ = job.RunOnce(ctx)
+	_ = job.RunOnce(ctx)
+
+	if len(alerter.alerts) != 2 || (alerter.alerts[1].Severity != common.AlertSeverityInfo) {
+		t.Errorf("Unexpected alerts after recovery: %v", alerter.alerts)
+	}
+}
+
+func TestHealthChangeAlert(t *testing.T) {
+	alerter := &recordingAlerter{}
+	hc := &HealthCheckJob{Alerter: alerter}
+	ctx := context.TODO()
+
+	hc.alertHealthChange(ctx, "Postgres", FlagTrue, FlagTrue)
+	hc.alertHealthChange(ctx, "Postgres", FlagTrue, FlagFalse)
+	hc.alertHealthChange(ctx, "Postgres", FlagFalse, FlagTrue)
+
+	if (len(alerter.alerts) != 2) ||
+		(alerter.alerts[0].Severity != common.AlertSeverityCritical) ||
+		(alerter.alerts[1].Severity != common.AlertSeverityInfo) {
+		t.Errorf("Unexpected alerts: %v", alerter.alerts)
+	}
+}
@@ -0,0 +1,317 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	apiKeysRotationBatchSize = 100
+	apiKeysExpiryBatchSize   = 100
+	// usage of the last hour is compared to the average hourly usage during the previous day
+	apiKeyUsageRecentPeriod   = 1 * time.Hour
+	apiKeyUsageBaselinePeriod = 24 * time.Hour
+	apiKeyUsageRetention      = 7 * 24 * time.Hour
+	// growth of hourly requests that is considered anomalous
+	apiKeyAnomalyGrowthFactor = 100
+	// keys with low usage are not checked for growth as their traffic is naturally spiky
+	apiKeyAnomalyMinRequests = 1000
+	// verifications of puzzles that belong to properties not owned by the owner of the key (within recent period)
+	apiKeyAnomalyMaxWrongOwner = 50
+)
+
+var (
+	// days before expiry when owners are reminded, the shortest period goes first so that keys that are about
+	// to expire do not receive both reminders at once
+	apiKeyExpiryReminderDays = []int{3, 14}
+)
+
+// RotateAPIKeysJob generates successors for API keys with rotation policy and disables rotated keys
+// after the overlap window, during which both old and new keys are valid
+type RotateAPIKeysJob struct {
+	Store        db.Implementor
+	Mailer       common.Mailer
+	Overlap      time.Duration
+	SettingsPath string
+}
+
+var _ common.PeriodicJob = (*RotateAPIKeysJob)(nil)
+
+func (j *RotateAPIKeysJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *RotateAPIKeysJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *RotateAPIKeysJob) Name() string {
+	return "rotate_apikeys_job"
+}
+
+func (j *RotateAPIKeysJob) rotateKey(ctx context.Context, key *dbgen.APIKey, tnow time.Time) error {
+	if err := j.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		_, err := impl.RotateAPIKey(ctx, key, tnow)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	ownerID, _, err := j.Store.Impl().RetrieveAPIKeyOwner(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	user, err := j.Store.Impl().RetrieveUser(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("API key <strong>%s</strong> was rotated, old secret stops working on %s.",
+		html.EscapeString(key.Name), tnow.Add(j.Overlap).Format("02 Jan 2006"))
+	if _, err := j.Store.Impl().CreateUserNotification(ctx, user.ID, dbgen.NotificationCategorySecurity, message); err != nil {
+		slog.ErrorContext(ctx, "Failed to create API key rotation notification", "keyID", key.ID, common.ErrAttr(err))
+	}
+
+	return j.Mailer.SendAPIKeyRotated(ctx, user.Email, key.Name, tnow.Add(j.Overlap), j.SettingsPath)
+}
+
+func (j *RotateAPIKeysJob) RunOnce(ctx context.Context) error {
+	tnow := time.Now().UTC()
+
+	if _, err := j.Store.Impl().DisableRotatedAPIKeys(ctx, tnow.Add(-j.Overlap)); err != nil {
+		return err
+	}
+
+	keys, err := j.Store.Impl().RetrieveAPIKeysDueForRotation(ctx, apiKeysRotationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := j.rotateKey(ctx, key, tnow); err != nil {
+			slog.ErrorContext(ctx, "Failed to rotate API key", "keyID", key.ID, common.ErrAttr(err))
+		}
+	}
+
+	slog.DebugContext(ctx, "Rotated API keys", "count", len(keys))
+
+	return nil
+}
+
+// ExpireAPIKeysJob reminds owners about API keys that expire soon, disables expired keys and archives the ones
+// that were expired for longer than ArchiveAfter
+type ExpireAPIKeysJob struct {
+	Store        db.Implementor
+	Mailer       common.Mailer
+	ArchiveAfter time.Duration
+	SettingsPath string
+}
+
+var _ common.PeriodicJob = (*ExpireAPIKeysJob)(nil)
+
+func (j *ExpireAPIKeysJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *ExpireAPIKeysJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *ExpireAPIKeysJob) Name() string {
+	return "expire_apikeys_job"
+}
+
+// daysLeft rounds the time until expiration up to whole days
+func daysLeft(expiresAt, tnow time.Time) int {
+	return int(math.Ceil(expiresAt.Sub(tnow).Hours() / 24))
+}
+
+func (j *ExpireAPIKeysJob) remind(ctx context.Context, key *dbgen.APIKey, reminderDays int, tnow time.Time) error {
+	// reminder state is saved first so that a failure below does not cause repeated emails
+	if err := j.Store.Impl().UpdateAPIKeyExpiryNotified(ctx, key, reminderDays); err != nil {
+		return err
+	}
+
+	ownerID, _, err := j.Store.Impl().RetrieveAPIKeyOwner(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	user, err := j.Store.Impl().RetrieveUser(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	days := daysLeft(key.ExpiresAt.Time, tnow)
+
+	message := fmt.Sprintf("API key <strong>%s</strong> expires in %d days, on %s.",
+		html.EscapeString(key.Name), days, key.ExpiresAt.Time.Format("02 Jan 2006"))
+	if _, err := j.Store.Impl().CreateUserNotification(ctx, user.ID, dbgen.NotificationCategorySecurity, message); err != nil {
+		slog.ErrorContext(ctx, "Failed to create API key expiring notification", "keyID", key.ID, common.ErrAttr(err))
+	}
+
+	return j.Mailer.SendAPIKeyExpiring(ctx, user.Email, key.Name, key.ExpiresAt.Time, days, j.SettingsPath)
+}
+
+func (j *ExpireAPIKeysJob) RunOnce(ctx context.Context) error {
+	tnow := time.Now().UTC()
+
+	for _, reminderDays := range apiKeyExpiryReminderDays {
+		keys, err := j.Store.Impl().RetrieveAPIKeysExpiringSoon(ctx, tnow, reminderDays, apiKeysExpiryBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := j.remind(ctx, key, reminderDays, tnow); err != nil {
+				slog.ErrorContext(ctx, "Failed to remind about expiring API key", "keyID", key.ID, common.ErrAttr(err))
+			}
+		}
+
+		slog.DebugContext(ctx, "Reminded about expiring API keys", "days", reminderDays, "count", len(keys))
+	}
+
+	expired, err := j.Store.Impl().ExpireAPIKeys(ctx, tnow)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range expired {
+		ownerID, _, err := j.Store.Impl().RetrieveAPIKeyOwner(ctx, key)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve API key owner", "keyID", key.ID, common.ErrAttr(err))
+			continue
+		}
+
+		message := fmt.Sprintf("API key <strong>%s</strong> has expired and was disabled.", html.EscapeString(key.Name))
+		if _, err := j.Store.Impl().CreateUserNotification(ctx, ownerID, dbgen.NotificationCategorySecurity, message); err != nil {
+			slog.ErrorContext(ctx, "Failed to create API key expired notification", "keyID", key.ID, common.ErrAttr(err))
+		}
+	}
+
+	archived, err := j.Store.Impl().ArchiveExpiredAPIKeys(ctx, tnow.Add(-j.ArchiveAfter), apiKeysExpiryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "Cleaned up expired API keys", "expired", len(expired), "archived", len(archived))
+
+	return nil
+}
+
+// apiKeyAnomaly returns the reason to suspend the key based on its usage or empty string if usage looks normal
+func apiKeyAnomaly(stats *dbgen.GetAPIKeysUsageStatsRow, baselineFrom time.Time) string {
+	if stats.RecentWrongOwner >= apiKeyAnomalyMaxWrongOwner {
+		return fmt.Sprintf("%d verifications of puzzles for properties that are not owned by the key owner", stats.RecentWrongOwner)
+	}
+
+	if stats.RecentRequests < apiKeyAnomalyMinRequests {
+		return ""
+	}
+
+	// keys created during the baseline period do not have usage history to compare with
+	if stats.APIKey.CreatedAt.Time.After(baselineFrom) {
+		return ""
+	}
+
+	hours := int64(apiKeyUsageBaselinePeriod / apiKeyUsageRecentPeriod)
+	baseline := max(stats.BaselineRequests/hours, 1)
+
+	if growth := stats.RecentRequests / baseline; growth >= apiKeyAnomalyGrowthFactor {
+		return fmt.Sprintf("hourly requests grew %d times compared to the previous day", growth)
+	}
+
+	return ""
+}
+
+// SuspendAnomalousAPIKeysJob disables API keys with anomalous usage (sudden growth of requests or verification of
+// puzzles that belong to someone else), which usually means that the key was leaked. Owners can re-enable keys
+// after review
+type SuspendAnomalousAPIKeysJob struct {
+	Store        db.Implementor
+	Mailer       common.Mailer
+	SettingsPath string
+}
+
+var _ common.PeriodicJob = (*SuspendAnomalousAPIKeysJob)(nil)
+
+func (j *SuspendAnomalousAPIKeysJob) Interval() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *SuspendAnomalousAPIKeysJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *SuspendAnomalousAPIKeysJob) Name() string {
+	return "suspend_anomalous_apikeys_job"
+}
+
+func (j *SuspendAnomalousAPIKeysJob) suspendKey(ctx context.Context, key *dbgen.APIKey, reason string) error {
+	suspended, err := j.Store.Impl().SuspendAPIKey(ctx, key.ID, reason)
+	if err != nil {
+		return err
+	}
+
+	ownerID, _, err := j.Store.Impl().RetrieveAPIKeyOwner(ctx, suspended)
+	if err != nil {
+		return err
+	}
+
+	user, err := j.Store.Impl().RetrieveUser(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("API key <strong>%s</strong> was suspended due to unusual activity (%s). Review it and re-enable in settings.",
+		html.EscapeString(key.Name), html.EscapeString(reason))
+	if _, err := j.Store.Impl().CreateUserNotification(ctx, user.ID, dbgen.NotificationCategorySecurity, message); err != nil {
+		slog.ErrorContext(ctx, "Failed to create API key suspended notification", "keyID", key.ID, common.ErrAttr(err))
+	}
+
+	return j.Mailer.SendAPIKeySuspended(ctx, user.Email, key.Name, reason, j.SettingsPath)
+}
+
+func (j *SuspendAnomalousAPIKeysJob) RunOnce(ctx context.Context) error {
+	tnow := time.Now().UTC()
+	recentFrom := tnow.Add(-apiKeyUsageRecentPeriod)
+	baselineFrom := recentFrom.Add(-apiKeyUsageBaselinePeriod)
+
+	stats, err := j.Store.Impl().RetrieveAPIKeysUsageStats(ctx, recentFrom, baselineFrom)
+	if err != nil {
+		return err
+	}
+
+	suspended := 0
+
+	for _, s := range stats {
+		reason := apiKeyAnomaly(s, baselineFrom)
+		if len(reason) == 0 {
+			continue
+		}
+
+		if err := j.suspendKey(ctx, &s.APIKey, reason); err != nil {
+			slog.ErrorContext(ctx, "Failed to suspend API key", "keyID", s.APIKey.ID, common.ErrAttr(err))
+			continue
+		}
+
+		suspended++
+	}
+
+	if err := j.Store.Impl().DeleteAPIKeysUsage(ctx, tnow.Add(-apiKeyUsageRetention)); err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "Checked API keys usage", "keys", len(stats), "suspended", suspended)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestDaysLeft(t *testing.T) {
+	tnow := time.Now().UTC()
+
+	testCases := []struct {
+		expiresAt time.Time
+		expected  int
+	}{
+		{tnow.Add(1 * time.Hour), 1},
+		{tnow.Add(24 * time.Hour), 1},
+		{tnow.Add(25 * time.Hour), 2},
+		{tnow.AddDate(0, 0, 14), 14},
+	}
+
+	for i, tc := range testCases {
+		if actual := daysLeft(tc.expiresAt, tnow); actual != tc.expected {
+			t.Errorf("Unexpected days left at %v: expected %v, got %v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestAPIKeyAnomaly(t *testing.T) {
+	tnow := time.Now().UTC()
+	baselineFrom := tnow.Add(-apiKeyUsageRecentPeriod - apiKeyUsageBaselinePeriod)
+	oldKey := dbgen.APIKey{CreatedAt: db.Timestampz(tnow.AddDate(0, -1, 0))}
+	newKey := dbgen.APIKey{CreatedAt: db.Timestampz(tnow.Add(-2 * time.Hour))}
+
+	testCases := []struct {
+		stats   dbgen.GetAPIKeysUsageStatsRow
+		anomaly bool
+	}{
+		// steady usage
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: oldKey, RecentRequests: 1000, BaselineRequests: 24 * 1000}, false},
+		// low usage is not checked for growth
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: oldKey, RecentRequests: 500, BaselineRequests: 0}, false},
+		// sudden growth
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: oldKey, RecentRequests: 100_000, BaselineRequests: 24 * 10}, true},
+		// idle key that suddenly got traffic
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: oldKey, RecentRequests: 5000, BaselineRequests: 0}, true},
+		// new key without history
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: newKey, RecentRequests: 5000, BaselineRequests: 0}, false},
+		// puzzles of other owners
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: newKey, RecentRequests: 100, RecentWrongOwner: apiKeyAnomalyMaxWrongOwner}, true},
+		{dbgen.GetAPIKeysUsageStatsRow{APIKey: oldKey, RecentRequests: 100, RecentWrongOwner: 1}, false},
+	}
+
+	for i, tc := range testCases {
+		if reason := apiKeyAnomaly(&tc.stats, baselineFrom); (len(reason) > 0) != tc.anomaly {
+			t.Errorf("Unexpected anomaly result at %v: %q", i, reason)
+		}
+	}
+}
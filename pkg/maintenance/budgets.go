@@ -0,0 +1,162 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+// sorted from the highest, only the highest reached threshold is alerted
+var budgetAlertThresholds = []int16{100, 80, 50}
+
+// OrgBudgetAlertsJob compares monthly usage of organizations against budgets that their owners set and sends
+// alerts when thresholds are reached. Budgets are independent of plan limits and do not affect captcha.
+type OrgBudgetAlertsJob struct {
+	Store         db.Implementor
+	TimeSeries    common.TimeSeriesStore
+	Mailer        common.Mailer
+	OrgPathPrefix string
+}
+
+var _ common.PeriodicJob = (*OrgBudgetAlertsJob)(nil)
+
+func (j *OrgBudgetAlertsJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *OrgBudgetAlertsJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *OrgBudgetAlertsJob) Name() string {
+	return "org_budget_alerts_job"
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// budgetAlertLevel returns the highest threshold reached by usage that was not yet alerted this month, or 0
+func budgetAlertLevel(budget *dbgen.OrgBudget, usage uint64, month time.Time) int16 {
+	if budget.MonthlyLimit <= 0 {
+		return 0
+	}
+
+	alerted := budget.AlertedPercent
+	if !budget.AlertedAt.Valid || budget.AlertedAt.Time.Before(month) {
+		alerted = 0
+	}
+
+	for _, threshold := range budgetAlertThresholds {
+		if usage*100 >= uint64(budget.MonthlyLimit)*uint64(threshold) {
+			if threshold > alerted {
+				return threshold
+			}
+
+			return 0
+		}
+	}
+
+	return 0
+}
+
+// alertEmail returns billing contact of the organization, if it's set, or primary email of the owner otherwise
+func (j *OrgBudgetAlertsJob) alertEmail(ctx context.Context, orgID, ownerID int32) (string, error) {
+	email, err := j.Store.Impl().RetrieveOrgBillingEmail(ctx, orgID)
+	if err == nil {
+		return email, nil
+	} else if err != db.ErrRecordNotFound {
+		slog.WarnContext(ctx, "Failed to retrieve org billing contact", "orgID", orgID, common.ErrAttr(err))
+	}
+
+	user, err := j.Store.Impl().RetrieveUser(ctx, ownerID)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Email, nil
+}
+
+func (j *OrgBudgetAlertsJob) sendAlert(ctx context.Context, row *dbgen.GetActiveOrgBudgetsRow, percent int16, usage uint64, tnow time.Time) error {
+	// alert state is saved first so that a failure below does not cause repeated alerts
+	if err := j.Store.Impl().UpdateOrgBudgetAlert(ctx, row.OrgBudget.OrgID, percent, tnow); err != nil {
+		return err
+	}
+
+	if !row.OwnerID.Valid {
+		return nil
+	}
+
+	ownerID := row.OwnerID.Int32
+	message := fmt.Sprintf("Organization <strong>%s</strong> has used %d%% of its monthly budget (%d of %d requests).",
+		html.EscapeString(row.OrgName), percent, usage, row.OrgBudget.MonthlyLimit)
+	duration := monthStart(tnow).AddDate(0, 1, 0).Sub(tnow)
+	if _, err := j.Store.Impl().CreateNotification(ctx, message, tnow, &duration, &ownerID); err != nil {
+		return err
+	}
+
+	if _, err := j.Store.Impl().CreateUserNotification(ctx, ownerID, dbgen.NotificationCategoryUsage, message); err != nil {
+		return err
+	}
+
+	email, err := j.alertEmail(ctx, row.OrgBudget.OrgID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	orgPath := j.OrgPathPrefix + "/" + strconv.Itoa(int(row.OrgBudget.OrgID))
+
+	return j.Mailer.SendBudgetAlert(ctx, email, row.OrgName, int(percent), int64(usage), row.OrgBudget.MonthlyLimit, orgPath)
+}
+
+func (j *OrgBudgetAlertsJob) RunOnce(ctx context.Context) error {
+	tnow := time.Now().UTC()
+	month := monthStart(tnow)
+
+	budgets, err := j.Store.Impl().RetrieveActiveOrgBudgets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	orgIDs := make([]int32, 0, len(budgets))
+	for _, b := range budgets {
+		orgIDs = append(orgIDs, b.OrgBudget.OrgID)
+	}
+
+	usage, err := j.TimeSeries.ReadOrgsMonthlyUsage(ctx, orgIDs, month)
+	if err != nil {
+		return err
+	}
+
+	alerts := 0
+
+	for _, b := range budgets {
+		orgUsage := usage[b.OrgBudget.OrgID]
+		percent := budgetAlertLevel(&b.OrgBudget, orgUsage, month)
+		if percent == 0 {
+			continue
+		}
+
+		if err := j.sendAlert(ctx, b, percent, orgUsage, tnow); err != nil {
+			slog.ErrorContext(ctx, "Failed to send org budget alert", "orgID", b.OrgBudget.OrgID, "percent", percent,
+				common.ErrAttr(err))
+		} else {
+			alerts++
+		}
+	}
+
+	slog.DebugContext(ctx, "Checked org budgets", "count", len(budgets), "alerts", alerts)
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestBudgetAlertLevel(t *testing.T) {
+	month := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	thisMonth := month.Add(48 * time.Hour)
+	lastMonth := month.Add(-48 * time.Hour)
+
+	testCases := []struct {
+		alerted   int16
+		alertedAt time.Time
+		usage     uint64
+		expected  int16
+	}{
+		{0, time.Time{}, 499, 0},
+		{0, time.Time{}, 500, 50},
+		{0, time.Time{}, 850, 80},
+		{0, time.Time{}, 1200, 100},
+		{50, thisMonth, 700, 0},
+		{50, thisMonth, 800, 80},
+		{80, thisMonth, 999, 0},
+		{100, thisMonth, 5000, 0},
+		{100, lastMonth, 600, 50},
+	}
+
+	for i, tc := range testCases {
+		budget := &dbgen.OrgBudget{MonthlyLimit: 1000, AlertedPercent: tc.alerted}
+		if !tc.alertedAt.IsZero() {
+			budget.AlertedAt = db.Timestampz(tc.alertedAt)
+		}
+
+		if actual := budgetAlertLevel(budget, tc.usage, month); actual != tc.expected {
+			t.Errorf("Unexpected alert level at %v: %v (expected %v)", i, actual, tc.expected)
+		}
+	}
+
+	if level := budgetAlertLevel(&dbgen.OrgBudget{MonthlyLimit: 0}, 100, month); level != 0 {
+		t.Errorf("Disabled budget produced an alert: %v", level)
+	}
+}
@@ -30,6 +30,29 @@ func (j *CleanupDBCacheJob) RunOnce(ctx context.Context) error {
 	return j.Store.Impl().DeleteExpiredCache(ctx)
 }
 
+// RefreshPropertySnapshotJob keeps local (per-node) property snapshot, used when Postgres is degraded, up to date
+type RefreshPropertySnapshotJob struct {
+	Store db.Implementor
+}
+
+var _ common.PeriodicJob = (*RefreshPropertySnapshotJob)(nil)
+
+func (j *RefreshPropertySnapshotJob) Interval() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *RefreshPropertySnapshotJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *RefreshPropertySnapshotJob) Name() string {
+	return "refresh_property_snapshot_job"
+}
+
+func (j *RefreshPropertySnapshotJob) RunOnce(ctx context.Context) error {
+	return j.Store.Impl().RefreshPropertySnapshot(ctx, time.Now().UTC())
+}
+
 type CleanupDeletedRecordsJob struct {
 	Store db.Implementor
 	Age   time.Duration
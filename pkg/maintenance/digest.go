@@ -0,0 +1,198 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	daysInWeek         = 7
+	maxDigestsPerRun   = 100
+	minSpikeFailures   = 50
+	failureSpikeFactor = 3
+)
+
+type weeklyUsage struct {
+	requests [2 * daysInWeek]uint64
+	success  [2 * daysInWeek]uint64
+	failures [2 * daysInWeek]uint64
+}
+
+// OrgDigestJob sends weekly activity summary of the organization to the owner. Digest is marked as sent before
+// sending so that each organization receives at most one digest per week even if the job is restarted.
+type OrgDigestJob struct {
+	Store         db.Implementor
+	TimeSeries    common.TimeSeriesStore
+	Mailer        common.Mailer
+	OrgPathPrefix string
+}
+
+var _ common.PeriodicJob = (*OrgDigestJob)(nil)
+
+func (j *OrgDigestJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *OrgDigestJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *OrgDigestJob) Name() string {
+	return "org_digest_job"
+}
+
+// weekStart returns the start (Monday, UTC) of the week that contains {t}
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + daysInWeek - int(time.Monday)) % daysInWeek
+	return day.AddDate(0, 0, -offset)
+}
+
+// findFailureSpikes returns days of the week when failures were notably higher than the daily average of the week before
+func findFailureSpikes(week time.Time, usage *weeklyUsage) []*common.FailureSpike {
+	var previous uint64
+	for i := 0; i < daysInWeek; i++ {
+		previous += usage.failures[i]
+	}
+
+	baseline := float64(previous) / daysInWeek
+	spikes := make([]*common.FailureSpike, 0)
+
+	for i := 0; i < daysInWeek; i++ {
+		failures := usage.failures[daysInWeek+i]
+		if (failures >= minSpikeFailures) && (float64(failures) > failureSpikeFactor*baseline) {
+			spikes = append(spikes, &common.FailureSpike{Day: week.AddDate(0, 0, i), Failures: failures})
+		}
+	}
+
+	return spikes
+}
+
+func newOrgDigest(name string, week time.Time, activity *dbgen.GetOrgDigestActivityRow, usage *weeklyUsage) *common.OrgDigest {
+	digest := &common.OrgDigest{
+		OrgName:        name,
+		WeekStart:      week,
+		NewProperties:  activity.NewProperties,
+		InvitedMembers: activity.InvitedMembers,
+		JoinedMembers:  activity.JoinedMembers,
+	}
+
+	if usage != nil {
+		for i := 0; i < daysInWeek; i++ {
+			digest.PreviousRequests += usage.requests[i]
+			digest.Requests += usage.requests[daysInWeek+i]
+			digest.VerifySuccess += usage.success[daysInWeek+i]
+			digest.VerifyFailure += usage.failures[daysInWeek+i]
+		}
+
+		digest.FailureSpikes = findFailureSpikes(week, usage)
+	}
+
+	return digest
+}
+
+// readUsage returns daily usage of organizations during the {week} and the week before it
+func (j *OrgDigestJob) readUsage(ctx context.Context, week time.Time, orgIDs map[int32]struct{}) (map[int32]*weeklyUsage, error) {
+	result := make(map[int32]*weeklyUsage)
+	from := week.AddDate(0, 0, -daysInWeek)
+
+	for i := 0; i < 2*daysInWeek; i++ {
+		counters, err := j.TimeSeries.ReadDailyUsageCounters(ctx, from.AddDate(0, 0, i))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range counters {
+			if _, ok := orgIDs[c.OrgID]; !ok {
+				continue
+			}
+
+			usage, ok := result[c.OrgID]
+			if !ok {
+				usage = &weeklyUsage{}
+				result[c.OrgID] = usage
+			}
+
+			usage.requests[i] += c.Requests
+			usage.success[i] += c.VerifySuccess
+			usage.failures[i] += c.VerifyFailure
+		}
+	}
+
+	return result, nil
+}
+
+func (j *OrgDigestJob) sendDigest(ctx context.Context, org *dbgen.GetOrgDigestCandidatesRow, week time.Time, usage *weeklyUsage) (bool, error) {
+	// digest is marked first so that a failure below does not cause repeated emails
+	if created, err := j.Store.Impl().CreateOrgDigest(ctx, org.ID, week); (err != nil) || !created {
+		return false, err
+	}
+
+	activity, err := j.Store.Impl().RetrieveOrgDigestActivity(ctx, org.ID, week, week.AddDate(0, 0, daysInWeek))
+	if err != nil {
+		return false, err
+	}
+
+	digest := newOrgDigest(org.Name, week, activity, usage)
+	if digest.IsEmpty() {
+		slog.DebugContext(ctx, "Skipping empty org digest", "orgID", org.ID)
+		return false, nil
+	}
+
+	owner, err := j.Store.Impl().RetrieveUser(ctx, org.OwnerID.Int32)
+	if err != nil {
+		return false, err
+	}
+
+	orgPath := j.OrgPathPrefix + "/" + strconv.Itoa(int(org.ID))
+
+	if err := j.Mailer.SendOrgDigest(ctx, owner.Email, digest, orgPath); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (j *OrgDigestJob) RunOnce(ctx context.Context) error {
+	week := weekStart(time.Now()).AddDate(0, 0, -daysInWeek)
+
+	orgs, err := j.Store.Impl().RetrieveOrgDigestCandidates(ctx, week, maxDigestsPerRun)
+	if err != nil {
+		return err
+	}
+
+	if len(orgs) == 0 {
+		return nil
+	}
+
+	orgIDs := make(map[int32]struct{}, len(orgs))
+	for _, org := range orgs {
+		orgIDs[org.ID] = struct{}{}
+	}
+
+	usage, err := j.readUsage(ctx, week, orgIDs)
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+
+	for _, org := range orgs {
+		if ok, err := j.sendDigest(ctx, org, week, usage[org.ID]); err != nil {
+			slog.ErrorContext(ctx, "Failed to send org digest", "orgID", org.ID, common.ErrAttr(err))
+		} else if ok {
+			sent++
+		}
+	}
+
+	slog.InfoContext(ctx, "Processed org digests", "week", week, "count", len(orgs), "sent", sent)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestWeekStart(t *testing.T) {
+	t.Parallel()
+
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	testCases := []time.Time{
+		monday,
+		monday.Add(13 * time.Hour),
+		time.Date(2025, 3, 6, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 9, 23, 59, 59, 0, time.UTC),
+	}
+
+	for i, tc := range testCases {
+		if actual := weekStart(tc); !actual.Equal(monday) {
+			t.Errorf("Unexpected week start at %v: %v", i, actual)
+		}
+	}
+
+	if actual := weekStart(monday.AddDate(0, 0, 7)); !actual.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("Unexpected start of the next week: %v", actual)
+	}
+}
+
+func TestOrgDigest(t *testing.T) {
+	t.Parallel()
+
+	week := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	usage := &weeklyUsage{}
+	for i := 0; i < daysInWeek; i++ {
+		usage.requests[i] = 100
+		usage.failures[i] = 20
+		usage.requests[daysInWeek+i] = 150
+		usage.success[daysInWeek+i] = 100
+		usage.failures[daysInWeek+i] = 20
+	}
+	// 3rd day of the week has a spike and 5th is just above the usual
+	usage.failures[daysInWeek+2] = 500
+	usage.failures[daysInWeek+4] = 50
+
+	digest := newOrgDigest("org", week, &dbgen.GetOrgDigestActivityRow{NewProperties: 1}, usage)
+
+	if (digest.Requests != 1050) || (digest.PreviousRequests != 700) || (digest.RequestsTrend() != 50) {
+		t.Errorf("Unexpected requests: %v (previous %v), trend %v", digest.Requests, digest.PreviousRequests,
+			digest.RequestsTrend())
+	}
+
+	if (digest.VerifySuccess != 700) || (digest.VerifyFailure != 5*20+500+50) {
+		t.Errorf("Unexpected verifications: %v success, %v failure", digest.VerifySuccess, digest.VerifyFailure)
+	}
+
+	if (len(digest.FailureSpikes) != 1) || !digest.FailureSpikes[0].Day.Equal(week.AddDate(0, 0, 2)) {
+		t.Errorf("Unexpected failure spikes: %v", digest.FailureSpikes)
+	}
+
+	if digest.IsEmpty() {
+		t.Error("Digest is empty")
+	}
+
+	if digest := newOrgDigest("org", week, &dbgen.GetOrgDigestActivityRow{}, nil); !digest.IsEmpty() {
+		t.Errorf("Digest without activity is not empty: %+v", digest)
+	}
+}
@@ -0,0 +1,78 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
+)
+
+const (
+	domainVerificationBatchSize = 100
+	domainLookupTimeout         = 5 * time.Second
+)
+
+// VerifyDomainsJob checks DNS challenges of properties with unverified domains. Property domain cannot be changed,
+// so once verified, domain stays verified even if the TXT record is removed afterwards
+type VerifyDomainsJob struct {
+	Store    db.Implementor
+	Resolver origins.TXTResolver
+	// how often the same pending challenge is checked
+	RecheckInterval time.Duration
+}
+
+var _ common.PeriodicJob = (*VerifyDomainsJob)(nil)
+
+func (j *VerifyDomainsJob) Interval() time.Duration {
+	return 15 * time.Minute
+}
+
+func (j *VerifyDomainsJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *VerifyDomainsJob) Name() string {
+	return "verify_domains_job"
+}
+
+func (j *VerifyDomainsJob) RunOnce(ctx context.Context) error {
+	pending, err := j.Store.Impl().RetrievePendingDomainVerifications(ctx, time.Now().UTC().Add(-j.RecheckInterval), domainVerificationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	verified := 0
+
+	for _, p := range pending {
+		propID := p.DomainVerification.PropertyID
+
+		lctx, cancel := context.WithTimeout(ctx, domainLookupTimeout)
+		verr := origins.VerifyOwnership(lctx, j.Resolver, p.Domain, p.DomainVerification.Token)
+		cancel()
+
+		lastError := ""
+		if verr != nil {
+			lastError = verr.Error()
+			slog.DebugContext(ctx, "Domain is not verified yet", "propID", propID, "domain", p.Domain, common.ErrAttr(verr))
+		} else if _, err := j.Store.Impl().UpdatePropertyDomainVerified(ctx, propID, true /*verified*/); err == nil {
+			verified++
+			slog.InfoContext(ctx, "Audit: property setting changed", "propID", propID, "setting", db.PropertySettingDomainVerified,
+				"old", false, "new", true)
+			_ = j.Store.Impl().CreatePropertyEvents(ctx, propID, 0 /*system*/, []*dbgen.PropertyEvent{
+				db.PropertyFlagEvent(db.PropertySettingDomainVerified, false, true),
+			})
+		} else {
+			continue
+		}
+
+		_ = j.Store.Impl().UpdateDomainVerificationCheck(ctx, propID, lastError)
+	}
+
+	slog.DebugContext(ctx, "Checked pending domain verifications", "count", len(pending), "verified", verified)
+
+	return nil
+}
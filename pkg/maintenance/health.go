@@ -19,9 +19,16 @@ type HealthCheckJob struct {
 	postgresFlag     atomic.Int32
 	clickhouseFlag   atomic.Int32
 	shuttingDownFlag atomic.Int32
+	checkedFlag      atomic.Bool
 	CheckInterval    common.ConfigItem
 	Metrics          common.PlatformMetrics
 	StrictReadiness  bool
+	// optional, notified when database becomes (un)available
+	Alerter common.Alerter
+	// results of the checks for the status feed (see StatusFeedHandler)
+	history     healthHistory
+	maintenance atomic.Pointer[config.MaintenanceModes]
+	feedCache   atomic.Pointer[cachedStatusFeed]
 }
 
 const (
@@ -48,16 +55,44 @@ func (j *HealthCheckJob) Name() string {
 
 func (hc *HealthCheckJob) RunOnce(ctx context.Context) error {
 	pgStatus := hc.checkPostgres(ctx)
-	hc.postgresFlag.Store(pgStatus)
+	oldPgStatus := hc.postgresFlag.Swap(pgStatus)
 
 	chStatus := hc.checkClickHouse(ctx)
-	hc.clickhouseFlag.Store(chStatus)
+	oldChStatus := hc.clickhouseFlag.Swap(chStatus)
 
 	hc.Metrics.ObserveHealth((pgStatus == FlagTrue), (chStatus == FlagTrue))
+	hc.history.add(time.Now().UTC(), (pgStatus == FlagTrue) && (chStatus == FlagTrue))
+
+	// flags are "unhealthy" before the first check so there is nothing to compare with
+	if hc.checkedFlag.Swap(true) {
+		hc.alertHealthChange(ctx, "Postgres", oldPgStatus, pgStatus)
+		hc.alertHealthChange(ctx, "ClickHouse", oldChStatus, chStatus)
+	}
 
 	return nil
 }
 
+func (hc *HealthCheckJob) alertHealthChange(ctx context.Context, name string, oldStatus, newStatus int32) {
+	if (hc.Alerter == nil) || (oldStatus == newStatus) {
+		return
+	}
+
+	if newStatus == FlagTrue {
+		hc.Alerter.SendAlert(ctx, &common.Alert{
+			Key:      "health_up_" + name,
+			Severity: common.AlertSeverityInfo,
+			Title:    name + " is healthy again",
+		})
+	} else {
+		hc.Alerter.SendAlert(ctx, &common.Alert{
+			Key:      "health_down_" + name,
+			Severity: common.AlertSeverityCritical,
+			Title:    name + " health check failed",
+			Text:     "Node cannot reach " + name + ", see logs for details.",
+		})
+	}
+}
+
 func (hc *HealthCheckJob) checkClickHouse(ctx context.Context) int32 {
 	result := int32(FlagFalse)
 	if err := hc.TimeSeriesDB.Ping(ctx); err == nil {
@@ -90,6 +125,10 @@ func (hc *HealthCheckJob) isShuttingDown() bool {
 	return hc.shuttingDownFlag.Load() == FlagTrue
 }
 
+func (hc *HealthCheckJob) UpdateConfig(modes *config.MaintenanceModes) {
+	hc.maintenance.Store(modes)
+}
+
 func (hc *HealthCheckJob) Shutdown(ctx context.Context) {
 	slog.DebugContext(ctx, "Shutting down health check job")
 	hc.shuttingDownFlag.Store(FlagTrue)
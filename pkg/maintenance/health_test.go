@@ -1,9 +1,14 @@
 package maintenance
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 )
 
 func TestLiveEndpoint(t *testing.T) {
@@ -21,3 +26,75 @@ func TestLiveEndpoint(t *testing.T) {
 		t.Errorf("Unexpected status code %d", w.Code)
 	}
 }
+
+func TestHealthHistoryUptime(t *testing.T) {
+	t.Parallel()
+
+	h := &healthHistory{}
+
+	if uptime, last := h.uptime(); (uptime != uptimeNoData) || (last != nil) {
+		t.Errorf("Unexpected uptime without data: %v", uptime)
+	}
+
+	tnow := time.Now().UTC()
+	// this one is out of the 24h window and will be trimmed
+	h.add(tnow.Add(-healthHistoryPeriod-time.Hour), false)
+	h.add(tnow.Add(-3*time.Hour), true)
+	h.add(tnow.Add(-2*time.Hour), true)
+	h.add(tnow.Add(-1*time.Hour), true)
+	h.add(tnow, false)
+
+	uptime, last := h.uptime()
+	if uptime != 75.0 {
+		t.Errorf("Unexpected uptime: %v", uptime)
+	}
+
+	if (last == nil) || last.healthy || !last.timestamp.Equal(tnow) {
+		t.Errorf("Unexpected last sample: %v", last)
+	}
+}
+
+func TestStatusFeed(t *testing.T) {
+	t.Parallel()
+
+	healthCheck := &HealthCheckJob{}
+	healthCheck.history.add(time.Now().UTC(), true)
+	healthCheck.UpdateConfig(&config.MaintenanceModes{All: true, Billing: true})
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	healthCheck.StatusFeedHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d", w.Code)
+	}
+
+	feed := &StatusFeed{}
+	if err := json.NewDecoder(w.Body).Decode(feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if feed.Status != StatusMaintenance {
+		t.Errorf("Unexpected status: %v", feed.Status)
+	}
+
+	if feed.Uptime24h != 100.0 {
+		t.Errorf("Unexpected uptime: %v", feed.Uptime24h)
+	}
+
+	if !feed.Maintenance[config.MaintenanceBilling] || feed.Maintenance[config.MaintenancePortal] {
+		t.Errorf("Unexpected maintenance flags: %v", feed.Maintenance)
+	}
+
+	// response is cached so that config changes are not visible immediately
+	healthCheck.UpdateConfig(&config.MaintenanceModes{})
+	w = httptest.NewRecorder()
+	healthCheck.StatusFeedHandler(w, req)
+	if !bytes.Contains(w.Body.Bytes(), []byte(StatusMaintenance)) {
+		t.Errorf("Expected cached response: %s", w.Body.String())
+	}
+}
@@ -11,9 +11,10 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 )
 
-func NewJobs(store db.Implementor) *jobs {
+func NewJobs(store db.Implementor, alerter common.Alerter) *jobs {
 	return &jobs{
 		store:        store,
+		alerter:      alerter,
 		periodicJobs: make([]common.PeriodicJob, 0),
 		oneOffJobs:   make([]common.OneOffJob, 0),
 	}
@@ -21,6 +22,7 @@ func NewJobs(store db.Implementor) *jobs {
 
 type jobs struct {
 	store             db.Implementor
+	alerter           common.Alerter
 	periodicJobs      []common.PeriodicJob
 	oneOffJobs        []common.OneOffJob
 	maintenanceCancel context.CancelFunc
@@ -28,7 +30,7 @@ type jobs struct {
 }
 
 func (j *jobs) AddLocked(lockDuration time.Duration, job common.PeriodicJob) {
-	j.periodicJobs = append(j.periodicJobs, &UniquePeriodicJob{
+	j.Add(&UniquePeriodicJob{
 		Job:          job,
 		Store:        j.store,
 		LockDuration: lockDuration,
@@ -36,10 +38,16 @@ func (j *jobs) AddLocked(lockDuration time.Duration, job common.PeriodicJob) {
 }
 
 func (j *jobs) Add(job common.PeriodicJob) {
+	if j.alerter != nil {
+		job = &alertingPeriodicJob{PeriodicJob: job, alerter: j.alerter}
+	}
 	j.periodicJobs = append(j.periodicJobs, job)
 }
 
 func (j *jobs) AddOneOff(job common.OneOffJob) {
+	if j.alerter != nil {
+		job = &alertingOneOffJob{OneOffJob: job, alerter: j.alerter}
+	}
 	j.oneOffJobs = append(j.oneOffJobs, job)
 }
 
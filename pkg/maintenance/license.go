@@ -0,0 +1,125 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
+)
+
+const (
+	// nodes that did not send a heartbeat for this long are forgotten
+	licenseNodeRetention = 24 * time.Hour
+)
+
+// LicenseHeartbeatJob registers the server instance so that running nodes can be counted against the license.
+// It runs on every instance.
+type LicenseHeartbeatJob struct {
+	Store   db.Implementor
+	License *license.License
+	NodeID  string
+	Version string
+}
+
+var _ common.PeriodicJob = (*LicenseHeartbeatJob)(nil)
+
+func (j *LicenseHeartbeatJob) Interval() time.Duration {
+	return license.NodeHeartbeatInterval
+}
+
+func (j *LicenseHeartbeatJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *LicenseHeartbeatJob) Name() string {
+	return "license_heartbeat_job"
+}
+
+func (j *LicenseHeartbeatJob) RunOnce(ctx context.Context) error {
+	tnow := time.Now().UTC()
+
+	if err := j.Store.Impl().UpdateLicenseNode(ctx, j.NodeID, j.Version, tnow.Add(-licenseNodeRetention)); err != nil {
+		return err
+	}
+
+	nodes, err := j.Store.Impl().RetrieveActiveLicenseNodesCount(ctx, tnow.Add(-license.NodeActiveWindow))
+	if err != nil {
+		return err
+	}
+
+	if j.License.NodesExceeded(nodes) {
+		slog.WarnContext(ctx, "Running nodes exceed the license limit", "nodes", nodes, "limit", j.License.MaxNodes)
+	}
+
+	if err := j.License.Check(tnow); err != nil {
+		slog.ErrorContext(ctx, "License is not valid", "expiresAt", j.License.ExpiresAt, common.ErrAttr(err))
+	} else if j.License.ExpiresSoon(tnow) {
+		slog.WarnContext(ctx, "License expires soon", "expiresAt", j.License.ExpiresAt)
+	}
+
+	return nil
+}
+
+// LicenseReportJob periodically sends usage aggregates of the installation. Counts are rounded and nothing that
+// identifies users, domains or hosts is included. It is not used for offline (air-gapped) installations.
+type LicenseReportJob struct {
+	Store      db.Implementor
+	TimeSeries common.TimeSeriesStore
+	License    *license.License
+	URL        string
+	Version    string
+	Client     *http.Client
+}
+
+var _ common.PeriodicJob = (*LicenseReportJob)(nil)
+
+func (j *LicenseReportJob) Interval() time.Duration {
+	return 24 * time.Hour
+}
+
+func (j *LicenseReportJob) Jitter() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *LicenseReportJob) Name() string {
+	return "license_report_job"
+}
+
+func (j *LicenseReportJob) RunOnce(ctx context.Context) error {
+	tnow := time.Now().UTC()
+
+	counts, err := j.Store.Impl().RetrieveInstanceCounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := j.Store.Impl().RetrieveActiveLicenseNodesCount(ctx, tnow.Add(-license.NodeActiveWindow))
+	if err != nil {
+		return err
+	}
+
+	usage, err := j.TimeSeries.ReadUsageCounters(ctx, monthStart(tnow))
+	if err != nil {
+		return err
+	}
+
+	var requests int64
+	for _, uc := range usage {
+		requests += int64(uc.Requests)
+	}
+
+	report := license.NewReport(j.License, j.Version, tnow, nodes, counts.Users, counts.Orgs, counts.Properties, requests)
+
+	if err := license.SendReport(ctx, j.Client, j.URL, report); err != nil {
+		slog.ErrorContext(ctx, "Failed to send license report", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Sent license report", "nodes", nodes, "date", report.Date)
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package maintenance
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Minimal Parquet writer: a single row group of required (non-nullable) columns, each stored as a single
+// PLAIN-encoded uncompressed data page. This is enough for daily aggregates and keeps us free of heavy dependencies.
+// Format reference: https://github.com/apache/parquet-format
+
+const (
+	parquetMagic = "PAR1"
+	// physical types
+	parquetTypeInt32 = 1
+	parquetTypeInt64 = 2
+	// converted (logical) types
+	parquetConvertedNone   = -1
+	parquetConvertedDate   = 6
+	parquetConvertedUint64 = 14
+	// FieldRepetitionType.REQUIRED
+	parquetRequired = 0
+	// Encoding values
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+	// PageType.DATA_PAGE
+	parquetDataPage = 0
+	// CompressionCodec.UNCOMPRESSED
+	parquetUncompressed = 0
+	// thrift compact protocol types
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+type parquetColumn struct {
+	name      string
+	physical  int32
+	converted int32
+	data      bytes.Buffer
+	count     int
+}
+
+func newParquetColumn(name string, physical, converted int32) *parquetColumn {
+	return &parquetColumn{name: name, physical: physical, converted: converted}
+}
+
+func (c *parquetColumn) appendInt32(v int32) {
+	_ = binary.Write(&c.data, binary.LittleEndian, v)
+	c.count++
+}
+
+func (c *parquetColumn) appendInt64(v int64) {
+	_ = binary.Write(&c.data, binary.LittleEndian, v)
+	c.count++
+}
+
+// thriftWriter implements (the subset of) thrift compact protocol that is needed for Parquet metadata
+type thriftWriter struct {
+	buf    bytes.Buffer
+	fields []int16
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) field(id int16, kind byte) {
+	last := w.fields[len(w.fields)-1]
+	if delta := id - last; (delta > 0) && (delta <= 15) {
+		w.buf.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		w.buf.WriteByte(kind)
+		w.zigzag(int64(id))
+	}
+	w.fields[len(w.fields)-1] = id
+}
+
+func (w *thriftWriter) beginStruct() {
+	w.fields = append(w.fields, 0)
+}
+
+func (w *thriftWriter) endStruct() {
+	w.buf.WriteByte(0)
+	w.fields = w.fields[:len(w.fields)-1]
+}
+
+func (w *thriftWriter) listHeader(size int, kind byte) {
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | kind)
+	} else {
+		w.buf.WriteByte(0xF0 | kind)
+		w.varint(uint64(size))
+	}
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) str(id int16, v string) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *thriftWriter) structField(id int16) {
+	w.field(id, thriftStruct)
+	w.beginStruct()
+}
+
+func (w *thriftWriter) listField(id int16, size int, kind byte) {
+	w.field(id, thriftList)
+	w.listHeader(size, kind)
+}
+
+func parquetPageHeader(c *parquetColumn) []byte {
+	w := &thriftWriter{}
+	w.beginStruct()
+	w.i32(1, parquetDataPage)
+	w.i32(2, int32(c.data.Len()))
+	w.i32(3, int32(c.data.Len()))
+	w.structField(5)
+	w.i32(1, int32(c.count))
+	w.i32(2, parquetEncodingPlain)
+	w.i32(3, parquetEncodingRLE)
+	w.i32(4, parquetEncodingRLE)
+	w.endStruct()
+	w.endStruct()
+	return w.buf.Bytes()
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+func parquetFileMetadata(columns []*parquetColumn, chunks []parquetChunk, rows int) []byte {
+	w := &thriftWriter{}
+	w.beginStruct()
+	w.i32(1, 1 /*version*/)
+
+	w.listField(2, len(columns)+1, thriftStruct)
+	// root of the schema
+	w.beginStruct()
+	w.str(4, "schema")
+	w.i32(5, int32(len(columns)))
+	w.endStruct()
+	for _, c := range columns {
+		w.beginStruct()
+		w.i32(1, c.physical)
+		w.i32(3, parquetRequired)
+		w.str(4, c.name)
+		if c.converted != parquetConvertedNone {
+			w.i32(6, c.converted)
+		}
+		w.endStruct()
+	}
+
+	w.i64(3, int64(rows))
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.size
+	}
+
+	w.listField(4, 1, thriftStruct)
+	w.beginStruct()
+	w.listField(1, len(columns), thriftStruct)
+	for i, c := range columns {
+		w.beginStruct()
+		w.i64(2, chunks[i].offset)
+		w.structField(3)
+		w.i32(1, c.physical)
+		w.listField(2, 2, thriftI32)
+		w.zigzag(parquetEncodingPlain)
+		w.zigzag(parquetEncodingRLE)
+		w.listField(3, 1, thriftBinary)
+		w.varint(uint64(len(c.name)))
+		w.buf.WriteString(c.name)
+		w.i32(4, parquetUncompressed)
+		w.i64(5, int64(c.count))
+		w.i64(6, chunks[i].size)
+		w.i64(7, chunks[i].size)
+		w.i64(9, chunks[i].offset)
+		w.endStruct()
+		w.endStruct()
+	}
+	w.i64(2, totalSize)
+	w.i64(3, int64(rows))
+	w.endStruct()
+
+	w.str(6, "PrivateCaptcha")
+	w.endStruct()
+
+	return w.buf.Bytes()
+}
+
+// encodeParquet writes columns (that all have to contain the same number of values) as a Parquet file
+func encodeParquet(columns []*parquetColumn, rows int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(parquetMagic)
+
+	chunks := make([]parquetChunk, 0, len(columns))
+	for _, c := range columns {
+		offset := int64(buf.Len())
+		buf.Write(parquetPageHeader(c))
+		buf.Write(c.data.Bytes())
+		chunks = append(chunks, parquetChunk{offset: offset, size: int64(buf.Len()) - offset})
+	}
+
+	metadata := parquetFileMetadata(columns, chunks, rows)
+	buf.Write(metadata)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(metadata)))
+	buf.WriteString(parquetMagic)
+
+	return buf.Bytes()
+}
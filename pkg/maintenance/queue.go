@@ -0,0 +1,34 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+// CleanupQueueJobsJob deletes persisted queue jobs that failed permanently (they are kept for a while for inspection)
+type CleanupQueueJobsJob struct {
+	Store db.Implementor
+	Age   time.Duration
+}
+
+var _ common.PeriodicJob = (*CleanupQueueJobsJob)(nil)
+
+func (j *CleanupQueueJobsJob) Interval() time.Duration {
+	return 24 * time.Hour
+}
+
+func (j *CleanupQueueJobsJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *CleanupQueueJobsJob) Name() string {
+	return "cleanup_queue_jobs_job"
+}
+
+func (j *CleanupQueueJobsJob) RunOnce(ctx context.Context) error {
+	before := time.Now().UTC().Add(-j.Age)
+	return j.Store.Impl().DeleteFailedQueueJobs(ctx, before)
+}
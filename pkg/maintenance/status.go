@@ -0,0 +1,148 @@
+package maintenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+const (
+	healthHistoryPeriod = 24 * time.Hour
+	statusFeedCacheTTL  = 30 * time.Second
+	StatusOperational   = "operational"
+	StatusDegraded      = "degraded"
+	StatusMaintenance   = "maintenance"
+	// reported before the first check, nothing went wrong yet
+	uptimeNoData = 100.0
+)
+
+type healthSample struct {
+	timestamp time.Time
+	healthy   bool
+}
+
+// healthHistory keeps results of health checks for the last 24 hours
+type healthHistory struct {
+	lock    sync.Mutex
+	samples []healthSample
+}
+
+func (h *healthHistory) add(tnow time.Time, healthy bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.samples = append(h.samples, healthSample{timestamp: tnow, healthy: healthy})
+
+	cutoff := tnow.Add(-healthHistoryPeriod)
+	i := 0
+	for (i < len(h.samples)) && h.samples[i].timestamp.Before(cutoff) {
+		i++
+	}
+
+	if i > 0 {
+		h.samples = append(h.samples[:0], h.samples[i:]...)
+	}
+}
+
+// uptime returns percentage of successful checks and the last check, if any
+func (h *healthHistory) uptime() (float64, *healthSample) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.samples) == 0 {
+		return uptimeNoData, nil
+	}
+
+	healthy := 0
+	for _, s := range h.samples {
+		if s.healthy {
+			healthy++
+		}
+	}
+
+	last := h.samples[len(h.samples)-1]
+	pct := 100.0 * float64(healthy) / float64(len(h.samples))
+
+	return math.Round(pct*100) / 100, &last
+}
+
+type StatusFeed struct {
+	Status      string          `json:"status"`
+	Uptime24h   float64         `json:"uptime_24h"`
+	CheckedAt   common.JSONTime `json:"checked_at"`
+	Maintenance map[string]bool `json:"maintenance"`
+}
+
+type cachedStatusFeed struct {
+	data    []byte
+	expires time.Time
+}
+
+func (hc *HealthCheckJob) statusFeed() *StatusFeed {
+	uptime, last := hc.history.uptime()
+
+	feed := &StatusFeed{
+		Status:      StatusOperational,
+		Uptime24h:   uptime,
+		Maintenance: make(map[string]bool),
+	}
+
+	if last != nil {
+		feed.CheckedAt = common.JSONTime(last.timestamp)
+		if !last.healthy {
+			// API keeps working from cache when databases are not available, but not all of it
+			feed.Status = StatusDegraded
+		}
+	}
+
+	if modes := hc.maintenance.Load(); modes != nil {
+		feed.Maintenance[config.MaintenancePortal] = modes.Portal
+		feed.Maintenance[config.MaintenanceBilling] = modes.Billing
+		feed.Maintenance[config.MaintenanceStats] = modes.Stats
+		feed.Maintenance[config.MaintenanceVerify] = modes.Verify
+
+		if modes.All {
+			feed.Status = StatusMaintenance
+		}
+	}
+
+	return feed
+}
+
+// StatusFeedHandler serves public (unauthenticated) status of the service for external status pages. It only uses
+// data in memory, and the response is cached, so it does not add any load to the databases
+func (hc *HealthCheckJob) StatusFeedHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tnow := time.Now()
+
+	cached := hc.feedCache.Load()
+	if (cached == nil) || tnow.After(cached.expires) {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(hc.statusFeed()); err != nil {
+			slog.ErrorContext(ctx, "Failed to encode status feed", common.ErrAttr(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		cached = &cachedStatusFeed{data: buf.Bytes(), expires: tnow.Add(statusFeedCacheTTL)}
+		hc.feedCache.Store(cached)
+	}
+
+	header := w.Header()
+	header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	header.Set(common.HeaderAccessControlOrigin, "*")
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(statusFeedCacheTTL.Seconds())))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(cached.data); err != nil {
+		slog.ErrorContext(ctx, "Failed to write status feed", common.ErrAttr(err))
+	}
+}
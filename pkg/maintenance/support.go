@@ -0,0 +1,102 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/mail"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+// SupportReplies appends replies to support requests, received by the inbound email webhook, to ticket threads.
+// Only replies from the support mailbox or from (verified) addresses of the ticket owner are accepted.
+type SupportReplies struct {
+	SupportEmail common.ConfigItem
+	AdminEmail   common.ConfigItem
+}
+
+func (sr *SupportReplies) supportMailbox() string {
+	if mailbox := sr.SupportEmail.Value(); len(mailbox) > 0 {
+		return mailbox
+	}
+
+	return sr.AdminEmail.Value()
+}
+
+func (sr *SupportReplies) isOwnerEmail(ctx context.Context, impl *db.BusinessStoreImpl, ticket *dbgen.SupportTicket, address string) (bool, error) {
+	user, err := impl.RetrieveUser(ctx, ticket.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.EqualFold(user.Email, address) {
+		return true, nil
+	}
+
+	emails, err := impl.RetrieveUserEmails(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range emails {
+		if e.VerifiedAt.Valid && strings.EqualFold(e.Email, address) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (sr *SupportReplies) HandleEvent(ctx context.Context, impl *db.BusinessStoreImpl, event *dbgen.WebhookEvent) error {
+	inbound := &email.InboundEmail{}
+	if err := json.Unmarshal(event.Payload, inbound); err != nil {
+		// retries will not help with that
+		slog.ErrorContext(ctx, "Failed to parse inbound email", common.ErrAttr(err))
+		return nil
+	}
+
+	ticketID, ok := email.ParseSupportTicketID(inbound.Subject)
+	if !ok {
+		slog.WarnContext(ctx, "Inbound email does not reference support ticket", "messageID", inbound.MessageID)
+		return nil
+	}
+
+	ticket, err := impl.RetrieveSupportTicket(ctx, ticketID)
+	if err != nil {
+		if err == db.ErrRecordNotFound {
+			slog.WarnContext(ctx, "Support ticket not found for inbound email", "ticketID", ticketID)
+			return nil
+		}
+		return err
+	}
+
+	from, err := mail.ParseAddress(inbound.From)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse sender of inbound email", "ticketID", ticketID, common.ErrAttr(err))
+		return nil
+	}
+
+	fromSupport := strings.EqualFold(from.Address, sr.supportMailbox())
+	if !fromSupport {
+		if isOwner, err := sr.isOwnerEmail(ctx, impl, ticket, from.Address); err != nil {
+			return err
+		} else if !isOwner {
+			slog.WarnContext(ctx, "Ignoring reply to support ticket from unknown sender", "ticketID", ticketID)
+			return nil
+		}
+	}
+
+	message := email.StripQuotedReply(inbound.Text)
+	if len(message) == 0 {
+		slog.WarnContext(ctx, "Ignoring empty reply to support ticket", "ticketID", ticketID)
+		return nil
+	}
+
+	_, err = impl.AddSupportTicketReply(ctx, ticket, fromSupport, message)
+	return err
+}
@@ -0,0 +1,278 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	WarehouseFormatParquet = "parquet"
+	WarehouseFormatCSV     = "csv"
+
+	warehouseExportTimeout = 1 * time.Minute
+	warehouseDefaultRegion = "us-east-1"
+	s3Service              = "s3"
+	sigV4Algorithm         = "AWS4-HMAC-SHA256"
+	amzDateFormat          = "20060102T150405Z"
+	amzDayFormat           = "20060102"
+)
+
+var (
+	errWarehouseStatus = errors.New("unexpected warehouse storage response status")
+)
+
+// WarehouseExportJob uploads daily request and verification aggregates of each organization to S3-compatible
+// storage, so that they can be ingested into customer's data warehouse. Objects are partitioned Hive-style as
+// {prefix}/org_id={id}/date={YYYY-MM-DD}/usage.{format} and are overwritten if the job runs again for the same day.
+type WarehouseExportJob struct {
+	TimeSeries common.TimeSeriesStore
+	// path-style URL of the bucket with optional prefix, e.g. https://s3.eu-central-1.amazonaws.com/bucket/captcha
+	URL       common.ConfigItem
+	Format    common.ConfigItem
+	Region    common.ConfigItem
+	AccessKey common.ConfigItem
+	SecretKey common.ConfigItem
+	Client    *http.Client
+}
+
+var _ common.PeriodicJob = (*WarehouseExportJob)(nil)
+
+func NewWarehouseExportJob(timeSeries common.TimeSeriesStore, cfg common.ConfigStore) *WarehouseExportJob {
+	return &WarehouseExportJob{
+		TimeSeries: timeSeries,
+		URL:        cfg.Get(common.WarehouseExportURLKey),
+		Format:     cfg.Get(common.WarehouseExportFormatKey),
+		Region:     cfg.Get(common.WarehouseExportRegionKey),
+		AccessKey:  cfg.Get(common.WarehouseExportAccessKeyKey),
+		SecretKey:  cfg.Get(common.WarehouseExportSecretKeyKey),
+		Client:     &http.Client{Timeout: warehouseExportTimeout},
+	}
+}
+
+func (j *WarehouseExportJob) Interval() time.Duration {
+	return 24 * time.Hour
+}
+
+func (j *WarehouseExportJob) Jitter() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *WarehouseExportJob) Name() string {
+	return "warehouse_export_job"
+}
+
+var warehouseColumns = []string{"date", "org_id", "property_id", "requests", "verify_success", "verify_failure"}
+
+func encodeUsageCSV(day time.Time, counters []*common.UsageCounter) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(warehouseColumns); err != nil {
+		return nil, err
+	}
+
+	date := day.Format(time.DateOnly)
+	for _, c := range counters {
+		if err := w.Write([]string{
+			date,
+			strconv.Itoa(int(c.OrgID)),
+			strconv.Itoa(int(c.PropertyID)),
+			strconv.FormatUint(c.Requests, 10),
+			strconv.FormatUint(c.VerifySuccess, 10),
+			strconv.FormatUint(c.VerifyFailure, 10),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+func encodeUsageParquet(day time.Time, counters []*common.UsageCounter) []byte {
+	date := newParquetColumn(warehouseColumns[0], parquetTypeInt32, parquetConvertedDate)
+	orgID := newParquetColumn(warehouseColumns[1], parquetTypeInt32, parquetConvertedNone)
+	propertyID := newParquetColumn(warehouseColumns[2], parquetTypeInt32, parquetConvertedNone)
+	requests := newParquetColumn(warehouseColumns[3], parquetTypeInt64, parquetConvertedUint64)
+	success := newParquetColumn(warehouseColumns[4], parquetTypeInt64, parquetConvertedUint64)
+	failure := newParquetColumn(warehouseColumns[5], parquetTypeInt64, parquetConvertedUint64)
+
+	// DATE is the number of days since the Unix epoch
+	days := int32(day.Unix() / (24 * 60 * 60))
+
+	for _, c := range counters {
+		date.appendInt32(days)
+		orgID.appendInt32(c.OrgID)
+		propertyID.appendInt32(c.PropertyID)
+		requests.appendInt64(int64(c.Requests))
+		success.appendInt64(int64(c.VerifySuccess))
+		failure.appendInt64(int64(c.VerifyFailure))
+	}
+
+	return encodeParquet([]*parquetColumn{date, orgID, propertyID, requests, success, failure}, len(counters))
+}
+
+func (j *WarehouseExportJob) encode(day time.Time, counters []*common.UsageCounter) ([]byte, string, error) {
+	switch format := j.Format.Value(); format {
+	case WarehouseFormatParquet, "":
+		return encodeUsageParquet(day, counters), "application/vnd.apache.parquet", nil
+	case WarehouseFormatCSV:
+		data, err := encodeUsageCSV(day, counters)
+		return data, "text/csv", err
+	default:
+		return nil, "", fmt.Errorf("unknown warehouse export format: %s", format)
+	}
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signS3Request adds AWS Signature Version 4 to the request. All headers that are already set are signed
+func signS3Request(req *http.Request, payloadHash, accessKey, secretKey, region string, tnow time.Time) {
+	amzDate := tnow.UTC().Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	day := tnow.UTC().Format(amzDayFormat)
+	scope := day + "/" + region + "/" + s3Service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(common.HeaderAuthorization, fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, accessKey, scope, signedHeaders, signature))
+}
+
+func warehouseObjectURL(baseURL string, orgID int32, day time.Time, format string) string {
+	if len(format) == 0 {
+		format = WarehouseFormatParquet
+	}
+
+	return fmt.Sprintf("%s/org_id=%d/date=%s/usage.%s", strings.TrimSuffix(baseURL, "/"), orgID, day.Format(time.DateOnly), format)
+}
+
+func (j *WarehouseExportJob) upload(ctx context.Context, url string, data []byte, contentType string, tnow time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(common.HeaderContentType, contentType)
+
+	region := j.Region.Value()
+	if len(region) == 0 {
+		region = warehouseDefaultRegion
+	}
+
+	payloadHash := sha256.Sum256(data)
+	signS3Request(req, hex.EncodeToString(payloadHash[:]), j.AccessKey.Value(), j.SecretKey.Value(), region, tnow)
+
+	resp, err := j.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		slog.ErrorContext(ctx, "Warehouse storage returned error", "status", resp.StatusCode, "url", url)
+		return errWarehouseStatus
+	}
+
+	return nil
+}
+
+func (j *WarehouseExportJob) RunOnce(ctx context.Context) error {
+	baseURL := j.URL.Value()
+	if len(baseURL) == 0 {
+		return nil
+	}
+
+	tnow := time.Now().UTC()
+	// previous day is complete
+	day := tnow.Truncate(24*time.Hour).AddDate(0, 0, -1)
+
+	counters, err := j.TimeSeries.ReadDailyUsageCounters(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	orgs := make(map[int32][]*common.UsageCounter)
+	for _, c := range counters {
+		orgs[c.OrgID] = append(orgs[c.OrgID], c)
+	}
+
+	var errs []error
+
+	for orgID, orgCounters := range orgs {
+		data, contentType, err := j.encode(day, orgCounters)
+		if err != nil {
+			return err
+		}
+
+		url := warehouseObjectURL(baseURL, orgID, day, j.Format.Value())
+		if err := j.upload(ctx, url, data, contentType, tnow); err != nil {
+			slog.ErrorContext(ctx, "Failed to export org usage to warehouse", "orgID", orgID, common.ErrAttr(err))
+			errs = append(errs, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Exported usage to warehouse", "orgs", len(orgs), "failed", len(errs), "day", day)
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,171 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	webhookEventsBatchSize   = 50
+	maxWebhookEventAttempts  = 10
+	webhookEventsBaseBackoff = 30 * time.Second
+	webhookEventsMaxBackoff  = 6 * time.Hour
+)
+
+var (
+	errNoWebhookHandler = errors.New("no handler for webhook event type")
+)
+
+// WebhookEventHandler performs side-effects of the webhook event. It is executed in a transaction together
+// with marking event as processed, so either all side-effects are applied (exactly once) or none of them.
+type WebhookEventHandler func(ctx context.Context, impl *db.BusinessStoreImpl, event *dbgen.WebhookEvent) error
+
+// ProcessWebhookEventsJob is the worker part of the transactional outbox: webhook handlers only persist
+// the event (idempotently by event ID) and this job applies side-effects with retries
+type ProcessWebhookEventsJob struct {
+	Store    db.Implementor
+	Handlers map[string]WebhookEventHandler
+}
+
+var _ common.PeriodicJob = (*ProcessWebhookEventsJob)(nil)
+
+func (j *ProcessWebhookEventsJob) Interval() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *ProcessWebhookEventsJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *ProcessWebhookEventsJob) Name() string {
+	return "process_webhook_events_job"
+}
+
+func webhookEventBackoff(attempts int32) time.Duration {
+	backoff := webhookEventsBaseBackoff
+	for i := int32(0); (i < attempts) && (backoff < webhookEventsMaxBackoff); i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, webhookEventsMaxBackoff)
+}
+
+func (j *ProcessWebhookEventsJob) processEvent(ctx context.Context, event *dbgen.WebhookEvent) error {
+	handler, ok := j.Handlers[event.EventType]
+	if !ok {
+		return errNoWebhookHandler
+	}
+
+	return j.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		if err := handler(ctx, impl, event); err != nil {
+			return err
+		}
+
+		return impl.MarkWebhookEventProcessed(ctx, event.ID)
+	})
+}
+
+func (j *ProcessWebhookEventsJob) RunOnce(ctx context.Context) error {
+	events, err := j.Store.Impl().RetrievePendingWebhookEvents(ctx, maxWebhookEventAttempts, webhookEventsBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		ectx := context.WithValue(ctx, common.TraceIDContextKey, event.EventID)
+
+		err := j.processEvent(ectx, event)
+		if err == errNoWebhookHandler {
+			// retries will not help, but event is still kept until cleanup in case it has to be replayed manually
+			slog.WarnContext(ectx, "Skipping webhook event without handler", "id", event.ID, "type", event.EventType)
+			_ = j.Store.Impl().MarkWebhookEventSkipped(ectx, event.ID, err.Error())
+			continue
+		}
+
+		if err != nil {
+			slog.ErrorContext(ectx, "Failed to process webhook event", "id", event.ID, "type", event.EventType,
+				"attempts", event.Attempts, common.ErrAttr(err))
+			nextAttempt := time.Now().UTC().Add(webhookEventBackoff(event.Attempts))
+			_ = j.Store.Impl().MarkWebhookEventFailed(ectx, event.ID, err.Error(), nextAttempt)
+			continue
+		}
+
+		slog.InfoContext(ectx, "Processed webhook event", "id", event.ID, "type", event.EventType)
+	}
+
+	return nil
+}
+
+// CleanupWebhookEventsJob deletes processed events and events that ran out of attempts after retention period.
+// The latter are never applied so operators are alerted about them.
+type CleanupWebhookEventsJob struct {
+	Store   db.Implementor
+	Age     time.Duration
+	Alerter common.Alerter
+}
+
+var _ common.PeriodicJob = (*CleanupWebhookEventsJob)(nil)
+
+func (j *CleanupWebhookEventsJob) Interval() time.Duration {
+	return 24 * time.Hour
+}
+
+func (j *CleanupWebhookEventsJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *CleanupWebhookEventsJob) Name() string {
+	return "cleanup_webhook_events_job"
+}
+
+func exhaustedWebhookEventsAlert(events []*dbgen.WebhookEvent) *common.Alert {
+	types := make(map[string]int)
+	for _, e := range events {
+		types[e.EventType]++
+	}
+
+	lines := make([]string, 0, len(types))
+	for t, count := range types {
+		lines = append(lines, fmt.Sprintf("%s: %d", t, count))
+	}
+	slices.Sort(lines)
+
+	return &common.Alert{
+		Key:      "webhook_events_exhausted",
+		Severity: common.AlertSeverityWarning,
+		Title:    fmt.Sprintf("%d webhook events were deleted without being processed", len(events)),
+		Text:     strings.Join(lines, "\n"),
+	}
+}
+
+func (j *CleanupWebhookEventsJob) RunOnce(ctx context.Context) error {
+	before := time.Now().UTC().Add(-j.Age)
+	if err := j.Store.Impl().DeleteProcessedWebhookEvents(ctx, before); err != nil {
+		return err
+	}
+
+	events, err := j.Store.Impl().DeleteExhaustedWebhookEvents(ctx, maxWebhookEventAttempts, before)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		slog.ErrorContext(ctx, "Deleted exhausted webhook event", "id", e.ID, "eventID", e.EventID, "type", e.EventType,
+			"attempts", e.Attempts, "error", e.LastError)
+	}
+
+	if (len(events) > 0) && (j.Alerter != nil) {
+		j.Alerter.SendAlert(ctx, exhaustedWebhookEventsAlert(events))
+	}
+
+	return nil
+}
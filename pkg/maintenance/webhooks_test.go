@@ -0,0 +1,54 @@
+package maintenance
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestWebhookEventBackoff(t *testing.T) {
+	if b := webhookEventBackoff(0); b != webhookEventsBaseBackoff {
+		t.Errorf("Unexpected initial backoff: %v", b)
+	}
+
+	if b := webhookEventBackoff(2); b != 4*webhookEventsBaseBackoff {
+		t.Errorf("Unexpected backoff: %v", b)
+	}
+
+	prev := time.Duration(0)
+	for i := int32(0); i < 2*maxWebhookEventAttempts; i++ {
+		b := webhookEventBackoff(i)
+		if b < prev {
+			t.Errorf("Backoff is not monotonic at attempt %v", i)
+		}
+		if b > webhookEventsMaxBackoff {
+			t.Errorf("Backoff %v exceeds maximum at attempt %v", b, i)
+		}
+		prev = b
+	}
+}
+
+func TestExhaustedWebhookEventsAlert(t *testing.T) {
+	events := []*dbgen.WebhookEvent{
+		{EventType: "subscription.updated"},
+		{EventType: "transaction.completed"},
+		{EventType: "subscription.updated"},
+	}
+
+	alert := exhaustedWebhookEventsAlert(events)
+
+	if alert.Severity != common.AlertSeverityWarning {
+		t.Errorf("Unexpected alert severity: %v", alert.Severity)
+	}
+
+	if !strings.HasPrefix(alert.Title, "3 ") {
+		t.Errorf("Unexpected alert title: %v", alert.Title)
+	}
+
+	if expected := "subscription.updated: 2\ntransaction.completed: 1"; alert.Text != expected {
+		t.Errorf("Unexpected alert text: %q", alert.Text)
+	}
+}
@@ -0,0 +1,99 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	batchMetricsSubsystem = "batch"
+	pipelineLabel         = "pipeline"
+)
+
+// batchMetrics are updated by the batch pipelines (access and verify logs, backfills) on every flush
+type batchMetrics struct {
+	queued      *prometheus.GaugeVec
+	pending     *prometheus.GaugeVec
+	size        *prometheus.HistogramVec
+	duration    *prometheus.HistogramVec
+	errors      *prometheus.CounterVec
+	dropped     *prometheus.CounterVec
+	lastSuccess *prometheus.GaugeVec
+}
+
+func newBatchMetrics(reg *prometheus.Registry) *batchMetrics {
+	labels := []string{pipelineLabel}
+
+	m := &batchMetrics{
+		queued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "queue_items",
+			Help:      "Number of items waiting in the channel of the batch pipeline",
+		}, labels),
+		pending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "pending_items",
+			Help:      "Number of items accumulated in the batch and not flushed yet",
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "flush_items",
+			Help:      "Number of items in the flushed batch",
+			Buckets:   []float64{1, 10, 50, 100, 500, 1000, 5000, 10000},
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "flush_duration_seconds",
+			Help:      "Duration of the batch flush",
+			Buckets:   []float64{.005, .01, .05, .1, .5, 1, 5, 10},
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "flush_errors_total",
+			Help:      "Total number of failed batch flushes (items are retried with the next flush)",
+		}, labels),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "dropped_items_total",
+			Help:      "Total number of items dropped because the batch could not be flushed for too long",
+		}, labels),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: batchMetricsSubsystem,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful batch flush",
+		}, labels),
+	}
+
+	reg.MustRegister(m.queued, m.pending, m.size, m.duration, m.errors, m.dropped, m.lastSuccess)
+
+	return m
+}
+
+func (s *Service) ObserveBatchQueue(pipeline string, queued, pending int) {
+	labels := prometheus.Labels{pipelineLabel: pipeline}
+	s.batch.queued.With(labels).Set(float64(queued))
+	s.batch.pending.With(labels).Set(float64(pending))
+}
+
+func (s *Service) ObserveBatchFlush(pipeline string, size int, duration time.Duration, err error) {
+	labels := prometheus.Labels{pipelineLabel: pipeline}
+	s.batch.size.With(labels).Observe(float64(size))
+	s.batch.duration.With(labels).Observe(duration.Seconds())
+	if err != nil {
+		s.batch.errors.With(labels).Inc()
+	} else {
+		s.batch.lastSuccess.With(labels).SetToCurrentTime()
+	}
+}
+
+func (s *Service) ObserveBatchDropped(pipeline string, count int) {
+	s.batch.dropped.With(prometheus.Labels{pipelineLabel: pipeline}).Add(float64(count))
+}
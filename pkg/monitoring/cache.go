@@ -0,0 +1,73 @@
+package monitoring
+
+import (
+	"log/slog"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	cacheMetricsSubsystem = "cache"
+	classLabel            = "class"
+)
+
+// cacheCollector exports usage stats of in-memory caches. Stats are read only on scrape so that cache
+// operations on the hot path do not need to update prometheus metrics.
+type cacheCollector struct {
+	name        string
+	source      common.CacheStatsSource
+	size        *prometheus.Desc
+	bytes       *prometheus.Desc
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*cacheCollector)(nil)
+
+func newCacheCollector(name string, source common.CacheStatsSource) *cacheCollector {
+	labels := []string{classLabel}
+	constLabels := prometheus.Labels{nameLabel: name}
+	fqName := func(metric string) string {
+		return prometheus.BuildFQName(MetricsNamespaceServer, cacheMetricsSubsystem, metric)
+	}
+
+	return &cacheCollector{
+		name:        name,
+		source:      source,
+		size:        prometheus.NewDesc(fqName("items"), "Number of items in the cache", labels, constLabels),
+		bytes:       prometheus.NewDesc(fqName("bytes"), "Approximate memory used by items in the cache", labels, constLabels),
+		hits:        prometheus.NewDesc(fqName("hits_total"), "Total number of cache hits", labels, constLabels),
+		misses:      prometheus.NewDesc(fqName("misses_total"), "Total number of cache misses", labels, constLabels),
+		evictions:   prometheus.NewDesc(fqName("evictions_total"), "Total number of items evicted due to capacity", labels, constLabels),
+		expirations: prometheus.NewDesc(fqName("expirations_total"), "Total number of expired items", labels, constLabels),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.bytes
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expirations
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.source.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.Size), s.Class)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(s.Bytes), s.Class)
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits), s.Class)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses), s.Class)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(s.Evictions), s.Class)
+		ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(s.Expirations), s.Class)
+	}
+}
+
+func (s *Service) RegisterCacheStats(name string, source common.CacheStatsSource) {
+	if err := s.Registry.Register(newCacheCollector(name, source)); err != nil {
+		slog.Error("Failed to register cache metrics", "name", name, common.ErrAttr(err))
+	}
+}
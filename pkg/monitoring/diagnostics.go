@@ -0,0 +1,125 @@
+package monitoring
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const DiagnosticsEndpoint = "debug/state"
+
+// DiagnosticsSource returns a JSON-serializable section of the runtime state dump
+type DiagnosticsSource func() any
+
+type runtimeState struct {
+	Goroutines  int    `json:"goroutines"`
+	GOMAXPROCS  int    `json:"gomaxprocs"`
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapObjects uint64 `json:"heap_objects"`
+	NumGC       uint32 `json:"num_gc"`
+	Uptime      string `json:"uptime"`
+}
+
+// Diagnostics collects current runtime state of the node (goroutines, caches, rate limiters, queues, config)
+// from registered sources to make debugging of stuck nodes possible. The dump is served on the local API and
+// logged on demand (e.g. on SIGUSR1)
+type Diagnostics struct {
+	lock    sync.Mutex
+	started time.Time
+	names   []string
+	sources map[string]DiagnosticsSource
+	caches  map[string]common.CacheStatsSource
+}
+
+var _ common.CacheStatsRegistry = (*Diagnostics)(nil)
+
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{
+		started: time.Now(),
+		names:   make([]string, 0),
+		sources: make(map[string]DiagnosticsSource),
+		caches:  make(map[string]common.CacheStatsSource),
+	}
+}
+
+// Register adds (or replaces) a named section of the dump
+func (d *Diagnostics) Register(name string, source DiagnosticsSource) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, ok := d.sources[name]; !ok {
+		d.names = append(d.names, name)
+	}
+
+	d.sources[name] = source
+}
+
+func (d *Diagnostics) RegisterCacheStats(name string, source common.CacheStatsSource) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.caches[name] = source
+}
+
+func (d *Diagnostics) runtimeState() *runtimeState {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return &runtimeState{
+		Goroutines:  runtime.NumGoroutine(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapObjects: mem.HeapObjects,
+		NumGC:       mem.NumGC,
+		Uptime:      time.Since(d.started).Truncate(time.Second).String(),
+	}
+}
+
+// Snapshot reads the state from all sources. Sources are called without holding the lock so that a stuck
+// source does not block registration
+func (d *Diagnostics) Snapshot() map[string]any {
+	d.lock.Lock()
+	names := append([]string{}, d.names...)
+	sources := make(map[string]DiagnosticsSource, len(d.sources))
+	for name, source := range d.sources {
+		sources[name] = source
+	}
+	caches := make(map[string]common.CacheStatsSource, len(d.caches))
+	for name, source := range d.caches {
+		caches[name] = source
+	}
+	d.lock.Unlock()
+
+	result := make(map[string]any, len(names)+2)
+	result["runtime"] = d.runtimeState()
+
+	cacheStats := make(map[string][]*common.CacheStats, len(caches))
+	for name, source := range caches {
+		cacheStats[name] = source.Stats()
+	}
+	result["caches"] = cacheStats
+
+	for _, name := range names {
+		result[name] = sources[name]()
+	}
+
+	return result
+}
+
+// Log writes the dump to the log (on signal, when local API is not reachable)
+func (d *Diagnostics) Log(ctx context.Context) {
+	slog.InfoContext(ctx, "Runtime diagnostics", "state", d.Snapshot())
+}
+
+func (d *Diagnostics) stateHandler(w http.ResponseWriter, r *http.Request) {
+	common.SendJSONResponse(r.Context(), w, d.Snapshot(), common.NoCacheHeaders)
+}
+
+func (d *Diagnostics) Setup(mux *http.ServeMux) {
+	mux.Handle(http.MethodGet+" /"+DiagnosticsEndpoint, common.Recovered(http.HandlerFunc(d.stateHandler)))
+}
@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+type cacheStatsStub struct{}
+
+func (cacheStatsStub) Stats() []*common.CacheStats {
+	return []*common.CacheStats{{Class: "user", Size: 3}}
+}
+
+func TestDiagnosticsState(t *testing.T) {
+	t.Parallel()
+
+	diagnostics := NewDiagnostics()
+	diagnostics.RegisterCacheStats("business", cacheStatsStub{})
+	diagnostics.Register("queues", func() any { return map[string]int{"verify_log": 5} })
+
+	mux := http.NewServeMux()
+	diagnostics.Setup(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+DiagnosticsEndpoint, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code: %v", w.Code)
+	}
+
+	var state struct {
+		Runtime runtimeState                    `json:"runtime"`
+		Caches  map[string][]*common.CacheStats `json:"caches"`
+		Queues  map[string]int                  `json:"queues"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Runtime.Goroutines == 0 {
+		t.Error("Goroutine count is missing")
+	}
+
+	if stats := state.Caches["business"]; (len(stats) != 1) || (stats[0].Class != "user") || (stats[0].Size != 3) {
+		t.Errorf("Unexpected cache stats: %v", stats)
+	}
+
+	if state.Queues["verify_log"] != 5 {
+		t.Errorf("Unexpected queues: %v", state.Queues)
+	}
+}
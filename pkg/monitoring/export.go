@@ -0,0 +1,278 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	ExportFormatRemoteWrite = "remote-write"
+	ExportFormatPushgateway = "pushgateway"
+
+	usageRequestsMetric     = "privatecaptcha_property_requests_total"
+	usageVerificationMetric = "privatecaptcha_property_verifications_total"
+	exportTimeout           = 30 * time.Second
+	// max length of a single literal in snappy block format that we produce
+	snappyMaxLiteral = 65536
+)
+
+var (
+	errExportStatus = errors.New("unexpected export response status")
+)
+
+type exportLabel struct {
+	Name  string
+	Value string
+}
+
+type exportSample struct {
+	Name   string
+	Labels []exportLabel
+	Value  float64
+}
+
+// UsageExportJob periodically pushes per-property usage counters to a configured Prometheus remote-write
+// or Pushgateway endpoint. Counters are cumulative since the start of the (UTC) day, which monitoring
+// systems handle as a regular counter reset.
+type UsageExportJob struct {
+	TimeSeries common.TimeSeriesStore
+	URL        common.ConfigItem
+	Format     common.ConfigItem
+	User       common.ConfigItem
+	Password   common.ConfigItem
+	Token      common.ConfigItem
+	Client     *http.Client
+	Period     time.Duration
+}
+
+var _ common.PeriodicJob = (*UsageExportJob)(nil)
+
+func NewUsageExportJob(timeSeries common.TimeSeriesStore, cfg common.ConfigStore) *UsageExportJob {
+	return &UsageExportJob{
+		TimeSeries: timeSeries,
+		URL:        cfg.Get(common.MetricsExportURLKey),
+		Format:     cfg.Get(common.MetricsExportFormatKey),
+		User:       cfg.Get(common.MetricsExportUserKey),
+		Password:   cfg.Get(common.MetricsExportPasswordKey),
+		Token:      cfg.Get(common.MetricsExportTokenKey),
+		Client:     &http.Client{Timeout: exportTimeout},
+		Period:     1 * time.Minute,
+	}
+}
+
+func (j *UsageExportJob) Interval() time.Duration {
+	return j.Period
+}
+
+func (j *UsageExportJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *UsageExportJob) Name() string {
+	return "usage_export_job"
+}
+
+func usageSamples(counters []*common.UsageCounter) []*exportSample {
+	samples := make([]*exportSample, 0, 3*len(counters))
+
+	for _, c := range counters {
+		// NOTE: labels are sorted by name as required by remote-write protocol
+		labels := []exportLabel{
+			{Name: "org_id", Value: strconv.Itoa(int(c.OrgID))},
+			{Name: "property_id", Value: strconv.Itoa(int(c.PropertyID))},
+			{Name: "user_id", Value: strconv.Itoa(int(c.UserID))},
+		}
+
+		samples = append(samples,
+			&exportSample{Name: usageRequestsMetric, Labels: labels, Value: float64(c.Requests)},
+			&exportSample{
+				Name:   usageVerificationMetric,
+				Labels: []exportLabel{labels[0], labels[1], {Name: "result", Value: "success"}, labels[2]},
+				Value:  float64(c.VerifySuccess),
+			},
+			&exportSample{
+				Name:   usageVerificationMetric,
+				Labels: []exportLabel{labels[0], labels[1], {Name: "result", Value: "failure"}, labels[2]},
+				Value:  float64(c.VerifyFailure),
+			},
+		)
+	}
+
+	return samples
+}
+
+// encodeRemoteWrite produces protobuf-encoded prometheus.WriteRequest message
+func encodeRemoteWrite(samples []*exportSample, tnow time.Time) []byte {
+	var request []byte
+
+	for _, s := range samples {
+		var series []byte
+
+		labels := append([]exportLabel{{Name: "__name__", Value: s.Name}}, s.Labels...)
+		for _, l := range labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.Name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.Value)
+
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, label)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(tnow.UnixMilli()))
+
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, sample)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, series)
+	}
+
+	return request
+}
+
+// snappyEncode produces a valid (uncompressed) snappy block: remote-write requires snappy framing, but
+// literal-only encoding is enough for relatively small payloads that we send
+func snappyEncode(data []byte) []byte {
+	result := binary.AppendUvarint(make([]byte, 0, len(data)+len(data)/snappyMaxLiteral*5+16), uint64(len(data)))
+
+	for len(data) > 0 {
+		chunk := data[:min(len(data), snappyMaxLiteral)]
+		data = data[len(chunk):]
+
+		n := len(chunk) - 1
+		switch {
+		case n < 60:
+			result = append(result, byte(n<<2))
+		case n < 1<<8:
+			result = append(result, 60<<2, byte(n))
+		default:
+			result = append(result, 61<<2, byte(n), byte(n>>8))
+		}
+
+		result = append(result, chunk...)
+	}
+
+	return result
+}
+
+// encodePushgateway produces metrics in Prometheus text exposition format
+func encodePushgateway(samples []*exportSample) []byte {
+	var buf bytes.Buffer
+	declared := make(map[string]struct{})
+
+	for _, s := range samples {
+		if _, ok := declared[s.Name]; !ok {
+			declared[s.Name] = struct{}{}
+			fmt.Fprintf(&buf, "# TYPE %s counter\n", s.Name)
+		}
+
+		buf.WriteString(s.Name)
+		buf.WriteByte('{')
+		for i, l := range s.Labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, "%s=%q", l.Name, l.Value)
+		}
+		buf.WriteString("} ")
+		buf.WriteString(strconv.FormatFloat(s.Value, 'f', -1, 64))
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+func (j *UsageExportJob) newRequest(ctx context.Context, url string, samples []*exportSample, tnow time.Time) (*http.Request, error) {
+	var req *http.Request
+	var err error
+
+	switch format := j.Format.Value(); format {
+	case ExportFormatPushgateway:
+		req, err = http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(encodePushgateway(samples)))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(common.HeaderContentType, "text/plain; version=0.0.4")
+	case ExportFormatRemoteWrite, "":
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(snappyEncode(encodeRemoteWrite(samples, tnow))))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(common.HeaderContentType, "application/x-protobuf")
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	default:
+		return nil, fmt.Errorf("unknown metrics export format: %s", format)
+	}
+
+	if token := j.Token.Value(); len(token) > 0 {
+		req.Header.Set(common.HeaderAuthorization, "Bearer "+token)
+	} else if user := j.User.Value(); len(user) > 0 {
+		req.SetBasicAuth(user, j.Password.Value())
+	}
+
+	return req, nil
+}
+
+func (j *UsageExportJob) RunOnce(ctx context.Context) error {
+	url := j.URL.Value()
+	if len(url) == 0 {
+		return nil
+	}
+
+	tnow := time.Now().UTC()
+	from := tnow.Truncate(24 * time.Hour)
+
+	counters, err := j.TimeSeries.ReadUsageCounters(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	if len(counters) == 0 {
+		slog.DebugContext(ctx, "No usage counters to export")
+		return nil
+	}
+
+	samples := usageSamples(counters)
+
+	req, err := j.newRequest(ctx, url, samples, tnow)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create metrics export request", common.ErrAttr(err))
+		return err
+	}
+
+	resp, err := j.Client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export usage metrics", common.ErrAttr(err))
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		slog.ErrorContext(ctx, "Metrics export endpoint returned error", "status", resp.StatusCode)
+		return errExportStatus
+	}
+
+	slog.InfoContext(ctx, "Exported usage metrics", "samples", len(samples), "format", j.Format.Value())
+
+	return nil
+}
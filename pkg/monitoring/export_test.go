@@ -0,0 +1,185 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type stubUsageTimeSeries struct {
+	common.TimeSeriesStore
+	counters []*common.UsageCounter
+}
+
+func (ts *stubUsageTimeSeries) ReadUsageCounters(ctx context.Context, from time.Time) ([]*common.UsageCounter, error) {
+	return ts.counters, nil
+}
+
+// snappyDecodeLiterals only supports literal elements that snappyEncode() produces
+func snappyDecodeLiterals(t *testing.T, data []byte) []byte {
+	length, n := binary.Uvarint(data)
+	if n <= 0 {
+		t.Fatal("Failed to read snappy length")
+	}
+	data = data[n:]
+
+	result := make([]byte, 0, length)
+	for len(data) > 0 {
+		tag := data[0]
+		if tag&0x03 != 0 {
+			t.Fatalf("Unexpected snappy element type %v", tag&0x03)
+		}
+
+		var size int
+		switch tag >> 2 {
+		case 60:
+			size, data = int(data[1])+1, data[2:]
+		case 61:
+			size, data = int(data[1])|int(data[2])<<8+1, data[3:]
+		default:
+			size, data = int(tag>>2)+1, data[1:]
+		}
+
+		result = append(result, data[:size]...)
+		data = data[size:]
+	}
+
+	if uint64(len(result)) != length {
+		t.Fatalf("Decoded length %v does not match expected %v", len(result), length)
+	}
+
+	return result
+}
+
+func TestSnappyEncode(t *testing.T) {
+	for _, size := range []int{1, 59, 60, 61, 255, 256, 257, snappyMaxLiteral, 3*snappyMaxLiteral + 17} {
+		data := bytes.Repeat([]byte{'a', 'b', 'c'}, size)[:size]
+		decoded := snappyDecodeLiterals(t, snappyEncode(data))
+		if !bytes.Equal(data, decoded) {
+			t.Errorf("Snappy roundtrip failed for size %v", size)
+		}
+	}
+}
+
+func newTestExportJob(url, format string, counters []*common.UsageCounter) *UsageExportJob {
+	return &UsageExportJob{
+		TimeSeries: &stubUsageTimeSeries{counters: counters},
+		URL:        config.NewStaticValue(common.MetricsExportURLKey, url),
+		Format:     config.NewStaticValue(common.MetricsExportFormatKey, format),
+		User:       config.NewStaticValue(common.MetricsExportUserKey, "user"),
+		Password:   config.NewStaticValue(common.MetricsExportPasswordKey, "password"),
+		Token:      config.NewStaticValue(common.MetricsExportTokenKey, ""),
+		Client:     http.DefaultClient,
+		Period:     time.Minute,
+	}
+}
+
+func TestExportPushgateway(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || (user != "user") || (password != "password") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+
+	job := newTestExportJob(srv.URL, ExportFormatPushgateway, []*common.UsageCounter{
+		{UserID: 1, OrgID: 2, PropertyID: 3, Requests: 100, VerifySuccess: 90, VerifyFailure: 5},
+	})
+
+	if err := job.RunOnce(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		`privatecaptcha_property_requests_total{org_id="2",property_id="3",user_id="1"} 100`,
+		`privatecaptcha_property_verifications_total{org_id="2",property_id="3",result="success",user_id="1"} 90`,
+		`privatecaptcha_property_verifications_total{org_id="2",property_id="3",result="failure",user_id="1"} 5`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Exported metrics do not contain %q", line)
+		}
+	}
+
+	if count := strings.Count(body, "# TYPE"); count != 2 {
+		t.Errorf("Unexpected number of TYPE declarations: %v", count)
+	}
+}
+
+func TestExportRemoteWrite(t *testing.T) {
+	var payload []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(common.HeaderAuthorization) != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "snappy" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payload, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	job := newTestExportJob(srv.URL, ExportFormatRemoteWrite, []*common.UsageCounter{
+		{UserID: 1, OrgID: 2, PropertyID: 3, Requests: 100},
+		{UserID: 1, OrgID: 2, PropertyID: 4, Requests: 200},
+	})
+	job.Token = config.NewStaticValue(common.MetricsExportTokenKey, "token")
+
+	if err := job.RunOnce(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	request := snappyDecodeLiterals(t, payload)
+	series := 0
+	for len(request) > 0 {
+		num, typ, n := protowire.ConsumeTag(request)
+		if (n < 0) || (num != 1) || (typ != protowire.BytesType) {
+			t.Fatalf("Unexpected field in write request: %v %v", num, typ)
+		}
+		request = request[n:]
+
+		_, n = protowire.ConsumeBytes(request)
+		if n < 0 {
+			t.Fatal("Failed to read time series")
+		}
+		request = request[n:]
+		series++
+	}
+
+	if series != 6 {
+		t.Errorf("Unexpected number of time series: %v", series)
+	}
+}
+
+func TestExportErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	job := newTestExportJob(srv.URL, ExportFormatRemoteWrite, []*common.UsageCounter{{UserID: 1, OrgID: 2, PropertyID: 3}})
+
+	if err := job.RunOnce(context.TODO()); err != errExportStatus {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package monitoring
+
+import (
+	"log/slog"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	puzzlePoolMetricsSubsystem = "puzzle_pool"
+	bucketLabel                = "bucket"
+)
+
+// puzzlePoolCollector exports depth and usage of the pre-generated puzzles pool (read only on scrape)
+type puzzlePoolCollector struct {
+	source    common.PuzzlePoolStatsSource
+	depth     *prometheus.Desc
+	capacity  *prometheus.Desc
+	hits      *prometheus.Desc
+	fallbacks *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*puzzlePoolCollector)(nil)
+
+func newPuzzlePoolCollector(source common.PuzzlePoolStatsSource) *puzzlePoolCollector {
+	labels := []string{bucketLabel}
+	fqName := func(metric string) string {
+		return prometheus.BuildFQName(MetricsNamespaceServer, puzzlePoolMetricsSubsystem, metric)
+	}
+
+	return &puzzlePoolCollector{
+		source:    source,
+		depth:     prometheus.NewDesc(fqName("depth"), "Number of pre-generated puzzles in the pool", labels, nil),
+		capacity:  prometheus.NewDesc(fqName("capacity"), "Maximum number of pre-generated puzzles in the pool", labels, nil),
+		hits:      prometheus.NewDesc(fqName("hits_total"), "Total number of puzzles served from the pool", labels, nil),
+		fallbacks: prometheus.NewDesc(fqName("fallbacks_total"), "Total number of puzzles generated on demand due to empty pool", labels, nil),
+	}
+}
+
+func (c *puzzlePoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.capacity
+	ch <- c.hits
+	ch <- c.fallbacks
+}
+
+func (c *puzzlePoolCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.source.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(s.Depth), s.Bucket)
+		ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(s.Capacity), s.Bucket)
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits), s.Bucket)
+		ch <- prometheus.MustNewConstMetric(c.fallbacks, prometheus.CounterValue, float64(s.Fallbacks), s.Bucket)
+	}
+}
+
+func (s *Service) RegisterPuzzlePoolStats(source common.PuzzlePoolStatsSource) {
+	if err := s.Registry.Register(newPuzzlePoolCollector(source)); err != nil {
+		slog.Error("Failed to register puzzle pool metrics", common.ErrAttr(err))
+	}
+}
@@ -0,0 +1,59 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	queueMetricsSubsystem = "queue"
+	queueLabel            = "queue"
+	jobResultLabel        = "result"
+)
+
+// queueMetrics are updated by the background job queues (emails, alerts) for every executed job
+type queueMetrics struct {
+	jobs     *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.GaugeVec
+}
+
+func newQueueMetrics(reg *prometheus.Registry) *queueMetrics {
+	m := &queueMetrics{
+		jobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: queueMetricsSubsystem,
+			Name:      "jobs_total",
+			Help:      "Total number of executed queued jobs by result (success, retry, failed, dropped)",
+		}, []string{queueLabel, jobResultLabel}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: queueMetricsSubsystem,
+			Name:      "job_duration_seconds",
+			Help:      "Duration of the queued job execution",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30},
+		}, []string{queueLabel}),
+		size: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: queueMetricsSubsystem,
+			Name:      "pending_jobs",
+			Help:      "Number of jobs waiting in the in-memory queue",
+		}, []string{queueLabel}),
+	}
+
+	reg.MustRegister(m.jobs, m.duration, m.size)
+
+	return m
+}
+
+func (s *Service) ObserveJob(queue, result string, duration time.Duration) {
+	s.queue.jobs.With(prometheus.Labels{queueLabel: queue, jobResultLabel: result}).Inc()
+	if duration > 0 {
+		s.queue.duration.With(prometheus.Labels{queueLabel: queue}).Observe(duration.Seconds())
+	}
+}
+
+func (s *Service) ObserveQueueSize(queue string, size int) {
+	s.queue.size.With(prometheus.Labels{queueLabel: queue}).Set(float64(size))
+}
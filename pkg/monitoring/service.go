@@ -24,9 +24,14 @@ const (
 	MetricsNamespacePortal   = "portal"
 	puzzleMetricsSubsystem   = "puzzle"
 	platformMetricsSubsystem = "platform"
+	authMetricsSubsystem     = "auth"
 	userIDLabel              = "user_id"
 	stubLabel                = "stub"
 	resultLabel              = "result"
+	nameLabel                = "name"
+	sourceLabel              = "source"
+	endpointLabel            = "endpoint"
+	kindLabel                = "kind"
 )
 
 type Service struct {
@@ -39,6 +44,15 @@ type Service struct {
 	verifyCount            *prometheus.CounterVec
 	clickhouseHealthGauge  *prometheus.GaugeVec
 	postgresHealthGauge    *prometheus.GaugeVec
+	circuitBreakerGauge    *prometheus.GaugeVec
+	queryDuration          *prometheus.HistogramVec
+	twoFactorFailureCount  *prometheus.CounterVec
+	loginThrottledCount    *prometheus.CounterVec
+	proxyRejectedCount     *prometheus.CounterVec
+	verifyBatchCount       *prometheus.CounterVec
+	verifyBatchItems       prometheus.Histogram
+	batch                  *batchMetrics
+	queue                  *queueMetrics
 }
 
 var _ common.PlatformMetrics = (*Service)(nil)
@@ -123,6 +137,84 @@ func NewService() *Service {
 	)
 	reg.MustRegister(postgresHealthGauge)
 
+	circuitBreakerGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "circuit_breaker_state",
+			Help:      "State of the circuit breaker (0 - closed, 1 - open, 2 - half-open)",
+		},
+		[]string{nameLabel},
+	)
+	reg.MustRegister(circuitBreakerGauge)
+
+	queryDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "query_duration_seconds",
+			Help:      "Duration of database queries by query name",
+			Buckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5},
+		},
+		[]string{sourceLabel, nameLabel},
+	)
+	reg.MustRegister(queryDuration)
+
+	twoFactorFailureCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespacePortal,
+			Subsystem: authMetricsSubsystem,
+			Name:      "twofactor_failures_total",
+			Help:      "Total number of failed two-factor verifications",
+		},
+		[]string{resultLabel},
+	)
+	reg.MustRegister(twoFactorFailureCount)
+
+	loginThrottledCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespacePortal,
+			Subsystem: authMetricsSubsystem,
+			Name:      "login_throttled_total",
+			Help:      "Total number of sign in attempts rejected by per-email or per-IP throttling",
+		},
+		[]string{endpointLabel, kindLabel},
+	)
+	reg.MustRegister(loginThrottledCount)
+
+	proxyRejectedCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "proxy_headers_rejected_total",
+			Help:      "Total number of requests with forwarding headers that were not trusted for client IP",
+		},
+		[]string{resultLabel},
+	)
+	reg.MustRegister(proxyRejectedCount)
+
+	verifyBatchCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "verify_batch_total",
+			Help:      "Total number of batch verify requests, including the ones rejected due to limits or load",
+		},
+		[]string{resultLabel},
+	)
+	reg.MustRegister(verifyBatchCount)
+
+	verifyBatchItems := prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "verify_batch_items",
+			Help:      "Number of items read from batch verify requests",
+			Buckets:   []float64{1, 10, 50, 100, 250, 500, 1000},
+		},
+	)
+	reg.MustRegister(verifyBatchItems)
+
 	fineRecorder := prometheus_metrics.NewRecorder(prometheus_metrics.Config{
 		Prefix:          "fine",
 		Registry:        reg,
@@ -169,6 +261,15 @@ func NewService() *Service {
 		verifyCount:           verifyCount,
 		clickhouseHealthGauge: clickhouseHealthGauge,
 		postgresHealthGauge:   postgresHealthGauge,
+		circuitBreakerGauge:   circuitBreakerGauge,
+		queryDuration:         queryDuration,
+		twoFactorFailureCount: twoFactorFailureCount,
+		loginThrottledCount:   loginThrottledCount,
+		proxyRejectedCount:    proxyRejectedCount,
+		verifyBatchCount:      verifyBatchCount,
+		verifyBatchItems:      verifyBatchItems,
+		batch:                 newBatchMetrics(reg),
+		queue:                 newQueueMetrics(reg),
 	}
 }
 
@@ -208,6 +309,13 @@ func (s *Service) ObservePuzzleVerified(userID int32, result string, isStub bool
 	}).Inc()
 }
 
+func (s *Service) ObserveVerifyBatch(result string, items int) {
+	s.verifyBatchCount.With(prometheus.Labels{
+		resultLabel: result,
+	}).Inc()
+	s.verifyBatchItems.Observe(float64(items))
+}
+
 func (s *Service) ObserveHealth(postgres, clickhouse bool) {
 	var chVal, pgVal float64
 
@@ -227,6 +335,38 @@ func (s *Service) ObserveHealth(postgres, clickhouse bool) {
 	s.clickhouseHealthGauge.With(prometheus.Labels{}).Set(chVal)
 }
 
+func (s *Service) ObserveCircuitBreaker(name string, state common.CircuitBreakerState) {
+	s.circuitBreakerGauge.With(prometheus.Labels{
+		nameLabel: name,
+	}).Set(float64(state))
+}
+
+func (s *Service) ObserveQuery(source, name string, duration time.Duration) {
+	s.queryDuration.With(prometheus.Labels{
+		sourceLabel: source,
+		nameLabel:   name,
+	}).Observe(duration.Seconds())
+}
+
+func (s *Service) ObserveTwoFactorFailure(result string) {
+	s.twoFactorFailureCount.With(prometheus.Labels{
+		resultLabel: result,
+	}).Inc()
+}
+
+func (s *Service) ObserveLoginThrottled(endpoint, kind string) {
+	s.loginThrottledCount.With(prometheus.Labels{
+		endpointLabel: endpoint,
+		kindLabel:     kind,
+	}).Inc()
+}
+
+func (s *Service) ObserveProxyHeaderRejected(reason string) {
+	s.proxyRejectedCount.With(prometheus.Labels{
+		resultLabel: reason,
+	}).Inc()
+}
+
 func (s *Service) Setup(mux *http.ServeMux) {
 	mux.Handle(http.MethodGet+" /metrics", common.Recovered(promhttp.HandlerFor(s.Registry, promhttp.HandlerOpts{Registry: s.Registry})))
 	s.setupProfiling(context.TODO(), mux)
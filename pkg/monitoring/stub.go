@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
@@ -27,4 +28,31 @@ func (sm *stubMetrics) ObservePuzzleCreated(userID int32) {}
 
 func (sm *stubMetrics) ObservePuzzleVerified(userID int32, result string, isStub bool) {}
 
+func (sm *stubMetrics) ObserveVerifyBatch(result string, items int) {}
+
+func (sm *stubMetrics) ObserveBatchQueue(pipeline string, queued, pending int) {}
+
+func (sm *stubMetrics) ObserveBatchFlush(pipeline string, size int, duration time.Duration, err error) {
+}
+
+func (sm *stubMetrics) ObserveBatchDropped(pipeline string, count int) {}
+
+func (sm *stubMetrics) ObserveJob(queue, result string, duration time.Duration) {}
+
+func (sm *stubMetrics) ObserveQueueSize(queue string, size int) {}
+
+func (sm *stubMetrics) ObserveProxyHeaderRejected(reason string) {}
+
 func (sm *stubMetrics) ObserveHealth(postgres, clickhouse bool) {}
+
+func (sm *stubMetrics) ObserveCircuitBreaker(name string, state common.CircuitBreakerState) {}
+
+func (sm *stubMetrics) ObserveQuery(source, name string, duration time.Duration) {}
+
+func (sm *stubMetrics) ObserveTwoFactorFailure(result string) {}
+
+func (sm *stubMetrics) ObserveLoginThrottled(endpoint, kind string) {}
+
+func (sm *stubMetrics) RegisterCacheStats(name string, source common.CacheStatsSource) {}
+
+func (sm *stubMetrics) RegisterPuzzlePoolStats(source common.PuzzlePoolStatsSource) {}
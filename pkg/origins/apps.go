@@ -0,0 +1,88 @@
+package origins
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// native apps do not send Origin header, instead SDK identifies the app with bundle ID (iOS) or package name (Android)
+	MaxAppIDs      = 20
+	maxAppIDLength = 255
+)
+
+var (
+	ErrEmptyAppID    = errors.New("app identifier is empty")
+	ErrAppIDTooLong  = errors.New("app identifier is too long")
+	ErrInvalidAppID  = errors.New("app identifier must be a reverse domain name (e.g. com.example.app)")
+	ErrTooManyAppIDs = errors.New("too many app identifiers")
+)
+
+// ValidateAppID checks that the identifier looks like iOS bundle ID or Android package name
+func ValidateAppID(appID string) error {
+	if len(appID) == 0 {
+		return ErrEmptyAppID
+	}
+
+	if len(appID) > maxAppIDLength {
+		return ErrAppIDTooLong
+	}
+
+	labels := strings.Split(appID, ".")
+	if len(labels) < 2 {
+		return ErrInvalidAppID
+	}
+
+	for _, label := range labels {
+		if len(label) == 0 {
+			return ErrInvalidAppID
+		}
+
+		for _, c := range label {
+			//nolint:staticcheck
+			if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || (c == '-') || (c == '_')) {
+				return ErrInvalidAppID
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseAppIDs validates all app identifiers from the list, skipping empty and duplicate ones
+func ParseAppIDs(values []string) ([]string, error) {
+	result := make([]string, 0, len(values))
+
+	for _, v := range values {
+		appID := strings.TrimSpace(v)
+		if len(appID) == 0 {
+			continue
+		}
+
+		if err := ValidateAppID(appID); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, appID)
+		}
+
+		if !slices.Contains(result, appID) {
+			result = append(result, appID)
+		}
+	}
+
+	if len(result) > MaxAppIDs {
+		return nil, ErrTooManyAppIDs
+	}
+
+	return result, nil
+}
+
+// AppAllowed checks app identifier (sent by the mobile SDK) against the list of the property. Identifiers are
+// compared exactly since Android package names are case-sensitive
+func AppAllowed(appID string, allowed []string) bool {
+	if len(appID) == 0 {
+		return false
+	}
+
+	return slices.Contains(allowed, appID)
+}
@@ -0,0 +1,51 @@
+package origins
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateAppID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		appID string
+		err   error
+	}{
+		{"com.example.app", nil},
+		{"com.Example.My_App", nil},
+		{"io.example.app-2", nil},
+		{"", ErrEmptyAppID},
+		{"app", ErrInvalidAppID},
+		{"com..app", ErrInvalidAppID},
+		{"com.example.app/", ErrInvalidAppID},
+		{"https://example.com", ErrInvalidAppID},
+	}
+
+	for _, tc := range testCases {
+		if err := ValidateAppID(tc.appID); !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected result for %q: %v", tc.appID, err)
+		}
+	}
+}
+
+func TestParseAppIDs(t *testing.T) {
+	t.Parallel()
+
+	appIDs, err := ParseAppIDs([]string{" com.example.app", "", "com.example.app", "org.example.android"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(appIDs) != 2) || (appIDs[0] != "com.example.app") || (appIDs[1] != "org.example.android") {
+		t.Errorf("Unexpected app IDs: %v", appIDs)
+	}
+
+	if _, err := ParseAppIDs([]string{"com.example.app", "example"}); !errors.Is(err, ErrInvalidAppID) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !AppAllowed("com.example.app", appIDs) || AppAllowed("com.example.App", appIDs) || AppAllowed("", appIDs) {
+		t.Error("Unexpected app matching")
+	}
+}
@@ -0,0 +1,266 @@
+// Package origins implements matching of request origins against the domain settings of a property.
+//
+// Rules are evaluated in the following order and the first matching rule wins:
+//  1. localhost and loopback addresses are allowed only if the property allows localhost
+//     (patterns never match them)
+//  2. origin equal to the property domain is always allowed
+//  3. any subdomain of the property domain is allowed if the property allows subdomains, unless
+//     the property domain is itself a public suffix (e.g. "github.io")
+//  4. origin matching any of the explicit patterns of the property is allowed
+//
+// Explicit pattern is either an exact domain name ("app.example.com") or a wildcard pattern where
+// a single '*' can be used only in the leftmost label ("*.example.com", "app-*.example.com").
+// Wildcard matches one or more characters within exactly one label, so "*.example.com" matches
+// "www.example.com", but neither "example.com" nor "a.b.example.com". The part after the wildcard
+// label must be a registrable domain (at least ETLD+1), so patterns like "*.com" or "*.co.uk" are rejected.
+package origins
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	Wildcard         = "*"
+	MaxPatterns      = 20
+	maxPatternLength = 255
+)
+
+var (
+	ErrEmptyPattern      = errors.New("pattern is empty")
+	ErrPatternTooLong    = errors.New("pattern is too long")
+	ErrInvalidCharacters = errors.New("pattern contains invalid characters")
+	ErrMisplacedWildcard = errors.New("wildcard is allowed only once in the leftmost label")
+	ErrPublicSuffix      = errors.New("pattern covers a public suffix")
+	ErrLocalhostPattern  = errors.New("localhost cannot be used in a pattern")
+	ErrWildcardIPAddress = errors.New("wildcard cannot be used with IP address")
+	ErrTooManyPatterns   = errors.New("too many patterns")
+	ErrInvalidDomain     = errors.New("pattern is not a valid domain name")
+)
+
+type Rules struct {
+	Domain          string
+	AllowSubdomains bool
+	AllowLocalhost  bool
+	Patterns        []string
+}
+
+// Allows checks origin (host part only, without scheme and port) against the rules
+func (r *Rules) Allows(origin string) bool {
+	if len(origin) == 0 {
+		return false
+	}
+
+	if common.IsLocalhost(origin) {
+		return r.AllowLocalhost
+	}
+
+	if origin == r.Domain {
+		return true
+	}
+
+	if r.AllowSubdomains && common.IsSubDomainOrDomain(origin, r.Domain) && !IsPublicSuffix(r.Domain) {
+		return true
+	}
+
+	for _, p := range r.Patterns {
+		if Match(origin, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsPublicSuffix returns true if domain cannot be registered by itself (e.g. "com", "co.uk" or "github.io")
+func IsPublicSuffix(domain string) bool {
+	if common.IsIPAddress(domain) {
+		return false
+	}
+
+	_, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	return err != nil
+}
+
+// Match checks origin against a single (valid) pattern
+func Match(origin, pattern string) bool {
+	wildcardIndex := strings.Index(pattern, Wildcard)
+	if wildcardIndex == -1 {
+		return origin == pattern
+	}
+
+	dotIndex := strings.IndexByte(pattern, '.')
+	if (dotIndex == -1) || (dotIndex < wildcardIndex) {
+		return false
+	}
+
+	// rest includes the leading dot
+	rest := pattern[dotIndex:]
+	if !strings.HasSuffix(origin, rest) {
+		return false
+	}
+
+	label := origin[:len(origin)-len(rest)]
+	if strings.IndexByte(label, '.') != -1 {
+		return false
+	}
+
+	prefix, suffix := pattern[:wildcardIndex], pattern[wildcardIndex+1:dotIndex]
+	if len(label) <= len(prefix)+len(suffix) {
+		return false
+	}
+
+	return strings.HasPrefix(label, prefix) && strings.HasSuffix(label, suffix)
+}
+
+func isValidPatternChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || (c == '-') || (c == '.') || (c == ':') || (c == '*')
+}
+
+// NormalizePattern converts user input (that can include scheme, port or path) to the pattern form
+func NormalizePattern(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if len(value) == 0 {
+		return ""
+	}
+
+	if strings.Contains(value, "://") {
+		if _, rest, ok := strings.Cut(value, "://"); ok {
+			value = rest
+		}
+	}
+
+	if slashIndex := strings.IndexByte(value, '/'); slashIndex != -1 {
+		value = value[:slashIndex]
+	}
+
+	// IPv6 addresses are not expected to be used with port
+	if !common.IsIPAddress(value) {
+		if colonIndex := strings.LastIndexByte(value, ':'); colonIndex != -1 {
+			value = value[:colonIndex]
+		}
+	}
+
+	return strings.TrimSuffix(value, ".")
+}
+
+// ValidatePattern checks that (normalized) pattern is well-formed and does not cover a public suffix
+func ValidatePattern(pattern string) error {
+	if len(pattern) == 0 {
+		return ErrEmptyPattern
+	}
+
+	if len(pattern) > maxPatternLength {
+		return ErrPatternTooLong
+	}
+
+	for _, c := range pattern {
+		if !isValidPatternChar(c) {
+			return ErrInvalidCharacters
+		}
+	}
+
+	if common.IsLocalhost(pattern) {
+		return ErrLocalhostPattern
+	}
+
+	wildcardIndex := strings.Index(pattern, Wildcard)
+	if wildcardIndex == -1 {
+		if common.IsIPAddress(pattern) {
+			return nil
+		}
+
+		if err := validateDomainSuffix(pattern); err != nil {
+			return err
+		}
+
+		if IsPublicSuffix(pattern) {
+			return ErrPublicSuffix
+		}
+
+		return nil
+	}
+
+	if strings.Count(pattern, Wildcard) > 1 {
+		return ErrMisplacedWildcard
+	}
+
+	dotIndex := strings.IndexByte(pattern, '.')
+	if (dotIndex == -1) || (dotIndex < wildcardIndex) {
+		return ErrMisplacedWildcard
+	}
+
+	rest := pattern[dotIndex+1:]
+	if common.IsIPAddress(rest) || strings.Contains(pattern, ":") || isNumeric(rest[strings.LastIndexByte(rest, '.')+1:]) {
+		return ErrWildcardIPAddress
+	}
+
+	if err := validateDomainSuffix(rest); err != nil {
+		return err
+	}
+
+	if IsPublicSuffix(rest) {
+		return ErrPublicSuffix
+	}
+
+	return nil
+}
+
+func isNumeric(label string) bool {
+	if len(label) == 0 {
+		return false
+	}
+
+	for _, c := range label {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateDomainSuffix(domain string) error {
+	if strings.Contains(domain, ":") {
+		return ErrInvalidCharacters
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if (len(label) == 0) || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return ErrInvalidDomain
+		}
+	}
+
+	return nil
+}
+
+// ParsePatterns normalizes and validates all patterns from the list, skipping empty and duplicate ones
+func ParsePatterns(values []string) ([]string, error) {
+	result := make([]string, 0, len(values))
+
+	for _, v := range values {
+		pattern := NormalizePattern(v)
+		if len(pattern) == 0 {
+			continue
+		}
+
+		if err := ValidatePattern(pattern); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, pattern)
+		}
+
+		if !slices.Contains(result, pattern) {
+			result = append(result, pattern)
+		}
+	}
+
+	if len(result) > MaxPatterns {
+		return nil, ErrTooManyPatterns
+	}
+
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package origins
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		origin  string
+		pattern string
+		match   bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", false},
+		{"www.example.com", "*.example.com", true},
+		{"example.com", "*.example.com", false},
+		{"a.b.example.com", "*.example.com", false},
+		{"wwwexample.com", "*.example.com", false},
+		{"app-1.example.com", "app-*.example.com", true},
+		{"app-.example.com", "app-*.example.com", false},
+		{"web-1.example.com", "app-*.example.com", false},
+		{"eu-app.example.com", "*-app.example.com", true},
+		{"eu-api.example.com", "*-app.example.com", false},
+		{"app-eu-1.example.com", "app-*-1.example.com", true},
+		{"app-1.example.org", "app-*.example.com", false},
+		{"app-1.example.com", "app-*", false},
+	}
+
+	for _, tc := range testCases {
+		if actual := Match(tc.origin, tc.pattern); actual != tc.match {
+			t.Errorf("Unexpected match of %s against %s: %v", tc.origin, tc.pattern, actual)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern string
+		err     error
+	}{
+		{"example.com", nil},
+		{"*.example.com", nil},
+		{"app-*.example.co.uk", nil},
+		{"*.my-app.github.io", nil},
+		{"192.168.0.1", nil},
+		{"", ErrEmptyPattern},
+		{"com", ErrPublicSuffix},
+		{"co.uk", ErrPublicSuffix},
+		{"github.io", ErrPublicSuffix},
+		{"*.com", ErrPublicSuffix},
+		{"*.co.uk", ErrPublicSuffix},
+		{"*.github.io", ErrPublicSuffix},
+		{"app.*.example.com", ErrMisplacedWildcard},
+		{"*.*.example.com", ErrMisplacedWildcard},
+		{"*", ErrMisplacedWildcard},
+		{"*.168.0.1", ErrWildcardIPAddress},
+		{"localhost", ErrLocalhostPattern},
+		{"exa_mple.com", ErrInvalidCharacters},
+		{"example..com", ErrInvalidDomain},
+		{"-example.com", ErrInvalidDomain},
+	}
+
+	for _, tc := range testCases {
+		if err := ValidatePattern(tc.pattern); err != tc.err {
+			t.Errorf("Unexpected validation result for %q: %v (expected %v)", tc.pattern, err, tc.err)
+		}
+	}
+}
+
+func TestNormalizePattern(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{" Example.COM ", "example.com"},
+		{"https://app-*.example.com:8443/path", "app-*.example.com"},
+		{"example.com.", "example.com"},
+		{"2001:db8::1", "2001:db8::1"},
+	}
+
+	for _, tc := range testCases {
+		if actual := NormalizePattern(tc.value); actual != tc.expected {
+			t.Errorf("Unexpected normalized pattern for %q: %q (expected %q)", tc.value, actual, tc.expected)
+		}
+	}
+}
+
+func TestRulesPrecedence(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		rules   Rules
+		origin  string
+		allowed bool
+	}{
+		{Rules{Domain: "example.com"}, "example.com", true},
+		{Rules{Domain: "example.com"}, "www.example.com", false},
+		{Rules{Domain: "example.com", AllowSubdomains: true}, "a.b.example.com", true},
+		{Rules{Domain: "example.com"}, "localhost", false},
+		{Rules{Domain: "example.com", AllowLocalhost: true}, "127.0.0.1", true},
+		// patterns never allow localhost
+		{Rules{Domain: "example.com", Patterns: []string{"localhost"}}, "localhost", false},
+		// subdomains of a public suffix are never allowed implicitly
+		{Rules{Domain: "github.io", AllowSubdomains: true}, "attacker.github.io", false},
+		{Rules{Domain: "github.io", AllowSubdomains: true}, "github.io", true},
+		{Rules{Domain: "example.com", Patterns: []string{"*.example.org"}}, "www.example.org", true},
+		{Rules{Domain: "example.com", Patterns: []string{"app-*.example.com"}}, "app-2.example.com", true},
+		{Rules{Domain: "example.com", Patterns: []string{"app-*.example.com"}}, "web.example.com", false},
+		{Rules{Domain: "example.com"}, "", false},
+	}
+
+	for i, tc := range testCases {
+		if actual := tc.rules.Allows(tc.origin); actual != tc.allowed {
+			t.Errorf("Unexpected result for %v (%v): %v", tc.origin, i, actual)
+		}
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	t.Parallel()
+
+	patterns, err := ParsePatterns([]string{"", " *.Example.com", "https://*.example.com", "app.example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(patterns) != 2) || (patterns[0] != "*.example.com") || (patterns[1] != "app.example.org") {
+		t.Errorf("Unexpected patterns: %v", patterns)
+	}
+
+	if _, err := ParsePatterns([]string{"example.com", "*.com"}); !errors.Is(err, ErrPublicSuffix) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package origins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+)
+
+const (
+	// ownership of the domain is proven with TXT record on a dedicated subdomain so that it does not interfere
+	// with other TXT records (e.g. SPF) of the domain itself
+	OwnershipRecordLabel = "_privatecaptcha-challenge"
+	ownershipValuePrefix = "privatecaptcha-verification="
+	ownershipTokenBytes  = 16
+)
+
+var (
+	ErrOwnershipRecordNotFound = errors.New("verification TXT record was not found")
+	ErrOwnershipLookup         = errors.New("failed to look up verification TXT record")
+)
+
+// TXTResolver is implemented by net.Resolver
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+func NewOwnershipToken() (string, error) {
+	buf := make([]byte, ownershipTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// OwnershipRecordName returns DNS name where the TXT record has to be created
+func OwnershipRecordName(domain string) string {
+	return OwnershipRecordLabel + "." + strings.TrimSuffix(domain, ".")
+}
+
+// OwnershipRecordValue returns expected value of the TXT record
+func OwnershipRecordValue(token string) string {
+	return ownershipValuePrefix + token
+}
+
+// VerifyOwnership checks that the verification TXT record of the domain contains the token
+func VerifyOwnership(ctx context.Context, resolver TXTResolver, domain, token string) error {
+	records, err := resolver.LookupTXT(ctx, OwnershipRecordName(domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrOwnershipRecordNotFound
+		}
+
+		return ErrOwnershipLookup
+	}
+
+	expected := OwnershipRecordValue(token)
+	if slices.ContainsFunc(records, func(r string) bool { return strings.TrimSpace(r) == expected }) {
+		return nil
+	}
+
+	return ErrOwnershipRecordNotFound
+}
@@ -0,0 +1,75 @@
+package origins
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type stubTXTResolver struct {
+	records map[string][]string
+	err     error
+}
+
+func (r *stubTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if records, ok := r.records[name]; ok {
+		return records, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestVerifyOwnership(t *testing.T) {
+	t.Parallel()
+
+	const token = "0123456789abcdef"
+
+	testCases := []struct {
+		name     string
+		resolver *stubTXTResolver
+		domain   string
+		expected error
+	}{
+		{"valid", &stubTXTResolver{records: map[string][]string{
+			"_privatecaptcha-challenge.example.com": {"v=spf1 -all", "privatecaptcha-verification=" + token},
+		}}, "example.com", nil},
+		{"trailing dot", &stubTXTResolver{records: map[string][]string{
+			"_privatecaptcha-challenge.example.com": {"privatecaptcha-verification=" + token},
+		}}, "example.com.", nil},
+		{"other token", &stubTXTResolver{records: map[string][]string{
+			"_privatecaptcha-challenge.example.com": {"privatecaptcha-verification=fedcba9876543210"},
+		}}, "example.com", ErrOwnershipRecordNotFound},
+		{"apex record", &stubTXTResolver{records: map[string][]string{
+			"example.com": {"privatecaptcha-verification=" + token},
+		}}, "example.com", ErrOwnershipRecordNotFound},
+		{"lookup failure", &stubTXTResolver{err: errors.New("timeout")}, "example.com", ErrOwnershipLookup},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := VerifyOwnership(context.TODO(), tc.resolver, tc.domain, token); err != tc.expected {
+				t.Errorf("Unexpected result: %v (expected %v)", err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNewOwnershipToken(t *testing.T) {
+	t.Parallel()
+
+	t1, err := NewOwnershipToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t2, _ := NewOwnershipToken()
+
+	if (len(t1) != 2*ownershipTokenBytes) || (t1 == t2) {
+		t.Errorf("Unexpected tokens: %v, %v", t1, t2)
+	}
+}
@@ -0,0 +1,233 @@
+package portal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+)
+
+func TestRotationDaysFromParam(t *testing.T) {
+	ctx := context.TODO()
+
+	testCases := []struct {
+		param    string
+		expected int32
+	}{
+		{"", 0},
+		{"0", 0},
+		{"30", 30},
+		{"90", 90},
+		{"180", 180},
+		{"1", 0},
+		{"abc", 0},
+	}
+
+	for _, tc := range testCases {
+		if actual := rotationDaysFromParam(ctx, tc.param); actual != tc.expected {
+			t.Errorf("Unexpected rotation for %q: %v (expected %v)", tc.param, actual, tc.expected)
+		}
+	}
+}
+
+func TestRotatedAPIKeysDisplay(t *testing.T) {
+	tnow := time.Now().UTC()
+
+	oldKey := &dbgen.APIKey{
+		ID:                1,
+		Name:              "old",
+		ExternalID:        db.UUIDFromSiteKey("aaaaaaaabbbbccccddddeeeeeeeeeeee"),
+		Enabled:           db.Bool(true),
+		RequestsPerSecond: 1,
+		RequestsBurst:     20,
+		CreatedAt:         db.Timestampz(tnow.AddDate(0, 0, -90)),
+		ExpiresAt:         db.Timestampz(tnow.AddDate(0, 3, 0)),
+		RotationDays:      90,
+		SuccessorID:       db.Int(2),
+		RotatedAt:         db.Timestampz(tnow),
+	}
+	newKey := &dbgen.APIKey{
+		ID:                2,
+		Name:              "old",
+		ExternalID:        db.UUIDFromSiteKey("bbbbbbbbbbbbccccddddeeeeeeeeeeee"),
+		Enabled:           db.Bool(true),
+		RequestsPerSecond: 1,
+		RequestsBurst:     20,
+		CreatedAt:         db.Timestampz(tnow),
+		ExpiresAt:         db.Timestampz(tnow.AddDate(0, 6, 0)),
+		RotationDays:      90,
+	}
+
+	keys := apiKeysToUserAPIKeys([]*dbgen.APIKey{oldKey, newKey}, tnow)
+	if len(keys) != 2 {
+		t.Fatalf("Unexpected number of keys: %v", len(keys))
+	}
+
+	if len(keys[0].RetiresAt) == 0 || len(keys[0].Secret) > 0 {
+		t.Errorf("Rotated key is not displayed correctly: %+v", keys[0])
+	}
+
+	if keys[1].Secret != db.UUIDToSecret(newKey.ExternalID) || keys[1].ReplacementUntil != keys[0].RetiresAt {
+		t.Errorf("Successor key is not displayed correctly: %+v", keys[1])
+	}
+
+	if len(keys[1].NextRotation) == 0 {
+		t.Errorf("Next rotation is not set for successor")
+	}
+
+	// after the old key is disabled, successor's secret is not shown anymore
+	oldKey.Enabled = db.Bool(false)
+	keys = apiKeysToUserAPIKeys([]*dbgen.APIKey{oldKey, newKey}, tnow)
+	if !keys[0].Disabled || len(keys[1].Secret) > 0 {
+		t.Errorf("Secret of successor is still shown after rotation finished")
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	tnow := time.Now().UTC()
+	key, err := store.Impl().CreateAPIKey(ctx, user.ID, "rotated", tnow.AddDate(0, 6, 0), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err = store.Impl().UpdateAPIKeyRotation(ctx, user.ID, key.ID, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var successor *dbgen.APIKey
+	if err := store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		var terr error
+		successor, terr = impl.RotateAPIKey(ctx, key, tnow)
+		return terr
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if successor.RotationDays != key.RotationDays || successor.Name != key.Name {
+		t.Errorf("Successor does not inherit rotation settings: %+v", successor)
+	}
+
+	// key cannot be rotated twice
+	if err := store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		_, terr := impl.RotateAPIKey(ctx, key, tnow)
+		return terr
+	}); err != db.ErrRecordNotFound {
+		t.Errorf("Unexpected error when rotating twice: %v", err)
+	}
+
+	disabled, err := store.Impl().DisableRotatedAPIKeys(ctx, time.Now().UTC().Add(1*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, k := range disabled {
+		if k.ID == successor.ID {
+			t.Errorf("Successor key was disabled")
+		}
+		found = found || (k.ID == key.ID)
+	}
+
+	if !found {
+		t.Errorf("Rotated key was not disabled")
+	}
+
+	cached, err := store.Impl().RetrieveAPIKey(ctx, db.UUIDToSecret(key.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cached.Enabled.Bool {
+		t.Errorf("Rotated key is still enabled")
+	}
+}
+
+func TestExpireAPIKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	tnow := time.Now().UTC()
+	expiring, err := store.Impl().CreateAPIKey(ctx, user.ID, "expiring", tnow.AddDate(0, 0, 2), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := store.Impl().CreateAPIKey(ctx, user.ID, "expired", tnow.AddDate(0, 0, -40), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containsKey := func(keys []*dbgen.APIKey, id int32) bool {
+		for _, k := range keys {
+			if k.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	soon, err := store.Impl().RetrieveAPIKeysExpiringSoon(ctx, tnow, 3, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !containsKey(soon, expiring.ID) || containsKey(soon, expired.ID) {
+		t.Errorf("Unexpected keys expiring soon")
+	}
+
+	if err := store.Impl().UpdateAPIKeyExpiryNotified(ctx, expiring, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, days := range []int{3, 14} {
+		if soon, err := store.Impl().RetrieveAPIKeysExpiringSoon(ctx, tnow, days, 1000); (err != nil) || containsKey(soon, expiring.ID) {
+			t.Errorf("Key was reminded about again for %v days (err: %v)", days, err)
+		}
+	}
+
+	disabled, err := store.Impl().ExpireAPIKeys(ctx, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !containsKey(disabled, expired.ID) || containsKey(disabled, expiring.ID) {
+		t.Errorf("Unexpected expired keys")
+	}
+
+	archived, err := store.Impl().ArchiveExpiredAPIKeys(ctx, tnow.Add(-common.APIKeyArchiveAfter), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !containsKey(archived, expired.ID) || containsKey(archived, expiring.ID) {
+		t.Errorf("Unexpected archived keys")
+	}
+
+	if _, err := store.Impl().RetrieveAPIKey(ctx, db.UUIDToSecret(expired.ExternalID)); err == nil {
+		t.Errorf("Archived key can still be retrieved")
+	}
+}
@@ -0,0 +1,301 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	propertyDashboardDiagnosticsTemplate = "property/diagnostics.html"
+	propertyDiagnosticsTabIndex          = 3
+	diagnosticsDNSTimeout                = 3 * time.Second
+	diagnosticOK                         = "ok"
+	diagnosticWarning                    = "warning"
+	diagnosticError                      = "error"
+)
+
+// diagnosticCheck is a result of a single live check with an advice what to do about it (if anything)
+type diagnosticCheck struct {
+	Name    string
+	Status  string
+	Details string
+	Advice  string
+}
+
+func (c *diagnosticCheck) OK() bool      { return c.Status == diagnosticOK }
+func (c *diagnosticCheck) Warning() bool { return c.Status == diagnosticWarning }
+
+type propertyDiagnosticsRenderContext struct {
+	propertyDashboardRenderContext
+	Checks []*diagnosticCheck
+}
+
+// diagnosticsResponseWriter discards the payload of the sample puzzle and only remembers its size
+type diagnosticsResponseWriter struct {
+	header http.Header
+	status int
+	size   int
+}
+
+func (w *diagnosticsResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *diagnosticsResponseWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	return len(data), nil
+}
+
+func (w *diagnosticsResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func checkDomainDNS(ctx context.Context, resolver *net.Resolver, domain string) *diagnosticCheck {
+	check := &diagnosticCheck{Name: "DNS resolution"}
+
+	if common.IsLocalhost(domain) || (net.ParseIP(domain) != nil) {
+		check.Status = diagnosticOK
+		check.Details = fmt.Sprintf("%s is not a domain name, nothing to resolve.", domain)
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsDNSTimeout)
+	defer cancel()
+
+	addrs, err := resolver.LookupHost(ctx, domain)
+	if err != nil || len(addrs) == 0 {
+		slog.WarnContext(ctx, "Failed to resolve property domain", "domain", domain, common.ErrAttr(err))
+		check.Status = diagnosticError
+		check.Details = fmt.Sprintf("%s does not resolve.", domain)
+		check.Advice = "Make sure the domain is spelled correctly and has A or AAAA records. The widget checks the domain of the page it is loaded on."
+		return check
+	}
+
+	const maxAddrs = 3
+	if len(addrs) > maxAddrs {
+		addrs = append(addrs[:maxAddrs], "...")
+	}
+
+	check.Status = diagnosticOK
+	check.Details = fmt.Sprintf("%s resolves to %s.", domain, strings.Join(addrs, ", "))
+
+	return check
+}
+
+// checkSitekeyCache interprets the state of the sitekey in the cache of the API on this node
+func checkSitekeyCache(property *dbgen.Property, cached *dbgen.Property, err error) *diagnosticCheck {
+	check := &diagnosticCheck{Name: "Sitekey cache"}
+
+	switch err {
+	case nil:
+		if cached.UpdatedAt.Time.Before(property.UpdatedAt.Time) {
+			check.Status = diagnosticWarning
+			check.Details = "Sitekey is cached with older settings."
+			check.Advice = "Recent changes of settings will be applied after the cache is refreshed, usually within a few minutes."
+		} else {
+			check.Status = diagnosticOK
+			check.Details = "Sitekey is cached with current settings, puzzles are served without delays."
+		}
+	case db.ErrCacheMiss:
+		check.Status = diagnosticOK
+		check.Details = "Sitekey is not cached yet. It will be loaded on the first puzzle request."
+	case db.ErrNegativeCacheHit, db.ErrRecordNotFound:
+		check.Status = diagnosticWarning
+		check.Details = "Sitekey was recently requested before it existed and is cached as missing."
+		check.Advice = "If the property was just created, wait a few minutes. Otherwise make sure the widget uses the sitekey from the Integrations tab."
+	default:
+		check.Status = diagnosticError
+		check.Details = "Failed to check the sitekey cache."
+		check.Advice = "Please try again later."
+	}
+
+	return check
+}
+
+// checkPropertyOrigins simulates CORS requests from the configured domain (and its www subdomain) the same way
+// as API does it for puzzle requests
+func checkPropertyOrigins(property *dbgen.Property) *diagnosticCheck {
+	check := &diagnosticCheck{Name: "Origin (CORS) simulation"}
+	rules := &origins.Rules{
+		Domain:          property.Domain,
+		AllowSubdomains: property.AllowSubdomains,
+		AllowLocalhost:  property.AllowLocalhost,
+		Patterns:        property.AllowedOrigins,
+	}
+
+	if !rules.Allows(property.Domain) {
+		check.Status = diagnosticError
+		check.Details = fmt.Sprintf("Requests from %s are rejected.", property.Domain)
+		check.Advice = "Check the domain of the property in settings."
+		return check
+	}
+
+	check.Status = diagnosticOK
+	check.Details = fmt.Sprintf("Requests from https://%s are allowed.", property.Domain)
+
+	if common.IsLocalhost(property.Domain) || (net.ParseIP(property.Domain) != nil) || strings.HasPrefix(property.Domain, "www.") {
+		return check
+	}
+
+	if www := "www." + property.Domain; !rules.Allows(www) {
+		check.Status = diagnosticWarning
+		check.Details += fmt.Sprintf(" Requests from https://%s are rejected.", www)
+		check.Advice = fmt.Sprintf("If your website is also served from %s, allow subdomains or add it to allowed origins.", www)
+	} else if property.AllowLocalhost && !property.TestMode {
+		check.Advice = "Localhost is allowed, which is convenient for development. Consider disabling it for production."
+	}
+
+	return check
+}
+
+// checkRecentFailures gives advice based on the most frequent recent verification failure
+func checkRecentFailures(stats []*common.VerifyFailureStat) *diagnosticCheck {
+	check := &diagnosticCheck{Name: "Recent verification errors"}
+
+	_, totals := groupVerifyFailures(stats)
+
+	type failure struct {
+		count  uint64
+		name   string
+		advice string
+	}
+
+	failures := []failure{
+		{totals.Expired, "expired", "Verify solutions right after the form is submitted or increase validity period in settings."},
+		{totals.Integrity, "integrity", "Pass the value of the captcha form field to the verify API unchanged."},
+		{totals.Replay, "replay", "Verify each solution exactly once or enable replay in settings."},
+		{totals.WrongOwner, "wrong owner", "Verify solutions with an API key of the organization owner."},
+		{totals.Other, "other", "Make sure that the widget and the verification code are up to date."},
+	}
+
+	var total uint64
+	top := failures[0]
+	for _, f := range failures {
+		total += f.count
+		if f.count > top.count {
+			top = f
+		}
+	}
+
+	if total == 0 {
+		check.Status = diagnosticOK
+		check.Details = "No failed verifications in the last 24 hours."
+		return check
+	}
+
+	check.Status = diagnosticWarning
+	check.Details = fmt.Sprintf("%d failed verifications in the last 24 hours, mostly %s (%d).", total, top.name, top.count)
+	check.Advice = top.advice
+
+	return check
+}
+
+func (s *Server) checkPuzzleIssuance(ctx context.Context, org *dbgen.Organization, property *dbgen.Property) *diagnosticCheck {
+	check := &diagnosticCheck{Name: "Sample puzzle"}
+
+	if !property.TestMode && org.UserID.Valid {
+		active := false
+		if owner, err := s.Store.Impl().RetrieveUser(ctx, org.UserID.Int32); err == nil && owner.SubscriptionID.Valid {
+			if subscr, err := s.Store.Impl().RetrieveSubscription(ctx, owner.SubscriptionID.Int32); err == nil {
+				active = s.PlanService.IsSubscriptionActive(subscr.Status)
+			}
+		}
+
+		if !active {
+			check.Status = diagnosticError
+			check.Details = "Puzzles are not served because organization owner does not have an active subscription."
+			check.Advice = "Organization owner needs to renew the subscription in account settings."
+			return check
+		}
+	}
+
+	p := puzzle.NewPuzzle(0 /*puzzle ID*/, property.ExternalID.Bytes, uint8(property.Level.Int16))
+	if err := p.Init(property.ValidityInterval); err != nil {
+		slog.ErrorContext(ctx, "Failed to init sample puzzle", common.ErrAttr(err))
+		check.Status = diagnosticError
+		check.Details = "Failed to create a sample puzzle."
+		check.Advice = "Please try again later or contact support."
+		return check
+	}
+
+	w := &diagnosticsResponseWriter{header: make(http.Header), status: http.StatusOK}
+	if err := s.PuzzleEngine.Write(ctx, p, property.Salt, w); (err != nil) || (w.status != http.StatusOK) {
+		slog.ErrorContext(ctx, "Failed to write sample puzzle", "status", w.status, common.ErrAttr(err))
+		check.Status = diagnosticError
+		check.Details = "Failed to issue a sample puzzle."
+		check.Advice = "Please try again later or contact support."
+		return check
+	}
+
+	check.Status = diagnosticOK
+	check.Details = fmt.Sprintf("Puzzle with difficulty %d was issued (%d bytes).", property.Level.Int16, w.size)
+
+	return check
+}
+
+func (s *Server) getPropertyDiagnostics(w http.ResponseWriter, r *http.Request) (*propertyDiagnosticsRenderContext, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	dashboardCtx, property, err := s.getOrgProperty(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx := &propertyDiagnosticsRenderContext{
+		propertyDashboardRenderContext: *dashboardCtx,
+		Checks:                         make([]*diagnosticCheck, 0, 5),
+	}
+	renderCtx.Tab = propertyDiagnosticsTabIndex
+
+	renderCtx.Checks = append(renderCtx.Checks, checkDomainDNS(ctx, net.DefaultResolver, property.Domain))
+
+	cached, cerr := s.Store.Impl().GetCachedPropertyBySitekey(ctx, db.UUIDToSiteKey(property.ExternalID))
+	renderCtx.Checks = append(renderCtx.Checks, checkSitekeyCache(property, cached, cerr))
+
+	renderCtx.Checks = append(renderCtx.Checks, s.checkPuzzleIssuance(ctx, org, property))
+	renderCtx.Checks = append(renderCtx.Checks, checkPropertyOrigins(property))
+
+	if stats, err := s.TimeSeries.RetrievePropertyFailures(ctx, org.ID, property.ID, common.TimePeriodToday, time.UTC); err == nil {
+		renderCtx.Checks = append(renderCtx.Checks, checkRecentFailures(stats))
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property failures", common.ErrAttr(err))
+		renderCtx.Checks = append(renderCtx.Checks, &diagnosticCheck{
+			Name:    "Recent verification errors",
+			Status:  diagnosticError,
+			Details: "Failed to retrieve recent verifications.",
+			Advice:  "Please try again later.",
+		})
+	}
+
+	return renderCtx, nil
+}
+
+func (s *Server) getPropertyDiagnosticsTab(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx, err := s.getPropertyDiagnostics(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ctx, propertyDashboardDiagnosticsTemplate, nil
+}
@@ -0,0 +1,73 @@
+package portal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestCheckPropertyOrigins(t *testing.T) {
+	testCases := []struct {
+		property dbgen.Property
+		status   string
+	}{
+		{dbgen.Property{Domain: "example.com"}, diagnosticWarning},
+		{dbgen.Property{Domain: "example.com", AllowSubdomains: true}, diagnosticOK},
+		{dbgen.Property{Domain: "example.com", AllowedOrigins: []string{"www.example.com"}}, diagnosticOK},
+		{dbgen.Property{Domain: "www.example.com"}, diagnosticOK},
+		{dbgen.Property{Domain: "localhost", AllowLocalhost: false}, diagnosticError},
+	}
+
+	for i, tc := range testCases {
+		if check := checkPropertyOrigins(&tc.property); check.Status != tc.status {
+			t.Errorf("Unexpected status at %v: %v (expected %v)", i, check.Status, tc.status)
+		}
+	}
+}
+
+func TestCheckSitekeyCache(t *testing.T) {
+	tnow := time.Now()
+	property := &dbgen.Property{UpdatedAt: db.Timestampz(tnow)}
+
+	if check := checkSitekeyCache(property, property, nil); !check.OK() {
+		t.Errorf("Unexpected status of cached property: %v", check.Status)
+	}
+
+	stale := &dbgen.Property{UpdatedAt: db.Timestampz(tnow.Add(-time.Minute))}
+	if check := checkSitekeyCache(property, stale, nil); !check.Warning() {
+		t.Errorf("Unexpected status of stale property: %v", check.Status)
+	}
+
+	if check := checkSitekeyCache(property, nil, db.ErrCacheMiss); !check.OK() {
+		t.Errorf("Unexpected status of cache miss: %v", check.Status)
+	}
+
+	if check := checkSitekeyCache(property, nil, db.ErrNegativeCacheHit); !check.Warning() || len(check.Advice) == 0 {
+		t.Errorf("Unexpected status of negative cache hit: %v", check.Status)
+	}
+}
+
+func TestCheckRecentFailures(t *testing.T) {
+	if check := checkRecentFailures(nil); !check.OK() {
+		t.Errorf("Unexpected status without failures: %v", check.Status)
+	}
+
+	tnow := time.Now()
+	check := checkRecentFailures([]*common.VerifyFailureStat{
+		{Timestamp: tnow, Status: uint8(puzzle.PuzzleExpiredError), Count: 2},
+		{Timestamp: tnow, Status: uint8(puzzle.VerifiedBeforeError), Count: 5},
+	})
+
+	if !check.Warning() {
+		t.Errorf("Unexpected status with failures: %v", check.Status)
+	}
+
+	if !strings.Contains(check.Details, "replay") || !strings.Contains(check.Advice, "exactly once") {
+		t.Errorf("Unexpected top failure: %v (%v)", check.Details, check.Advice)
+	}
+}
@@ -0,0 +1,122 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
+)
+
+const (
+	domainLookupTimeout = 5 * time.Second
+)
+
+func (s *Server) dnsResolver() origins.TXTResolver {
+	if s.DNSResolver != nil {
+		return s.DNSResolver
+	}
+
+	return net.DefaultResolver
+}
+
+// domainVerification returns DNS challenge of the property, creating one on first request
+func (s *Server) domainVerification(ctx context.Context, propertyID int32) (*dbgen.DomainVerification, error) {
+	token, err := origins.NewOwnershipToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate domain verification token", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return s.Store.Impl().CreateDomainVerification(ctx, propertyID, token)
+}
+
+func (s *Server) loadDomainVerification(ctx context.Context, renderCtx *propertySettingsRenderContext, property *dbgen.Property) {
+	renderCtx.DomainRequired = s.VerifiedDomains
+
+	if property.DomainVerifiedAt.Valid {
+		return
+	}
+
+	verification, err := s.domainVerification(ctx, property.ID)
+	if err != nil {
+		return
+	}
+
+	renderCtx.DomainRecordName = origins.OwnershipRecordName(property.Domain)
+	renderCtx.DomainRecordValue = origins.OwnershipRecordValue(verification.Token)
+	renderCtx.DomainError = verification.LastError
+}
+
+// putPropertyDomain checks DNS challenge of the property right away, instead of waiting for the background job
+func (s *Server) putPropertyDomain(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to verify domain", "userID", user.ID, "propID", renderCtx.Property.ID)
+		renderCtx.DomainError = "Insufficient permissions to verify domain."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	if renderCtx.Property.DomainVerified {
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	verification, err := s.domainVerification(ctx, property.ID)
+	if err != nil {
+		renderCtx.DomainError = "Failed to verify domain. Please try again."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	lctx, cancel := context.WithTimeout(ctx, domainLookupTimeout)
+	verr := origins.VerifyOwnership(lctx, s.dnsResolver(), property.Domain, verification.Token)
+	cancel()
+
+	if verr != nil {
+		slog.DebugContext(ctx, "Failed to verify property domain", "propID", property.ID, "domain", property.Domain, common.ErrAttr(verr))
+		_ = s.Store.Impl().UpdateDomainVerificationCheck(ctx, property.ID, verr.Error())
+		renderCtx.DomainError = verr.Error()
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	updatedProperty, err := s.Store.Impl().UpdatePropertyDomainVerified(ctx, property.ID, true /*verified*/)
+	if err != nil {
+		renderCtx.DomainError = "Failed to verify domain. Please try again."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	s.recordPropertyEvents(ctx, property.ID, user.ID, db.PropertyChanges(property, updatedProperty))
+	s.loadPropertyHistory(ctx, renderCtx, property.ID, user)
+
+	_ = s.Store.Impl().UpdateDomainVerificationCheck(ctx, property.ID, "")
+
+	renderCtx.Property.DomainVerified = true
+	renderCtx.DomainError = ""
+
+	return renderCtx, propertyDashboardSettingsTemplate, nil
+}
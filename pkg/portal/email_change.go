@@ -0,0 +1,104 @@
+package portal
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	emailRevertTemplate = "email-revert/revert.html"
+	revertTokenLength   = 32
+)
+
+type emailRevertRenderContext struct {
+	AlertRenderContext
+	Token    string
+	Email    string
+	Reverted bool
+}
+
+func newRevertToken() (string, error) {
+	buf := make([]byte, revertTokenLength)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// notifyEmailChanged records the email change (as an audit trail) and sends a revert link to the previous address
+func (s *Server) notifyEmailChanged(ctx context.Context, user *dbgen.User, newEmail string) error {
+	token, err := newRevertToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate revert token", common.ErrAttr(err))
+		return err
+	}
+
+	expiresAt := time.Now().UTC().Add(common.EmailChangeRevertTimeout)
+	if _, err := s.Store.Impl().CreateEmailChange(ctx, user.ID, user.Email, newEmail, token, expiresAt); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Audit: user email changed", "userID", user.ID, "old", common.MaskEmail(user.Email, '*'),
+		"new", common.MaskEmail(newEmail, '*'))
+
+	return s.Mailer.SendEmailChanged(ctx, user.Email, newEmail, s.PartsURL(common.EmailEndpoint, common.RevertEndpoint, token))
+}
+
+func (s *Server) getEmailRevert(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	token := r.PathValue(common.ParamToken)
+	if len(token) != 2*revertTokenLength {
+		return nil, "", errInvalidPathArg
+	}
+
+	return &emailRevertRenderContext{Token: token}, emailRevertTemplate, nil
+}
+
+func (s *Server) postEmailRevert(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	token := r.PathValue(common.ParamToken)
+	if len(token) != 2*revertTokenLength {
+		return nil, "", errInvalidPathArg
+	}
+
+	renderCtx := &emailRevertRenderContext{Token: token}
+
+	var change *dbgen.EmailChange
+	err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		var txErr error
+		change, txErr = impl.RevertEmailChange(ctx, token, time.Now().UTC())
+		return txErr
+	})
+
+	switch err {
+	case nil:
+		slog.InfoContext(ctx, "Audit: user email change reverted", "userID", change.UserID, "changeID", change.ID)
+		// whoever changed the email could still be signed in, so all sessions (including current one) are signed out
+		if logins, rerr := s.Store.Impl().RevokeOtherUserLogins(ctx, change.UserID, 0 /*current login ID*/); rerr == nil {
+			s.destroyRevokedSessions(ctx, logins, "" /*current session ID*/)
+			slog.InfoContext(ctx, "Audit: signed out user sessions after email revert", "userID", change.UserID, "count", len(logins))
+		} else {
+			slog.ErrorContext(ctx, "Failed to sign out user sessions after email revert", "userID", change.UserID, common.ErrAttr(rerr))
+		}
+		renderCtx.Reverted = true
+		renderCtx.Email = change.OldEmail
+		renderCtx.SuccessMessage = "Email address was reverted. Please sign in and review your account security."
+	case db.ErrRecordNotFound:
+		renderCtx.ErrorMessage = "This link is invalid or has expired."
+	case db.ErrMaintenance:
+		return nil, "", err
+	default:
+		renderCtx.ErrorMessage = "Failed to revert email change. Please try again."
+	}
+
+	return renderCtx, emailRevertTemplate, nil
+}
@@ -0,0 +1,91 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestRevertEmailChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	oldEmail := user.Email
+	newEmail := "new_" + oldEmail
+
+	if err := server.Store.Impl().UpdateUser(ctx, user.ID, user.Name, newEmail, oldEmail); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := newRevertToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Now().UTC().Add(common.EmailChangeRevertTimeout)
+	if _, err := server.Store.Impl().CreateEmailChange(ctx, user.ID, oldEmail, newEmail, token, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	// session of whoever changed the email
+	cookie, err := portal_tests.AuthenticateSuite(ctx, newEmail, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/" + common.EmailEndpoint + "/" + common.RevertEndpoint + "/" + token
+
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %v", w.Code)
+	}
+
+	reverted, err := server.Store.Impl().FindUserByEmail(ctx, oldEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reverted.ID != user.ID {
+		t.Errorf("Unexpected user found by old email: %v", reverted.ID)
+	}
+
+	// sessions that existed before revert are signed out
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("Unexpected portal response code with session from before revert: %v", w.Code)
+	}
+
+	if location := w.Header().Get("Location"); !strings.HasSuffix(location, common.LoginEndpoint) {
+		t.Errorf("Unexpected redirect location: %v", location)
+	}
+
+	// revert link can only be used once
+	if _, err := server.Store.Impl().RevertEmailChange(ctx, token, time.Now().UTC()); err == nil {
+		t.Error("Email change was reverted twice")
+	}
+}
@@ -38,6 +38,7 @@ func (s *Server) renderError(ctx context.Context, w http.ResponseWriter, code in
 		LoggedIn:    ok && loggedIn,
 		CurrentYear: time.Now().Year(),
 		CDN:         s.CDNURL,
+		CSPNonce:    common.CSPNonce(ctx),
 	}
 
 	actualData := struct {
@@ -67,7 +68,6 @@ func (s *Server) renderError(ctx context.Context, w http.ResponseWriter, code in
 	err := s.template.Render(ctx, &out, errorTemplate, actualData)
 	if err == nil {
 		common.WriteHeaders(w, common.HtmlContentHeaders)
-		common.WriteHeaders(w, common.SecurityHeaders)
 		common.WriteHeaders(w, common.CachedHeaders)
 		w.WriteHeader(code)
 		if _, werr := out.WriteTo(w); werr != nil {
@@ -0,0 +1,654 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"github.com/justinas/alice"
+)
+
+var (
+	errAPIUnauthorized = errors.New("portal API request is not authorized")
+)
+
+// JSON counterparts of portal read models (for clients that cannot use HTML/htmx, e.g. mobile apps)
+type apiOrg struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+type apiProperty struct {
+	ID              string   `json:"id"`
+	OrgID           string   `json:"org_id"`
+	Name            string   `json:"name"`
+	Domain          string   `json:"domain"`
+	Difficulty      int      `json:"difficulty"`
+	AllowSubdomains bool     `json:"allow_subdomains"`
+	AllowLocalhost  bool     `json:"allow_localhost"`
+	AllowReplay     bool     `json:"allow_replay"`
+	MemoryHard      bool     `json:"memory_hard"`
+	PrivacyMode     bool     `json:"privacy_mode"`
+	IplessMode      bool     `json:"ipless_mode"`
+	AccessibleMode  bool     `json:"accessible_mode"`
+	TestMode        bool     `json:"test_mode"`
+	Paused          bool     `json:"paused"`
+	DomainVerified  bool     `json:"domain_verified"`
+	WidgetChannel   string   `json:"widget_channel"`
+	DataRegion      string   `json:"data_region"`
+	AllowedOrigins  []string `json:"allowed_origins"`
+	TrustedVisitors int      `json:"trusted_visitors_threshold"`
+	Tags            []string `json:"tags"`
+}
+
+type apiUsage struct {
+	Limit    int           `json:"limit"`
+	Data     []*statsPoint `json:"data"`
+	Timezone string        `json:"timezone"`
+}
+
+func userOrgsToAPIOrgs(orgs []*userOrg) []*apiOrg {
+	result := make([]*apiOrg, 0, len(orgs))
+	for _, o := range orgs {
+		result = append(result, &apiOrg{ID: o.ID, Name: o.Name, Level: o.Level})
+	}
+	return result
+}
+
+func userPropertiesToAPIProperties(properties []*userProperty) []*apiProperty {
+	result := make([]*apiProperty, 0, len(properties))
+	for _, p := range properties {
+		tags := p.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		result = append(result, &apiProperty{
+			ID:              p.ID,
+			OrgID:           p.OrgID,
+			Name:            p.Name,
+			Domain:          p.Domain,
+			Difficulty:      p.Level,
+			AllowSubdomains: p.AllowSubdomains,
+			AllowLocalhost:  p.AllowLocalhost,
+			AllowReplay:     p.AllowReplay,
+			MemoryHard:      p.MemoryHard,
+			PrivacyMode:     p.PrivacyMode,
+			IplessMode:      p.IplessMode,
+			AccessibleMode:  p.AccessibleMode,
+			TestMode:        p.TestMode,
+			Paused:          p.Paused,
+			DomainVerified:  p.DomainVerified,
+			WidgetChannel:   p.WidgetChannel,
+			DataRegion:      p.DataRegion,
+			AllowedOrigins:  p.AllowedOrigins,
+			TrustedVisitors: p.TrustedThreshold,
+			Tags:            tags,
+		})
+	}
+	return result
+}
+
+func (s *Server) MiddlewareAPIRead(public alice.Chain) alice.Chain {
+	return public.Append(s.maintenance, s.privateTimeoutHandler, s.apiAuth)
+}
+
+// MiddlewareAPIWrite only allows personal access tokens as write requests from portal session are not protected with CSRF
+func (s *Server) MiddlewareAPIWrite(public alice.Chain) alice.Chain {
+	return public.Append(s.maintenance, s.maxBytesHandler, s.privateTimeoutHandler, s.apiTokenRequired, s.apiAuth)
+}
+
+func (s *Server) apiTokenRequired(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get(common.HeaderAPIKey)) == 0 {
+			slog.WarnContext(r.Context(), "Personal access token is required for API write requests")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isPersonalTokenValid(ctx context.Context, key *dbgen.APIKey, tnow time.Time) bool {
+	if !key.UserID.Valid {
+		slog.WarnContext(ctx, "API key does not belong to a user")
+		return false
+	}
+
+	if !key.Enabled.Valid || !key.Enabled.Bool {
+		slog.WarnContext(ctx, "API key is disabled")
+		return false
+	}
+
+	if !key.ExpiresAt.Valid || key.ExpiresAt.Time.Before(tnow) {
+		slog.WarnContext(ctx, "API key is expired", "expiresAt", key.ExpiresAt)
+		return false
+	}
+
+	return true
+}
+
+// apiUserID authenticates the request with a personal access token (user's API key) or with portal session
+func (s *Server) apiUserID(w http.ResponseWriter, r *http.Request) (int32, error) {
+	ctx := r.Context()
+
+	if secret := r.Header.Get(common.HeaderAPIKey); len(secret) > 0 {
+		if len(secret) != db.SecretLen {
+			return -1, errAPIUnauthorized
+		}
+
+		apiKey, err := s.Store.Impl().RetrieveAPIKey(ctx, secret)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to retrieve personal access token", common.ErrAttr(err))
+			return -1, errAPIUnauthorized
+		}
+
+		if !isPersonalTokenValid(ctx, apiKey, time.Now().UTC()) {
+			return -1, errAPIUnauthorized
+		}
+
+		return apiKey.UserID.Int32, nil
+	}
+
+	// we do not want to start new sessions for clients that do not have one
+	if _, err := r.Cookie(s.Sessions.CookieName); err != nil {
+		return -1, errAPIUnauthorized
+	}
+
+	sess := s.Sessions.SessionStart(w, r)
+	if step, ok := sess.Get(session.KeyLoginStep).(int); !ok || (step != loginStepCompleted) {
+		return -1, errAPIUnauthorized
+	}
+
+	if s.sessionOriginChanged(r, sess) {
+		return -1, errAPIUnauthorized
+	}
+
+	userID, ok := sess.Get(session.KeyUserID).(int32)
+	if !ok {
+		slog.ErrorContext(ctx, "Failed to get userID from session")
+		return -1, errAPIUnauthorized
+	}
+
+	return userID, nil
+}
+
+func (s *Server) apiAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := s.apiUserID(w, r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		// same as for private portal pages
+		s.Auth.UpdateLimits(r)
+
+		ctx := context.WithValue(r.Context(), common.UserIDContextKey, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *Server) apiUser(ctx context.Context) (*dbgen.User, error) {
+	userID, ok := ctx.Value(common.UserIDContextKey).(int32)
+	if !ok {
+		slog.ErrorContext(ctx, "Failed to get userID from context")
+		return nil, errAPIUnauthorized
+	}
+
+	return s.Store.Impl().RetrieveUser(ctx, userID)
+}
+
+func (s *Server) sendAPIError(ctx context.Context, w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+
+	switch err {
+	case errAPIUnauthorized, db.ErrRecordNotFound, db.ErrNegativeCacheHit:
+		code = http.StatusUnauthorized
+	case errInvalidPathArg, ErrInvalidRequestArg:
+		code = http.StatusBadRequest
+	case db.ErrPermissions:
+		code = http.StatusForbidden
+	case errOrgSoftDeleted, errPropertySoftDeleted, db.ErrSoftDeleted:
+		code = http.StatusNotFound
+	case db.ErrMaintenance:
+		code = http.StatusServiceUnavailable
+	default:
+		slog.ErrorContext(ctx, "Failed to handle portal API request", common.ErrAttr(err))
+	}
+
+	http.Error(w, http.StatusText(code), code)
+}
+
+func (s *Server) getAPIOrgs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.apiUser(ctx)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	response := struct {
+		Orgs []*apiOrg `json:"orgs"`
+	}{
+		Orgs: userOrgsToAPIOrgs(orgsToUserOrgs(orgs)),
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) getAPIOrgProperties(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.apiUser(ctx)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	properties, err := s.OrgProperties(ctx, org, user.ID)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	_, userProperties := s.applyPropertyTags(ctx, org.ID, propertiesToUserProperties(ctx, properties),
+		r.URL.Query().Get(common.ParamTag))
+
+	response := struct {
+		Properties []*apiProperty `json:"properties"`
+	}{
+		Properties: userPropertiesToAPIProperties(userProperties),
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) getAPIPropertyStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.apiUser(ctx)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	response := s.retrievePropertyStats(ctx, org.ID, property.ID, periodFromParam(ctx, r.PathValue(common.ParamPeriod)),
+		userLocation(ctx, user))
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) getAPIUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.apiUser(ctx)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	tz := userLocation(ctx, user)
+
+	response := &apiUsage{
+		Limit:    s.createUsageSettingsModel(ctx, user).Limit,
+		Data:     s.retrieveAccountStats(ctx, user.ID, tz),
+		Timezone: tz.String(),
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+// apiPropertySpec is the desired state of the property for idempotent upserts. Omitted (null) fields keep
+// their current values (or defaults for new properties), so output of GET can be sent back as is
+type apiPropertySpec struct {
+	Name            string    `json:"name"`
+	Domain          *string   `json:"domain"`
+	Difficulty      *int      `json:"difficulty"`
+	AllowSubdomains *bool     `json:"allow_subdomains"`
+	AllowLocalhost  *bool     `json:"allow_localhost"`
+	AllowReplay     *bool     `json:"allow_replay"`
+	MemoryHard      *bool     `json:"memory_hard"`
+	PrivacyMode     *bool     `json:"privacy_mode"`
+	IplessMode      *bool     `json:"ipless_mode"`
+	AccessibleMode  *bool     `json:"accessible_mode"`
+	TestMode        *bool     `json:"test_mode"`
+	WidgetChannel   *string   `json:"widget_channel"`
+	DataRegion      *string   `json:"data_region"`
+	AllowedOrigins  *[]string `json:"allowed_origins"`
+	TrustedVisitors *int      `json:"trusted_visitors_threshold"`
+	Tags            *[]string `json:"tags"`
+}
+
+// apply returns update params with the spec applied to the property and if anything has changed
+func (spec *apiPropertySpec) apply(property *dbgen.Property) (*dbgen.UpdatePropertyParams, bool, string) {
+	params := &dbgen.UpdatePropertyParams{
+		ID:                       property.ID,
+		Name:                     property.Name,
+		Level:                    property.Level,
+		Growth:                   property.Growth,
+		ValidityInterval:         property.ValidityInterval,
+		AllowSubdomains:          property.AllowSubdomains,
+		AllowLocalhost:           property.AllowLocalhost,
+		AllowReplay:              property.AllowReplay,
+		Algorithm:                property.Algorithm,
+		PrivacyMode:              property.PrivacyMode,
+		AllowedOrigins:           property.AllowedOrigins,
+		TrustedVisitorsThreshold: property.TrustedVisitorsThreshold,
+		TrustedVisitorsTtl:       property.TrustedVisitorsTtl,
+		IplessMode:               property.IplessMode,
+		WidgetChannel:            property.WidgetChannel,
+		DataRegion:               property.DataRegion,
+		AccessibleMode:           property.AccessibleMode,
+	}
+
+	// sandbox properties accept forced verification outcomes so they cannot be switched to/from production
+	if (spec.TestMode != nil) && (*spec.TestMode != property.TestMode) {
+		return nil, false, "Test mode cannot be changed after the property is created."
+	}
+
+	if spec.Difficulty != nil {
+		if (*spec.Difficulty <= 0) || (*spec.Difficulty > int(common.MaxDifficultyLevel)) {
+			return nil, false, "Difficulty is out of range."
+		}
+		params.Level = db.Int2(int16(*spec.Difficulty))
+	}
+
+	if spec.AllowSubdomains != nil {
+		params.AllowSubdomains = *spec.AllowSubdomains
+	}
+
+	if spec.AllowLocalhost != nil {
+		params.AllowLocalhost = *spec.AllowLocalhost
+	}
+
+	if spec.AllowReplay != nil {
+		params.AllowReplay = *spec.AllowReplay
+	}
+
+	if spec.MemoryHard != nil {
+		params.Algorithm = dbgen.PowAlgorithmBlake2b
+		if *spec.MemoryHard {
+			params.Algorithm = dbgen.PowAlgorithmArgon2id
+		}
+	}
+
+	if spec.PrivacyMode != nil {
+		params.PrivacyMode = *spec.PrivacyMode
+	}
+
+	if spec.IplessMode != nil {
+		params.IplessMode = *spec.IplessMode
+	}
+
+	if spec.AccessibleMode != nil {
+		params.AccessibleMode = *spec.AccessibleMode
+	}
+
+	if spec.WidgetChannel != nil {
+		switch channel := dbgen.WidgetChannel(*spec.WidgetChannel); channel {
+		case dbgen.WidgetChannelStable, dbgen.WidgetChannelBeta, dbgen.WidgetChannelPinned:
+			params.WidgetChannel = channel
+		default:
+			return nil, false, "Widget channel is not valid."
+		}
+	}
+
+	// availability of the region depends on the configuration and is checked by the caller
+	if spec.DataRegion != nil {
+		if region := strings.ToLower(strings.TrimSpace(*spec.DataRegion)); (len(region) == 0) || common.IsValidDataRegion(region) {
+			params.DataRegion = region
+		} else {
+			return nil, false, "Data region is not valid."
+		}
+	}
+
+	if spec.AllowedOrigins != nil {
+		origins, err := parseAllowedOrigins(strings.Join(*spec.AllowedOrigins, "\n"))
+		if err != nil {
+			return nil, false, fmt.Sprintf("Origin pattern is not valid (%v).", err)
+		}
+		params.AllowedOrigins = origins
+	}
+
+	if spec.TrustedVisitors != nil {
+		if (*spec.TrustedVisitors < 0) || (*spec.TrustedVisitors > maxTrustedThreshold) {
+			return nil, false, "Trusted visitors threshold is out of range."
+		}
+		params.TrustedVisitorsThreshold = int16(*spec.TrustedVisitors)
+		if (params.TrustedVisitorsThreshold > 0) && (params.TrustedVisitorsTtl == 0) {
+			params.TrustedVisitorsTtl = defaultTrustedTTL
+		}
+	}
+
+	changed := (params.Level != property.Level) ||
+		(params.AllowSubdomains != property.AllowSubdomains) ||
+		(params.AllowLocalhost != property.AllowLocalhost) ||
+		(params.AllowReplay != property.AllowReplay) ||
+		(params.Algorithm != property.Algorithm) ||
+		(params.PrivacyMode != property.PrivacyMode) ||
+		(params.IplessMode != property.IplessMode) ||
+		(params.AccessibleMode != property.AccessibleMode) ||
+		(params.WidgetChannel != property.WidgetChannel) ||
+		(params.DataRegion != property.DataRegion) ||
+		!slices.Equal(params.AllowedOrigins, property.AllowedOrigins) ||
+		(params.TrustedVisitorsThreshold != property.TrustedVisitorsThreshold) ||
+		(params.TrustedVisitorsTtl != property.TrustedVisitorsTtl)
+
+	return params, changed, ""
+}
+
+func sendAPIValidationError(w http.ResponseWriter, message string) {
+	http.Error(w, message, http.StatusBadRequest)
+}
+
+// createAPIProperty creates the property from spec or returns the existing one, if it was created concurrently
+func (s *Server) createAPIProperty(ctx context.Context, user *dbgen.User, org *dbgen.Organization, spec *apiPropertySpec) (*dbgen.Property, string, error) {
+	if (spec.Domain == nil) || (len(*spec.Domain) == 0) {
+		return nil, "Domain is required to create a property.", nil
+	}
+
+	domain, err := common.ParseDomainName(*spec.Domain)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse domain name", "domain", *spec.Domain, common.ErrAttr(err))
+		return nil, "Invalid format of domain name", nil
+	}
+
+	if domainError := s.validateDomainName(ctx, domain); len(domainError) > 0 {
+		return nil, domainError, nil
+	}
+
+	if limitError := s.validatePropertiesLimit(ctx, org, user); len(limitError) > 0 {
+		return nil, limitError, nil
+	}
+
+	property, err := s.Store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       spec.Name,
+		OrgID:      db.Int(org.ID),
+		CreatorID:  db.Int(user.ID),
+		OrgOwnerID: org.UserID,
+		Domain:     domain,
+		Level:      db.Int2(int16(common.DifficultyLevelSmall)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+		TestMode:   (spec.TestMode != nil) && *spec.TestMode,
+	})
+	if err != nil {
+		// name is unique per organization so the only "valid" reason is a concurrent upsert of the same property
+		if existing, ferr := s.Store.Impl().FindOrgProperty(ctx, spec.Name, org.ID); ferr == nil {
+			slog.WarnContext(ctx, "Property was created concurrently", "name", spec.Name, "orgID", org.ID)
+			return existing, "", nil
+		}
+
+		return nil, "", err
+	}
+
+	slog.InfoContext(ctx, "Created property via API", "propID", property.ID, "orgID", org.ID, "userID", user.ID)
+
+	return property, "", nil
+}
+
+// putAPIOrgProperty creates or updates the property with the given name in the organization. Repeating the same
+// request does not cause any changes, which allows managing properties declaratively (e.g. with Terraform)
+func (s *Server) putAPIOrgProperty(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.apiUser(ctx)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	spec := &apiPropertySpec{}
+	if err := json.NewDecoder(r.Body).Decode(spec); err != nil {
+		slog.WarnContext(ctx, "Failed to parse property spec", common.ErrAttr(err))
+		s.sendAPIError(ctx, w, ErrInvalidRequestArg)
+		return
+	}
+
+	spec.Name = strings.TrimSpace(spec.Name)
+	if (len(spec.Name) == 0) || (len(spec.Name) > maxPropertyNameLength) {
+		sendAPIValidationError(w, "Name is empty or too long.")
+		return
+	}
+
+	var tags []string
+	if spec.Tags != nil {
+		var tagsError string
+		if tags, tagsError = parsePropertyTags(strings.Join(*spec.Tags, ",")); len(tagsError) > 0 {
+			sendAPIValidationError(w, tagsError)
+			return
+		}
+	}
+
+	status := http.StatusOK
+	property, err := s.Store.Impl().FindOrgProperty(ctx, spec.Name, org.ID)
+	switch err {
+	case nil:
+		if !s.canEditProperty(ctx, org, property, user.ID) {
+			slog.WarnContext(ctx, "Insufficient permissions to edit property", "userID", user.ID, "propID", property.ID)
+			s.sendAPIError(ctx, w, db.ErrPermissions)
+			return
+		}
+
+		if spec.Domain != nil {
+			if domain, derr := common.ParseDomainName(*spec.Domain); (derr != nil) || (domain != property.Domain) {
+				sendAPIValidationError(w, "Domain of existing property cannot be changed.")
+				return
+			}
+		}
+	case db.ErrRecordNotFound:
+		var validationError string
+		property, validationError, err = s.createAPIProperty(ctx, user, org, spec)
+		if err != nil {
+			s.sendAPIError(ctx, w, err)
+			return
+		}
+
+		if len(validationError) > 0 {
+			sendAPIValidationError(w, validationError)
+			return
+		}
+
+		status = http.StatusCreated
+	default:
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	params, changed, validationError := spec.apply(property)
+	if len(validationError) > 0 {
+		sendAPIValidationError(w, validationError)
+		return
+	}
+
+	if (params.DataRegion != property.DataRegion) && !s.isDataRegionAvailable(params.DataRegion) {
+		sendAPIValidationError(w, "Data region is not available.")
+		return
+	}
+
+	if changed {
+		if property, err = s.Store.Impl().UpdateProperty(ctx, params); err != nil {
+			s.sendAPIError(ctx, w, err)
+			return
+		}
+
+		slog.DebugContext(ctx, "Updated property via API", "propID", property.ID, "orgID", org.ID)
+	}
+
+	currentTags, err := s.Store.Impl().RetrievePropertyTags(ctx, property.ID)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	if (spec.Tags != nil) && !slices.Equal(tags, currentTags) {
+		if err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+			return impl.UpdatePropertyTags(ctx, property.ID, tags)
+		}); err != nil {
+			s.sendAPIError(ctx, w, err)
+			return
+		}
+
+		currentTags = tags
+	}
+
+	result := propertyToUserProperty(property)
+	result.Tags = currentTags
+
+	response, err := json.Marshal(userPropertiesToAPIProperties([]*userProperty{result})[0])
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	wHeader := w.Header()
+	wHeader.Set(common.HeaderContentType, common.ContentTypeJSON)
+	for key, value := range common.NoCacheHeaders {
+		wHeader[key] = value
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
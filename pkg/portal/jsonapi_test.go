@@ -0,0 +1,225 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestPortalAPIUnauthorized(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, "/"+common.APIEndpoint+"/"+common.V1Endpoint+"/"+common.OrgEndpoint, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code %v", w.Code)
+	}
+
+	if len(w.Result().Cookies()) > 0 {
+		t.Error("Session was started for unauthorized API request")
+	}
+}
+
+func TestPortalAPIOrgProperties(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	ctx := context.TODO()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("failed to create new account: %v", err)
+	}
+
+	property, err := db_tests.CreatePropertyForOrg(ctx, store, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// session authentication
+	req := httptest.NewRequest(http.MethodGet, "/"+common.APIEndpoint+"/"+common.V1Endpoint+"/"+common.OrgEndpoint, nil)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %v", w.Code)
+	}
+
+	var orgsResponse struct {
+		Orgs []*apiOrg `json:"orgs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&orgsResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(orgsResponse.Orgs) != 1) || (orgsResponse.Orgs[0].ID != strconv.Itoa(int(org.ID))) {
+		t.Fatalf("Unexpected orgs: %v", orgsResponse.Orgs)
+	}
+
+	// personal access token authentication
+	apiKey, err := server.Store.Impl().CreateAPIKey(ctx, user.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/" + common.APIEndpoint + "/" + common.V1Endpoint + "/" + common.OrgEndpoint + "/" + strconv.Itoa(int(org.ID)) + "/" + common.PropertyEndpoint
+	req = httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(common.HeaderAPIKey, db.UUIDToSecret(apiKey.ExternalID))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %v", w.Code)
+	}
+
+	var propertiesResponse struct {
+		Properties []*apiProperty `json:"properties"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&propertiesResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(propertiesResponse.Properties) != 1) || (propertiesResponse.Properties[0].ID != strconv.Itoa(int(property.ID))) {
+		t.Errorf("Unexpected properties: %v", propertiesResponse.Properties)
+	}
+}
+
+func TestPropertySpecApply(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{
+		ID:               1,
+		Name:             "test",
+		Level:            db.Int2(int16(common.DifficultyLevelSmall)),
+		Algorithm:        dbgen.PowAlgorithmBlake2b,
+		ValidityInterval: 6 * time.Hour,
+	}
+
+	if _, changed, errMsg := (&apiPropertySpec{Name: "test"}).apply(property); changed || (len(errMsg) > 0) {
+		t.Errorf("Empty spec changed property (%v)", errMsg)
+	}
+
+	difficulty := int(common.DifficultyLevelSmall)
+	if _, changed, _ := (&apiPropertySpec{Name: "test", Difficulty: &difficulty}).apply(property); changed {
+		t.Error("Same difficulty changed property")
+	}
+
+	memoryHard := true
+	params, changed, _ := (&apiPropertySpec{Name: "test", MemoryHard: &memoryHard}).apply(property)
+	if !changed || (params.Algorithm != dbgen.PowAlgorithmArgon2id) {
+		t.Errorf("Algorithm was not updated: %v", params.Algorithm)
+	}
+
+	threshold := maxTrustedThreshold + 1
+	if _, _, errMsg := (&apiPropertySpec{Name: "test", TrustedVisitors: &threshold}).apply(property); len(errMsg) == 0 {
+		t.Error("Invalid trusted visitors threshold was accepted")
+	}
+
+	testMode := true
+	if _, _, errMsg := (&apiPropertySpec{Name: "test", TestMode: &testMode}).apply(property); len(errMsg) == 0 {
+		t.Error("Test mode of existing property was changed")
+	}
+
+	testMode = false
+	if _, changed, errMsg := (&apiPropertySpec{Name: "test", TestMode: &testMode}).apply(property); changed || (len(errMsg) > 0) {
+		t.Errorf("Same test mode changed property (%v)", errMsg)
+	}
+}
+
+func TestPortalAPIPropertyUpsert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	ctx := context.TODO()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("failed to create new account: %v", err)
+	}
+
+	apiKey, err := server.Store.Impl().CreateAPIKey(ctx, user.ID, "", time.Now().Add(1*time.Hour), 10.0 /*rps*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/" + common.APIEndpoint + "/" + common.V1Endpoint + "/" + common.OrgEndpoint + "/" + strconv.Itoa(int(org.ID)) + "/" + common.PropertyEndpoint
+	const body = `{"name": "terraform", "domain": "example.com", "difficulty": 100, "tags": ["iac"]}`
+
+	upsert := func(secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		if len(secret) > 0 {
+			req.Header.Set(common.HeaderAPIKey, secret)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	secret := db.UUIDToSecret(apiKey.ExternalID)
+
+	if w := upsert(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Unexpected status code without token: %v", w.Code)
+	}
+
+	w := upsert(secret)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Unexpected status code on create: %v (%v)", w.Code, w.Body.String())
+	}
+
+	var created apiProperty
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	if (created.Difficulty != 100) || !slices.Equal(created.Tags, []string{"iac"}) {
+		t.Errorf("Unexpected created property: %+v", created)
+	}
+
+	w = upsert(secret)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code on repeated upsert: %v", w.Code)
+	}
+
+	var updated apiProperty
+	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.ID != created.ID {
+		t.Errorf("Repeated upsert created a new property: %v", updated.ID)
+	}
+}
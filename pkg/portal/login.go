@@ -96,6 +96,13 @@ func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.throttleLogin(w, r, s.loginThrottle, email) {
+		slog.WarnContext(ctx, "Sign in attempt is throttled")
+		data.EmailError = loginThrottledError
+		s.render(w, r, loginFormTemplate, data)
+		return
+	}
+
 	user, err := s.Store.Impl().FindUserByEmail(ctx, email)
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to find user by email", "email", email, common.ErrAttr(err))
@@ -104,6 +111,14 @@ func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.userLocked(ctx, user.ID, time.Now().UTC()) {
+		slog.WarnContext(ctx, "Attempt to sign in to locked account", "userID", user.ID)
+		s.Metrics.ObserveTwoFactorFailure(twoFactorResultLocked)
+		data.EmailError = accountLockedError
+		s.render(w, r, loginFormTemplate, data)
+		return
+	}
+
 	sess := s.Sessions.SessionStart(w, r)
 	if step, ok := sess.Get(session.KeyLoginStep).(int); ok {
 		if step == loginStepCompleted {
@@ -128,6 +143,7 @@ func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
 	_ = sess.Set(session.KeyUserName, user.Name)
 	_ = sess.Set(session.KeyTwoFactorCode, code)
 	_ = sess.Set(session.KeyUserID, user.ID)
+	_ = sess.Set(session.KeyReverifyNewLogins, user.ReverifyNewLogins)
 
 	common.Redirect(s.RelURL(common.TwoFactorEndpoint), http.StatusOK, w, r)
 }
@@ -0,0 +1,186 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+)
+
+const (
+	unknownBrowser = "Unknown browser"
+	unknownOS      = "unknown OS"
+)
+
+type userAgentToken struct {
+	token string
+	name  string
+}
+
+var (
+	// NOTE: order matters as e.g. Edge and Opera user agents also contain "Chrome/" and Chrome contains "Safari/"
+	browserTokens = []userAgentToken{
+		{"Edg/", "Edge"},
+		{"EdgiOS/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	osTokens = []userAgentToken{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+func matchUserAgent(ua string, tokens []userAgentToken, unknown string) string {
+	for _, t := range tokens {
+		if strings.Contains(ua, t.token) {
+			return t.name
+		}
+	}
+
+	return unknown
+}
+
+// deviceFromUserAgent returns a coarse description of the device (browser and OS, without versions) so that
+// it stays the same when browser gets updated
+func deviceFromUserAgent(ua string) string {
+	return matchUserAgent(ua, browserTokens, unknownBrowser) + " on " + matchUserAgent(ua, osTokens, unknownOS)
+}
+
+// countryFromHeader only accepts 2-letter country codes as header can be set by anybody if reverse proxy
+// does not override it
+func countryFromHeader(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) != 2 {
+		return ""
+	}
+
+	for _, c := range value {
+		if (c < 'A') || (c > 'Z') {
+			return ""
+		}
+	}
+
+	return value
+}
+
+func (s *Server) signInInfo(r *http.Request) *common.SignInInfo {
+	info := &common.SignInInfo{
+		Device:    deviceFromUserAgent(r.UserAgent()),
+		Timestamp: time.Now().UTC(),
+	}
+
+	if ip, ok := r.Context().Value(common.RateLimitKeyContextKey).(netip.Addr); ok && ip.IsValid() {
+		info.IPAddress = ip.String()
+	}
+
+	if len(s.CountryHeader) > 0 {
+		info.Country = countryFromHeader(r.Header.Get(s.CountryHeader))
+	}
+
+	return info
+}
+
+// recordSignIn saves the sign-in in user's login history and, if it came from a new device or country, records
+// an audit event and notifies the user via email and in the notification center
+func (s *Server) recordSignIn(ctx context.Context, sess *common.Session, userID int32, email string, info *common.SignInInfo, userAgent string) {
+	login, err := s.Store.Impl().RecordUserLogin(ctx, userID, info.IPAddress, info.Country, info.Device, userAgent, sess.SessionID())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record user login", "userID", userID, common.ErrAttr(err))
+		return
+	}
+
+	// allows to revoke this session from a different one
+	_ = sess.Set(session.KeyLoginID, login.ID)
+
+	s.enforceSessionsLimit(ctx, userID, sess, login.ID)
+
+	if !login.NewOrigin {
+		return
+	}
+
+	slog.InfoContext(ctx, "Audit: sign-in from a new device or location", "userID", userID, "loginID", login.ID,
+		"device", info.Device, "country", info.Country, "ip", info.IPAddress)
+
+	message := fmt.Sprintf("New sign-in from <strong>%s</strong>", html.EscapeString(info.Device))
+	if len(info.Country) > 0 {
+		message += fmt.Sprintf(" (%s)", html.EscapeString(info.Country))
+	}
+	message += ". If this was not you, review your security settings."
+	if _, err := s.Store.Impl().CreateUserNotification(ctx, userID, dbgen.NotificationCategorySecurity, message); err != nil {
+		slog.ErrorContext(ctx, "Failed to create new sign-in notification", "userID", userID, common.ErrAttr(err))
+	}
+
+	if err := s.Mailer.SendNewSignIn(ctx, email, info, s.PartsURL(common.SettingsEndpoint)); err != nil {
+		slog.ErrorContext(ctx, "Failed to send new sign-in notification", "userID", userID, common.ErrAttr(err))
+	}
+}
+
+// sessionOriginChanged checks if logged in session is used from a different device or country than the one it was
+// verified from (which can mean that session cookie was stolen)
+func (s *Server) sessionOriginChanged(r *http.Request, sess *common.Session) bool {
+	if reverify, ok := sess.Get(session.KeyReverifyNewLogins).(bool); !ok || !reverify {
+		return false
+	}
+
+	info := s.signInInfo(r)
+
+	if device, ok := sess.Get(session.KeyLoginDevice).(string); ok && (device != info.Device) {
+		slog.WarnContext(r.Context(), "Session device changed", "old", device, "new", info.Device)
+		return true
+	}
+
+	if country, ok := sess.Get(session.KeyLoginCountry).(string); ok && (len(country) > 0) && (len(info.Country) > 0) && (country != info.Country) {
+		slog.WarnContext(r.Context(), "Session country changed", "old", country, "new", info.Country)
+		return true
+	}
+
+	return false
+}
+
+// reverifySession moves the session back to the 2FA step and sends a new verification code
+func (s *Server) reverifySession(w http.ResponseWriter, r *http.Request, sess *common.Session) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		s.Sessions.SessionDestroy(w, r)
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	slog.InfoContext(ctx, "Audit: session re-verification required", "userID", user.ID)
+
+	code := twoFactorCode()
+
+	if err := s.Mailer.SendTwoFactor(ctx, user.Email, code); err != nil {
+		slog.ErrorContext(ctx, "Failed to send email message", common.ErrAttr(err))
+		s.Sessions.SessionDestroy(w, r)
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	_ = sess.Set(session.KeyLoginStep, loginStepSignInVerify)
+	_ = sess.Set(session.KeyUserEmail, user.Email)
+	_ = sess.Set(session.KeyTwoFactorCode, code)
+	_ = sess.Set(session.KeyReturnURL, r.URL.RequestURI())
+
+	common.Redirect(s.RelURL(common.TwoFactorEndpoint), http.StatusUnauthorized, w, r)
+}
@@ -0,0 +1,111 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestDeviceFromUserAgent(t *testing.T) {
+	testCases := []struct {
+		ua     string
+		device string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15", "Safari on macOS"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1", "Chrome on iOS"},
+		{"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36", "Chrome on Android"},
+		{"", "Unknown browser on unknown OS"},
+	}
+
+	for _, tc := range testCases {
+		if device := deviceFromUserAgent(tc.ua); device != tc.device {
+			t.Errorf("Unexpected device for %q: %v (expected %v)", tc.ua, device, tc.device)
+		}
+	}
+}
+
+func TestCountryFromHeader(t *testing.T) {
+	testCases := []struct {
+		value   string
+		country string
+	}{
+		{"EE", "EE"},
+		{" de ", "DE"},
+		{"", ""},
+		{"EST", ""},
+		{"<b", ""},
+		{"T1", ""},
+	}
+
+	for _, tc := range testCases {
+		if country := countryFromHeader(tc.value); country != tc.country {
+			t.Errorf("Unexpected country for %q: %v (expected %v)", tc.value, country, tc.country)
+		}
+	}
+}
+
+func TestReverifySessionFromNewDevice(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("failed to create new account: %v", err)
+	}
+
+	if _, err := server.Store.Impl().UpdateUserReverifyNewLogins(ctx, user.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	stubMailer := server.Mailer.(*email.StubMailer)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, stubMailer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected portal response code from the same device: %v", w.Code)
+	}
+
+	loginCode := stubMailer.LastCode
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0")
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Unexpected portal response code from a new device: %v", w.Code)
+	}
+
+	if location := w.Header().Get("Location"); !strings.HasSuffix(location, common.TwoFactorEndpoint) {
+		t.Errorf("Unexpected redirect location: %v", location)
+	}
+
+	if (stubMailer.LastEmail != user.Email) || (stubMailer.LastCode == loginCode) {
+		t.Error("New verification code was not sent")
+	}
+}
@@ -106,6 +106,31 @@ func TestGetLoginMaintenance(t *testing.T) {
 	}
 }
 
+func TestPostLoginReadOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	server.readOnlyMode.Store(true)
+	defer server.readOnlyMode.Store(false)
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	// reads are still served in read-only mode
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/"+common.LoginEndpoint, nil))
+	if resp := w.Result(); resp.StatusCode != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("POST", "/"+common.LoginEndpoint, nil))
+	if resp := w.Result(); resp.StatusCode != http.StatusSeeOther {
+		t.Errorf("handler returned wrong status code: got %v want %v", resp.StatusCode, http.StatusSeeOther)
+	}
+}
+
 func TestPostLogin(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -0,0 +1,135 @@
+package portal
+
+import (
+	crand "crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"golang.org/x/net/xsrftoken"
+)
+
+const (
+	magicLinkNonceLength = 16
+	magicLinkAction      = "magic-link"
+	magicLinkSeparator   = "."
+)
+
+func newMagicLinkNonce() (string, error) {
+	buf := make([]byte, magicLinkNonceLength)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// magicLinkToken signs the nonce (and email) so that link cannot be forged or used after the timeout, while
+// the nonce, that is only kept in the session, makes the link single-use and bound to the browser that requested it
+func (s *Server) magicLinkToken(nonce, email string) string {
+	return nonce + magicLinkSeparator + xsrftoken.Generate(s.XSRF.Key, nonce+email, magicLinkAction)
+}
+
+func (s *Server) verifyMagicLinkToken(token, nonce, email string) bool {
+	tokenNonce, signature, found := strings.Cut(token, magicLinkSeparator)
+	if !found || (len(tokenNonce) != 2*magicLinkNonceLength) {
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return false
+	}
+
+	return xsrftoken.ValidFor(signature, s.XSRF.Key, nonce+email, magicLinkAction, common.MagicLinkTimeout)
+}
+
+// sendMagicLink emails a sign-in link as an alternative to entering the verification code. Sending a new link
+// invalidates the previously sent one
+func (s *Server) sendMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess := s.Sessions.SessionStart(w, r)
+	if step, ok := sess.Get(session.KeyLoginStep).(int); !ok || ((step != loginStepSignInVerify) && (step != loginStepSignUpVerify)) {
+		slog.WarnContext(ctx, "User session is not valid", "step", step)
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	email, ok := sess.Get(session.KeyUserEmail).(string)
+	if !ok {
+		slog.ErrorContext(ctx, "Failed to get email from session")
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	nonce, err := newMagicLinkNonce()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate sign-in link nonce", common.ErrAttr(err))
+		s.render(w, r, "twofactor/link-error.html", struct{}{})
+		return
+	}
+
+	token := s.magicLinkToken(nonce, email)
+
+	if err := s.Mailer.SendMagicLink(ctx, email, s.PartsURL(common.LoginEndpoint, common.MagicLinkEndpoint, token)); err != nil {
+		slog.ErrorContext(ctx, "Failed to send sign-in link", common.ErrAttr(err))
+		s.render(w, r, "twofactor/link-error.html", struct{}{})
+		return
+	}
+
+	_ = sess.Set(session.KeyMagicLinkNonce, nonce)
+	s.render(w, r, "twofactor/link.html", struct{}{})
+}
+
+func (s *Server) getMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess := s.Sessions.SessionStart(w, r)
+	step, ok := sess.Get(session.KeyLoginStep).(int)
+	if !ok || ((step != loginStepSignInVerify) && (step != loginStepSignUpVerify)) {
+		// this also happens when the link is opened in a different browser
+		slog.WarnContext(ctx, "User session is not valid for sign-in link", "step", step)
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	email, ok := sess.Get(session.KeyUserEmail).(string)
+	if !ok {
+		slog.ErrorContext(ctx, "Failed to get email from session")
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	nonce, ok := sess.Get(session.KeyMagicLinkNonce).(string)
+	if !ok || (len(nonce) == 0) {
+		slog.WarnContext(ctx, "Sign-in link was not sent or was already used")
+		common.Redirect(s.RelURL(common.TwoFactorEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	// link is single-use regardless of the outcome
+	_ = sess.Delete(session.KeyMagicLinkNonce)
+
+	if !s.verifyMagicLinkToken(r.PathValue(common.ParamToken), nonce, email) {
+		s.Metrics.ObserveTwoFactorFailure(twoFactorResultInvalid)
+		slog.WarnContext(ctx, "Failed to verify sign-in link")
+		common.Redirect(s.RelURL(common.TwoFactorEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	if userID, ok := sess.Get(session.KeyUserID).(int32); ok && (step == loginStepSignInVerify) && s.userLocked(ctx, userID, time.Now().UTC()) {
+		s.Metrics.ObserveTwoFactorFailure(twoFactorResultLocked)
+		slog.WarnContext(ctx, "User is locked", "userID", userID)
+		common.Redirect(s.RelURL(common.TwoFactorEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	slog.DebugContext(ctx, "Verified sign-in link", "step", step)
+
+	s.completeLogin(w, r, sess, step, email)
+}
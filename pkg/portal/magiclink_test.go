@@ -0,0 +1,121 @@
+package portal
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+func TestMagicLinkToken(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{XSRF: &common.XSRFMiddleware{Key: "key", Timeout: common.MagicLinkTimeout}}
+
+	nonce, err := newMagicLinkNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const email = "user@example.com"
+	token := s.magicLinkToken(nonce, email)
+
+	if !s.verifyMagicLinkToken(token, nonce, email) {
+		t.Error("Failed to verify sign-in link token")
+	}
+
+	otherNonce, _ := newMagicLinkNonce()
+	if s.verifyMagicLinkToken(token, otherNonce, email) {
+		t.Error("Token is valid for other nonce")
+	}
+
+	if s.verifyMagicLinkToken(token, nonce, "other@example.com") {
+		t.Error("Token is valid for other email")
+	}
+
+	if s.verifyMagicLinkToken(nonce+magicLinkSeparator+"invalid", nonce, email) {
+		t.Error("Token with invalid signature is valid")
+	}
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("failed to create new account: %v", err)
+	}
+
+	form := url.Values{}
+	form.Add(common.ParamCSRFToken, server.XSRF.Token(""))
+	form.Add(common.ParamEmail, user.Email)
+
+	req := httptest.NewRequest(http.MethodPost, "/"+common.LoginEndpoint, bytes.NewBufferString(form.Encode()))
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	idx := slices.IndexFunc(cookies, func(c *http.Cookie) bool { return c.Name == server.Sessions.CookieName })
+	if idx == -1 {
+		t.Fatal("Cannot find session cookie in response")
+	}
+	cookie := cookies[idx]
+
+	form = url.Values{}
+	form.Add(common.ParamCSRFToken, server.XSRF.Token(user.Email))
+
+	req = httptest.NewRequest(http.MethodPost, "/"+common.MagicLinkEndpoint, bytes.NewBufferString(form.Encode()))
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected send link response code: %v", w.Code)
+	}
+
+	loginPath := server.Mailer.(*email.StubMailer).LastLoginPath
+
+	req = httptest.NewRequest(http.MethodGet, loginPath, nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if location := w.Header().Get("Location"); location != server.RelURL("/") {
+		t.Fatalf("Unexpected sign-in link redirect: %v", location)
+	}
+
+	// link cannot be used twice
+	req = httptest.NewRequest(http.MethodGet, loginPath, nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if location := w.Header().Get("Location"); location == server.RelURL("/") {
+		t.Error("Sign-in link was used twice")
+	}
+
+	privReq := httptest.NewRequest(http.MethodGet, "/", nil)
+	privReq.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, privReq)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Unexpected portal response code: %v", w.Code)
+	}
+}
@@ -41,10 +41,8 @@ type AuthMiddleware struct {
 	rateLimiter ratelimit.HTTPRateLimiter
 }
 
-func NewRateLimiter(cfg common.ConfigStore) ratelimit.HTTPRateLimiter {
-	rateLimitHeader := cfg.Get(common.RateLimitHeaderKey).Value()
-
-	return ratelimit.NewIPAddrRateLimiter("default", rateLimitHeader, newDefaultIPAddrBuckets(cfg))
+func NewRateLimiter(cfg common.ConfigStore, ipStrategy *ratelimit.ClientIPStrategy) ratelimit.HTTPRateLimiter {
+	return ratelimit.NewIPAddrRateLimiter("default", ipStrategy, newDefaultIPAddrBuckets(cfg))
 }
 
 func NewAuthMiddleware(rateLimiter ratelimit.HTTPRateLimiter) *AuthMiddleware {
@@ -65,6 +63,10 @@ func (am *AuthMiddleware) UpdateConfig(cfg common.ConfigStore) {
 		leakybucket.Interval(defaultBucketRate.Value(), defaultLeakInterval))
 }
 
+func (am *AuthMiddleware) BucketsCount() int {
+	return am.rateLimiter.BucketsCount()
+}
+
 func (am *AuthMiddleware) Shutdown() {
 	am.rateLimiter.Shutdown()
 }
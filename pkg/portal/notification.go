@@ -7,9 +7,46 @@ import (
 	"strconv"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
 
+const (
+	notificationsPanelTemplate = "notifications/panel.html"
+	notificationsBadgeTemplate = "notifications/badge.html"
+	maxUserNotifications       = 20
+)
+
+type userNotification struct {
+	ID        string
+	Category  string
+	Message   string
+	CreatedAt string
+	Unread    bool
+}
+
+type notificationsRenderContext struct {
+	CsrfRenderContext
+	Notifications []*userNotification
+	Unread        int
+	// badge is swapped "out of band" when it is rendered as part of the panel
+	SwapBadge bool
+}
+
+func userNotificationsToRender(notifications []*dbgen.UserNotification) []*userNotification {
+	result := make([]*userNotification, 0, len(notifications))
+	for _, n := range notifications {
+		result = append(result, &userNotification{
+			ID:        strconv.Itoa(int(n.ID)),
+			Category:  string(n.Category),
+			Message:   n.Message,
+			CreatedAt: n.CreatedAt.Time.Format("02 Jan 2006"),
+			Unread:    !n.ReadAt.Valid,
+		})
+	}
+	return result
+}
+
 func (s *Server) createSystemNotificationContext(ctx context.Context, sess *common.Session) systemNotificationContext {
 	renderCtx := systemNotificationContext{}
 
@@ -46,3 +83,125 @@ func (s *Server) dismissNotification(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "", http.StatusBadRequest)
 	}
 }
+
+func (s *Server) createNotificationsPanelContext(ctx context.Context, user *dbgen.User) (*notificationsRenderContext, error) {
+	impl := s.Store.Impl()
+
+	notifications, err := impl.RetrieveUserNotifications(ctx, user.ID, maxUserNotifications)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := impl.RetrieveUnreadUserNotificationsCount(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notificationsRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		Notifications:     userNotificationsToRender(notifications),
+		Unread:            int(unread),
+		SwapBadge:         true,
+	}, nil
+}
+
+func (s *Server) getUserNotifications(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.getUserNotificationsPanel(ctx, user)
+}
+
+func (s *Server) getUserNotificationsCount(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	unread, err := s.Store.Impl().RetrieveUnreadUserNotificationsCount(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &notificationsRenderContext{Unread: int(unread)}, notificationsBadgeTemplate, nil
+}
+
+func (s *Server) userNotificationID(r *http.Request) (int32, error) {
+	id, value, err := common.IntPathArg(r, common.ParamID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to parse notification path parameter", "value", value, common.ErrAttr(err))
+		return -1, errInvalidPathArg
+	}
+
+	return int32(id), nil
+}
+
+func (s *Server) postUserNotificationRead(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	notificationID, err := s.userNotificationID(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.Store.Impl().MarkUserNotificationRead(ctx, user.ID, notificationID); err != nil {
+		return nil, "", err
+	}
+
+	return s.getUserNotificationsPanel(ctx, user)
+}
+
+func (s *Server) postUserNotificationsRead(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.Store.Impl().MarkAllUserNotificationsRead(ctx, user.ID); err != nil {
+		return nil, "", err
+	}
+
+	return s.getUserNotificationsPanel(ctx, user)
+}
+
+func (s *Server) deleteUserNotification(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	notificationID, err := s.userNotificationID(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.Store.Impl().DismissUserNotification(ctx, user.ID, notificationID); err != nil {
+		return nil, "", err
+	}
+
+	return s.getUserNotificationsPanel(ctx, user)
+}
+
+func (s *Server) getUserNotificationsPanel(ctx context.Context, user *dbgen.User) (Model, string, error) {
+	renderCtx, err := s.createNotificationsPanelContext(ctx, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return renderCtx, notificationsPanelTemplate, nil
+}
@@ -0,0 +1,149 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	onboardingTemplate = "portal/onboarding.html"
+)
+
+type onboardingStep struct {
+	Title       string
+	Description string
+	Link        string
+	Done        bool
+}
+
+type onboardingChecklist struct {
+	OrgID     string
+	Steps     []*onboardingStep
+	Completed int
+	// checklist is refreshed while we wait for the first puzzle request or verification
+	Live bool
+}
+
+func (c *onboardingChecklist) Done() bool {
+	return c.Completed == len(c.Steps)
+}
+
+type onboardingRenderContext struct {
+	Onboarding *onboardingChecklist
+}
+
+// createOnboardingChecklist evaluates onboarding progress in the org. Widget is considered installed as soon as any
+// of the properties receives a puzzle request
+func createOnboardingChecklist(orgID string, properties []*dbgen.Property, activity map[int32]*common.PropertyActivity, partsURL func(...string) string) *onboardingChecklist {
+	installed, verified := false, false
+	for _, a := range activity {
+		installed = installed || !a.FirstRequest.IsZero()
+		verified = verified || !a.FirstVerify.IsZero()
+	}
+
+	integrationsLink := ""
+	if len(properties) > 0 {
+		integrationsLink = partsURL(common.OrgEndpoint, orgID, common.PropertyEndpoint, strconv.Itoa(int(properties[0].ID))) +
+			"?" + common.ParamTab + "=" + common.IntegrationsEndpoint
+	}
+
+	steps := []*onboardingStep{
+		{
+			Title:       "Create an organization",
+			Description: "Organizations group properties and members that share access to them.",
+			Done:        true,
+		},
+		{
+			Title:       "Create a property",
+			Description: "Property is a website or an app that you want to protect.",
+			Link:        partsURL(common.OrgEndpoint, orgID, common.PropertyEndpoint, common.NewEndpoint),
+			Done:        len(properties) > 0,
+		},
+		{
+			Title:       "Install the widget",
+			Description: "Add captcha widget to your form. This step completes with the first puzzle request.",
+			Link:        integrationsLink,
+			Done:        installed,
+		},
+		{
+			Title:       "Make the first verification",
+			Description: "Verify the solution on your backend. This step completes with the first successful verification.",
+			Link:        integrationsLink,
+			Done:        verified,
+		},
+	}
+
+	checklist := &onboardingChecklist{OrgID: orgID, Steps: steps}
+	for _, step := range steps {
+		if step.Done {
+			checklist.Completed++
+		}
+	}
+
+	checklist.Live = (len(properties) > 0) && !checklist.Done()
+
+	return checklist
+}
+
+func (s *Server) createOnboardingContext(ctx context.Context, org *dbgen.Organization, properties []*dbgen.Property) *onboardingChecklist {
+	var activity map[int32]*common.PropertyActivity
+
+	if len(properties) > 0 {
+		propertyIDs := make([]int32, 0, len(properties))
+		for _, p := range properties {
+			propertyIDs = append(propertyIDs, p.ID)
+		}
+
+		var err error
+		// this is not critical so errors (e.g. ClickHouse maintenance) only mean that the step is not done yet
+		if activity, err = s.TimeSeries.RetrievePropertiesActivity(ctx, org.ID, propertyIDs); err != nil {
+			slog.WarnContext(ctx, "Failed to retrieve properties activity", "orgID", org.ID, common.ErrAttr(err))
+		}
+	}
+
+	return createOnboardingChecklist(strconv.Itoa(int(org.ID)), properties, activity, s.PartsURL)
+}
+
+func (s *Server) getOnboarding(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	properties, err := s.OrgProperties(ctx, org, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &onboardingRenderContext{Onboarding: s.createOnboardingContext(ctx, org, properties)}, onboardingTemplate, nil
+}
+
+func (s *Server) deleteOnboarding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	if _, err := s.Store.Impl().DismissOnboarding(ctx, user.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to dismiss onboarding", "userID", user.ID, common.ErrAttr(err))
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	slog.DebugContext(ctx, "User dismissed onboarding", "userID", user.ID)
+
+	w.WriteHeader(http.StatusOK)
+}
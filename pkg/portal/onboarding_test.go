@@ -0,0 +1,44 @@
+package portal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestOnboardingChecklist(t *testing.T) {
+	t.Parallel()
+
+	partsURL := func(a ...string) string { return strings.Join(a, "/") }
+	properties := []*dbgen.Property{{ID: 1}, {ID: 2}}
+	tnow := time.Now().UTC()
+
+	testCases := []struct {
+		properties []*dbgen.Property
+		activity   map[int32]*common.PropertyActivity
+		completed  int
+		live       bool
+	}{
+		{[]*dbgen.Property{}, nil, 1, false},
+		{properties, nil, 2, true},
+		{properties, map[int32]*common.PropertyActivity{2: {PropertyID: 2, FirstRequest: tnow}}, 3, true},
+		{properties, map[int32]*common.PropertyActivity{
+			1: {PropertyID: 1, FirstRequest: tnow},
+			2: {PropertyID: 2, FirstRequest: tnow, FirstVerify: tnow},
+		}, 4, false},
+	}
+
+	for i, tc := range testCases {
+		checklist := createOnboardingChecklist("123", tc.properties, tc.activity, partsURL)
+		if (checklist.Completed != tc.completed) || (checklist.Live != tc.live) {
+			t.Errorf("Unexpected checklist at %v: completed %v, live %v", i, checklist.Completed, checklist.Live)
+		}
+
+		if checklist.Done() != (tc.completed == len(checklist.Steps)) {
+			t.Errorf("Unexpected done state at %v", i)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -25,18 +26,36 @@ const (
 	orgPropertiesTemplate         = "portal/org-dashboard.html"
 	orgSettingsTemplate           = "portal/org-settings.html"
 	orgMembersTemplate            = "portal/org-members.html"
+	orgDeleteTemplate             = "portal/org-delete.html"
 	orgWizardTemplate             = "org-wizard/wizard.html"
 	portalTemplate                = "portal/portal.html"
 	activeSubscriptionForOrgError = "You need an active subscription to create new organizations."
+	orgDeletionStatsPeriod        = 30 * 24 * time.Hour
 	enterpriseOrgError            = "Creating new organizations is only available in the enterprise edition of Private Captcha."
 )
 
 type orgSettingsRenderContext struct {
 	AlertRenderContext
+	CsrfRenderContext
+	CurrentOrg    *userOrg
+	NameError     string
+	CanEdit       bool
+	Budget        int64
+	BudgetError   string
+	BudgetUpdated bool
+	// organization API tokens act on behalf of the org owner and do not depend on the member who created them
+	Tokens         []*userAPIKey
+	TokenNameError string
+}
+
+// orgDeleteRenderContext shows the impact of the organization deletion before it's confirmed
+type orgDeleteRenderContext struct {
 	CsrfRenderContext
 	CurrentOrg *userOrg
-	NameError  string
-	CanEdit    bool
+	Properties int
+	Members    int
+	Requests   int
+	Verifies   int
 }
 
 type orgUser struct {
@@ -63,10 +82,14 @@ type userOrg struct {
 type orgDashboardRenderContext struct {
 	CsrfRenderContext
 	systemNotificationContext
+	propertyTagsRenderContext
+	onboardingRenderContext
 	Orgs       []*userOrg
 	CurrentOrg *userOrg
 	// shortened from CurrentOrgProperties for simplicity
 	Properties []*userProperty
+	// non-default tab that was requested via query param, loaded separately
+	Tab string
 }
 
 type orgWizardRenderContext struct {
@@ -119,6 +142,16 @@ func orgsToUserOrgs(orgs []*dbgen.GetUserOrganizationsRow) []*userOrg {
 	return result
 }
 
+func countOwnedOrgs(orgs []*dbgen.GetUserOrganizationsRow) int {
+	count := 0
+	for _, org := range orgs {
+		if org.Level == dbgen.AccessLevelOwner {
+			count++
+		}
+	}
+	return count
+}
+
 func (s *Server) getNewOrg(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 
@@ -159,7 +192,7 @@ func (s *Server) validateOrgName(ctx context.Context, name string, userID int32)
 	return ""
 }
 
-func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, sess *common.Session) (*orgDashboardRenderContext, error) {
+func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, tag string, sess *common.Session) (*orgDashboardRenderContext, error) {
 	slog.DebugContext(ctx, "Creating org dashboard context", "orgID", orgID)
 
 	user, err := s.SessionUser(ctx, sess)
@@ -194,9 +227,15 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 		CurrentOrg:                stubUserOrg,
 	}
 
+	prefs := s.userPreferences(ctx, user.ID)
+
 	if idx >= 0 {
 		renderCtx.CurrentOrg = renderCtx.Orgs[idx]
 		slog.DebugContext(ctx, "Selected current org from path", "index", idx)
+	} else if prefIdx := preferredOrgIndex(orgs, prefs.DefaultOrgID.Int32); prefIdx >= 0 {
+		idx = prefIdx
+		renderCtx.CurrentOrg = renderCtx.Orgs[prefIdx]
+		slog.DebugContext(ctx, "Selected current org from preferences", "index", idx)
 	} else if len(renderCtx.Orgs) > 0 {
 		earliestIdx := 0
 		earliestDate := time.Now()
@@ -215,8 +254,16 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 
 	if (0 <= idx) && (idx < len(orgs)) {
 		if orgs[idx].Level != dbgen.AccessLevelInvited {
-			if properties, err := s.Store.Impl().RetrieveOrgProperties(ctx, orgs[idx].Organization.ID); err == nil {
-				renderCtx.Properties = propertiesToUserProperties(ctx, properties)
+			if properties, err := s.OrgProperties(ctx, &orgs[idx].Organization, user.ID); err == nil {
+				renderCtx.propertyTagsRenderContext, renderCtx.Properties = s.applyPropertyTags(ctx, orgs[idx].Organization.ID,
+					propertiesToUserProperties(ctx, properties), tag)
+
+				// completed checklist is only shown when it's completed "live", not on subsequent visits
+				if !prefs.OnboardingDismissedAt.Valid {
+					if checklist := s.createOnboardingContext(ctx, &orgs[idx].Organization, properties); !checklist.Done() {
+						renderCtx.Onboarding = checklist
+					}
+				}
 			}
 		}
 	}
@@ -236,7 +283,7 @@ func (s *Server) getPortal(w http.ResponseWriter, r *http.Request) {
 		orgID = -1
 	}
 
-	renderCtx, err := s.createOrgDashboardContext(ctx, int32(orgID), sess)
+	renderCtx, err := s.createOrgDashboardContext(ctx, int32(orgID), r.URL.Query().Get(common.ParamTag), sess)
 	if err != nil {
 		if (orgID == -1) && (err == errNoOrgs) {
 			common.Redirect(s.PartsURL(common.OrgEndpoint, common.NewEndpoint), http.StatusOK, w, r)
@@ -252,9 +299,24 @@ func (s *Server) getPortal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	renderCtx.Tab = orgTabFromParam(r.URL.Query().Get(common.ParamTab), renderCtx.CurrentOrg)
+
 	s.render(w, r, portalTemplate, renderCtx)
 }
 
+func orgTabFromParam(tab string, org *userOrg) string {
+	switch tab {
+	case common.MembersEndpoint:
+		if org.Level == string(dbgen.AccessLevelOwner) {
+			return tab
+		}
+	case common.SettingsEndpoint:
+		return tab
+	}
+
+	return ""
+}
+
 func (s *Server) getOrgDashboard(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 	user, err := s.SessionUser(ctx, s.Session(w, r))
@@ -267,7 +329,7 @@ func (s *Server) getOrgDashboard(w http.ResponseWriter, r *http.Request) (Model,
 		return nil, "", err
 	}
 
-	properties, err := s.Store.Impl().RetrieveOrgProperties(ctx, org.ID)
+	properties, err := s.OrgProperties(ctx, org, user.ID)
 	if err != nil {
 		return nil, "", err
 	}
@@ -275,9 +337,11 @@ func (s *Server) getOrgDashboard(w http.ResponseWriter, r *http.Request) (Model,
 	renderCtx := &orgPropertiesRenderContext{
 		CsrfRenderContext: s.CreateCsrfContext(user),
 		CurrentOrg:        orgToUserOrg(org, user.ID),
-		Properties:        propertiesToUserProperties(ctx, properties),
 	}
 
+	renderCtx.propertyTagsRenderContext, renderCtx.Properties = s.applyPropertyTags(ctx, org.ID,
+		propertiesToUserProperties(ctx, properties), r.URL.Query().Get(common.ParamTag))
+
 	return renderCtx, orgPropertiesTemplate, nil
 }
 
@@ -333,9 +397,82 @@ func (s *Server) getOrgSettings(w http.ResponseWriter, r *http.Request) (Model,
 		CanEdit:           org.UserID.Int32 == user.ID,
 	}
 
+	if renderCtx.CanEdit {
+		s.loadOrgOwnerSettings(ctx, renderCtx, org.ID)
+	}
+
 	return renderCtx, orgSettingsTemplate, nil
 }
 
+// orgDeletionSummary collects what will be lost with the organization (usage stats are optional)
+func (s *Server) orgDeletionSummary(ctx context.Context, org *dbgen.Organization, user *dbgen.User) (*orgDeleteRenderContext, error) {
+	properties, err := s.Store.Impl().RetrieveOrgProperties(ctx, org.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org properties", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	renderCtx := &orgDeleteRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		CurrentOrg:        orgToUserOrg(org, user.ID),
+		Properties:        len(properties),
+	}
+
+	for _, m := range members {
+		if m.Level == dbgen.AccessLevelMember {
+			renderCtx.Members++
+		}
+	}
+
+	if len(properties) > 0 {
+		propertyIDs := make([]int32, 0, len(properties))
+		for _, p := range properties {
+			propertyIDs = append(propertyIDs, p.ID)
+		}
+
+		from := time.Now().UTC().Add(-orgDeletionStatsPeriod)
+		if stats, err := s.TimeSeries.RetrievePropertiesTotals(ctx, org.ID, propertyIDs, from); err == nil {
+			renderCtx.Requests = stats.RequestsCount
+			renderCtx.Verifies = stats.VerifiesCount
+		} else {
+			slog.ErrorContext(ctx, "Failed to retrieve org usage", "orgID", org.ID, common.ErrAttr(err))
+		}
+	}
+
+	return renderCtx, nil
+}
+
+func (s *Server) orgBudget(ctx context.Context, orgID int32) int64 {
+	budget, err := s.Store.Impl().RetrieveOrgBudget(ctx, orgID)
+	if err != nil {
+		if err != db.ErrRecordNotFound {
+			slog.ErrorContext(ctx, "Failed to retrieve org budget", "orgID", orgID, common.ErrAttr(err))
+		}
+		return 0
+	}
+
+	return budget.MonthlyLimit
+}
+
+// loadOrgOwnerSettings fills parts of org settings that are only visible to the owner
+func (s *Server) loadOrgOwnerSettings(ctx context.Context, renderCtx *orgSettingsRenderContext, orgID int32) {
+	renderCtx.Budget = s.orgBudget(ctx, orgID)
+
+	keys, err := s.Store.Impl().RetrieveOrgAPIKeys(ctx, orgID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org API keys", "orgID", orgID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Could not load API tokens."
+	}
+
+	renderCtx.Tokens = apiKeysToUserAPIKeys(keys, time.Now().UTC())
+}
+
 func (s *Server) putOrg(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 	user, err := s.SessionUser(ctx, s.Session(w, r))
@@ -364,6 +501,8 @@ func (s *Server) putOrg(w http.ResponseWriter, r *http.Request) (Model, string,
 		return renderCtx, orgSettingsTemplate, nil
 	}
 
+	s.loadOrgOwnerSettings(ctx, renderCtx, org.ID)
+
 	name := r.FormValue(common.ParamName)
 	if name != org.Name {
 		if nameError := s.validateOrgName(ctx, name, user.ID); len(nameError) > 0 {
@@ -381,3 +520,147 @@ func (s *Server) putOrg(w http.ResponseWriter, r *http.Request) (Model, string,
 
 	return renderCtx, orgSettingsTemplate, nil
 }
+
+func (s *Server) putOrgBudget(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx := &orgSettingsRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		CurrentOrg:        orgToUserOrg(org, user.ID),
+		CanEdit:           org.UserID.Int32 == user.ID,
+	}
+
+	if !renderCtx.CanEdit {
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return renderCtx, orgSettingsTemplate, nil
+	}
+
+	s.loadOrgOwnerSettings(ctx, renderCtx, org.ID)
+
+	var budget int64
+	if value := strings.TrimSpace(r.FormValue(common.ParamBudget)); len(value) > 0 {
+		budget, err = strconv.ParseInt(value, 10, 64)
+		if (err != nil) || (budget < 0) {
+			slog.WarnContext(ctx, "Failed to parse org budget", "value", value, common.ErrAttr(err))
+			renderCtx.BudgetError = "Budget must be a non-negative number."
+			return renderCtx, orgSettingsTemplate, nil
+		}
+	}
+
+	if budget != renderCtx.Budget {
+		if _, err := s.Store.Impl().UpdateOrgBudget(ctx, org.ID, budget); err != nil {
+			renderCtx.BudgetError = "Failed to update budget. Please try again."
+			return renderCtx, orgSettingsTemplate, nil
+		}
+
+		renderCtx.Budget = budget
+	}
+
+	renderCtx.BudgetUpdated = true
+
+	return renderCtx, orgSettingsTemplate, nil
+}
+
+func (s *Server) postOrgAPIKey(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx := &orgSettingsRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		CurrentOrg:        orgToUserOrg(org, user.ID),
+		CanEdit:           org.UserID.Int32 == user.ID,
+	}
+
+	if !renderCtx.CanEdit {
+		renderCtx.ErrorMessage = "Insufficient permissions to manage API tokens."
+		return renderCtx, orgSettingsTemplate, nil
+	}
+
+	s.loadOrgOwnerSettings(ctx, renderCtx, org.ID)
+
+	formName := strings.TrimSpace(r.FormValue(common.ParamName))
+	if len(formName) < 3 {
+		renderCtx.TokenNameError = "Name is too short."
+		return renderCtx, orgSettingsTemplate, nil
+	}
+
+	months := monthsFromParam(ctx, r.FormValue(common.ParamMonths))
+	tnow := time.Now().UTC()
+	expiration := tnow.AddDate(0, months, 0)
+	// only owner can create tokens so limits of their plan are the ones of the organization
+	requestsPerSecond := s.apiKeyRequestsPerSecond(ctx, user)
+
+	if newKey, err := s.Store.Impl().CreateOrgAPIKey(ctx, org.ID, formName, expiration, requestsPerSecond); err == nil {
+		token := apiKeyToUserAPIKey(newKey, tnow)
+		token.Secret = db.UUIDToSecret(newKey.ExternalID)
+		renderCtx.Tokens = append(renderCtx.Tokens, token)
+	} else {
+		slog.ErrorContext(ctx, "Failed to create org API key", "orgID", org.ID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Failed to create API token. Please try again."
+	}
+
+	return renderCtx, orgSettingsTemplate, nil
+}
+
+func (s *Server) deleteOrgAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	if org.UserID.Int32 != user.ID {
+		slog.ErrorContext(ctx, "Not enough permissions to delete org API key", "userID", user.ID, "orgUserID", org.UserID.Int32)
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	keyID, value, err := common.IntPathArg(r, common.ParamKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse key path parameter", "value", value)
+		s.RedirectError(http.StatusBadRequest, w, r)
+		return
+	}
+
+	if err := s.Store.Impl().DeleteOrgAPIKey(ctx, org.ID, int32(keyID)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete the org API key", "keyID", keyID, "orgID", org.ID, common.ErrAttr(err))
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
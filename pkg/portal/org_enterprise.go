@@ -5,6 +5,7 @@ package portal
 import (
 	"context"
 	"fmt"
+	"html"
 	"log/slog"
 	"net/http"
 	"slices"
@@ -47,11 +48,14 @@ func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) string
 		return ""
 	}
 
-	if !plan.CheckOrgsLimit(len(orgs)) {
-		slog.WarnContext(ctx, "Organizations limit check failed", "orgs", len(orgs), "userID", user.ID, "subscriptionID", subscr.ID,
+	// only owned organizations count towards the limit
+	ownedOrgs := countOwnedOrgs(orgs)
+
+	if !plan.CheckOrgsLimit(ownedOrgs) {
+		slog.WarnContext(ctx, "Organizations limit check failed", "orgs", ownedOrgs, "userID", user.ID, "subscriptionID", subscr.ID,
 			"plan", plan.Name(), "internal", isInternalSubscription)
 
-		return "Organizations limit reached on your current plan, please upgrade to create more."
+		return fmt.Sprintf("Organizations limit (%d) reached on your current plan, please upgrade to create more.", plan.OrgsLimit())
 	}
 
 	return ""
@@ -141,7 +145,10 @@ func (s *Server) validateAddOrgMember(ctx context.Context, user *dbgen.User, mem
 	}
 
 	if !plan.CheckOrgMembersLimit(len(members)) {
-		return "Organization members limit reached on your current plan, please upgrade to invite more."
+		slog.WarnContext(ctx, "Organization members limit check failed", "members", len(members), "userID", user.ID,
+			"subscriptionID", subscr.ID, "plan", plan.Name(), "internal", isInternalSubscription)
+
+		return fmt.Sprintf("Organization members limit (%d) reached on your current plan, please upgrade to invite more.", plan.OrgMembersLimit())
 	}
 
 	return ""
@@ -286,6 +293,113 @@ func (s *Server) leaveOrg(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// putPropertyMemberAccess grants org member explicit access to the property (or revokes it)
+func (s *Server) putPropertyMemberAccess(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	renderCtx, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if renderCtx.Org.Level != string(dbgen.AccessLevelOwner) {
+		slog.WarnContext(ctx, "Insufficient permissions to change member access", "userID", user.ID, "propID", renderCtx.Property.ID)
+		renderCtx.AccessError = "Only organization owner can change member access."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	memberID := r.FormValue(common.ParamUser)
+	idx := slices.IndexFunc(renderCtx.Members, func(m *propertyMemberAccess) bool { return m.ID == memberID })
+	if idx == -1 {
+		slog.WarnContext(ctx, "User is not a member of the org", "memberID", memberID)
+		renderCtx.AccessError = "User is not a member of this organization."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	userID, err := strconv.Atoi(memberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse member ID", "value", memberID, common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	level := dbgen.PropertyAccessLevel(r.FormValue(common.ParamAccess))
+	if err := s.Store.Impl().UpdatePropertyPermission(ctx, org.ID, property.ID, int32(userID), level); err != nil {
+		if err == db.ErrInvalidInput {
+			renderCtx.AccessError = "Access level is not valid."
+		} else {
+			renderCtx.AccessError = "Failed to update access. Please try again."
+		}
+	} else {
+		renderCtx.Members[idx].Level = string(level)
+		renderCtx.AccessUpdated = true
+	}
+
+	return renderCtx, propertyDashboardSettingsTemplate, nil
+}
+
+func (s *Server) getOrgDeletePreview(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		slog.WarnContext(ctx, "Does not have permissions to delete org", "userID", user.ID, "orgUserID", org.UserID.Int32)
+		return nil, "", db.ErrPermissions
+	}
+
+	renderCtx, err := s.orgDeletionSummary(ctx, org, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return renderCtx, orgDeleteTemplate, nil
+}
+
+func (s *Server) notifyOrgDeleted(ctx context.Context, org *dbgen.Organization, members []*dbgen.GetOrganizationUsersRow) {
+	for _, m := range members {
+		if m.Level != dbgen.AccessLevelMember {
+			continue
+		}
+
+		if err := s.Mailer.SendOrgDeleted(ctx, m.User.Email, org.Name); err != nil {
+			slog.ErrorContext(ctx, "Failed to notify member about deleted org", "userID", m.User.ID, "orgID", org.ID, common.ErrAttr(err))
+		}
+
+		message := fmt.Sprintf("Organization <strong>%s</strong> was deleted by its owner.", html.EscapeString(org.Name))
+		if _, err := s.Store.Impl().CreateUserNotification(ctx, m.User.ID, dbgen.NotificationCategoryGeneral, message); err != nil {
+			slog.ErrorContext(ctx, "Failed to create org deleted user notification", "userID", m.User.ID, common.ErrAttr(err))
+		}
+	}
+}
+
 func (s *Server) deleteOrg(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -307,11 +421,28 @@ func (s *Server) deleteOrg(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// confirmation (typing the org name) protects from accidental deletion of the wrong org
+	if confirmName := strings.TrimSpace(r.FormValue(common.ParamName)); confirmName != org.Name {
+		slog.WarnContext(ctx, "Organization deletion is not confirmed", "orgID", org.ID)
+		s.RedirectError(http.StatusBadRequest, w, r)
+		return
+	}
+
+	// members have to be retrieved before deletion as they are not accessible afterwards
+	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users", common.ErrAttr(err))
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
 	if err := s.Store.Impl().SoftDeleteOrganization(ctx, org.ID, user.ID); err != nil {
 		slog.ErrorContext(ctx, "Failed to delete organization", common.ErrAttr(err))
 		s.RedirectError(http.StatusInternalServerError, w, r)
 		return
 	}
 
+	go s.notifyOrgDeleted(common.CopyTraceID(ctx, context.Background()), org, members)
+
 	common.Redirect(s.RelURL("/"), http.StatusOK, w, r)
 }
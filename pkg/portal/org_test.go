@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
 	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
@@ -55,3 +56,55 @@ func TestGetAnotherUsersOrg(t *testing.T) {
 		t.Errorf("Unexpected redirect: %s", path)
 	}
 }
+
+func TestOrgDeletionSummary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := db_tests.CreatePropertyForOrg(ctx, store, org); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary, err := server.orgDeletionSummary(ctx, org, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (summary.Properties != 2) || (summary.Members != 0) {
+		t.Errorf("Unexpected deletion summary: %+v", summary)
+	}
+}
+
+func TestOrgTabFromParam(t *testing.T) {
+	t.Parallel()
+
+	owner := &userOrg{Level: string(dbgen.AccessLevelOwner)}
+	member := &userOrg{Level: string(dbgen.AccessLevelMember)}
+
+	testCases := []struct {
+		tab      string
+		org      *userOrg
+		expected string
+	}{
+		{common.MembersEndpoint, owner, common.MembersEndpoint},
+		{common.MembersEndpoint, member, ""},
+		{common.SettingsEndpoint, member, common.SettingsEndpoint},
+		{common.DashboardEndpoint, owner, ""},
+		{"foo", owner, ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := orgTabFromParam(tc.tab, tc.org); actual != tc.expected {
+			t.Errorf("Unexpected tab for %q (%v): %q", tc.tab, tc.org.Level, actual)
+		}
+	}
+}
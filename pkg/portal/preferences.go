@@ -0,0 +1,99 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+var (
+	uiThemes       = []string{string(dbgen.UiThemeLight), string(dbgen.UiThemeDark), string(dbgen.UiThemeSystem)}
+	tableDensities = []string{string(dbgen.TableDensityComfortable), string(dbgen.TableDensityCompact)}
+)
+
+func defaultUserPreferences(userID int32) *dbgen.UserPreference {
+	return &dbgen.UserPreference{
+		UserID:       userID,
+		Theme:        dbgen.UiThemeLight,
+		TableDensity: dbgen.TableDensityComfortable,
+	}
+}
+
+func parseUITheme(value string) (dbgen.UiTheme, bool) {
+	if !slices.Contains(uiThemes, value) {
+		return "", false
+	}
+
+	return dbgen.UiTheme(value), true
+}
+
+func parseTableDensity(value string) (dbgen.TableDensity, bool) {
+	if !slices.Contains(tableDensities, value) {
+		return "", false
+	}
+
+	return dbgen.TableDensity(value), true
+}
+
+// parseDefaultOrg returns ID of the organization among user's orgs (0 means "no default")
+func parseDefaultOrg(value string, orgs []*dbgen.GetUserOrganizationsRow) (int32, bool) {
+	if len(value) == 0 {
+		return 0, true
+	}
+
+	orgID, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if preferredOrgIndex(orgs, int32(orgID)) == -1 {
+		return 0, false
+	}
+
+	return int32(orgID), true
+}
+
+// preferredOrgIndex returns index of the (default) org if user is still a member of it
+func preferredOrgIndex(orgs []*dbgen.GetUserOrganizationsRow, orgID int32) int {
+	if orgID <= 0 {
+		return -1
+	}
+
+	return slices.IndexFunc(orgs, func(o *dbgen.GetUserOrganizationsRow) bool {
+		return (o.Organization.ID == orgID) && (o.Level != dbgen.AccessLevelInvited)
+	})
+}
+
+// userPreferences returns saved UI preferences of the user or defaults if there are none (or they are not available)
+func (s *Server) userPreferences(ctx context.Context, userID int32) *dbgen.UserPreference {
+	prefs, err := s.Store.Impl().RetrieveUserPreferences(ctx, userID)
+	if err != nil {
+		if (err != db.ErrRecordNotFound) && (err != db.ErrMaintenance) {
+			slog.WarnContext(ctx, "Failed to retrieve user preferences", "userID", userID, common.ErrAttr(err))
+		}
+
+		return defaultUserPreferences(userID)
+	}
+
+	return prefs
+}
+
+func (s *Server) updateUserPreferences(ctx context.Context, prefs *dbgen.UserPreference, theme dbgen.UiTheme, density dbgen.TableDensity, defaultOrgID int32) error {
+	if (theme == prefs.Theme) && (density == prefs.TableDensity) && (defaultOrgID == prefs.DefaultOrgID.Int32) {
+		return nil
+	}
+
+	if _, err := s.Store.Impl().UpdateUserPreferences(ctx, prefs.UserID, theme, density, defaultOrgID); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "User changed UI preferences", "userID", prefs.UserID, "theme", theme, "density", density,
+		"defaultOrgID", defaultOrgID)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package portal
+
+import (
+	"testing"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestParseUIPreferences(t *testing.T) {
+	t.Parallel()
+
+	for _, theme := range uiThemes {
+		if _, ok := parseUITheme(theme); !ok {
+			t.Errorf("Failed to parse theme %q", theme)
+		}
+	}
+
+	for _, density := range tableDensities {
+		if _, ok := parseTableDensity(density); !ok {
+			t.Errorf("Failed to parse density %q", density)
+		}
+	}
+
+	if _, ok := parseUITheme("neon"); ok {
+		t.Error("Unknown theme was accepted")
+	}
+
+	if _, ok := parseTableDensity(""); ok {
+		t.Error("Empty density was accepted")
+	}
+}
+
+func TestParseDefaultOrg(t *testing.T) {
+	t.Parallel()
+
+	orgs := []*dbgen.GetUserOrganizationsRow{
+		{Organization: dbgen.Organization{ID: 1}, Level: dbgen.AccessLevelOwner},
+		{Organization: dbgen.Organization{ID: 2}, Level: dbgen.AccessLevelMember},
+		{Organization: dbgen.Organization{ID: 3}, Level: dbgen.AccessLevelInvited},
+	}
+
+	testCases := []struct {
+		value string
+		orgID int32
+		valid bool
+	}{
+		{"", 0, true},
+		{"1", 1, true},
+		{"2", 2, true},
+		{"3", 0, false},
+		{"4", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, tc := range testCases {
+		orgID, ok := parseDefaultOrg(tc.value, orgs)
+		if (ok != tc.valid) || (orgID != tc.orgID) {
+			t.Errorf("Unexpected result for %q: %v (%v)", tc.value, orgID, ok)
+		}
+	}
+
+	if idx := preferredOrgIndex(orgs, 0); idx != -1 {
+		t.Errorf("Unexpected index without default org: %v", idx)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +15,9 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/PrivateCaptcha/PrivateCaptcha/widget"
 	"golang.org/x/net/idna"
 )
 
@@ -30,6 +34,8 @@ const (
 	propertySettingsTabIndex              = 2
 	propertyIntegrationsTabIndex          = 1
 	activeSubscriptionForPropertyError    = "You need an active subscription to create new properties."
+	maxTrustedThreshold                   = 5
+	defaultTrustedTTL                     = 30 * time.Minute
 )
 
 type difficultyLevelsRenderContext struct {
@@ -59,10 +65,25 @@ type userProperty struct {
 	AllowSubdomains  bool
 	AllowLocalhost   bool
 	AllowReplay      bool
+	MemoryHard       bool
+	PrivacyMode      bool
+	IplessMode       bool
+	AccessibleMode   bool
+	TestMode         bool
+	Paused           bool
+	DomainVerified   bool
+	WidgetChannel    string
+	DataRegion       string
+	AllowedOrigins   []string
+	AllowedAppIDs    []string
+	TrustedThreshold int
+	TrustedTTL       int
+	Tags             []string
 }
 
 type orgPropertiesRenderContext struct {
 	CsrfRenderContext
+	propertyTagsRenderContext
 	Properties []*userProperty
 	CurrentOrg *userOrg
 }
@@ -75,6 +96,7 @@ type propertyDashboardRenderContext struct {
 	Property  *userProperty
 	Org       *userOrg
 	NameError string
+	TagsError string
 	Tab       int
 	CanEdit   bool
 }
@@ -82,8 +104,56 @@ type propertyDashboardRenderContext struct {
 type propertySettingsRenderContext struct {
 	propertyDashboardRenderContext
 	difficultyLevelsRenderContext
-	MinLevel int
-	MaxLevel int
+	MinLevel         int
+	MaxLevel         int
+	Messages         userPropertyMessages
+	MessagesError    string
+	RedirectURLError string
+	OriginsError     string
+	AppsError        string
+	// explicit access of org members to the property (only for org owner)
+	Members       []*propertyMemberAccess
+	AccessError   string
+	AccessUpdated bool
+	// share of the owner's plan quota allocated to the property (only for org owner)
+	CanAllocateQuota bool
+	QuotaPercent     int
+	QuotaPeriod      string
+	QuotaError       string
+	QuotaUpdated     bool
+	// DNS challenge for the unverified domain
+	DomainRecordName  string
+	DomainRecordValue string
+	DomainError       string
+	DomainRequired    bool
+	// recent settings changes
+	History []*userPropertyEvent
+}
+
+type propertyMemberAccess struct {
+	ID   string
+	Name string
+	// empty level means that member has no explicit access to this property
+	Level string
+}
+
+func membersToPropertyMembersAccess(members []*dbgen.GetOrganizationUsersRow, permissions []*dbgen.PropertyPermission) []*propertyMemberAccess {
+	result := make([]*propertyMemberAccess, 0, len(members))
+
+	for _, m := range members {
+		ma := &propertyMemberAccess{
+			ID:   strconv.Itoa(int(m.User.ID)),
+			Name: m.User.Name,
+		}
+
+		if idx := slices.IndexFunc(permissions, func(p *dbgen.PropertyPermission) bool { return p.UserID == m.User.ID }); idx != -1 {
+			ma.Level = string(permissions[idx].Level)
+		}
+
+		result = append(result, ma)
+	}
+
+	return result
 }
 
 func (pc *propertySettingsRenderContext) UpdateLevels() {
@@ -95,7 +165,37 @@ func (pc *propertySettingsRenderContext) UpdateLevels() {
 
 type propertyIntegrationsRenderContext struct {
 	propertyDashboardRenderContext
-	Sitekey string
+	Sitekey         string
+	WidgetScript    string
+	WidgetIntegrity string
+	// previous sitekey is set only during the overlap window after rotation
+	PreviousSitekey          string
+	PreviousSitekeyExpiresAt string
+	PreviousSitekeyExpiresIn string
+}
+
+func (pc *propertyIntegrationsRenderContext) setPreviousSitekey(sitekey string, expiresAt time.Time, tnow time.Time) {
+	pc.PreviousSitekey = sitekey
+	pc.PreviousSitekeyExpiresAt = expiresAt.Format("02 Jan 2006 15:04 MST")
+	pc.PreviousSitekeyExpiresIn = remainingTimeString(expiresAt.Sub(tnow))
+}
+
+// remainingTimeString returns rounded down (to days or hours) human-readable duration
+func remainingTimeString(d time.Duration) string {
+	const day = 24 * time.Hour
+
+	switch {
+	case d >= 2*day:
+		return fmt.Sprintf("%d days", int(d/day))
+	case d >= day:
+		return "1 day"
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	case d >= time.Hour:
+		return "1 hour"
+	default:
+		return "less than an hour"
+	}
 }
 
 func createDifficultyLevelsRenderContext() difficultyLevelsRenderContext {
@@ -118,9 +218,48 @@ func propertyToUserProperty(p *dbgen.Property) *userProperty {
 		AllowReplay:      p.AllowReplay,
 		AllowSubdomains:  p.AllowSubdomains,
 		AllowLocalhost:   p.AllowLocalhost,
+		MemoryHard:       p.Algorithm == dbgen.PowAlgorithmArgon2id,
+		PrivacyMode:      p.PrivacyMode,
+		IplessMode:       p.IplessMode,
+		AccessibleMode:   p.AccessibleMode,
+		TestMode:         p.TestMode,
+		Paused:           p.PausedAt.Valid,
+		DomainVerified:   p.DomainVerifiedAt.Valid,
+		WidgetChannel:    string(p.WidgetChannel),
+		DataRegion:       p.DataRegion,
+		AllowedOrigins:   p.AllowedOrigins,
+		AllowedAppIDs:    p.AllowedAppIDs,
+		TrustedThreshold: int(p.TrustedVisitorsThreshold),
+		TrustedTTL:       trustedTTLToIndex(p.TrustedVisitorsTtl),
 	}
 }
 
+// parseAllowedOrigins parses origin patterns separated by newlines or commas
+func parseAllowedOrigins(value string) ([]string, error) {
+	values := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ','
+	})
+
+	return origins.ParsePatterns(values)
+}
+
+func (p *userProperty) AllowedOriginsString() string {
+	return strings.Join(p.AllowedOrigins, "\n")
+}
+
+// parseAllowedAppIDs parses identifiers of native apps separated by newlines or commas
+func parseAllowedAppIDs(value string) ([]string, error) {
+	values := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ','
+	})
+
+	return origins.ParseAppIDs(values)
+}
+
+func (p *userProperty) AllowedAppIDsString() string {
+	return strings.Join(p.AllowedAppIDs, "\n")
+}
+
 func propertiesToUserProperties(ctx context.Context, properties []*dbgen.Property) []*userProperty {
 	result := make([]*userProperty, 0, len(properties))
 
@@ -173,6 +312,16 @@ func growthLevelFromIndex(ctx context.Context, index string) dbgen.DifficultyGro
 	}
 }
 
+func widgetChannelFromValue(ctx context.Context, value string) dbgen.WidgetChannel {
+	switch channel := dbgen.WidgetChannel(value); channel {
+	case dbgen.WidgetChannelStable, dbgen.WidgetChannelBeta, dbgen.WidgetChannelPinned:
+		return channel
+	default:
+		slog.WarnContext(ctx, "Invalid widget channel", "value", value)
+		return dbgen.WidgetChannelStable
+	}
+}
+
 func validityIntervalToIndex(period time.Duration) int {
 	switch period {
 	case 1 * time.Hour:
@@ -218,6 +367,60 @@ func validityIntervalFromIndex(ctx context.Context, index string) time.Duration
 	}
 }
 
+func trustedTTLToIndex(ttl time.Duration) int {
+	switch ttl {
+	case 10 * time.Minute:
+		return 0
+	case 30 * time.Minute:
+		return 1
+	case 1 * time.Hour:
+		return 2
+	case 6 * time.Hour:
+		return 3
+	default:
+		return 1
+	}
+}
+
+func trustedTTLFromIndex(ctx context.Context, index string) time.Duration {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert trusted visitors TTL", "value", index, common.ErrAttr(err))
+		return defaultTrustedTTL
+	}
+
+	switch i {
+	case 0:
+		return 10 * time.Minute
+	case 1:
+		return 30 * time.Minute
+	case 2:
+		return 1 * time.Hour
+	case 3:
+		return 6 * time.Hour
+	default:
+		slog.WarnContext(ctx, "Invalid trusted visitors TTL index", "index", i)
+		return defaultTrustedTTL
+	}
+}
+
+// trustedThresholdFromValue returns the number of successful verifications required to trust a returning visitor
+// (0 means trusted visitors are disabled)
+func trustedThresholdFromValue(ctx context.Context, value string) int16 {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert trusted visitors threshold", "value", value, common.ErrAttr(err))
+		return 0
+	}
+
+	if (i < 0) || (i > maxTrustedThreshold) {
+		slog.WarnContext(ctx, "Invalid trusted visitors threshold", "value", i)
+		return 0
+	}
+
+	return int16(i)
+}
+
 func difficultyLevelFromValue(ctx context.Context, value string) common.DifficultyLevel {
 	i, err := strconv.Atoi(value)
 	if err != nil {
@@ -397,10 +600,10 @@ func (s *Server) doValidatePropertiesLimit(ctx context.Context, subscr *dbgen.Su
 			"plan", plan.Name(), "internal", isInternalSubscription)
 
 		if isOrgOwner {
-			return "Properties limit reached on your current plan, please upgrade to create more."
+			return fmt.Sprintf("Properties limit (%d) reached on your current plan, please upgrade to create more.", plan.PropertiesLimit())
 		}
 
-		return "Properties limit reached for this organization's owner, contact them to upgrade."
+		return fmt.Sprintf("Properties limit (%d) reached for this organization's owner, contact them to upgrade.", plan.PropertiesLimit())
 	}
 
 	return ""
@@ -487,6 +690,8 @@ func (s *Server) postNewOrgProperty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	_, testMode := r.Form[common.ParamTestMode]
+
 	property, err := s.Store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
 		Name:       renderCtx.Name,
 		OrgID:      db.Int(org.ID),
@@ -495,6 +700,7 @@ func (s *Server) postNewOrgProperty(w http.ResponseWriter, r *http.Request) {
 		Domain:     domain,
 		Level:      db.Int2(int16(common.DifficultyLevelSmall)),
 		Growth:     dbgen.DifficultyGrowthMedium,
+		TestMode:   testMode,
 	})
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to create property", common.ErrAttr(err))
@@ -507,6 +713,85 @@ func (s *Server) postNewOrgProperty(w http.ResponseWriter, r *http.Request) {
 	common.Redirect(dashboardURL, http.StatusOK, w, r)
 }
 
+type statsPoint struct {
+	Date  int64 `json:"x"`
+	Value int   `json:"y"`
+}
+
+type propertyStatsResponse struct {
+	Requested []*statsPoint `json:"requested"`
+	Verified  []*statsPoint `json:"verified"`
+	// IANA name of the timezone that buckets are aligned to
+	Timezone string `json:"timezone"`
+	// percentage of requests during the period that came from known hosting networks
+	DatacenterShare float64 `json:"datacenter_share"`
+}
+
+func periodFromParam(ctx context.Context, periodStr string) common.TimePeriod {
+	switch periodStr {
+	case "24h":
+		return common.TimePeriodToday
+	case "7d":
+		return common.TimePeriodWeek
+	case "30d":
+		return common.TimePeriodMonth
+	case "1y":
+		return common.TimePeriodYear
+	default:
+		slog.ErrorContext(ctx, "Incorrect period argument", "period", periodStr)
+		return common.TimePeriodToday
+	}
+}
+
+func (s *Server) retrievePropertyStats(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) *propertyStatsResponse {
+	requested := []*statsPoint{}
+	verified := []*statsPoint{}
+	datacenterShare := 0.0
+
+	if stats, err := s.TimeSeries.RetrievePropertyStats(ctx, orgID, propertyID, period, tz); err == nil {
+		anyNonZero := false
+		for _, st := range stats {
+			if (st.RequestsCount > 0) || (st.VerifiesCount > 0) {
+				anyNonZero = true
+			}
+			requested = append(requested, &statsPoint{Date: st.Timestamp.Unix(), Value: st.RequestsCount})
+			verified = append(verified, &statsPoint{Date: st.Timestamp.Unix(), Value: st.VerifiesCount})
+		}
+
+		datacenterShare = datacenterTrafficShare(stats)
+
+		// we want to show "No data available" on the client
+		if !anyNonZero {
+			requested = []*statsPoint{}
+			verified = []*statsPoint{}
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property stats", common.ErrAttr(err))
+	}
+
+	return &propertyStatsResponse{
+		Requested:       requested,
+		Verified:        verified,
+		Timezone:        tz.String(),
+		DatacenterShare: datacenterShare,
+	}
+}
+
+// datacenterTrafficShare returns percentage (rounded to 0.1) of requests that came from hosting networks
+func datacenterTrafficShare(stats []*common.TimePeriodStat) float64 {
+	requests, datacenter := 0, 0
+	for _, st := range stats {
+		requests += st.RequestsCount
+		datacenter += st.DatacenterCount
+	}
+
+	if requests == 0 {
+		return 0.0
+	}
+
+	return math.Round(1000.0*float64(datacenter)/float64(requests)) / 10.0
+}
+
 func (s *Server) getPropertyStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -523,61 +808,279 @@ func (s *Server) getPropertyStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	property, err := s.Property(org.ID, r)
+	property, err := s.Property(org.ID, user.ID, r)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
 
-	periodStr := r.PathValue(common.ParamPeriod)
-	var period common.TimePeriod
-	switch periodStr {
-	case "24h":
-		period = common.TimePeriodToday
-	case "7d":
-		period = common.TimePeriodWeek
-	case "30d":
-		period = common.TimePeriodMonth
-	case "1y":
-		period = common.TimePeriodYear
+	response := s.retrievePropertyStats(ctx, org.ID, property.ID, periodFromParam(ctx, r.PathValue(common.ParamPeriod)),
+		userLocation(ctx, user))
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+// failureCounts groups verification errors by their likely cause in the customer's integration
+type failureCounts struct {
+	// solution was submitted too late (or puzzle was cached for too long)
+	Expired uint64 `json:"expired"`
+	// solution was tampered with or not produced by the widget
+	Integrity uint64 `json:"integrity"`
+	// the same solution was verified more than once
+	Replay uint64 `json:"replay"`
+	// solution was verified with API key of a different account
+	WrongOwner uint64 `json:"wrong_owner"`
+	Other      uint64 `json:"other"`
+}
+
+func (fc *failureCounts) add(status uint8, count uint64) {
+	switch puzzle.VerifyError(status) {
+	case puzzle.PuzzleExpiredError:
+		fc.Expired += count
+	case puzzle.IntegrityError:
+		fc.Integrity += count
+	case puzzle.VerifiedBeforeError, puzzle.DuplicateSolutionsError:
+		fc.Replay += count
+	case puzzle.WrongOwnerError:
+		fc.WrongOwner += count
 	default:
-		slog.ErrorContext(ctx, "Incorrect period argument", "period", periodStr)
-		period = common.TimePeriodToday
+		fc.Other += count
 	}
+}
 
-	type point struct {
-		Date  int64 `json:"x"`
-		Value int   `json:"y"`
-	}
+type failuresBucket struct {
+	Date int64 `json:"x"`
+	failureCounts
+}
 
-	requested := []*point{}
-	verified := []*point{}
+type propertyFailuresResponse struct {
+	// only buckets that have any failures, sorted by time
+	Buckets []*failuresBucket `json:"buckets"`
+	Totals  *failureCounts    `json:"totals"`
+	// IANA name of the timezone that buckets are aligned to
+	Timezone string `json:"timezone"`
+}
 
-	if stats, err := s.TimeSeries.RetrievePropertyStats(ctx, org.ID, property.ID, period); err == nil {
-		anyNonZero := false
-		for _, st := range stats {
-			if (st.RequestsCount > 0) || (st.VerifiesCount > 0) {
-				anyNonZero = true
-			}
-			requested = append(requested, &point{Date: st.Timestamp.Unix(), Value: st.RequestsCount})
-			verified = append(verified, &point{Date: st.Timestamp.Unix(), Value: st.VerifiesCount})
-		}
+// groupVerifyFailures expects stats to be sorted by time (as returned by time series store)
+func groupVerifyFailures(stats []*common.VerifyFailureStat) ([]*failuresBucket, *failureCounts) {
+	buckets := make([]*failuresBucket, 0)
+	totals := &failureCounts{}
 
-		// we want to show "No data available" on the client
-		if !anyNonZero {
-			requested = []*point{}
-			verified = []*point{}
+	var bucket *failuresBucket
+	for _, st := range stats {
+		if date := st.Timestamp.Unix(); (bucket == nil) || (bucket.Date != date) {
+			bucket = &failuresBucket{Date: date}
+			buckets = append(buckets, bucket)
 		}
+
+		bucket.add(st.Status, st.Count)
+		totals.add(st.Status, st.Count)
+	}
+
+	return buckets, totals
+}
+
+func (s *Server) getPropertyFailures(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	response := s.retrievePropertyFailures(ctx, org.ID, property.ID, periodFromParam(ctx, r.PathValue(common.ParamPeriod)),
+		userLocation(ctx, user))
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) retrievePropertyFailures(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) *propertyFailuresResponse {
+	response := &propertyFailuresResponse{
+		Buckets:  []*failuresBucket{},
+		Totals:   &failureCounts{},
+		Timezone: tz.String(),
+	}
+
+	if stats, err := s.TimeSeries.RetrievePropertyFailures(ctx, orgID, propertyID, period, tz); err == nil {
+		response.Buckets, response.Totals = groupVerifyFailures(stats)
 	} else {
-		slog.ErrorContext(ctx, "Failed to retrieve property stats", common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve property failures", common.ErrAttr(err))
+	}
+
+	return response
+}
+
+type fingerprintCounts struct {
+	// approximate number of distinct clients (fingerprints)
+	Unique   uint64 `json:"unique"`
+	Requests uint64 `json:"requests"`
+	// share of requests that came from clients that were already seen before in the same bucket (or period)
+	RepeatRatio float64 `json:"repeat_ratio"`
+}
+
+func newFingerprintCounts(st *common.FingerprintStat) fingerprintCounts {
+	fc := fingerprintCounts{Unique: st.UniqueCount, Requests: st.RequestsCount}
+
+	// uniqCombined is an approximation that can slightly exceed the number of requests for small counts
+	if fc.Unique > fc.Requests {
+		fc.Unique = fc.Requests
+	}
+
+	if fc.Requests > 0 {
+		fc.RepeatRatio = math.Round(1000*float64(fc.Requests-fc.Unique)/float64(fc.Requests)) / 1000
+	}
+
+	return fc
+}
+
+type fingerprintsBucket struct {
+	Date int64 `json:"x"`
+	fingerprintCounts
+}
+
+type propertyFingerprintsResponse struct {
+	// distinct clients are not known for properties that do not keep (or randomize) fingerprints
+	Available bool `json:"available"`
+	// only buckets that have any requests, sorted by time
+	Buckets []*fingerprintsBucket `json:"buckets"`
+	// distinct clients of the whole period (not a sum of buckets)
+	Totals *fingerprintCounts `json:"totals"`
+	// IANA name of the timezone that buckets are aligned to
+	Timezone string `json:"timezone"`
+}
+
+func (s *Server) getPropertyFingerprints(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	response := s.retrievePropertyFingerprints(ctx, property, periodFromParam(ctx, r.PathValue(common.ParamPeriod)),
+		userLocation(ctx, user))
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) retrievePropertyFingerprints(ctx context.Context, property *dbgen.Property, period common.TimePeriod, tz *time.Location) *propertyFingerprintsResponse {
+	response := &propertyFingerprintsResponse{
+		Available: !property.PrivacyMode && !property.IplessMode,
+		Buckets:   []*fingerprintsBucket{},
+		Totals:    &fingerprintCounts{},
+		Timezone:  tz.String(),
+	}
+
+	if !response.Available {
+		return response
 	}
 
-	response := struct {
-		Requested []*point `json:"requested"`
-		Verified  []*point `json:"verified"`
-	}{
-		Requested: requested,
-		Verified:  verified,
+	stats, err := s.TimeSeries.RetrievePropertyFingerprints(ctx, property.OrgID.Int32, property.ID, period, tz)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve property fingerprints", common.ErrAttr(err))
+		return response
+	}
+
+	for _, st := range stats.Buckets {
+		response.Buckets = append(response.Buckets, &fingerprintsBucket{
+			Date:              st.Timestamp.Unix(),
+			fingerprintCounts: newFingerprintCounts(st),
+		})
+	}
+
+	if stats.Total != nil {
+		totals := newFingerprintCounts(stats.Total)
+		response.Totals = &totals
+	}
+
+	return response
+}
+
+type propertyAction struct {
+	Action  string `json:"action"`
+	Count   uint64 `json:"count"`
+	Success uint64 `json:"success"`
+}
+
+type propertyActionsResponse struct {
+	// sorted by count, most frequent actions first
+	Actions []*propertyAction `json:"actions"`
+}
+
+// periodStart returns the beginning of the period ending at tnow
+func periodStart(period common.TimePeriod, tnow time.Time) time.Time {
+	switch period {
+	case common.TimePeriodWeek:
+		return tnow.AddDate(0, 0, -7)
+	case common.TimePeriodMonth:
+		return tnow.AddDate(0, -1, 0)
+	case common.TimePeriodYear:
+		return tnow.AddDate(-1, 0, 0)
+	default:
+		return tnow.AddDate(0, 0, -1)
+	}
+}
+
+func (s *Server) getPropertyActions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	response := &propertyActionsResponse{
+		Actions: []*propertyAction{},
+	}
+
+	period := periodFromParam(ctx, r.PathValue(common.ParamPeriod))
+	// actions are aggregated daily so start of the period is aligned to the day
+	from := periodStart(period, time.Now().UTC()).Truncate(24 * time.Hour)
+	if stats, err := s.TimeSeries.RetrievePropertyActions(ctx, org.ID, property.ID, from); err == nil {
+		for _, st := range stats {
+			response.Actions = append(response.Actions, &propertyAction{Action: st.Action, Count: st.Count, Success: st.SuccessCount})
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property actions", common.ErrAttr(err))
 	}
 
 	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
@@ -596,7 +1099,7 @@ func (s *Server) getOrgProperty(w http.ResponseWriter, r *http.Request) (*proper
 		return nil, nil, err
 	}
 
-	property, err := s.Property(org.ID, r)
+	property, err := s.Property(org.ID, user.ID, r)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -606,14 +1109,14 @@ func (s *Server) getOrgProperty(w http.ResponseWriter, r *http.Request) (*proper
 		CaptchaRenderContext: s.createDemoCaptchaRenderContext(strings.ReplaceAll(propertySettingsPropertyID, "-", "")),
 		Property:             propertyToUserProperty(property),
 		Org:                  orgToUserOrg(org, user.ID),
-		CanEdit:              (user.ID == org.UserID.Int32) || (user.ID == property.CreatorID.Int32),
+		CanEdit:              s.canEditProperty(ctx, org, property, user.ID),
 	}
 
 	return renderCtx, property, nil
 }
 
 func (s *Server) getOrgPropertySettings(w http.ResponseWriter, r *http.Request) (*propertySettingsRenderContext, error) {
-	propertyRenderCtx, _, err := s.getOrgProperty(w, r)
+	propertyRenderCtx, property, err := s.getOrgProperty(w, r)
 	if err != nil {
 		return nil, err
 	}
@@ -623,6 +1126,26 @@ func (s *Server) getOrgPropertySettings(w http.ResponseWriter, r *http.Request)
 		difficultyLevelsRenderContext:  createDifficultyLevelsRenderContext(),
 	}
 
+	if tags, err := s.Store.Impl().RetrievePropertyTags(r.Context(), property.ID); err == nil {
+		renderCtx.Property.Tags = tags
+	}
+
+	renderCtx.Messages = s.retrievePropertyMessages(r.Context(), property.ID)
+
+	s.loadDomainVerification(r.Context(), renderCtx, property)
+
+	if user, err := s.SessionUser(r.Context(), s.Session(w, r)); err == nil {
+		s.loadPropertyHistory(r.Context(), renderCtx, property.ID, user)
+	}
+
+	if s.isEnterprise() && (renderCtx.Org.Level == string(dbgen.AccessLevelOwner)) {
+		s.loadPropertyMembersAccess(r.Context(), renderCtx, property)
+	}
+
+	if renderCtx.Org.Level == string(dbgen.AccessLevelOwner) {
+		s.loadPropertyQuota(r.Context(), renderCtx, property.ID)
+	}
+
 	renderCtx.Tab = propertySettingsTabIndex
 
 	renderCtx.UpdateLevels()
@@ -630,6 +1153,22 @@ func (s *Server) getOrgPropertySettings(w http.ResponseWriter, r *http.Request)
 	return renderCtx, nil
 }
 
+func (s *Server) loadPropertyMembersAccess(ctx context.Context, renderCtx *propertySettingsRenderContext, property *dbgen.Property) {
+	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, property.OrgID.Int32)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users", "orgID", property.OrgID.Int32, common.ErrAttr(err))
+		return
+	}
+
+	permissions, err := s.Store.Impl().RetrievePropertyPermissions(ctx, property.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve property permissions", "propID", property.ID, common.ErrAttr(err))
+		return
+	}
+
+	renderCtx.Members = membersToPropertyMembersAccess(members, permissions)
+}
+
 func (s *Server) getPropertyDashboard(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 	tabParam := r.URL.Query().Get(common.ParamTab)
@@ -649,12 +1188,19 @@ func (s *Server) getPropertyDashboard(w http.ResponseWriter, r *http.Request) (M
 		} else {
 			derr = err
 		}
+	case common.DiagnosticsEndpoint:
+		if renderCtx, err := s.getPropertyDiagnostics(w, r); err == nil {
+			model = renderCtx
+		} else {
+			derr = err
+		}
 	default:
 		if (tabParam != common.ReportsEndpoint) && (tabParam != "") {
 			slog.ErrorContext(ctx, "Unknown tab requested", "tab", tabParam)
 		}
-		if renderCtx, _, err := s.getOrgProperty(w, r); err == nil {
+		if renderCtx, property, err := s.getOrgProperty(w, r); err == nil {
 			renderCtx.Tab = 0
+			renderCtx.WarningMessage = s.propertyQuotaWarning(ctx, property, time.Now().UTC())
 			model = renderCtx
 		} else {
 			derr = err
@@ -669,11 +1215,13 @@ func (s *Server) getPropertyDashboard(w http.ResponseWriter, r *http.Request) (M
 }
 
 func (s *Server) getPropertyReportsTab(w http.ResponseWriter, r *http.Request) (Model, string, error) {
-	renderCtx, _, err := s.getOrgProperty(w, r)
+	renderCtx, property, err := s.getOrgProperty(w, r)
 	if err != nil {
 		return nil, "", err
 	}
 
+	renderCtx.WarningMessage = s.propertyQuotaWarning(r.Context(), property, time.Now().UTC())
+
 	return renderCtx, propertyDashboardReportsTemplate, nil
 }
 
@@ -697,6 +1245,12 @@ func (s *Server) getPropertyIntegrations(w http.ResponseWriter, r *http.Request)
 		Sitekey:                        db.UUIDToSiteKey(property.ExternalID),
 	}
 
+	renderCtx.WidgetScript, renderCtx.WidgetIntegrity = widget.ChannelScriptURL(string(property.WidgetChannel))
+
+	if rotation, err := s.Store.Impl().RetrievePropertySitekeyRotation(r.Context(), property.ID); err == nil {
+		renderCtx.setPreviousSitekey(db.UUIDToSiteKey(rotation.PreviousExternalID), rotation.ExpiresAt.Time, time.Now())
+	}
+
 	renderCtx.Tab = propertyIntegrationsTabIndex
 
 	return renderCtx, nil
@@ -711,6 +1265,110 @@ func (s *Server) getPropertyIntegrationsTab(w http.ResponseWriter, r *http.Reque
 	return ctx, propertyDashboardIntegrationsTemplate, nil
 }
 
+// postPropertySitekey generates a new sitekey for the property, previous one keeps working during the overlap window
+func (s *Server) postPropertySitekey(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx, err := s.getPropertyIntegrations(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to rotate sitekey", "userID", user.ID, "propID", renderCtx.Property.ID)
+		renderCtx.ErrorMessage = "Insufficient permissions to rotate sitekey."
+		return renderCtx, propertyDashboardIntegrationsTemplate, nil
+	}
+
+	if len(renderCtx.PreviousSitekey) > 0 {
+		renderCtx.ErrorMessage = "Previous sitekey is still active. Retire it before rotating sitekey again."
+		return renderCtx, propertyDashboardIntegrationsTemplate, nil
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tnow := time.Now().UTC()
+	expiresAt := tnow.Add(common.SitekeyRotationOverlap)
+	var rotated *dbgen.Property
+
+	if err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		var err error
+		rotated, err = impl.RotatePropertySitekey(ctx, property, expiresAt)
+		return err
+	}); err != nil {
+		renderCtx.ErrorMessage = "Failed to rotate sitekey. Please try again."
+		return renderCtx, propertyDashboardIntegrationsTemplate, nil
+	}
+
+	slog.DebugContext(ctx, "Rotated property sitekey", "propID", property.ID, "orgID", org.ID)
+
+	renderCtx.Sitekey = db.UUIDToSiteKey(rotated.ExternalID)
+	renderCtx.setPreviousSitekey(db.UUIDToSiteKey(property.ExternalID), expiresAt, tnow)
+	renderCtx.SuccessMessage = "Sitekey was rotated. Update your website before the previous sitekey stops working."
+
+	return renderCtx, propertyDashboardIntegrationsTemplate, nil
+}
+
+// deletePropertyPreviousSitekey ends the overlap window after sitekey rotation right away
+func (s *Server) deletePropertyPreviousSitekey(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx, err := s.getPropertyIntegrations(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to retire sitekey", "userID", user.ID, "propID", renderCtx.Property.ID)
+		renderCtx.ErrorMessage = "Insufficient permissions to retire sitekey."
+		return renderCtx, propertyDashboardIntegrationsTemplate, nil
+	}
+
+	if len(renderCtx.PreviousSitekey) == 0 {
+		return renderCtx, propertyDashboardIntegrationsTemplate, nil
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.Store.Impl().ExpirePropertySitekeyRotation(ctx, property.ID); (err != nil) && (err != db.ErrRecordNotFound) {
+		renderCtx.ErrorMessage = "Failed to retire previous sitekey. Please try again."
+		return renderCtx, propertyDashboardIntegrationsTemplate, nil
+	}
+
+	slog.DebugContext(ctx, "Retired previous property sitekey", "propID", property.ID, "orgID", org.ID)
+
+	renderCtx.PreviousSitekey = ""
+	renderCtx.PreviousSitekeyExpiresAt = ""
+	renderCtx.PreviousSitekeyExpiresIn = ""
+	renderCtx.SuccessMessage = "Previous sitekey was retired."
+
+	return renderCtx, propertyDashboardIntegrationsTemplate, nil
+}
+
 func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 	user, err := s.SessionUser(ctx, s.Session(w, r))
@@ -735,7 +1393,7 @@ func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (Model, str
 		return nil, "", err
 	}
 
-	property, err := s.Property(org.ID, r)
+	property, err := s.Property(org.ID, user.ID, r)
 	if err != nil {
 		return nil, "", err
 	}
@@ -756,35 +1414,187 @@ func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (Model, str
 		}
 	}
 
+	tags, tagsError := parsePropertyTags(r.FormValue(common.ParamTags))
+	if len(tagsError) > 0 {
+		renderCtx.TagsError = tagsError
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	// we cannot compare with tags of the render context as they are empty if loading them failed
+	currentTags, err := s.Store.Impl().RetrievePropertyTags(ctx, property.ID)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+	// parsed tags are sorted bytewise, which can be different from database collation
+	slices.Sort(currentTags)
+
+	allowedOrigins, err := parseAllowedOrigins(r.FormValue(common.ParamAllowedOrigins))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse allowed origins", common.ErrAttr(err))
+		renderCtx.OriginsError = fmt.Sprintf("Origin pattern is not valid (%v).", err)
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	allowedAppIDs, err := parseAllowedAppIDs(r.FormValue(common.ParamAllowedApps))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse allowed apps", common.ErrAttr(err))
+		renderCtx.AppsError = fmt.Sprintf("App identifier is not valid (%v).", err)
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
 	difficulty := difficultyLevelFromValue(ctx, r.FormValue(common.ParamDifficulty))
 	growth := growthLevelFromIndex(ctx, r.FormValue(common.ParamGrowth))
 	validityInterval := validityIntervalFromIndex(ctx, r.FormValue(common.ParamValidityInterval))
+	trustedThreshold := trustedThresholdFromValue(ctx, r.FormValue(common.ParamTrustedThreshold))
+	trustedTTL := trustedTTLFromIndex(ctx, r.FormValue(common.ParamTrustedTTL))
 	_, allowSubdomains := r.Form[common.ParamAllowSubdomains]
 	_, allowLocalhost := r.Form[common.ParamAllowLocalhost]
 	_, allowReplay := r.Form[common.ParamAllowReplay]
+	_, privacyMode := r.Form[common.ParamPrivacyMode]
+	_, iplessMode := r.Form[common.ParamIplessMode]
+	_, accessibleMode := r.Form[common.ParamAccessibleMode]
+	widgetChannel := widgetChannelFromValue(ctx, r.FormValue(common.ParamWidgetChannel))
+	algorithm := dbgen.PowAlgorithmBlake2b
+	if _, memoryHard := r.Form[common.ParamMemoryHard]; memoryHard {
+		algorithm = dbgen.PowAlgorithmArgon2id
+	}
 
 	if (name != property.Name) ||
 		(int16(difficulty) != property.Level.Int16) ||
 		(growth != property.Growth) ||
 		(validityInterval != property.ValidityInterval) ||
 		(allowReplay != property.AllowReplay) ||
+		(algorithm != property.Algorithm) ||
+		(privacyMode != property.PrivacyMode) ||
+		(iplessMode != property.IplessMode) ||
+		(accessibleMode != property.AccessibleMode) ||
+		(widgetChannel != property.WidgetChannel) ||
+		!slices.Equal(allowedOrigins, property.AllowedOrigins) ||
+		(trustedThreshold != property.TrustedVisitorsThreshold) ||
+		(trustedTTL != property.TrustedVisitorsTtl) ||
 		(allowSubdomains != property.AllowSubdomains) ||
 		(allowLocalhost != property.AllowLocalhost) {
 		if updatedProperty, err := s.Store.Impl().UpdateProperty(ctx, &dbgen.UpdatePropertyParams{
-			ID:               property.ID,
-			Name:             name,
-			Level:            db.Int2(int16(difficulty)),
-			Growth:           growth,
-			ValidityInterval: validityInterval,
-			AllowSubdomains:  allowSubdomains,
-			AllowLocalhost:   allowLocalhost,
-			AllowReplay:      allowReplay,
+			ID:                       property.ID,
+			Name:                     name,
+			Level:                    db.Int2(int16(difficulty)),
+			Growth:                   growth,
+			ValidityInterval:         validityInterval,
+			AllowSubdomains:          allowSubdomains,
+			AllowLocalhost:           allowLocalhost,
+			AllowReplay:              allowReplay,
+			Algorithm:                algorithm,
+			PrivacyMode:              privacyMode,
+			AllowedOrigins:           allowedOrigins,
+			TrustedVisitorsThreshold: trustedThreshold,
+			TrustedVisitorsTtl:       trustedTTL,
+			IplessMode:               iplessMode,
+			WidgetChannel:            widgetChannel,
+			DataRegion:               property.DataRegion,
+			AccessibleMode:           accessibleMode,
 		}); err != nil {
 			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
 		} else {
 			slog.DebugContext(ctx, "Edited property", "propID", property.ID, "orgID", org.ID)
+			s.recordPropertyEvents(ctx, property.ID, user.ID, db.PropertyChanges(property, updatedProperty))
 			renderCtx.SuccessMessage = "Settings were updated"
 			renderCtx.Property = propertyToUserProperty(updatedProperty)
+			renderCtx.Property.Tags = currentTags
+		}
+	}
+
+	if !slices.Equal(tags, currentTags) && (len(renderCtx.ErrorMessage) == 0) {
+		if err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+			return impl.UpdatePropertyTags(ctx, property.ID, tags)
+		}); err != nil {
+			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+		} else {
+			slog.DebugContext(ctx, "Edited property tags", "propID", property.ID, "orgID", org.ID)
+			s.recordPropertyEvents(ctx, property.ID, user.ID, []*dbgen.PropertyEvent{
+				db.PropertyListEvent(db.PropertySettingTags, currentTags, tags),
+			})
+			renderCtx.SuccessMessage = "Settings were updated"
+			renderCtx.Property.Tags = tags
+		}
+	}
+
+	if !slices.Equal(allowedAppIDs, property.AllowedAppIDs) && (len(renderCtx.ErrorMessage) == 0) {
+		if updatedProperty, err := s.Store.Impl().UpdatePropertyAppIDs(ctx, property.ID, allowedAppIDs); err != nil {
+			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+		} else {
+			slog.DebugContext(ctx, "Edited property apps", "propID", property.ID, "orgID", org.ID, "apps", len(allowedAppIDs))
+			s.recordPropertyEvents(ctx, property.ID, user.ID, []*dbgen.PropertyEvent{
+				db.PropertyListEvent(db.PropertySettingAllowedAppIDs, property.AllowedAppIDs, allowedAppIDs),
+			})
+			renderCtx.SuccessMessage = "Settings were updated"
+			renderCtx.Property.AllowedAppIDs = updatedProperty.AllowedAppIDs
+		}
+	}
+
+	s.loadPropertyHistory(ctx, renderCtx, property.ID, user)
+
+	return renderCtx, propertyDashboardSettingsTemplate, nil
+}
+
+// putPropertyPaused pauses (or resumes) puzzle issuance without touching any other settings of the property
+func (s *Server) putPropertyPaused(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	paused, err := strconv.ParseBool(r.FormValue(common.ParamPaused))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse paused value", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	renderCtx, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to pause property", "userID", user.ID, "propID", renderCtx.Property.ID)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if paused == property.PausedAt.Valid {
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	if updatedProperty, err := s.Store.Impl().UpdatePropertyPaused(ctx, property.ID, paused); err != nil {
+		renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+	} else {
+		slog.DebugContext(ctx, "Changed property paused state", "propID", property.ID, "orgID", org.ID, "paused", paused)
+		s.recordPropertyEvents(ctx, property.ID, user.ID, db.PropertyChanges(property, updatedProperty))
+		s.loadPropertyHistory(ctx, renderCtx, property.ID, user)
+		tags := renderCtx.Property.Tags
+		renderCtx.Property = propertyToUserProperty(updatedProperty)
+		renderCtx.Property.Tags = tags
+		if paused {
+			renderCtx.SuccessMessage = "Property was paused"
+		} else {
+			renderCtx.SuccessMessage = "Property was resumed"
 		}
 	}
 
@@ -806,7 +1616,7 @@ func (s *Server) deleteProperty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	property, err := s.Property(org.ID, r)
+	property, err := s.Property(org.ID, user.ID, r)
 	if err != nil {
 		s.RedirectError(http.StatusBadRequest, w, r)
 		return
@@ -0,0 +1,110 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	maxPropertyEvents = 20
+)
+
+var propertySettingLabels = map[string]string{
+	db.PropertySettingName:             "Name",
+	db.PropertySettingDifficulty:       "Difficulty",
+	db.PropertySettingGrowth:           "Difficulty growth",
+	db.PropertySettingValidityInterval: "Verification window",
+	db.PropertySettingAllowSubdomains:  "Allow subdomains",
+	db.PropertySettingAllowLocalhost:   "Allow localhost",
+	db.PropertySettingAllowReplay:      "Allow replay",
+	db.PropertySettingAlgorithm:        "Algorithm",
+	db.PropertySettingPrivacyMode:      "Privacy mode",
+	db.PropertySettingIplessMode:       "IP-less mode",
+	db.PropertySettingAccessibleMode:   "Accessible mode",
+	db.PropertySettingWidgetChannel:    "Widget channel",
+	db.PropertySettingAllowedOrigins:   "Allowed origins",
+	db.PropertySettingAllowedAppIDs:    "Allowed apps",
+	db.PropertySettingTrustedThreshold: "Trusted visitors threshold",
+	db.PropertySettingTrustedTTL:       "Trusted visitors period",
+	db.PropertySettingPaused:           "Paused",
+	db.PropertySettingDomainVerified:   "Domain verified",
+	db.PropertySettingTags:             "Tags",
+}
+
+type userPropertyEvent struct {
+	Time     string
+	Actor    string
+	Setting  string
+	OldValue string
+	NewValue string
+}
+
+func propertyEventValue(value string) string {
+	switch value {
+	case "":
+		return "(none)"
+	case "true":
+		return "on"
+	case "false":
+		return "off"
+	default:
+		return value
+	}
+}
+
+func propertyEventsToUserEvents(events []*dbgen.GetPropertyEventsRow, loc *time.Location) []*userPropertyEvent {
+	result := make([]*userPropertyEvent, 0, len(events))
+	for _, e := range events {
+		actor := "System"
+		if e.PropertyEvent.UserID.Valid {
+			if e.UserName.Valid {
+				actor = e.UserName.String
+			} else {
+				actor = "Deleted user"
+			}
+		}
+
+		setting, ok := propertySettingLabels[e.PropertyEvent.Setting]
+		if !ok {
+			setting = e.PropertyEvent.Setting
+		}
+
+		result = append(result, &userPropertyEvent{
+			Time:     e.PropertyEvent.CreatedAt.Time.In(loc).Format("02 Jan 2006 15:04 MST"),
+			Actor:    actor,
+			Setting:  setting,
+			OldValue: propertyEventValue(e.PropertyEvent.OldValue),
+			NewValue: propertyEventValue(e.PropertyEvent.NewValue),
+		})
+	}
+	return result
+}
+
+// recordPropertyEvents writes settings changes to the audit log and to the property history
+func (s *Server) recordPropertyEvents(ctx context.Context, propertyID int32, userID int32, events []*dbgen.PropertyEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	for _, e := range events {
+		slog.InfoContext(ctx, "Audit: property setting changed", "propID", propertyID, "userID", userID, "setting", e.Setting,
+			"old", e.OldValue, "new", e.NewValue)
+	}
+
+	_ = s.Store.Impl().CreatePropertyEvents(ctx, propertyID, userID, events)
+}
+
+func (s *Server) loadPropertyHistory(ctx context.Context, renderCtx *propertySettingsRenderContext, propertyID int32, user *dbgen.User) {
+	events, err := s.Store.Impl().RetrievePropertyEvents(ctx, propertyID, maxPropertyEvents)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve property history", "propID", propertyID, common.ErrAttr(err))
+		return
+	}
+
+	renderCtx.History = propertyEventsToUserEvents(events, userLocation(ctx, user))
+}
@@ -0,0 +1,162 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/widget"
+)
+
+const (
+	propertyMessagesFormTemplate = "property/settings-messages-form.html"
+	maxWidgetMessageLength       = 255
+	maxRedirectURLLength         = 512
+)
+
+// userPropertyMessages are shown by the widget instead of the puzzle when property cannot serve captcha
+type userPropertyMessages struct {
+	BlockedMessage     string
+	QuotaMessage       string
+	MaintenanceMessage string
+	RedirectURL        string
+	Theme              string
+	Locale             string
+}
+
+func propertyMessagesToUserMessages(m *dbgen.PropertyMessage) userPropertyMessages {
+	return userPropertyMessages{
+		BlockedMessage:     m.BlockedMessage,
+		QuotaMessage:       m.QuotaMessage,
+		MaintenanceMessage: m.MaintenanceMessage,
+		RedirectURL:        m.RedirectUrl,
+		Theme:              m.Theme,
+		Locale:             m.Locale,
+	}
+}
+
+func validateWidgetMessage(message string) string {
+	if utf8.RuneCountInString(message) > maxWidgetMessageLength {
+		return "Messages cannot be longer than 255 characters."
+	}
+
+	return ""
+}
+
+// isValidWidgetOption allows empty value, which means widget's own default
+func isValidWidgetOption(value string, options []string) bool {
+	return (len(value) == 0) || slices.Contains(options, value)
+}
+
+func validateRedirectURL(value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+
+	if len(value) > maxRedirectURLLength {
+		return "Redirect URL is too long."
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || (len(u.Host) == 0) {
+		return "Redirect URL must be an absolute http(s) link."
+	}
+
+	return ""
+}
+
+func (s *Server) putPropertyMessages(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	renderCtx, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to edit property messages", "userID", user.ID,
+			"propID", renderCtx.Property.ID)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return renderCtx, propertyMessagesFormTemplate, nil
+	}
+
+	messages := userPropertyMessages{
+		BlockedMessage:     strings.TrimSpace(r.FormValue(common.ParamBlockedMessage)),
+		QuotaMessage:       strings.TrimSpace(r.FormValue(common.ParamQuotaMessage)),
+		MaintenanceMessage: strings.TrimSpace(r.FormValue(common.ParamMaintenanceMsg)),
+		RedirectURL:        strings.TrimSpace(r.FormValue(common.ParamRedirectURL)),
+		Theme:              r.FormValue(common.ParamTheme),
+		Locale:             r.FormValue(common.ParamLocale),
+	}
+	renderCtx.Messages = messages
+
+	for _, m := range []string{messages.BlockedMessage, messages.QuotaMessage, messages.MaintenanceMessage} {
+		if msgError := validateWidgetMessage(m); len(msgError) > 0 {
+			renderCtx.MessagesError = msgError
+			return renderCtx, propertyMessagesFormTemplate, nil
+		}
+	}
+
+	if urlError := validateRedirectURL(messages.RedirectURL); len(urlError) > 0 {
+		renderCtx.RedirectURLError = urlError
+		return renderCtx, propertyMessagesFormTemplate, nil
+	}
+
+	// selects do not allow other values, so this can only be a crafted request
+	if !isValidWidgetOption(messages.Theme, widget.Themes) || !isValidWidgetOption(messages.Locale, widget.Locales) {
+		slog.WarnContext(ctx, "Invalid widget appearance", "theme", messages.Theme, "locale", messages.Locale)
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := s.Store.Impl().UpdatePropertyMessages(ctx, &dbgen.UpsertPropertyMessagesParams{
+		PropertyID:         property.ID,
+		BlockedMessage:     messages.BlockedMessage,
+		QuotaMessage:       messages.QuotaMessage,
+		MaintenanceMessage: messages.MaintenanceMessage,
+		RedirectUrl:        messages.RedirectURL,
+		Theme:              messages.Theme,
+		Locale:             messages.Locale,
+	}); err != nil {
+		renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+	} else {
+		slog.DebugContext(ctx, "Edited property messages", "propID", property.ID)
+		renderCtx.SuccessMessage = "Widget messages were updated"
+	}
+
+	return renderCtx, propertyMessagesFormTemplate, nil
+}
+
+func (s *Server) retrievePropertyMessages(ctx context.Context, propertyID int32) userPropertyMessages {
+	if messages, err := s.Store.Impl().RetrievePropertyMessages(ctx, propertyID); err == nil {
+		return propertyMessagesToUserMessages(messages)
+	}
+
+	return userPropertyMessages{}
+}
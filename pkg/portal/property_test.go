@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
@@ -16,6 +18,9 @@ import (
 	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
 	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/testsupport"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestPutPropertyInsufficientPermissions(t *testing.T) {
@@ -82,3 +87,358 @@ func TestPutPropertyInsufficientPermissions(t *testing.T) {
 		t.Errorf("Unexpected redirect: %s", path)
 	}
 }
+
+func TestValidateRedirectURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value string
+		valid bool
+	}{
+		{"", true},
+		{"https://example.com/support", true},
+		{"http://example.com", true},
+		{"javascript:alert(1)", false},
+		{"/relative/path", false},
+		{"https://", false},
+		{"ftp://example.com", false},
+	}
+
+	for _, tc := range testCases {
+		if msg := validateRedirectURL(tc.value); (len(msg) == 0) != tc.valid {
+			t.Errorf("Unexpected validation result for %q: %v", tc.value, msg)
+		}
+	}
+}
+
+func TestDatacenterTrafficShare(t *testing.T) {
+	testCases := []struct {
+		stats    []*common.TimePeriodStat
+		expected float64
+	}{
+		{nil, 0.0},
+		{[]*common.TimePeriodStat{{RequestsCount: 0, DatacenterCount: 0}}, 0.0},
+		{[]*common.TimePeriodStat{{RequestsCount: 3, DatacenterCount: 1}}, 33.3},
+		{[]*common.TimePeriodStat{{RequestsCount: 100, DatacenterCount: 10}, {RequestsCount: 100, DatacenterCount: 90}}, 50.0},
+	}
+
+	for i, tc := range testCases {
+		if actual := datacenterTrafficShare(tc.stats); actual != tc.expected {
+			t.Errorf("Unexpected share (%v): expected %v, got %v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestRemainingTimeString(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "less than an hour"},
+		{59 * time.Minute, "less than an hour"},
+		{90 * time.Minute, "1 hour"},
+		{5*time.Hour + 30*time.Minute, "5 hours"},
+		{25 * time.Hour, "1 day"},
+		{common.SitekeyRotationOverlap - time.Minute, "6 days"},
+		{common.SitekeyRotationOverlap, "7 days"},
+	}
+
+	for i, tc := range testCases {
+		if actual := remainingTimeString(tc.duration); actual != tc.expected {
+			t.Errorf("Unexpected remaining time (%v): expected %v, got %v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestAccessibleProperties(t *testing.T) {
+	const userID = 10
+
+	properties := []*dbgen.Property{
+		{ID: 1, CreatorID: db.Int(userID)},
+		{ID: 2, CreatorID: db.Int(userID + 1)},
+		{ID: 3, CreatorID: db.Int(userID + 1)},
+	}
+
+	testCases := []struct {
+		permissions []*dbgen.PropertyPermission
+		expected    []int32
+		edit        []int32
+	}{
+		{nil, []int32{1, 2, 3}, []int32{1}},
+		{[]*dbgen.PropertyPermission{{PropertyID: 3, Level: dbgen.PropertyAccessLevelView}}, []int32{1, 3}, []int32{1}},
+		{[]*dbgen.PropertyPermission{{PropertyID: 2, Level: dbgen.PropertyAccessLevelEdit}}, []int32{1, 2}, []int32{1, 2}},
+	}
+
+	for i, tc := range testCases {
+		var actual, edit []int32
+		for _, p := range accessibleProperties(properties, userID, tc.permissions) {
+			actual = append(actual, p.ID)
+			if level, _ := db.PropertyAccessLevel(p, userID, tc.permissions); level == dbgen.PropertyAccessLevelEdit {
+				edit = append(edit, p.ID)
+			}
+		}
+
+		if !slices.Equal(actual, tc.expected) {
+			t.Errorf("Unexpected accessible properties (%v): expected %v, got %v", i, tc.expected, actual)
+		}
+
+		if !slices.Equal(edit, tc.edit) {
+			t.Errorf("Unexpected editable properties (%v): expected %v, got %v", i, tc.edit, edit)
+		}
+	}
+}
+
+func TestGroupVerifyFailures(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	stats := []*common.VerifyFailureStat{
+		{Timestamp: t1, Status: uint8(puzzle.PuzzleExpiredError), Count: 3},
+		{Timestamp: t1, Status: uint8(puzzle.VerifiedBeforeError), Count: 1},
+		{Timestamp: t1, Status: uint8(puzzle.DuplicateSolutionsError), Count: 2},
+		{Timestamp: t2, Status: uint8(puzzle.IntegrityError), Count: 5},
+		{Timestamp: t2, Status: uint8(puzzle.WrongOwnerError), Count: 1},
+		{Timestamp: t2, Status: uint8(puzzle.ParseResponseError), Count: 7},
+	}
+
+	buckets, totals := groupVerifyFailures(stats)
+	if len(buckets) != 2 {
+		t.Fatalf("Unexpected number of buckets: %v", len(buckets))
+	}
+
+	if b := buckets[0]; (b.Date != t1.Unix()) || (b.Expired != 3) || (b.Replay != 3) || (b.Integrity != 0) {
+		t.Errorf("Unexpected first bucket: %+v", b)
+	}
+
+	if b := buckets[1]; (b.Date != t2.Unix()) || (b.Integrity != 5) || (b.WrongOwner != 1) || (b.Other != 7) {
+		t.Errorf("Unexpected second bucket: %+v", b)
+	}
+
+	expected := failureCounts{Expired: 3, Integrity: 5, Replay: 3, WrongOwner: 1, Other: 7}
+	if *totals != expected {
+		t.Errorf("Unexpected totals: %+v", totals)
+	}
+
+	if buckets, _ := groupVerifyFailures(nil); (buckets == nil) || (len(buckets) != 0) {
+		t.Errorf("Unexpected buckets for empty stats: %v", buckets)
+	}
+}
+
+func TestRetrievePropertyStatsFromTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	timeSeries := testsupport.NewTimeSeries()
+	timeSeries.Now = func() time.Time { return tnow }
+	srv := &Server{TimeSeries: timeSeries}
+
+	ctx := context.TODO()
+	_ = timeSeries.WriteAccessLogBatch(ctx, []*common.AccessRecord{
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-10 * time.Minute)},
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-20 * time.Minute), Datacenter: true},
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-2 * time.Hour)},
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-3 * time.Hour)},
+	})
+	_ = timeSeries.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-5 * time.Minute)},
+	})
+
+	response := srv.retrievePropertyStats(ctx, 1, 2, common.TimePeriodToday, time.UTC)
+	if (len(response.Requested) != 3) || (len(response.Verified) != 3) {
+		t.Fatalf("Unexpected number of points: %v, %v", len(response.Requested), len(response.Verified))
+	}
+
+	if p := response.Requested[2]; (p.Date != tnow.Truncate(time.Hour).Unix()) || (p.Value != 2) {
+		t.Errorf("Unexpected last requested point: %+v", p)
+	}
+
+	if response.DatacenterShare != 25.0 {
+		t.Errorf("Unexpected datacenter share: %v", response.DatacenterShare)
+	}
+
+	if response := srv.retrievePropertyStats(ctx, 1, 3, common.TimePeriodToday, time.UTC); (len(response.Requested) != 0) ||
+		(len(response.Verified) != 0) {
+		t.Errorf("Unexpected stats of empty property: %+v", response)
+	}
+
+	timeSeries.SetError("RetrievePropertyStats", testsupport.ErrInjected)
+	if response := srv.retrievePropertyStats(ctx, 1, 2, common.TimePeriodToday, time.UTC); (response.Requested == nil) ||
+		(len(response.Requested) != 0) || (response.DatacenterShare != 0.0) {
+		t.Errorf("Unexpected stats on time series error: %+v", response)
+	}
+}
+
+func TestRetrievePropertyFailuresFromTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	timeSeries := testsupport.NewTimeSeries()
+	timeSeries.Now = func() time.Time { return tnow }
+	srv := &Server{TimeSeries: timeSeries}
+
+	ctx := context.TODO()
+	_ = timeSeries.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-5 * time.Minute)},
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-5 * time.Minute), Status: int8(puzzle.PuzzleExpiredError)},
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-26 * time.Hour), Status: int8(puzzle.VerifiedBeforeError)},
+		{OrgID: 1, PropertyID: 2, Timestamp: tnow.Add(-26 * time.Hour), Status: int8(puzzle.IntegrityError)},
+	})
+
+	response := srv.retrievePropertyFailures(ctx, 1, 2, common.TimePeriodWeek, time.UTC)
+	if len(response.Buckets) != 2 {
+		t.Fatalf("Unexpected number of buckets: %v", len(response.Buckets))
+	}
+
+	expected := failureCounts{Expired: 1, Integrity: 1, Replay: 1}
+	if *response.Totals != expected {
+		t.Errorf("Unexpected totals: %+v", response.Totals)
+	}
+
+	timeSeries.SetLatency(time.Second)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if response := srv.retrievePropertyFailures(cctx, 1, 2, common.TimePeriodWeek, time.UTC); len(response.Buckets) != 0 {
+		t.Errorf("Unexpected buckets on time series timeout: %v", len(response.Buckets))
+	}
+}
+
+func TestPropertyEventsToUserEvents(t *testing.T) {
+	events := []*dbgen.GetPropertyEventsRow{
+		{
+			PropertyEvent: dbgen.PropertyEvent{
+				UserID:    pgtype.Int4{Int32: 1, Valid: true},
+				Setting:   db.PropertySettingAllowReplay,
+				OldValue:  "false",
+				NewValue:  "true",
+				CreatedAt: pgtype.Timestamptz{Time: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC), Valid: true},
+			},
+			UserName: pgtype.Text{String: "Alice", Valid: true},
+		},
+		{
+			PropertyEvent: dbgen.PropertyEvent{
+				Setting:  db.PropertySettingAllowedOrigins,
+				NewValue: "example.com",
+			},
+		},
+		{
+			PropertyEvent: dbgen.PropertyEvent{
+				UserID:  pgtype.Int4{Int32: 2, Valid: true},
+				Setting: "unknown",
+			},
+		},
+	}
+
+	result := propertyEventsToUserEvents(events, time.UTC)
+	if len(result) != len(events) {
+		t.Fatalf("Unexpected events count: %v", len(result))
+	}
+
+	if e := result[0]; (e.Actor != "Alice") || (e.Setting != "Allow replay") || (e.OldValue != "off") || (e.NewValue != "on") ||
+		(e.Time != "01 Jan 2025 10:00 UTC") {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+
+	if e := result[1]; (e.Actor != "System") || (e.OldValue != "(none)") || (e.NewValue != "example.com") {
+		t.Errorf("Unexpected system event: %+v", e)
+	}
+
+	if e := result[2]; (e.Actor != "Deleted user") || (e.Setting != "unknown") {
+		t.Errorf("Unexpected event of deleted user: %+v", e)
+	}
+}
+
+func TestNewFingerprintCounts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		stat     common.FingerprintStat
+		expected fingerprintCounts
+	}{
+		{common.FingerprintStat{UniqueCount: 0, RequestsCount: 0}, fingerprintCounts{}},
+		{common.FingerprintStat{UniqueCount: 3, RequestsCount: 3}, fingerprintCounts{Unique: 3, Requests: 3}},
+		{common.FingerprintStat{UniqueCount: 1, RequestsCount: 4}, fingerprintCounts{Unique: 1, Requests: 4, RepeatRatio: 0.75}},
+		{common.FingerprintStat{UniqueCount: 2, RequestsCount: 3}, fingerprintCounts{Unique: 2, Requests: 3, RepeatRatio: 0.333}},
+		// approximate distinct count can be higher than the number of requests
+		{common.FingerprintStat{UniqueCount: 6, RequestsCount: 5}, fingerprintCounts{Unique: 5, Requests: 5}},
+	}
+
+	for i, tc := range testCases {
+		if actual := newFingerprintCounts(&tc.stat); actual != tc.expected {
+			t.Errorf("Unexpected counts at %v: %+v (expected %+v)", i, actual, tc.expected)
+		}
+	}
+}
+
+func TestRetrievePropertyFingerprintsFromTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	timeSeries := testsupport.NewTimeSeries()
+	timeSeries.Now = func() time.Time { return tnow }
+	srv := &Server{TimeSeries: timeSeries}
+	property := &dbgen.Property{ID: 2, OrgID: db.Int(1)}
+
+	ctx := context.TODO()
+	_ = timeSeries.WriteAccessLogBatch(ctx, []*common.AccessRecord{
+		{OrgID: 1, PropertyID: 2, Fingerprint: 1, Timestamp: tnow.Add(-10 * time.Minute)},
+		{OrgID: 1, PropertyID: 2, Fingerprint: 1, Timestamp: tnow.Add(-20 * time.Minute)},
+		{OrgID: 1, PropertyID: 2, Fingerprint: 2, Timestamp: tnow.Add(-20 * time.Minute)},
+		{OrgID: 1, PropertyID: 2, Fingerprint: 1, Timestamp: tnow.Add(-3 * time.Hour)},
+		{OrgID: 1, PropertyID: 2, Fingerprint: 3, Timestamp: tnow.Add(-3 * time.Hour), IPLess: true},
+		// written while property was in privacy mode
+		{OrgID: 1, PropertyID: 2, Fingerprint: 0, Timestamp: tnow.Add(-3 * time.Hour)},
+	})
+
+	response := srv.retrievePropertyFingerprints(ctx, property, common.TimePeriodToday, time.UTC)
+	if !response.Available {
+		t.Errorf("Fingerprints are not available")
+	}
+
+	if len(response.Buckets) != 2 {
+		t.Fatalf("Unexpected number of buckets: %v", len(response.Buckets))
+	}
+
+	if b := response.Buckets[1]; (b.Requests != 3) || (b.Unique != 2) {
+		t.Errorf("Unexpected last bucket: %+v", b)
+	}
+
+	expected := fingerprintCounts{Unique: 2, Requests: 4, RepeatRatio: 0.5}
+	if *response.Totals != expected {
+		t.Errorf("Unexpected totals: %+v", response.Totals)
+	}
+
+	timeSeries.SetLatency(time.Second)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if response := srv.retrievePropertyFingerprints(cctx, property, common.TimePeriodToday, time.UTC); len(response.Buckets) != 0 {
+		t.Errorf("Unexpected buckets on time series timeout: %v", len(response.Buckets))
+	}
+}
+
+func TestRetrievePropertyFingerprintsPrivacyMode(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	timeSeries := testsupport.NewTimeSeries()
+	timeSeries.Now = func() time.Time { return tnow }
+	srv := &Server{TimeSeries: timeSeries}
+
+	ctx := context.TODO()
+	// records written before privacy mode was turned on still have fingerprints
+	_ = timeSeries.WriteAccessLogBatch(ctx, []*common.AccessRecord{
+		{OrgID: 1, PropertyID: 2, Fingerprint: 1, Timestamp: tnow.Add(-10 * time.Minute)},
+		{OrgID: 1, PropertyID: 2, Fingerprint: 0, Timestamp: tnow.Add(-5 * time.Minute)},
+	})
+
+	for _, property := range []*dbgen.Property{
+		{ID: 2, OrgID: db.Int(1), PrivacyMode: true},
+		{ID: 2, OrgID: db.Int(1), IplessMode: true},
+	} {
+		response := srv.retrievePropertyFingerprints(ctx, property, common.TimePeriodToday, time.UTC)
+		if response.Available || (len(response.Buckets) != 0) || (response.Totals.Unique != 0) {
+			t.Errorf("Unexpected fingerprints response: %+v", response)
+		}
+	}
+}
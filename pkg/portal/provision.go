@@ -0,0 +1,287 @@
+package portal
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/badoux/checkmail"
+)
+
+const (
+	maxProvisionProperties = 10
+	provisionAPIKeyName    = "Provisioning"
+	provisionAPIKeyPeriod  = 365 * 24 * time.Hour
+)
+
+type provisionPropertySpec struct {
+	Name     string `json:"name"`
+	Domain   string `json:"domain"`
+	TestMode bool   `json:"test_mode"`
+}
+
+// provisionRequest describes complete account of a reseller's customer
+type provisionRequest struct {
+	Email      string                   `json:"email"`
+	Name       string                   `json:"name"`
+	OrgName    string                   `json:"org_name"`
+	ProductID  string                   `json:"product_id"`
+	PriceID    string                   `json:"price_id"`
+	Properties []*provisionPropertySpec `json:"properties"`
+}
+
+type provisionedProperty struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Domain  string `json:"domain"`
+	Sitekey string `json:"sitekey"`
+}
+
+type provisionResponse struct {
+	UserID          string                 `json:"user_id"`
+	OrgID           string                 `json:"org_id"`
+	Properties      []*provisionedProperty `json:"properties"`
+	APIKey          string                 `json:"api_key"`
+	APIKeyExpiresAt time.Time              `json:"api_key_expires_at"`
+}
+
+// normalize trims inputs and returns a validation error message if request cannot be fulfilled
+func (pr *provisionRequest) normalize() string {
+	pr.Email = strings.TrimSpace(pr.Email)
+	if err := checkmail.ValidateFormat(pr.Email); err != nil {
+		return "Email address is not valid."
+	}
+
+	pr.Name = strings.TrimSpace(pr.Name)
+	if len(pr.Name) == 0 {
+		return "Name is required."
+	}
+
+	pr.OrgName = strings.TrimSpace(pr.OrgName)
+	if len(pr.OrgName) == 0 {
+		pr.OrgName = common.DefaultOrgName
+	} else if len(pr.OrgName) > maxOrgNameLength {
+		return "Organization name is too long."
+	}
+
+	if (len(pr.ProductID) == 0) != (len(pr.PriceID) == 0) {
+		return "Both product and price IDs are required to choose a plan."
+	}
+
+	if len(pr.Properties) > maxProvisionProperties {
+		return "Too many properties in a single request."
+	}
+
+	names := make(map[string]struct{}, len(pr.Properties))
+	for _, p := range pr.Properties {
+		if p == nil {
+			return "Property cannot be empty."
+		}
+
+		p.Name = strings.TrimSpace(p.Name)
+		if (len(p.Name) == 0) || (len(p.Name) > maxPropertyNameLength) {
+			return "Property name is empty or too long."
+		}
+
+		if _, ok := names[p.Name]; ok {
+			return "Property names must be unique."
+		}
+		names[p.Name] = struct{}{}
+
+		domain, err := common.ParseDomainName(p.Domain)
+		if (err != nil) || (len(domain) == 0) {
+			return "Invalid format of domain name"
+		}
+		p.Domain = domain
+	}
+
+	return ""
+}
+
+// sharedKeyAuth authenticates server-to-server requests by the bearer token. Empty key disables the endpoint
+func sharedKeyAuth(key *atomic.Pointer[string], name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := key.Load()
+		if (value == nil) || (len(*value) == 0) {
+			// API is not enabled so it should look like it does not exist
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get(common.HeaderAuthorization), "Bearer ")
+		if !ok || (subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(*value)) != 1) {
+			slog.WarnContext(r.Context(), "Request without valid key", "api", name)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) provisioningAuth(next http.Handler) http.Handler {
+	return sharedKeyAuth(&s.provisioningKey, "provisioning", next)
+}
+
+func (s *Server) provisionPlan(req *provisionRequest) (billing.Plan, error) {
+	if len(req.ProductID) == 0 {
+		return s.PlanService.GetInternalTrialPlan(), nil
+	}
+
+	return s.PlanService.FindPlan(req.ProductID, req.PriceID, s.Stage, false /*internal*/)
+}
+
+// provisionSubscription starts a trial unless the plan was chosen explicitly, in which case reseller pays for it
+func (s *Server) provisionSubscription(req *provisionRequest, plan billing.Plan) *dbgen.CreateSubscriptionParams {
+	if len(req.ProductID) == 0 {
+		return createInternalTrial(plan, s.PlanService.TrialStatus())
+	}
+
+	subscrParams := createInternalSubscription(plan, s.PlanService.ActiveStatus())
+	subscrParams.ExternalPriceID = req.PriceID
+	return subscrParams
+}
+
+// provisionAccount creates user, organization, subscription, properties and an API key in a single transaction
+func (s *Server) provisionAccount(ctx context.Context, req *provisionRequest, plan billing.Plan) (*provisionResponse, error) {
+	subscrParams := s.provisionSubscription(req, plan)
+	expiration := time.Now().UTC().Add(provisionAPIKeyPeriod)
+
+	response := &provisionResponse{
+		Properties: make([]*provisionedProperty, 0, len(req.Properties)),
+	}
+
+	if err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) error {
+		user, org, err := impl.CreateNewAccount(ctx, subscrParams, req.Email, req.Name, req.OrgName, -1 /*existing user ID*/)
+		if err != nil {
+			return err
+		}
+
+		if org == nil {
+			// existing user without subscription was "adopted", which we do not want for provisioning
+			return db.ErrDuplicateAccount
+		}
+
+		response.UserID = strconv.Itoa(int(user.ID))
+		response.OrgID = strconv.Itoa(int(org.ID))
+
+		for _, p := range req.Properties {
+			property, err := impl.CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+				Name:       p.Name,
+				OrgID:      db.Int(org.ID),
+				CreatorID:  db.Int(user.ID),
+				OrgOwnerID: db.Int(user.ID),
+				Domain:     p.Domain,
+				Level:      db.Int2(int16(common.DifficultyLevelSmall)),
+				Growth:     dbgen.DifficultyGrowthMedium,
+				TestMode:   p.TestMode,
+			})
+			if err != nil {
+				return err
+			}
+
+			response.Properties = append(response.Properties, &provisionedProperty{
+				ID:      strconv.Itoa(int(property.ID)),
+				Name:    property.Name,
+				Domain:  property.Domain,
+				Sitekey: db.UUIDToSiteKey(property.ExternalID),
+			})
+		}
+
+		key, err := impl.CreateAPIKey(ctx, user.ID, provisionAPIKeyName, expiration, plan.APIRequestsPerSecond())
+		if err != nil {
+			return err
+		}
+
+		response.APIKey = db.UUIDToSecret(key.ExternalID)
+		response.APIKeyExpiresAt = key.ExpiresAt.Time
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// postProvisionAccount allows hosting resellers to set up captcha for their customers programmatically
+func (s *Server) postProvisionAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &provisionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		slog.WarnContext(ctx, "Failed to parse provisioning request", common.ErrAttr(err))
+		s.sendAPIError(ctx, w, ErrInvalidRequestArg)
+		return
+	}
+
+	if validationError := req.normalize(); len(validationError) > 0 {
+		sendAPIValidationError(w, validationError)
+		return
+	}
+
+	plan, err := s.provisionPlan(req)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to find provisioning plan", "productID", req.ProductID, "priceID", req.PriceID, common.ErrAttr(err))
+		sendAPIValidationError(w, "Unknown plan.")
+		return
+	}
+
+	if limit := plan.PropertiesLimit(); (limit > 0) && (len(req.Properties) > limit) {
+		sendAPIValidationError(w, fmt.Sprintf("Properties limit (%d) of the plan is exceeded.", limit))
+		return
+	}
+
+	for _, p := range req.Properties {
+		if domainError := s.validateDomainName(ctx, p.Domain); len(domainError) > 0 {
+			sendAPIValidationError(w, domainError)
+			return
+		}
+	}
+
+	if _, err := s.Store.Impl().FindUserByEmail(ctx, req.Email); err == nil {
+		slog.WarnContext(ctx, "Cannot provision account for existing user", "email", req.Email)
+		http.Error(w, "Such email is already registered.", http.StatusConflict)
+		return
+	}
+
+	response, err := s.provisionAccount(ctx, req, plan)
+	if err != nil {
+		if errors.Is(err, db.ErrDuplicateAccount) {
+			http.Error(w, "Such email is already registered.", http.StatusConflict)
+			return
+		}
+
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Audit: provisioned account", "userID", response.UserID, "orgID", response.OrgID,
+		"properties", len(response.Properties))
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	wHeader := w.Header()
+	wHeader.Set(common.HeaderContentType, common.ContentTypeJSON)
+	for key, value := range common.NoCacheHeaders {
+		wHeader[key] = value
+	}
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(data)
+}
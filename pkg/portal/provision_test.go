@@ -0,0 +1,182 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/testsupport"
+)
+
+func TestProvisionRequestNormalize(t *testing.T) {
+	testCases := []struct {
+		name  string
+		req   provisionRequest
+		valid bool
+	}{
+		{"valid", provisionRequest{Email: " user@example.com ", Name: "User", Properties: []*provisionPropertySpec{{Name: "site", Domain: "example.com"}}}, true},
+		{"no properties", provisionRequest{Email: "user@example.com", Name: "User"}, true},
+		{"invalid email", provisionRequest{Email: "user", Name: "User"}, false},
+		{"empty name", provisionRequest{Email: "user@example.com", Name: " "}, false},
+		{"price without product", provisionRequest{Email: "user@example.com", Name: "User", PriceID: "price"}, false},
+		{"duplicate properties", provisionRequest{Email: "user@example.com", Name: "User", Properties: []*provisionPropertySpec{
+			{Name: "site", Domain: "example.com"},
+			{Name: "site", Domain: "example.org"},
+		}}, false},
+		{"empty domain", provisionRequest{Email: "user@example.com", Name: "User", Properties: []*provisionPropertySpec{{Name: "site", Domain: ""}}}, false},
+		{"invalid domain", provisionRequest{Email: "user@example.com", Name: "User", Properties: []*provisionPropertySpec{{Name: "site", Domain: "%zz"}}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if message := tc.req.normalize(); (len(message) == 0) != tc.valid {
+				t.Errorf("Unexpected validation result: %q", message)
+			}
+		})
+	}
+
+	req := &provisionRequest{Email: " user@example.com ", Name: "User"}
+	if message := req.normalize(); len(message) > 0 {
+		t.Fatal(message)
+	}
+
+	if (req.Email != "user@example.com") || (req.OrgName != common.DefaultOrgName) {
+		t.Errorf("Request was not normalized: %+v", req)
+	}
+}
+
+func TestProvisionSubscription(t *testing.T) {
+	s := &Server{PlanService: testsupport.NewPlanService(nil)}
+	plan := s.PlanService.GetInternalTrialPlan()
+
+	trial := s.provisionSubscription(&provisionRequest{}, plan)
+	if (trial.Status != billing.InternalStatusTrialing) || !trial.TrialEndsAt.Valid {
+		t.Errorf("Unexpected subscription without plan: %+v", trial)
+	}
+
+	active := s.provisionSubscription(&provisionRequest{ProductID: plan.ProductID(), PriceID: "price"}, plan)
+	if (active.Status != billing.InternalStatusActive) || active.TrialEndsAt.Valid || (active.ExternalPriceID != "price") {
+		t.Errorf("Unexpected subscription with explicit plan: %+v", active)
+	}
+
+	if !s.PlanService.IsSubscriptionActive(active.Status) {
+		t.Errorf("Provisioned subscription is not active")
+	}
+}
+
+func TestProvisioningAuth(t *testing.T) {
+	s := &Server{}
+	handler := s.provisioningAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if len(token) > 0 {
+			req.Header.Set(common.HeaderAuthorization, "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(""); code != http.StatusNotFound {
+		t.Errorf("Unexpected status code when disabled: %v", code)
+	}
+
+	key := strings.Repeat("k", 32)
+	s.provisioningKey.Store(&key)
+
+	if code := send(""); code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code without key: %v", code)
+	}
+
+	if code := send("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code with wrong key: %v", code)
+	}
+
+	if code := send(key); code != http.StatusOK {
+		t.Errorf("Unexpected status code with valid key: %v", code)
+	}
+}
+
+func TestProvisionAccount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	key := strings.Repeat("p", 32)
+	server.provisioningKey.Store(&key)
+	defer func() {
+		empty := ""
+		server.provisioningKey.Store(&empty)
+	}()
+
+	body := `{"email": "` + t.Name() + `@privatecaptcha.com", "name": "Reseller Customer", "org_name": "Hosting",
+		"properties": [{"name": "blog", "domain": "example.com"}, {"name": "shop", "domain": "example.com", "test_mode": true}]}`
+
+	provision := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/"+common.APIEndpoint+"/"+common.V1Endpoint+"/"+common.ProvisionEndpoint, strings.NewReader(body))
+		req.Header.Set(common.HeaderAuthorization, "Bearer "+key)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := provision()
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Unexpected status code: %v (%v)", w.Code, w.Body.String())
+	}
+
+	response := &provisionResponse{}
+	if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(response.Properties) != 2) || (len(response.APIKey) == 0) {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+
+	ctx := context.TODO()
+
+	for _, p := range response.Properties {
+		if len(p.Sitekey) == 0 {
+			t.Errorf("Sitekey is missing for property %v", p.Name)
+		}
+	}
+
+	apiKey, err := store.Impl().RetrieveAPIKey(ctx, response.APIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := store.Impl().FindUserByEmail(ctx, t.Name()+"@privatecaptcha.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (apiKey.UserID.Int32 != user.ID) || !user.SubscriptionID.Valid {
+		t.Errorf("Unexpected provisioned user: %+v", user)
+	}
+
+	subscription, err := store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (subscription.Status != billing.InternalStatusTrialing) || !subscription.TrialEndsAt.Valid {
+		t.Errorf("Unexpected subscription without explicit plan: %+v", subscription)
+	}
+
+	if w := provision(); w.Code != http.StatusConflict {
+		t.Errorf("Unexpected status code for repeated request: %v", w.Code)
+	}
+}
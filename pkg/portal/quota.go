@@ -0,0 +1,174 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func quotaToAllocation(quota *dbgen.PropertyQuota) billing.Allocation {
+	return billing.Allocation{Percent: int(quota.Percent), Weekly: quota.Period == dbgen.QuotaPeriodWeek}
+}
+
+func quotaPeriodName(period dbgen.QuotaPeriod) string {
+	if period == dbgen.QuotaPeriodWeek {
+		return "week"
+	}
+
+	return "month"
+}
+
+// ownerRequestsLimit returns monthly requests quota of the user's plan (0 if there's no limit or it's unknown)
+func (s *Server) ownerRequestsLimit(ctx context.Context, ownerID int32) int64 {
+	owner, err := s.Store.Impl().RetrieveUser(ctx, ownerID)
+	if (err != nil) || !owner.SubscriptionID.Valid {
+		return 0
+	}
+
+	subscription, err := s.Store.Impl().RetrieveSubscription(ctx, owner.SubscriptionID.Int32)
+	if err != nil {
+		return 0
+	}
+
+	plan, err := s.PlanService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, s.Stage,
+		db.IsInternalSubscription(subscription.Source))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find billing plan for quota", "userID", ownerID, common.ErrAttr(err))
+		return 0
+	}
+
+	return plan.RequestsLimit()
+}
+
+func (s *Server) loadPropertyQuota(ctx context.Context, renderCtx *propertySettingsRenderContext, propertyID int32) {
+	renderCtx.CanAllocateQuota = true
+	renderCtx.QuotaPeriod = string(dbgen.QuotaPeriodMonth)
+
+	if quota, err := s.Store.Impl().RetrievePropertyQuota(ctx, propertyID); err == nil {
+		renderCtx.QuotaPercent = int(quota.Percent)
+		renderCtx.QuotaPeriod = string(quota.Period)
+	}
+}
+
+// propertyQuotaWarning returns a message if the property is close to use up the share of the quota allocated to it
+func (s *Server) propertyQuotaWarning(ctx context.Context, property *dbgen.Property, tnow time.Time) string {
+	quota, err := s.Store.Impl().RetrievePropertyQuota(ctx, property.ID)
+	if err != nil {
+		return ""
+	}
+
+	allocation := quotaToAllocation(quota)
+
+	limit := allocation.Requests(s.ownerRequestsLimit(ctx, property.OrgOwnerID.Int32))
+	if limit == 0 {
+		return ""
+	}
+
+	stats, err := s.TimeSeries.RetrievePropertiesTotals(ctx, property.OrgID.Int32, []int32{property.ID}, allocation.PeriodStart(tnow))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve property usage", "propID", property.ID, common.ErrAttr(err))
+		return ""
+	}
+
+	used := int64(stats.RequestsCount)
+	period := quotaPeriodName(quota.Period)
+
+	if used >= limit {
+		return fmt.Sprintf("This property used up its allocated quota of %d requests per %s and does not serve captcha until %s.",
+			limit, period, allocation.PeriodEnd(tnow).Format("02 Jan 2006"))
+	}
+
+	if percent := used * 100 / limit; percent >= billing.AllocationWarningPercent {
+		return fmt.Sprintf("This property used %d%% of its allocated quota of %d requests per %s.", percent, limit, period)
+	}
+
+	return ""
+}
+
+// putPropertyQuota allocates share of the owner's plan quota to the property
+func (s *Server) putPropertyQuota(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	renderCtx, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !renderCtx.CanAllocateQuota {
+		slog.WarnContext(ctx, "Insufficient permissions to allocate quota", "userID", user.ID, "propID", renderCtx.Property.ID)
+		renderCtx.ErrorMessage = "Only organization owner can allocate quota."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	var percent int
+	if value := strings.TrimSpace(r.FormValue(common.ParamPercent)); len(value) > 0 {
+		percent, err = strconv.Atoi(value)
+		if (err != nil) || (percent < 0) || (percent > 100) {
+			slog.WarnContext(ctx, "Failed to parse quota percent", "value", value, common.ErrAttr(err))
+			renderCtx.QuotaError = "Allocation must be a number between 0 and 100."
+			return renderCtx, propertyDashboardSettingsTemplate, nil
+		}
+	}
+
+	period := dbgen.QuotaPeriod(r.FormValue(common.ParamQuotaPeriod))
+	if (period != dbgen.QuotaPeriodWeek) && (period != dbgen.QuotaPeriodMonth) {
+		slog.WarnContext(ctx, "Invalid quota period", "period", period)
+		renderCtx.QuotaError = "Period is not valid."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	property, err := s.Property(org.ID, user.ID, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if percent > 0 {
+		allocated, err := s.Store.Impl().RetrieveOwnerAllocatedQuota(ctx, property.OrgOwnerID.Int32, property.ID)
+		if err != nil {
+			renderCtx.QuotaError = "Failed to update quota allocation. Please try again."
+			return renderCtx, propertyDashboardSettingsTemplate, nil
+		}
+
+		if allocated+percent > 100 {
+			slog.WarnContext(ctx, "Quota is over-allocated", "propID", property.ID, "allocated", allocated, "percent", percent)
+			renderCtx.QuotaError = fmt.Sprintf("Only %d%% of your quota is not allocated to other properties.", max(0, 100-allocated))
+			return renderCtx, propertyDashboardSettingsTemplate, nil
+		}
+	}
+
+	if _, err := s.Store.Impl().UpdatePropertyQuota(ctx, property.ID, int16(percent), period); err != nil {
+		renderCtx.QuotaError = "Failed to update quota allocation. Please try again."
+		return renderCtx, propertyDashboardSettingsTemplate, nil
+	}
+
+	renderCtx.QuotaPercent = percent
+	renderCtx.QuotaPeriod = string(period)
+	renderCtx.QuotaUpdated = true
+
+	return renderCtx, propertyDashboardSettingsTemplate, nil
+}
@@ -135,6 +135,13 @@ func createInternalTrial(plan billing.Plan, status string) *dbgen.CreateSubscrip
 	}
 }
 
+// createInternalSubscription creates subscription for the plan that was chosen explicitly so it does not expire
+func createInternalSubscription(plan billing.Plan, status string) *dbgen.CreateSubscriptionParams {
+	subscrParams := createInternalTrial(plan, status)
+	subscrParams.TrialEndsAt = pgtype.Timestamptz{}
+	return subscrParams
+}
+
 func (s *Server) doRegister(ctx context.Context, sess *common.Session) (*dbgen.User, *dbgen.Organization, error) {
 	email, ok := sess.Get(session.KeyUserEmail).(string)
 	if !ok {
@@ -10,97 +10,230 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"github.com/PrivateCaptcha/PrivateCaptcha/widget"
 )
 
 type RenderConstants struct {
-	LoginEndpoint        string
-	TwoFactorEndpoint    string
-	ResendEndpoint       string
-	RegisterEndpoint     string
-	SettingsEndpoint     string
-	LogoutEndpoint       string
-	NewEndpoint          string
-	OrgEndpoint          string
-	PropertyEndpoint     string
-	DashboardEndpoint    string
-	TabEndpoint          string
-	ReportsEndpoint      string
-	IntegrationsEndpoint string
-	EditEndpoint         string
-	Token                string
-	Email                string
-	Name                 string
-	Tab                  string
-	VerificationCode     string
-	Domain               string
-	Difficulty           string
-	Growth               string
-	Stats                string
-	DeleteEndpoint       string
-	MembersEndpoint      string
-	OrgLevelInvited      string
-	OrgLevelMember       string
-	OrgLevelOwner        string
-	GeneralEndpoint      string
-	EmailEndpoint        string
-	UserEndpoint         string
-	APIKeysEndpoint      string
-	Months               string
-	HeaderCSRFToken      string
-	UsageEndpoint        string
-	NotificationEndpoint string
-	ErrorEndpoint        string
-	ValidityInterval     string
-	AllowSubdomains      string
-	AllowLocalhost       string
-	AllowReplay          string
-	IgnoreError          string
+	LoginEndpoint         string
+	TwoFactorEndpoint     string
+	ResendEndpoint        string
+	MagicLinkEndpoint     string
+	RegisterEndpoint      string
+	SettingsEndpoint      string
+	LogoutEndpoint        string
+	NewEndpoint           string
+	OrgEndpoint           string
+	PropertyEndpoint      string
+	DashboardEndpoint     string
+	TabEndpoint           string
+	ReportsEndpoint       string
+	IntegrationsEndpoint  string
+	DiagnosticsEndpoint   string
+	EditEndpoint          string
+	Token                 string
+	Email                 string
+	Name                  string
+	Tab                   string
+	VerificationCode      string
+	Domain                string
+	Difficulty            string
+	Growth                string
+	Stats                 string
+	Failures              string
+	Fingerprints          string
+	DeleteEndpoint        string
+	MembersEndpoint       string
+	OrgLevelInvited       string
+	OrgLevelMember        string
+	OrgLevelOwner         string
+	GeneralEndpoint       string
+	EmailEndpoint         string
+	UserEndpoint          string
+	APIKeysEndpoint       string
+	Months                string
+	HeaderCSRFToken       string
+	UsageEndpoint         string
+	NotificationEndpoint  string
+	NotificationsEndpoint string
+	ReadEndpoint          string
+	CountEndpoint         string
+	ErrorEndpoint         string
+	ValidityInterval      string
+	AllowSubdomains       string
+	AllowLocalhost        string
+	AllowReplay           string
+	MemoryHard            string
+	PrivacyMode           string
+	IplessMode            string
+	AccessibleMode        string
+	TestMode              string
+	WidgetChannel         string
+	TestOutcome           string
+	AllowedOrigins        string
+	AllowedApps           string
+	TrustedThreshold      string
+	TrustedTTL            string
+	IgnoreError           string
+	SearchEndpoint        string
+	Query                 string
+	RevertEndpoint        string
+	Tag                   string
+	Tags                  string
+	SecurityEndpoint      string
+	ReverifyLogins        string
+	Timezone              string
+	MessagesEndpoint      string
+	BlockedMessage        string
+	QuotaMessage          string
+	MaintenanceMessage    string
+	RedirectURL           string
+	Rotation              string
+	BudgetEndpoint        string
+	Budget                string
+	ConfirmEndpoint       string
+	EmailsEndpoint        string
+	BillingEndpoint       string
+	EmailID               string
+	Org                   string
+	SessionsEndpoint      string
+	PauseEndpoint         string
+	SitekeyEndpoint       string
+	ResumeEndpoint        string
+	OnboardingEndpoint    string
+	Paused                string
+	Theme                 string
+	Locale                string
+	WidgetThemes          []string
+	WidgetLocales         []string
+	WidgetScript          string
+	WidgetIntegrity       string
+	User                  string
+	Access                string
+	PropertyAccessView    string
+	PropertyAccessEdit    string
+	Period                string
+	ShareEndpoint         string
+	QuotaEndpoint         string
+	Percent               string
+	QuotaPeriod           string
+	QuotaPeriodWeek       string
+	QuotaPeriodMonth      string
+	DomainEndpoint        string
+	UITheme               string
+	TableDensity          string
+	DefaultOrg            string
 }
 
 func NewRenderConstants() *RenderConstants {
 	return &RenderConstants{
-		LoginEndpoint:        common.LoginEndpoint,
-		TwoFactorEndpoint:    common.TwoFactorEndpoint,
-		ResendEndpoint:       common.ResendEndpoint,
-		RegisterEndpoint:     common.RegisterEndpoint,
-		SettingsEndpoint:     common.SettingsEndpoint,
-		LogoutEndpoint:       common.LogoutEndpoint,
-		OrgEndpoint:          common.OrgEndpoint,
-		PropertyEndpoint:     common.PropertyEndpoint,
-		DashboardEndpoint:    common.DashboardEndpoint,
-		NewEndpoint:          common.NewEndpoint,
-		Token:                common.ParamCSRFToken,
-		Email:                common.ParamEmail,
-		Name:                 common.ParamName,
-		Tab:                  common.ParamTab,
-		VerificationCode:     common.ParamVerificationCode,
-		Domain:               common.ParamDomain,
-		Difficulty:           common.ParamDifficulty,
-		Growth:               common.ParamGrowth,
-		Stats:                common.StatsEndpoint,
-		TabEndpoint:          common.TabEndpoint,
-		ReportsEndpoint:      common.ReportsEndpoint,
-		IntegrationsEndpoint: common.IntegrationsEndpoint,
-		EditEndpoint:         common.EditEndpoint,
-		DeleteEndpoint:       common.DeleteEndpoint,
-		MembersEndpoint:      common.MembersEndpoint,
-		OrgLevelInvited:      string(dbgen.AccessLevelInvited),
-		OrgLevelMember:       string(dbgen.AccessLevelMember),
-		OrgLevelOwner:        string(dbgen.AccessLevelOwner),
-		GeneralEndpoint:      common.GeneralEndpoint,
-		EmailEndpoint:        common.EmailEndpoint,
-		UserEndpoint:         common.UserEndpoint,
-		APIKeysEndpoint:      common.APIKeysEndpoint,
-		Months:               common.ParamMonths,
-		HeaderCSRFToken:      common.HeaderCSRFToken,
-		UsageEndpoint:        common.UsageEndpoint,
-		NotificationEndpoint: common.NotificationEndpoint,
-		ErrorEndpoint:        common.ErrorEndpoint,
-		ValidityInterval:     common.ParamValidityInterval,
-		AllowSubdomains:      common.ParamAllowSubdomains,
-		AllowLocalhost:       common.ParamAllowLocalhost,
-		AllowReplay:          common.ParamAllowReplay,
-		IgnoreError:          common.ParamIgnoreError,
+		LoginEndpoint:         common.LoginEndpoint,
+		TwoFactorEndpoint:     common.TwoFactorEndpoint,
+		ResendEndpoint:        common.ResendEndpoint,
+		MagicLinkEndpoint:     common.MagicLinkEndpoint,
+		RegisterEndpoint:      common.RegisterEndpoint,
+		SettingsEndpoint:      common.SettingsEndpoint,
+		LogoutEndpoint:        common.LogoutEndpoint,
+		OrgEndpoint:           common.OrgEndpoint,
+		PropertyEndpoint:      common.PropertyEndpoint,
+		DashboardEndpoint:     common.DashboardEndpoint,
+		NewEndpoint:           common.NewEndpoint,
+		Token:                 common.ParamCSRFToken,
+		Email:                 common.ParamEmail,
+		Name:                  common.ParamName,
+		Tab:                   common.ParamTab,
+		VerificationCode:      common.ParamVerificationCode,
+		Domain:                common.ParamDomain,
+		Difficulty:            common.ParamDifficulty,
+		Growth:                common.ParamGrowth,
+		Stats:                 common.StatsEndpoint,
+		Failures:              common.FailuresEndpoint,
+		Fingerprints:          common.FingerprintsEndpoint,
+		TabEndpoint:           common.TabEndpoint,
+		ReportsEndpoint:       common.ReportsEndpoint,
+		IntegrationsEndpoint:  common.IntegrationsEndpoint,
+		DiagnosticsEndpoint:   common.DiagnosticsEndpoint,
+		EditEndpoint:          common.EditEndpoint,
+		DeleteEndpoint:        common.DeleteEndpoint,
+		MembersEndpoint:       common.MembersEndpoint,
+		OrgLevelInvited:       string(dbgen.AccessLevelInvited),
+		OrgLevelMember:        string(dbgen.AccessLevelMember),
+		OrgLevelOwner:         string(dbgen.AccessLevelOwner),
+		GeneralEndpoint:       common.GeneralEndpoint,
+		EmailEndpoint:         common.EmailEndpoint,
+		UserEndpoint:          common.UserEndpoint,
+		APIKeysEndpoint:       common.APIKeysEndpoint,
+		Months:                common.ParamMonths,
+		HeaderCSRFToken:       common.HeaderCSRFToken,
+		UsageEndpoint:         common.UsageEndpoint,
+		NotificationEndpoint:  common.NotificationEndpoint,
+		NotificationsEndpoint: common.NotificationsEndpoint,
+		ReadEndpoint:          common.ReadEndpoint,
+		CountEndpoint:         common.CountEndpoint,
+		ErrorEndpoint:         common.ErrorEndpoint,
+		ValidityInterval:      common.ParamValidityInterval,
+		AllowSubdomains:       common.ParamAllowSubdomains,
+		AllowLocalhost:        common.ParamAllowLocalhost,
+		AllowReplay:           common.ParamAllowReplay,
+		MemoryHard:            common.ParamMemoryHard,
+		PrivacyMode:           common.ParamPrivacyMode,
+		IplessMode:            common.ParamIplessMode,
+		AccessibleMode:        common.ParamAccessibleMode,
+		TestMode:              common.ParamTestMode,
+		WidgetChannel:         common.ParamWidgetChannel,
+		TestOutcome:           common.ParamTestOutcome,
+		AllowedOrigins:        common.ParamAllowedOrigins,
+		AllowedApps:           common.ParamAllowedApps,
+		TrustedThreshold:      common.ParamTrustedThreshold,
+		TrustedTTL:            common.ParamTrustedTTL,
+		IgnoreError:           common.ParamIgnoreError,
+		SearchEndpoint:        common.SearchEndpoint,
+		Query:                 common.ParamQuery,
+		RevertEndpoint:        common.RevertEndpoint,
+		Tag:                   common.ParamTag,
+		Tags:                  common.ParamTags,
+		SecurityEndpoint:      common.SecurityEndpoint,
+		ReverifyLogins:        common.ParamReverifyLogins,
+		Timezone:              common.ParamTimezone,
+		MessagesEndpoint:      common.MessagesEndpoint,
+		BlockedMessage:        common.ParamBlockedMessage,
+		QuotaMessage:          common.ParamQuotaMessage,
+		MaintenanceMessage:    common.ParamMaintenanceMsg,
+		RedirectURL:           common.ParamRedirectURL,
+		Rotation:              common.ParamRotation,
+		BudgetEndpoint:        common.BudgetEndpoint,
+		Budget:                common.ParamBudget,
+		ConfirmEndpoint:       common.ConfirmEndpoint,
+		EmailsEndpoint:        common.EmailsEndpoint,
+		BillingEndpoint:       common.BillingEndpoint,
+		EmailID:               common.ParamEmailID,
+		Org:                   common.ParamOrg,
+		SessionsEndpoint:      common.SessionsEndpoint,
+		PauseEndpoint:         common.PauseEndpoint,
+		SitekeyEndpoint:       common.SitekeyEndpoint,
+		ResumeEndpoint:        common.ResumeEndpoint,
+		OnboardingEndpoint:    common.OnboardingEndpoint,
+		Paused:                common.ParamPaused,
+		Theme:                 common.ParamTheme,
+		Locale:                common.ParamLocale,
+		WidgetThemes:          widget.Themes,
+		WidgetLocales:         widget.Locales,
+		WidgetScript:          widget.ScriptURL(),
+		WidgetIntegrity:       widget.Integrity(widget.ScriptPath),
+		User:                  common.ParamUser,
+		Access:                common.ParamAccess,
+		PropertyAccessView:    string(dbgen.PropertyAccessLevelView),
+		PropertyAccessEdit:    string(dbgen.PropertyAccessLevelEdit),
+		Period:                common.ParamPeriod,
+		ShareEndpoint:         common.ShareEndpoint,
+		QuotaEndpoint:         common.QuotaEndpoint,
+		Percent:               common.ParamPercent,
+		QuotaPeriod:           common.ParamQuotaPeriod,
+		QuotaPeriodWeek:       string(dbgen.QuotaPeriodWeek),
+		QuotaPeriodMonth:      string(dbgen.QuotaPeriodMonth),
+		DomainEndpoint:        common.DomainEndpoint,
+		UITheme:               common.ParamUITheme,
+		TableDensity:          common.ParamTableDensity,
+		DefaultOrg:            common.ParamDefaultOrg,
 	}
 }
 
@@ -141,6 +274,7 @@ func (s *Server) render(w http.ResponseWriter, r *http.Request, name string, dat
 		LoggedIn:    ok && loggedIn,
 		CurrentYear: time.Now().Year(),
 		CDN:         s.CDNURL,
+		CSPNonce:    common.CSPNonce(ctx),
 	}
 
 	sess := s.Sessions.SessionStart(w, r)
@@ -148,9 +282,14 @@ func (s *Server) render(w http.ResponseWriter, r *http.Request, name string, dat
 		reqCtx.UserName = username
 	}
 
+	if userID, ok := sess.Get(session.KeyUserID).(int32); ok && reqCtx.LoggedIn {
+		prefs := s.userPreferences(ctx, userID)
+		reqCtx.Theme = string(prefs.Theme)
+		reqCtx.TableDensity = string(prefs.TableDensity)
+	}
+
 	out, err := s.RenderResponse(ctx, name, data, reqCtx)
 	if err == nil {
-		common.WriteHeaders(w, common.SecurityHeaders)
 		common.WriteHeaders(w, common.HtmlContentHeaders)
 		w.WriteHeader(http.StatusOK)
 		if _, werr := out.WriteTo(w); werr != nil {
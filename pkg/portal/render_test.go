@@ -87,6 +87,22 @@ func TestRenderHTML(t *testing.T) {
 			template: registerTemplate,
 			model:    &registerRenderContext{CsrfRenderContext: stubToken()},
 		},
+		{
+			path:     []string{common.EmailEndpoint, common.RevertEndpoint, "abcdef"},
+			template: emailRevertTemplate,
+			model:    &emailRevertRenderContext{Token: "abcdef"},
+		},
+		{
+			path:     []string{common.EmailEndpoint, common.RevertEndpoint, "abcdef"},
+			template: emailRevertTemplate,
+			model: &emailRevertRenderContext{
+				AlertRenderContext: AlertRenderContext{SuccessMessage: "Reverted"},
+				Email:              "foo@bar.com",
+				Reverted:           true,
+			},
+			selector: "p#revert-email strong",
+			matches:  []string{"foo@bar.com"},
+		},
 		{
 			path:     []string{common.OrgEndpoint, common.NewEndpoint},
 			template: orgWizardTemplate,
@@ -103,6 +119,64 @@ func TestRenderHTML(t *testing.T) {
 			selector: "p.property-name",
 			matches:  []string{"1", "2"},
 		},
+		{
+			path:     []string{common.OrgEndpoint, "123"},
+			template: portalTemplate,
+			model: &orgDashboardRenderContext{
+				Orgs:       []*userOrg{stubOrgEx("123", dbgen.AccessLevelOwner)},
+				CurrentOrg: stubOrgEx("123", dbgen.AccessLevelOwner),
+				Properties: []*userProperty{stubProperty("1", "123"), {ID: "2", OrgID: "123", Name: "2", Paused: true}},
+			},
+			selector: "span.property-paused",
+			matches:  []string{"Paused"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123"},
+			template: portalTemplate,
+			model: &orgDashboardRenderContext{
+				Orgs:       []*userOrg{stubOrgEx("123", dbgen.AccessLevelOwner)},
+				CurrentOrg: stubOrgEx("123", dbgen.AccessLevelOwner),
+				Tab:        common.MembersEndpoint,
+			},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123"},
+			template: portalTemplate,
+			model: &orgDashboardRenderContext{
+				Orgs:       []*userOrg{stubOrgEx("123", dbgen.AccessLevelOwner)},
+				CurrentOrg: stubOrgEx("123", dbgen.AccessLevelOwner),
+				Properties: []*userProperty{stubProperty("1", "123")},
+				propertyTagsRenderContext: propertyTagsRenderContext{
+					Tags:      []string{"marketing", "team-a"},
+					ActiveTag: "team-a",
+					TagStats:  &tagStats{Requests: 100, Verifies: 90},
+				},
+			},
+			selector: "a.tag-chip",
+			matches:  []string{"All", "marketing", "team-a"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123"},
+			template: portalTemplate,
+			model: &orgDashboardRenderContext{
+				Orgs:       []*userOrg{stubOrgEx("123", dbgen.AccessLevelOwner)},
+				CurrentOrg: stubOrgEx("123", dbgen.AccessLevelOwner),
+				onboardingRenderContext: onboardingRenderContext{
+					Onboarding: createOnboardingChecklist("123", []*dbgen.Property{}, nil, func(a ...string) string { return strings.Join(a, "/") }),
+				},
+			},
+			selector: "p.onboarding-progress",
+			matches:  []string{"1 of 4 steps completed"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.OnboardingEndpoint},
+			template: onboardingTemplate,
+			model: &onboardingRenderContext{
+				Onboarding: createOnboardingChecklist("123", []*dbgen.Property{{ID: 1}}, nil, func(a ...string) string { return strings.Join(a, "/") }),
+			},
+			selector: "div#onboarding[hx-trigger] p.onboarding-progress",
+			matches:  []string{"2 of 4 steps completed"},
+		},
 		// same as above, but when Invited, we don't show properties
 		{
 			path:     []string{common.OrgEndpoint, "123"},
@@ -139,6 +213,88 @@ func TestRenderHTML(t *testing.T) {
 				CanEdit:           true,
 			},
 		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.APIKeysEndpoint, common.NewEndpoint},
+			template: orgSettingsTemplate,
+			model: &orgSettingsRenderContext{
+				CurrentOrg:        stubOrg("123"),
+				CsrfRenderContext: stubToken(),
+				CanEdit:           true,
+				Tokens: []*userAPIKey{
+					{ID: "1", Name: "backend", ExpiresAt: "01 Jan 2030", RequestsPerMinute: 60},
+					{ID: "2", Name: "new token", Secret: "abcdef"},
+				},
+			},
+			selector: "p.org-token-name",
+			matches:  []string{"backend", "new token"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.BudgetEndpoint},
+			template: orgSettingsTemplate,
+			model: &orgSettingsRenderContext{
+				CurrentOrg:        stubOrg("123"),
+				CsrfRenderContext: stubToken(),
+				CanEdit:           true,
+				Budget:            100000,
+				BudgetError:       "Budget must be a non-negative number.",
+			},
+			selector: "p.pc-form-error-text",
+			matches:  []string{"Budget must be a non-negative number."},
+		},
+		{
+			path:     []string{common.EmailEndpoint, common.ConfirmEndpoint, "abcdef"},
+			template: emailConfirmTemplate,
+			model: &emailConfirmRenderContext{
+				AlertRenderContext: AlertRenderContext{SuccessMessage: "Confirmed"},
+				Email:              "foo@bar.com",
+				Confirmed:          true,
+			},
+			selector: "p#confirm-email strong",
+			matches:  []string{"foo@bar.com"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.EmailsEndpoint},
+			template: settingsEmailsFormTemplate,
+			model: &settingsGeneralRenderContext{
+				settingsEmailsRenderContext: settingsEmailsRenderContext{
+					Emails: []*secondaryEmail{
+						{ID: "1", Email: "billing@bar.com", Verified: true},
+						{ID: "2", Email: "pending@bar.com"},
+					},
+					BillingOrgs: []*orgBillingContact{{OrgID: "123", OrgName: "Foo", EmailID: "1"}},
+				},
+			},
+			selector: "p.secondary-email",
+			matches:  []string{"billing@bar.com", "pending@bar.com"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.SessionsEndpoint},
+			template: settingsSignInsTemplate,
+			model: &settingsGeneralRenderContext{
+				settingsSignInsRenderContext: settingsSignInsRenderContext{
+					SignIns: []*userSignIn{
+						{Time: "01 Jan 2025 10:00 UTC", Device: "Firefox on Linux", Current: true},
+						{Time: "01 Jan 2025 09:00 UTC", Device: "Chrome on macOS", Revoked: true},
+					},
+				},
+			},
+			selector: "span.signin-current",
+			matches:  []string{"This session"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.DeleteEndpoint},
+			template: orgDeleteTemplate,
+			model: &orgDeleteRenderContext{
+				CurrentOrg:        stubOrg("123"),
+				CsrfRenderContext: stubToken(),
+				Properties:        2,
+				Members:           1,
+				Requests:          1000,
+				Verifies:          900,
+			},
+			selector: "ul#org-delete-summary strong",
+			matches:  []string{"2", "1", "1000", "900"},
+		},
 		{
 			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, common.NewEndpoint},
 			template: propertyWizardTemplate,
@@ -154,6 +310,39 @@ func TestRenderHTML(t *testing.T) {
 				CanEdit:           true,
 			},
 		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.TabEndpoint, common.ReportsEndpoint},
+			template: propertyDashboardReportsTemplate,
+			model: &propertyDashboardRenderContext{
+				AlertRenderContext: AlertRenderContext{WarningMessage: "This property used 85% of its allocated quota of 1000 requests per week."},
+				CsrfRenderContext:  stubToken(),
+				Property:           stubProperty("Foo", "123"),
+				Org:                stubOrg("123"),
+				CanEdit:            true,
+			},
+			selector: "#property-quota-warning",
+			matches:  []string{"This property used 85% of its allocated quota of 1000 requests per week."},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.ShareEndpoint},
+			template: propertyShareTemplate,
+			model: &propertyShareRenderContext{
+				ShareURL:  "/share/1.2.3.abc",
+				ExpiresAt: "01 Jan 2030 00:00 UTC",
+			},
+			selector: "p time",
+			matches:  []string{"01 Jan 2030 00:00 UTC"},
+		},
+		{
+			path:     []string{common.ShareEndpoint, "1.2.3.abc"},
+			template: sharedReportTemplate,
+			model: &sharedReportRenderContext{
+				Property:   stubProperty("Foo", "123"),
+				ReportsURL: "/share/1.2.3.abc",
+			},
+			selector: "h2#shared-property-name",
+			matches:  []string{"Foo"},
+		},
 		// same as above, but property integrations _template_
 		{
 			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
@@ -165,7 +354,46 @@ func TestRenderHTML(t *testing.T) {
 					Org:               stubOrg("123"),
 					CanEdit:           true,
 				},
-				Sitekey: "qwerty",
+				Sitekey:      "qwerty",
+				WidgetScript: "widget/beta/js/privatecaptcha.js",
+			},
+		},
+		// same as above, but during sitekey rotation
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
+			template: propertyDashboardIntegrationsTemplate,
+			model: &propertyIntegrationsRenderContext{
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				Sitekey:                  "qwerty",
+				WidgetScript:             "widget/beta/js/privatecaptcha.js",
+				PreviousSitekey:          "asdfgh",
+				PreviousSitekeyExpiresAt: "01 Jan 2025 00:00 UTC",
+				PreviousSitekeyExpiresIn: "7 days",
+			},
+			selector: "dd#previous-sitekey",
+			matches:  []string{"asdfgh"},
+		},
+		// same as above, but property diagnostics _template_
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
+			template: propertyDashboardDiagnosticsTemplate,
+			model: &propertyDiagnosticsRenderContext{
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				Checks: []*diagnosticCheck{
+					{Name: "DNS resolution", Status: diagnosticOK, Details: "example.com resolves"},
+					{Name: "Sitekey cache", Status: diagnosticWarning, Details: "Stale", Advice: "Wait"},
+					{Name: "Sample puzzle", Status: diagnosticError, Details: "Failed"},
+				},
 			},
 		},
 		// same as above, but property settings _template_
@@ -185,6 +413,146 @@ func TestRenderHTML(t *testing.T) {
 				},
 			},
 		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				OriginsError: "Test",
+			},
+			selector: "p.pc-form-error-text",
+			matches:  []string{"Test"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          &userProperty{ID: "456", OrgID: "123", Name: "Foo", Domain: "example.com", AllowedAppIDs: []string{"com.example.app"}},
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+			},
+			selector: "textarea#" + common.ParamAllowedApps,
+			matches:  []string{"com.example.app"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.PauseEndpoint},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          &userProperty{ID: "456", OrgID: "123", Name: "Foo", Domain: "example.com", Paused: true},
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+			},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.MembersEndpoint},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				Members: []*propertyMemberAccess{
+					{ID: "1", Name: "Alice", Level: string(dbgen.PropertyAccessLevelEdit)},
+					{ID: "2", Name: "Bob"},
+				},
+				AccessUpdated: true,
+			},
+			selector: "#property-member-access p.member-name",
+			matches:  []string{"Alice", "Bob"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.DomainEndpoint},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				DomainRecordName:  "_privatecaptcha-challenge.example.com",
+				DomainRecordValue: "privatecaptcha-verification=0123456789abcdef",
+				DomainError:       "verification TXT record was not found",
+				DomainRequired:    true,
+			},
+			selector: "#property-domain-verification dd.domain-record-name",
+			matches:  []string{"_privatecaptcha-challenge.example.com"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				History: []*userPropertyEvent{
+					{Time: "01 Jan 2025 10:00 UTC", Actor: "Alice", Setting: "Difficulty", OldValue: "80", NewValue: "100"},
+					{Time: "01 Jan 2025 09:00 UTC", Actor: "System", Setting: "Domain verified", OldValue: "off", NewValue: "on"},
+				},
+			},
+			selector: "#property-history span.property-event-actor",
+			matches:  []string{"Alice", "System"},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.QuotaEndpoint},
+			template: propertyDashboardSettingsTemplate,
+			model: &propertySettingsRenderContext{
+				difficultyLevelsRenderContext: createDifficultyLevelsRenderContext(),
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				CanAllocateQuota: true,
+				QuotaPercent:     10,
+				QuotaPeriod:      string(dbgen.QuotaPeriodWeek),
+				QuotaError:       "Only 5% of your quota is not allocated to other properties.",
+			},
+			selector: "#property-quota p.pc-form-error-text",
+			matches:  []string{"Only 5% of your quota is not allocated to other properties."},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.MessagesEndpoint},
+			template: propertyMessagesFormTemplate,
+			model: &propertySettingsRenderContext{
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				Messages: userPropertyMessages{
+					BlockedMessage: "Blocked",
+					RedirectURL:    "https://example.com/support",
+				},
+				RedirectURLError: "Test",
+			},
+			selector: "p.pc-form-error-text",
+			matches:  []string{"Test"},
+		},
 		{
 			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint},
 			template: settingsGeneralTemplatePrefix + "page.html",
@@ -201,6 +569,45 @@ func TestRenderHTML(t *testing.T) {
 				Name: "User",
 			},
 		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.SecurityEndpoint},
+			template: settingsSecurityFormTemplate,
+			model: &settingsGeneralRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.GeneralEndpoint,
+				},
+				ReverifyNewLogins: true,
+			},
+			selector: "input[checked]",
+			matches:  []string{""},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint},
+			template: settingsGeneralFormTemplate,
+			model: &settingsGeneralRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.GeneralEndpoint,
+				},
+				Name:           "User",
+				Timezone:       "Europe/Berlin",
+				Timezones:      timezoneChoices("Europe/Berlin"),
+				UITheme:        string(dbgen.UiThemeDark),
+				UIThemes:       uiThemes,
+				TableDensity:   string(dbgen.TableDensityCompact),
+				TableDensities: tableDensities,
+				DefaultOrg:     "123",
+				Orgs: []*userOrg{
+					{Name: "Foo", ID: "123", Level: string(dbgen.AccessLevelOwner)},
+					{Name: "Bar", ID: "456", Level: string(dbgen.AccessLevelInvited)},
+				},
+			},
+			selector: "option[selected]",
+			matches:  []string{"Europe/Berlin", "dark", "compact", "Foo"},
+		},
 		{
 			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.APIKeysEndpoint},
 			template: settingsAPIKeysTemplatePrefix + "page.html",
@@ -220,6 +627,117 @@ func TestRenderHTML(t *testing.T) {
 			selector: "p.apikey-name",
 			matches:  []string{"foo", "bar"},
 		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.APIKeysEndpoint},
+			template: settingsAPIKeysTemplatePrefix + "page.html",
+			model: &settingsAPIKeysRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.APIKeysEndpoint,
+					Tabs:              CreateTabViewModels(common.APIKeysEndpoint, server.SettingsTabs),
+				},
+				Keys: []*userAPIKey{
+					{ID: "1", Name: "old", ExpiresAt: "01 Jan 2030", RotationDays: 90, RetiresAt: "08 Jan 2029"},
+					{ID: "2", Name: "new", ExpiresAt: "01 Jul 2030", RotationDays: 90, NextRotation: "01 Apr 2029",
+						Secret: "secret", ReplacementUntil: "08 Jan 2029"},
+				},
+			},
+			selector: "time",
+			matches:  []string{"01 Jan 2030", "08 Jan 2029", "08 Jan 2029"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.APIKeysEndpoint},
+			template: settingsAPIKeysTemplatePrefix + "page.html",
+			model: &settingsAPIKeysRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.APIKeysEndpoint,
+					Tabs:              CreateTabViewModels(common.APIKeysEndpoint, server.SettingsTabs),
+				},
+				Keys: []*userAPIKey{
+					stubAPIKey("foo"),
+					{ID: "2", Name: "leaked", ExpiresAt: "01 Jan 2030", Disabled: true, Suspended: true},
+				},
+			},
+			selector: "a[hx-put$='/resume'] span.sr-only",
+			matches:  []string{", API key"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.LicenseEndpoint},
+			template: settingsLicenseTemplatePrefix + "page.html",
+			model: &settingsLicenseRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.LicenseEndpoint,
+					Tabs:              CreateTabViewModels(common.LicenseEndpoint, server.SettingsTabs),
+				},
+				ID:        "lic_123",
+				Customer:  "ACME",
+				Plan:      "Enterprise",
+				IssuedAt:  "01 Jan 2025",
+				ExpiresAt: "01 Jan 2026",
+				MaxNodes:  3,
+				Nodes:     2,
+			},
+			selector: "dd.license-field",
+			matches:  []string{"ACME", "Enterprise", "lic_123", "01 Jan 2025", "01 Jan 2026", "2 of 3"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.SupportEndpoint},
+			template: settingsSupportTemplatePrefix + "page.html",
+			model: &settingsSupportRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.SupportEndpoint,
+					Tabs:              CreateTabViewModels(common.SupportEndpoint, server.SettingsTabs),
+				},
+				Tickets: []*userSupportTicket{
+					{TicketID: "abc", Subject: "Help", Message: "Nothing works", Status: string(dbgen.SupportTicketStatusAnswered), CreatedAt: "01 Jan 2030 10:00 UTC",
+						Replies: []*supportTicketReply{{FromSupport: true, Message: "Try again", CreatedAt: "01 Jan 2030 11:00 UTC"}}},
+					{TicketID: "def", Subject: "Billing", Message: "Invoice", Status: string(dbgen.SupportTicketStatusOpen), CreatedAt: "02 Jan 2030 10:00 UTC"},
+				},
+			},
+			selector: "p.ticket-message",
+			matches:  []string{"Nothing works", "Try again", "Invoice"},
+		},
+		{
+			path:     []string{common.SearchEndpoint},
+			template: searchResultsTemplate,
+			model: &searchRenderContext{
+				Term:       "foo",
+				Orgs:       []*searchOrg{{ID: "123", Name: "foo org"}},
+				Properties: []*searchProperty{{ID: "456", OrgID: "123", Name: "foo", Domain: "example.com", OrgName: "foo org"}},
+				APIKeys:    []*searchAPIKey{{Name: "foo key"}},
+			},
+			selector: "span.search-result-name",
+			matches:  []string{"foo org", "foo", "foo key"},
+		},
+		{
+			path:     []string{common.NotificationsEndpoint},
+			template: notificationsPanelTemplate,
+			model: &notificationsRenderContext{
+				CsrfRenderContext: stubToken(),
+				Notifications: []*userNotification{
+					{ID: "1", Category: string(dbgen.NotificationCategorySecurity), Message: "New <strong>sign-in</strong>", CreatedAt: "01 Jan 2030", Unread: true},
+					{ID: "2", Category: string(dbgen.NotificationCategoryUsage), Message: "Budget", CreatedAt: "01 Jan 2030"},
+				},
+				Unread:    1,
+				SwapBadge: true,
+			},
+			selector: "span.notification-category",
+			matches:  []string{"security", "usage"},
+		},
+		{
+			path:     []string{common.NotificationsEndpoint, common.CountEndpoint},
+			template: notificationsBadgeTemplate,
+			model:    &notificationsRenderContext{Unread: 12},
+			selector: "span#notifications-badge > span",
+			matches:  []string{"9+", "unread notifications"},
+		},
 		{
 			path: []string{common.SettingsEndpoint, common.TabEndpoint, common.UsageEndpoint},
 			// NOTE: we use "tab" here instead of "page" because of <script> text and JS that breaks XML parser
@@ -239,6 +757,26 @@ func TestRenderHTML(t *testing.T) {
 			selector: "",
 			matches:  []string{},
 		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.UsageEndpoint},
+			template: settingsUsageTemplatePrefix + "tab.html",
+			model: &settingsUsageRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.UsageEndpoint,
+					Tabs:              CreateTabViewModels(common.UsageEndpoint, server.SettingsTabs),
+				},
+				Limit:           12345,
+				HasPlan:         true,
+				Properties:      3,
+				PropertiesLimit: 50,
+				Orgs:            1,
+				OrgMembersLimit: 10,
+			},
+			selector: "dl dd",
+			matches:  []string{"3 of 50", "1 (unlimited)", "Up to 10"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,122 @@
+package portal
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	searchResultsTemplate = "search/results.html"
+	minSearchTermLength   = 2
+	maxSearchTermLength   = 100
+	maxSearchResults      = 5
+)
+
+type searchProperty struct {
+	ID      string
+	OrgID   string
+	Name    string
+	Domain  string
+	OrgName string
+}
+
+type searchOrg struct {
+	ID   string
+	Name string
+}
+
+type searchAPIKey struct {
+	Name string
+}
+
+type searchRenderContext struct {
+	Term       string
+	Properties []*searchProperty
+	Orgs       []*searchOrg
+	APIKeys    []*searchAPIKey
+}
+
+func (rc *searchRenderContext) Empty() bool {
+	return len(rc.Properties) == 0 && len(rc.Orgs) == 0 && len(rc.APIKeys) == 0
+}
+
+func searchPropertiesToRender(properties []*dbgen.SearchUserPropertiesRow) []*searchProperty {
+	result := make([]*searchProperty, 0, len(properties))
+	for _, p := range properties {
+		result = append(result, &searchProperty{
+			ID:      strconv.Itoa(int(p.ID)),
+			OrgID:   strconv.Itoa(int(p.OrgID.Int32)),
+			Name:    p.Name,
+			Domain:  p.Domain,
+			OrgName: p.OrgName,
+		})
+	}
+	return result
+}
+
+func searchOrgsToRender(orgs []*dbgen.SearchUserOrganizationsRow) []*searchOrg {
+	result := make([]*searchOrg, 0, len(orgs))
+	for _, o := range orgs {
+		result = append(result, &searchOrg{
+			ID:   strconv.Itoa(int(o.ID)),
+			Name: o.Name,
+		})
+	}
+	return result
+}
+
+func searchAPIKeysToRender(keys []*dbgen.APIKey) []*searchAPIKey {
+	result := make([]*searchAPIKey, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, &searchAPIKey{Name: k.Name})
+	}
+	return result
+}
+
+func (s *Server) getSearch(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	term := strings.TrimSpace(r.URL.Query().Get(common.ParamQuery))
+	renderCtx := &searchRenderContext{
+		Term:       term,
+		Properties: []*searchProperty{},
+		Orgs:       []*searchOrg{},
+		APIKeys:    []*searchAPIKey{},
+	}
+
+	if termLength := utf8.RuneCountInString(term); (termLength < minSearchTermLength) || (termLength > maxSearchTermLength) {
+		return renderCtx, searchResultsTemplate, nil
+	}
+
+	impl := s.Store.Impl()
+
+	orgs, err := impl.SearchUserOrganizations(ctx, user.ID, term, maxSearchResults)
+	if err != nil {
+		return nil, "", err
+	}
+	renderCtx.Orgs = searchOrgsToRender(orgs)
+
+	properties, err := impl.SearchUserProperties(ctx, user.ID, term, maxSearchResults)
+	if err != nil {
+		return nil, "", err
+	}
+	renderCtx.Properties = searchPropertiesToRender(properties)
+
+	keys, err := impl.SearchUserAPIKeys(ctx, user.ID, term, maxSearchResults)
+	if err != nil {
+		return nil, "", err
+	}
+	renderCtx.APIKeys = searchAPIKeysToRender(keys)
+
+	return renderCtx, searchResultsTemplate, nil
+}
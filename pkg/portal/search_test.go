@@ -0,0 +1,117 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestSearchOnlyOwnProperties(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	_, org1, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_1", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create owner account: %v", err)
+	}
+
+	_, err = server.Store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       "searchable_property",
+		OrgID:      db.Int(org1.ID),
+		CreatorID:  org1.UserID,
+		OrgOwnerID: org1.UserID,
+		Domain:     "searchable.example.com",
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create new property: %v", err)
+	}
+
+	user2, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_2", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create another account: %v", err)
+	}
+
+	owned, err := server.Store.Impl().SearchUserProperties(ctx, org1.UserID.Int32, "searchable", maxSearchResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) != 1 {
+		t.Errorf("Unexpected number of owner search results: %v", len(owned))
+	}
+
+	foreign, err := server.Store.Impl().SearchUserProperties(ctx, user2.ID, "searchable", maxSearchResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foreign) != 0 {
+		t.Errorf("Search returned properties of another user: %v", len(foreign))
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user2.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/"+common.SearchEndpoint+"?"+url.Values{common.ParamQuery: {"searchable"}}.Encode(), nil)
+	req.AddCookie(cookie)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %v", w.Code)
+	}
+}
+
+func TestSearchSkipsInvitedOrganizations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	_, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_1", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create owner account: %v", err)
+	}
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_2", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create another account: %v", err)
+	}
+
+	if err := server.Store.Impl().InviteUserToOrg(ctx, org.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	invited, err := server.Store.Impl().SearchUserOrganizations(ctx, user.ID, org.Name, maxSearchResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invited) != 0 {
+		t.Errorf("Search returned organization with pending invite: %v", len(invited))
+	}
+
+	owned, err := server.Store.Impl().SearchUserOrganizations(ctx, org.UserID.Int32, org.Name, maxSearchResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) == 0 {
+		t.Errorf("Search did not return owned organization")
+	}
+}
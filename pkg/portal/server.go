@@ -17,11 +17,19 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/origins"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
 
+const (
+	defaultPrivateTimeout = 10 * time.Second
+	defaultPublicTimeout  = 2 * time.Second
+	defaultMaxBodyBytes   = 256 * 1024
+)
+
 var (
 	errInvalidPathArg      = errors.New("path argument is not valid")
 	ErrInvalidRequestArg   = errors.New("request argument is not valid")
@@ -52,6 +60,11 @@ type RequestContext struct {
 	UserName    string
 	UserEmail   string
 	CDN         string
+	// nonce for inline scripts, see common.SecurityPolicy
+	CSPNonce string
+	// UI preferences of the logged in user
+	Theme        string
+	TableDensity string
 }
 
 type CsrfRenderContext struct {
@@ -105,15 +118,40 @@ type Server struct {
 	Metrics         common.PortalMetrics
 	maintenanceMode atomic.Bool
 	canRegister     atomic.Bool
+	// targeted maintenance: portal does not accept changes, billing routes are not available
+	readOnlyMode       atomic.Bool
+	billingMaintenance atomic.Bool
+	// sign in throttling per email and per IP, endpoints have separate budgets
+	loginThrottle     *loginThrottle
+	twoFactorThrottle *loginThrottle
+	// reloadable limits of private, public and body size, defaults are used until config is loaded
+	privateTimeout common.RouteLimit
+	publicTimeout  common.RouteLimit
+	maxBodyBytes   common.RouteLimit
+	// limit of concurrent sessions per user, older sessions are signed out on sign in (0 means unlimited)
+	maxUserSessions atomic.Int64
+	// shared secret of the provisioning API, empty value disables it
+	provisioningKey atomic.Pointer[string]
+	// shared secret of the inbound email webhook (replies to support tickets), empty value disables it
+	supportInboundKey atomic.Pointer[string]
+	// regions of time-series data (ClickHouse clusters) that properties can be assigned to
+	dataRegions     atomic.Pointer[map[string]string]
 	SettingsTabs    []*SettingsTab
 	Auth            *AuthMiddleware
 	RenderConstants interface{}
 	Jobs            Jobs
 	PlatformCtx     interface{}
+	// header with ISO country code of the client, set by reverse proxy (e.g. CF-IPCountry)
+	CountryHeader string
+	// properties serve puzzles only after their domain was verified (nil resolver means the default one)
+	VerifiedDomains bool
+	DNSResolver     origins.TXTResolver
+	// set only for licensed (enterprise) installations
+	License *license.License
 }
 
 func (s *Server) createSettingsTabs() []*SettingsTab {
-	return []*SettingsTab{
+	tabs := []*SettingsTab{
 		{
 			ID:             common.GeneralEndpoint,
 			Name:           "General",
@@ -133,6 +171,27 @@ func (s *Server) createSettingsTabs() []*SettingsTab {
 			ModelHandler:   s.getUsageSettings,
 		},
 	}
+
+	// support requests are sent from the enterprise portal
+	if s.isEnterprise() {
+		tabs = append(tabs, &SettingsTab{
+			ID:             common.SupportEndpoint,
+			Name:           "Support",
+			TemplatePrefix: settingsSupportTemplatePrefix,
+			ModelHandler:   s.getSupportSettings,
+		})
+	}
+
+	if s.License != nil {
+		tabs = append(tabs, &SettingsTab{
+			ID:             common.LicenseEndpoint,
+			Name:           "License",
+			TemplatePrefix: settingsLicenseTemplatePrefix,
+			ModelHandler:   s.getLicenseSettings,
+		})
+	}
+
+	return tabs
 }
 
 func (s *Server) Init(ctx context.Context, templateBuilder *TemplatesBuilder) error {
@@ -151,6 +210,8 @@ func (s *Server) Init(ctx context.Context, templateBuilder *TemplatesBuilder) er
 	s.Jobs = s
 	s.SettingsTabs = s.createSettingsTabs()
 	s.RenderConstants = NewRenderConstants()
+	s.loginThrottle = newLoginThrottle(loginThrottleLogin)
+	s.twoFactorThrottle = newLoginThrottle(loginThrottleTwoFactor)
 	s.PlatformCtx = &PlatformRenderContext{
 		Enterprise: s.isEnterprise(),
 	}
@@ -159,15 +220,53 @@ func (s *Server) Init(ctx context.Context, templateBuilder *TemplatesBuilder) er
 }
 
 func (s *Server) UpdateConfig(ctx context.Context, cfg common.ConfigStore) {
-	maintenanceMode := config.AsBool(cfg.Get(common.MaintenanceModeKey))
+	modes := config.AsMaintenanceModes(cfg)
+	maintenanceMode := modes.All
 	oldMaintenanceMode := s.maintenanceMode.Swap(maintenanceMode)
+	oldReadOnlyMode := s.readOnlyMode.Swap(modes.Portal)
+	s.billingMaintenance.Store(modes.Billing)
 
 	registrationAllowed := config.AsBool(cfg.Get(common.RegistrationAllowedKey))
 	s.canRegister.Store(registrationAllowed)
 
+	s.privateTimeout.Store(int64(config.AsDuration(cfg.Get(common.PortalTimeoutKey), defaultPrivateTimeout)))
+	s.publicTimeout.Store(int64(config.AsDuration(cfg.Get(common.PortalPublicTimeoutKey), defaultPublicTimeout)))
+	s.maxBodyBytes.Store(int64(config.AsInt(cfg.Get(common.PortalMaxBytesKey), defaultMaxBodyBytes)))
+	s.maxUserSessions.Store(int64(config.AsInt(cfg.Get(common.PortalMaxSessionsKey), 0)))
+
+	provisioningKey := cfg.Get(common.ProvisioningAPIKeyKey).Value()
+	s.provisioningKey.Store(&provisioningKey)
+
+	supportInboundKey := cfg.Get(common.SupportInboundKeyKey).Value()
+	s.supportInboundKey.Store(&supportInboundKey)
+
+	if regions, err := config.ParseDataRegions(cfg.Get(common.ClickHouseRegionsKey).Value()); err == nil {
+		s.dataRegions.Store(&regions)
+	} else {
+		slog.ErrorContext(ctx, "Failed to parse data regions", common.ErrAttr(err))
+	}
+
 	if oldMaintenanceMode != maintenanceMode {
 		slog.InfoContext(ctx, "Maintenance mode change", "old", oldMaintenanceMode, "new", maintenanceMode)
 	}
+
+	if oldReadOnlyMode != modes.Portal {
+		slog.InfoContext(ctx, "Portal read-only mode change", "old", oldReadOnlyMode, "new", modes.Portal)
+	}
+}
+
+// isDataRegionAvailable checks if properties can be assigned to the region (empty region is the primary cluster)
+func (s *Server) isDataRegionAvailable(region string) bool {
+	if len(region) == 0 {
+		return true
+	}
+
+	if regions := s.dataRegions.Load(); regions != nil {
+		_, ok := (*regions)[region]
+		return ok
+	}
+
+	return false
 }
 
 func (s *Server) Setup(router *http.ServeMux, domain string, security alice.Constructor) *RouteGenerator {
@@ -192,8 +291,16 @@ func (s *Server) PartsURL(a ...string) string {
 	return s.RelURL(strings.Join(a, "/"))
 }
 
-func defaultMaxBytesHandler(next http.Handler) http.Handler {
-	return http.MaxBytesHandler(next, 256*1024)
+func (s *Server) maxBytesHandler(next http.Handler) http.Handler {
+	return common.ConfiguredMaxBytesHandler(&s.maxBodyBytes, defaultMaxBodyBytes)(next)
+}
+
+func (s *Server) privateTimeoutHandler(next http.Handler) http.Handler {
+	return common.ConfiguredTimeoutHandler(&s.privateTimeout, defaultPrivateTimeout)(next)
+}
+
+func (s *Server) publicTimeoutHandler(next http.Handler) http.Handler {
+	return common.ConfiguredTimeoutHandler(&s.publicTimeout, defaultPublicTimeout)(next)
 }
 
 func (s *Server) MiddlewarePublicChain(rg *RouteGenerator, security alice.Constructor) alice.Chain {
@@ -201,13 +308,11 @@ func (s *Server) MiddlewarePublicChain(rg *RouteGenerator, security alice.Constr
 }
 
 func (s *Server) MiddlewarePrivateRead(public alice.Chain) alice.Chain {
-	internalTimeout := common.TimeoutHandler(10 * time.Second)
-	return public.Append(s.maintenance, internalTimeout, s.private)
+	return public.Append(s.maintenance, s.privateTimeoutHandler, s.private)
 }
 
 func (s *Server) MiddlewarePrivateWrite(public alice.Chain) alice.Chain {
-	internalTimeout := common.TimeoutHandler(10 * time.Second)
-	return public.Append(s.maintenance, defaultMaxBytesHandler, internalTimeout, s.csrf(s.csrfUserIDKeyFunc), s.private)
+	return public.Append(s.maintenance, s.maxBytesHandler, s.privateTimeoutHandler, s.csrf(s.csrfUserIDKeyFunc), s.private)
 }
 
 func (s *Server) setupWithPrefix(router *http.ServeMux, rg *RouteGenerator, security alice.Constructor) {
@@ -219,17 +324,23 @@ func (s *Server) setupWithPrefix(router *http.ServeMux, rg *RouteGenerator, secu
 
 	// separately configured "public" ones
 	public := s.MiddlewarePublicChain(rg, security)
-	publicTimeout := common.TimeoutHandler(2 * time.Second)
-	openRead := public.Append(s.maintenance, publicTimeout)
+	openRead := public.Append(s.maintenance, s.publicTimeoutHandler)
 	router.Handle(rg.Get(common.LoginEndpoint), openRead.Then(common.Cached(s.Handler(s.getLogin))))
 	router.Handle(rg.Get(common.RegisterEndpoint), openRead.Then(common.Cached(s.Handler(s.getRegister))))
 	router.Handle(rg.Get(common.TwoFactorEndpoint), openRead.ThenFunc(s.getTwoFactor))
+	router.Handle(rg.Get(common.LoginEndpoint, common.MagicLinkEndpoint, arg(common.ParamToken)), openRead.ThenFunc(s.getMagicLink))
 	router.Handle(rg.Get(common.ErrorEndpoint, arg(common.ParamCode)), public.ThenFunc(s.error))
+	router.Handle(rg.Get(common.EmailEndpoint, common.RevertEndpoint, arg(common.ParamToken)), openRead.Then(s.Handler(s.getEmailRevert)))
+	router.Handle(rg.Get(common.EmailEndpoint, common.ConfirmEndpoint, arg(common.ParamToken)), openRead.Then(s.Handler(s.getEmailConfirm)))
 	router.Handle(rg.Get(common.ExpiredEndpoint), public.ThenFunc(s.expired))
 	router.Handle(rg.Get(common.LogoutEndpoint), public.ThenFunc(s.logout))
+	router.Handle(rg.Get(common.ShareEndpoint, arg(common.ParamToken)), openRead.ThenFunc(s.getSharedReport))
+	router.Handle(rg.Get(common.ShareEndpoint, arg(common.ParamToken), common.StatsEndpoint, arg(common.ParamPeriod)), openRead.ThenFunc(s.getSharedReportStats))
+	router.Handle(rg.Get(common.ShareEndpoint, arg(common.ParamToken), common.FailuresEndpoint, arg(common.ParamPeriod)), openRead.ThenFunc(s.getSharedReportFailures))
+	router.Handle(rg.Get(common.ShareEndpoint, arg(common.ParamToken), common.FingerprintsEndpoint, arg(common.ParamPeriod)), openRead.ThenFunc(s.getSharedReportFingerprints))
 
 	// openWrite is protected by captcha, other "write" handlers are protected by CSRF token / auth
-	openWrite := public.Append(s.maintenance, defaultMaxBytesHandler, publicTimeout)
+	openWrite := public.Append(s.maintenance, s.maxBytesHandler, s.publicTimeoutHandler)
 	csrfEmail := openWrite.Append(s.csrf(s.csrfUserEmailKeyFunc))
 	privateWrite := s.MiddlewarePrivateWrite(public)
 	privateRead := s.MiddlewarePrivateRead(public)
@@ -238,36 +349,82 @@ func (s *Server) setupWithPrefix(router *http.ServeMux, rg *RouteGenerator, secu
 	router.Handle(rg.Post(common.RegisterEndpoint), openWrite.ThenFunc(s.postRegister))
 	router.Handle(rg.Post(common.TwoFactorEndpoint), csrfEmail.ThenFunc(s.postTwoFactor))
 	router.Handle(rg.Post(common.ResendEndpoint), csrfEmail.ThenFunc(s.resend2fa))
+	router.Handle(rg.Post(common.MagicLinkEndpoint), csrfEmail.ThenFunc(s.sendMagicLink))
+	router.Handle(rg.Post(common.EmailEndpoint, common.RevertEndpoint, arg(common.ParamToken)), openWrite.Then(s.Handler(s.postEmailRevert)))
+	router.Handle(rg.Post(common.EmailEndpoint, common.ConfirmEndpoint, arg(common.ParamToken)), openWrite.Then(s.Handler(s.postEmailConfirm)))
 	router.Handle(rg.Get(common.OrgEndpoint, common.NewEndpoint), privateRead.Then(s.Handler(s.getNewOrg)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg)), privateRead.ThenFunc(s.getPortal))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.DashboardEndpoint), privateRead.Then(s.Handler(s.getOrgDashboard)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.MembersEndpoint), privateRead.Then(s.Handler(s.getOrgMembers)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.SettingsEndpoint), privateRead.Then(s.Handler(s.getOrgSettings)))
+	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.OnboardingEndpoint), privateRead.Then(s.Handler(s.getOnboarding)))
 	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.EditEndpoint), privateWrite.Then(s.Handler(s.putOrg)))
+	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.BudgetEndpoint), privateWrite.Then(s.Handler(s.putOrgBudget)))
+	router.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.APIKeysEndpoint, common.NewEndpoint), privateWrite.Then(s.Handler(s.postOrgAPIKey)))
+	router.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.APIKeysEndpoint, arg(common.ParamKey)), privateWrite.ThenFunc(s.deleteOrgAPIKey))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, common.NewEndpoint), privateRead.Then(s.Handler(s.getNewOrgProperty)))
 	router.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, common.NewEndpoint), privateWrite.ThenFunc(s.postNewOrgProperty))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty)), privateRead.Then(s.Handler(s.getPropertyDashboard)))
 	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.EditEndpoint), privateWrite.Then(s.Handler(s.putProperty)))
 	router.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.DeleteEndpoint), privateWrite.ThenFunc(s.deleteProperty))
+	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.MessagesEndpoint), privateWrite.Then(s.Handler(s.putPropertyMessages)))
+	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.PauseEndpoint), privateWrite.Then(s.Handler(s.putPropertyPaused)))
+	router.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.SitekeyEndpoint), privateWrite.Then(s.Handler(s.postPropertySitekey)))
+	router.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.SitekeyEndpoint), privateWrite.Then(s.Handler(s.deletePropertyPreviousSitekey)))
+	router.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ShareEndpoint), privateWrite.Then(s.Handler(s.postPropertyShare)))
+	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.QuotaEndpoint), privateWrite.Then(s.Handler(s.putPropertyQuota)))
+	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.DomainEndpoint), privateWrite.Then(s.Handler(s.putPropertyDomain)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.ReportsEndpoint), privateRead.Then(s.Handler(s.getPropertyReportsTab)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.SettingsEndpoint), privateRead.Then(s.Handler(s.getPropertySettingsTab)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.IntegrationsEndpoint), privateRead.Then(s.Handler(s.getPropertyIntegrationsTab)))
+	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.DiagnosticsEndpoint), privateRead.Then(s.Handler(s.getPropertyDiagnosticsTab)))
 	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.StatsEndpoint, arg(common.ParamPeriod)), privateRead.ThenFunc(s.getPropertyStats))
+	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.FailuresEndpoint, arg(common.ParamPeriod)), privateRead.ThenFunc(s.getPropertyFailures))
+	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.FingerprintsEndpoint, arg(common.ParamPeriod)), privateRead.ThenFunc(s.getPropertyFingerprints))
+	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ActionsEndpoint, arg(common.ParamPeriod)), privateRead.ThenFunc(s.getPropertyActions))
 
 	router.Handle(rg.Get(common.SettingsEndpoint), privateRead.Then(s.Handler(s.getSettings)))
 	router.Handle(rg.Get(common.SettingsEndpoint, common.TabEndpoint, arg(common.ParamTab)), privateRead.Then(s.Handler(s.getSettingsTab)))
 	router.Handle(rg.Post(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.EmailEndpoint), privateWrite.Then(s.Handler(s.editEmail)))
 	router.Handle(rg.Put(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint), privateWrite.Then(s.Handler(s.putGeneralSettings)))
+	router.Handle(rg.Put(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.SecurityEndpoint), privateWrite.Then(s.Handler(s.putSecuritySettings)))
+	router.Handle(rg.Post(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.EmailsEndpoint), privateWrite.Then(s.Handler(s.postUserEmail)))
+	router.Handle(rg.Delete(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.EmailsEndpoint, arg(common.ParamID)), privateWrite.Then(s.Handler(s.deleteUserEmail)))
+	router.Handle(rg.Put(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.BillingEndpoint), privateWrite.Append(s.billing).Then(s.Handler(s.putOrgBillingContact)))
+	router.Handle(rg.Delete(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.SessionsEndpoint), privateWrite.Then(s.Handler(s.deleteOtherSessions)))
 	router.Handle(rg.Post(common.SettingsEndpoint, common.TabEndpoint, common.APIKeysEndpoint, common.NewEndpoint), privateWrite.Then(s.Handler(s.postAPIKeySettings)))
 
 	router.Handle(rg.Get(common.UserEndpoint, common.StatsEndpoint), privateRead.ThenFunc(s.getAccountStats))
 	router.Handle(rg.Delete(common.APIKeysEndpoint, arg(common.ParamKey)), privateWrite.ThenFunc(s.deleteAPIKey))
+	router.Handle(rg.Put(common.APIKeysEndpoint, arg(common.ParamKey), common.ResumeEndpoint), privateWrite.ThenFunc(s.putAPIKeyResume))
 	router.Handle(rg.Delete(common.UserEndpoint), privateWrite.ThenFunc(s.deleteAccount))
+	router.Handle(rg.Delete(common.OnboardingEndpoint), privateWrite.ThenFunc(s.deleteOnboarding))
 	router.Handle(rg.Delete(common.NotificationEndpoint, arg(common.ParamID)), openWrite.Append(s.private).ThenFunc(s.dismissNotification))
+	router.Handle(rg.Get(common.NotificationsEndpoint), privateRead.Then(s.Handler(s.getUserNotifications)))
+	router.Handle(rg.Get(common.NotificationsEndpoint, common.CountEndpoint), privateRead.Then(s.Handler(s.getUserNotificationsCount)))
+	router.Handle(rg.Post(common.NotificationsEndpoint, common.ReadEndpoint), privateWrite.Then(s.Handler(s.postUserNotificationsRead)))
+	router.Handle(rg.Post(common.NotificationsEndpoint, arg(common.ParamID), common.ReadEndpoint), privateWrite.Then(s.Handler(s.postUserNotificationRead)))
+	router.Handle(rg.Delete(common.NotificationsEndpoint, arg(common.ParamID)), privateWrite.Then(s.Handler(s.deleteUserNotification)))
 	router.Handle(rg.Post(common.ErrorEndpoint), privateRead.ThenFunc(s.postClientSideError))
 	router.Handle(rg.Get(common.EchoPuzzleEndpoint, arg(common.ParamDifficulty)), privateRead.ThenFunc(s.echoPuzzle))
-
-	s.setupEnterprise(router, rg, privateWrite)
+	router.Handle(rg.Get(common.SearchEndpoint), privateRead.Then(s.Handler(s.getSearch)))
+
+	// JSON API authenticated by session or personal access token
+	apiRead := s.MiddlewareAPIRead(public)
+	router.Handle(rg.Get(common.APIEndpoint, common.V1Endpoint, common.OrgEndpoint), apiRead.ThenFunc(s.getAPIOrgs))
+	router.Handle(rg.Get(common.APIEndpoint, common.V1Endpoint, common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint), apiRead.ThenFunc(s.getAPIOrgProperties))
+	apiWrite := s.MiddlewareAPIWrite(public)
+	router.Handle(rg.Put(common.APIEndpoint, common.V1Endpoint, common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint), apiWrite.ThenFunc(s.putAPIOrgProperty))
+	router.Handle(rg.Get(common.APIEndpoint, common.V1Endpoint, common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.StatsEndpoint, arg(common.ParamPeriod)), apiRead.ThenFunc(s.getAPIPropertyStats))
+	router.Handle(rg.Get(common.APIEndpoint, common.V1Endpoint, common.UsageEndpoint), apiRead.ThenFunc(s.getAPIUsage))
+	// server-to-server API for resellers, authenticated by the shared provisioning key
+	provisioning := public.Append(s.maintenance, s.billing, s.maxBytesHandler, s.privateTimeoutHandler, s.provisioningAuth)
+	router.Handle(rg.Post(common.APIEndpoint, common.V1Endpoint, common.ProvisionEndpoint), provisioning.ThenFunc(s.postProvisionAccount))
+	// inbound email webhook of the mail provider, authenticated by the shared key
+	supportInbound := public.Append(s.maintenance, s.maxBytesHandler, s.privateTimeoutHandler, s.supportInboundAuth)
+	router.Handle(rg.Post(common.APIEndpoint, common.V1Endpoint, common.SupportEndpoint, common.InboundEndpoint), supportInbound.ThenFunc(s.postSupportInbound))
+
+	s.setupEnterprise(router, rg, privateRead, privateWrite)
 
 	// {$} matches the end of the URL
 	router.Handle(http.MethodGet+" "+rg.Prefix+"{$}", privateRead.ThenFunc(s.getPortal))
@@ -341,6 +498,29 @@ func (s *Server) maintenance(next http.Handler) http.Handler {
 			return
 		}
 
+		if s.readOnlyMode.Load() && !isReadOnlyMethod(r.Method) {
+			slog.Log(r.Context(), common.LevelTrace, "Rejecting write request under read-only mode", "method", r.Method)
+			s.RedirectError(http.StatusServiceUnavailable, w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isReadOnlyMethod(method string) bool {
+	return (method == http.MethodGet) || (method == http.MethodHead) || (method == http.MethodOptions)
+}
+
+// billing protects routes that change billing data, so that they can be disabled separately from the rest of portal
+func (s *Server) billing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.billingMaintenance.Load() {
+			slog.Log(r.Context(), common.LevelTrace, "Rejecting billing request under maintenance")
+			s.RedirectError(http.StatusServiceUnavailable, w, r)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -354,6 +534,17 @@ func (s *Server) private(next http.Handler) http.Handler {
 
 		if step, ok := sess.Get(session.KeyLoginStep).(int); ok {
 			if step == loginStepCompleted {
+				if s.sessionOriginChanged(r, sess) {
+					s.reverifySession(w, r, sess)
+					return
+				}
+
+				if s.sessionRevoked(ctx, sess, time.Now()) {
+					s.Sessions.SessionDestroy(w, r)
+					common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+					return
+				}
+
 				// update limits each time as rate limiting gets cleaned up frequently (impact shouldn't be much in portal)
 				s.Auth.UpdateLimits(r)
 
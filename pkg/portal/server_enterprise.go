@@ -14,7 +14,7 @@ func (s *Server) isEnterprise() bool {
 	return true
 }
 
-func (s *Server) setupEnterprise(router *http.ServeMux, rg *RouteGenerator, privateWrite alice.Chain) {
+func (s *Server) setupEnterprise(router *http.ServeMux, rg *RouteGenerator, privateRead, privateWrite alice.Chain) {
 	arg := func(s string) string {
 		return fmt.Sprintf("{%s}", s)
 	}
@@ -24,5 +24,7 @@ func (s *Server) setupEnterprise(router *http.ServeMux, rg *RouteGenerator, priv
 	router.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint, arg(common.ParamUser)), privateWrite.ThenFunc(s.deleteOrgMembers))
 	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint), privateWrite.ThenFunc(s.joinOrg))
 	router.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint), privateWrite.ThenFunc(s.leaveOrg))
+	router.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.MembersEndpoint), privateWrite.Then(s.Handler(s.putPropertyMemberAccess)))
+	router.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.DeleteEndpoint), privateRead.Then(s.Handler(s.getOrgDeletePreview)))
 	router.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.DeleteEndpoint), privateWrite.ThenFunc(s.deleteOrg))
 }
@@ -12,6 +12,6 @@ func (s *Server) isEnterprise() bool {
 	return false
 }
 
-func (s *Server) setupEnterprise(*http.ServeMux, *RouteGenerator, alice.Chain) {
+func (s *Server) setupEnterprise(*http.ServeMux, *RouteGenerator, alice.Chain, alice.Chain) {
 	// BUMP
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session/store/memory"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/testsupport"
 	"github.com/PrivateCaptcha/PrivateCaptcha/web"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -56,15 +57,16 @@ func TestMain(m *testing.M) {
 
 	if testing.Short() {
 		server = &Server{
-			Stage:  common.StageTest,
-			Prefix: "",
-			XSRF:   &common.XSRFMiddleware{Key: "key", Timeout: 1 * time.Hour},
+			Stage:      common.StageTest,
+			TimeSeries: testsupport.NewTimeSeries(),
+			Prefix:     "",
+			XSRF:       &common.XSRFMiddleware{Key: "key", Timeout: 1 * time.Hour},
 			Sessions: &session.Manager{
 				CookieName:  "pcsid",
 				MaxLifetime: 1 * time.Minute,
 			},
 			PuzzleEngine: &fakePuzzleEngine{result: puzzle.VerifyNoError},
-			PlanService:  planService,
+			PlanService:  testsupport.NewPlanService(nil),
 		}
 
 		ctx := context.TODO()
@@ -92,7 +94,7 @@ func TestMain(m *testing.M) {
 
 	timeSeries = db.NewTimeSeries(clickhouse)
 
-	levels := difficulty.NewLevels(timeSeries, 100, 5*time.Minute)
+	levels := difficulty.NewLevels(timeSeries, monitoring.NewStub(), 100, 5*time.Minute)
 	levels.Init(2*time.Second, 5*time.Minute)
 	defer levels.Shutdown()
 
@@ -9,9 +9,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/license"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 	"github.com/badoux/checkmail"
 )
@@ -21,9 +23,11 @@ const (
 	settingsGeneralTemplatePrefix = "settings-general/"
 	settingsAPIKeysTemplatePrefix = "settings-apikeys/"
 	settingsUsageTemplatePrefix   = "settings-usage/"
+	settingsLicenseTemplatePrefix = "settings-license/"
 
 	// Other templates
 	settingsGeneralFormTemplate    = "settings-general/form.html"
+	settingsSecurityFormTemplate   = "settings-general/security-form.html"
 	settingsAPIKeysContentTemplate = "settings-apikeys/content.html"
 )
 
@@ -60,16 +64,49 @@ type SettingsCommonRenderContext struct {
 type settingsUsageRenderContext struct {
 	SettingsCommonRenderContext
 	Limit int
+	// counts vs plan limits, where zero limit means "unlimited"
+	HasPlan         bool
+	Properties      int
+	PropertiesLimit int
+	Orgs            int
+	OrgsLimit       int
+	OrgMembersLimit int
+}
+
+type settingsLicenseRenderContext struct {
+	SettingsCommonRenderContext
+	ID            string
+	Customer      string
+	Plan          string
+	IssuedAt      string
+	ExpiresAt     string
+	ExpiresSoon   bool
+	MaxNodes      int
+	Nodes         int64
+	NodesExceeded bool
 }
 
 type settingsGeneralRenderContext struct {
 	SettingsCommonRenderContext
+	settingsEmailsRenderContext
+	settingsSignInsRenderContext
 	Name           string
 	NameError      string
 	EmailError     string
 	TwoFactorError string
 	TwoFactorEmail string
 	EditEmail      bool
+	// require 2FA again if session is used from a new device or country
+	ReverifyNewLogins bool
+	Timezone          string
+	Timezones         []string
+	// UI preferences
+	UITheme        string
+	UIThemes       []string
+	TableDensity   string
+	TableDensities []string
+	DefaultOrg     string
+	Orgs           []*userOrg
 }
 
 type userAPIKey struct {
@@ -79,6 +116,16 @@ type userAPIKey struct {
 	Secret            string
 	RequestsPerMinute int
 	ExpiresSoon       bool
+	// automatic rotation
+	RotationDays int
+	NextRotation string
+	// set when the key was replaced by a successor and will be disabled soon
+	RetiresAt string
+	// set for the successor while the key it replaced is still valid
+	ReplacementUntil string
+	Disabled         bool
+	// disabled automatically due to anomalous usage, can be re-enabled by the owner
+	Suspended bool
 }
 
 type settingsAPIKeysRenderContext struct {
@@ -98,25 +145,69 @@ func apiKeyToUserAPIKey(key *dbgen.APIKey, tnow time.Time) *userAPIKey {
 	periodsPerMinute := float64(time.Minute) / period
 	requestsPerMinute := capacity * periodsPerMinute
 
-	return &userAPIKey{
+	result := &userAPIKey{
 		ID:                strconv.Itoa(int(key.ID)),
 		Name:              key.Name,
 		ExpiresAt:         key.ExpiresAt.Time.Format("02 Jan 2006"),
 		ExpiresSoon:       key.ExpiresAt.Time.Sub(tnow) < 31*24*time.Hour,
 		RequestsPerMinute: int(requestsPerMinute),
+		RotationDays:      int(key.RotationDays),
+		Disabled:          !key.Enabled.Valid || !key.Enabled.Bool,
+		Suspended:         key.SuspendedAt.Valid,
+	}
+
+	if key.SuccessorID.Valid && key.RotatedAt.Valid {
+		result.RetiresAt = key.RotatedAt.Time.Add(common.APIKeyRotationOverlap).Format("02 Jan 2006")
+	} else if key.RotationDays > 0 {
+		result.NextRotation = key.CreatedAt.Time.AddDate(0, 0, int(key.RotationDays)).Format("02 Jan 2006")
 	}
+
+	return result
 }
 
 func apiKeysToUserAPIKeys(keys []*dbgen.APIKey, tnow time.Time) []*userAPIKey {
 	result := make([]*userAPIKey, 0, len(keys))
+	indices := make(map[int32]int, len(keys))
 
-	for _, key := range keys {
+	for i, key := range keys {
 		result = append(result, apiKeyToUserAPIKey(key, tnow))
+		indices[key.ID] = i
+	}
+
+	// successor's secret is shown until the key it replaces is disabled, so that integrations can be updated
+	for i, key := range keys {
+		if !key.SuccessorID.Valid || result[i].Disabled {
+			continue
+		}
+
+		if j, ok := indices[key.SuccessorID.Int32]; ok {
+			result[j].Secret = db.UUIDToSecret(keys[j].ExternalID)
+			result[j].ReplacementUntil = result[i].RetiresAt
+		}
 	}
 
 	return result
 }
 
+func rotationDaysFromParam(ctx context.Context, param string) int32 {
+	if len(param) == 0 {
+		return 0
+	}
+
+	i, err := strconv.Atoi(param)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert rotation days", "value", param, common.ErrAttr(err))
+		return 0
+	}
+
+	switch i {
+	case 30, 90, 180:
+		return int32(i)
+	default:
+		return 0
+	}
+}
+
 func (s *Server) getSettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 
@@ -211,21 +302,101 @@ func (s *Server) CreateSettingsCommonRenderContext(activeTabID string, user *dbg
 }
 
 func (s *Server) createGeneralSettingsModel(ctx context.Context, user *dbgen.User) *settingsGeneralRenderContext {
-	return &settingsGeneralRenderContext{
+	renderCtx := &settingsGeneralRenderContext{
 		SettingsCommonRenderContext: s.CreateSettingsCommonRenderContext(common.GeneralEndpoint, user),
 		Name:                        user.Name,
+		ReverifyNewLogins:           user.ReverifyNewLogins,
+		Timezone:                    user.Timezone,
+		Timezones:                   timezoneChoices(user.Timezone),
+		UIThemes:                    uiThemes,
+		TableDensities:              tableDensities,
+		Orgs:                        []*userOrg{},
+	}
+
+	renderCtx.setPreferences(s.userPreferences(ctx, user.ID))
+
+	if orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID); err == nil {
+		renderCtx.Orgs = orgsToUserOrgs(orgs)
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve user orgs", "userID", user.ID, common.ErrAttr(err))
+	}
+
+	return renderCtx
+}
+
+func (rc *settingsGeneralRenderContext) setPreferences(prefs *dbgen.UserPreference) {
+	rc.UITheme = string(prefs.Theme)
+	rc.TableDensity = string(prefs.TableDensity)
+	rc.DefaultOrg = ""
+	if prefs.DefaultOrgID.Valid {
+		rc.DefaultOrg = strconv.Itoa(int(prefs.DefaultOrgID.Int32))
+	}
+}
+
+// putPreferences updates UI preferences from the general settings form. Returns true if preferences were changed
+func (s *Server) putPreferences(ctx context.Context, r *http.Request, user *dbgen.User, renderCtx *settingsGeneralRenderContext) bool {
+	prefs := s.userPreferences(ctx, user.ID)
+
+	theme, ok := parseUITheme(r.FormValue(common.ParamUITheme))
+	if !ok {
+		theme = prefs.Theme
+	}
+
+	density, ok := parseTableDensity(r.FormValue(common.ParamTableDensity))
+	if !ok {
+		density = prefs.TableDensity
+	}
+
+	defaultOrgID := prefs.DefaultOrgID.Int32
+	if _, present := r.Form[common.ParamDefaultOrg]; present {
+		orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+		if err != nil {
+			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+			return false
+		}
+
+		if defaultOrgID, ok = parseDefaultOrg(r.FormValue(common.ParamDefaultOrg), orgs); !ok {
+			slog.WarnContext(ctx, "Invalid default org", "userID", user.ID, "value", r.FormValue(common.ParamDefaultOrg))
+			renderCtx.ErrorMessage = "Default organization is not valid."
+			return false
+		}
 	}
+
+	if (theme == prefs.Theme) && (density == prefs.TableDensity) && (defaultOrgID == prefs.DefaultOrgID.Int32) {
+		return false
+	}
+
+	if err := s.updateUserPreferences(ctx, prefs, theme, density, defaultOrgID); err != nil {
+		renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+		return false
+	}
+
+	prefs.Theme, prefs.TableDensity = theme, density
+	prefs.DefaultOrgID.Int32, prefs.DefaultOrgID.Valid = defaultOrgID, defaultOrgID > 0
+	renderCtx.setPreferences(prefs)
+	renderCtx.SuccessMessage = "Settings were updated."
+
+	return true
 }
 
 func (s *Server) getGeneralSettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
-	user, err := s.SessionUser(ctx, s.Session(w, r))
+	sess := s.Session(w, r)
+	user, err := s.SessionUser(ctx, sess)
 	if err != nil {
 		return nil, "", err
 	}
 
 	renderCtx := s.createGeneralSettingsModel(ctx, user)
 
+	if err := s.fillEmailsSettings(ctx, renderCtx, user); err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve secondary emails", "userID", user.ID, common.ErrAttr(err))
+	}
+
+	if err := s.fillSignInsSettings(ctx, renderCtx, user, sess); err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user sign-ins", "userID", user.ID, common.ErrAttr(err))
+	}
+
 	return renderCtx, "", nil
 }
 
@@ -310,6 +481,25 @@ func (s *Server) putGeneralSettings(w http.ResponseWriter, r *http.Request) (Mod
 		anyChange = (len(formName) > 0) && (formName != user.Name)
 	}
 
+	if !renderCtx.EditEmail {
+		if formTimezone := strings.TrimSpace(r.FormValue(common.ParamTimezone)); (len(formTimezone) > 0) && (formTimezone != user.Timezone) {
+			if err := s.updateUserTimezone(ctx, user, formTimezone); err == nil {
+				renderCtx.Timezone = formTimezone
+				renderCtx.Timezones = timezoneChoices(formTimezone)
+				renderCtx.SuccessMessage = "Settings were updated."
+			} else if err == ErrInvalidRequestArg {
+				renderCtx.ErrorMessage = "Timezone is not valid."
+			} else {
+				renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+			}
+		}
+
+		if s.putPreferences(ctx, r, user, renderCtx) {
+			// theme and density are applied to the whole page
+			w.Header().Set(common.HeaderHtmxRefresh, "true")
+		}
+	}
+
 	if anyChange {
 		emailToUpdate := user.Email
 		if renderCtx.EditEmail {
@@ -322,6 +512,10 @@ func (s *Server) putGeneralSettings(w http.ResponseWriter, r *http.Request) (Mod
 			if emailToUpdate != user.Email {
 				_ = sess.Set(session.KeyUserEmail, emailToUpdate)
 				renderCtx.Email = emailToUpdate
+
+				if err := s.notifyEmailChanged(ctx, user, emailToUpdate); err != nil {
+					slog.ErrorContext(ctx, "Failed to notify about email change", "userID", user.ID, common.ErrAttr(err))
+				}
 			}
 		} else {
 			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
@@ -331,6 +525,45 @@ func (s *Server) putGeneralSettings(w http.ResponseWriter, r *http.Request) (Mod
 	return renderCtx, settingsGeneralFormTemplate, nil
 }
 
+func (s *Server) putSecuritySettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	sess := s.Session(w, r)
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	_, reverify := r.Form[common.ParamReverifyLogins]
+
+	renderCtx := s.createGeneralSettingsModel(ctx, user)
+
+	if reverify != user.ReverifyNewLogins {
+		if _, err := s.Store.Impl().UpdateUserReverifyNewLogins(ctx, user.ID, reverify); err == nil {
+			slog.InfoContext(ctx, "Audit: user changed sign-in re-verification", "userID", user.ID, "enabled", reverify)
+			renderCtx.ReverifyNewLogins = reverify
+			renderCtx.SuccessMessage = "Security settings were updated."
+
+			_ = sess.Set(session.KeyReverifyNewLogins, reverify)
+			// sessions created before origin tracking was added start to be tracked from now on
+			if !sess.Has(session.KeyLoginDevice) {
+				signIn := s.signInInfo(r)
+				_ = sess.Set(session.KeyLoginDevice, signIn.Device)
+				_ = sess.Set(session.KeyLoginCountry, signIn.Country)
+			}
+		} else {
+			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+		}
+	}
+
+	return renderCtx, settingsSecurityFormTemplate, nil
+}
+
 func (s *Server) deleteAccount(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, err := s.SessionUser(ctx, s.Session(w, r))
@@ -409,6 +642,26 @@ func monthsFromParam(ctx context.Context, param string) int {
 	}
 }
 
+// apiKeyRequestsPerSecond returns rate limit of new API keys according to user's plan
+func (s *Server) apiKeyRequestsPerSecond(ctx context.Context, user *dbgen.User) float64 {
+	if !user.SubscriptionID.Valid {
+		return 1.0
+	}
+
+	subscription, err := s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		return 1.0
+	}
+
+	plan, err := s.PlanService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, s.Stage,
+		db.IsInternalSubscription(subscription.Source))
+	if err != nil {
+		return 1.0
+	}
+
+	return plan.APIRequestsPerSecond()
+}
+
 func (s *Server) postAPIKeySettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 	user, err := s.SessionUser(ctx, s.Session(w, r))
@@ -431,20 +684,15 @@ func (s *Server) postAPIKeySettings(w http.ResponseWriter, r *http.Request) (Mod
 		return renderCtx, settingsAPIKeysContentTemplate, nil
 	}
 
-	apiKeyRequestsPerSecond := 1.0
-	if user.SubscriptionID.Valid {
-		if subscription, err := s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32); err == nil {
-			if plan, err := s.PlanService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, s.Stage,
-				db.IsInternalSubscription(subscription.Source)); err == nil {
-				apiKeyRequestsPerSecond = plan.APIRequestsPerSecond()
-			}
-		}
-	}
+	apiKeyRequestsPerSecond := s.apiKeyRequestsPerSecond(ctx, user)
 
 	months := monthsFromParam(ctx, r.FormValue(common.ParamMonths))
 	tnow := time.Now().UTC()
 	expiration := tnow.AddDate(0, months, 0)
 	newKey, err := s.Store.Impl().CreateAPIKey(ctx, user.ID, formName, expiration, apiKeyRequestsPerSecond)
+	if rotationDays := rotationDaysFromParam(ctx, r.FormValue(common.ParamRotation)); (err == nil) && (rotationDays > 0) {
+		newKey, err = s.Store.Impl().UpdateAPIKeyRotation(ctx, user.ID, newKey.ID, rotationDays)
+	}
 	if err == nil {
 		userKey := apiKeyToUserAPIKey(newKey, tnow)
 		userKey.Secret = db.UUIDToSecret(newKey.ExternalID)
@@ -482,44 +730,74 @@ func (s *Server) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (s *Server) getAccountStats(w http.ResponseWriter, r *http.Request) {
+// putAPIKeyResume re-enables API key (personal or of the owned org) that was suspended due to anomalous usage
+func (s *Server) putAPIKeyResume(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-
 	user, err := s.SessionUser(ctx, s.Session(w, r))
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		s.RedirectError(http.StatusUnauthorized, w, r)
 		return
 	}
 
-	type point struct {
-		Date  int64 `json:"x"`
-		Value int   `json:"y"`
+	keyID, value, err := common.IntPathArg(r, common.ParamKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse key path parameter", "value", value)
+		s.RedirectError(http.StatusBadRequest, w, r)
+		return
 	}
 
-	data := []*point{}
+	if _, err := s.Store.Impl().ResumeAPIKey(ctx, user.ID, int32(keyID)); err != nil {
+		slog.ErrorContext(ctx, "Failed to resume the API key", "keyID", keyID, common.ErrAttr(err))
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+
+	// key is shown both in personal settings and in organization settings
+	w.Header().Set(common.HeaderHtmxRefresh, "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) retrieveAccountStats(ctx context.Context, userID int32, tz *time.Location) []*statsPoint {
+	data := []*statsPoint{}
 
 	timeFrom := time.Now().UTC().AddDate(-1 /*years*/, 0 /*months*/, 0 /*days*/)
-	if stats, err := s.TimeSeries.ReadAccountStats(ctx, user.ID, timeFrom); err == nil {
+	if stats, err := s.TimeSeries.ReadAccountStats(ctx, userID, timeFrom, tz); err == nil {
 		anyNonZero := false
 		for _, st := range stats {
 			if st.Count > 0 {
 				anyNonZero = true
 			}
-			data = append(data, &point{Date: st.Timestamp.Unix(), Value: int(st.Count)})
+			data = append(data, &statsPoint{Date: st.Timestamp.Unix(), Value: int(st.Count)})
 		}
 
 		// we want to show "No data available" on the client
 		if !anyNonZero {
-			data = []*point{}
+			data = []*statsPoint{}
 		}
 	} else {
 		slog.ErrorContext(ctx, "Failed to retrieve account stats", common.ErrAttr(err))
 	}
 
+	return data
+}
+
+func (s *Server) getAccountStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	tz := userLocation(ctx, user)
+
 	response := struct {
-		Data []*point `json:"data"`
+		Data     []*statsPoint `json:"data"`
+		Timezone string        `json:"timezone"`
 	}{
-		Data: data,
+		Data:     s.retrieveAccountStats(ctx, user.ID, tz),
+		Timezone: tz.String(),
 	}
 
 	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
@@ -539,6 +817,7 @@ func (s *Server) createUsageSettingsModel(ctx context.Context, user *dbgen.User)
 			if plan, err := s.PlanService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, s.Stage,
 				db.IsInternalSubscription(subscription.Source)); err == nil {
 				renderCtx.Limit = int(plan.RequestsLimit())
+				s.fillUsagePlanLimits(ctx, renderCtx, user, plan)
 			} else {
 				slog.ErrorContext(ctx, "Failed to find billing plan for usage tab", "productID", subscription.ExternalProductID, "priceID", subscription.ExternalPriceID, common.ErrAttr(err))
 				renderCtx.ErrorMessage = "Could not determine usage limits from your plan."
@@ -552,6 +831,25 @@ func (s *Server) createUsageSettingsModel(ctx context.Context, user *dbgen.User)
 	return renderCtx
 }
 
+func (s *Server) fillUsagePlanLimits(ctx context.Context, renderCtx *settingsUsageRenderContext, user *dbgen.User, plan billing.Plan) {
+	renderCtx.HasPlan = true
+	renderCtx.PropertiesLimit = plan.PropertiesLimit()
+	renderCtx.OrgsLimit = plan.OrgsLimit()
+	renderCtx.OrgMembersLimit = plan.OrgMembersLimit()
+
+	if count, err := s.Store.Impl().RetrieveUserPropertiesCount(ctx, user.ID); err == nil {
+		renderCtx.Properties = int(count)
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve properties count for usage tab", "userID", user.ID, common.ErrAttr(err))
+	}
+
+	if orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID); err == nil {
+		renderCtx.Orgs = countOwnedOrgs(orgs)
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve organizations for usage tab", "userID", user.ID, common.ErrAttr(err))
+	}
+}
+
 func (s *Server) getUsageSettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
 	ctx := r.Context()
 
@@ -564,3 +862,43 @@ func (s *Server) getUsageSettings(w http.ResponseWriter, r *http.Request) (Model
 
 	return renderCtx, "", nil
 }
+
+func (s *Server) getLicenseSettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	tnow := time.Now().UTC()
+	lic := s.License
+
+	renderCtx := &settingsLicenseRenderContext{
+		SettingsCommonRenderContext: s.CreateSettingsCommonRenderContext(common.LicenseEndpoint, user),
+		ID:                          lic.ID,
+		Customer:                    lic.Customer,
+		Plan:                        lic.Plan,
+		IssuedAt:                    lic.IssuedAt.Format("02 Jan 2006"),
+		ExpiresAt:                   lic.ExpiresAt.Format("02 Jan 2006"),
+		ExpiresSoon:                 lic.ExpiresSoon(tnow),
+		MaxNodes:                    lic.MaxNodes,
+	}
+
+	if nodes, err := s.Store.Impl().RetrieveActiveLicenseNodesCount(ctx, tnow.Add(-license.NodeActiveWindow)); err == nil {
+		renderCtx.Nodes = nodes
+		renderCtx.NodesExceeded = lic.NodesExceeded(nodes)
+	} else {
+		renderCtx.ErrorMessage = "Could not load the number of running nodes."
+	}
+
+	if err := lic.Check(tnow); err != nil {
+		renderCtx.WarningMessage = "License has expired. Please renew it and update the activation file."
+	} else if renderCtx.NodesExceeded {
+		renderCtx.WarningMessage = "More nodes are running than the license allows."
+	} else if renderCtx.ExpiresSoon {
+		renderCtx.WarningMessage = "License expires soon. Please renew it and update the activation file."
+	}
+
+	return renderCtx, "", nil
+}
@@ -0,0 +1,204 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"golang.org/x/net/xsrftoken"
+)
+
+const (
+	sharedReportTemplate     = "shared-report/report.html"
+	propertyShareTemplate    = "property/share-link.html"
+	sharedReportAction       = "shared-report"
+	sharedReportSeparator    = "."
+	sharedReportPayloadParts = 3
+)
+
+var (
+	errInvalidShareToken = errors.New("invalid shared report token")
+)
+
+type propertyShareRenderContext struct {
+	AlertRenderContext
+	ShareURL  string
+	ExpiresAt string
+}
+
+type sharedReportRenderContext struct {
+	// base.html expects CSRF token that is empty for anonymous viewers
+	CsrfRenderContext
+	Property   *userProperty
+	ReportsURL string
+}
+
+type sharedReportToken struct {
+	OrgID      int32
+	PropertyID int32
+	// access to the property is evaluated on behalf of the member who shared the report
+	UserID int32
+}
+
+func (t *sharedReportToken) payload() string {
+	return strings.Join([]string{
+		strconv.Itoa(int(t.OrgID)),
+		strconv.Itoa(int(t.PropertyID)),
+		strconv.Itoa(int(t.UserID)),
+	}, sharedReportSeparator)
+}
+
+// sharedReportToken signs property reference so that the link cannot be forged or used after the timeout. Signature
+// itself is an xsrftoken which carries issue time and does not contain the separator
+func (s *Server) sharedReportToken(t *sharedReportToken) string {
+	payload := t.payload()
+	return payload + sharedReportSeparator + xsrftoken.Generate(s.XSRF.Key, payload, sharedReportAction)
+}
+
+func (s *Server) verifySharedReportToken(token string) (*sharedReportToken, error) {
+	parts := strings.SplitN(token, sharedReportSeparator, sharedReportPayloadParts+1)
+	if len(parts) != sharedReportPayloadParts+1 {
+		return nil, errInvalidShareToken
+	}
+
+	ids := make([]int32, 0, sharedReportPayloadParts)
+	for _, p := range parts[:sharedReportPayloadParts] {
+		id, err := strconv.ParseInt(p, 10, 32)
+		if err != nil || (id <= 0) {
+			return nil, errInvalidShareToken
+		}
+		ids = append(ids, int32(id))
+	}
+
+	payload := strings.Join(parts[:sharedReportPayloadParts], sharedReportSeparator)
+	if !xsrftoken.ValidFor(parts[sharedReportPayloadParts], s.XSRF.Key, payload, sharedReportAction, common.SharedReportTimeout) {
+		return nil, errInvalidShareToken
+	}
+
+	return &sharedReportToken{OrgID: ids[0], PropertyID: ids[1], UserID: ids[2]}, nil
+}
+
+// sharedProperty returns property referenced by the shared report token, if the member who shared it still has access
+func (s *Server) sharedProperty(ctx context.Context, token string) (*dbgen.Property, error) {
+	t, err := s.verifySharedReportToken(token)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to verify shared report token", common.ErrAttr(err))
+		return nil, err
+	}
+
+	if _, err := s.Store.Impl().RetrieveUserOrganization(ctx, t.UserID, t.OrgID); err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve shared report org", "orgID", t.OrgID, "userID", t.UserID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	property, err := s.Store.Impl().RetrieveOrgProperty(ctx, t.OrgID, t.UserID, t.PropertyID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve shared report property", "propID", t.PropertyID, "userID", t.UserID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return property, nil
+}
+
+func (s *Server) postPropertyShare(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	dashboardCtx, property, err := s.getOrgProperty(w, r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx := &propertyShareRenderContext{}
+
+	if !dashboardCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to share reports", "userID", user.ID, "propID", property.ID)
+		renderCtx.ErrorMessage = "Insufficient permissions to share reports."
+		return renderCtx, propertyShareTemplate, nil
+	}
+
+	token := s.sharedReportToken(&sharedReportToken{
+		OrgID:      property.OrgID.Int32,
+		PropertyID: property.ID,
+		UserID:     user.ID,
+	})
+
+	renderCtx.ShareURL = s.PartsURL(common.ShareEndpoint, token)
+	renderCtx.ExpiresAt = time.Now().Add(common.SharedReportTimeout).UTC().Format("02 Jan 2006 15:04 MST")
+
+	slog.InfoContext(ctx, "Created shared report link", "propID", property.ID, "userID", user.ID)
+
+	return renderCtx, propertyShareTemplate, nil
+}
+
+func (s *Server) getSharedReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.PathValue(common.ParamToken)
+
+	property, err := s.sharedProperty(ctx, token)
+	if err != nil {
+		s.RedirectError(http.StatusNotFound, w, r)
+		return
+	}
+
+	renderCtx := &sharedReportRenderContext{
+		Property:   propertyToUserProperty(property),
+		ReportsURL: s.PartsURL(common.ShareEndpoint, token),
+	}
+
+	s.render(w, r, sharedReportTemplate, renderCtx)
+}
+
+func (s *Server) getSharedReportStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	property, err := s.sharedProperty(ctx, r.PathValue(common.ParamToken))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	// shared reports are not bound to a viewer so they are always aligned to UTC
+	response := s.retrievePropertyStats(ctx, property.OrgID.Int32, property.ID, periodFromParam(ctx, r.PathValue(common.ParamPeriod)),
+		time.UTC)
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) getSharedReportFailures(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	property, err := s.sharedProperty(ctx, r.PathValue(common.ParamToken))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	response := s.retrievePropertyFailures(ctx, property.OrgID.Int32, property.ID, periodFromParam(ctx, r.PathValue(common.ParamPeriod)),
+		time.UTC)
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) getSharedReportFingerprints(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	property, err := s.sharedProperty(ctx, r.PathValue(common.ParamToken))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	response := s.retrievePropertyFingerprints(ctx, property, periodFromParam(ctx, r.PathValue(common.ParamPeriod)), time.UTC)
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
@@ -0,0 +1,41 @@
+package portal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func TestSharedReportToken(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{XSRF: &common.XSRFMiddleware{Key: "key", Timeout: common.SharedReportTimeout}}
+
+	expected := &sharedReportToken{OrgID: 1, PropertyID: 2, UserID: 3}
+	token := s.sharedReportToken(expected)
+
+	actual, err := s.verifySharedReportToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *actual != *expected {
+		t.Errorf("Unexpected token data: %+v", actual)
+	}
+
+	signature := token[strings.LastIndex(token, sharedReportSeparator)+1:]
+
+	for _, invalid := range []string{
+		"",
+		"1.2.3",
+		"1.2.4." + signature,
+		"01.2.3." + signature,
+		"1.2.-3." + signature,
+		"1.2.3.invalid",
+	} {
+		if _, err := s.verifySharedReportToken(invalid); err == nil {
+			t.Errorf("Token %q is valid", invalid)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+)
+
+const (
+	settingsSignInsTemplate = "settings-general/signins.html"
+	maxRecentSignIns        = 10
+	// sessions are kept in memory of each node so revocation is checked periodically instead of on every request
+	sessionRevocationCheckInterval = 1 * time.Minute
+)
+
+type userSignIn struct {
+	Time      string
+	Device    string
+	Country   string
+	IPAddress string
+	NewOrigin bool
+	Current   bool
+	Revoked   bool
+}
+
+type settingsSignInsRenderContext struct {
+	SignIns []*userSignIn
+}
+
+func userLoginsToSignIns(logins []*dbgen.UserLogin, currentSessionID string, loc *time.Location) []*userSignIn {
+	result := make([]*userSignIn, 0, len(logins))
+	for _, l := range logins {
+		result = append(result, &userSignIn{
+			Time:      l.CreatedAt.Time.In(loc).Format("02 Jan 2006 15:04 MST"),
+			Device:    l.Device,
+			Country:   l.Country,
+			IPAddress: l.IpAddress,
+			NewOrigin: l.NewOrigin,
+			Current:   (len(l.SessionID) > 0) && (l.SessionID == currentSessionID),
+			Revoked:   l.RevokedAt.Valid,
+		})
+	}
+	return result
+}
+
+func (s *Server) fillSignInsSettings(ctx context.Context, renderCtx *settingsGeneralRenderContext, user *dbgen.User, sess *common.Session) error {
+	logins, err := s.Store.Impl().RetrieveUserLogins(ctx, user.ID, maxRecentSignIns)
+	if err != nil {
+		return err
+	}
+
+	renderCtx.SignIns = userLoginsToSignIns(logins, sess.SessionID(), userLocation(ctx, user))
+
+	return nil
+}
+
+// sessionRevoked checks if the sign-in that created the session was revoked from another session. Errors are
+// treated as "not revoked" so that a database hiccup does not sign everybody out
+func (s *Server) sessionRevoked(ctx context.Context, sess *common.Session, tnow time.Time) bool {
+	loginID, ok := sess.Get(session.KeyLoginID).(int32)
+	if !ok {
+		return false
+	}
+
+	if checkedAt, ok := sess.Get(session.KeyLoginCheckedAt).(int64); ok && (tnow.Sub(time.Unix(checkedAt, 0)) < sessionRevocationCheckInterval) {
+		return false
+	}
+
+	login, err := s.Store.Impl().RetrieveUserLogin(ctx, loginID)
+	if err != nil {
+		if err == db.ErrRecordNotFound {
+			slog.WarnContext(ctx, "Session sign-in record is missing", "loginID", loginID)
+			return true
+		}
+		return false
+	}
+
+	if login.RevokedAt.Valid {
+		slog.InfoContext(ctx, "Session was revoked", "loginID", loginID, "userID", login.UserID)
+		return true
+	}
+
+	_ = sess.Set(session.KeyLoginCheckedAt, tnow.Unix())
+
+	return false
+}
+
+// loginsOverLimit returns sign-ins (ordered newest first) that do not fit into the limit of concurrent sessions
+// together with the current one
+func loginsOverLimit(logins []*dbgen.UserLogin, currentLoginID int32, limit int) []*dbgen.UserLogin {
+	if limit <= 0 {
+		return nil
+	}
+
+	kept := 1
+	result := make([]*dbgen.UserLogin, 0)
+
+	for _, l := range logins {
+		if l.ID == currentLoginID {
+			continue
+		}
+
+		if kept < limit {
+			kept++
+			continue
+		}
+
+		result = append(result, l)
+	}
+
+	return result
+}
+
+// destroyRevokedSessions deletes sessions of revoked sign-ins that are known to this node.
+// Other nodes will find out about revocation on the next periodic check
+func (s *Server) destroyRevokedSessions(ctx context.Context, logins []*dbgen.UserLogin, currentSessionID string) {
+	sids := make([]string, 0, len(logins))
+	for _, l := range logins {
+		if l.SessionID != currentSessionID {
+			sids = append(sids, l.SessionID)
+		}
+	}
+
+	s.Sessions.SessionsDestroy(ctx, sids)
+}
+
+// enforceSessionsLimit signs out the oldest sessions of the user if there are more of them than configured
+func (s *Server) enforceSessionsLimit(ctx context.Context, userID int32, sess *common.Session, loginID int32) {
+	limit := int(s.maxUserSessions.Load())
+	if limit <= 0 {
+		return
+	}
+
+	logins, err := s.Store.Impl().RetrieveActiveUserLogins(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	overLimit := loginsOverLimit(logins, loginID, limit)
+	if len(overLimit) == 0 {
+		return
+	}
+
+	loginIDs := make([]int32, 0, len(overLimit))
+	for _, l := range overLimit {
+		loginIDs = append(loginIDs, l.ID)
+	}
+
+	revoked, err := s.Store.Impl().RevokeUserLogins(ctx, userID, loginIDs)
+	if err != nil {
+		return
+	}
+
+	s.destroyRevokedSessions(ctx, revoked, sess.SessionID())
+
+	slog.InfoContext(ctx, "Audit: signed out sessions over the limit", "userID", userID, "count", len(revoked), "limit", limit)
+}
+
+// deleteOtherSessions signs out all sessions of the user except for the current one
+func (s *Server) deleteOtherSessions(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	sess := s.Session(w, r)
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx := s.createGeneralSettingsModel(ctx, user)
+
+	// sessions that were started before sign-in history have no login ID and will not be affected
+	loginID, _ := sess.Get(session.KeyLoginID).(int32)
+
+	if logins, err := s.Store.Impl().RevokeOtherUserLogins(ctx, user.ID, loginID); err == nil {
+		s.destroyRevokedSessions(ctx, logins, sess.SessionID())
+		slog.InfoContext(ctx, "Audit: user signed out other sessions", "userID", user.ID, "count", len(logins))
+		renderCtx.SuccessMessage = "Other sessions were signed out."
+	} else {
+		renderCtx.ErrorMessage = "Failed to sign out other sessions. Please try again."
+	}
+
+	if err := s.fillSignInsSettings(ctx, renderCtx, user, sess); err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user sign-ins", "userID", user.ID, common.ErrAttr(err))
+	}
+
+	return renderCtx, settingsSignInsTemplate, nil
+}
@@ -0,0 +1,67 @@
+package portal
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestUserLoginsToSignIns(t *testing.T) {
+	tnow := time.Date(2024, time.March, 5, 10, 30, 0, 0, time.UTC)
+
+	logins := []*dbgen.UserLogin{
+		{ID: 3, SessionID: "current", Device: "Firefox on Linux", CreatedAt: pgtype.Timestamptz{Time: tnow, Valid: true}},
+		{ID: 2, SessionID: "other", RevokedAt: pgtype.Timestamptz{Time: tnow, Valid: true}},
+		// logins recorded before session tracking do not have session ID
+		{ID: 1, SessionID: ""},
+	}
+
+	signIns := userLoginsToSignIns(logins, "current", time.UTC)
+	if len(signIns) != len(logins) {
+		t.Fatalf("Unexpected number of sign-ins: %v", len(signIns))
+	}
+
+	if !signIns[0].Current || signIns[0].Revoked || (signIns[0].Time != "05 Mar 2024 10:30 UTC") || (signIns[0].Device != "Firefox on Linux") {
+		t.Errorf("Unexpected current sign-in: %+v", signIns[0])
+	}
+
+	if signIns[1].Current || !signIns[1].Revoked {
+		t.Errorf("Unexpected revoked sign-in: %+v", signIns[1])
+	}
+
+	if empty := userLoginsToSignIns(logins[2:], "", time.UTC); empty[0].Current {
+		t.Errorf("Sign-in without session ID cannot be current")
+	}
+}
+
+func TestLoginsOverLimit(t *testing.T) {
+	// newest first, current login is not necessarily the newest one (e.g. it was not persisted yet)
+	logins := []*dbgen.UserLogin{{ID: 5}, {ID: 4}, {ID: 3}, {ID: 2}, {ID: 1}}
+
+	testCases := []struct {
+		current  int32
+		limit    int
+		expected []int32
+	}{
+		{4, 0, []int32{}},
+		{4, 1, []int32{5, 3, 2, 1}},
+		{4, 3, []int32{2, 1}},
+		{6, 3, []int32{3, 2, 1}},
+		{1, 5, []int32{}},
+	}
+
+	for i, tc := range testCases {
+		actual := loginsOverLimit(logins, tc.current, tc.limit)
+		ids := make([]int32, 0, len(actual))
+		for _, l := range actual {
+			ids = append(ids, l.ID)
+		}
+
+		if !slices.Equal(ids, tc.expected) {
+			t.Errorf("Unexpected logins over limit at %v: %v (expected %v)", i, ids, tc.expected)
+		}
+	}
+}
@@ -230,3 +230,141 @@ func TestSystemNotification(t *testing.T) {
 		t.Errorf("Cannot retrieve specific user notification: %v", err)
 	}
 }
+
+func TestUserNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	impl := store.Impl()
+
+	first, err := impl.CreateUserNotification(ctx, user.ID, dbgen.NotificationCategoryBilling, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := impl.CreateUserNotification(ctx, user.ID, dbgen.NotificationCategorySecurity, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := impl.RetrieveUnreadUserNotificationsCount(ctx, user.ID); (err != nil) || (count != 2) {
+		t.Errorf("Unexpected unread count: %v (err: %v)", count, err)
+	}
+
+	if err := impl.MarkUserNotificationRead(ctx, user.ID, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := impl.RetrieveUnreadUserNotificationsCount(ctx, user.ID); (err != nil) || (count != 1) {
+		t.Errorf("Unexpected unread count after read: %v (err: %v)", count, err)
+	}
+
+	if err := impl.DismissUserNotification(ctx, user.ID, second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	notifications, err := impl.RetrieveUserNotifications(ctx, user.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(notifications) != 1) || (notifications[0].ID != first.ID) || !notifications[0].ReadAt.Valid {
+		t.Errorf("Unexpected notifications after dismiss: %v", notifications)
+	}
+
+	if count, err := impl.RetrieveUnreadUserNotificationsCount(ctx, user.ID); (err != nil) || (count != 0) {
+		t.Errorf("Unexpected unread count after dismiss: %v (err: %v)", count, err)
+	}
+}
+
+func TestUserLockout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	impl := store.Impl()
+	tnow := time.Now().UTC()
+	const maxAttempts = 3
+
+	for i := 0; i < maxAttempts-1; i++ {
+		if _, locked, err := impl.RecordTwoFactorFailure(ctx, user.ID, maxAttempts, time.Hour, tnow); (err != nil) || locked {
+			t.Fatalf("Unexpected lockout after %v attempts (err: %v)", i+1, err)
+		}
+	}
+
+	lockout, locked, err := impl.RecordTwoFactorFailure(ctx, user.ID, maxAttempts, time.Hour, tnow)
+	if (err != nil) || !locked {
+		t.Fatalf("User was not locked (err: %v)", err)
+	}
+
+	if !isUserLocked(lockout, tnow) || isUserLocked(lockout, tnow.Add(2*time.Hour)) {
+		t.Errorf("Unexpected lockout period: %v", lockout.LockedUntil.Time)
+	}
+
+	if err := impl.ResetUserLockout(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := impl.RetrieveUserLockout(ctx, user.ID); err != db.ErrRecordNotFound {
+		t.Errorf("Unexpected error after lockout reset: %v", err)
+	}
+}
+
+func TestUserLockoutDecay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	impl := store.Impl()
+	tnow := time.Now().UTC()
+	const maxAttempts = 3
+
+	// occasional failures that are further apart than the lockout window never add up
+	for i := 0; i < 2*maxAttempts; i++ {
+		lockout, locked, err := impl.RecordTwoFactorFailure(ctx, user.ID, maxAttempts, time.Hour, tnow.Add(time.Duration(i)*2*time.Hour))
+		if (err != nil) || locked {
+			t.Fatalf("Unexpected lockout after %v attempts (err: %v)", i+1, err)
+		}
+
+		if lockout.FailedAttempts != 1 {
+			t.Errorf("Failed attempts were not reset: %v", lockout.FailedAttempts)
+		}
+	}
+
+	if err := impl.ResetUserLockout(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// failures within the window are still counted
+	for i := 0; i < maxAttempts-1; i++ {
+		if _, locked, err := impl.RecordTwoFactorFailure(ctx, user.ID, maxAttempts, time.Hour, tnow.Add(time.Duration(i)*30*time.Minute)); (err != nil) || locked {
+			t.Fatalf("Unexpected lockout after %v attempts (err: %v)", i+1, err)
+		}
+	}
+
+	if _, locked, err := impl.RecordTwoFactorFailure(ctx, user.ID, maxAttempts, time.Hour, tnow.Add(time.Hour)); (err != nil) || !locked {
+		t.Errorf("User was not locked (err: %v)", err)
+	}
+}
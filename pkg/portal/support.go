@@ -0,0 +1,144 @@
+package portal
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+const (
+	settingsSupportTemplatePrefix = "settings-support/"
+	maxSupportTickets             = 50
+	supportTimeFormat             = "02 Jan 2006 15:04 MST"
+)
+
+type supportTicketReply struct {
+	FromSupport bool
+	Message     string
+	CreatedAt   string
+}
+
+type userSupportTicket struct {
+	TicketID  string
+	Subject   string
+	Message   string
+	Status    string
+	CreatedAt string
+	Replies   []*supportTicketReply
+}
+
+type settingsSupportRenderContext struct {
+	SettingsCommonRenderContext
+	Tickets []*userSupportTicket
+}
+
+func (s *Server) getSupportSettings(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx := &settingsSupportRenderContext{
+		SettingsCommonRenderContext: s.CreateSettingsCommonRenderContext(common.SupportEndpoint, user),
+	}
+
+	tickets, err := s.Store.Impl().RetrieveUserSupportTickets(ctx, user.ID, maxSupportTickets)
+	if err != nil {
+		renderCtx.ErrorMessage = "Could not load support tickets."
+		return renderCtx, "", nil
+	}
+
+	loc := userLocation(ctx, user)
+	ids := make([]int32, 0, len(tickets))
+	byID := make(map[int32]*userSupportTicket, len(tickets))
+	renderCtx.Tickets = make([]*userSupportTicket, 0, len(tickets))
+
+	for _, t := range tickets {
+		ticket := &userSupportTicket{
+			TicketID:  t.TicketID,
+			Subject:   t.Subject,
+			Message:   t.Message,
+			Status:    string(t.Status),
+			CreatedAt: t.CreatedAt.Time.In(loc).Format(supportTimeFormat),
+		}
+		renderCtx.Tickets = append(renderCtx.Tickets, ticket)
+		byID[t.ID] = ticket
+		ids = append(ids, t.ID)
+	}
+
+	replies, err := s.Store.Impl().RetrieveSupportTicketsReplies(ctx, ids)
+	if err != nil {
+		renderCtx.ErrorMessage = "Could not load replies to support tickets."
+		return renderCtx, "", nil
+	}
+
+	for _, reply := range replies {
+		if ticket, ok := byID[reply.TicketID]; ok {
+			ticket.Replies = append(ticket.Replies, &supportTicketReply{
+				FromSupport: reply.FromSupport,
+				Message:     reply.Message,
+				CreatedAt:   reply.CreatedAt.Time.In(loc).Format(supportTimeFormat),
+			})
+		}
+	}
+
+	return renderCtx, "", nil
+}
+
+func (s *Server) supportInboundAuth(next http.Handler) http.Handler {
+	return sharedKeyAuth(&s.supportInboundKey, "support", next)
+}
+
+// postSupportInbound receives replies to support requests from the inbound email webhook of the mail provider.
+// Emails are only persisted in the outbox here and are matched to tickets by the worker.
+func (s *Server) postSupportInbound(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	inbound := &email.InboundEmail{}
+	if err := json.NewDecoder(r.Body).Decode(inbound); err != nil {
+		slog.WarnContext(ctx, "Failed to parse inbound email", common.ErrAttr(err))
+		s.sendAPIError(ctx, w, ErrInvalidRequestArg)
+		return
+	}
+
+	inbound.MessageID = strings.TrimSpace(inbound.MessageID)
+	if (len(inbound.MessageID) == 0) || (len(inbound.From) == 0) {
+		sendAPIValidationError(w, "Message ID and sender are required.")
+		return
+	}
+
+	ticketID, ok := email.ParseSupportTicketID(inbound.Subject)
+	if !ok {
+		// mail provider should not retry emails that are not replies to support requests
+		slog.WarnContext(ctx, "Ignoring inbound email without ticket ID", "messageID", inbound.MessageID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	payload, err := json.Marshal(inbound)
+	if err != nil {
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	if _, err := s.Store.Impl().CreateWebhookEvent(ctx, inbound.MessageID, email.SupportReplyEventType, payload); err != nil {
+		if err == db.ErrDuplicateEvent {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		s.sendAPIError(ctx, w, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Received reply to support ticket", "ticketID", ticketID, "messageID", inbound.MessageID)
+
+	w.WriteHeader(http.StatusAccepted)
+}
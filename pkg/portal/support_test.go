@@ -0,0 +1,132 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+)
+
+func postSupportInbound(handler http.Handler, key, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/"+common.APIEndpoint+"/"+common.V1Endpoint+"/"+common.SupportEndpoint+"/"+common.InboundEndpoint,
+		strings.NewReader(body))
+	req.Header.Set(common.HeaderAuthorization, "Bearer "+key)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSupportInboundValidation(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	key := strings.Repeat("s", 32)
+	handler := s.supportInboundAuth(http.HandlerFunc(s.postSupportInbound))
+
+	if code := postSupportInbound(handler, key, `{}`); code != http.StatusNotFound {
+		t.Errorf("Unexpected status code when disabled: %v", code)
+	}
+
+	s.supportInboundKey.Store(&key)
+
+	testCases := []struct {
+		body string
+		code int
+	}{
+		{`not json`, http.StatusBadRequest},
+		{`{"from": "user@example.com", "subject": "Support request abc"}`, http.StatusBadRequest},
+		// not a reply to support request, it should not be retried by mail provider
+		{`{"message_id": "123", "from": "user@example.com", "subject": "Hello"}`, http.StatusOK},
+	}
+
+	for i, tc := range testCases {
+		if code := postSupportInbound(handler, key, tc.body); code != tc.code {
+			t.Errorf("Unexpected status code in case %d: %v (expected %v)", i, code, tc.code)
+		}
+	}
+}
+
+func TestSupportTicketReply(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	ticketID := t.Name() + "_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	ticket, err := server.Store.Impl().CreateSupportTicket(ctx, user.ID, ticketID, "Help", "Nothing works")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	key := strings.Repeat("i", 32)
+	server.supportInboundKey.Store(&key)
+	defer func() {
+		empty := ""
+		server.supportInboundKey.Store(&empty)
+	}()
+
+	body := `{"message_id": "` + ticketID + `", "from": "User <` + user.Email + `>", "subject": "Re: [Private Captcha] Support request ` +
+		ticketID + `: Help", "text": "It works now, thanks!\n\nOn Mon, 1 Jan 2030 at 10:00, Support wrote:\n> Try again"}`
+
+	if code := postSupportInbound(srv, key, body); code != http.StatusAccepted {
+		t.Fatalf("Unexpected status code: %v", code)
+	}
+
+	// the same email is delivered again
+	if code := postSupportInbound(srv, key, body); code != http.StatusOK {
+		t.Errorf("Unexpected status code of duplicate: %v", code)
+	}
+
+	events, err := server.Store.Impl().RetrievePendingWebhookEvents(ctx, 10 /*max attempts*/, 100 /*limit*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	supportReplies := &maintenance.SupportReplies{
+		SupportEmail: config.NewStaticValue(common.SupportEmailKey, "support@example.com"),
+		AdminEmail:   config.NewStaticValue(common.AdminEmailKey, ""),
+	}
+
+	for _, event := range events {
+		if event.EventID == ticketID {
+			if err := supportReplies.HandleEvent(ctx, server.Store.Impl(), event); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	replies, err := server.Store.Impl().RetrieveSupportTicketsReplies(ctx, []int32{ticket.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(replies) != 1) || replies[0].FromSupport || (replies[0].Message != "It works now, thanks!") {
+		t.Fatalf("Unexpected replies: %+v", replies)
+	}
+
+	ticket, err = server.Store.Impl().RetrieveSupportTicket(ctx, ticketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ticket.Status != dbgen.SupportTicketStatusOpen {
+		t.Errorf("Unexpected ticket status: %v", ticket.Status)
+	}
+}
@@ -0,0 +1,139 @@
+package portal
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxPropertyTags      = 10
+	maxPropertyTagLength = 32
+	// for how long we aggregate stats for all properties with the same tag
+	tagStatsPeriod = 30 * 24 * time.Hour
+)
+
+type tagStats struct {
+	Requests int
+	Verifies int
+}
+
+type propertyTagsRenderContext struct {
+	// all distinct tags of the org properties
+	Tags      []string
+	ActiveTag string
+	TagStats  *tagStats
+}
+
+func normalizePropertyTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+func isValidPropertyTag(tag string) bool {
+	if (len(tag) == 0) || (utf8.RuneCountInString(tag) > maxPropertyTagLength) {
+		return false
+	}
+
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && (r != '-') && (r != '_') && (r != '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parsePropertyTags parses comma-separated list of tags and returns error message (for the user) if tags are invalid
+func parsePropertyTags(value string) ([]string, string) {
+	result := make([]string, 0)
+
+	for _, part := range strings.Split(value, ",") {
+		tag := normalizePropertyTag(part)
+		if len(tag) == 0 {
+			continue
+		}
+
+		if !isValidPropertyTag(tag) {
+			return nil, "Tags can only contain letters, digits, dashes, dots and underscores and be up to 32 characters long."
+		}
+
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	if len(result) > maxPropertyTags {
+		return nil, "Please use no more than 10 tags."
+	}
+
+	slices.Sort(result)
+
+	return result, ""
+}
+
+// applyPropertyTags fills tags of the properties (that user can access, see OrgProperties) and filters them
+// by the active tag (if any)
+func (s *Server) applyPropertyTags(ctx context.Context, orgID int32, properties []*userProperty, activeTag string) (propertyTagsRenderContext, []*userProperty) {
+	renderCtx := propertyTagsRenderContext{Tags: []string{}}
+
+	tags, err := s.Store.Impl().RetrieveOrgPropertyTags(ctx, orgID)
+	if err != nil {
+		return renderCtx, properties
+	}
+
+	// properties are already filtered by user's access, so tags of other properties are not exposed
+	propertyTags := make(map[string][]string, len(properties))
+	for _, p := range properties {
+		propertyTags[p.ID] = nil
+	}
+
+	for _, t := range tags {
+		id := strconv.Itoa(int(t.PropertyID))
+		if _, ok := propertyTags[id]; !ok {
+			continue
+		}
+
+		propertyTags[id] = append(propertyTags[id], t.Tag)
+
+		if !slices.Contains(renderCtx.Tags, t.Tag) {
+			renderCtx.Tags = append(renderCtx.Tags, t.Tag)
+		}
+	}
+
+	for _, p := range properties {
+		p.Tags = propertyTags[p.ID]
+	}
+
+	activeTag = normalizePropertyTag(activeTag)
+	if !slices.Contains(renderCtx.Tags, activeTag) {
+		return renderCtx, properties
+	}
+
+	renderCtx.ActiveTag = activeTag
+
+	filtered := make([]*userProperty, 0, len(properties))
+	propertyIDs := make([]int32, 0, len(properties))
+	for _, p := range properties {
+		if slices.Contains(p.Tags, activeTag) {
+			filtered = append(filtered, p)
+			if id, err := strconv.Atoi(p.ID); err == nil {
+				propertyIDs = append(propertyIDs, int32(id))
+			}
+		}
+	}
+
+	from := time.Now().UTC().Add(-tagStatsPeriod)
+	if stats, err := s.TimeSeries.RetrievePropertiesTotals(ctx, orgID, propertyIDs, from); err == nil {
+		renderCtx.TagStats = &tagStats{Requests: stats.RequestsCount, Verifies: stats.VerifiesCount}
+	}
+
+	return renderCtx, filtered
+}
+
+func (p *userProperty) TagsString() string {
+	return strings.Join(p.Tags, ", ")
+}
@@ -0,0 +1,147 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestParsePropertyTags(t *testing.T) {
+	testCases := []struct {
+		value string
+		tags  []string
+		valid bool
+	}{
+		{"", []string{}, true},
+		{" , ,", []string{}, true},
+		{"Marketing", []string{"marketing"}, true},
+		{"team a, marketing,team A", []string{"marketing", "team-a"}, true},
+		{"v1.2,foo_bar", []string{"foo_bar", "v1.2"}, true},
+		{"foo/bar", nil, false},
+		{"<script>", nil, false},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil, false},
+		{"1,2,3,4,5,6,7,8,9,10,11", nil, false},
+	}
+
+	for i, tc := range testCases {
+		tags, errMessage := parsePropertyTags(tc.value)
+		if valid := len(errMessage) == 0; valid != tc.valid {
+			t.Errorf("Unexpected validation result at %v: %v", i, errMessage)
+			continue
+		}
+
+		if !slices.Equal(tags, tc.tags) {
+			t.Errorf("Unexpected tags at %v: %v", i, tags)
+		}
+	}
+}
+
+func createTaggedPropertyForTest(ctx context.Context, t *testing.T, org *dbgen.Organization, name string, tags []string) *dbgen.Property {
+	property, err := server.Store.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
+		Name:       name,
+		OrgID:      db.Int(org.ID),
+		CreatorID:  org.UserID,
+		OrgOwnerID: org.UserID,
+		Domain:     "example.com",
+		Level:      db.Int2(int16(common.DifficultyLevelMedium)),
+		Growth:     dbgen.DifficultyGrowthMedium,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create new property: %v", err)
+	}
+
+	if err := server.Store.Impl().UpdatePropertyTags(ctx, property.ID, tags); err != nil {
+		t.Fatal(err)
+	}
+
+	return property
+}
+
+func TestPutPropertyUnchangedTags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	property := createTaggedPropertyForTest(ctx, t, org, "propertyName", []string{"marketing", "team-a"})
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{}
+	form.Set(common.ParamCSRFToken, server.XSRF.Token(strconv.Itoa(int(user.ID))))
+	form.Set(common.ParamName, "Updated Property Name")
+	form.Set(common.ParamDifficulty, strconv.Itoa(int(common.DifficultyLevelMedium)))
+	form.Set(common.ParamGrowth, "2")
+	form.Set(common.ParamTags, "team-a, marketing")
+
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/org/%d/property/%d/edit", org.ID, property.ID),
+		strings.NewReader(form.Encode()))
+	req.AddCookie(cookie)
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %v", w.Code)
+	}
+
+	events, err := server.Store.Impl().RetrievePropertyEvents(ctx, property.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range events {
+		if e.PropertyEvent.Setting == db.PropertySettingTags {
+			t.Errorf("Unchanged tags were rewritten: %+v", e.PropertyEvent)
+		}
+	}
+}
+
+func TestApplyPropertyTagsOnlyAccessible(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	_, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	accessible := createTaggedPropertyForTest(ctx, t, org, "accessible", []string{"public"})
+	_ = createTaggedPropertyForTest(ctx, t, org, "restricted", []string{"secret"})
+
+	renderCtx, properties := server.applyPropertyTags(ctx, org.ID, propertiesToUserProperties(ctx, []*dbgen.Property{accessible}), "secret")
+	if !slices.Equal(renderCtx.Tags, []string{"public"}) {
+		t.Errorf("Unexpected tags: %v", renderCtx.Tags)
+	}
+
+	if (len(renderCtx.ActiveTag) > 0) || (len(properties) != 1) {
+		t.Errorf("Tag of inaccessible property was applied: %v", renderCtx.ActiveTag)
+	}
+}
@@ -0,0 +1,88 @@
+package portal
+
+import (
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
+)
+
+const (
+	maxLoginThrottleBuckets = 10_000
+	// a single email can be attempted a few times in a row and then once every few minutes
+	loginEmailBucketCap    = 5
+	loginEmailLeakInterval = 3 * time.Minute
+	// multiple legitimate users can share one IP address (NATs, VPNs, offices) so the budget is larger
+	loginIPBucketCap    = 30
+	loginIPLeakInterval = 20 * time.Second
+	// throttle kinds for the metric
+	loginThrottleEmail = "email"
+	loginThrottleIP    = "ip"
+	// endpoints have independent budgets
+	loginThrottleLogin     = "login"
+	loginThrottleTwoFactor = "twofactor"
+	// NOTE: the same message is shown regardless if account exists or not
+	loginThrottledError = "Too many sign in attempts. Please try again later."
+)
+
+type emailBuckets = leakybucket.Manager[string, leakybucket.ConstLeakyBucket[string], *leakybucket.ConstLeakyBucket[string]]
+type ipAddrBuckets = leakybucket.Manager[netip.Addr, leakybucket.ConstLeakyBucket[netip.Addr], *leakybucket.ConstLeakyBucket[netip.Addr]]
+
+// loginThrottle limits attempts on a sign in endpoint independently per email and per client IP, in addition
+// to the global portal rate limiter. Former protects a single account from distributed guessing and latter
+// protects all accounts from a single source
+type loginThrottle struct {
+	endpoint string
+	emails   *emailBuckets
+	ips      *ipAddrBuckets
+}
+
+func newLoginThrottle(endpoint string) *loginThrottle {
+	return &loginThrottle{
+		endpoint: endpoint,
+		emails:   leakybucket.NewManager[string, leakybucket.ConstLeakyBucket[string]](maxLoginThrottleBuckets, loginEmailBucketCap, loginEmailLeakInterval),
+		ips:      leakybucket.NewManager[netip.Addr, leakybucket.ConstLeakyBucket[netip.Addr]](maxLoginThrottleBuckets, loginIPBucketCap, loginIPLeakInterval),
+	}
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// allow records the attempt and returns the kind of throttle that rejected it (empty if attempt is allowed)
+// and when it makes sense to retry. Email budget is not spent on attempts that are rejected by IP
+func (lt *loginThrottle) allow(email string, addr netip.Addr, tnow time.Time) (string, time.Duration) {
+	// missing IP (misconfiguration or tests) is handled by the global rate limiter
+	if addr.IsValid() {
+		if result := lt.ips.Add(addr, 1, tnow); result.Added == 0 {
+			return loginThrottleIP, result.RetryAfter
+		}
+	}
+
+	if key := normalizeLoginEmail(email); len(key) > 0 {
+		if result := lt.emails.Add(key, 1, tnow); result.Added == 0 {
+			return loginThrottleEmail, result.RetryAfter
+		}
+	}
+
+	return "", 0
+}
+
+// throttleLogin returns true if the attempt should be rejected, in which case Retry-After header is already set
+func (s *Server) throttleLogin(w http.ResponseWriter, r *http.Request, lt *loginThrottle, email string) bool {
+	addr, _ := r.Context().Value(common.RateLimitKeyContextKey).(netip.Addr)
+
+	kind, retryAfter := lt.allow(email, addr, time.Now())
+	if len(kind) == 0 {
+		return false
+	}
+
+	s.Metrics.ObserveLoginThrottled(lt.endpoint, kind)
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(retryAfter.Seconds()))))
+
+	return true
+}
@@ -0,0 +1,67 @@
+package portal
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePerEmail(t *testing.T) {
+	t.Parallel()
+
+	lt := newLoginThrottle(loginThrottleLogin)
+	tnow := time.Now()
+
+	for i := 0; i < loginEmailBucketCap; i++ {
+		// different IPs to not hit IP budget
+		addr := netip.AddrFrom4([4]byte{10, 0, 0, byte(i)})
+		if kind, _ := lt.allow("foo@bar.com", addr, tnow); len(kind) > 0 {
+			t.Fatalf("Attempt %d was throttled by %v", i, kind)
+		}
+	}
+
+	kind, retryAfter := lt.allow("  FOO@bar.com ", netip.AddrFrom4([4]byte{10, 0, 1, 1}), tnow)
+	if kind != loginThrottleEmail {
+		t.Errorf("Expected email throttle, but got %q", kind)
+	}
+
+	if retryAfter <= 0 {
+		t.Errorf("Unexpected retry after: %v", retryAfter)
+	}
+
+	if kind, _ := lt.allow("other@bar.com", netip.AddrFrom4([4]byte{10, 0, 1, 1}), tnow); len(kind) > 0 {
+		t.Errorf("Other email was throttled by %v", kind)
+	}
+
+	if kind, _ := lt.allow("foo@bar.com", netip.Addr{}, tnow.Add(loginEmailLeakInterval)); len(kind) > 0 {
+		t.Errorf("Email was throttled after leak interval by %v", kind)
+	}
+}
+
+func TestLoginThrottlePerIP(t *testing.T) {
+	t.Parallel()
+
+	lt := newLoginThrottle(loginThrottleTwoFactor)
+	tnow := time.Now()
+	addr := netip.AddrFrom4([4]byte{192, 168, 0, 1})
+
+	for i := 0; i < loginIPBucketCap; i++ {
+		if kind, _ := lt.allow(fmt.Sprintf("user%d@bar.com", i), addr, tnow); len(kind) > 0 {
+			t.Fatalf("Attempt %d was throttled by %v", i, kind)
+		}
+	}
+
+	const email = "fresh@bar.com"
+
+	if kind, _ := lt.allow(email, addr, tnow); kind != loginThrottleIP {
+		t.Errorf("Expected IP throttle, but got %q", kind)
+	}
+
+	// attempts rejected by IP do not spend email budget
+	for i := 0; i < loginEmailBucketCap; i++ {
+		if kind, _ := lt.allow(email, netip.AddrFrom4([4]byte{192, 168, 1, byte(i)}), tnow); len(kind) > 0 {
+			t.Fatalf("Attempt %d from other IP was throttled by %v", i, kind)
+		}
+	}
+}
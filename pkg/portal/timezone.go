@@ -0,0 +1,114 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	maxTimezoneLength = 64
+)
+
+var (
+	// NOTE: any IANA timezone is accepted, this list is only used to render choices in settings
+	commonTimezones = []string{
+		"UTC",
+		"Pacific/Honolulu",
+		"America/Anchorage",
+		"America/Los_Angeles",
+		"America/Denver",
+		"America/Chicago",
+		"America/New_York",
+		"America/Halifax",
+		"America/Sao_Paulo",
+		"Atlantic/Azores",
+		"Europe/London",
+		"Europe/Lisbon",
+		"Europe/Berlin",
+		"Europe/Paris",
+		"Europe/Amsterdam",
+		"Europe/Stockholm",
+		"Europe/Warsaw",
+		"Europe/Helsinki",
+		"Europe/Kyiv",
+		"Europe/Istanbul",
+		"Europe/Moscow",
+		"Africa/Lagos",
+		"Africa/Johannesburg",
+		"Asia/Dubai",
+		"Asia/Karachi",
+		"Asia/Kolkata",
+		"Asia/Bangkok",
+		"Asia/Singapore",
+		"Asia/Shanghai",
+		"Asia/Tokyo",
+		"Australia/Perth",
+		"Australia/Sydney",
+		"Pacific/Auckland",
+	}
+)
+
+// timezoneChoices returns the list of timezones for the settings form, including the current one
+func timezoneChoices(current string) []string {
+	if (len(current) == 0) || slices.Contains(commonTimezones, current) {
+		return commonTimezones
+	}
+
+	return append([]string{current}, commonTimezones...)
+}
+
+func parseTimezone(name string) (*time.Location, bool) {
+	if (len(name) == 0) || (len(name) > maxTimezoneLength) {
+		return nil, false
+	}
+
+	// "Local" depends on the server and is not a valid user preference
+	if name == "Local" {
+		return nil, false
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+
+	return loc, true
+}
+
+// userLocation returns the timezone that user's stats should be bucketed in
+func userLocation(ctx context.Context, user *dbgen.User) *time.Location {
+	if (user == nil) || (len(user.Timezone) == 0) {
+		return time.UTC
+	}
+
+	loc, ok := parseTimezone(user.Timezone)
+	if !ok {
+		slog.WarnContext(ctx, "Failed to load user timezone", "userID", user.ID, "timezone", user.Timezone)
+		return time.UTC
+	}
+
+	return loc
+}
+
+func (s *Server) updateUserTimezone(ctx context.Context, user *dbgen.User, timezone string) error {
+	if timezone == user.Timezone {
+		return nil
+	}
+
+	if _, ok := parseTimezone(timezone); !ok {
+		slog.WarnContext(ctx, "Invalid timezone", "timezone", timezone)
+		return ErrInvalidRequestArg
+	}
+
+	if _, err := s.Store.Impl().UpdateUserTimezone(ctx, user.ID, timezone); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Audit: user changed timezone", "userID", user.ID, "timezone", timezone)
+
+	return nil
+}
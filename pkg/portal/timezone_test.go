@@ -0,0 +1,68 @@
+package portal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestParseTimezone(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		valid bool
+	}{
+		{"UTC", true},
+		{"Europe/Berlin", true},
+		{"America/New_York", true},
+		{"", false},
+		{"Local", false},
+		{"Mars/Olympus_Mons", false},
+		{"../../etc/passwd", false},
+	}
+
+	for _, tc := range testCases {
+		if _, ok := parseTimezone(tc.name); ok != tc.valid {
+			t.Errorf("Unexpected result for timezone %q: %v", tc.name, ok)
+		}
+	}
+}
+
+func TestTimezoneChoices(t *testing.T) {
+	t.Parallel()
+
+	if choices := timezoneChoices("Europe/Berlin"); len(choices) != len(commonTimezones) {
+		t.Errorf("Common timezone was added twice")
+	}
+
+	if choices := timezoneChoices("Asia/Kathmandu"); (len(choices) != len(commonTimezones)+1) || (choices[0] != "Asia/Kathmandu") {
+		t.Errorf("Custom timezone is missing from choices")
+	}
+
+	for _, tz := range commonTimezones {
+		if _, ok := parseTimezone(tz); !ok {
+			t.Errorf("Failed to load timezone %v", tz)
+		}
+	}
+}
+
+func TestUserLocation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	if loc := userLocation(ctx, &dbgen.User{}); loc != time.UTC {
+		t.Errorf("Unexpected default location: %v", loc)
+	}
+
+	if loc := userLocation(ctx, &dbgen.User{Timezone: "invalid"}); loc != time.UTC {
+		t.Errorf("Unexpected location for invalid timezone: %v", loc)
+	}
+
+	if loc := userLocation(ctx, &dbgen.User{Timezone: "Asia/Tokyo"}); loc.String() != "Asia/Tokyo" {
+		t.Errorf("Unexpected location: %v", loc)
+	}
+}
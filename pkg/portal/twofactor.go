@@ -2,18 +2,36 @@ package portal
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
 
 const (
 	twofactorFormTemplate = ""
 	twofactorTemplate     = "twofactor/twofactor.html"
+	// failed attempts within the session that are not delayed
+	twoFactorFreeAttempts = 2
+	twoFactorBaseBackoff  = 2 * time.Second
+	twoFactorMaxBackoff   = 5 * time.Minute
+	// after this many failures the code is invalidated and user has to sign in again
+	twoFactorMaxSessionFailures = 5
+	// after this many failures across sessions (since the last successful sign-in) the account is locked. Failures
+	// are forgotten if there were none for the lockout duration
+	twoFactorMaxAccountFailures = 10
+	twoFactorLockoutDuration    = 1 * time.Hour
+	// results for failed two-factor attempts metric
+	twoFactorResultInvalid   = "invalid"
+	twoFactorResultThrottled = "throttled"
+	twoFactorResultLocked    = "locked"
+	accountLockedError       = "Too many failed attempts. Signing in is temporarily blocked, please try again later."
 )
 
 type twoFactorRenderContext struct {
@@ -22,6 +40,69 @@ type twoFactorRenderContext struct {
 	Error string
 }
 
+// twoFactorBackoff returns the delay required after the given number of failed attempts (it grows exponentially)
+func twoFactorBackoff(failures int) time.Duration {
+	if failures <= twoFactorFreeAttempts {
+		return 0
+	}
+
+	shift := failures - twoFactorFreeAttempts - 1
+	if shift >= 16 {
+		return twoFactorMaxBackoff
+	}
+
+	return min(twoFactorBaseBackoff<<shift, twoFactorMaxBackoff)
+}
+
+func isUserLocked(lockout *dbgen.UserLockout, tnow time.Time) bool {
+	return (lockout != nil) && lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(tnow)
+}
+
+func (s *Server) userLocked(ctx context.Context, userID int32, tnow time.Time) bool {
+	lockout, err := s.Store.Impl().RetrieveUserLockout(ctx, userID)
+	if err != nil {
+		return false
+	}
+
+	return isUserLocked(lockout, tnow)
+}
+
+func resetTwoFactorFailures(sess *common.Session) {
+	_ = sess.Delete(session.KeyTwoFactorFailures)
+	_ = sess.Delete(session.KeyTwoFactorLastFailure)
+}
+
+// twoFactorFailed records failed attempt in the session and for the account (if known) and returns
+// if the code should not be accepted anymore
+func (s *Server) twoFactorFailed(ctx context.Context, sess *common.Session, step int, email string, tnow time.Time) bool {
+	s.Metrics.ObserveTwoFactorFailure(twoFactorResultInvalid)
+
+	failures, _ := sess.Get(session.KeyTwoFactorFailures).(int)
+	failures++
+	_ = sess.Set(session.KeyTwoFactorFailures, failures)
+	_ = sess.Set(session.KeyTwoFactorLastFailure, tnow.Unix())
+
+	if userID, ok := sess.Get(session.KeyUserID).(int32); ok && (step == loginStepSignInVerify) {
+		lockout, locked, err := s.Store.Impl().RecordTwoFactorFailure(ctx, userID, twoFactorMaxAccountFailures, twoFactorLockoutDuration, tnow)
+		if err == nil && locked {
+			go func(bctx context.Context) {
+				if err := s.Mailer.SendAccountLocked(bctx, email, lockout.LockedUntil.Time); err != nil {
+					slog.ErrorContext(bctx, "Failed to send account locked notification", "userID", userID, common.ErrAttr(err))
+				}
+
+				message := "Signing in was temporarily blocked after too many failed verification attempts."
+				if _, err := s.Store.Impl().CreateUserNotification(bctx, userID, dbgen.NotificationCategorySecurity, message); err != nil {
+					slog.ErrorContext(bctx, "Failed to create account locked user notification", "userID", userID, common.ErrAttr(err))
+				}
+			}(common.CopyTraceID(ctx, context.Background()))
+
+			return true
+		}
+	}
+
+	return failures >= twoFactorMaxSessionFailures
+}
+
 func (s *Server) getTwoFactor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -88,14 +169,65 @@ func (s *Server) postTwoFactor(w http.ResponseWriter, r *http.Request) {
 		Email: common.MaskEmail(email, '*'),
 	}
 
+	tnow := time.Now().UTC()
+
+	if lastFailure, ok := sess.Get(session.KeyTwoFactorLastFailure).(int64); ok {
+		failures, _ := sess.Get(session.KeyTwoFactorFailures).(int)
+		if wait := time.Unix(lastFailure, 0).Add(twoFactorBackoff(failures)).Sub(tnow); wait > 0 {
+			s.Metrics.ObserveTwoFactorFailure(twoFactorResultThrottled)
+			slog.WarnContext(ctx, "Code verification is throttled", "failures", failures, "wait", wait.Seconds())
+			data.Error = fmt.Sprintf("Too many attempts. Please try again in %d seconds.", int(math.Ceil(wait.Seconds())))
+			s.render(w, r, "twofactor/form.html", data)
+			return
+		}
+	}
+
+	if s.throttleLogin(w, r, s.twoFactorThrottle, email) {
+		slog.WarnContext(ctx, "Code verification attempt is throttled")
+		data.Error = loginThrottledError
+		s.render(w, r, "twofactor/form.html", data)
+		return
+	}
+
+	if userID, ok := sess.Get(session.KeyUserID).(int32); ok && (step == loginStepSignInVerify) && s.userLocked(ctx, userID, tnow) {
+		s.Metrics.ObserveTwoFactorFailure(twoFactorResultLocked)
+		slog.WarnContext(ctx, "User is locked", "userID", userID)
+		data.Error = accountLockedError
+		s.render(w, r, "twofactor/form.html", data)
+		return
+	}
+
 	formCode := r.FormValue(common.ParamVerificationCode)
 	if enteredCode, err := strconv.Atoi(formCode); (err != nil) || (enteredCode != sentCode) {
-		data.Error = "Code is not valid."
 		slog.WarnContext(ctx, "Code verification failed", "actual", formCode, "expected", sentCode, common.ErrAttr(err))
+
+		if exhausted := s.twoFactorFailed(ctx, sess, step, email, tnow); exhausted {
+			slog.WarnContext(ctx, "Too many failed two-factor attempts, restarting sign in")
+			_ = sess.Delete(session.KeyTwoFactorCode)
+			_ = sess.Delete(session.KeyMagicLinkNonce)
+			_ = sess.Delete(session.KeyLoginStep)
+			resetTwoFactorFailures(sess)
+			common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusOK, w, r)
+			return
+		}
+
+		data.Error = "Code is not valid."
 		s.render(w, r, "twofactor/form.html", data)
 		return
 	}
 
+	s.completeLogin(w, r, sess, step, email)
+}
+
+// completeLogin finishes the sign in (or sign up) after user's email was verified
+func (s *Server) completeLogin(w http.ResponseWriter, r *http.Request, sess *common.Session, step int, email string) {
+	ctx := r.Context()
+
+	// session ID could be known to somebody else before sign in (session fixation)
+	sess = s.Sessions.SessionRegenerate(w, r, sess)
+
+	resetTwoFactorFailures(sess)
+
 	if step == loginStepSignUpVerify {
 		slog.DebugContext(ctx, "Proceeding with the user registration flow after 2FA")
 		if user, _, err := s.doRegister(ctx, sess); err == nil {
@@ -109,8 +241,16 @@ func (s *Server) postTwoFactor(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	signIn := s.signInInfo(r)
+	userAgent := r.UserAgent()
+	_ = sess.Set(session.KeyLoginDevice, signIn.Device)
+	_ = sess.Set(session.KeyLoginCountry, signIn.Country)
+
 	go func(bctx context.Context) {
 		if userID, ok := sess.Get(session.KeyUserID).(int32); ok {
+			s.recordSignIn(bctx, sess, userID, email, signIn, userAgent)
+			_ = s.Store.Impl().ResetUserLockout(bctx, userID)
+
 			slog.DebugContext(bctx, "Fetching system notification for user", "userID", userID)
 			if n, err := s.Store.Impl().RetrieveUserNotification(bctx, time.Now().UTC(), userID); err == nil {
 				_ = sess.Set(session.KeyNotificationID, n.ID)
@@ -122,6 +262,8 @@ func (s *Server) postTwoFactor(w http.ResponseWriter, r *http.Request) {
 
 	_ = sess.Set(session.KeyLoginStep, loginStepCompleted)
 	_ = sess.Delete(session.KeyTwoFactorCode)
+	// any outstanding sign-in links cannot be used anymore
+	_ = sess.Delete(session.KeyMagicLinkNonce)
 	_ = sess.Delete(session.KeyUserEmail)
 	_ = sess.Set(session.KeyPersistent, true)
 
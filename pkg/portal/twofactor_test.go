@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
@@ -42,3 +43,25 @@ func TestPostTwoFactor(t *testing.T) {
 		t.Errorf("Unexpected portal response code: %v", w.Code)
 	}
 }
+
+func TestTwoFactorBackoff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{0, 0},
+		{twoFactorFreeAttempts, 0},
+		{twoFactorFreeAttempts + 1, twoFactorBaseBackoff},
+		{twoFactorFreeAttempts + 2, 2 * twoFactorBaseBackoff},
+		{twoFactorFreeAttempts + 3, 4 * twoFactorBaseBackoff},
+		{100, twoFactorMaxBackoff},
+	}
+
+	for _, tc := range testCases {
+		if actual := twoFactorBackoff(tc.failures); actual != tc.expected {
+			t.Errorf("Unexpected backoff for %v failures: %v (expected %v)", tc.failures, actual, tc.expected)
+		}
+	}
+}
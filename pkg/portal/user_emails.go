@@ -0,0 +1,310 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/badoux/checkmail"
+)
+
+const (
+	emailConfirmTemplate         = "email-confirm/confirm.html"
+	settingsEmailsFormTemplate   = "settings-general/emails-form.html"
+	maxSecondaryEmails           = 5
+	verifyTokenLength            = revertTokenLength
+	primaryBillingContactEmailID = "0"
+)
+
+type secondaryEmail struct {
+	ID       string
+	Email    string
+	Verified bool
+}
+
+// orgBillingContact is the email that receives billing and usage alerts of the organization (owned by the user)
+type orgBillingContact struct {
+	OrgID   string
+	OrgName string
+	EmailID string
+}
+
+type settingsEmailsRenderContext struct {
+	Emails        []*secondaryEmail
+	BillingOrgs   []*orgBillingContact
+	NewEmail      string
+	NewEmailError string
+}
+
+func (c *settingsEmailsRenderContext) VerifiedEmails() []*secondaryEmail {
+	result := make([]*secondaryEmail, 0, len(c.Emails))
+	for _, e := range c.Emails {
+		if e.Verified {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+type emailConfirmRenderContext struct {
+	AlertRenderContext
+	Token     string
+	Email     string
+	Confirmed bool
+}
+
+func userEmailsToSecondaryEmails(emails []*dbgen.UserEmail) []*secondaryEmail {
+	result := make([]*secondaryEmail, 0, len(emails))
+	for _, e := range emails {
+		result = append(result, &secondaryEmail{
+			ID:       strconv.Itoa(int(e.ID)),
+			Email:    e.Email,
+			Verified: e.VerifiedAt.Valid,
+		})
+	}
+	return result
+}
+
+func (s *Server) fillEmailsSettings(ctx context.Context, renderCtx *settingsGeneralRenderContext, user *dbgen.User) error {
+	emails, err := s.Store.Impl().RetrieveUserEmails(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	renderCtx.Emails = userEmailsToSecondaryEmails(emails)
+
+	orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	contacts, err := s.Store.Impl().RetrieveOrgBillingContacts(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	renderCtx.BillingOrgs = make([]*orgBillingContact, 0, len(orgs))
+	for _, o := range orgs {
+		if o.Level != dbgen.AccessLevelOwner {
+			continue
+		}
+
+		contact := &orgBillingContact{
+			OrgID:   strconv.Itoa(int(o.Organization.ID)),
+			OrgName: o.Organization.Name,
+			EmailID: primaryBillingContactEmailID,
+		}
+
+		if i := slices.IndexFunc(contacts, func(c *dbgen.OrgBillingContact) bool { return c.OrgID == o.Organization.ID }); i != -1 {
+			contact.EmailID = strconv.Itoa(int(contacts[i].EmailID))
+		}
+
+		renderCtx.BillingOrgs = append(renderCtx.BillingOrgs, contact)
+	}
+
+	return nil
+}
+
+func (s *Server) emailsSettingsModel(ctx context.Context, user *dbgen.User) (*settingsGeneralRenderContext, error) {
+	renderCtx := s.createGeneralSettingsModel(ctx, user)
+	if err := s.fillEmailsSettings(ctx, renderCtx, user); err != nil {
+		return nil, err
+	}
+
+	return renderCtx, nil
+}
+
+func (s *Server) validateSecondaryEmail(ctx context.Context, user *dbgen.User, emails []*secondaryEmail, email string) string {
+	if err := checkmail.ValidateFormat(email); err != nil {
+		slog.WarnContext(ctx, "Failed to validate email format", common.ErrAttr(err))
+		return "Email address is not valid."
+	}
+
+	if strings.EqualFold(email, user.Email) {
+		return "This is already your primary email address."
+	}
+
+	if slices.ContainsFunc(emails, func(e *secondaryEmail) bool { return e.Verified && strings.EqualFold(e.Email, email) }) {
+		return "This email address is already added."
+	}
+
+	if len(emails) >= maxSecondaryEmails {
+		return "You cannot add more email addresses."
+	}
+
+	return ""
+}
+
+func (s *Server) postUserEmail(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	renderCtx, err := s.emailsSettingsModel(ctx, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	email := strings.TrimSpace(r.FormValue(common.ParamEmail))
+	renderCtx.NewEmail = email
+
+	if emailError := s.validateSecondaryEmail(ctx, user, renderCtx.Emails, email); len(emailError) > 0 {
+		renderCtx.NewEmailError = emailError
+		return renderCtx, settingsEmailsFormTemplate, nil
+	}
+
+	token, err := newRevertToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate verification token", common.ErrAttr(err))
+		return nil, "", err
+	}
+
+	expiresAt := time.Now().UTC().Add(common.EmailVerificationTimeout)
+	userEmail, err := s.Store.Impl().CreateUserEmail(ctx, user.ID, email, token, expiresAt)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to add email address. Please try again."
+		return renderCtx, settingsEmailsFormTemplate, nil
+	}
+
+	if err := s.Mailer.SendEmailVerification(ctx, email, s.PartsURL(common.EmailEndpoint, common.ConfirmEndpoint, token)); err != nil {
+		slog.ErrorContext(ctx, "Failed to send email verification", "userID", user.ID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Failed to send confirmation email. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Confirmation link was sent to the new email address."
+		renderCtx.NewEmail = ""
+	}
+
+	if !slices.ContainsFunc(renderCtx.Emails, func(e *secondaryEmail) bool { return e.ID == strconv.Itoa(int(userEmail.ID)) }) {
+		renderCtx.Emails = append(renderCtx.Emails, userEmailsToSecondaryEmails([]*dbgen.UserEmail{userEmail})...)
+	}
+
+	return renderCtx, settingsEmailsFormTemplate, nil
+}
+
+func (s *Server) deleteUserEmail(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	emailID, value, err := common.IntPathArg(r, common.ParamID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse email ID from request", "value", value, common.ErrAttr(err))
+		return nil, "", errInvalidPathArg
+	}
+
+	if err := s.Store.Impl().DeleteUserEmail(ctx, user.ID, int32(emailID)); err != nil {
+		return nil, "", err
+	}
+
+	renderCtx, err := s.emailsSettingsModel(ctx, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	renderCtx.SuccessMessage = "Email address was removed."
+
+	return renderCtx, settingsEmailsFormTemplate, nil
+}
+
+func (s *Server) putOrgBillingContact(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	orgID, err := strconv.Atoi(r.FormValue(common.ParamOrg))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse org ID", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	emailID, err := strconv.Atoi(r.FormValue(common.ParamEmailID))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse email ID", common.ErrAttr(err))
+		return nil, "", ErrInvalidRequestArg
+	}
+
+	org, err := s.Store.Impl().RetrieveUserOrganization(ctx, user.ID, int32(orgID))
+	if err != nil {
+		return nil, "", err
+	}
+
+	updateErr := s.Store.Impl().UpdateOrgBillingContact(ctx, org, user.ID, int32(emailID))
+	if updateErr == db.ErrPermissions {
+		return nil, "", updateErr
+	}
+
+	renderCtx, err := s.emailsSettingsModel(ctx, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if updateErr != nil {
+		renderCtx.ErrorMessage = "Failed to update billing contact. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Billing contact was updated."
+	}
+
+	return renderCtx, settingsEmailsFormTemplate, nil
+}
+
+func (s *Server) getEmailConfirm(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	token := r.PathValue(common.ParamToken)
+	if len(token) != 2*verifyTokenLength {
+		return nil, "", errInvalidPathArg
+	}
+
+	return &emailConfirmRenderContext{Token: token}, emailConfirmTemplate, nil
+}
+
+func (s *Server) postEmailConfirm(w http.ResponseWriter, r *http.Request) (Model, string, error) {
+	ctx := r.Context()
+
+	token := r.PathValue(common.ParamToken)
+	if len(token) != 2*verifyTokenLength {
+		return nil, "", errInvalidPathArg
+	}
+
+	renderCtx := &emailConfirmRenderContext{Token: token}
+
+	userEmail, err := s.Store.Impl().VerifyUserEmail(ctx, token, time.Now().UTC())
+	switch err {
+	case nil:
+		slog.InfoContext(ctx, "Audit: secondary email verified", "userID", userEmail.UserID, "emailID", userEmail.ID)
+		renderCtx.Confirmed = true
+		renderCtx.Email = userEmail.Email
+		renderCtx.SuccessMessage = "Email address was confirmed."
+	case db.ErrRecordNotFound:
+		renderCtx.ErrorMessage = "This link is invalid or has expired."
+	case db.ErrMaintenance:
+		return nil, "", err
+	default:
+		renderCtx.ErrorMessage = "Failed to confirm email address. Please try again."
+	}
+
+	return renderCtx, emailConfirmTemplate, nil
+}
@@ -0,0 +1,95 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+)
+
+func TestValidateSecondaryEmail(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	user := &dbgen.User{Email: "primary@example.com"}
+	emails := []*secondaryEmail{{ID: "1", Email: "billing@example.com", Verified: true}}
+
+	testCases := []struct {
+		email string
+		valid bool
+	}{
+		{"new@example.com", true},
+		{"invalid", false},
+		{"Primary@example.com", false},
+		{"billing@example.com", false},
+	}
+
+	for _, tc := range testCases {
+		if msg := s.validateSecondaryEmail(context.TODO(), user, emails, tc.email); (len(msg) == 0) != tc.valid {
+			t.Errorf("Unexpected validation result for %v: %v", tc.email, msg)
+		}
+	}
+}
+
+func TestSecondaryEmailBillingContact(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.TODO()
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	token, err := newRevertToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	billingEmail := "billing_" + user.Email
+	userEmail, err := server.Store.Impl().CreateUserEmail(ctx, user.ID, billingEmail, token, time.Now().UTC().Add(common.EmailVerificationTimeout))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only verified emails can be billing contacts
+	if err := server.Store.Impl().UpdateOrgBillingContact(ctx, org, user.ID, userEmail.ID); err == nil {
+		t.Error("Unverified email was set as billing contact")
+	}
+
+	srv := http.NewServeMux()
+	_ = server.Setup(srv, portalDomain(), common.NoopMiddleware)
+
+	path := "/" + common.EmailEndpoint + "/" + common.ConfirmEndpoint + "/" + token
+
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if (w.Code != http.StatusOK) || !strings.Contains(w.Body.String(), billingEmail) {
+		t.Fatalf("Unexpected confirmation response: %v", w.Code)
+	}
+
+	if err := server.Store.Impl().UpdateOrgBillingContact(ctx, org, user.ID, userEmail.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if email, err := server.Store.Impl().RetrieveOrgBillingEmail(ctx, org.ID); (err != nil) || (email != billingEmail) {
+		t.Errorf("Unexpected billing email: %v (%v)", email, err)
+	}
+
+	if err := server.Store.Impl().DeleteUserEmail(ctx, user.ID, userEmail.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.Store.Impl().RetrieveOrgBillingEmail(ctx, org.ID); err == nil {
+		t.Error("Billing contact was not reset with email removal")
+	}
+}
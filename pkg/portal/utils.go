@@ -97,7 +97,7 @@ func (s *Server) OrgID(r *http.Request) (int32, error) {
 	return int32(orgID), nil
 }
 
-func (s *Server) Property(orgID int32, r *http.Request) (*dbgen.Property, error) {
+func (s *Server) Property(orgID, userID int32, r *http.Request) (*dbgen.Property, error) {
 	ctx := r.Context()
 
 	propertyID, value, err := common.IntPathArg(r, common.ParamProperty)
@@ -106,7 +106,7 @@ func (s *Server) Property(orgID int32, r *http.Request) (*dbgen.Property, error)
 		return nil, errInvalidPathArg
 	}
 
-	property, err := s.Store.Impl().RetrieveOrgProperty(ctx, orgID, int32(propertyID))
+	property, err := s.Store.Impl().RetrieveOrgProperty(ctx, orgID, userID, int32(propertyID))
 	if err != nil {
 		if err == db.ErrSoftDeleted {
 			return nil, errPropertySoftDeleted
@@ -119,6 +119,56 @@ func (s *Server) Property(orgID int32, r *http.Request) (*dbgen.Property, error)
 	return property, nil
 }
 
+// OrgProperties returns properties of the organization that user is allowed to access
+func (s *Server) OrgProperties(ctx context.Context, org *dbgen.Organization, userID int32) ([]*dbgen.Property, error) {
+	properties, err := s.Store.Impl().RetrieveOrgProperties(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.UserID.Int32 == userID {
+		return properties, nil
+	}
+
+	permissions, err := s.Store.Impl().RetrieveUserPropertyPermissions(ctx, org.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return accessibleProperties(properties, userID, permissions), nil
+}
+
+func accessibleProperties(properties []*dbgen.Property, userID int32, permissions []*dbgen.PropertyPermission) []*dbgen.Property {
+	if len(permissions) == 0 {
+		return properties
+	}
+
+	result := make([]*dbgen.Property, 0, len(permissions))
+	for _, p := range properties {
+		if _, ok := db.PropertyAccessLevel(p, userID, permissions); ok {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+// canEditProperty is true for org owner and for members with "edit" access to the property
+func (s *Server) canEditProperty(ctx context.Context, org *dbgen.Organization, property *dbgen.Property, userID int32) bool {
+	if org.UserID.Int32 == userID {
+		return true
+	}
+
+	permissions, err := s.Store.Impl().RetrieveUserPropertyPermissions(ctx, org.ID, userID)
+	if err != nil {
+		return false
+	}
+
+	level, ok := db.PropertyAccessLevel(property, userID, permissions)
+
+	return ok && (level == dbgen.PropertyAccessLevelEdit)
+}
+
 func (s *Server) Session(w http.ResponseWriter, r *http.Request) *common.Session {
 	ctx := r.Context()
 	sess, ok := ctx.Value(common.SessionContextKey).(*common.Session)
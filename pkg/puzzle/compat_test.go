@@ -0,0 +1,246 @@
+package puzzle
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// compatibility fixtures are payloads exactly as widget puts them into the form field (solutions.puzzle.signature)
+// along with the salt they were signed with. Payloads captured from the widget (e.g. from local environment, where the
+// salt is known) can be added to the corpus as is. Existing fixtures must never be regenerated: any change in
+// pkg/puzzle that fails them will also break widgets that are already deployed.
+const compatFixturesDir = "testdata/compat"
+
+var generateCompatFixtures = flag.Bool("compat-generate", false, "generate missing compatibility fixtures")
+
+type compatFixture struct {
+	Description    string `json:"description"`
+	Salt           string `json:"salt"`
+	ExtraSalt      string `json:"extra_salt,omitempty"`
+	Payload        string `json:"payload"`
+	Version        uint8  `json:"version"`
+	Difficulty     uint8  `json:"difficulty"`
+	SolutionsCount uint8  `json:"solutions_count"`
+	Action         string `json:"action,omitempty"`
+	Wasm           bool   `json:"wasm"`
+}
+
+func readCompatFixtures(t *testing.T) map[string]*compatFixture {
+	files, err := filepath.Glob(filepath.Join(compatFixturesDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := make(map[string]*compatFixture)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fixture := &compatFixture{}
+		if err := json.Unmarshal(data, fixture); err != nil {
+			t.Fatalf("Failed to parse fixture %s: %v", file, err)
+		}
+
+		fixtures[strings.TrimSuffix(filepath.Base(file), ".json")] = fixture
+	}
+
+	return fixtures
+}
+
+func decodeFixtureBytes(t *testing.T, value string) []byte {
+	if len(value) == 0 {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestWidgetCompatibility(t *testing.T) {
+	t.Parallel()
+
+	fixtures := readCompatFixtures(t)
+	if len(fixtures) == 0 {
+		t.Fatal("Compatibility fixtures are missing")
+	}
+
+	for name, fixture := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.TODO()
+			salt := NewSalt(decodeFixtureBytes(t, fixture.Salt))
+			extraSalt := decodeFixtureBytes(t, fixture.ExtraSalt)
+
+			payload, err := ParseVerifyPayload(ctx, fixture.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if payload.NeedsExtraSalt() != (len(extraSalt) > 0) {
+				t.Errorf("Unexpected extra salt flag: %v", payload.NeedsExtraSalt())
+			}
+
+			if err := payload.VerifySignature(ctx, salt, extraSalt); err != nil {
+				t.Fatal(err)
+			}
+
+			p := payload.Puzzle()
+			if (p.Version != fixture.Version) || (p.Difficulty != fixture.Difficulty) ||
+				(p.SolutionsCount != fixture.SolutionsCount) || (p.Action != fixture.Action) {
+				t.Errorf("Unexpected puzzle: version=%v difficulty=%v solutions=%v action=%q", p.Version, p.Difficulty,
+					p.SolutionsCount, p.Action)
+			}
+
+			metadata, verr := payload.VerifySolutions(ctx)
+			if verr != VerifyNoError {
+				t.Fatalf("Unexpected verify error: %v", verr)
+			}
+
+			if (metadata.ErrorCode() != 0) || (metadata.WasmFlag() != fixture.Wasm) {
+				t.Errorf("Unexpected metadata: error=%v wasm=%v", metadata.ErrorCode(), metadata.WasmFlag())
+			}
+		})
+	}
+}
+
+// TestWidgetCompatibilityTampered makes sure that the harness does not pass trivially
+func TestWidgetCompatibilityTampered(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	for name, fixture := range readCompatFixtures(t) {
+		parts := strings.Split(fixture.Payload, ".")
+		solutions, err := NewSolutions(parts[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 2nd byte of the solution is never the index of the solution
+		solutions.Buffer[1] ^= 0xff
+		parts[0] = solutions.String()
+
+		payload, err := ParseVerifyPayload(ctx, strings.Join(parts, "."))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, verr := payload.VerifySolutions(ctx); verr != InvalidSolutionError {
+			t.Errorf("Unexpected verify error for tampered %s: %v", name, verr)
+		}
+	}
+}
+
+func newCompatFixture(t *testing.T, description string, p *Puzzle, extraSalt []byte) *compatFixture {
+	salt := NewSalt([]byte("privatecaptcha-compatibility-salt"))
+
+	if err := p.Init(DefaultValidityPeriod); err != nil {
+		t.Fatal(err)
+	}
+
+	solutions, err := (&Solver{}).Solve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same as widget running in a browser with WebAssembly support
+	solutions.Metadata = &Metadata{wasmFlag: true, elapsedMillis: 1234}
+
+	pp, err := p.Serialize(context.TODO(), salt, extraSalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(solutions.String())
+	buf.Write(dotBytes)
+	if err := pp.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := &compatFixture{
+		Description:    description,
+		Salt:           base64.StdEncoding.EncodeToString(salt.Data()),
+		Payload:        buf.String(),
+		Version:        p.Version,
+		Difficulty:     p.Difficulty,
+		SolutionsCount: p.SolutionsCount,
+		Action:         p.Action,
+		Wasm:           true,
+	}
+
+	if len(extraSalt) > 0 {
+		fixture.ExtraSalt = base64.StdEncoding.EncodeToString(extraSalt)
+	}
+
+	return fixture
+}
+
+// TestGenerateCompatFixtures adds fixtures that are missing from the corpus when run with -compat-generate flag
+func TestGenerateCompatFixtures(t *testing.T) {
+	if !*generateCompatFixtures {
+		t.Skip("compatibility fixtures generation is not requested")
+	}
+
+	propertyID := [PropertyIDSize]byte{}
+	copy(propertyID[:], "compat-property")
+
+	argon2Puzzle := NewPuzzle(2, propertyID, 110)
+	argon2Puzzle.UseArgon2id()
+
+	actionPuzzle := NewPuzzle(3, propertyID, 100)
+	actionPuzzle.Action = "login"
+
+	generators := map[string]func() *compatFixture{
+		"blake2b": func() *compatFixture {
+			return newCompatFixture(t, "Blake2b puzzle", NewPuzzle(1, propertyID, 120), nil)
+		},
+		"argon2id": func() *compatFixture {
+			return newCompatFixture(t, "Argon2id puzzle", argon2Puzzle, nil)
+		},
+		"blake2b_action": func() *compatFixture {
+			return newCompatFixture(t, "Blake2b puzzle with signed action", actionPuzzle, nil)
+		},
+		"blake2b_extra_salt": func() *compatFixture {
+			return newCompatFixture(t, "Blake2b puzzle signed with extra (API key) salt", NewPuzzle(4, propertyID, 100),
+				[]byte("extra-salt"))
+		},
+	}
+
+	if err := os.MkdirAll(compatFixturesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, generator := range generators {
+		path := filepath.Join(compatFixturesDir, name+".json")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		data, err := json.MarshalIndent(generator(), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Logf("Generated fixture %s", path)
+	}
+}
@@ -0,0 +1,65 @@
+package puzzle
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// puzzle version defines which hash function is used to verify solutions
+	VersionBlake2b  = 1
+	VersionArgon2id = 2
+
+	// argon2id parameters are fixed per version and must match the widget
+	argon2Time    = 1
+	argon2Memory  = 64 // KiB
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	// argon2id hash is ~2^8 times more expensive than blake2b of the puzzle buffer (see BenchmarkVerify*)
+	// and each 8 points of difficulty halve the amount of work, so argon2id puzzles take comparable time to solve
+	argon2DifficultyOffset = 64
+)
+
+var (
+	errUnsupportedVersion = errors.New("unsupported puzzle version")
+	argon2Salt            = []byte("PrivateCaptcha/argon2id")
+)
+
+func IsSupportedVersion(version uint8) bool {
+	return (version == VersionBlake2b) || (version == VersionArgon2id)
+}
+
+// argon2Difficulty maps the difficulty of blake2b puzzle to the comparable argon2id difficulty
+func argon2Difficulty(difficulty uint8) uint8 {
+	if difficulty <= argon2DifficultyOffset {
+		return min(difficulty, 1)
+	}
+
+	return difficulty - argon2DifficultyOffset
+}
+
+// AdjustedDifficulty converts (blake2b) difficulty into the difficulty of the puzzle of the given version
+func AdjustedDifficulty(version uint8, difficulty uint8) uint8 {
+	if version == VersionArgon2id {
+		return argon2Difficulty(difficulty)
+	}
+
+	return difficulty
+}
+
+// hashPrefix returns little-endian uint32 prefix of the hash of puzzle buffer (with solution)
+func hashPrefix(version uint8, buf []byte) (uint32, error) {
+	switch version {
+	case VersionBlake2b:
+		hash := blake2b.Sum256(buf)
+		return binary.LittleEndian.Uint32(hash[:4]), nil
+	case VersionArgon2id:
+		hash := argon2.IDKey(buf, argon2Salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return binary.LittleEndian.Uint32(hash[:4]), nil
+	default:
+		return 0, errUnsupportedVersion
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"io"
 	"log/slog"
 	randv2 "math/rand/v2"
@@ -21,12 +22,13 @@ const (
 	PropertyIDSize        = 16
 	UserDataSize          = 16
 	DefaultValidityPeriod = 6 * time.Hour
-	puzzleVersion         = 1
 	solutionsCount        = 16
+	MaxActionLength       = 64
 )
 
 var (
-	dotBytes = []byte(".")
+	dotBytes         = []byte(".")
+	errActionTooLong = errors.New("puzzle action is too long")
 )
 
 type Puzzle struct {
@@ -37,11 +39,13 @@ type Puzzle struct {
 	PuzzleID       uint64
 	Expiration     time.Time
 	UserData       []byte
+	// Action is opaque integrator-defined metadata (e.g. form name), it is signed, but not solved
+	Action string
 }
 
 func NewPuzzle(puzzleID uint64, propertyID [16]byte, difficulty uint8) *Puzzle {
 	return &Puzzle{
-		Version:        puzzleVersion,
+		Version:        VersionBlake2b,
 		Difficulty:     difficulty,
 		SolutionsCount: solutionsCount,
 		PropertyID:     propertyID,
@@ -51,6 +55,35 @@ func NewPuzzle(puzzleID uint64, propertyID [16]byte, difficulty uint8) *Puzzle {
 	}
 }
 
+// IsValidAction checks that action is short and consists only of alphanumerics and "_-./:" characters
+func IsValidAction(action string) bool {
+	if (len(action) == 0) || (len(action) > MaxActionLength) {
+		return false
+	}
+
+	for i := 0; i < len(action); i++ {
+		c := action[i]
+		switch {
+		case ('a' <= c) && (c <= 'z'), ('A' <= c) && (c <= 'Z'), ('0' <= c) && (c <= '9'):
+		case c == '_', c == '-', c == '.', c == '/', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// UseArgon2id switches puzzle to the memory-hard variant. Difficulty is adjusted to keep solving time comparable
+func (p *Puzzle) UseArgon2id() {
+	if p.Version == VersionArgon2id {
+		return
+	}
+
+	p.Version = VersionArgon2id
+	p.Difficulty = argon2Difficulty(p.Difficulty)
+}
+
 func (p *Puzzle) Init(validityPeriod time.Duration) error {
 	if _, err := io.ReadFull(rand.Reader, p.UserData); err != nil {
 		return err
@@ -192,8 +225,19 @@ func (p *Puzzle) Serialize(ctx context.Context, salt *Salt, extraSalt []byte) (*
 		}
 	}
 
+	if len(p.Action) > MaxActionLength {
+		return nil, errActionTooLong
+	}
+
+	if len(p.Action) > 0 {
+		if _, werr := hasher.Write([]byte(p.Action)); werr != nil {
+			slog.ErrorContext(ctx, "Failed to hash puzzle action", "size", len(p.Action), common.ErrAttr(werr))
+			return nil, werr
+		}
+	}
+
 	hash := hasher.Sum(nil)
-	sign := newSignature(hash, salt, extraSalt)
+	sign := newSignature(hash, salt, extraSalt, p.Action)
 
 	puzzleBase64Len := base64.StdEncoding.EncodedLen(int(puzzleSize))
 	signatureBase64Len := base64.StdEncoding.EncodedLen(sign.BinarySize())
@@ -227,6 +271,11 @@ func (p *Puzzle) Serialize(ctx context.Context, salt *Salt, extraSalt []byte) (*
 	return pp, nil
 }
 
+// Size is the number of bytes that Write() will produce
+func (pp *PuzzlePayload) Size() int {
+	return len(pp.puzzleBase64) + len(dotBytes) + len(pp.signatureBase64)
+}
+
 func (pp *PuzzlePayload) Write(w io.Writer) error {
 	if _, werr := w.Write(pp.puzzleBase64); werr != nil {
 		return werr
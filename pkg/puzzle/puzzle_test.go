@@ -2,8 +2,11 @@ package puzzle
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"io"
 	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -119,3 +122,106 @@ func TestZeroPuzzleMarshalling(t *testing.T) {
 
 	checkPuzzles(puzzle, &newPuzzle, t)
 }
+
+func serializeForVerify(t *testing.T, p *Puzzle, salt *Salt, extraSalt []byte) string {
+	payload, err := p.Serialize(context.TODO(), salt, extraSalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := payload.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// solutions are not checked during signature verification
+	return "AAAA." + buf.String()
+}
+
+func TestPuzzleActionSignature(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	salt := NewSalt([]byte("salt"))
+	extraSalt := []byte("property")
+
+	p := NewPuzzle(RandomPuzzleID(), [16]byte{}, 123)
+	_ = p.Init(DefaultValidityPeriod)
+	p.Action = "login"
+
+	vp, err := ParseVerifyPayload(ctx, serializeForVerify(t, p, salt, extraSalt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vp.VerifySignature(ctx, salt, extraSalt); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := vp.Puzzle().Action; actual != p.Action {
+		t.Errorf("Unexpected action: %v", actual)
+	}
+
+	// tamper with action keeping the same length and hash
+	parts := strings.Split(serializeForVerify(t, p, salt, extraSalt), ".")
+	signatureBytes, _ := base64.StdEncoding.DecodeString(parts[2])
+	tampered := bytes.Replace(signatureBytes, []byte("login"), []byte("admin"), 1)
+	parts[2] = base64.StdEncoding.EncodeToString(tampered)
+
+	vp, err = ParseVerifyPayload(ctx, strings.Join(parts, "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vp.VerifySignature(ctx, salt, extraSalt); err != errSignatureMismatch {
+		t.Errorf("Expected signature mismatch, got %v", err)
+	}
+}
+
+func TestPuzzleWithoutActionSignature(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	salt := NewSalt([]byte("salt"))
+
+	p := NewPuzzle(RandomPuzzleID(), [16]byte{}, 123)
+	_ = p.Init(DefaultValidityPeriod)
+
+	vp, err := ParseVerifyPayload(ctx, serializeForVerify(t, p, salt, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vp.VerifySignature(ctx, salt, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := vp.Puzzle().Action; actual != "" {
+		t.Errorf("Unexpected action: %v", actual)
+	}
+}
+
+func TestIsValidAction(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		action string
+		valid  bool
+	}{
+		{"login", true},
+		{"signup/step-2", true},
+		{"checkout:v1.2_final", true},
+		{"", false},
+		{"has space", false},
+		{"<script>", false},
+		{"emoji😀", false},
+		{strings.Repeat("a", MaxActionLength), true},
+		{strings.Repeat("a", MaxActionLength+1), false},
+	}
+
+	for _, tc := range testCases {
+		if actual := IsValidAction(tc.action); actual != tc.valid {
+			t.Errorf("IsValidAction(%q) = %v, expected %v", tc.action, actual, tc.valid)
+		}
+	}
+}
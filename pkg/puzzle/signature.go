@@ -9,26 +9,34 @@ import (
 const (
 	signatureVersion       = 1
 	flagWithExtra    uint8 = 1 << iota
+	flagWithAction
 )
 
 type signature struct {
 	Version     uint8
 	Fingerprint uint8
 	Flags       uint8
-	Hash        []byte
+	// Action is signed together with the puzzle, but lives outside of puzzle bytes so that solving is not affected
+	Action []byte
+	Hash   []byte
 }
 
-func newSignature(hash []byte, salt *Salt, extraSalt []byte) *signature {
+func newSignature(hash []byte, salt *Salt, extraSalt []byte, action string) *signature {
 	var flags uint8 = 0
 
 	if len(extraSalt) > 0 {
 		flags |= flagWithExtra
 	}
 
+	if len(action) > 0 {
+		flags |= flagWithAction
+	}
+
 	return &signature{
 		Version:     signatureVersion,
 		Fingerprint: salt.Fingerprint(),
 		Flags:       flags,
+		Action:      []byte(action),
 		Hash:        hash,
 	}
 }
@@ -37,8 +45,16 @@ func (s *signature) HasExtra() bool {
 	return s.Flags&flagWithExtra != 0
 }
 
+func (s *signature) HasAction() bool {
+	return s.Flags&flagWithAction != 0
+}
+
 func (s *signature) BinarySize() int {
-	return 3 + len(s.Hash)
+	size := 3 + len(s.Hash)
+	if s.HasAction() {
+		size += 1 + len(s.Action)
+	}
+	return size
 }
 
 func (s *signature) WriteTo(w io.Writer) (int64, error) {
@@ -51,8 +67,20 @@ func (s *signature) WriteTo(w io.Writer) (int64, error) {
 	if err := binary.Write(w, binary.LittleEndian, s.Fingerprint); err != nil {
 		return 2, err
 	}
+	var offset int64 = 3
+	if s.HasAction() {
+		if err := binary.Write(w, binary.LittleEndian, uint8(len(s.Action))); err != nil {
+			return offset, err
+		}
+		offset++
+		n, err := w.Write(s.Action)
+		offset += int64(n)
+		if err != nil {
+			return offset, err
+		}
+	}
 	n, err := w.Write(s.Hash)
-	return 3 + int64(n), err
+	return offset + int64(n), err
 }
 
 func (s *signature) MarshalBinary() ([]byte, error) {
@@ -64,7 +92,7 @@ func (s *signature) MarshalBinary() ([]byte, error) {
 }
 
 func (s *signature) UnmarshalBinary(data []byte) error {
-	if len(data) < 2 {
+	if len(data) < 3 {
 		return io.ErrShortBuffer
 	}
 
@@ -79,6 +107,22 @@ func (s *signature) UnmarshalBinary(data []byte) error {
 	s.Fingerprint = data[offset]
 	offset += 1
 
+	if s.HasAction() {
+		if len(data) <= offset {
+			return io.ErrShortBuffer
+		}
+
+		actionLen := int(data[offset])
+		offset += 1
+
+		if len(data) < offset+actionLen {
+			return io.ErrShortBuffer
+		}
+
+		s.Action = data[offset : offset+actionLen]
+		offset += actionLen
+	}
+
 	s.Hash = data[offset:]
 	return nil
 }
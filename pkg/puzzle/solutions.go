@@ -12,7 +12,6 @@ import (
 	"math"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
-	"golang.org/x/crypto/blake2b"
 )
 
 const (
@@ -197,6 +196,12 @@ func (s *Solutions) Verify(ctx context.Context, puzzleBytes []byte, difficulty u
 		return 0, ErrInvalidPuzzleBytes
 	}
 
+	version := puzzleBytes[0]
+	if !IsSupportedVersion(version) {
+		slog.WarnContext(ctx, "Puzzle version is not supported", "version", version)
+		return 0, errUnsupportedVersion
+	}
+
 	validSolutions := 0
 	threshold := thresholdFromDifficulty((difficulty))
 
@@ -205,11 +210,9 @@ func (s *Solutions) Verify(ctx context.Context, puzzleBytes []byte, difficulty u
 		sIndex := solution[0]
 		copy(puzzleBytes[PuzzleBytesLength-SolutionLength:], solution)
 
-		hash := blake2b.Sum256(puzzleBytes)
-		var resultInt uint32
-		err := binary.Read(bytes.NewReader(hash[:4]), binary.LittleEndian, &resultInt)
+		resultInt, err := hashPrefix(version, puzzleBytes)
 		if err != nil {
-			slog.WarnContext(ctx, "Failed to read hash prefix", "solution", sIndex, "size", 4, common.ErrAttr(err))
+			slog.WarnContext(ctx, "Failed to hash solution", "solution", sIndex, "version", version, common.ErrAttr(err))
 			continue
 		}
 
@@ -1,19 +1,15 @@
 package puzzle
 
 import (
-	"bytes"
-	"encoding/binary"
 	"log/slog"
 	"sync"
 	"time"
-
-	"golang.org/x/crypto/blake2b"
 )
 
 type Solver struct {
 }
 
-func (s *Solver) solveOne(buf []byte, threshold uint32) []byte {
+func (s *Solver) solveOne(version uint8, buf []byte, threshold uint32) []byte {
 	size := len(buf)
 	for i := 0; i < 256; i++ {
 		buf[size-1-3] = byte(i)
@@ -27,12 +23,10 @@ func (s *Solver) solveOne(buf []byte, threshold uint32) []byte {
 				for l := 0; l < 256; l++ {
 					buf[size-1-0] = byte(l)
 
-					hash := blake2b.Sum256(buf)
-					var resultInt uint32
-					err := binary.Read(bytes.NewReader(hash[:4]), binary.LittleEndian, &resultInt)
+					resultInt, err := hashPrefix(version, buf)
 					if err != nil {
-						slog.Error("Failed to read hash prefix", "error", err)
-						continue
+						slog.Error("Failed to hash solution", "error", err)
+						return make([]byte, SolutionLength)
 					}
 
 					if resultInt <= threshold {
@@ -86,7 +80,7 @@ func (s *Solver) Solve(p *Puzzle) (*Solutions, error) {
 
 		go func(data []byte) {
 			defer wg.Done()
-			solution := s.solveOne(data, threshold)
+			solution := s.solveOne(p.Version, data, threshold)
 
 			mux.Lock()
 			defer mux.Unlock()
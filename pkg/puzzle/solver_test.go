@@ -118,3 +118,110 @@ func BenchmarkDifficulty165(b *testing.B) {
 func BenchmarkDifficulty180(b *testing.B) {
 	benchmarkDifficulty(180, b)
 }
+
+func TestArgon2idSolver(t *testing.T) {
+	t.Parallel()
+
+	p := NewPuzzle(0, [16]byte{}, 120)
+	p.UseArgon2id()
+	if err := p.Init(DefaultValidityPeriod); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Version != VersionArgon2id {
+		t.Fatalf("Unexpected puzzle version: %v", p.Version)
+	}
+
+	solver := &Solver{}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzleBytes, _ := p.MarshalBinary()
+	puzzleBytes = normalizePuzzleBuffer(puzzleBytes)
+	found, err := solutions.Verify(context.TODO(), puzzleBytes, p.Difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found != int(p.SolutionsCount) {
+		t.Errorf("Found %v solutions, but expected %v", found, p.SolutionsCount)
+	}
+
+	// the same solutions should not be valid for a different hash function
+	puzzleBytes[0] = VersionBlake2b
+	found, err = solutions.Verify(context.TODO(), puzzleBytes, p.Difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found == int(p.SolutionsCount) {
+		t.Error("Argon2id solutions are valid for blake2b puzzle")
+	}
+}
+
+func TestUnsupportedPuzzleVersion(t *testing.T) {
+	t.Parallel()
+
+	p := NewPuzzle(0, [16]byte{}, 100)
+	p.Version = VersionArgon2id + 1
+
+	puzzleBytes, _ := p.MarshalBinary()
+	puzzleBytes = normalizePuzzleBuffer(puzzleBytes)
+	if _, err := emptySolutions(solutionsCount).Verify(context.TODO(), puzzleBytes, p.Difficulty); err != errUnsupportedVersion {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// benchmarkVerify measures server-side cost of verifying a solved puzzle, which is the same regardless of
+// difficulty and only depends on the puzzle version (hash function)
+func benchmarkVerify(version uint8, b *testing.B) {
+	p := NewPuzzle(0, [16]byte{}, 130)
+	if version == VersionArgon2id {
+		p.UseArgon2id()
+	}
+	if err := p.Init(DefaultValidityPeriod); err != nil {
+		b.Fatal(err)
+	}
+
+	solver := &Solver{}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	puzzleBytes, _ := p.MarshalBinary()
+	puzzleBytes = normalizePuzzleBuffer(puzzleBytes)
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if _, err := solutions.Verify(context.TODO(), puzzleBytes, p.Difficulty); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyBlake2b(b *testing.B) {
+	benchmarkVerify(VersionBlake2b, b)
+}
+
+func BenchmarkVerifyArgon2id(b *testing.B) {
+	benchmarkVerify(VersionArgon2id, b)
+}
+
+func BenchmarkArgon2idDifficulty130(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		p := NewPuzzle(0, [16]byte{}, 130)
+		p.UseArgon2id()
+		if err := p.Init(DefaultValidityPeriod); err != nil {
+			b.Fatal(err)
+		}
+
+		solver := &Solver{}
+		if _, err := solver.Solve(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -147,6 +147,9 @@ func ParseVerifyPayload(ctx context.Context, payload string) (*VerifyPayload, er
 		return nil, uerr
 	}
 
+	// action can only be trusted after signature verification
+	p.Action = string(s.Action)
+
 	return &VerifyPayload{
 		solutions:  solutionsStr,
 		puzzleData: puzzleBytes,
@@ -178,6 +181,13 @@ func (vp *VerifyPayload) VerifySignature(ctx context.Context, salt *Salt, extraS
 		}
 	}
 
+	if vp.signature.HasAction() {
+		if _, werr := hasher.Write(vp.signature.Action); werr != nil {
+			slog.ErrorContext(ctx, "Failed to hash puzzle action", "size", len(vp.signature.Action), common.ErrAttr(werr))
+			return werr
+		}
+	}
+
 	actualSignature := hasher.Sum(nil)
 
 	if !bytes.Equal(actualSignature, vp.signature.Hash) {
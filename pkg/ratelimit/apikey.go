@@ -22,19 +22,9 @@ func NewAPIKeyBuckets(maxBuckets int, bucketCap uint32, leakInterval time.Durati
 	return buckets
 }
 
-func NewAPIKeyRateLimiter(header string,
+func NewAPIKeyRateLimiter(strategy realclientip.Strategy,
 	buckets *StringBuckets,
 	keyFunc func(r *http.Request) string) HTTPRateLimiter {
-	var strategy realclientip.Strategy
-
-	if len(header) > 0 {
-		strategy = realclientip.Must(realclientip.NewSingleIPHeaderStrategy(header))
-	} else {
-		strategy = realclientip.NewChainStrategy(
-			realclientip.Must(realclientip.NewRightmostNonPrivateStrategy("X-Forwarded-For")),
-			realclientip.RemoteAddrStrategy{})
-	}
-
 	limiter := &httpRateLimiter[string]{
 		name:            "apikey",
 		rejectedHandler: defaultRejectedHandler,
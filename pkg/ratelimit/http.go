@@ -43,6 +43,8 @@ type HTTPRateLimiter interface {
 	RateLimit(next http.Handler) http.Handler
 	Updater(r *http.Request) leakybucket.LimitUpdaterFunc
 	UpdateLimits(capacity leakybucket.TLevel, leakInterval time.Duration)
+	State(r *http.Request) (leakybucket.AddResult, bool)
+	BucketsCount() int
 }
 
 type httpRateLimiter[TKey comparable] struct {
@@ -64,6 +66,10 @@ func (l *httpRateLimiter[TKey]) UpdateLimits(capacity leakybucket.TLevel, leakIn
 	l.buckets.SetGlobalLimits(capacity, leakInterval)
 }
 
+func (l *httpRateLimiter[TKey]) BucketsCount() int {
+	return l.buckets.BucketsCount()
+}
+
 func (l *httpRateLimiter[TKey]) cleanup(ctx context.Context) {
 	const jitter = 4 * time.Second
 	// don't overload server on start
@@ -80,7 +86,7 @@ func (l *httpRateLimiter[TKey]) RateLimit(next http.Handler) http.Handler {
 
 		addResult := l.buckets.Add(key, 1, time.Now())
 
-		setRateLimitHeaders(w, addResult)
+		SetRateLimitHeaders(w, addResult)
 
 		if addResult.Added > 0 {
 			//slog.Log(r.Context(), common.LevelTrace, "Allowing request", "ratelimiter", l.name,
@@ -116,20 +122,29 @@ func (l *httpRateLimiter[TKey]) Updater(r *http.Request) leakybucket.LimitUpdate
 	}
 }
 
-func setRateLimitHeaders(w http.ResponseWriter, addResult leakybucket.AddResult) {
+// State returns the bucket state for the rate limiting key of the request (available after RateLimit() middleware)
+func (l *httpRateLimiter[TKey]) State(r *http.Request) (leakybucket.AddResult, bool) {
+	if key, ok := r.Context().Value(common.RateLimitKeyContextKey).(TKey); ok {
+		return l.buckets.State(key, time.Now())
+	}
+
+	return leakybucket.AddResult{}, false
+}
+
+func SetRateLimitHeaders(w http.ResponseWriter, addResult leakybucket.AddResult) {
 	headers := w.Header()
 
 	if v := addResult.Capacity; v > 0 {
 		headers[rateLimitHeader] = []string{strconv.Itoa(int(v))}
-	}
-
-	if v := addResult.Remaining(); v > 0 {
-		headers[rateLimitRemainingHeader] = []string{strconv.Itoa(int(v))}
+		// NOTE: zero remaining is a valid value when capacity is known
+		headers[rateLimitRemainingHeader] = []string{strconv.Itoa(int(addResult.Remaining()))}
 	}
 
 	if v := addResult.ResetAfter; v > 0 {
 		vi := int(math.Max(1.0, v.Seconds()+0.5))
 		headers[rateLimitResetHeader] = []string{strconv.Itoa(vi)}
+	} else {
+		delete(headers, rateLimitResetHeader)
 	}
 
 	if v := addResult.RetryAfter; v > 0 {
@@ -41,17 +41,7 @@ func NewIPAddrBuckets(maxBuckets int, bucketCap uint32, leakInterval time.Durati
 	return buckets
 }
 
-func NewIPAddrRateLimiter(name, header string, buckets *IPAddrBuckets) *httpRateLimiter[netip.Addr] {
-	var strategy realclientip.Strategy
-
-	if len(header) > 0 {
-		strategy = realclientip.Must(realclientip.NewSingleIPHeaderStrategy(header))
-	} else {
-		strategy = realclientip.NewChainStrategy(
-			realclientip.Must(realclientip.NewRightmostNonPrivateStrategy("X-Forwarded-For")),
-			realclientip.RemoteAddrStrategy{})
-	}
-
+func NewIPAddrRateLimiter(name string, strategy realclientip.Strategy, buckets *IPAddrBuckets) *httpRateLimiter[netip.Addr] {
 	limiter := &httpRateLimiter[netip.Addr]{
 		name:            name,
 		rejectedHandler: defaultRejectedHandler,
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	realclientip "github.com/realclientip/realclientip-go"
+)
+
+const (
+	// forwarding headers came from a peer that is not a trusted proxy (most likely spoofed)
+	ProxyRejectUntrustedPeer = "untrusted_peer"
+	// trusted proxy did not add a valid client IP to forwarding headers (most likely misconfiguration)
+	ProxyRejectInvalidHeader = "invalid_header"
+)
+
+var (
+	forwardedHeader     = http.CanonicalHeaderKey("Forwarded")
+	xForwardedForHeader = http.CanonicalHeaderKey("X-Forwarded-For")
+)
+
+// ClientIPStrategy resolves client IP of the request and is shared by all IP rate limiters. Without trusted proxies
+// it keeps the legacy behavior (configured single header or rightmost non-private X-Forwarded-For). With trusted
+// proxies, forwarding headers are read only from requests of these proxies, in order: configured single header or
+// Forwarded, then X-Forwarded-For. In all other cases peer address is used
+type ClientIPStrategy struct {
+	header  string
+	trusted []net.IPNet
+	// ordered header strategies that are used for requests from trusted proxies
+	strategies []realclientip.Strategy
+	legacy     realclientip.Strategy
+	metrics    common.ProxyMetrics
+}
+
+var _ realclientip.Strategy = (*ClientIPStrategy)(nil)
+
+// ParseTrustedProxies parses comma-separated list of IP addresses and CIDR ranges
+func ParseTrustedProxies(value string) ([]net.IPNet, error) {
+	ranges := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			ranges = append(ranges, item)
+		}
+	}
+
+	return realclientip.AddressesAndRangesToIPNets(ranges...)
+}
+
+func NewClientIPStrategy(header, trustedProxies string, metrics common.ProxyMetrics) (*ClientIPStrategy, error) {
+	trusted, err := ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ClientIPStrategy{
+		header:  http.CanonicalHeaderKey(header),
+		trusted: trusted,
+		metrics: metrics,
+	}
+
+	if len(header) > 0 {
+		s.legacy = realclientip.Must(realclientip.NewSingleIPHeaderStrategy(header))
+		s.strategies = []realclientip.Strategy{s.legacy}
+	} else {
+		s.legacy = realclientip.NewChainStrategy(
+			realclientip.Must(realclientip.NewRightmostNonPrivateStrategy(xForwardedForHeader)),
+			realclientip.RemoteAddrStrategy{})
+		s.strategies = []realclientip.Strategy{
+			realclientip.Must(realclientip.NewRightmostTrustedRangeStrategy(forwardedHeader, trusted)),
+			realclientip.Must(realclientip.NewRightmostTrustedRangeStrategy(xForwardedForHeader, trusted)),
+		}
+	}
+
+	return s, nil
+}
+
+// hasForwardingHeaders checks if the request carries any of the headers that we could read client IP from
+func (s *ClientIPStrategy) hasForwardingHeaders(headers http.Header) bool {
+	if len(s.header) > 0 {
+		return len(headers.Get(s.header)) > 0
+	}
+
+	return (len(headers.Get(forwardedHeader)) > 0) || (len(headers.Get(xForwardedForHeader)) > 0)
+}
+
+func (s *ClientIPStrategy) isTrustedPeer(remoteAddr string) bool {
+	ip := net.ParseIP(realclientip.RemoteAddrStrategy{}.ClientIP(nil, remoteAddr))
+	if ip == nil {
+		return false
+	}
+
+	for _, r := range s.trusted {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *ClientIPStrategy) reject(reason string) {
+	if s.metrics != nil {
+		s.metrics.ObserveProxyHeaderRejected(reason)
+	}
+}
+
+func (s *ClientIPStrategy) ClientIP(headers http.Header, remoteAddr string) string {
+	if len(s.trusted) == 0 {
+		return s.legacy.ClientIP(headers, remoteAddr)
+	}
+
+	if !s.isTrustedPeer(remoteAddr) {
+		if s.hasForwardingHeaders(headers) {
+			s.reject(ProxyRejectUntrustedPeer)
+		}
+
+		return realclientip.RemoteAddrStrategy{}.ClientIP(headers, remoteAddr)
+	}
+
+	for _, strategy := range s.strategies {
+		if ip := strategy.ClientIP(headers, remoteAddr); len(ip) > 0 {
+			return ip
+		}
+	}
+
+	// requests from the proxy itself (e.g. health checks) do not have forwarding headers
+	if s.hasForwardingHeaders(headers) {
+		s.reject(ProxyRejectInvalidHeader)
+	}
+
+	return realclientip.RemoteAddrStrategy{}.ClientIP(headers, remoteAddr)
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testProxyMetrics struct {
+	rejected map[string]int
+}
+
+func (m *testProxyMetrics) ObserveProxyHeaderRejected(reason string) {
+	m.rejected[reason]++
+}
+
+func TestClientIPStrategy(t *testing.T) {
+	t.Parallel()
+
+	metrics := &testProxyMetrics{rejected: make(map[string]int)}
+	strategy, err := NewClientIPStrategy("", "10.0.0.0/8, 192.168.1.1", metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := NewClientIPStrategy("", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		strategy   *ClientIPStrategy
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{strategy, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 2.2.2.2"}, "2.2.2.2"},
+		{strategy, "192.168.1.1:1234", map[string]string{"X-Forwarded-For": "2.2.2.2, 10.0.0.5"}, "2.2.2.2"},
+		// Forwarded is preferred to X-Forwarded-For
+		{strategy, "10.1.2.3:1234", map[string]string{"Forwarded": "for=3.3.3.3", "X-Forwarded-For": "2.2.2.2"}, "3.3.3.3"},
+		// spoofed headers directly from the client
+		{strategy, "4.4.4.4:1234", map[string]string{"X-Forwarded-For": "2.2.2.2"}, "4.4.4.4"},
+		{strategy, "4.4.4.4:1234", map[string]string{}, "4.4.4.4"},
+		// invalid header from the trusted proxy
+		{strategy, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "garbage"}, "10.1.2.3"},
+		{legacy, "4.4.4.4:1234", map[string]string{"X-Forwarded-For": "2.2.2.2"}, "2.2.2.2"},
+		{legacy, "4.4.4.4:1234", map[string]string{}, "4.4.4.4"},
+	}
+
+	for i, tc := range testCases {
+		headers := make(http.Header)
+		for k, v := range tc.headers {
+			headers.Set(k, v)
+		}
+
+		if ip := tc.strategy.ClientIP(headers, tc.remoteAddr); ip != tc.expected {
+			t.Errorf("Unexpected client IP at %v: %v (expected %v)", i, ip, tc.expected)
+		}
+	}
+
+	if (metrics.rejected[ProxyRejectUntrustedPeer] != 1) || (metrics.rejected[ProxyRejectInvalidHeader] != 1) {
+		t.Errorf("Unexpected rejections: %v", metrics.rejected)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	if ranges, err := ParseTrustedProxies(" 10.0.0.0/8,,::1 "); (err != nil) || (len(ranges) != 2) {
+		t.Errorf("Failed to parse trusted proxies: %v (%v)", ranges, err)
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil {
+		t.Error("Expected error for invalid trusted proxy")
+	}
+}
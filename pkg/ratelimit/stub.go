@@ -26,3 +26,9 @@ func (srl *StubRateLimiter) Updater(r *http.Request) leakybucket.LimitUpdaterFun
 func (srl *StubRateLimiter) UpdateLimits(capacity leakybucket.TLevel, leakInterval time.Duration) {
 	// BUMP
 }
+func (srl *StubRateLimiter) State(r *http.Request) (leakybucket.AddResult, bool) {
+	return leakybucket.AddResult{}, false
+}
+func (srl *StubRateLimiter) BucketsCount() int {
+	return 0
+}
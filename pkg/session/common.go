@@ -11,4 +11,12 @@ const (
 	KeyPersistent
 	KeyNotificationID
 	KeyReturnURL
+	KeyLoginDevice
+	KeyLoginCountry
+	KeyReverifyNewLogins
+	KeyTwoFactorFailures
+	KeyTwoFactorLastFailure
+	KeyMagicLinkNonce
+	KeyLoginID
+	KeyLoginCheckedAt
 )
@@ -22,6 +22,18 @@ func (m *Manager) sessionID() string {
 	return xid.New().String()
 }
 
+func (m *Manager) setCookie(w http.ResponseWriter, sid string) {
+	cookie := http.Cookie{
+		Name:     m.CookieName,
+		Value:    url.QueryEscape(sid),
+		Path:     m.Path,
+		HttpOnly: true,
+		MaxAge:   int(m.MaxLifetime.Seconds()),
+	}
+	http.SetCookie(w, &cookie)
+	w.Header().Add("Cache-Control", `no-cache="Set-Cookie"`)
+}
+
 func (m *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (session *common.Session) {
 	cookie, err := r.Cookie(m.CookieName)
 	ctx := r.Context()
@@ -32,15 +44,7 @@ func (m *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (session
 		if err = m.Store.Init(ctx, session); err != nil {
 			slog.ErrorContext(ctx, "Failed to register session", "sid", sid, common.ErrAttr(err))
 		}
-		cookie := http.Cookie{
-			Name:     m.CookieName,
-			Value:    url.QueryEscape(sid),
-			Path:     m.Path,
-			HttpOnly: true,
-			MaxAge:   int(m.MaxLifetime.Seconds()),
-		}
-		http.SetCookie(w, &cookie)
-		w.Header().Add("Cache-Control", `no-cache="Set-Cookie"`)
+		m.setCookie(w, sid)
 	} else {
 		sid, _ := url.QueryUnescape(cookie.Value)
 		slog.Log(ctx, common.LevelTrace, "Session cookie found in the request", "sid", sid, "path", r.URL.Path, "method", r.Method)
@@ -58,6 +62,50 @@ func (m *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (session
 	return
 }
 
+// SessionRegenerate moves session values to a new session ID and destroys the old session. It should be called on
+// privilege changes (e.g. after sign in) so that session ID, that could be planted before, becomes useless (session fixation)
+func (m *Manager) SessionRegenerate(w http.ResponseWriter, r *http.Request, sess *common.Session) *common.Session {
+	ctx := r.Context()
+	sid := m.sessionID()
+	result := sess.Clone(sid)
+
+	if err := m.Store.Init(ctx, result); err != nil {
+		slog.ErrorContext(ctx, "Failed to register regenerated session", "sid", sid, common.ErrAttr(err))
+		return sess
+	}
+
+	if err := m.Store.Destroy(ctx, sess.SessionID()); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete old session from storage", "sid", sess.SessionID(), common.ErrAttr(err))
+	}
+
+	m.setCookie(w, sid)
+
+	slog.DebugContext(ctx, "Regenerated session", "old", sess.SessionID(), "new", sid)
+
+	return result
+}
+
+// SessionsDestroy deletes sessions from storage on this node. Sessions cached on other nodes have to be invalidated
+// separately (e.g. by revoking their sign-ins)
+func (m *Manager) SessionsDestroy(ctx context.Context, sids []string) int {
+	count := 0
+
+	for _, sid := range sids {
+		if len(sid) == 0 {
+			continue
+		}
+
+		if err := m.Store.Destroy(ctx, sid); err != nil {
+			slog.WarnContext(ctx, "Failed to destroy session", "sid", sid, common.ErrAttr(err))
+			continue
+		}
+
+		count++
+	}
+
+	return count
+}
+
 func (m *Manager) SessionDestroy(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(m.CookieName)
 	if err != nil || cookie.Value == "" {
@@ -0,0 +1,48 @@
+package testsupport
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+)
+
+// PlanService is a billing.PlanService that finds plans the same way as billing.CorePlanService does, but
+// subscription operations only get recorded (and can be configured to fail or to be slow)
+type PlanService struct {
+	*billing.CorePlanService
+	faults
+	lock      sync.Mutex
+	cancelled []string
+}
+
+var _ billing.PlanService = (*PlanService)(nil)
+
+func NewPlanService(stagePlans map[string][]billing.Plan) *PlanService {
+	return &PlanService{
+		CorePlanService: billing.NewPlanService(stagePlans),
+		cancelled:       make([]string, 0),
+	}
+}
+
+func (s *PlanService) CancelSubscription(ctx context.Context, sid string) error {
+	if err := s.call(ctx, "CancelSubscription"); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.cancelled = append(s.cancelled, sid)
+
+	return nil
+}
+
+// Cancelled returns IDs of subscriptions that were cancelled
+func (s *PlanService) Cancelled() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.cancelled)
+}
@@ -0,0 +1,582 @@
+// Package testsupport contains in-memory fakes of external dependencies (ClickHouse, billing provider) that make
+// it possible to unit test handlers without live services.
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+var (
+	ErrInjected = errors.New("injected error")
+)
+
+const (
+	// all methods fail if error is set for this "method"
+	AnyMethod = ""
+	// status of successful verification (puzzle.VerifyNoError)
+	verifySuccessStatus = 0
+)
+
+// faults keeps configured latency and errors of fake methods
+type faults struct {
+	mux     sync.Mutex
+	latency time.Duration
+	errors  map[string]error
+}
+
+// SetLatency makes every call of the fake to take (at least) given time
+func (f *faults) SetLatency(latency time.Duration) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.latency = latency
+}
+
+// SetError makes method (or all methods if method is AnyMethod) return err. Nil err removes the fault
+func (f *faults) SetError(method string, err error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.errors == nil {
+		f.errors = make(map[string]error)
+	}
+
+	if err == nil {
+		delete(f.errors, method)
+	} else {
+		f.errors[method] = err
+	}
+}
+
+func (f *faults) call(ctx context.Context, method string) error {
+	f.mux.Lock()
+	latency := f.latency
+	err, ok := f.errors[method]
+	if !ok {
+		err = f.errors[AnyMethod]
+	}
+	f.mux.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	return err
+}
+
+// TimeSeries is an in-memory common.TimeSeriesStore. Stats are computed from written access and verify records
+// with the same buckets as ClickHouse implementation uses (without filling gaps), so results are deterministic.
+type TimeSeries struct {
+	faults
+	lock          sync.Mutex
+	accessRecords []*common.AccessRecord
+	verifyRecords []*common.VerifyRecord
+	// Now is used to compute starts of time periods (time.Now() by default)
+	Now func() time.Time
+}
+
+var _ common.TimeSeriesStore = (*TimeSeries)(nil)
+
+func NewTimeSeries() *TimeSeries {
+	return &TimeSeries{
+		accessRecords: make([]*common.AccessRecord, 0),
+		verifyRecords: make([]*common.VerifyRecord, 0),
+		Now:           time.Now,
+	}
+}
+
+// AccessRecords returns a copy of all written access records
+func (ts *TimeSeries) AccessRecords() []*common.AccessRecord {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	return slices.Clone(ts.accessRecords)
+}
+
+// VerifyRecords returns a copy of all written verify records
+func (ts *TimeSeries) VerifyRecords() []*common.VerifyRecord {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	return slices.Clone(ts.verifyRecords)
+}
+
+func (ts *TimeSeries) filterAccess(filter func(r *common.AccessRecord) bool) []*common.AccessRecord {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	result := make([]*common.AccessRecord, 0)
+	for _, r := range ts.accessRecords {
+		if filter(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func (ts *TimeSeries) filterVerify(filter func(r *common.VerifyRecord) bool) []*common.VerifyRecord {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	result := make([]*common.VerifyRecord, 0)
+	for _, r := range ts.verifyRecords {
+		if filter(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func (ts *TimeSeries) Ping(ctx context.Context) error {
+	return ts.call(ctx, "Ping")
+}
+
+func (ts *TimeSeries) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	if err := ts.call(ctx, "WriteAccessLogBatch"); err != nil {
+		return err
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.accessRecords = append(ts.accessRecords, records...)
+
+	return nil
+}
+
+func (ts *TimeSeries) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	if err := ts.call(ctx, "WriteVerifyLogBatch"); err != nil {
+		return err
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.verifyRecords = append(ts.verifyRecords, records...)
+
+	return nil
+}
+
+// timeCounts converts (unordered) buckets to a list sorted by time
+func timeCounts(buckets map[time.Time]uint32) []*common.TimeCount {
+	result := make([]*common.TimeCount, 0, len(buckets))
+	for t, count := range buckets {
+		result = append(result, &common.TimeCount{Timestamp: t, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result
+}
+
+func (ts *TimeSeries) ReadPropertyStats(ctx context.Context, r *common.BackfillRequest, from time.Time) ([]*common.TimeCount, error) {
+	if err := ts.call(ctx, "ReadPropertyStats"); err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[time.Time]uint32)
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool {
+		return (ar.UserID == r.UserID) && (ar.OrgID == r.OrgID) && (ar.PropertyID == r.PropertyID)
+	}) {
+		if t := ar.Timestamp.UTC().Truncate(5 * time.Minute); !t.Before(from) {
+			buckets[t]++
+		}
+	}
+
+	return timeCounts(buckets), nil
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// localDay labels UTC day (or month) as the same calendar date in the timezone (as ClickHouse rollups do)
+func localDay(t time.Time, tz *time.Location) time.Time {
+	if tz == nil {
+		tz = time.UTC
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tz)
+}
+
+func (ts *TimeSeries) ReadAccountStats(ctx context.Context, userID int32, from time.Time, tz *time.Location) ([]*common.TimeCount, error) {
+	if err := ts.call(ctx, "ReadAccountStats"); err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[time.Time]uint32)
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool { return ar.UserID == userID }) {
+		if t := monthStart(ar.Timestamp.UTC()); !t.Before(from) {
+			buckets[localDay(t, tz)]++
+		}
+	}
+
+	return timeCounts(buckets), nil
+}
+
+// periodBucket returns time from and bucketing function of the period (see db.newPeriodParams)
+func periodBucket(period common.TimePeriod, tnow time.Time, tz *time.Location) (time.Time, func(t time.Time) time.Time) {
+	if tz == nil {
+		tz = time.UTC
+	}
+
+	switch period {
+	case common.TimePeriodWeek:
+		// 6 hours intervals are computed over daily rollups
+		return tnow.AddDate(0, 0, -7), func(t time.Time) time.Time { return localDay(dayStart(t.UTC()), tz) }
+	case common.TimePeriodMonth:
+		return tnow.AddDate(0, -1, 0), func(t time.Time) time.Time { return localDay(dayStart(t.UTC()), tz) }
+	case common.TimePeriodYear:
+		return tnow.AddDate(-1, 0, 0), func(t time.Time) time.Time { return localDay(monthStart(t.UTC()), tz) }
+	default:
+		return tnow.AddDate(0, 0, -1), func(t time.Time) time.Time { return t.In(tz).Truncate(time.Hour) }
+	}
+}
+
+func (ts *TimeSeries) RetrievePropertyStats(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) ([]*common.TimePeriodStat, error) {
+	if err := ts.call(ctx, "RetrievePropertyStats"); err != nil {
+		return nil, err
+	}
+
+	from, bucket := periodBucket(period, ts.Now(), tz)
+	buckets := make(map[int64]*common.TimePeriodStat)
+	stat := func(t time.Time) *common.TimePeriodStat {
+		b := bucket(t)
+		st, ok := buckets[b.Unix()]
+		if !ok {
+			st = &common.TimePeriodStat{Timestamp: b}
+			buckets[b.Unix()] = st
+		}
+		return st
+	}
+
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool {
+		return (ar.OrgID == orgID) && (ar.PropertyID == propertyID) && !ar.Timestamp.Before(from)
+	}) {
+		st := stat(ar.Timestamp)
+		st.RequestsCount++
+		if ar.Datacenter {
+			st.DatacenterCount++
+		}
+	}
+
+	for _, vr := range ts.filterVerify(func(vr *common.VerifyRecord) bool {
+		return (vr.OrgID == orgID) && (vr.PropertyID == propertyID) && !vr.Sandbox && !vr.Timestamp.Before(from)
+	}) {
+		stat(vr.Timestamp).VerifiesCount++
+	}
+
+	result := make([]*common.TimePeriodStat, 0, len(buckets))
+	for _, st := range buckets {
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result, nil
+}
+
+func (ts *TimeSeries) RetrievePropertyFailures(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) ([]*common.VerifyFailureStat, error) {
+	if err := ts.call(ctx, "RetrievePropertyFailures"); err != nil {
+		return nil, err
+	}
+
+	type failureKey struct {
+		timestamp int64
+		status    uint8
+	}
+
+	from, bucket := periodBucket(period, ts.Now(), tz)
+	buckets := make(map[failureKey]*common.VerifyFailureStat)
+
+	for _, vr := range ts.filterVerify(func(vr *common.VerifyRecord) bool {
+		return (vr.OrgID == orgID) && (vr.PropertyID == propertyID) && (vr.Status != verifySuccessStatus) &&
+			!vr.Timestamp.Before(from)
+	}) {
+		b := bucket(vr.Timestamp)
+		key := failureKey{timestamp: b.Unix(), status: uint8(vr.Status)}
+		st, ok := buckets[key]
+		if !ok {
+			st = &common.VerifyFailureStat{Timestamp: b, Status: uint8(vr.Status)}
+			buckets[key] = st
+		}
+		st.Count++
+	}
+
+	result := make([]*common.VerifyFailureStat, 0, len(buckets))
+	for _, st := range buckets {
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].Status < result[j].Status
+		}
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	return result, nil
+}
+
+func (ts *TimeSeries) RetrievePropertyFingerprints(ctx context.Context, orgID, propertyID int32, period common.TimePeriod, tz *time.Location) (*common.FingerprintStats, error) {
+	if err := ts.call(ctx, "RetrievePropertyFingerprints"); err != nil {
+		return nil, err
+	}
+
+	from, bucket := periodBucket(period, ts.Now(), tz)
+	buckets := make(map[int64]*common.FingerprintStat)
+	// fingerprints are counted precisely here, unlike uniqCombined in ClickHouse
+	bucketFingerprints := make(map[int64]map[common.TFingerprint]struct{})
+	totalFingerprints := make(map[common.TFingerprint]struct{})
+	result := &common.FingerprintStats{Total: &common.FingerprintStat{Timestamp: from}}
+
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool {
+		return (ar.OrgID == orgID) && (ar.PropertyID == propertyID) && !ar.IPLess && (ar.Fingerprint != 0) && !ar.Timestamp.Before(from)
+	}) {
+		b := bucket(ar.Timestamp)
+		st, ok := buckets[b.Unix()]
+		if !ok {
+			st = &common.FingerprintStat{Timestamp: b}
+			buckets[b.Unix()] = st
+			bucketFingerprints[b.Unix()] = make(map[common.TFingerprint]struct{})
+		}
+		st.RequestsCount++
+		bucketFingerprints[b.Unix()][ar.Fingerprint] = struct{}{}
+		st.UniqueCount = uint64(len(bucketFingerprints[b.Unix()]))
+
+		result.Total.RequestsCount++
+		totalFingerprints[ar.Fingerprint] = struct{}{}
+	}
+
+	result.Total.UniqueCount = uint64(len(totalFingerprints))
+
+	result.Buckets = make([]*common.FingerprintStat, 0, len(buckets))
+	for _, st := range buckets {
+		result.Buckets = append(result.Buckets, st)
+	}
+
+	sort.Slice(result.Buckets, func(i, j int) bool { return result.Buckets[i].Timestamp.Before(result.Buckets[j].Timestamp) })
+
+	return result, nil
+}
+
+func (ts *TimeSeries) RetrievePropertyActions(ctx context.Context, orgID, propertyID int32, from time.Time) ([]*common.ActionStat, error) {
+	if err := ts.call(ctx, "RetrievePropertyActions"); err != nil {
+		return nil, err
+	}
+
+	actions := make(map[string]*common.ActionStat)
+	for _, vr := range ts.filterVerify(func(vr *common.VerifyRecord) bool {
+		return (vr.OrgID == orgID) && (vr.PropertyID == propertyID) && (len(vr.Action) > 0) && !vr.Timestamp.Before(from)
+	}) {
+		st, ok := actions[vr.Action]
+		if !ok {
+			st = &common.ActionStat{Action: vr.Action}
+			actions[vr.Action] = st
+		}
+		st.Count++
+		if vr.Status == verifySuccessStatus {
+			st.SuccessCount++
+		}
+	}
+
+	result := make([]*common.ActionStat, 0, len(actions))
+	for _, st := range actions {
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Action < result[j].Action
+		}
+		return result[i].Count > result[j].Count
+	})
+
+	return result, nil
+}
+
+type usageKey struct {
+	userID     int32
+	orgID      int32
+	propertyID int32
+}
+
+func (ts *TimeSeries) usageCounters(accessFilter func(t time.Time) bool) []*common.UsageCounter {
+	counters := make(map[usageKey]*common.UsageCounter)
+	counter := func(userID, orgID, propertyID int32) *common.UsageCounter {
+		key := usageKey{userID: userID, orgID: orgID, propertyID: propertyID}
+		uc, ok := counters[key]
+		if !ok {
+			uc = &common.UsageCounter{UserID: userID, OrgID: orgID, PropertyID: propertyID}
+			counters[key] = uc
+		}
+		return uc
+	}
+
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool { return accessFilter(ar.Timestamp) }) {
+		counter(ar.UserID, ar.OrgID, ar.PropertyID).Requests++
+	}
+
+	for _, vr := range ts.filterVerify(func(vr *common.VerifyRecord) bool { return !vr.Sandbox && accessFilter(vr.Timestamp) }) {
+		key := usageKey{userID: vr.UserID, orgID: vr.OrgID, propertyID: vr.PropertyID}
+		// same as LEFT JOIN in ClickHouse: verifications without requests are not counted
+		if uc, ok := counters[key]; ok {
+			if vr.Status == verifySuccessStatus {
+				uc.VerifySuccess++
+			} else {
+				uc.VerifyFailure++
+			}
+		}
+	}
+
+	result := make([]*common.UsageCounter, 0, len(counters))
+	for _, uc := range counters {
+		result = append(result, uc)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].PropertyID < result[j].PropertyID })
+
+	return result
+}
+
+func (ts *TimeSeries) ReadUsageCounters(ctx context.Context, from time.Time) ([]*common.UsageCounter, error) {
+	if err := ts.call(ctx, "ReadUsageCounters"); err != nil {
+		return nil, err
+	}
+
+	return ts.usageCounters(func(t time.Time) bool { return !t.Before(from) }), nil
+}
+
+func (ts *TimeSeries) ReadDailyUsageCounters(ctx context.Context, day time.Time) ([]*common.UsageCounter, error) {
+	if err := ts.call(ctx, "ReadDailyUsageCounters"); err != nil {
+		return nil, err
+	}
+
+	return ts.usageCounters(func(t time.Time) bool { return dayStart(t.UTC()).Equal(day) }), nil
+}
+
+func (ts *TimeSeries) RetrievePropertiesTotals(ctx context.Context, orgID int32, propertyIDs []int32, from time.Time) (*common.TimePeriodStat, error) {
+	if err := ts.call(ctx, "RetrievePropertiesTotals"); err != nil {
+		return nil, err
+	}
+
+	result := &common.TimePeriodStat{Timestamp: from}
+
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool {
+		return (ar.OrgID == orgID) && slices.Contains(propertyIDs, ar.PropertyID) && !ar.Timestamp.Before(from)
+	}) {
+		result.RequestsCount++
+		if ar.Datacenter {
+			result.DatacenterCount++
+		}
+	}
+
+	result.VerifiesCount = len(ts.filterVerify(func(vr *common.VerifyRecord) bool {
+		return (vr.OrgID == orgID) && slices.Contains(propertyIDs, vr.PropertyID) && !vr.Sandbox && !vr.Timestamp.Before(from)
+	}))
+
+	return result, nil
+}
+
+func (ts *TimeSeries) RetrievePropertiesActivity(ctx context.Context, orgID int32, propertyIDs []int32) (map[int32]*common.PropertyActivity, error) {
+	if err := ts.call(ctx, "RetrievePropertiesActivity"); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int32]*common.PropertyActivity)
+	activity := func(propertyID int32) *common.PropertyActivity {
+		if _, ok := result[propertyID]; !ok {
+			result[propertyID] = &common.PropertyActivity{PropertyID: propertyID}
+		}
+		return result[propertyID]
+	}
+
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool {
+		return (ar.OrgID == orgID) && slices.Contains(propertyIDs, ar.PropertyID)
+	}) {
+		if a := activity(ar.PropertyID); a.FirstRequest.IsZero() || ar.Timestamp.Before(a.FirstRequest) {
+			a.FirstRequest = ar.Timestamp
+		}
+	}
+
+	for _, vr := range ts.filterVerify(func(vr *common.VerifyRecord) bool {
+		return (vr.OrgID == orgID) && slices.Contains(propertyIDs, vr.PropertyID) && (vr.Status == 0)
+	}) {
+		if a := activity(vr.PropertyID); a.FirstVerify.IsZero() || vr.Timestamp.Before(a.FirstVerify) {
+			a.FirstVerify = vr.Timestamp
+		}
+	}
+
+	return result, nil
+}
+
+func (ts *TimeSeries) ReadOrgsMonthlyUsage(ctx context.Context, orgIDs []int32, month time.Time) (map[int32]uint64, error) {
+	if err := ts.call(ctx, "ReadOrgsMonthlyUsage"); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int32]uint64)
+	for _, ar := range ts.filterAccess(func(ar *common.AccessRecord) bool {
+		return slices.Contains(orgIDs, ar.OrgID) && monthStart(ar.Timestamp.UTC()).Equal(monthStart(month))
+	}) {
+		result[ar.OrgID]++
+	}
+
+	return result, nil
+}
+
+func (ts *TimeSeries) deleteData(accessFilter func(ar *common.AccessRecord) bool, verifyFilter func(vr *common.VerifyRecord) bool) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.accessRecords = slices.DeleteFunc(ts.accessRecords, accessFilter)
+	ts.verifyRecords = slices.DeleteFunc(ts.verifyRecords, verifyFilter)
+}
+
+func (ts *TimeSeries) DeletePropertiesData(ctx context.Context, propertyIDs []int32) error {
+	if err := ts.call(ctx, "DeletePropertiesData"); err != nil {
+		return err
+	}
+
+	ts.deleteData(func(ar *common.AccessRecord) bool { return slices.Contains(propertyIDs, ar.PropertyID) },
+		func(vr *common.VerifyRecord) bool { return slices.Contains(propertyIDs, vr.PropertyID) })
+
+	return nil
+}
+
+func (ts *TimeSeries) DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error {
+	if err := ts.call(ctx, "DeleteOrganizationsData"); err != nil {
+		return err
+	}
+
+	ts.deleteData(func(ar *common.AccessRecord) bool { return slices.Contains(orgIDs, ar.OrgID) },
+		func(vr *common.VerifyRecord) bool { return slices.Contains(orgIDs, vr.OrgID) })
+
+	return nil
+}
+
+func (ts *TimeSeries) DeleteUsersData(ctx context.Context, userIDs []int32) error {
+	if err := ts.call(ctx, "DeleteUsersData"); err != nil {
+		return err
+	}
+
+	ts.deleteData(func(ar *common.AccessRecord) bool { return slices.Contains(userIDs, ar.UserID) },
+		func(vr *common.VerifyRecord) bool { return slices.Contains(userIDs, vr.UserID) })
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func TestTimeSeriesPropertyStats(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	ts := NewTimeSeries()
+	ts.Now = func() time.Time { return tnow }
+
+	ctx := context.TODO()
+	_ = ts.WriteAccessLogBatch(ctx, []*common.AccessRecord{
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: tnow.Add(-10 * time.Minute)},
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: tnow.Add(-20 * time.Minute), Datacenter: true},
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: tnow.Add(-2 * time.Hour)},
+		// outside of the period
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: tnow.Add(-48 * time.Hour)},
+		// other property
+		{UserID: 1, OrgID: 2, PropertyID: 4, Timestamp: tnow},
+	})
+	_ = ts.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: tnow.Add(-5 * time.Minute)},
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: tnow.Add(-5 * time.Minute), Status: 3, Action: "login"},
+	})
+
+	stats, err := ts.RetrievePropertyStats(ctx, 2, 3, common.TimePeriodToday, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("Unexpected number of buckets: %v", len(stats))
+	}
+
+	if st := stats[1]; !st.Timestamp.Equal(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)) || (st.RequestsCount != 2) ||
+		(st.VerifiesCount != 2) || (st.DatacenterCount != 1) {
+		t.Errorf("Unexpected last bucket: %+v", st)
+	}
+
+	failures, err := ts.RetrievePropertyFailures(ctx, 2, 3, common.TimePeriodWeek, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(failures) != 1) || (failures[0].Status != 3) || (failures[0].Count != 1) {
+		t.Errorf("Unexpected failures: %v", failures)
+	}
+
+	actions, err := ts.RetrievePropertyActions(ctx, 2, 3, tnow.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(actions) != 1) || (actions[0].Action != "login") || (actions[0].Count != 1) || (actions[0].SuccessCount != 0) {
+		t.Errorf("Unexpected actions: %v", actions)
+	}
+
+	if err := ts.DeletePropertiesData(ctx, []int32{3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := ts.AccessRecords(); len(records) != 1 {
+		t.Errorf("Unexpected access records after delete: %v", len(records))
+	}
+}
+
+func TestTimeSeriesUsageCounters(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	ts := NewTimeSeries()
+
+	ctx := context.TODO()
+	_ = ts.WriteAccessLogBatch(ctx, []*common.AccessRecord{
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: day.Add(time.Hour)},
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: day.Add(2 * time.Hour)},
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: day.Add(-time.Hour)},
+	})
+	_ = ts.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: day.Add(time.Hour)},
+		{UserID: 1, OrgID: 2, PropertyID: 3, Timestamp: day.Add(time.Hour), Status: 1},
+	})
+
+	counters, err := ts.ReadDailyUsageCounters(ctx, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(counters) != 1) || (counters[0].Requests != 2) || (counters[0].VerifySuccess != 1) || (counters[0].VerifyFailure != 1) {
+		t.Errorf("Unexpected counters: %+v", counters[0])
+	}
+
+	usage, err := ts.ReadOrgsMonthlyUsage(ctx, []int32{2}, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usage[2] != 3 {
+		t.Errorf("Unexpected monthly usage: %v", usage[2])
+	}
+}
+
+func TestTimeSeriesFaults(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTimeSeries()
+	ctx := context.TODO()
+
+	ts.SetError("Ping", ErrInjected)
+	if err := ts.Ping(ctx); !errors.Is(err, ErrInjected) {
+		t.Errorf("Unexpected ping error: %v", err)
+	}
+
+	if err := ts.WriteAccessLogBatch(ctx, []*common.AccessRecord{{}}); err != nil {
+		t.Errorf("Unexpected write error: %v", err)
+	}
+
+	ts.SetError(AnyMethod, ErrInjected)
+	if err := ts.WriteAccessLogBatch(ctx, []*common.AccessRecord{{}}); !errors.Is(err, ErrInjected) {
+		t.Errorf("Unexpected write error: %v", err)
+	}
+
+	if records := ts.AccessRecords(); len(records) != 1 {
+		t.Errorf("Failed write was stored: %v", len(records))
+	}
+
+	ts.SetError(AnyMethod, nil)
+	ts.SetError("Ping", nil)
+	ts.SetLatency(1 * time.Second)
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if err := ts.Ping(cctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error with latency: %v", err)
+	}
+}
+
+func TestPlanServiceCancel(t *testing.T) {
+	t.Parallel()
+
+	svc := NewPlanService(nil)
+	ctx := context.TODO()
+
+	if err := svc.CancelSubscription(ctx, "sub_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.SetError("CancelSubscription", ErrInjected)
+	if err := svc.CancelSubscription(ctx, "sub_2"); !errors.Is(err, ErrInjected) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if cancelled := svc.Cancelled(); (len(cancelled) != 1) || (cancelled[0] != "sub_1") {
+		t.Errorf("Unexpected cancelled subscriptions: %v", cancelled)
+	}
+
+	if plan := svc.GetInternalTrialPlan(); plan == nil {
+		t.Error("Trial plan is not found")
+	}
+}
@@ -1,24 +1,164 @@
 package widget
 
 import (
+	"crypto/sha512"
 	"embed"
+	"encoding/base64"
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
+const (
+	// Version has to be incremented every time widget assets change, because versioned assets are cached "forever"
+	Version = 1
+	// ScriptPath is the path of the main widget script inside of the (versioned) assets directory
+	ScriptPath = "js/privatecaptcha.js"
+	// path prefix (before the version) that widget assets are served under on CDN
+	basePath = "/widget/"
+	// release channels: new (potentially breaking) versions land in beta first and are promoted to stable later
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+	// pinned "channel" always references the exact current version (with SRI)
+	ChannelPinned = "pinned"
+	// StableVersion and BetaVersion are versions that release channels currently point to. Only assets of the current
+	// Version are embedded so promoting a release means changing these alongside Version
+	StableVersion = Version
+	BetaVersion   = Version
+)
+
+var (
+	VersionPrefix = "v" + strconv.Itoa(Version)
+	// VersionedPath is the path under which assets of the current version are served
+	VersionedPath    = basePath + VersionPrefix + "/"
+	immutableHeaders = map[string][]string{
+		http.CanonicalHeaderKey("Cache-Control"): []string{"public, max-age=31536000, immutable"},
+		// required for Subresource Integrity checks from other origins
+		http.CanonicalHeaderKey("Access-Control-Allow-Origin"): []string{"*"},
+	}
+	deprecationHeaders = map[string][]string{
+		http.CanonicalHeaderKey("Deprecation"): []string{"true"},
+	}
+	integrityOnce sync.Once
+	integrity     map[string]string
+	// Channels maps release channels to versions that they serve
+	Channels = map[string]int{
+		ChannelStable: StableVersion,
+		ChannelBeta:   BetaVersion,
+	}
+	// Themes and Locales are supported by the widget (see styles.css and strings.js)
+	Themes  = []string{"light", "dark"}
+	Locales = []string{"en"}
+)
+
 //go:embed static
 var staticFiles embed.FS
 
-func Static() http.HandlerFunc {
+func staticFS() fs.FS {
 	sub, _ := fs.Sub(staticFiles, "static")
-	srv := http.FileServer(http.FS(sub))
+	return sub
+}
+
+func computeIntegrity(fsys fs.FS) map[string]string {
+	result := make(map[string]string)
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		sum := sha512.Sum384(data)
+		result[path] = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to compute integrity of widget assets", common.ErrAttr(err))
+	}
+
+	return result
+}
+
+// Integrity returns Subresource Integrity value for the asset (e.g. "js/privatecaptcha.js") or empty string
+// if such asset does not exist
+func Integrity(path string) string {
+	integrityOnce.Do(func() {
+		integrity = computeIntegrity(staticFS())
+	})
+
+	return integrity[path]
+}
+
+// ScriptURL returns path to the current version of widget script (without the leading slash)
+func ScriptURL() string {
+	return VersionedPath[1:] + ScriptPath
+}
+
+// ChannelPath returns the path under which assets of the release channel are served
+func ChannelPath(channel string) string {
+	return basePath + channel + "/"
+}
+
+// ChannelScriptURL returns path to the widget script (without the leading slash) for the release channel and its
+// Subresource Integrity value. Channel paths change contents on promotion so integrity is only returned for pinned
+func ChannelScriptURL(channel string) (string, string) {
+	if _, ok := Channels[channel]; ok {
+		return ChannelPath(channel)[1:] + ScriptPath, ""
+	}
+
+	return ScriptURL(), Integrity(ScriptPath)
+}
+
+// Static serves assets under the legacy unversioned path. They are cached for a shorter time and the response
+// points to the versioned path of the same asset
+func Static() http.HandlerFunc {
+	srv := http.FileServer(http.FS(staticFS()))
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		slog.DebugContext(r.Context(), "Static request", "path", r.URL.Path)
 		common.WriteHeaders(w, common.CachedHeaders)
+		common.WriteHeaders(w, deprecationHeaders)
+		w.Header().Set("Link", "<"+VersionedPath+r.URL.Path+">; rel=\"successor-version\"")
+		srv.ServeHTTP(w, r)
+	}
+}
+
+// VersionedStatic serves assets of the current version with immutable cache headers
+func VersionedStatic() http.HandlerFunc {
+	srv := http.FileServer(http.FS(staticFS()))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		slog.DebugContext(r.Context(), "Versioned static request", "path", r.URL.Path, "version", Version)
+		common.WriteHeaders(w, immutableHeaders)
+		srv.ServeHTTP(w, r)
+	}
+}
+
+// ChannelStatic serves assets of the version that release channel points to. Unlike versioned assets, they are cached
+// for a shorter time (so that promotion reaches clients) and the response points to the versioned path
+func ChannelStatic(channel string) http.HandlerFunc {
+	srv := http.FileServer(http.FS(staticFS()))
+	versionedPath := basePath + "v" + strconv.Itoa(Channels[channel]) + "/"
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		slog.DebugContext(r.Context(), "Channel static request", "path", r.URL.Path, "channel", channel)
+		common.WriteHeaders(w, common.CachedHeaders)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Link", "<"+versionedPath+r.URL.Path+">; rel=\"canonical\"")
 		srv.ServeHTTP(w, r)
 	}
 }
@@ -0,0 +1,109 @@
+package widget
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// widget build replaces the contents of static directory so any asset is used
+func testAssetPath(t *testing.T) string {
+	matches, err := fs.Glob(staticFS(), "js/*.js")
+	if (err != nil) || (len(matches) == 0) {
+		t.Fatalf("Failed to find widget assets: %v", err)
+	}
+
+	return matches[0]
+}
+
+func serveAsset(handler http.Handler, prefix, path string) *http.Response {
+	srv := http.NewServeMux()
+	srv.Handle(prefix, http.StripPrefix(prefix, handler))
+
+	req := httptest.NewRequest(http.MethodGet, prefix+path, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func TestVersionedStatic(t *testing.T) {
+	asset := testAssetPath(t)
+
+	resp := serveAsset(VersionedStatic(), VersionedPath, asset)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code: %v", resp.StatusCode)
+	}
+
+	if cc := resp.Header.Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+		t.Errorf("Unexpected cache control: %v", cc)
+	}
+
+	if dep := resp.Header.Get("Deprecation"); len(dep) > 0 {
+		t.Errorf("Versioned asset is deprecated")
+	}
+}
+
+func TestUnversionedStaticIsDeprecated(t *testing.T) {
+	asset := testAssetPath(t)
+
+	resp := serveAsset(Static(), basePath, asset)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code: %v", resp.StatusCode)
+	}
+
+	if dep := resp.Header.Get("Deprecation"); dep != "true" {
+		t.Errorf("Unexpected deprecation header: %v", dep)
+	}
+
+	if link := resp.Header.Get("Link"); !strings.Contains(link, VersionedPath+asset) {
+		t.Errorf("Unexpected link header: %v", link)
+	}
+}
+
+func TestIntegrity(t *testing.T) {
+	asset := testAssetPath(t)
+
+	value := Integrity(asset)
+	if !strings.HasPrefix(value, "sha384-") {
+		t.Errorf("Unexpected integrity value: %v", value)
+	}
+
+	if Integrity("js/missing.js") != "" {
+		t.Error("Integrity of missing asset is not empty")
+	}
+}
+
+func TestChannelStatic(t *testing.T) {
+	asset := testAssetPath(t)
+
+	for channel := range Channels {
+		resp := serveAsset(ChannelStatic(channel), ChannelPath(channel), asset)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status code for channel %v: %v", channel, resp.StatusCode)
+		}
+
+		if cc := resp.Header.Get("Cache-Control"); strings.Contains(cc, "immutable") {
+			t.Errorf("Channel %v asset is immutable", channel)
+		}
+
+		if link := resp.Header.Get("Link"); !strings.Contains(link, asset) {
+			t.Errorf("Unexpected link header for channel %v: %v", channel, link)
+		}
+	}
+}
+
+func TestChannelScriptURL(t *testing.T) {
+	if url, sri := ChannelScriptURL(ChannelBeta); (url != "widget/beta/"+ScriptPath) || (sri != "") {
+		t.Errorf("Unexpected beta script: %v (%v)", url, sri)
+	}
+
+	if url, sri := ChannelScriptURL(ChannelPinned); (url != ScriptURL()) || (sri != Integrity(ScriptPath)) {
+		t.Errorf("Unexpected pinned script: %v (%v)", url, sri)
+	}
+}
@@ -42,6 +42,19 @@ function label(text, forElement) {
     return `<label for="${forElement}">${text}</label>`;
 }
 
+function escapeHTML(value) {
+    return value.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;').replace(/'/g, '&#39;');
+}
+
+// custom message is configured per property and returned by /status endpoint
+function customErrorDescription(message, link) {
+    let text = escapeHTML(message);
+    if (link && /^https?:\/\//i.test(link)) {
+        text = `<a href="${escapeHTML(link)}" target="_blank" rel="noopener noreferrer">${text}</a>`;
+    }
+    return text;
+}
+
 function errorDescription(code, strings) {
     switch (code) {
         case errors.ERROR_NO_ERROR:
@@ -63,6 +76,8 @@ export class CaptchaElement extends HTMLElement {
         this._root = this.attachShadow({ mode: 'open' });
         this._debug = this.getAttribute('debug');
         this._error = null;
+        this._customMessage = null;
+        this._customLink = null;
         this._displayMode = this.getAttribute('display-mode');
         this._lang = this.getAttribute('lang');
         if (!(this._lang in i18n.STRINGS)) {
@@ -137,7 +152,7 @@ export class CaptchaElement extends HTMLElement {
         }
 
         if (this._debug || this._error) {
-            const debugText = this._error ? errorDescription(this._error, strings) : `[${state}]`;
+            const debugText = this._error ? this.errorText(strings) : `[${state}]`;
             activeArea += `<span id="${DEBUG_ID}" class="${this._error ? DEBUG_ERROR_CLASS : ''}">${debugText}</span>`;
         }
 
@@ -207,6 +222,31 @@ export class CaptchaElement extends HTMLElement {
 
     setError(value) {
         this._error = value;
+        if (!value) {
+            this._customMessage = null;
+            this._customLink = null;
+        }
+    }
+
+    // setLang changes localization (only before user interacted with the widget)
+    setLang(lang) {
+        if (!(lang in i18n.STRINGS) || (this._state != STATE_EMPTY)) { return; }
+        this._lang = lang;
+        const canShow = (this._displayMode == DISPLAY_WIDGET);
+        this._state = '';
+        this.setState(STATE_EMPTY, canShow);
+    }
+
+    setCustomMessage(message, link) {
+        this._customMessage = message;
+        this._customLink = link;
+    }
+
+    errorText(strings) {
+        if (this._customMessage) {
+            return customErrorDescription(this._customMessage, this._customLink);
+        }
+        return errorDescription(this._error, strings);
     }
 
     setDebugText(text, error) {
@@ -215,7 +255,7 @@ export class CaptchaElement extends HTMLElement {
             let debugText = '';
             if (this._error) {
                 const strings = i18n.STRINGS[this._lang];
-                debugText = errorDescription(this._error, strings);
+                debugText = this.errorText(strings);
             } else {
                 debugText = `[${text}]`;
             }
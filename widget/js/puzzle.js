@@ -6,10 +6,15 @@ const PUZZLE_BUFFER_LENGTH = 128;
 // RequestTimeout, Conflict, TooManyRequests
 const ACCEPTABLE_CLIENT_ERRORS = [408, 409, 429];
 
-export async function getPuzzle(endpoint, sitekey) {
+// withAction appends optional integrator-defined action that is signed into the puzzle and returned from /verify
+function withAction(url, action) {
+    return action ? `${url}&action=${encodeURIComponent(action)}` : url;
+}
+
+export async function getPuzzle(endpoint, sitekey, action) {
     try {
-        const response = await fetchWithBackoff(`${endpoint}?sitekey=${sitekey}`,
-            { headers: [["x-pc-captcha-version", "1"]], mode: "cors" },
+        const response = await fetchWithBackoff(withAction(`${endpoint}?sitekey=${sitekey}`, action),
+            { headers: [["x-pc-captcha-version", "2"]], mode: "cors" },
             3 /*max attempts*/
         );
 
@@ -30,6 +35,48 @@ export async function getPuzzle(endpoint, sitekey) {
     throw Error('Internal error');
 };
 
+// getConfig returns signed bootstrap config of the property (or null)
+export async function getConfig(endpoint, sitekey) {
+    try {
+        const response = await fetch(`${endpoint}?sitekey=${sitekey}`, { mode: "cors" });
+        if (!response.ok) { return null; }
+        const data = await response.json();
+        if (data && data.config) {
+            return data.config;
+        }
+    } catch (err) {
+        console.warn('[privatecaptcha] failed to fetch config', err);
+    }
+
+    return null;
+}
+
+// getStatus returns custom message of the property when it cannot serve puzzles (or null)
+export async function getStatus(endpoint, sitekey) {
+    try {
+        const response = await fetch(`${endpoint}?sitekey=${sitekey}`, { mode: "cors" });
+        if (!response.ok) { return null; }
+        const status = await response.json();
+        if (status && status.message) {
+            return { message: status.message, link: status.redirect_url };
+        }
+    } catch (err) {
+        console.warn('[privatecaptcha] failed to fetch status', err);
+    }
+
+    return null;
+}
+
+// getAccessibleChallenge returns an image with the code (and the signed challenge) for visitors who cannot solve the puzzle
+export async function getAccessibleChallenge(endpoint, sitekey, action) {
+    const response = await fetchWithBackoff(withAction(`${endpoint}?sitekey=${sitekey}`, action), { mode: "cors" }, 2 /*max attempts*/);
+    if (!response.ok) {
+        throw Error(`failed to fetch accessible challenge. status=${response.status}`);
+    }
+
+    return await response.json();
+}
+
 function wait(delay) {
     return new Promise((resolve) => setTimeout(resolve, delay));
 }
@@ -1,6 +1,16 @@
 'use strict';
 
 import * as blake2bModule from './blake2-wrapper.js';
+import { argon2id } from 'hash-wasm';
+
+const PUZZLE_VERSION_ARGON2ID = 2;
+// parameters must match the server (pkg/puzzle/hash.go)
+const ARGON2_SALT = new TextEncoder().encode('PrivateCaptcha/argon2id');
+const ARGON2_ITERATIONS = 1;
+const ARGON2_MEMORY_KIB = 64;
+const ARGON2_PARALLELISM = 1;
+const ARGON2_HASH_LENGTH = 32;
+
 let blake2b = blake2bModule.impl;
 let blake2bInitialized = false;
 let puzzleBuffer = null;
@@ -73,6 +83,41 @@ function findSolution(threshold, puzzleIndex, debug) {
     return new Uint8Array(0);
 }
 
+async function findArgon2Solution(threshold, puzzleIndex, debug) {
+    const length = puzzleBuffer.length;
+    if (debug) {
+        console.debug(`[privatecaptcha][worker] looking for argon2id solution. threshold=${threshold} puzzleID=${puzzleIndex} length=${length}`);
+    }
+    puzzleBuffer[length - 8] = puzzleIndex;
+
+    for (let n = 0; n <= 0xffffffff; n++) {
+        puzzleBuffer[length - 1 - 3] = (n >>> 24) & 0xff;
+        puzzleBuffer[length - 1 - 2] = (n >>> 16) & 0xff;
+        puzzleBuffer[length - 1 - 1] = (n >>> 8) & 0xff;
+        puzzleBuffer[length - 1 - 0] = n & 0xff;
+
+        const hash = await argon2id({
+            password: puzzleBuffer,
+            salt: ARGON2_SALT,
+            iterations: ARGON2_ITERATIONS,
+            memorySize: ARGON2_MEMORY_KIB,
+            parallelism: ARGON2_PARALLELISM,
+            hashLength: ARGON2_HASH_LENGTH,
+            outputType: 'binary',
+        });
+        const prefix = readUInt32LE(hash, 0);
+
+        if (prefix <= threshold) {
+            if (debug) {
+                console.debug(`[privatecaptcha][worker] found argon2id solution. prefix=${prefix} threshold=${threshold}`);
+            }
+            return puzzleBuffer.slice(length - 8);
+        }
+    }
+
+    return new Uint8Array(0);
+}
+
 self.onmessage = (event) => {
     const { command, argument } = event.data;
 
@@ -91,8 +136,15 @@ self.onmessage = (event) => {
         case "solve":
             const { difficulty, puzzleIndex, debug } = argument;
             const threshold = thresholdFromDifficulty(difficulty);
-            const solution = findSolution(threshold, puzzleIndex, debug);
-            self.postMessage({ command: command, argument: { id: puzzleID, solution: solution, wasm: useWasm } });
+            // first byte of the puzzle is the version, which defines the hash function
+            if (puzzleBuffer[0] === PUZZLE_VERSION_ARGON2ID) {
+                findArgon2Solution(threshold, puzzleIndex, debug).then((solution) => {
+                    self.postMessage({ command: command, argument: { id: puzzleID, solution: solution, wasm: true } });
+                });
+            } else {
+                const solution = findSolution(threshold, puzzleIndex, debug);
+                self.postMessage({ command: command, argument: { id: puzzleID, solution: solution, wasm: useWasm } });
+            }
             break;
         default:
             break;
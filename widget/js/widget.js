@@ -1,6 +1,6 @@
 'use strict';
 
-import { getPuzzle, Puzzle } from './puzzle.js'
+import { getPuzzle, getStatus, getConfig, getAccessibleChallenge, Puzzle } from './puzzle.js'
 import { WorkersPool } from './workerspool.js'
 import { CaptchaElement, STATE_EMPTY, STATE_ERROR, STATE_READY, STATE_IN_PROGRESS, STATE_VERIFIED, STATE_LOADING, STATE_INVALID, DISPLAY_POPUP, DISPLAY_WIDGET } from './html.js';
 import * as errors from './errors.js';
@@ -8,6 +8,32 @@ import * as errors from './errors.js';
 window.customElements.define('private-captcha', CaptchaElement);
 
 const PUZZLE_ENDPOINT_URL = 'https://api.privatecaptcha.com/puzzle';
+const STATUS_ENDPOINT_URL = 'https://api.privatecaptcha.com/status';
+const CONFIG_ENDPOINT_URL = 'https://api.privatecaptcha.com/config';
+const ACCESSIBLE_ENDPOINT_URL = 'https://api.privatecaptcha.com/accessible';
+const ACCESSIBLE_PAYLOAD_PREFIX = 'accessible:';
+const ACCESSIBLE_ANSWER_SEPARATOR = ':';
+
+function statusEndpointFromPuzzle(puzzleEndpoint) {
+    if (puzzleEndpoint && puzzleEndpoint.endsWith('/puzzle')) {
+        return puzzleEndpoint.slice(0, -'puzzle'.length) + 'status';
+    }
+    return STATUS_ENDPOINT_URL;
+}
+
+function configEndpointFromPuzzle(puzzleEndpoint) {
+    if (puzzleEndpoint && puzzleEndpoint.endsWith('/puzzle')) {
+        return puzzleEndpoint.slice(0, -'puzzle'.length) + 'config';
+    }
+    return CONFIG_ENDPOINT_URL;
+}
+
+function accessibleEndpointFromPuzzle(puzzleEndpoint) {
+    if (puzzleEndpoint && puzzleEndpoint.endsWith('/puzzle')) {
+        return puzzleEndpoint.slice(0, -'puzzle'.length) + 'accessible';
+    }
+    return ACCESSIBLE_ENDPOINT_URL;
+}
 
 
 function findParentFormElement(element) {
@@ -21,6 +47,7 @@ export class CaptchaWidget {
     constructor(element, options = {}) {
         this._element = element;
         this._puzzle = null;
+        this._accessibleAnswer = null;
         this._expiryTimeout = null;
         this._state = STATE_EMPTY;
         this._lastProgress = null;
@@ -60,7 +87,9 @@ export class CaptchaWidget {
                 }
             }
 
-            this.checkConfigured();
+            if (this.checkConfigured()) {
+                this.applyConfig();
+            }
         } else {
             console.warn('[privatecaptcha] cannot find form element');
         }
@@ -72,7 +101,11 @@ export class CaptchaWidget {
             debug: this._element.dataset["debug"],
             fieldName: this._element.dataset["solutionField"] || "private-captcha-solution",
             puzzleEndpoint: this._element.dataset["puzzleEndpoint"] || PUZZLE_ENDPOINT_URL,
+            statusEndpoint: this._element.dataset["statusEndpoint"] || statusEndpointFromPuzzle(this._element.dataset["puzzleEndpoint"]),
+            configEndpoint: this._element.dataset["configEndpoint"] || configEndpointFromPuzzle(this._element.dataset["puzzleEndpoint"]),
+            accessibleEndpoint: this._element.dataset["accessibleEndpoint"] || accessibleEndpointFromPuzzle(this._element.dataset["puzzleEndpoint"]),
             sitekey: this._element.dataset["sitekey"] || "",
+            action: this._element.dataset["action"] || "",
             displayMode: this._element.dataset["displayMode"] || "widget",
             lang: this._element.dataset["lang"] || "en",
             theme: this._element.dataset["theme"] || "light",
@@ -87,6 +120,7 @@ export class CaptchaWidget {
 
         this._puzzle = null;
         this._solution = null;
+        this._accessibleAnswer = null;
         this._errorCode = errors.ERROR_NO_ERROR;
 
         const sitekey = this.checkConfigured();
@@ -106,7 +140,7 @@ export class CaptchaWidget {
         try {
             this.setState(STATE_LOADING);
             this.trace('fetching puzzle');
-            const puzzleData = await getPuzzle(this._options.puzzleEndpoint, sitekey);
+            const puzzleData = await getPuzzle(this._options.puzzleEndpoint, sitekey, this._options.action);
             this._puzzle = new Puzzle(puzzleData);
             if (this._puzzle && this._puzzle.isZero()) { this._errorCode = errors.ERROR_ZERO_PUZZLE; }
             const expirationMillis = this._puzzle.expirationMillis();
@@ -125,6 +159,7 @@ export class CaptchaWidget {
             if (this._userStarted) {
                 this.signalErrored();
             }
+            this.showStatusMessage(sitekey);
         }
     }
 
@@ -250,6 +285,53 @@ export class CaptchaWidget {
         return this._solution;
     }
 
+    // switches to the alternative challenge (for visitors who cannot complete the puzzle in reasonable time, e.g. on
+    // low-end devices) and returns data URL of the image with the code that has to be passed to answerAccessible()
+    async accessible() {
+        const sitekey = this.checkConfigured();
+        if (!sitekey) { return null; }
+
+        this.trace('fetching accessible challenge');
+
+        if (this._expiryTimeout) { clearTimeout(this._expiryTimeout); }
+        if (this._workersPool) { this._workersPool.stop(); }
+
+        try {
+            const challenge = await getAccessibleChallenge(this._options.accessibleEndpoint, sitekey, this._options.action);
+            // accessible challenge also has a puzzle (of reduced difficulty) that is solved after the code is entered
+            this._puzzle = new Puzzle(challenge.challenge);
+            this._solution = null;
+            this._accessibleAnswer = null;
+            this._userStarted = false;
+            this.ensureNoSolutionField();
+            const expirationMillis = this._puzzle.expirationMillis();
+            if (expirationMillis) { this._expiryTimeout = setTimeout(() => this.expire(), expirationMillis); }
+            this.setState(STATE_LOADING);
+            this._workersPool.init(this._puzzle, false /*start*/);
+            return challenge.image;
+        } catch (e) {
+            console.error('[privatecaptcha]', e);
+            return null;
+        }
+    }
+
+    answerAccessible(answer) {
+        if (!this._puzzle || (this._state === STATE_IN_PROGRESS) || (this._state === STATE_VERIFIED)) {
+            console.warn(`[privatecaptcha] accessible challenge was not requested. state=${this._state}`);
+            return;
+        }
+
+        this._accessibleAnswer = btoa(String.fromCodePoint(...new TextEncoder().encode(answer)));
+        this.trace('saved accessible answer');
+
+        this._userStarted = true;
+        this.setProgressState(STATE_IN_PROGRESS);
+        // otherwise solving will start as soon as workers are ready
+        if (this._state === STATE_READY) {
+            this.start();
+        }
+    }
+
     onFocusIn(event) {
         this.trace('onFocusIn event handler');
         const pcElement = this._element.querySelector('private-captcha');
@@ -359,7 +441,10 @@ export class CaptchaWidget {
 
     saveSolutions() {
         const solutions = this._workersPool.serializeSolutions(this._errorCode);
-        const payload = `${solutions}.${this._puzzle.rawData}`;
+        let payload = `${solutions}.${this._puzzle.rawData}`;
+        if (this._accessibleAnswer) {
+            payload = `${ACCESSIBLE_PAYLOAD_PREFIX}${this._accessibleAnswer}${ACCESSIBLE_ANSWER_SEPARATOR}${payload}`;
+        }
 
         this.ensureNoSolutionField();
         this._element.insertAdjacentHTML('beforeend', `<input name="${this._options.fieldName}" type="hidden" value="${payload}">`);
@@ -369,6 +454,46 @@ export class CaptchaWidget {
         this.trace(`saved solutions. payload=${payload}`);
     }
 
+    // applies theme and language configured for the property, unless they are set explicitly on the element
+    async applyConfig() {
+        const explicitTheme = this._element.dataset["theme"];
+        const explicitLang = this._element.dataset["lang"];
+        if (explicitTheme && explicitLang) { return; }
+
+        const sitekey = this._options.sitekey || this._element.dataset["sitekey"];
+        if (!sitekey) { return; }
+
+        const config = await getConfig(this._options.configEndpoint, sitekey);
+        if (!config) { return; }
+
+        this.trace(`received config. theme=${config.theme} locale=${config.locale}`);
+        const pcElement = this._element.querySelector('private-captcha');
+        if (!pcElement) { return; }
+
+        if (!explicitTheme && config.theme) {
+            this._options.theme = config.theme;
+            pcElement.setAttribute('theme', config.theme);
+        }
+
+        if (!explicitLang && config.locale) {
+            this._options.lang = config.locale;
+            pcElement.setLang(config.locale);
+        }
+    }
+
+    // shows property's custom message (if configured) when puzzle cannot be fetched
+    async showStatusMessage(sitekey) {
+        const status = await getStatus(this._options.statusEndpoint, sitekey);
+        if (!status) { return; }
+
+        this.trace(`received custom status message. link=${status.link}`);
+        const pcElement = this._element.querySelector('private-captcha');
+        if (pcElement && (STATE_ERROR == this._state)) {
+            pcElement.setCustomMessage(status.message, status.link);
+            pcElement.setDebugText(this._state, true);
+        }
+    }
+
     // this updates the "UI" state of the widget
     setProgressState(state) {
         // NOTE: hidden display mode is taken care of inside setState() even when (_userStarted == true)